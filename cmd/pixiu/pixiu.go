@@ -56,7 +56,7 @@ var (
 	logConfigPath string
 	logLevel      string
 
-	// CURRENTLY USELESS
+	// logFormat console or json
 	logFormat string
 
 	limitCpus string
@@ -131,6 +131,10 @@ func initLog() error {
 		logger.SetLoggerLevel(flagToLogLevel[constant.DefaultLogLevel])
 		return fmt.Errorf("logLevel is invalid, set log level to default: %s", constant.DefaultLogLevel)
 	}
+
+	if logFormat != "" && !logger.SetLoggerFormat(logFormat) {
+		return fmt.Errorf("logFormat %s is invalid, keep the format of log config", logFormat)
+	}
 	return nil
 }
 
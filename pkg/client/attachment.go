@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+)
+
+type attachmentsKey struct{}
+
+// WithAttachment return the context carrying the attachment, it is sent to the rpc upstream like dubbo
+func WithAttachment(ctx context.Context, key string, value interface{}) context.Context {
+	old := Attachments(ctx)
+	attachments := make(map[string]interface{}, len(old)+1)
+	for k, v := range old {
+		attachments[k] = v
+	}
+	attachments[key] = value
+	return context.WithValue(ctx, attachmentsKey{}, attachments)
+}
+
+// Attachments get the attachments of the context, the returned map should not be modified
+func Attachments(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	attachments, _ := ctx.Value(attachmentsKey{}).(map[string]interface{})
+	return attachments
+}
@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// ConcurrencyStats the concurrency limiting statistics of the invocations to an upstream cluster
+	ConcurrencyStats struct {
+		// Client the kind of client, like dubbo
+		Client string
+		// Cluster the upstream cluster
+		Cluster string
+		// Active the invocations in flight
+		Active int64
+		// Available the invocations allowed at once, always 0 for the unlimited cluster
+		Available int64
+		// WaitTime the accumulated time of the invocations waiting for the limit
+		WaitTime time.Duration
+		// Limited the count of the invocations waiting beyond the threshold, including the ones timed out
+		Limited int64
+	}
+
+	// ConcurrencyStatsProvider the client exposing its concurrency limiting statistics
+	ConcurrencyStatsProvider interface {
+		ConcurrencyStats() []ConcurrencyStats
+	}
+)
+
+var concurrencyStatsProviders sync.Map
+
+// RegisterConcurrencyStatsProvider register the concurrency statistics of the client, the later one replaces
+// the former of the same name
+func RegisterConcurrencyStatsProvider(name string, p ConcurrencyStatsProvider) {
+	concurrencyStatsProviders.Store(name, p)
+}
+
+// CollectConcurrencyStats collect the concurrency statistics of all registered clients, sorted by client and cluster
+func CollectConcurrencyStats() []ConcurrencyStats {
+	var stats []ConcurrencyStats
+	concurrencyStatsProviders.Range(func(_, v interface{}) bool {
+		stats = append(stats, v.(ConcurrencyStatsProvider).ConcurrencyStats()...)
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Client != stats[j].Client {
+			return stats[i].Client < stats[j].Client
+		}
+		return stats[i].Cluster < stats[j].Cluster
+	})
+	return stats
+}
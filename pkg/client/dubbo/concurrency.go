@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+)
+
+const (
+	concurrencyStatsName = "dubbo"
+
+	defaultLimitedThreshold = 50 * time.Millisecond
+)
+
+// ErrConcurrencyLimited the request deadline expires while waiting for the concurrency limit of the cluster
+var ErrConcurrencyLimited = errors.New("dubbo concurrency limit reached")
+
+type (
+	// ConcurrencyConfig limit the concurrent invocations of each upstream cluster, the connections are
+	// managed by the dubbo-go protocol itself
+	ConcurrencyConfig struct {
+		// MaxActive the max concurrent invocations per cluster, 0 means unlimited
+		MaxActive int `yaml:"max_active" json:"max_active" mapstructure:"max_active"`
+		// LimitedThreshold the wait time beyond it the invocation is counted as limited, default 50ms
+		LimitedThreshold string `yaml:"limited_threshold" json:"limited_threshold" mapstructure:"limited_threshold"`
+	}
+
+	// concurrencyLimiter the invocation semaphore of each upstream cluster, the waiting request gives up at its deadline
+	concurrencyLimiter struct {
+		maxActive int
+		threshold time.Duration
+
+		mu       sync.Mutex
+		clusters map[string]*clusterLimit
+	}
+
+	clusterLimit struct {
+		// slots nil when the cluster is unlimited
+		slots     chan struct{}
+		active    int64
+		waitNanos int64
+		limited   int64
+	}
+)
+
+func newConcurrencyLimiter(cfg *ConcurrencyConfig) (*concurrencyLimiter, error) {
+	l := &concurrencyLimiter{threshold: defaultLimitedThreshold, clusters: make(map[string]*clusterLimit)}
+	if cfg == nil {
+		return l, nil
+	}
+	if cfg.MaxActive < 0 {
+		return nil, errors.Errorf("invalid concurrency max active %d", cfg.MaxActive)
+	}
+	l.maxActive = cfg.MaxActive
+	if cfg.LimitedThreshold != "" {
+		d, err := time.ParseDuration(cfg.LimitedThreshold)
+		if err != nil {
+			return nil, errors.Wrap(err, "limited threshold parse fail")
+		}
+		l.threshold = d
+	}
+	return l, nil
+}
+
+func (l *concurrencyLimiter) cluster(cluster string) *clusterLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cl, ok := l.clusters[cluster]
+	if !ok {
+		cl = &clusterLimit{}
+		if l.maxActive > 0 {
+			cl.slots = make(chan struct{}, l.maxActive)
+		}
+		l.clusters[cluster] = cl
+	}
+	return cl
+}
+
+// acquire take a slot of the cluster, the returned func must be called to release it
+func (l *concurrencyLimiter) acquire(ctx context.Context, cluster string) (func(), error) {
+	cl := l.cluster(cluster)
+	if cl.slots != nil {
+		select {
+		case cl.slots <- struct{}{}:
+		default:
+			start := time.Now()
+			select {
+			case cl.slots <- struct{}{}:
+				wait := time.Since(start)
+				atomic.AddInt64(&cl.waitNanos, int64(wait))
+				if wait > l.threshold {
+					atomic.AddInt64(&cl.limited, 1)
+				}
+			case <-ctx.Done():
+				atomic.AddInt64(&cl.waitNanos, int64(time.Since(start)))
+				atomic.AddInt64(&cl.limited, 1)
+				return nil, errors.Wrapf(ErrConcurrencyLimited, "cluster %s: %v", cluster, ctx.Err())
+			}
+		}
+	}
+
+	atomic.AddInt64(&cl.active, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt64(&cl.active, -1)
+			if cl.slots != nil {
+				<-cl.slots
+			}
+		})
+	}, nil
+}
+
+// ConcurrencyStats implements client.ConcurrencyStatsProvider
+func (l *concurrencyLimiter) ConcurrencyStats() []client.ConcurrencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := make([]client.ConcurrencyStats, 0, len(l.clusters))
+	for cluster, cl := range l.clusters {
+		s := client.ConcurrencyStats{
+			Client:   concurrencyStatsName,
+			Cluster:  cluster,
+			Active:   atomic.LoadInt64(&cl.active),
+			WaitTime: time.Duration(atomic.LoadInt64(&cl.waitNanos)),
+			Limited:  atomic.LoadInt64(&cl.limited),
+		}
+		if cl.slots != nil {
+			s.Available = int64(cap(cl.slots) - len(cl.slots))
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
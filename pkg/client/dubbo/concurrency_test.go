@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+)
+
+func TestConcurrencyLimited(t *testing.T) {
+	limiter, err := newConcurrencyLimiter(&ConcurrencyConfig{MaxActive: 1, LimitedThreshold: "10ms"})
+	assert.Nil(t, err)
+	client.RegisterConcurrencyStatsProvider(concurrencyStatsName, limiter)
+
+	release, err := limiter.acquire(context.Background(), "StudentProvider")
+	assert.Nil(t, err)
+	stats := client.CollectConcurrencyStats()
+	assert.Equal(t, []client.ConcurrencyStats{{Client: concurrencyStatsName, Cluster: "StudentProvider", Active: 1}}, stats)
+
+	// wait beyond the threshold until the slot is released
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		release()
+	}()
+	release2, err := limiter.acquire(context.Background(), "StudentProvider")
+	assert.Nil(t, err)
+	stats = client.CollectConcurrencyStats()
+	assert.Equal(t, int64(1), stats[0].Limited)
+	assert.True(t, stats[0].WaitTime >= 20*time.Millisecond, stats[0].WaitTime)
+
+	// give up at the deadline of the request
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx, "StudentProvider")
+	assert.True(t, errors.Is(err, ErrConcurrencyLimited))
+	assert.Equal(t, int64(2), limiter.ConcurrencyStats()[0].Limited)
+
+	release2()
+	release2()
+	stats = limiter.ConcurrencyStats()
+	assert.Equal(t, int64(0), stats[0].Active)
+	assert.Equal(t, int64(1), stats[0].Available)
+}
+
+func TestConcurrencyUnlimited(t *testing.T) {
+	limiter, err := newConcurrencyLimiter(nil)
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := limiter.acquire(context.Background(), "UserProvider")
+		assert.Nil(t, err)
+	}
+	stats := limiter.ConcurrencyStats()
+	assert.Equal(t, int64(3), stats[0].Active)
+	assert.Equal(t, int64(0), stats[0].Limited)
+
+	_, err = newConcurrencyLimiter(&ConcurrencyConfig{LimitedThreshold: "soon"})
+	assert.Error(t, err)
+	_, err = newConcurrencyLimiter(&ConcurrencyConfig{MaxActive: -1})
+	assert.Error(t, err)
+}
@@ -31,4 +31,13 @@ type DubboProxyConfig struct {
 	IsDefaultMap bool
 	// AutoResolve whether to resolve api config from request
 	AutoResolve bool `yaml:"auto_resolve" json:"auto_resolve,omitempty"`
+	// Pojos the json body binding of the java pojo parameters
+	Pojos []*PojoConfig `yaml:"pojos" json:"pojos,omitempty"`
+	// Concurrency the concurrency limit of the invocations to each upstream cluster
+	Concurrency *ConcurrencyConfig `yaml:"concurrency" json:"concurrency,omitempty"`
+	// MappingLimit the depth and size limit of the json body mapped into the arguments
+	MappingLimit *MappingLimit `yaml:"mapping_limit" json:"mapping_limit,omitempty"`
+	// MethodTimeouts the timeouts keyed by interface.method, e.g. com.dubbogo.pixiu.UserService.GetStudentTimeout: 500ms,
+	// they override the route timeout, which is used for the methods not configured
+	MethodTimeouts map[string]string `yaml:"method_timeouts" json:"method_timeouts,omitempty"`
 }
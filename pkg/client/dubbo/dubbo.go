@@ -92,6 +92,9 @@ type Client struct {
 	GenericServicePool map[string]*generic.GenericService
 	dubboProxyConfig   *DubboProxyConfig
 	rootConfig         *dg.RootConfig
+	limiter            *concurrencyLimiter
+	// methodTimeouts the parsed timeouts keyed by interface.method
+	methodTimeouts map[string]time.Duration
 }
 
 // SingletonDubboClient singleton dubbo clent
@@ -116,9 +119,11 @@ func InitDefaultDubboClient(dpc *DubboProxyConfig) {
 
 // NewDubboClient create dubbo client
 func NewDubboClient() *Client {
+	limiter, _ := newConcurrencyLimiter(nil)
 	return &Client{
 		lock:               sync.RWMutex{},
 		GenericServicePool: make(map[string]*generic.GenericService, 4),
+		limiter:            limiter,
 	}
 }
 
@@ -129,6 +134,23 @@ func (dc *Client) SetConfig(dpc *DubboProxyConfig) {
 
 // Apply init dubbo, config mapping can do here
 func (dc *Client) Apply() error {
+	for _, p := range dc.dubboProxyConfig.Pojos {
+		if err := RegisterPojo(p); err != nil {
+			return err
+		}
+	}
+	SetMappingLimit(dc.dubboProxyConfig.MappingLimit)
+	methodTimeouts, err := parseMethodTimeouts(dc.dubboProxyConfig.MethodTimeouts)
+	if err != nil {
+		return err
+	}
+	dc.methodTimeouts = methodTimeouts
+	limiter, err := newConcurrencyLimiter(dc.dubboProxyConfig.Concurrency)
+	if err != nil {
+		return err
+	}
+	dc.limiter = limiter
+	client.RegisterConcurrencyStatsProvider(concurrencyStatsName, limiter)
 
 	rootConfigBuilder := dg.NewRootConfigBuilder()
 	for k, v := range dc.dubboProxyConfig.Registries {
@@ -207,6 +229,26 @@ func (dc *Client) Call(req *client.Request) (res interface{}, err error) {
 	span.SetAttributes(attribute.Key(spanTagValues).String(string(finalValues)))
 	defer span.End()
 	ctx := context.WithValue(req.Context, constant.TracingRemoteSpanCtx, trace.SpanFromContext(req.Context).SpanContext())
+	attachments := client.Attachments(req.Context)
+	if meta := client.GetInvocationMeta(req.Context); meta != nil {
+		_, invocationID, _ := meta.Snapshot()
+		attachments = client.Attachments(client.WithAttachment(req.Context, invocationIDAttachment, invocationID))
+		start := time.Now()
+		defer func() { meta.SetElapsed(time.Since(start)) }()
+	}
+	if len(attachments) > 0 {
+		ctx = context.WithValue(ctx, constant.AttachmentKey, attachments)
+	}
+	if timeout := dc.invokeTimeout(req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	release, err := dc.limiter.acquire(ctx, limitKey(&dm))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	rst, err := gs.Invoke(ctx, method, types, vals)
 	if err != nil {
 		return nil, err
@@ -286,6 +328,14 @@ func apiKey(ir *fc.IntegrationRequest) string {
 	return strings.Join([]string{dbc.ClusterName, dbc.ApplicationName, dbc.Interface, dbc.Version, dbc.Group}, "_")
 }
 
+// limitKey the upstream cluster of the request, the interface is used when the cluster is not configured
+func limitKey(ir *fc.IntegrationRequest) string {
+	if ir.DubboBackendConfig.ClusterName != "" {
+		return ir.DubboBackendConfig.ClusterName
+	}
+	return ir.Interface
+}
+
 func (dc *Client) create(key string, irequest fc.IntegrationRequest) *generic.GenericService {
 	useNacosRegister := false
 	registerIds := make([]string, 0)
@@ -304,6 +354,8 @@ func (dc *Client) create(key string, irequest fc.IntegrationRequest) *generic.Ge
 		Generic:       "true",
 		Version:       irequest.DubboBackendConfig.Version,
 		Group:         irequest.Group,
+		Filter:        invocationFilterName,
+		Methods:       dc.methodConfigs(irequest.Interface),
 	}
 
 	if len(irequest.DubboBackendConfig.Retries) == 0 {
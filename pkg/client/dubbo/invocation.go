@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common/extension"
+	"dubbo.apache.org/dubbo-go/v3/filter"
+	"dubbo.apache.org/dubbo-go/v3/protocol"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+)
+
+const (
+	// invocationFilterName the dubbo-go filter recording the provider of the invocation
+	invocationFilterName = "pixiu_invocation"
+	// invocationIDAttachment the attachment carrying the invocation id to the provider
+	invocationIDAttachment = "pixiu-invocation-id"
+)
+
+func init() {
+	extension.SetFilter(invocationFilterName, func() filter.Filter {
+		return &invocationFilter{}
+	})
+}
+
+// invocationFilter record the provider address picked by the cluster into the InvocationMeta of context
+type invocationFilter struct{}
+
+func (f *invocationFilter) Invoke(ctx context.Context, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	if meta := client.GetInvocationMeta(ctx); meta != nil && invoker.GetURL() != nil {
+		meta.SetProvider(invoker.GetURL().Location)
+	}
+	return invoker.Invoke(ctx, invocation)
+}
+
+func (f *invocationFilter) OnResponse(ctx context.Context, result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+)
+
+const (
+	defaultMaxDepth    = 32
+	defaultMaxBodySize = 4 << 20
+)
+
+var (
+	mappingLimitLock sync.RWMutex
+	mappingLimit     = MappingLimit{MaxDepth: defaultMaxDepth, MaxBodySize: defaultMaxBodySize}
+)
+
+// MappingLimit guard the json body mapped into the dubbo arguments, the pathological body
+// is rejected before it is decoded
+type MappingLimit struct {
+	// MaxDepth the max nesting depth of objects and arrays, 32 by default
+	MaxDepth int `yaml:"max_depth" json:"max_depth,omitempty"`
+	// MaxBodySize the max bytes of the body, 4MB by default
+	MaxBodySize int `yaml:"max_body_size" json:"max_body_size,omitempty"`
+}
+
+// SetMappingLimit replace the mapping limit, the unset ones are defaulted
+func SetMappingLimit(l *MappingLimit) {
+	limit := MappingLimit{MaxDepth: defaultMaxDepth, MaxBodySize: defaultMaxBodySize}
+	if l != nil {
+		if l.MaxDepth > 0 {
+			limit.MaxDepth = l.MaxDepth
+		}
+		if l.MaxBodySize > 0 {
+			limit.MaxBodySize = l.MaxBodySize
+		}
+	}
+	mappingLimitLock.Lock()
+	defer mappingLimitLock.Unlock()
+	mappingLimit = limit
+}
+
+func currentMappingLimit() MappingLimit {
+	mappingLimitLock.RLock()
+	defer mappingLimitLock.RUnlock()
+	return mappingLimit
+}
+
+// readMappingBody read the body through the size limit, so the oversized body is never read into memory.
+// The read bytes are returned with the error too, so that the body can be restored
+func readMappingBody(body io.Reader) ([]byte, error) {
+	limit := currentMappingLimit()
+	read, err := ioutil.ReadAll(io.LimitReader(body, int64(limit.MaxBodySize)+1))
+	if err != nil {
+		return read, err
+	}
+	if len(read) > limit.MaxBodySize {
+		return read, client.NewParamError("request body size exceeds the limit %d", limit.MaxBodySize)
+	}
+	return read, nil
+}
+
+// checkMappingLimit check the size and depth of the json body by scanning the bytes,
+// nothing is allocated for the body however deep it is
+func checkMappingLimit(body []byte) error {
+	limit := currentMappingLimit()
+	if len(body) > limit.MaxBodySize {
+		return client.NewParamError("request body size %d exceeds the limit %d", len(body), limit.MaxBodySize)
+	}
+	depth := 0
+	inString, escaped := false, false
+	for _, b := range body {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > limit.MaxDepth {
+				return client.NewParamError("request body nesting depth exceeds the limit %d", limit.MaxDepth)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
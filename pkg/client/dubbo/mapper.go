@@ -20,6 +20,7 @@ package dubbo
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"reflect"
@@ -42,6 +43,9 @@ import (
 	"github.com/apache/dubbo-go-pixiu/pkg/router"
 )
 
+// defaultTypeSep separate the map type and the default value of the optional param
+const defaultTypeSep = ",default="
+
 var mappers = map[string]client.ParamMapper{
 	constant.QueryStrings: queryStringsMapper{},
 	constant.Headers:      headerMapper{},
@@ -99,10 +103,14 @@ func (qm queryStringsMapper) Map(mp config.MappingParam, c *client.Request, targ
 	}
 	qValue := queryValues.Get(key[0])
 	if len(qValue) == 0 {
-		return errors.Errorf("Query parameter %s does not exist", key)
+		def, ok := defaultValue(mp.MapType)
+		if !ok {
+			return client.NewParamError("Query parameter %s does not exist", key)
+		}
+		qValue = def
 	}
 
-	return setTargetWithOpt(c, option, t, pos, qValue, mp.MapType)
+	return setTargetWithOpt(c, option, t, pos, qValue, mp)
 }
 
 type headerMapper struct{}
@@ -120,10 +128,14 @@ func (hm headerMapper) Map(mp config.MappingParam, c *client.Request, target int
 	}
 	header := c.IngressRequest.Header.Get(key[0])
 	if len(header) == 0 {
-		return errors.Errorf("Header %s not found", key[0])
+		def, ok := defaultValue(mp.MapType)
+		if !ok {
+			return client.NewParamError("Header %s not found", key[0])
+		}
+		header = def
 	}
 
-	return setTargetWithOpt(c, option, rv, pos, header, mp.MapType)
+	return setTargetWithOpt(c, option, rv, pos, header, mp)
 }
 
 type bodyMapper struct{}
@@ -144,18 +156,28 @@ func (bm bodyMapper) Map(mp config.MappingParam, c *client.Request, target inter
 		return errors.Errorf("Parameter mapping %v incorrect, parameters for Dubbo backend must be mapped to an int to represent position", mp)
 	}
 
-	rawBody, err := ioutil.ReadAll(c.IngressRequest.Body)
+	body := c.IngressRequest.Body
+	rawBody, err := readMappingBody(body)
 	defer func() {
-		c.IngressRequest.Body = ioutil.NopCloser(bytes.NewReader(rawBody))
+		// the unread rest of the oversized body is kept for the later filters
+		c.IngressRequest.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(rawBody), body))
 	}()
 	if err != nil {
 		return err
 	}
+	if err := checkMappingLimit(rawBody); err != nil {
+		return err
+	}
 	mapBody := map[string]interface{}{}
 	json.Unmarshal(rawBody, &mapBody)
 	val, err := client.GetMapValue(mapBody, keys)
+	if err != nil {
+		if def, ok := defaultValue(mp.MapType); ok {
+			val = def
+		}
+	}
 
-	if err := setTargetWithOpt(c, option, rv, pos, val, mp.MapType); err != nil {
+	if err := setTargetWithOpt(c, option, rv, pos, val, mp); err != nil {
 		return errors.Wrap(err, "set target fail")
 	}
 
@@ -180,8 +202,14 @@ func (um uriMapper) Map(mp config.MappingParam, c *client.Request, target interf
 		return errors.Errorf("Parameter mapping %v incorrect", mp)
 	}
 	uriValues := router.GetURIParams(&c.API, *c.IngressRequest.URL)
+	uValue := uriValues.Get(keys[0])
+	if len(uValue) == 0 {
+		if def, ok := defaultValue(mp.MapType); ok {
+			uValue = def
+		}
+	}
 
-	return setTargetWithOpt(c, option, rv, pos, uriValues.Get(keys[0]), mp.MapType)
+	return setTargetWithOpt(c, option, rv, pos, uValue, mp)
 }
 
 // validateTarget verify if the incoming target for the Map function
@@ -195,18 +223,48 @@ func validateTarget(target interface{}) (*dubboTarget, error) {
 }
 
 func setTargetWithOpt(req *client.Request, option client.RequestOption,
-	target *dubboTarget, pos int, value interface{}, targetType string) error {
+	target *dubboTarget, pos int, value interface{}, mp config.MappingParam) error {
+	targetType := trimDefault(mp.MapType)
 	if option != nil {
 		return setGenericTarget(req, option, target, value, targetType)
 	}
-	value, err := mapTypes(targetType, value)
+	mapped, err := mapTypes(targetType, value)
 	if err != nil {
+		if knownType(targetType) {
+			// the value from client can not be converted, it is not the fault of gateway
+			return client.NewParamError("Parameter %s is malformed: %s", mp.Name, err)
+		}
 		return err
 	}
-	setCommonTarget(target, pos, value, targetType)
+	setCommonTarget(target, pos, mapped, targetType)
 	return nil
 }
 
+// defaultValue return the default value declared in map type, e.g. "int32,default=18"
+func defaultValue(mapType string) (string, bool) {
+	i := strings.Index(mapType, defaultTypeSep)
+	if i < 0 {
+		return "", false
+	}
+	return mapType[i+len(defaultTypeSep):], true
+}
+
+// trimDefault return the map type without the default value
+func trimDefault(mapType string) string {
+	if i := strings.Index(mapType, defaultTypeSep); i >= 0 {
+		return strings.TrimSpace(mapType[:i])
+	}
+	return mapType
+}
+
+func knownType(jType string) bool {
+	if _, ok := constant.JTypeMapper[jType]; ok {
+		return true
+	}
+	_, ok := lookupPojo(jType)
+	return ok
+}
+
 func setGenericTarget(req *client.Request, option client.RequestOption,
 	target *dubboTarget, value interface{}, targetType string) error {
 	var err error
@@ -237,6 +295,9 @@ func setCommonTarget(target *dubboTarget, pos int, value interface{}, targetType
 func mapTypes(jType string, originVal interface{}) (interface{}, error) {
 	targetType, ok := constant.JTypeMapper[jType]
 	if !ok {
+		if p, ok := lookupPojo(jType); ok {
+			return p.bind(originVal)
+		}
 		return nil, errors.Errorf("Invalid parameter type: %s", jType)
 	}
 	switch targetType {
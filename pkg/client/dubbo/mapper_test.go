@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"context"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -171,6 +172,140 @@ func TestBodyMapper(t *testing.T) {
 	}))
 }
 
+// nestedStudent build the student whose mentor is a student, nested by depth
+func nestedStudent(depth int) string {
+	return strings.Repeat(`{"name": "Joe", "mentor": `, depth-1) + `{"name": "Joe"}` + strings.Repeat("}", depth-1)
+}
+
+func TestBodyMapperLimit(t *testing.T) {
+	defer SetMappingLimit(nil)
+	mp := config.MappingParam{Name: "requestBody.name", MapTo: "0", MapType: "string"}
+	api := mock.GetMockAPI(config.MethodPost, "/mock/student")
+	api.IntegrationRequest.MappingParams = []config.MappingParam{mp}
+
+	tests := []struct {
+		name  string
+		limit *MappingLimit
+		body  string
+		err   bool
+	}{
+		{name: "flat", body: nestedStudent(1)},
+		{name: "under default depth", body: nestedStudent(defaultMaxDepth)},
+		{name: "deeply nested", body: nestedStudent(10000), err: true},
+		{name: "nested array", body: `{"name": "Joe", "courses": ` + strings.Repeat("[", 100) + strings.Repeat("]", 100) + "}", err: true},
+		{name: "brackets in string", limit: &MappingLimit{MaxDepth: 2}, body: `{"name": "Joe", "note": "[[[{{{\"["}`},
+		{name: "over configured depth", limit: &MappingLimit{MaxDepth: 2}, body: nestedStudent(3), err: true},
+		{name: "over body size", limit: &MappingLimit{MaxBodySize: 16}, body: nestedStudent(2), err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMappingLimit(tt.limit)
+			r, _ := http.NewRequest("POST", "/mock/student", strings.NewReader(tt.body))
+			target := newDubboTarget(api.IntegrationRequest.MappingParams)
+			req := client.NewReq(context.TODO(), r, api)
+
+			err := bodyMapper{}.Map(mp, req, target, nil)
+			if tt.err {
+				assert.True(t, client.IsParamError(err), err)
+				// rejected before any argument is constructed
+				assert.Nil(t, target.Values[0])
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "Joe", target.Values[0])
+		})
+	}
+}
+
+func TestGenericTargetTrimDefault(t *testing.T) {
+	mp := config.MappingParam{Name: "requestBody.name", MapTo: "opt.values", MapType: "string,default=Joe"}
+	api := mock.GetMockAPI(config.MethodPost, "/mock/student")
+	r, _ := http.NewRequest("POST", "/mock/student", nil)
+	target := &dubboTarget{}
+
+	// the default value is not taken as one more generic type
+	err := setTargetWithOpt(client.NewReq(context.TODO(), r, api), &valuesOpt{}, target, 0, "Joe", mp)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"string"}, target.Types)
+	assert.Equal(t, []interface{}{"Joe"}, target.Values)
+}
+
+// countingReader count the bytes read from the endless body
+type countingReader struct {
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	r.read += len(p)
+	return len(p), nil
+}
+
+func TestBodyMapperReadLimit(t *testing.T) {
+	defer SetMappingLimit(nil)
+	SetMappingLimit(&MappingLimit{MaxBodySize: 16})
+	mp := config.MappingParam{Name: "requestBody.name", MapTo: "0", MapType: "string"}
+	api := mock.GetMockAPI(config.MethodPost, "/mock/student")
+	api.IntegrationRequest.MappingParams = []config.MappingParam{mp}
+
+	body := &countingReader{}
+	r, _ := http.NewRequest("POST", "/mock/student", body)
+	target := newDubboTarget(api.IntegrationRequest.MappingParams)
+	err := bodyMapper{}.Map(mp, client.NewReq(context.TODO(), r, api), target, nil)
+	assert.True(t, client.IsParamError(err), err)
+	// the oversized body is not read beyond the limit
+	assert.Equal(t, 17, body.read)
+}
+
+func TestMultiParamsMapper(t *testing.T) {
+	api := mock.GetMockAPI(config.MethodGet, "/mock/test")
+	api.IntegrationRequest.MappingParams = []config.MappingParam{
+		{
+			Name:    "queryStrings.name",
+			MapTo:   "0",
+			MapType: "string",
+		},
+		{
+			Name:    "queryStrings.age",
+			MapTo:   "1",
+			MapType: "int32,default=18",
+		},
+		{
+			Name:    "headers.Code",
+			MapTo:   "2",
+			MapType: "int64",
+		},
+	}
+	dc := NewDubboClient()
+
+	r, _ := http.NewRequest("GET", "/mock/test?name=joe&age=20", nil)
+	r.Header.Set("Code", "1001")
+	target, err := dc.MapParams(client.NewReq(context.TODO(), r, api))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"joe", int32(20), int64(1001)}, target.(*dubboTarget).Values)
+	assert.Equal(t, []string{"string", "int32", "int64"}, target.(*dubboTarget).Types)
+
+	// the optional param is absent, use the default value
+	r, _ = http.NewRequest("GET", "/mock/test?name=joe", nil)
+	r.Header.Set("Code", "1001")
+	target, err = dc.MapParams(client.NewReq(context.TODO(), r, api))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(18), target.(*dubboTarget).Values[1])
+
+	r, _ = http.NewRequest("GET", "/mock/test?name=joe&age=abc", nil)
+	r.Header.Set("Code", "1001")
+	_, err = dc.MapParams(client.NewReq(context.TODO(), r, api))
+	assert.True(t, client.IsParamError(err))
+	assert.EqualError(t, err, "Parameter queryStrings.age is malformed: unable to cast \"abc\" of type string to int32")
+
+	r, _ = http.NewRequest("GET", "/mock/test?name=joe", nil)
+	_, err = dc.MapParams(client.NewReq(context.TODO(), r, api))
+	assert.True(t, client.IsParamError(err))
+	assert.EqualError(t, err, "Header Code not found")
+}
+
 func TestURIMapper(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/mock/12345/joe?age=19", bytes.NewReader([]byte(
 		`{"sex": "male", "name":{"firstName": "Joe", "lastName": "Biden"}}`)))
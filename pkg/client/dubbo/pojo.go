@@ -0,0 +1,132 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+// the key carries the java class name of the pojo in generic invoke
+const pojoClassKey = "class"
+
+var (
+	pojoLock sync.RWMutex
+	pojos    = map[string]*PojoConfig{}
+)
+
+type (
+	// PojoConfig describe how the json body binds to the java pojo parameter of dubbo method,
+	// the java class name can be used as the MapType of the mapping param
+	PojoConfig struct {
+		JavaClassName string       `yaml:"java_class_name" json:"java_class_name" mapstructure:"java_class_name"`
+		Fields        []*PojoField `yaml:"fields" json:"fields" mapstructure:"fields"`
+	}
+
+	// PojoField the field of pojo
+	PojoField struct {
+		// Name the field name of the pojo
+		Name string `yaml:"name" json:"name" mapstructure:"name"`
+		// From the key in json body, default is Name
+		From string `yaml:"from" json:"from,omitempty" mapstructure:"from"`
+		// Type the java type of the field, such as long or java.util.Date
+		Type string `yaml:"type" json:"type" mapstructure:"type"`
+		// Required the request is rejected when the field is absent
+		Required bool `yaml:"required" json:"required,omitempty" mapstructure:"required"`
+		// OmitEmpty the zero value is not sent to the provider
+		OmitEmpty bool `yaml:"omitempty" json:"omitempty,omitempty" mapstructure:"omitempty"`
+	}
+)
+
+// RegisterPojo register the pojo binding, the later one replaces the former with the same class name
+func RegisterPojo(p *PojoConfig) error {
+	if p == nil || p.JavaClassName == "" {
+		return errors.New("pojo java class name is empty")
+	}
+	for _, f := range p.Fields {
+		if f.Name == "" {
+			return errors.Errorf("pojo %s has field without name", p.JavaClassName)
+		}
+		if f.Type == "" {
+			f.Type = "object"
+		}
+	}
+	pojoLock.Lock()
+	defer pojoLock.Unlock()
+	pojos[p.JavaClassName] = p
+	return nil
+}
+
+func lookupPojo(javaClassName string) (*PojoConfig, bool) {
+	pojoLock.RLock()
+	defer pojoLock.RUnlock()
+	p, ok := pojos[javaClassName]
+	return p, ok
+}
+
+// bind convert the json object to the generic map of pojo
+func (p *PojoConfig) bind(originVal interface{}) (map[string]interface{}, error) {
+	body, ok := originVal.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("%s expects json object, but got %T", p.JavaClassName, originVal)
+	}
+	pojo := make(map[string]interface{}, len(p.Fields)+1)
+	pojo[pojoClassKey] = p.JavaClassName
+	for _, f := range p.Fields {
+		key := f.From
+		if key == "" {
+			key = f.Name
+		}
+		raw, ok := body[key]
+		if !ok || raw == nil {
+			if f.Required {
+				return nil, errors.Errorf("required field %s of %s is missing", key, p.JavaClassName)
+			}
+			continue
+		}
+		val, err := mapTypes(f.Type, raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %s of %s", key, p.JavaClassName)
+		}
+		if f.OmitEmpty && isZero(val) {
+			continue
+		}
+		pojo[f.Name] = val
+	}
+	return pojo, nil
+}
+
+func isZero(val interface{}) bool {
+	if t, ok := val.(time.Time); ok {
+		return t.IsZero()
+	}
+	rv := reflect.ValueOf(val)
+	if !rv.IsValid() {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	}
+	return reflect.DeepEqual(val, reflect.Zero(rv.Type()).Interface())
+}
@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/dubbo-go-pixiu-filter/pkg/api/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/mock"
+)
+
+const studentClassName = "com.dubbogo.pixiu.Student"
+
+func registerStudent(t *testing.T) {
+	assert.Nil(t, RegisterPojo(&PojoConfig{
+		JavaClassName: studentClassName,
+		Fields: []*PojoField{
+			{Name: "name", Type: "string", Required: true},
+			{Name: "code", Type: "long", Required: true},
+			{Name: "age", Type: "int", OmitEmpty: true},
+			{Name: "time", From: "createTime", Type: "java.util.Date"},
+		},
+	}))
+}
+
+func TestRegisterPojo(t *testing.T) {
+	assert.Error(t, RegisterPojo(&PojoConfig{}))
+	assert.Error(t, RegisterPojo(&PojoConfig{JavaClassName: "com.Foo", Fields: []*PojoField{{Type: "string"}}}))
+}
+
+func TestBodyMapperPojo(t *testing.T) {
+	registerStudent(t)
+	api := mock.GetMockAPI(config.MethodPost, "/mock/test")
+	api.IntegrationRequest.MappingParams = []config.MappingParam{
+		{
+			Name:    "requestBody._all",
+			MapTo:   "0",
+			MapType: studentClassName,
+		},
+	}
+
+	r, _ := http.NewRequest("POST", "/mock/test", bytes.NewReader([]byte(`{"name":"joe","code":3,"age":0,"createTime":"2021-08-01T10:00:00Z","sex":"male"}`)))
+	req := client.NewReq(context.TODO(), r, api)
+	target := newDubboTarget(api.IntegrationRequest.MappingParams)
+	err := bodyMapper{}.Map(api.IntegrationRequest.MappingParams[0], req, target, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, studentClassName, target.Types[0])
+	assert.Equal(t, map[string]interface{}{
+		"class": studentClassName,
+		"name":  "joe",
+		"code":  int64(3),
+		"time":  time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC),
+	}, target.Values[0])
+
+	// optional field absent, it is not sent as zero value
+	r, _ = http.NewRequest("POST", "/mock/test", bytes.NewReader([]byte(`{"name":"joe","code":3}`)))
+	req = client.NewReq(context.TODO(), r, api)
+	target = newDubboTarget(api.IntegrationRequest.MappingParams)
+	err = bodyMapper{}.Map(api.IntegrationRequest.MappingParams[0], req, target, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"class": studentClassName, "name": "joe", "code": int64(3)}, target.Values[0])
+
+	r, _ = http.NewRequest("POST", "/mock/test", bytes.NewReader([]byte(`{"name":"joe"}`)))
+	req = client.NewReq(context.TODO(), r, api)
+	target = newDubboTarget(api.IntegrationRequest.MappingParams)
+	err = bodyMapper{}.Map(api.IntegrationRequest.MappingParams[0], req, target, nil)
+	assert.EqualError(t, err, "set target fail: Parameter requestBody._all is malformed: required field code of com.dubbogo.pixiu.Student is missing")
+}
+
+func TestMapTypePojo(t *testing.T) {
+	registerStudent(t)
+	_, err := mapTypes(studentClassName, "joe")
+	assert.EqualError(t, err, "com.dubbogo.pixiu.Student expects json object, but got string")
+
+	_, err = mapTypes(studentClassName, map[string]interface{}{"name": "joe", "code": "abc"})
+	assert.Error(t, err)
+}
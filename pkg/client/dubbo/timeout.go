@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+import (
+	dg "dubbo.apache.org/dubbo-go/v3/config"
+
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+)
+
+// parseMethodTimeouts parse the timeouts keyed by interface.method
+func parseMethodTimeouts(conf map[string]string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(conf))
+	for key, v := range conf {
+		if strings.LastIndex(key, ".") <= 0 {
+			return nil, errors.Errorf("method timeout key %s must be interface.method", key)
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "method timeout of %s parse fail", key)
+		}
+		if d <= 0 {
+			return nil, errors.Errorf("invalid method timeout %s of %s", v, key)
+		}
+		timeouts[key] = d
+	}
+	return timeouts, nil
+}
+
+// methodKey the key of method timeouts
+func methodKey(iface, method string) string {
+	return iface + "." + method
+}
+
+// invokeTimeout return the timeout of the invoked method, the route timeout is used when the method
+// is not configured, 0 means no timeout
+func (dc *Client) invokeTimeout(req *client.Request) time.Duration {
+	ir := req.API.Method.IntegrationRequest
+	if d, ok := dc.methodTimeouts[methodKey(ir.Interface, ir.Method)]; ok {
+		return d
+	}
+	return req.API.Timeout
+}
+
+// methodConfigs the method configs of the reference, so that the dubbo invoker applies the method timeouts too
+func (dc *Client) methodConfigs(iface string) []*dg.MethodConfig {
+	var methods []*dg.MethodConfig
+	prefix := iface + "."
+	for key, d := range dc.methodTimeouts {
+		name := strings.TrimPrefix(key, prefix)
+		if name == key || strings.Contains(name, ".") {
+			continue
+		}
+		methods = append(methods, &dg.MethodConfig{Name: name, RequestTimeout: d.String()})
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods
+}
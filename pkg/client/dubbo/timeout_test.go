@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/dubbo-go-pixiu-filter/pkg/api/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/mock"
+)
+
+const userService = "com.dubbogo.pixiu.UserService"
+
+func TestInvokeTimeout(t *testing.T) {
+	timeouts, err := parseMethodTimeouts(map[string]string{
+		userService + ".GetStudentTimeout":   "500ms",
+		"com.dubbogo.pixiu.OtherService.Get": "1s",
+	})
+	assert.Nil(t, err)
+	dClient := NewDubboClient()
+	dClient.methodTimeouts = timeouts
+
+	newReq := func(method string) *client.Request {
+		r, _ := http.NewRequest("GET", "/mock/test", nil)
+		api := mock.GetMockAPI(config.MethodGet, "/mock/test")
+		api.IntegrationRequest.Interface = userService
+		api.IntegrationRequest.Method = method
+		api.Timeout = 3 * time.Second
+		return client.NewReq(context.TODO(), r, api)
+	}
+
+	assert.Equal(t, 500*time.Millisecond, dClient.invokeTimeout(newReq("GetStudentTimeout")))
+	// fall back to the route timeout
+	assert.Equal(t, 3*time.Second, dClient.invokeTimeout(newReq("GetStudentByName")))
+
+	methods := dClient.methodConfigs(userService)
+	assert.Equal(t, 1, len(methods))
+	assert.Equal(t, "GetStudentTimeout", methods[0].Name)
+	assert.Equal(t, "500ms", methods[0].RequestTimeout)
+	assert.Empty(t, dClient.methodConfigs("com.dubbogo.pixiu.StudentService"))
+}
+
+func TestParseMethodTimeoutsInvalid(t *testing.T) {
+	for _, conf := range []map[string]string{
+		{"GetStudentTimeout": "500ms"},
+		{userService + ".GetStudentTimeout": "fast"},
+		{userService + ".GetStudentTimeout": "-1s"},
+	} {
+		_, err := parseMethodTimeouts(conf)
+		assert.Error(t, err)
+	}
+}
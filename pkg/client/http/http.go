@@ -118,7 +118,7 @@ func (dc *Client) Call(req *client.Request) (resp interface{}, err error) {
 	newReq.Header.Set(jaegerTraceIDInHeader, span.SpanContext().TraceID().String())
 	defer span.End()
 
-	tmpRet, err := httpClient.Do(newReq)
+	tmpRet, err := Do(httpClient, newReq)
 	if tmpRet != nil {
 		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(tmpRet.StatusCode))
 	}
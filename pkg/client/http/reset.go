@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+// UpstreamError the failure of upstream call, classified by whether the request was sent
+type UpstreamError struct {
+	// Sent the request was written to the upstream, it may have been processed although the connection is reset
+	Sent bool
+	Err  error
+}
+
+func (e *UpstreamError) Error() string {
+	if e.Sent {
+		return "upstream failed after request sent: " + e.Err.Error()
+	}
+	return "upstream failed before request sent: " + e.Err.Error()
+}
+
+// Unwrap return the error of http client
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// Status the status replied to client, 503 if the request is not sent, 502 if the upstream fails
+// after the request is sent, and 504 on timeout
+func (e *UpstreamError) Status() int {
+	var netErr net.Error
+	if errors.As(e.Err, &netErr) && netErr.Timeout() {
+		return http.StatusGatewayTimeout
+	}
+	if e.Sent {
+		return http.StatusBadGateway
+	}
+	return http.StatusServiceUnavailable
+}
+
+// Retryable whether the request can be sent again safely, the sent one is only retried when the method is idempotent
+func (e *UpstreamError) Retryable(method string) bool {
+	return !e.Sent || IsIdempotent(method)
+}
+
+// IsIdempotent the method is idempotent by RFC 7231 section 4.2.2
+func IsIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// Do send the request by the client, the failure is returned as *UpstreamError
+func Do(cli *http.Client, req *http.Request) (*http.Response, error) {
+	var sent int32
+	trace := &httptrace.ClientTrace{
+		// the transport may retry on another connection, only the last one counts
+		GotConn: func(httptrace.GotConnInfo) {
+			atomic.StoreInt32(&sent, 0)
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				atomic.StoreInt32(&sent, 1)
+			}
+		},
+	}
+	resp, err := cli.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		return nil, &UpstreamError{Sent: atomic.LoadInt32(&sent) == 1, Err: err}
+	}
+	return resp, nil
+}
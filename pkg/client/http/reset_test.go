@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+// resetAfterRead read the request then reset the connection without any response
+func resetAfterRead(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = http.ReadRequest(bufio.NewReader(conn))
+			_ = conn.(*net.TCPConn).SetLinger(0)
+			_ = conn.Close()
+		}
+	}()
+	return l
+}
+
+func TestDoResetBeforeSend(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := l.Addr().String()
+	// nobody listens, the connection is refused before the request is written
+	_ = l.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "http://"+addr+"/mock", strings.NewReader("body"))
+	_, err = Do(&http.Client{}, req)
+	ue, ok := err.(*UpstreamError)
+	assert.True(t, ok)
+	assert.False(t, ue.Sent)
+	assert.Equal(t, http.StatusServiceUnavailable, ue.Status())
+	assert.True(t, ue.Retryable(http.MethodPost))
+}
+
+func TestDoResetAfterSend(t *testing.T) {
+	l := resetAfterRead(t)
+	defer l.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "http://"+l.Addr().String()+"/mock", strings.NewReader("body"))
+	_, err := Do(&http.Client{}, req)
+	ue, ok := err.(*UpstreamError)
+	assert.True(t, ok)
+	assert.True(t, ue.Sent)
+	assert.Equal(t, http.StatusBadGateway, ue.Status())
+	assert.False(t, ue.Retryable(http.MethodPost))
+	assert.True(t, ue.Retryable(http.MethodGet))
+}
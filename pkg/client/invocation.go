@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type invocationMetaKey struct{}
+
+// InvocationMeta the metadata of the rpc invocation, filled by the client when the call is finished
+type InvocationMeta struct {
+	mu sync.Mutex
+	// Provider the address of the provider serving the call, the last one if the call is retried
+	Provider string
+	// InvocationID identify the invocation, it is sent to the provider as attachment
+	InvocationID string
+	// Elapsed the time spent in the call
+	Elapsed time.Duration
+}
+
+// WithInvocationMeta return the context collecting the metadata of the invocation made with it
+func WithInvocationMeta(ctx context.Context) (context.Context, *InvocationMeta) {
+	meta := &InvocationMeta{InvocationID: newInvocationID()}
+	return context.WithValue(ctx, invocationMetaKey{}, meta), meta
+}
+
+// GetInvocationMeta get the metadata collector of the context, nil if the caller does not collect it
+func GetInvocationMeta(ctx context.Context) *InvocationMeta {
+	if ctx == nil {
+		return nil
+	}
+	meta, _ := ctx.Value(invocationMetaKey{}).(*InvocationMeta)
+	return meta
+}
+
+// SetProvider record the provider address
+func (m *InvocationMeta) SetProvider(provider string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Provider = provider
+}
+
+// SetElapsed record the time spent in the call
+func (m *InvocationMeta) SetElapsed(elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Elapsed = elapsed
+}
+
+// Snapshot return the provider, invocation id and elapsed time
+func (m *InvocationMeta) Snapshot() (string, string, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Provider, m.InvocationID, m.Elapsed
+}
+
+func newInvocationID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -18,6 +18,7 @@
 package client
 
 import (
+	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
@@ -39,6 +40,26 @@ type ParamMapper interface {
 	Map(config.MappingParam, *Request, interface{}, RequestOption) error
 }
 
+// ParamError the request parameter is absent or malformed, it is the fault of client
+type ParamError struct {
+	msg string
+}
+
+// NewParamError create ParamError
+func NewParamError(format string, args ...interface{}) error {
+	return errors.WithStack(&ParamError{msg: fmt.Sprintf(format, args...)})
+}
+
+func (e *ParamError) Error() string {
+	return e.msg
+}
+
+// IsParamError check whether the error is caused by request parameter
+func IsParamError(err error) bool {
+	var pe *ParamError
+	return errors.As(err, &pe)
+}
+
 // ParseMapSource parses the source parameter config in the mappingParams
 // the source parameter in config could be queryStrings.*, headers.*, requestBody.*
 func ParseMapSource(source string) (from string, params []string, err error) {
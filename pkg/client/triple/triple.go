@@ -76,20 +76,28 @@ func SingletonTripleClient() *Client {
 	return NewTripleClient()
 }
 
-// Call invoke service
+// Call invoke service, the json body is marshalled into the protobuf request by the server reflection.
+// The invocation is bound to the request context, so that it is cancelled by the timeout of the filter chain.
 func (dc *Client) Call(req *client.Request) (res interface{}, err error) {
-	address := strings.Split(req.API.IntegrationRequest.HTTPBackendConfig.URL, ":")
+	ctx := req.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	address := strings.SplitN(req.API.IntegrationRequest.HTTPBackendConfig.URL, ":", 2)
+	if len(address) != 2 {
+		return "", errors.Errorf("invalid triple server address %s", req.API.IntegrationRequest.HTTPBackendConfig.URL)
+	}
 	p := proxy.NewProxy()
 	targetURL := &url.URL{
 		Scheme: address[0],
 		Opaque: address[1],
 	}
-	if err := p.Connect(context.Background(), targetURL); err != nil {
+	if err := p.Connect(ctx, targetURL); err != nil {
 		return "", errors.Errorf("connect triple server error = %s", err)
 	}
 	meta := make(map[string][]string)
 	reqData, _ := ioutil.ReadAll(req.IngressRequest.Body)
-	call, err := p.Call(context.Background(), req.API.Method.IntegrationRequest.Interface, req.API.Method.IntegrationRequest.Method, reqData, (*proxymeta.Metadata)(&meta))
+	call, err := p.Call(ctx, req.API.Method.IntegrationRequest.Interface, req.API.Method.IntegrationRequest.Method, reqData, (*proxymeta.Metadata)(&meta))
 	if err != nil {
 		return "", errors.Errorf("call triple server error = %s", err)
 	}
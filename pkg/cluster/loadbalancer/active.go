@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadbalancer
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// activeCalls the in-flight calls by cluster and endpoint, reported by the proxies calling the endpoints
+var activeCalls sync.Map
+
+func activeKey(clusterName string, e *model.Endpoint) string {
+	id := e.ID
+	if id == "" {
+		id = e.Address.GetAddress()
+	}
+	return clusterName + "/" + id
+}
+
+// Begin count a call to the endpoint of the cluster until the returned done is called, so that the
+// least connections strategy sees it. done is safe to be called more than once.
+func Begin(clusterName string, e *model.Endpoint) (done func()) {
+	v, _ := activeCalls.LoadOrStore(activeKey(clusterName, e), new(int64))
+	n := v.(*int64)
+	atomic.AddInt64(n, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { atomic.AddInt64(n, -1) })
+	}
+}
+
+// ActiveCalls the in-flight calls to the endpoint of the cluster
+func ActiveCalls(c *model.Cluster, e *model.Endpoint) int64 {
+	v, ok := activeCalls.Load(activeKey(c.Name, e))
+	if !ok {
+		return 0
+	}
+	return atomic.LoadInt64(v.(*int64))
+}
+
+// DoneOnClose end the call when the body is closed, so the call lasts until the response is read,
+// not until the headers are received
+func DoneOnClose(body io.ReadCloser, done func()) io.ReadCloser {
+	return &doneCloser{ReadCloser: body, done: done}
+}
+
+type doneCloser struct {
+	io.ReadCloser
+	done func()
+}
+
+func (c *doneCloser) Close() error {
+	defer c.done()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,141 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package consistenthash
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	// replicas the virtual nodes of each endpoint on the ring
+	replicas = 160
+
+	hashKeyRequestID    = "request_id"
+	hashKeyHeaderPrefix = "header:"
+)
+
+func init() {
+	loadbalancer.RegisterLoadBalancer(model.LoadBalanceConsistentHashing, ConsistentHashing{})
+}
+
+// ConsistentHashing pick the endpoint by the hash of the request attribute configured by the hash_key of
+// the cluster, so the retries of a request stick to the same endpoint, and only the keys of the removed
+// endpoint move when the endpoints change. The request without the key picks randomly.
+type ConsistentHashing struct{}
+
+type (
+	// ring the sorted virtual nodes of the endpoints
+	ring struct {
+		// signature the endpoints the ring is built from, the indexes are valid for the endpoints of the same signature
+		signature string
+		hashes    []uint64
+		indexes   []int
+	}
+
+	point struct {
+		hash  uint64
+		index int
+	}
+)
+
+// rings cache the ring of each cluster, it is rebuilt when the endpoints change
+var rings sync.Map
+
+func (ConsistentHashing) Handler(c *model.Cluster) *model.Endpoint {
+	return c.Endpoints[rand.Intn(len(c.Endpoints))]
+}
+
+func (h ConsistentHashing) HandlerWithHint(c *model.Cluster, hint loadbalancer.Hint) *model.Endpoint {
+	key := hashKey(c, hint)
+	if key == "" {
+		return h.Handler(c)
+	}
+	return c.Endpoints[ringOf(c).pick(loadbalancer.Hash(key))]
+}
+
+// hashKey the request attribute configured by the cluster, empty if the request does not have it
+func hashKey(c *model.Cluster, hint loadbalancer.Hint) string {
+	if name := strings.TrimPrefix(c.HashKey, hashKeyHeaderPrefix); name != c.HashKey {
+		if hint.Header == nil {
+			return ""
+		}
+		return hint.Header(name)
+	}
+	return hint.RequestID
+}
+
+func ringOf(c *model.Cluster) *ring {
+	sig := signature(c.Endpoints)
+	if v, ok := rings.Load(c.Name); ok && v.(*ring).signature == sig {
+		return v.(*ring)
+	}
+	r := newRing(sig, c.Endpoints)
+	rings.Store(c.Name, r)
+	return r
+}
+
+func newRing(sig string, endpoints []*model.Endpoint) *ring {
+	points := make([]point, 0, len(endpoints)*replicas)
+	for index, e := range endpoints {
+		id := endpointID(e)
+		for i := 0; i < replicas; i++ {
+			points = append(points, point{hash: loadbalancer.Hash(id + "#" + strconv.Itoa(i)), index: index})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+	r := &ring{signature: sig, hashes: make([]uint64, len(points)), indexes: make([]int, len(points))}
+	for i, p := range points {
+		r.hashes[i], r.indexes[i] = p.hash, p.index
+	}
+	return r
+}
+
+// pick the endpoint index of the first virtual node clockwise from the hash
+func (r *ring) pick(hash uint64) int {
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= hash })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.indexes[i]
+}
+
+func signature(endpoints []*model.Endpoint) string {
+	ids := make([]string, len(endpoints))
+	for i, e := range endpoints {
+		ids[i] = endpointID(e)
+	}
+	return strings.Join(ids, ",")
+}
+
+// endpointID the address identifies the endpoint on the ring, so the same instance keeps its keys
+// however it is named
+func endpointID(e *model.Endpoint) string {
+	if addr := e.Address.GetAddress(); addr != "" && addr != ":0" {
+		return addr
+	}
+	return e.ID
+}
@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package consistenthash
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func newCluster(name string, n int) *model.Cluster {
+	c := &model.Cluster{Name: name}
+	for i := 0; i < n; i++ {
+		c.Endpoints = append(c.Endpoints, &model.Endpoint{
+			ID:      fmt.Sprintf("%d", i),
+			Address: model.SocketAddress{Address: fmt.Sprintf("10.0.0.%d", i+1), Port: 20000},
+		})
+	}
+	return c
+}
+
+func TestConsistentHashingDistribution(t *testing.T) {
+	c := newCluster("test-ch", 4)
+	picks := make([]string, 10000)
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < len(picks); i += 10 {
+				picks[i] = ConsistentHashing{}.HandlerWithHint(c, loadbalancer.Hint{RequestID: fmt.Sprintf("req-%d", i)}).ID
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	hits := map[string]int{}
+	for i, id := range picks {
+		hits[id]++
+		// the retries of a request stick to the endpoint
+		assert.Equal(t, id, ConsistentHashing{}.HandlerWithHint(c, loadbalancer.Hint{RequestID: fmt.Sprintf("req-%d", i)}).ID)
+	}
+	for _, e := range c.Endpoints {
+		// 2500 each if perfectly balanced
+		assert.True(t, hits[e.ID] > 2000 && hits[e.ID] < 3000, hits)
+	}
+
+	// only the keys of the removed endpoint move
+	c.Endpoints = c.Endpoints[:3]
+	for i, id := range picks {
+		moved := ConsistentHashing{}.HandlerWithHint(c, loadbalancer.Hint{RequestID: fmt.Sprintf("req-%d", i)}).ID
+		if id != "3" {
+			assert.Equal(t, id, moved)
+		}
+	}
+}
+
+func TestConsistentHashingHeader(t *testing.T) {
+	c := newCluster("test-ch-header", 3)
+	c.HashKey = "header:X-User-Id"
+	header := func(id string) func(string) string {
+		return func(name string) string {
+			if name == "X-User-Id" {
+				return id
+			}
+			return ""
+		}
+	}
+	e := ConsistentHashing{}.HandlerWithHint(c, loadbalancer.Hint{RequestID: "req-1", Header: header("user-1")})
+	for i := 0; i < 10; i++ {
+		// the request id is ignored when hashing by header
+		hint := loadbalancer.Hint{RequestID: fmt.Sprintf("req-%d", i), Header: header("user-1")}
+		assert.Equal(t, e, ConsistentHashing{}.HandlerWithHint(c, hint))
+	}
+	// the request without the key still gets an endpoint
+	assert.NotNil(t, ConsistentHashing{}.HandlerWithHint(c, loadbalancer.Hint{}))
+	assert.NotNil(t, loadbalancer.Pick(ConsistentHashing{}, c, loadbalancer.Hint{Header: header("")}))
+}
@@ -0,0 +1,55 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package leastconn
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func init() {
+	loadbalancer.RegisterLoadBalancer(model.LoadBalancerLeastConnections, LeastConnections{})
+}
+
+// LeastConnections pick the endpoint with the least in-flight calls reported by loadbalancer.Begin,
+// the endpoints of the same calls are picked in turn
+type LeastConnections struct{}
+
+// offsets the endpoint each pick starts from, so that the ties are not always broken to the first one
+var offsets sync.Map
+
+func (LeastConnections) Handler(c *model.Cluster) *model.Endpoint {
+	v, _ := offsets.LoadOrStore(c.Name, new(uint64))
+	n := uint64(len(c.Endpoints))
+	start := atomic.AddUint64(v.(*uint64), 1) - 1
+
+	var picked *model.Endpoint
+	var least int64
+	for i := uint64(0); i < n; i++ {
+		e := c.Endpoints[(start+i)%n]
+		if active := loadbalancer.ActiveCalls(c, e); picked == nil || active < least {
+			picked, least = e, active
+		}
+	}
+	return picked
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package leastconn
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func newCluster(name string, n int) *model.Cluster {
+	c := &model.Cluster{Name: name}
+	for i := 0; i < n; i++ {
+		c.Endpoints = append(c.Endpoints, &model.Endpoint{ID: strconv.Itoa(i)})
+	}
+	return c
+}
+
+func TestLeastConnections(t *testing.T) {
+	c := newCluster("test-lc", 3)
+	busy := loadbalancer.Begin(c.Name, c.Endpoints[0])
+	for i := 0; i < 10; i++ {
+		assert.NotEqual(t, "0", LeastConnections{}.Handler(c).ID)
+	}
+	busy()
+	busy()
+	assert.Equal(t, int64(0), loadbalancer.ActiveCalls(c, c.Endpoints[0]))
+
+	// the idle endpoints are picked in turn
+	hits := map[string]int{}
+	for i := 0; i < 30; i++ {
+		hits[LeastConnections{}.Handler(c).ID]++
+	}
+	assert.Equal(t, map[string]int{"0": 10, "1": 10, "2": 10}, hits)
+}
+
+func TestLeastConnectionsConcurrent(t *testing.T) {
+	c := newCluster("test-lc-concurrent", 4)
+	var mu sync.Mutex
+	hits := map[string]int{}
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				e := LeastConnections{}.Handler(c)
+				done := loadbalancer.Begin(c.Name, e)
+				time.Sleep(time.Millisecond)
+				done()
+				mu.Lock()
+				hits[e.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	for i := 0; i < 4; i++ {
+		// 200 calls each if perfectly balanced
+		assert.True(t, hits[strconv.Itoa(i)] > 120 && hits[strconv.Itoa(i)] < 280, hits)
+	}
+}
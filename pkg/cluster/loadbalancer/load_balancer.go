@@ -17,14 +17,42 @@
 
 package loadbalancer
 
+import (
+	"hash/fnv"
+)
+
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
 )
 
+// LoadBalancer the strategy picking an endpoint of the cluster, registered by RegisterLoadBalancer
+// and selected by the lb_policy of the cluster
 type LoadBalancer interface {
 	Handler(c *model.Cluster) *model.Endpoint
 }
 
+// HintLoadBalancer is an optional interface of LoadBalancer picking by the request attributes,
+// like the consistent hashing. Handler is used when the caller has no request.
+type HintLoadBalancer interface {
+	HandlerWithHint(c *model.Cluster, hint Hint) *model.Endpoint
+}
+
+// Hint the request attributes the strategy may pick by
+type Hint struct {
+	// RequestID the id of the request, the retries of a request share it
+	RequestID string
+	// Header return the request header, nil if the caller has no request
+	Header func(name string) string
+}
+
+// Pick the endpoint of the cluster by the strategy, the hint is ignored by the strategy not supporting it
+func Pick(lb LoadBalancer, c *model.Cluster, hint Hint) *model.Endpoint {
+	if h, ok := lb.(HintLoadBalancer); ok {
+		return h.HandlerWithHint(c, hint)
+	}
+	return lb.Handler(c)
+}
+
 // LoadBalancerStrategy load balancer strategy mode
 var LoadBalancerStrategy = map[model.LbPolicyType]LoadBalancer{}
 
@@ -34,3 +62,17 @@ func RegisterLoadBalancer(name model.LbPolicyType, balancer LoadBalancer) {
 	}
 	LoadBalancerStrategy[name] = balancer
 }
+
+// Hash the key to a well distributed value, the strategies and filters picking by the same key agree on it
+func Hash(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	// fnv spreads the similar keys like "10.0.0.1#1" and "10.0.0.1#2" poorly, mix the bits as splitmix64 does
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -17,6 +17,11 @@
 
 package roundrobin
 
+import (
+	"sync"
+	"sync/atomic"
+)
+
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
@@ -28,12 +33,11 @@ func init() {
 
 type RoundRobin struct{}
 
+// counters the next pick of each cluster, the clusters are picked concurrently under the read lock
+var counters sync.Map
+
 func (RoundRobin) Handler(c *model.Cluster) *model.Endpoint {
-	lens := len(c.Endpoints)
-	if c.PrePickEndpointIndex >= lens {
-		c.PrePickEndpointIndex = 0
-	}
-	e := c.Endpoints[c.PrePickEndpointIndex]
-	c.PrePickEndpointIndex = (c.PrePickEndpointIndex + 1) % lens
-	return e
+	v, _ := counters.LoadOrStore(c.Name, new(uint64))
+	n := atomic.AddUint64(v.(*uint64), 1) - 1
+	return c.Endpoints[n%uint64(len(c.Endpoints))]
 }
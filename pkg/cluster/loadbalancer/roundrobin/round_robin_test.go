@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package roundrobin
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestRoundRobinConcurrent(t *testing.T) {
+	c := &model.Cluster{Name: "test-rr"}
+	for i := 0; i < 4; i++ {
+		c.Endpoints = append(c.Endpoints, &model.Endpoint{ID: strconv.Itoa(i)})
+	}
+
+	var mu sync.Mutex
+	hits := map[string]int{}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local := map[string]int{}
+			for i := 0; i < 1000; i++ {
+				local[RoundRobin{}.Handler(c).ID]++
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for id, n := range local {
+				hits[id] += n
+			}
+		}()
+	}
+	wg.Wait()
+	// every endpoint is picked exactly in turn however the picks interleave
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, 2000, hits[strconv.Itoa(i)])
+	}
+}
@@ -18,13 +18,20 @@
 package constant
 
 const (
-	HeaderKeyContextType = "Content-Type"
+	HeaderKeyContextType   = "Content-Type"
+	HeaderKeyContentLength = "Content-Length"
 
 	HeaderKeyAccessControlAllowOrigin      = "Access-Control-Allow-Origin"
 	HeaderKeyAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
 	HeaderKeyAccessControlAllowMethods     = "Access-Control-Allow-Methods"
 	HeaderKeyAccessControlMaxAge           = "Access-Control-Max-Age"
 	HeaderKeyAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	HeaderKeyRequestID                     = "X-Request-Id"
+	HeaderKeyFilterTimings                 = "X-Pixiu-Filter-Timings"
+	HeaderKeyRateLimitLimit                = "X-RateLimit-Limit"
+	HeaderKeyRateLimitRemaining            = "X-RateLimit-Remaining"
+	HeaderKeyRateLimitReset                = "X-RateLimit-Reset"
+	HeaderKeyRetryAfter                    = "Retry-After"
 
 	HeaderValueJsonUtf8  = "application/json;charset=UTF-8"
 	HeaderValueTextPlain = "text/plain"
@@ -35,6 +42,32 @@ const (
 	PathParamIdentifier = ":"
 )
 
+const (
+	// RequestIDContextKey the context key of request id shared among filters
+	RequestIDContextKey = "request_id"
+	// TenantParam the context param and dubbo attachment of the tenant id, set by the tenant filter
+	TenantParam = "tenant_id"
+	// FilterTimingsParam the context param of the filter timings, set by the filter chain when tracing is enabled
+	FilterTimingsParam = "filter_timings"
+	// CircuitOpenParam the context param marking the request is rejected by the open circuit breaker
+	CircuitOpenParam = "circuit_open"
+	// AuthUserParam the context param of the authenticated user or client, set by the basic and apikey auth filters
+	AuthUserParam = "auth_user"
+	// FeatureFlagsParam the context param of the map from the flag name to whether it is enabled, set by the featureflag filter
+	FeatureFlagsParam = "feature_flags"
+	// RetryAfterParam the context param of the waiter queuing the retry of the 503 response by its Retry-After,
+	// set by the retryafter filter and used by the http proxy filter
+	RetryAfterParam = "retry_after"
+)
+
+const (
+	// UpstreamProtocolParam the context param of the protocol speaking to the upstream, set by the protocol filter
+	UpstreamProtocolParam = "upstream_protocol"
+
+	UpstreamProtocolHTTP1 = "http1"
+	UpstreamProtocolH2C   = "h2c"
+)
+
 const (
 	Http1HeaderKeyHost = "Host"
 	Http2HeaderKeyHost = ":authority"
@@ -32,17 +32,55 @@ const (
 	HTTPAccessLogFilter      = "dgp.filter.http.accesslog"
 	HTTPRateLimitFilter      = "dgp.filter.http.ratelimit"
 	HTTPGrpcProxyFilter      = "dgp.filter.http.grpcproxy"
+	HTTPGrpcWebFilter        = "dgp.filter.http.grpcweb"
+	HTTPStubFilter           = "dgp.filter.http.stub"
+	HTTPNonceFilter          = "dgp.filter.http.nonce"
+	HTTPGeoIPFilter          = "dgp.filter.http.geoip"
+	HTTPNegotiateFilter      = "dgp.filter.http.negotiate"
+	HTTPUploadFilter         = "dgp.filter.http.upload"
+	HTTPAggregateFilter      = "dgp.filter.http.aggregate"
+	HTTPWebSocketFilter      = "dgp.filter.http.websocket"
 	HTTPDubboProxyFilter     = "dgp.filter.http.dubboproxy"
 	HTTPApiConfigFilter      = "dgp.filter.http.apiconfig"
 	HTTPTimeoutFilter        = "dgp.filter.http.timeout"
 	TracingFilter            = "dgp.filters.tracing"
 	HTTPCircuitBreakerFilter = "dgp.filter.http.circuitbreaker"
 	HTTPAuthJwtFilter        = "dgp.filter.http.auth.jwt"
+	HTTPAuthBasicFilter      = "dgp.filter.http.auth.basic"
+	HTTPAuthAPIKeyFilter     = "dgp.filter.http.auth.apikey"
+	HTTPAuthMTLSFilter       = "dgp.filter.http.auth.mtls"
+	HTTPAuthWebhookFilter    = "dgp.filter.http.auth.webhook"
+	HTTPAuthSignatureFilter  = "dgp.filter.http.auth.signature"
 	HTTPCorsFilter           = "dgp.filter.http.cors"
 	HTTPCsrfFilter           = "dgp.filter.http.csrf"
 	HTTPProxyRewriteFilter   = "dgp.filter.http.proxyrewrite"
 	HTTPLoadBalanceFilter    = "dgp.filter.http.loadbalance"
 	HTTPEventFilter          = "dgp.filter.http.event"
+	HTTPDelayFilter          = "dgp.filter.http.delay"
+	HTTPRequestIDFilter      = "dgp.filter.http.requestid"
+	HTTPETagFilter           = "dgp.filter.http.etag"
+	HTTPFaultFilter          = "dgp.filter.http.fault"
+	HTTPCanaryFilter         = "dgp.filter.http.canary"
+	HTTPQuotaFilter          = "dgp.filter.http.quota"
+	HTTPJSONCaseFilter       = "dgp.filter.http.jsoncase"
+	HTTPHealthFilter         = "dgp.filter.http.health"
+	HTTPCacheFilter          = "dgp.filter.http.cache"
+	HTTPAdminFilter          = "dgp.filter.http.admin"
+	HTTPProtocolFilter       = "dgp.filter.http.protocol"
+	HTTPMirrorFilter         = "dgp.filter.http.mirror"
+	HTTPTenantFilter         = "dgp.filter.http.tenant"
+	HTTPQueryParamsFilter    = "dgp.filter.http.queryparams"
+	HTTPConcurrencyFilter    = "dgp.filter.http.concurrency"
+	HTTPIdempotencyFilter    = "dgp.filter.http.idempotency"
+	HTTPJSONSchemaFilter     = "dgp.filter.http.jsonschema"
+	HTTPFallbackFilter       = "dgp.filter.http.fallback"
+	HTTPContentTypeFilter    = "dgp.filter.http.contenttype"
+	HTTPRequestLimitFilter   = "dgp.filter.http.requestlimit"
+	HTTPStatusMapFilter      = "dgp.filter.http.statusmap"
+	HTTPFeatureFlagFilter    = "dgp.filter.http.featureflag"
+	HTTPRetryAfterFilter     = "dgp.filter.http.retryafter"
+	HTTPWAFFilter            = "dgp.filter.http.waf"
+	HTTPSlowLogFilter        = "dgp.filter.http.slowlog"
 
 	DubboHttpFilter  = "dgp.filter.dubbo.http"
 	DubboProxyFilter = "dgp.filter.dubbo.proxy"
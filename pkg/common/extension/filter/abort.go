@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"encoding/json"
+	stdHttp "net/http"
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+// AbortResponse the response of the filter short-circuiting the chain
+type AbortResponse = http.AbortResponse
+
+// AbortRender write the abort response to client
+type AbortRender func(ctx *http.HttpContext, resp *AbortResponse)
+
+var (
+	abortRenderMu sync.RWMutex
+	abortRender   AbortRender = defaultAbortRender
+)
+
+// Abort stop the chain with the response, the response is written by the chain with the abort render,
+// usage: return filter.Abort(ctx, &filter.AbortResponse{Status: http.StatusUnauthorized})
+func Abort(ctx *http.HttpContext, resp *AbortResponse) FilterStatus {
+	ctx.SetAbortResponse(resp)
+	return Stop
+}
+
+// SetAbortRender customize how the abort responses of all filters are written, nil restores the default
+func SetAbortRender(render AbortRender) {
+	abortRenderMu.Lock()
+	defer abortRenderMu.Unlock()
+	if render == nil {
+		render = defaultAbortRender
+	}
+	abortRender = render
+}
+
+// renderAbort write the abort response if the stopped filter aborts and nothing is written yet
+func renderAbort(ctx *http.HttpContext) {
+	resp := ctx.GetAbortResponse()
+	if resp == nil || ctx.LocalReply() {
+		return
+	}
+	abortRenderMu.RLock()
+	render := abortRender
+	abortRenderMu.RUnlock()
+	render(ctx, resp)
+}
+
+// defaultAbortRender write the headers and body, the body defaults to the json ErrResponse of the status text
+func defaultAbortRender(ctx *http.HttpContext, resp *AbortResponse) {
+	status := resp.Status
+	if status == 0 {
+		status = stdHttp.StatusInternalServerError
+	}
+	for k, v := range resp.Headers {
+		ctx.Writer.Header().Set(k, v)
+	}
+	body := resp.Body
+	if len(body) == 0 {
+		body, _ = json.Marshal(http.ErrResponse{Message: stdHttp.StatusText(status)})
+		if ctx.Writer.Header().Get(constant.HeaderKeyContextType) == "" {
+			ctx.Writer.Header().Set(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+		}
+	}
+	ctx.SendLocalReply(status, body)
+}
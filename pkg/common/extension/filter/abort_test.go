@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+// abortingFilter abort the chain with the response
+type abortingFilter struct {
+	resp *AbortResponse
+}
+
+func (f *abortingFilter) Decode(ctx *contexthttp.HttpContext) FilterStatus {
+	return Abort(ctx, f.resp)
+}
+
+// unreachableFilter fail the test when the chain is not stopped
+type unreachableFilter struct {
+	t *testing.T
+}
+
+func (f *unreachableFilter) Decode(ctx *contexthttp.HttpContext) FilterStatus {
+	f.t.Error("the chain should be stopped")
+	return Continue
+}
+
+func runAbort(t *testing.T, resp *AbortResponse) *httptest.ResponseRecorder {
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock", nil)
+	assert.NoError(t, err)
+	rec := httptest.NewRecorder()
+	ctx := &contexthttp.HttpContext{Request: request, Writer: rec}
+	ctx.Reset()
+
+	chain := NewDefaultFilterChain()
+	chain.AppendDecodeFilters(&abortingFilter{resp: resp}, &unreachableFilter{t: t})
+	chain.OnDecode(ctx)
+	assert.True(t, ctx.LocalReply())
+	assert.Equal(t, rec.Code, ctx.GetStatusCode())
+	return rec
+}
+
+func TestAbort(t *testing.T) {
+	rec := runAbort(t, &AbortResponse{Status: http.StatusTooManyRequests, Headers: map[string]string{"Retry-After": "1"}})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, `{"message":"Too Many Requests"}`, rec.Body.String())
+	assert.Equal(t, constant.HeaderValueJsonUtf8, rec.Header().Get(constant.HeaderKeyContextType))
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	rec = runAbort(t, &AbortResponse{Status: http.StatusUnauthorized, Body: []byte("denied")})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "denied", rec.Body.String())
+	assert.Equal(t, constant.HeaderValueTextPlain, rec.Header().Get(constant.HeaderKeyContextType))
+}
+
+func TestSetAbortRender(t *testing.T) {
+	SetAbortRender(func(ctx *contexthttp.HttpContext, resp *AbortResponse) {
+		ctx.Writer.Header().Set("X-Pixiu-Abort", "true")
+		ctx.SendLocalReply(resp.Status, []byte(`{"code":"ABORTED"}`))
+	})
+	defer SetAbortRender(nil)
+
+	rec := runAbort(t, &AbortResponse{Status: http.StatusForbidden})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, `{"code":"ABORTED"}`, rec.Body.String())
+	assert.Equal(t, "true", rec.Header().Get("X-Pixiu-Abort"))
+}
@@ -20,7 +20,9 @@ package filter
 import (
 	"context"
 	"fmt"
+	"io"
 	stdHttp "net/http"
+	"sort"
 )
 
 import (
@@ -58,6 +60,46 @@ type (
 		PrepareFilterChain(ctx *http.HttpContext, chain FilterChain) error
 	}
 
+	// HttpFilterValidator is an optional interface of HttpFilterFactory,
+	// it checks the injected config without any side effect, used by FilterManager.DryRun
+	HttpFilterValidator interface {
+		Validate() error
+	}
+
+	// FilterStage the stage a decode filter belongs to, see HttpFilterStager
+	FilterStage int
+
+	// HttpFilterStager is an optional interface of HttpFilterFactory declaring the stage of its filter.
+	// FilterManager moves the auth filters configured after a body consuming filter before it,
+	// so that the unauthenticated requests are rejected before the body is read.
+	HttpFilterStager interface {
+		Stage() FilterStage
+	}
+
+	// OrderHint the filter kinds a filter must run before or after, the absent kinds are ignored
+	OrderHint struct {
+		Before []string
+		After  []string
+	}
+
+	// HttpFilterOrderer is an optional interface of HttpFilterFactory declaring the order constraint of its filter.
+	// FilterManager reorders the configured filters to satisfy the hints, and fails to load the impossible order.
+	HttpFilterOrderer interface {
+		OrderHint() OrderHint
+	}
+
+	// HttpFilterCloser is an optional interface of HttpFilterFactory releasing its resources like connection pools.
+	// FilterManager closes the factory replaced by reload after the in-flight requests using it are finished.
+	HttpFilterCloser interface {
+		Close() error
+	}
+
+	// FilterManagerAware is an optional interface of HttpFilterFactory managing the other filters, like the admin api.
+	// The manager applying the factory is injected after it is applied.
+	FilterManagerAware interface {
+		SetFilterManager(fm *FilterManager)
+	}
+
 	// HttpDecodeFilter before invoke upstream, like add/remove Header, route mutation etc..
 	//
 	// if config like this:
@@ -65,7 +107,7 @@ type (
 	// - B
 	// - C
 	// decode filters will be invoked in the config order: A、B、C, and decode filters will be
-	// invoked in the reverse order: C、B、A
+	// invoked in the reverse order: C、B、A. The auth filters may be moved ahead, see HttpFilterStager
 	HttpDecodeFilter interface {
 		Decode(ctx *http.HttpContext) FilterStatus
 	}
@@ -76,6 +118,23 @@ type (
 		Encode(ctx *http.HttpContext) FilterStatus
 	}
 
+	// HttpStreamEncodeFilter is an optional interface of HttpEncodeFilter supporting the streamed response.
+	// When the upstream response is large or of unknown length and every encode filter of the chain implements it,
+	// the body is piped to client through the filters chunk by chunk instead of buffered in ctx.TargetResp.Data.
+	// The filters needing the whole body, like validating or caching it, opt out by not implementing it,
+	// then the response is buffered and Encode is called as usual.
+	HttpStreamEncodeFilter interface {
+		HttpEncodeFilter
+
+		// EncodeStream is called instead of Encode when streaming, in the reverse order of Encode, so that the
+		// writer returned by a filter writes to the one of the filter after it. The filter can update the status
+		// and headers, and return a writer transforming the body written to w, e.g. compressing it, in which case
+		// the Content-Length header must be removed. It must not write to w before returning.
+		// The writer is closed after the whole body is written, to flush the data it buffers. Stop aborts
+		// the response before anything is written, in which case the writers are not closed.
+		EncodeStream(ctx *http.HttpContext, w io.Writer) (io.WriteCloser, FilterStatus)
+	}
+
 	// NetworkFilter describe network filter plugin
 	NetworkFilterPlugin interface {
 		// Kind returns the unique kind name to represent itself.
@@ -120,6 +179,18 @@ type (
 	}
 )
 
+const (
+	// StageDefault the filter has no order requirement
+	StageDefault FilterStage = iota
+	// StageAuth the filter authenticates or authorizes the request without reading the body
+	StageAuth
+	// StageBody the filter reads or buffers the request body
+	StageBody
+)
+
+// ErrPluginNotFound the plugin of the kind is not registered
+var ErrPluginNotFound = errors.New("plugin not found")
+
 var (
 	httpFilterPluginRegistry    = map[string]HttpFilterPlugin{}
 	networkFilterPluginRegistry = map[string]NetworkFilterPlugin{}
@@ -171,7 +242,17 @@ func GetHttpFilterPlugin(kind string) (HttpFilterPlugin, error) {
 	if existed {
 		return existedFilter, nil
 	}
-	return nil, errors.Errorf("plugin not found %s", kind)
+	return nil, errors.Wrapf(ErrPluginNotFound, "http filter %s", kind)
+}
+
+// ListHttpFilterPlugins return the sorted kinds of all registered http filter plugins
+func ListHttpFilterPlugins() []string {
+	kinds := make([]string, 0, len(httpFilterPluginRegistry))
+	for kind := range httpFilterPluginRegistry {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
 }
 
 // RegisterNetworkFilter registers network filter.
@@ -194,7 +275,7 @@ func GetNetworkFilterPlugin(kind string) (NetworkFilterPlugin, error) {
 	if existed {
 		return existedFilter, nil
 	}
-	return nil, errors.Errorf("plugin not found %s", kind)
+	return nil, errors.Wrapf(ErrPluginNotFound, "network filter %s", kind)
 }
 
 // RegisterDubboFilterPlugin registers dubbo filter.
@@ -217,5 +298,5 @@ func GetDubboFilterPlugin(kind string) (DubboFilterPlugin, error) {
 	if existed {
 		return existedFilter, nil
 	}
-	return nil, errors.Errorf("plugin not found %s", kind)
+	return nil, errors.Wrapf(ErrPluginNotFound, "dubbo filter %s", kind)
 }
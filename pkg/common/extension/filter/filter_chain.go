@@ -17,6 +17,11 @@
 
 package filter
 
+import (
+	"io"
+	"time"
+)
+
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
 )
@@ -30,12 +35,92 @@ type FilterChain interface {
 	OnEncode(ctx *http.HttpContext)
 }
 
+// ChainDeferrer is implemented by the filter chain created by FilterManager, the deferred functions are
+// called in reverse order when the chain is released, whether the request is finished, stopped or panics
+type ChainDeferrer interface {
+	Defer(fn func())
+}
+
+// StreamFilterChain is implemented by the filter chain created by FilterManager, see HttpStreamEncodeFilter
+type StreamFilterChain interface {
+	// Streamable whether every encode filter supports the streamed response
+	Streamable() bool
+	// OnEncodeStream call EncodeStream of the encode filters instead of OnEncode, it returns the writer the upstream
+	// body is written to, which must be closed after the whole body is written, or nil if a filter stops the chain
+	OnEncodeStream(ctx *http.HttpContext, w io.Writer) io.WriteCloser
+}
+
+// NopWriteCloser return the writer with a no-op Close, for the stream filter not transforming the body
+func NopWriteCloser(w io.Writer) io.WriteCloser {
+	return nopWriteCloser{Writer: w}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// CloseFuncWriter return the writer calling fn once it is closed, for the stream filter acting after
+// the whole body is written, e.g. logging the latency
+func CloseFuncWriter(w io.Writer, fn func()) io.WriteCloser {
+	return &closeFuncWriter{Writer: w, fn: fn}
+}
+
+type closeFuncWriter struct {
+	io.Writer
+	fn func()
+}
+
+func (w *closeFuncWriter) Close() error {
+	if w.fn != nil {
+		fn := w.fn
+		w.fn = nil
+		fn()
+	}
+	return nil
+}
+
+// streamWriter the head of the writers returned by the stream filters, it closes them in the order the body flows
+type streamWriter struct {
+	io.Writer
+	writers []io.WriteCloser
+}
+
+func (w *streamWriter) Close() error {
+	var err error
+	for _, wc := range w.writers {
+		if e := wc.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Defer register fn to the chain, it returns false if the chain does not support it
+func Defer(chain FilterChain, fn func()) bool {
+	d, ok := chain.(ChainDeferrer)
+	if ok {
+		d.Defer(fn)
+	}
+	return ok
+}
+
 type defaultFilterChain struct {
 	decodeFilters      []HttpDecodeFilter
 	decodeFiltersIndex int
 
 	encodeFilters      []HttpEncodeFilter
 	encodeFiltersIndex int
+
+	// gen the filter generation the chain is created from, see FilterManager.ReleaseFilterChain
+	gen *filterGeneration
+	// deferred the functions called when the chain is released
+	deferred []func()
+	// timings record the latency of each filter, nil when the tracing is disabled
+	timings *FilterTimings
 }
 
 func NewDefaultFilterChain() FilterChain {
@@ -47,6 +132,18 @@ func NewDefaultFilterChain() FilterChain {
 	}
 }
 
+func (c *defaultFilterChain) Defer(fn func()) {
+	c.deferred = append(c.deferred, fn)
+}
+
+// runDeferred call the deferred functions in reverse order, each of them is called once
+func (c *defaultFilterChain) runDeferred() {
+	for i := len(c.deferred) - 1; i >= 0; i-- {
+		c.deferred[i]()
+	}
+	c.deferred = nil
+}
+
 func (c *defaultFilterChain) AppendDecodeFilters(f ...HttpDecodeFilter) {
 	c.decodeFilters = append(c.decodeFilters, f...)
 }
@@ -60,12 +157,13 @@ func (c *defaultFilterChain) AppendEncodeFilters(f ...HttpEncodeFilter) {
 
 func (c *defaultFilterChain) OnDecode(ctx *http.HttpContext) {
 	for ; c.decodeFiltersIndex < len(c.decodeFilters); c.decodeFiltersIndex++ {
-		filterStatus := c.decodeFilters[c.decodeFiltersIndex].Decode(ctx)
+		filterStatus := c.decode(ctx, c.decodeFilters[c.decodeFiltersIndex])
 
 		switch filterStatus {
 		case Continue:
 			continue
 		case Stop:
+			renderAbort(ctx)
 			return
 		}
 	}
@@ -73,13 +171,70 @@ func (c *defaultFilterChain) OnDecode(ctx *http.HttpContext) {
 
 func (c *defaultFilterChain) OnEncode(ctx *http.HttpContext) {
 	for ; c.encodeFiltersIndex < len(c.encodeFilters); c.encodeFiltersIndex++ {
-		filterStatus := c.encodeFilters[c.encodeFiltersIndex].Encode(ctx)
+		filterStatus := c.encode(ctx, c.encodeFilters[c.encodeFiltersIndex])
 
 		switch filterStatus {
 		case Continue:
 			continue
 		case Stop:
+			renderAbort(ctx)
 			return
 		}
 	}
 }
+
+func (c *defaultFilterChain) decode(ctx *http.HttpContext, f HttpDecodeFilter) FilterStatus {
+	if c.timings == nil {
+		return f.Decode(ctx)
+	}
+	start := time.Now()
+	defer func() { c.timings.record(f, phaseDecode, time.Since(start)) }()
+	return f.Decode(ctx)
+}
+
+func (c *defaultFilterChain) encode(ctx *http.HttpContext, f HttpEncodeFilter) FilterStatus {
+	if c.timings == nil {
+		return f.Encode(ctx)
+	}
+	start := time.Now()
+	defer func() { c.timings.record(f, phaseEncode, time.Since(start)) }()
+	return f.Encode(ctx)
+}
+
+func (c *defaultFilterChain) encodeStream(ctx *http.HttpContext, f HttpStreamEncodeFilter, w io.Writer) (io.WriteCloser, FilterStatus) {
+	if c.timings == nil {
+		return f.EncodeStream(ctx, w)
+	}
+	start := time.Now()
+	defer func() { c.timings.record(f, phaseEncode, time.Since(start)) }()
+	return f.EncodeStream(ctx, w)
+}
+
+func (c *defaultFilterChain) Streamable() bool {
+	for _, f := range c.encodeFilters {
+		if _, ok := f.(HttpStreamEncodeFilter); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *defaultFilterChain) OnEncodeStream(ctx *http.HttpContext, w io.Writer) io.WriteCloser {
+	writers := make([]io.WriteCloser, len(c.encodeFilters))
+	for i := len(c.encodeFilters) - 1; i >= 0; i-- {
+		sw, status := c.encodeStream(ctx, c.encodeFilters[i].(HttpStreamEncodeFilter), w)
+		if status == Stop {
+			c.encodeFiltersIndex = len(c.encodeFilters)
+			renderAbort(ctx)
+			return nil
+		}
+		if sw == nil {
+			sw = NopWriteCloser(w)
+		}
+		writers[i] = sw
+		w = sw
+	}
+	// the encode filters are done, OnEncode does nothing
+	c.encodeFiltersIndex = len(c.encodeFilters)
+	return &streamWriter{Writer: w, writers: writers}
+}
@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// suffixFilter append the suffix to the body, buffered or streamed
+type suffixFilter struct {
+	suffix string
+}
+
+func (f *suffixFilter) Encode(ctx *contexthttp.HttpContext) FilterStatus {
+	ctx.TargetResp.Data = append(ctx.TargetResp.Data, f.suffix...)
+	return Continue
+}
+
+func (f *suffixFilter) EncodeStream(ctx *contexthttp.HttpContext, w io.Writer) (io.WriteCloser, FilterStatus) {
+	ctx.AddHeader("X-Suffix", f.suffix)
+	return &suffixWriter{Writer: w, suffix: f.suffix}, Continue
+}
+
+type suffixWriter struct {
+	io.Writer
+	suffix string
+}
+
+func (w *suffixWriter) Close() error {
+	_, err := w.Write([]byte(w.suffix))
+	return err
+}
+
+// bufferedFilter only supports the buffered response
+type bufferedFilter struct{}
+
+func (f *bufferedFilter) Encode(ctx *contexthttp.HttpContext) FilterStatus {
+	return Continue
+}
+
+func TestOnEncodeStream(t *testing.T) {
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+
+	chain := &defaultFilterChain{}
+	rc := &recoverChain{FilterChain: chain, factory: newRecoverFactory("suffix", "", nil)}
+	rc.AppendEncodeFilters(&suffixFilter{suffix: "-a"}, &suffixFilter{suffix: "-b"})
+	assert.True(t, chain.Streamable())
+
+	// the body flows the filters in the order of Encode
+	var out bytes.Buffer
+	w := chain.OnEncodeStream(ctx, &out)
+	_, err = w.Write([]byte("body"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "body-a-b", out.String())
+	assert.Equal(t, "-b", ctx.Writer.Header().Get("X-Suffix"))
+
+	chain.AppendEncodeFilters(&bufferedFilter{})
+	assert.False(t, chain.Streamable())
+}
+
+// panicWriterFilter return the writer panicking on write
+type panicWriterFilter struct{}
+
+func (f *panicWriterFilter) Encode(ctx *contexthttp.HttpContext) FilterStatus {
+	return Continue
+}
+
+func (f *panicWriterFilter) EncodeStream(ctx *contexthttp.HttpContext, w io.Writer) (io.WriteCloser, FilterStatus) {
+	return CloseFuncWriter(panicWriter{}, nil), Continue
+}
+
+type panicWriter struct{}
+
+func (panicWriter) Write(p []byte) (int, error) {
+	panic("mock write panic")
+}
+
+func TestOnEncodeStreamPanic(t *testing.T) {
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+
+	chain := &defaultFilterChain{}
+	rc := &recoverChain{FilterChain: chain, factory: newRecoverFactory("panic", model.FilterPanicFailOpen, nil)}
+	rc.AppendEncodeFilters(&panicWriterFilter{})
+
+	// the streamed body can not be replied with 500, the panic interrupts the stream
+	w := chain.OnEncodeStream(ctx, &bytes.Buffer{})
+	assert.NotPanics(t, func() {
+		_, err = w.Write([]byte("body"))
+	})
+	assert.Error(t, err)
+	assert.NoError(t, w.Close())
+}
+
+func TestCloseFuncWriter(t *testing.T) {
+	closed := 0
+	w := CloseFuncWriter(&bytes.Buffer{}, func() { closed++ })
+	assert.NoError(t, w.Close())
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 1, closed)
+}
@@ -0,0 +1,186 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// defaultDrainTimeout the replaced filters are closed after the timeout even if requests are still in flight
+const defaultDrainTimeout = 30 * time.Second
+
+// filterGeneration count the in-flight requests of the filters loaded at the same time,
+// drained is closed when the generation is retired by reload and no request is in flight
+type filterGeneration struct {
+	inflight int64
+	retired  int32
+	drained  chan struct{}
+	once     sync.Once
+}
+
+func newFilterGeneration() *filterGeneration {
+	return &filterGeneration{drained: make(chan struct{})}
+}
+
+func (g *filterGeneration) acquire() {
+	atomic.AddInt64(&g.inflight, 1)
+}
+
+func (g *filterGeneration) release() {
+	if atomic.AddInt64(&g.inflight, -1) == 0 && atomic.LoadInt32(&g.retired) == 1 {
+		g.once.Do(func() { close(g.drained) })
+	}
+}
+
+func (g *filterGeneration) retire() {
+	atomic.StoreInt32(&g.retired, 1)
+	if atomic.LoadInt64(&g.inflight) == 0 {
+		g.once.Do(func() { close(g.drained) })
+	}
+}
+
+// SetDrainTimeout set how long the replaced filters wait for the in-flight requests before closed
+func (fm *FilterManager) SetDrainTimeout(timeout time.Duration) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.drainTimeout = timeout
+}
+
+// ReleaseFilterChain mark the request of the chain created by CreateFilterChain finished,
+// and call the functions deferred by its filters
+func (fm *FilterManager) ReleaseFilterChain(chain FilterChain) {
+	c, ok := chain.(*defaultFilterChain)
+	if !ok {
+		return
+	}
+	defer func() {
+		if c.gen != nil {
+			c.gen.release()
+			c.gen = nil
+		}
+	}()
+	c.runDeferred()
+}
+
+// WaitForDrain wait until the filters replaced by reload are closed
+func (fm *FilterManager) WaitForDrain(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		fm.draining.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return errors.Errorf("replaced filters are not drained in %s", timeout)
+	}
+}
+
+// retire start a new generation for the filters being swapped in, the replaced filters are closed once the
+// requests of the old generation and those before it are finished, the caller must hold the lock.
+// The generation is linked to the drain even if nothing is replaced, its requests may still use the filters
+// replaced by a later reload.
+func (fm *FilterManager) retire(replaced []HttpFilterFactory) {
+	old := fm.gen
+	fm.gen = newFilterGeneration()
+
+	timeout := fm.drainTimeout
+	if timeout <= 0 {
+		timeout = defaultDrainTimeout
+	}
+	// the filters replaced now may still be used by the requests of the earlier generations
+	prev := fm.lastDrain
+	closed := make(chan struct{})
+	fm.lastDrain = closed
+	fm.draining.Add(1)
+	old.retire()
+
+	go func() {
+		defer fm.draining.Done()
+		defer close(closed)
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		for _, drained := range []chan struct{}{prev, old.drained} {
+			if drained == nil {
+				continue
+			}
+			select {
+			case <-drained:
+			case <-timer.C:
+				if len(replaced) == 0 {
+					return
+				}
+				logger.Warnw("filters are closed with requests in flight after drain timeout", "inflight", atomic.LoadInt64(&old.inflight),
+					"timeout", timeout.String())
+				closeFactories(replaced)
+				return
+			}
+		}
+		closeFactories(replaced)
+	}()
+}
+
+// replacedFactories return the old factories not in use by the new ones
+func replacedFactories(old, current []*HttpFilterFactory) []HttpFilterFactory {
+	inUse := make(map[HttpFilterFactory]struct{}, len(current))
+	for _, f := range current {
+		if *f != nil {
+			inUse[*f] = struct{}{}
+		}
+	}
+	var replaced []HttpFilterFactory
+	for _, f := range old {
+		if *f == nil {
+			continue
+		}
+		if _, ok := inUse[*f]; !ok {
+			replaced = append(replaced, *f)
+		}
+	}
+	return replaced
+}
+
+func closeFactories(factories []HttpFilterFactory) {
+	for _, f := range factories {
+		if err := closeFactory(f); err != nil {
+			logger.Warnw("close filter fail", "filter", fmt.Sprintf("%T", f), "error", err.Error())
+		}
+	}
+}
+
+func closeFactory(factory HttpFilterFactory) error {
+	if c, ok := factory.(HttpFilterCloser); ok {
+		return c.Close()
+	}
+	return nil
+}
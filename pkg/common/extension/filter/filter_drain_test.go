@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const demoCloser = "dgp.filters.demo.closer"
+
+// closerClosed count the closed demo closer filters
+var closerClosed int32
+
+func init() {
+	RegisterHttpFilter(&closerPlugin{})
+}
+
+// closerPlugin create the demo filter counting its close
+type closerPlugin struct {
+}
+
+func (p *closerPlugin) Kind() string {
+	return demoCloser
+}
+
+func (p *closerPlugin) CreateFilterFactory() (HttpFilterFactory, error) {
+	return &closerFilterFactory{conf: &Config{}}, nil
+}
+
+type closerFilterFactory struct {
+	conf   *Config
+	closed int32
+}
+
+func (f *closerFilterFactory) Config() interface{} {
+	return f.conf
+}
+
+func (f *closerFilterFactory) Apply() error {
+	return nil
+}
+
+func (f *closerFilterFactory) PrepareFilterChain(ctx *contexthttp.HttpContext, chain FilterChain) error {
+	return nil
+}
+
+func (f *closerFilterFactory) Close() error {
+	atomic.AddInt32(&f.closed, 1)
+	atomic.AddInt32(&closerClosed, 1)
+	return nil
+}
+
+func closerOf(fm *FilterManager) *closerFilterFactory {
+	return fm.filters[demoCloser].(*recoverFactory).HttpFilterFactory.(*closerFilterFactory)
+}
+
+func closerFilters(foo string) []*model.HTTPFilter {
+	return []*model.HTTPFilter{{Name: demoCloser, Config: map[string]interface{}{"foo": foo}}}
+}
+
+func TestDrainBeforeClose(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	assert.Nil(t, fm.ReLoad(closerFilters("v1")))
+	v1 := closerOf(fm)
+
+	ctx := &contexthttp.HttpContext{}
+	ctx.Reset()
+	inflight := fm.CreateFilterChain(ctx)
+
+	assert.Nil(t, fm.ReLoad(closerFilters("v2")))
+	v2 := closerOf(fm)
+	// the new requests use the new filter at once
+	assert.Equal(t, "v2", fm.GetFactory()[0].Config().(*Config).Foo)
+
+	// the replaced filter is still used by the in-flight request
+	assert.Error(t, fm.WaitForDrain(50*time.Millisecond))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&v1.closed))
+
+	fm.ReleaseFilterChain(inflight)
+	assert.Nil(t, fm.WaitForDrain(time.Second))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&v1.closed))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&v2.closed))
+
+	// the unchanged filter is kept
+	assert.Nil(t, fm.ReLoad(closerFilters("v2")))
+	assert.Nil(t, fm.WaitForDrain(time.Second))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&v2.closed))
+}
+
+func TestDrainAcrossUnchangedReload(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	assert.Nil(t, fm.ReLoad(closerFilters("v1")))
+	v1 := closerOf(fm)
+
+	ctx := &contexthttp.HttpContext{}
+	ctx.Reset()
+	inflight := fm.CreateFilterChain(ctx)
+
+	// nothing is replaced, the generation of the in-flight request is retired still
+	assert.Nil(t, fm.ReLoad(closerFilters("v1")))
+	assert.Nil(t, fm.ReLoad(closerFilters("v2")))
+	assert.Error(t, fm.WaitForDrain(50*time.Millisecond))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&v1.closed))
+
+	fm.ReleaseFilterChain(inflight)
+	assert.Nil(t, fm.WaitForDrain(time.Second))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&v1.closed))
+}
+
+func TestDrainTimeout(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	fm.SetDrainTimeout(50 * time.Millisecond)
+	assert.Nil(t, fm.ReLoad(closerFilters("v1")))
+	v1 := closerOf(fm)
+
+	ctx := &contexthttp.HttpContext{}
+	ctx.Reset()
+	// never released
+	fm.CreateFilterChain(ctx)
+
+	assert.Nil(t, fm.ReLoad(closerFilters("v2")))
+	assert.Nil(t, fm.WaitForDrain(time.Second))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&v1.closed))
+}
+
+func TestCloseAppliedOnFailure(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	assert.Nil(t, fm.ReLoad(closerFilters("v1")))
+	v1 := closerOf(fm)
+
+	before := atomic.LoadInt32(&closerClosed)
+	err := fm.ReLoad(append(closerFilters("v2"), &model.HTTPFilter{Name: "dgp.filters.demo.unknown"}))
+	assert.Error(t, err)
+	// the filter applied by the failed reload is closed at once, the loaded one still serves
+	assert.Equal(t, before+1, atomic.LoadInt32(&closerClosed))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&v1.closed))
+	assert.True(t, v1 == closerOf(fm))
+}
@@ -18,7 +18,9 @@
 package filter
 
 import (
+	"fmt"
 	"sync"
+	"time"
 )
 
 import (
@@ -26,6 +28,7 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/yaml"
 	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
@@ -38,82 +41,735 @@ type FilterManager struct {
 	filtersArray  []*HttpFilterFactory
 	filterConfigs []*model.HTTPFilter
 
-	mu sync.RWMutex
+	chains       []*namedFilterChain
+	chainConfigs []*model.HTTPFilterChain
+
+	// plugins cache the resolved plugins by filter name, so that reload does not look up the registry again
+	plugins sync.Map
+	// applied the applied factories by chain and filter name, the unchanged filters are reused on reload
+	applied map[string]map[string]*appliedFilter
+
+	// gen count the in-flight requests of the loaded filters, the replaced filters are closed after drained
+	gen          *filterGeneration
+	lastDrain    chan struct{}
+	draining     sync.WaitGroup
+	drainTimeout time.Duration
+
+	// strict reject the filter configs containing unknown keys unless the filter is lenient
+	strict bool
+	// timings trace the latency of each filter of the created chains
+	timings bool
+
+	// reloadMu serialize the reload and patch of the default filters and chains
+	reloadMu sync.Mutex
+	mu       sync.RWMutex
+}
+
+// namedFilterChain the filter factories of a named http filter chain
+type namedFilterChain struct {
+	name         string
+	match        model.HTTPFilterChainMatch
+	filtersArray []*HttpFilterFactory
+	// overrides the chain is composed from the default filters, it is recomposed when they are reloaded
+	overrides []*model.HTTPFilterOverride
+}
+
+// appliedFilter the applied factory and the signature of the config it is applied with
+type appliedFilter struct {
+	signature string
+	factory   HttpFilterFactory
 }
 
+// defaultChainScope the scope of the default filters in applied cache
+const defaultChainScope = ""
+
+// ErrFilterNotFound the filter is not configured in the default filters or the named chain
+var ErrFilterNotFound = errors.New("filter not found")
+
+// ErrChainNotFound the named filter chain is not loaded
+var ErrChainNotFound = errors.New("filter chain not found")
+
 // NewFilterManager create filter manager
 func NewFilterManager(fs []*model.HTTPFilter) *FilterManager {
-	fm := &FilterManager{filterConfigs: fs, filters: make(map[string]HttpFilterFactory)}
+	fm := &FilterManager{filterConfigs: fs, filters: make(map[string]HttpFilterFactory), gen: newFilterGeneration()}
+	return fm
+}
+
+// NewFilterManagerWithChains create filter manager with the default filters and named filter chains
+func NewFilterManagerWithChains(fs []*model.HTTPFilter, chains []*model.HTTPFilterChain) *FilterManager {
+	fm := &FilterManager{filterConfigs: fs, chainConfigs: chains, filters: make(map[string]HttpFilterFactory), gen: newFilterGeneration()}
 	return fm
 }
 
 // NewEmptyFilterManager create empty filter manager
 func NewEmptyFilterManager() *FilterManager {
-	return &FilterManager{filters: make(map[string]HttpFilterFactory)}
+	return &FilterManager{filters: make(map[string]HttpFilterFactory), gen: newFilterGeneration()}
 }
 
+// SetConfigMode set the default config mode of the filters, it should be called before Load
+func (fm *FilterManager) SetConfigMode(mode string) {
+	fm.strict = mode == model.FilterConfigStrict
+}
+
+// SetFilterTimings enable or disable tracing the latency of each filter, it is disabled by default
+func (fm *FilterManager) SetFilterTimings(enabled bool) {
+	fm.timings = enabled
+}
+
+// strictFor whether the config of the filter is unmarshalled strictly
+func (fm *FilterManager) strictFor(f *model.HTTPFilter) bool {
+	switch f.ConfigMode {
+	case model.FilterConfigStrict:
+		return true
+	case model.FilterConfigLenient:
+		return false
+	default:
+		return fm.strict
+	}
+}
+
+// CreateFilterChain create the filter chain for the request, the chain should be released by ReleaseFilterChain
+// after the request is finished, so that the filters replaced by reload can be closed in time
 func (fm *FilterManager) CreateFilterChain(ctx *http.HttpContext) FilterChain {
-	chain := NewDefaultFilterChain()
+	chain := &defaultFilterChain{}
 
-	for _, f := range fm.GetFactory() {
+	// acquire under the lock, so that the generation is not retired before the request is counted
+	fm.mu.RLock()
+	gen := fm.gen
+	gen.acquire()
+	factories := fm.filtersArray
+	if ctx.Request != nil {
+		factories = fm.factoryFor(ctx.Request.Host, ctx.GetUrl())
+	}
+	fm.mu.RUnlock()
+	chain.gen = gen
+	if fm.timings && ctx.Params != nil {
+		chain.timings = &FilterTimings{}
+		ctx.Params[constant.FilterTimingsParam] = chain.timings
+	}
+
+	for _, f := range factories {
 		_ = (*f).PrepareFilterChain(ctx, chain)
 	}
 	return chain
 }
 
 // GetFactory get all filter from manager
-func (fm *FilterManager) GetFactory() []*HttpFilterFactory {
+func (fm *FilterManager) GetFactory() []HttpFilterFactory {
 	fm.mu.RLock()
 	defer fm.mu.RUnlock()
 
+	return copyFactories(fm.filtersArray)
+}
+
+// GetFactoryFor get the filters of the first named chain matching the host and path,
+// the default filters will be returned if no chain matches
+func (fm *FilterManager) GetFactoryFor(host, path string) []HttpFilterFactory {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+
+	return copyFactories(fm.factoryFor(host, path))
+}
+
+// copyFactories return the factories the slots point to, so that the caller holding them is not affected by
+// reload and can not replace the filters of manager through the slots
+func copyFactories(factories []*HttpFilterFactory) []HttpFilterFactory {
+	if factories == nil {
+		return nil
+	}
+	copied := make([]HttpFilterFactory, len(factories))
+	for i, f := range factories {
+		copied[i] = *f
+	}
+	return copied
+}
+
+// factoryFor the caller must hold the lock
+func (fm *FilterManager) factoryFor(host, path string) []*HttpFilterFactory {
+	for _, c := range fm.chains {
+		if c.match.Match(host, path) {
+			return c.filtersArray
+		}
+	}
 	return fm.filtersArray
 }
 
-// Load the filter from config
-func (fm *FilterManager) Load() {
-	fm.ReLoad(fm.filterConfigs)
+// Load the filter from config, it fails if any filter fails to apply, so that the gateway never starts with
+// a partial chain. The later reloads keep the loaded filters instead.
+func (fm *FilterManager) Load() error {
+	if err := fm.ReLoad(fm.filterConfigs); err != nil {
+		return err
+	}
+	return fm.ReLoadChains(fm.chainConfigs)
+}
+
+// Close close the loaded filters once the requests in flight are finished, and drop the manager from the reload
+// statistics, it is called when the manager is discarded and the manager should not be used later
+func (fm *FilterManager) Close() error {
+	fm.reloadMu.Lock()
+	defer fm.reloadMu.Unlock()
+
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	loaded := append([]*HttpFilterFactory(nil), fm.filtersArray...)
+	for _, c := range fm.chains {
+		loaded = append(loaded, c.filtersArray...)
+	}
+	fm.retire(replacedFactories(loaded, nil))
+	fm.filters = make(map[string]HttpFilterFactory)
+	fm.filtersArray = nil
+	fm.chains = nil
+	fm.applied = nil
+	reloadStats.dropLoaded(fm)
+	return nil
+}
+
+// GetFilterByName get the applied factory of the default filter by name
+func (fm *FilterManager) GetFilterByName(name string) (HttpFilterFactory, bool) {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+
+	f, ok := fm.filters[name]
+	return f, ok && f != nil
+}
+
+// PatchFilter re-apply the first default filter of the name with the new config, the other filters are reused.
+// The loaded filter is kept when the new config fails to apply. The chains composed by overrides are recomposed
+// with the patched filter, the filters of the other named chains are patched by PatchChainFilter.
+func (fm *FilterManager) PatchFilter(name string, conf map[string]interface{}) error {
+	fm.reloadMu.Lock()
+	defer fm.reloadMu.Unlock()
+
+	fm.mu.RLock()
+	configs := fm.filterConfigs
+	fm.mu.RUnlock()
+
+	index := -1
+	for i, f := range configs {
+		if f.Name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return errors.Wrapf(ErrFilterNotFound, "http filter %s", name)
+	}
+
+	patched := *configs[index]
+	patched.Config = conf
+	sig, err := filterSignature(&patched)
+	if err != nil {
+		return errors.Wrapf(err, "patch filter %s fail", name)
+	}
+	factory, err := fm.applyFilter(&patched)
+	if err != nil {
+		return errors.Wrapf(err, "patch filter %s fail", name)
+	}
+
+	// seed the applied cache, so that reload picks the patched factory and reuses the others
+	key := name + "#0"
+	fm.mu.Lock()
+	prev, hasPrev := fm.applied[defaultChainScope][key]
+	if fm.applied[defaultChainScope] == nil {
+		fm.storeApplied(defaultChainScope, make(map[string]*appliedFilter))
+	}
+	fm.applied[defaultChainScope][key] = &appliedFilter{signature: sig, factory: factory}
+	fm.mu.Unlock()
+
+	filters := make([]*model.HTTPFilter, len(configs))
+	copy(filters, configs)
+	filters[index] = &patched
+	if err := fm.reload(filters); err != nil {
+		fm.mu.Lock()
+		if hasPrev {
+			fm.applied[defaultChainScope][key] = prev
+		} else {
+			delete(fm.applied[defaultChainScope], key)
+		}
+		fm.mu.Unlock()
+		_ = closeFactory(factory)
+		return errors.Wrapf(err, "patch filter %s fail", name)
+	}
+	logger.Infow("filter is patched", "filter", name)
+	return nil
+}
+
+// ReLoad filter configs, the loaded filters are kept when any filter fails to apply or the filters can not be ordered
+func (fm *FilterManager) ReLoad(filters []*model.HTTPFilter) error {
+	fm.reloadMu.Lock()
+	defer fm.reloadMu.Unlock()
+
+	return fm.reload(filters)
 }
 
-// ReLoad filter configs
-func (fm *FilterManager) ReLoad(filters []*model.HTTPFilter) {
+// reload the caller must hold the reloadMu
+func (fm *FilterManager) reload(filters []*model.HTTPFilter) error {
+	tmp, filtersArray, applied, err := fm.applyFilters(defaultChainScope, filters)
+	reloadStats.reloaded(err != nil)
+	if err != nil {
+		logger.Errorw("reload filters fail", "error", err.Error())
+		return err
+	}
+
+	fm.mu.RLock()
+	oldFilters, oldChains := fm.filtersArray, fm.chains
+	fm.mu.RUnlock()
+	chains, chainsApplied, err := fm.recomposeChains(oldChains, filters)
+	if err != nil {
+		closeFactories(replacedFactories(filtersArray, oldFilters))
+		logger.Errorw("reload filters fail", "error", err.Error())
+		return err
+	}
+
+	// avoid filter inconsistency
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	replaced := replacedFactories(fm.filtersArray, filtersArray)
+	for i, c := range chains {
+		if c != oldChains[i] {
+			replaced = append(replaced, replacedFactories(oldChains[i].filtersArray, c.filtersArray)...)
+		}
+	}
+	fm.retire(replaced)
+	fm.filters = tmp
+	fm.filtersArray = filtersArray
+	fm.filterConfigs = filters
+	fm.chains = chains
+	fm.storeApplied(defaultChainScope, applied)
+	for scope, applied := range chainsApplied {
+		fm.storeApplied(scope, applied)
+	}
+	reloadStats.setLoaded(fm)
+	return nil
+}
+
+// recomposeChains compose the chains having overrides from the new default filters, the other chains are
+// returned as they are. The recomposed filters are closed when any chain fails to compose.
+func (fm *FilterManager) recomposeChains(chains []*namedFilterChain, base []*model.HTTPFilter) ([]*namedFilterChain, map[string]map[string]*appliedFilter, error) {
+	recomposed := make([]*namedFilterChain, len(chains))
+	chainsApplied := make(map[string]map[string]*appliedFilter)
+	for i, c := range chains {
+		recomposed[i] = c
+		if len(c.overrides) == 0 {
+			continue
+		}
+		err := func() error {
+			filters, err := composeFilters(base, c.overrides)
+			if err != nil {
+				return err
+			}
+			scope := chainScope(c.name)
+			_, filtersArray, applied, err := fm.applyFilters(scope, filters)
+			if err != nil {
+				return err
+			}
+			chain := *c
+			chain.filtersArray = filtersArray
+			recomposed[i] = &chain
+			chainsApplied[scope] = applied
+			return nil
+		}()
+		if err != nil {
+			for j := 0; j < i; j++ {
+				if recomposed[j] != chains[j] {
+					closeFactories(replacedFactories(recomposed[j].filtersArray, chains[j].filtersArray))
+				}
+			}
+			return nil, nil, errors.Wrapf(err, "filter chain %s", c.name)
+		}
+	}
+	return recomposed, chainsApplied, nil
+}
+
+// ReLoadChains named filter chain configs, the chains are matched in the config order. The chain having
+// overrides is composed from the default filters, and recomposed when the default filters are reloaded.
+func (fm *FilterManager) ReLoadChains(chains []*model.HTTPFilterChain) error {
+	fm.reloadMu.Lock()
+	defer fm.reloadMu.Unlock()
+
+	fm.mu.RLock()
+	base := fm.filterConfigs
+	var oldFactories []*HttpFilterFactory
+	for _, c := range fm.chains {
+		oldFactories = append(oldFactories, c.filtersArray...)
+	}
+	fm.mu.RUnlock()
+
+	namedChains := make([]*namedFilterChain, 0, len(chains))
+	chainsApplied := make(map[string]map[string]*appliedFilter, len(chains))
+	var newFactories []*HttpFilterFactory
+	failed := false
+	if len(chains) > 0 {
+		defer func() { reloadStats.reloaded(failed) }()
+	}
+	for _, c := range chains {
+		scope := chainScope(c.Name)
+		filters, err := chainFilters(c, base)
+		if err == nil {
+			var filtersArray []*HttpFilterFactory
+			var applied map[string]*appliedFilter
+			if _, filtersArray, applied, err = fm.applyFilters(scope, filters); err == nil {
+				namedChains = append(namedChains, &namedFilterChain{name: c.Name, match: c.Match, filtersArray: filtersArray, overrides: c.Overrides})
+				chainsApplied[scope] = applied
+				newFactories = append(newFactories, filtersArray...)
+				continue
+			}
+		}
+		failed = true
+		// close the filters applied for the chains before, the reused ones still serve the loaded chains
+		closeFactories(replacedFactories(newFactories, oldFactories))
+		logger.Errorw("reload filter chain fail", "chain", c.Name, "error", err.Error())
+		return errors.Wrapf(err, "filter chain %s", c.Name)
+	}
+
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	fm.retire(replacedFactories(oldFactories, newFactories))
+	fm.chains = namedChains
+	fm.chainConfigs = chains
+	// drop the filters of the removed chains
+	for scope := range fm.applied {
+		if scope != defaultChainScope {
+			delete(fm.applied, scope)
+		}
+	}
+	for scope, applied := range chainsApplied {
+		fm.storeApplied(scope, applied)
+	}
+	reloadStats.setLoaded(fm)
+	return nil
+}
+
+// ReplaceChain re-apply the named chain with the filters, the other chains are untouched. The new chain is fully
+// applied before swapped in, and the replaced filters of the old chain are closed after drained.
+// The old chain is kept when any filter fails to apply. The overrides of the chain are dropped, so it is not
+// recomposed when the default filters are reloaded.
+func (fm *FilterManager) ReplaceChain(name string, filters []*model.HTTPFilter) error {
+	fm.reloadMu.Lock()
+	defer fm.reloadMu.Unlock()
+
+	return fm.replaceChain(name, filters)
+}
+
+// PatchChainFilter re-apply the first filter of the name in the named chain with the new config, the other filters
+// of the chain are reused. The chain is kept when the new config fails to apply. The chain composed from the default
+// filters by overrides is rejected, patch the default filter instead.
+func (fm *FilterManager) PatchChainFilter(chain, name string, conf map[string]interface{}) error {
+	fm.reloadMu.Lock()
+	defer fm.reloadMu.Unlock()
+
+	fm.mu.RLock()
+	var chainConf *model.HTTPFilterChain
+	if fm.chainIndex(chain) >= 0 {
+		for _, c := range fm.chainConfigs {
+			if c.Name == chain {
+				chainConf = c
+				break
+			}
+		}
+	}
+	fm.mu.RUnlock()
+	if chainConf == nil {
+		return errors.Wrapf(ErrChainNotFound, "http filter chain %s", chain)
+	}
+	if len(chainConf.Overrides) > 0 {
+		return errors.Errorf("http filter chain %s is composed by overrides, patch the default filter instead", chain)
+	}
+
+	index := -1
+	for i, f := range chainConf.HTTPFilters {
+		if f.Name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return errors.Wrapf(ErrFilterNotFound, "http filter %s of chain %s", name, chain)
+	}
+
+	patched := *chainConf.HTTPFilters[index]
+	patched.Config = conf
+	filters := make([]*model.HTTPFilter, len(chainConf.HTTPFilters))
+	copy(filters, chainConf.HTTPFilters)
+	filters[index] = &patched
+	// the unchanged filters are reused by the applied cache of the chain
+	if err := fm.replaceChain(chain, filters); err != nil {
+		return errors.Wrapf(err, "patch filter %s of chain %s fail", name, chain)
+	}
+	logger.Infow("filter is patched", "filter", name, "chain", chain)
+	return nil
+}
+
+// replaceChain the caller must hold the reloadMu
+func (fm *FilterManager) replaceChain(name string, filters []*model.HTTPFilter) error {
+	fm.mu.RLock()
+	index := fm.chainIndex(name)
+	var old []*HttpFilterFactory
+	if index >= 0 {
+		old = fm.chains[index].filtersArray
+	}
+	fm.mu.RUnlock()
+	if index < 0 {
+		return errors.Wrapf(ErrChainNotFound, "http filter chain %s", name)
+	}
+
+	scope := chainScope(name)
+	_, filtersArray, applied, err := fm.applyFilters(scope, filters)
+	reloadStats.reloaded(err != nil)
+	if err != nil {
+		return errors.Wrapf(err, "replace filter chain %s fail", name)
+	}
+
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	// the chains may be reloaded meanwhile, swap the one of the name
+	if index = fm.chainIndex(name); index < 0 {
+		closeFactories(replacedFactories(filtersArray, old))
+		return errors.Wrapf(ErrChainNotFound, "http filter chain %s", name)
+	}
+	replaced := *fm.chains[index]
+	replaced.filtersArray = filtersArray
+	replaced.overrides = nil
+	chains := make([]*namedFilterChain, len(fm.chains))
+	copy(chains, fm.chains)
+	chains[index] = &replaced
+	fm.retire(replacedFactories(fm.chains[index].filtersArray, filtersArray))
+	fm.chains = chains
+	fm.storeApplied(scope, applied)
+	for i, c := range fm.chainConfigs {
+		if c.Name == name {
+			conf := *c
+			conf.HTTPFilters = filters
+			conf.Overrides = nil
+			configs := make([]*model.HTTPFilterChain, len(fm.chainConfigs))
+			copy(configs, fm.chainConfigs)
+			configs[i] = &conf
+			fm.chainConfigs = configs
+			break
+		}
+	}
+	reloadStats.setLoaded(fm)
+	logger.Infow("filter chain is replaced", "chain", name)
+	return nil
+}
+
+// chainIndex the index of the named chain, -1 if not found, the caller must hold the lock
+func (fm *FilterManager) chainIndex(name string) int {
+	for i, c := range fm.chains {
+		if c.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyFilters apply the filters of the scope, the filter whose config is not changed since last applied
+// is reused instead of being applied again. It fails if any filter fails to apply, and the filters applied
+// by the call are closed, so that a partial chain is never returned.
+func (fm *FilterManager) applyFilters(scope string, filters []*model.HTTPFilter) (map[string]HttpFilterFactory, []*HttpFilterFactory, map[string]*appliedFilter, error) {
+	fm.mu.RLock()
+	prev := fm.applied[scope]
+	fm.mu.RUnlock()
+
 	tmp := make(map[string]HttpFilterFactory)
 	filtersArray := make([]*HttpFilterFactory, len(filters))
+	names := make(map[*HttpFilterFactory]string, len(filters))
+	applied := make(map[string]*appliedFilter, len(filters))
+	seen := make(map[string]int, len(filters))
+	var fresh []HttpFilterFactory
 	for i, f := range filters {
-		apply, err := fm.Apply(f.Name, f.Config)
+		// the same filter may be configured more than once, tell them apart by occurrence
+		key := fmt.Sprintf("%s#%d", f.Name, seen[f.Name])
+		seen[f.Name]++
+		sig, sigErr := filterSignature(f)
+		if old, ok := prev[key]; ok && sigErr == nil && old.signature == sig {
+			factory := old.factory
+			tmp[f.Name] = factory
+			filtersArray[i] = &factory
+			names[filtersArray[i]] = f.Name
+			applied[key] = old
+			continue
+		}
+
+		apply, err := fm.applyFilter(f)
 		if err != nil {
-			logger.Errorf("apply [%s] init fail, %s", f.Name, err.Error())
+			logger.Errorw("apply filter init fail", "filter", f.Name, "error", err.Error())
+			// the reused filters still serve the loaded ones
+			closeFactories(fresh)
+			return nil, nil, nil, errors.Wrapf(err, "apply filter %s fail", f.Name)
+		}
+		if sigErr == nil {
+			applied[key] = &appliedFilter{signature: sig, factory: apply}
 		}
+		fresh = append(fresh, apply)
 		tmp[f.Name] = apply
 		filtersArray[i] = &apply
+		names[filtersArray[i]] = f.Name
 	}
-	// avoid filter inconsistency
-	fm.mu.Lock()
-	defer fm.mu.Unlock()
+	ordered, err := orderByHint(orderByStage(filtersArray), names)
+	if err != nil {
+		closeFactories(fresh)
+		return nil, nil, nil, err
+	}
+	return tmp, ordered, applied, nil
+}
 
-	fm.filters = tmp
-	fm.filtersArray = filtersArray
+// applyFilter apply the filter and wrap it with its panic policy and predicate
+func (fm *FilterManager) applyFilter(f *model.HTTPFilter) (HttpFilterFactory, error) {
+	apply, err := fm.apply(f.Name, f.Config, fm.strictFor(f))
+	if err != nil {
+		reloadStats.applyFailed(f.Name)
+		return nil, err
+	}
+	if f.Match != nil {
+		if err := f.Match.Compile(); err != nil {
+			// never run the filter without its predicate
+			_ = closeFactory(apply)
+			reloadStats.applyFailed(f.Name)
+			return nil, errors.Wrap(err, "match invalid")
+		}
+	}
+	if a, ok := apply.(FilterManagerAware); ok {
+		a.SetFilterManager(fm)
+	}
+	apply = newRecoverFactory(f.Name, f.OnPanic, apply)
+	if f.Match != nil {
+		apply = &matchFactory{HttpFilterFactory: apply, match: f.Match}
+	}
+	return apply, nil
+}
+
+// storeApplied replace the applied filters of the scope, the caller must hold the lock
+func (fm *FilterManager) storeApplied(scope string, applied map[string]*appliedFilter) {
+	if fm.applied == nil {
+		fm.applied = make(map[string]map[string]*appliedFilter)
+	}
+	fm.applied[scope] = applied
+}
+
+func chainScope(name string) string {
+	return "chain:" + name
+}
+
+// filterSignature marshal the filter config as its signature, so that the config mutated after
+// applied is still detected as changed
+func filterSignature(f *model.HTTPFilter) (string, error) {
+	b, err := yaml.MarshalYML(f)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// orderByStage move the auth filters configured after the first body consuming filter before it,
+// the relative order of the other filters is kept.
+func orderByStage(factories []*HttpFilterFactory) []*HttpFilterFactory {
+	firstBody := -1
+	var deferred []*HttpFilterFactory
+	ordered := make([]*HttpFilterFactory, 0, len(factories))
+	for _, f := range factories {
+		switch stageOf(*f) {
+		case StageBody:
+			if firstBody < 0 {
+				firstBody = len(ordered)
+			}
+		case StageAuth:
+			if firstBody >= 0 {
+				logger.Warnw("auth filter is configured after body consuming filter, move it ahead", "filter", fmt.Sprintf("%T", *f))
+				deferred = append(deferred, f)
+				continue
+			}
+		}
+		ordered = append(ordered, f)
+	}
+	if len(deferred) == 0 {
+		return ordered
+	}
+
+	result := make([]*HttpFilterFactory, 0, len(factories))
+	result = append(result, ordered[:firstBody]...)
+	result = append(result, deferred...)
+	return append(result, ordered[firstBody:]...)
+}
+
+func stageOf(factory HttpFilterFactory) FilterStage {
+	if s, ok := factory.(HttpFilterStager); ok {
+		return s.Stage()
+	}
+	return StageDefault
 }
 
 // Apply return a new filter factory by name & conf
 func (fm *FilterManager) Apply(name string, conf map[string]interface{}) (HttpFilterFactory, error) {
-	plugin, err := GetHttpFilterPlugin(name)
+	return fm.apply(name, conf, fm.strict)
+}
+
+func (fm *FilterManager) apply(name string, conf map[string]interface{}, strict bool) (HttpFilterFactory, error) {
+	filter, err := fm.createFactory(name, conf, strict)
+	if err != nil {
+		return nil, err
+	}
+	err = filter.Apply()
+	if err != nil {
+		return nil, errors.Wrap(err, "create fail")
+	}
+	return filter, nil
+}
+
+// DryRun check the filter configs without applying them, the loaded filters of manager will not be changed.
+// It returns the first config error with the filter name, or nil if all filters are fine.
+func (fm *FilterManager) DryRun(filters []*model.HTTPFilter) error {
+	for _, f := range filters {
+		factory, err := fm.createFactory(f.Name, f.Config, fm.strictFor(f))
+		if err != nil {
+			return errors.Wrapf(err, "dry run [%s] fail", f.Name)
+		}
+		if v, ok := factory.(HttpFilterValidator); ok {
+			if err := v.Validate(); err != nil {
+				return errors.Wrapf(err, "dry run [%s] validate fail", f.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// createFactory create a filter factory by name and inject the conf into it, the unknown keys
+// of conf are rejected when strict
+func (fm *FilterManager) createFactory(name string, conf map[string]interface{}, strict bool) (HttpFilterFactory, error) {
+	plugin, err := fm.lookupPlugin(name)
 	if err != nil {
-		return nil, errors.New("filter not found")
+		return nil, err
 	}
 
+	// always create a fresh factory, so that the config is not shared between filters
 	filter, err := plugin.CreateFilterFactory()
 
 	if err != nil {
 		return nil, errors.New("plugin create filter error")
 	}
 
+	parse := yaml.ParseConfig
+	if strict {
+		parse = yaml.ParseConfigStrict
+	}
 	factoryConf := filter.Config()
-	if err := yaml.ParseConfig(factoryConf, conf); err != nil {
+	if err := parse(factoryConf, conf); err != nil {
 		return nil, errors.Wrap(err, "config error")
 	}
-	err = filter.Apply()
+	return filter, nil
+}
+
+// lookupPlugin get plugin by name from cache, resolve it from registry on the first call
+func (fm *FilterManager) lookupPlugin(name string) (HttpFilterPlugin, error) {
+	if plugin, ok := fm.plugins.Load(name); ok {
+		return plugin.(HttpFilterPlugin), nil
+	}
+	plugin, err := GetHttpFilterPlugin(name)
 	if err != nil {
-		return nil, errors.Wrap(err, "create fail")
+		return nil, err
 	}
-	return filter, nil
+	fm.plugins.Store(name, plugin)
+	return plugin, nil
 }
@@ -18,7 +18,12 @@
 package filter
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -38,8 +43,75 @@ const (
 	Kind = DEMO
 )
 
+const (
+	demoAuth = "dgp.filters.demo.auth"
+	demoBody = "dgp.filters.demo.body"
+)
+
 func init() {
 	RegisterHttpFilter(&Plugin{})
+	RegisterHttpFilter(&stagePlugin{kind: demoAuth, stage: StageAuth})
+	RegisterHttpFilter(&stagePlugin{kind: demoBody, stage: StageBody})
+}
+
+// stagePlugin create the demo auth filter rejecting the request without Authorization header,
+// or the demo body filter reading the whole request body
+type stagePlugin struct {
+	kind  string
+	stage FilterStage
+}
+
+func (p *stagePlugin) Kind() string {
+	return p.kind
+}
+
+func (p *stagePlugin) CreateFilterFactory() (HttpFilterFactory, error) {
+	return &stageFilterFactory{stage: p.stage}, nil
+}
+
+type stageFilterFactory struct {
+	stage FilterStage
+}
+
+func (f *stageFilterFactory) Config() interface{} {
+	return &Config{}
+}
+
+func (f *stageFilterFactory) Apply() error {
+	return nil
+}
+
+func (f *stageFilterFactory) Stage() FilterStage {
+	return f.stage
+}
+
+func (f *stageFilterFactory) PrepareFilterChain(ctx *contexthttp.HttpContext, chain FilterChain) error {
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *stageFilterFactory) Decode(ctx *contexthttp.HttpContext) FilterStatus {
+	if f.stage == StageAuth {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.StatusCode(http.StatusUnauthorized)
+			return Stop
+		}
+		return Continue
+	}
+	_, _ = ioutil.ReadAll(ctx.Request.Body)
+	return Continue
+}
+
+// countReader count the bytes read from the body
+type countReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
 }
 
 type (
@@ -103,6 +175,13 @@ func (f *DemoFilterFactory) Apply() error {
 	return nil
 }
 
+func (f *DemoFilterFactory) Validate() error {
+	if f.conf.Foo == "" {
+		return errors.New("foo is required")
+	}
+	return nil
+}
+
 func TestApply(t *testing.T) {
 	fm := NewEmptyFilterManager()
 
@@ -144,6 +223,371 @@ func TestLoad(t *testing.T) {
 	runFilter(t, fm, filtersConf)
 }
 
+func TestDryRun(t *testing.T) {
+	fm := NewEmptyFilterManager()
+
+	err := fm.DryRun([]*model.HTTPFilter{
+		{Name: DEMO, Config: map[string]interface{}{"foo": "Cat", "bar": "The Walnut"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(fm.GetFactory()))
+
+	err = fm.DryRun([]*model.HTTPFilter{
+		{Name: DEMO, Config: map[string]interface{}{"foo": ""}},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), DEMO)
+
+	err = fm.DryRun([]*model.HTTPFilter{
+		{Name: DEMO},
+		{Name: "dgp.filters.unknown"},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dgp.filters.unknown")
+}
+
+func TestGetFactoryFor(t *testing.T) {
+	fm := NewFilterManagerWithChains(
+		[]*model.HTTPFilter{{Name: DEMO, Config: map[string]interface{}{"foo": "default"}}},
+		[]*model.HTTPFilterChain{
+			{
+				Name:        "admin",
+				Match:       model.HTTPFilterChainMatch{Hosts: []string{"admin.pixiu.com"}},
+				HTTPFilters: []*model.HTTPFilter{{Name: DEMO, Config: map[string]interface{}{"foo": "admin"}}},
+			},
+			{
+				Name:        "public",
+				Match:       model.HTTPFilterChainMatch{Hosts: []string{"*.pixiu.com"}, Prefix: "/public"},
+				HTTPFilters: []*model.HTTPFilter{{Name: DEMO, Config: map[string]interface{}{"foo": "public"}}},
+			},
+		})
+	fm.Load()
+
+	tests := []struct {
+		host string
+		path string
+		foo  string
+	}{
+		{host: "admin.pixiu.com:8888", path: "/public/api", foo: "admin"},
+		{host: "www.pixiu.com", path: "/public/api", foo: "public"},
+		{host: "www.pixiu.com", path: "/private/api", foo: "default"},
+		{host: "www.dubbo.com", path: "/public/api", foo: "default"},
+	}
+	for _, tt := range tests {
+		factories := fm.GetFactoryFor(tt.host, tt.path)
+		assert.Equal(t, 1, len(factories))
+		assert.Equal(t, tt.foo, factories[0].Config().(*Config).Foo)
+	}
+}
+
+func TestReplaceChain(t *testing.T) {
+	demo := func(foo string) []*model.HTTPFilter {
+		return []*model.HTTPFilter{{Name: DEMO, Config: map[string]interface{}{"foo": foo}}}
+	}
+	fm := NewFilterManagerWithChains(demo("default"), []*model.HTTPFilterChain{
+		{Name: "admin", Match: model.HTTPFilterChainMatch{Hosts: []string{"admin.pixiu.com"}}, HTTPFilters: demo("admin")},
+		{Name: "public", Match: model.HTTPFilterChainMatch{Prefix: "/public"}, HTTPFilters: demo("public")},
+	})
+	assert.Nil(t, fm.Load())
+	foo := func(host, path string) string {
+		factories := fm.GetFactoryFor(host, path)
+		assert.Equal(t, 1, len(factories))
+		return factories[0].Config().(*Config).Foo
+	}
+
+	assert.Nil(t, fm.ReplaceChain("admin", demo("admin2")))
+	assert.Equal(t, "admin2", foo("admin.pixiu.com", "/"))
+	assert.Equal(t, "public", foo("www.pixiu.com", "/public/api"))
+	assert.Equal(t, "default", foo("www.pixiu.com", "/"))
+
+	// the old chain is kept when a filter fails to apply
+	err := fm.ReplaceChain("admin", append(demo("admin3"), &model.HTTPFilter{Name: "dgp.filters.demo.unknown"}))
+	assert.Error(t, err)
+	assert.Equal(t, "admin2", foo("admin.pixiu.com", "/"))
+
+	err = fm.ReplaceChain("unknown", demo("unknown"))
+	assert.True(t, errors.Is(err, ErrChainNotFound))
+}
+
+func TestChainOverrides(t *testing.T) {
+	demo := func(foo string) *model.HTTPFilter {
+		return &model.HTTPFilter{Name: DEMO, Config: map[string]interface{}{"foo": foo}}
+	}
+	fm := NewFilterManagerWithChains([]*model.HTTPFilter{demo("default"), {Name: demoBody}}, []*model.HTTPFilterChain{
+		{Name: "admin", Match: model.HTTPFilterChainMatch{Hosts: []string{"admin.pixiu.com"}}, Overrides: []*model.HTTPFilterOverride{
+			{Op: model.FilterOverrideInsert, After: DEMO, Filter: demo("admin")},
+			{Op: model.FilterOverrideRemove, Name: demoBody},
+			{Op: model.FilterOverrideAppend, Filter: &model.HTTPFilter{Name: demoAuth}},
+		}},
+	})
+	assert.Nil(t, fm.Load())
+	check := func(foo string) {
+		factories := fm.GetFactoryFor("admin.pixiu.com", "/")
+		assert.Equal(t, 3, len(factories))
+		assert.Equal(t, foo, factories[0].Config().(*Config).Foo)
+		assert.Equal(t, "admin", factories[1].Config().(*Config).Foo)
+		assert.Equal(t, StageAuth, stageOf(factories[2]))
+	}
+	check("default")
+	assert.Equal(t, 2, len(fm.GetFactoryFor("www.pixiu.com", "/")))
+
+	// the chain is recomposed from the reloaded default filters
+	assert.Nil(t, fm.ReLoad([]*model.HTTPFilter{demo("reloaded"), {Name: demoBody}}))
+	check("reloaded")
+
+	// the default filters are kept when the chain can not be composed from them
+	err := fm.ReLoad([]*model.HTTPFilter{{Name: demoBody}})
+	assert.True(t, errors.Is(err, ErrFilterNotFound))
+	assert.Equal(t, 2, len(fm.GetFactory()))
+	check("reloaded")
+
+	err = fm.ReLoadChains([]*model.HTTPFilterChain{
+		{Name: "admin", Overrides: []*model.HTTPFilterOverride{{Op: model.FilterOverrideRemove, Name: demoAuth}}},
+	})
+	assert.True(t, errors.Is(err, ErrFilterNotFound))
+	err = fm.ReLoadChains([]*model.HTTPFilterChain{
+		{Name: "admin", HTTPFilters: []*model.HTTPFilter{demo("admin")}, Overrides: []*model.HTTPFilterOverride{{Op: model.FilterOverrideRemove, Name: DEMO}}},
+	})
+	assert.Error(t, err)
+}
+
+func TestConfigMode(t *testing.T) {
+	typo := map[string]interface{}{"foo": "Cat", "barr": "Dog"}
+
+	// lenient by default, the unknown key is dropped
+	fm := NewEmptyFilterManager()
+	f, err := fm.Apply(DEMO, typo)
+	assert.Nil(t, err)
+	assert.Equal(t, "Cat", f.Config().(*Config).Foo)
+
+	fm.SetConfigMode(model.FilterConfigStrict)
+	_, err = fm.Apply(DEMO, typo)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "key barr")
+	assert.Nil(t, fm.DryRun([]*model.HTTPFilter{{Name: DEMO, Config: typo, ConfigMode: model.FilterConfigLenient}}))
+
+	// the filter overrides the default mode
+	fm.SetConfigMode(model.FilterConfigLenient)
+	_, err = fm.applyFilter(&model.HTTPFilter{Name: DEMO, Config: typo, ConfigMode: model.FilterConfigStrict})
+	assert.Error(t, err)
+	_, err = fm.applyFilter(&model.HTTPFilter{Name: DEMO, Config: typo})
+	assert.Nil(t, err)
+}
+
+func TestPluginCache(t *testing.T) {
+	fm := NewEmptyFilterManager()
+
+	f1, err := fm.Apply(DEMO, map[string]interface{}{"foo": "Cat"})
+	assert.Nil(t, err)
+	f2, err := fm.Apply(DEMO, map[string]interface{}{"foo": "Dog"})
+	assert.Nil(t, err)
+
+	_, cached := fm.plugins.Load(DEMO)
+	assert.True(t, cached)
+	// the plugin is reused, but each filter owns its config
+	assert.Equal(t, "Cat", f1.Config().(*Config).Foo)
+	assert.Equal(t, "Dog", f2.Config().(*Config).Foo)
+
+	_, err = fm.Apply("dgp.filters.unknown", nil)
+	assert.True(t, errors.Is(err, ErrPluginNotFound))
+	_, cached = fm.plugins.Load("dgp.filters.unknown")
+	assert.False(t, cached)
+}
+
+func TestGetFactoryCopy(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	fm.ReLoad([]*model.HTTPFilter{{Name: DEMO}, {Name: demoAuth}})
+
+	factories := fm.GetFactory()
+	first := factories[0]
+	factories[0] = nil
+	assert.Equal(t, first, fm.GetFactory()[0])
+
+	// the held slice is not affected by reload
+	fm.ReLoad([]*model.HTTPFilter{{Name: demoBody}})
+	assert.Equal(t, 2, len(factories))
+	assert.Equal(t, 1, len(fm.GetFactory()))
+	assert.Equal(t, StageAuth, stageOf(factories[1]))
+}
+
+func TestAuthBeforeBodyRead(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	// misconfigured order, the auth filter should be moved before the body filter
+	fm.ReLoad([]*model.HTTPFilter{{Name: DEMO}, {Name: demoBody}, {Name: demoAuth}})
+
+	factories := fm.GetFactory()
+	assert.Equal(t, 3, len(factories))
+	assert.Equal(t, StageDefault, stageOf(factories[0]))
+	assert.Equal(t, StageAuth, stageOf(factories[1]))
+	assert.Equal(t, StageBody, stageOf(factories[2]))
+
+	run := func(authorization string) (*contexthttp.HttpContext, *countReader) {
+		body := &countReader{r: strings.NewReader(strings.Repeat("x", 1<<20))}
+		request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/upload", body)
+		assert.NoError(t, err)
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		ctx := &contexthttp.HttpContext{Request: request}
+		ctx.Reset()
+		fm.CreateFilterChain(ctx).OnDecode(ctx)
+		return ctx, body
+	}
+
+	ctx, body := run("")
+	assert.Equal(t, http.StatusUnauthorized, ctx.GetStatusCode())
+	assert.Equal(t, 0, body.read)
+
+	_, body = run("Bearer token")
+	assert.Equal(t, 1<<20, body.read)
+}
+
+func TestReLoadReuseUnchanged(t *testing.T) {
+	fm := NewFilterManagerWithChains(
+		[]*model.HTTPFilter{
+			{Name: DEMO, Config: map[string]interface{}{"foo": "Cat"}},
+			{Name: demoAuth},
+		},
+		[]*model.HTTPFilterChain{
+			{Name: "admin", HTTPFilters: []*model.HTTPFilter{{Name: DEMO, Config: map[string]interface{}{"foo": "admin"}}}},
+		})
+	assert.Nil(t, fm.Load())
+	demo, auth := fm.filters[DEMO], fm.filters[demoAuth]
+	admin := *fm.chains[0].filtersArray[0]
+
+	// only the config of the demo filter is changed
+	conf := map[string]interface{}{"foo": "Dog"}
+	assert.Nil(t, fm.ReLoad([]*model.HTTPFilter{{Name: DEMO, Config: conf}, {Name: demoAuth}}))
+	assert.True(t, auth == fm.filters[demoAuth])
+	assert.False(t, demo == fm.filters[DEMO])
+	assert.Equal(t, "Dog", fm.filters[DEMO].Config().(*Config).Foo)
+	assert.True(t, admin == *fm.chains[0].filtersArray[0])
+
+	// the config mutated in place is detected as well
+	demo = fm.filters[DEMO]
+	conf["foo"] = "Bird"
+	assert.Nil(t, fm.ReLoad([]*model.HTTPFilter{{Name: DEMO, Config: conf}, {Name: demoAuth}}))
+	assert.False(t, demo == fm.filters[DEMO])
+	assert.Equal(t, "Bird", fm.filters[DEMO].Config().(*Config).Foo)
+	assert.True(t, auth == fm.filters[demoAuth])
+}
+
+func TestReLoadKeepLoaded(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	assert.Nil(t, fm.ReLoad([]*model.HTTPFilter{{Name: DEMO, Config: map[string]interface{}{"foo": "Cat"}}}))
+	demo := fm.filters[DEMO]
+
+	// the loaded filters are kept when any filter fails to apply
+	err := fm.ReLoad([]*model.HTTPFilter{
+		{Name: DEMO, Config: map[string]interface{}{"foo": "Dog"}},
+		{Name: "dgp.filters.demo.unknown"},
+	})
+	assert.Error(t, err)
+	factories := fm.GetFactory()
+	assert.Equal(t, 1, len(factories))
+	assert.True(t, demo == factories[0])
+	assert.Equal(t, "Cat", factories[0].Config().(*Config).Foo)
+	assert.Equal(t, DEMO, fm.filterConfigs[0].Name)
+	assert.Equal(t, 1, len(fm.filterConfigs))
+}
+
+func TestPatchFilter(t *testing.T) {
+	fm := NewFilterManager([]*model.HTTPFilter{
+		{Name: DEMO, Config: map[string]interface{}{"foo": "Cat"}},
+		{Name: demoAuth},
+	})
+	assert.Nil(t, fm.Load())
+	auth := fm.filters[demoAuth]
+
+	assert.Nil(t, fm.PatchFilter(DEMO, map[string]interface{}{"foo": "Dog"}))
+	demo, ok := fm.GetFilterByName(DEMO)
+	assert.True(t, ok)
+	assert.Equal(t, "Dog", demo.Config().(*Config).Foo)
+	assert.True(t, auth == fm.filters[demoAuth])
+	assert.Equal(t, 2, len(fm.GetFactory()))
+
+	// the invalid config is rejected and the patched filter is kept
+	assert.Error(t, fm.PatchFilter(DEMO, map[string]interface{}{"foo": map[string]interface{}{"a": "b"}}))
+	current, _ := fm.GetFilterByName(DEMO)
+	assert.True(t, demo == current)
+
+	err := fm.PatchFilter("dgp.filters.unknown", map[string]interface{}{})
+	assert.True(t, errors.Is(err, ErrFilterNotFound))
+
+	// the following reload keeps the patched filter as long as its config is the same
+	assert.Nil(t, fm.ReLoad(fm.filterConfigs))
+	current, _ = fm.GetFilterByName(DEMO)
+	assert.True(t, demo == current)
+}
+
+func TestPatchChainFilter(t *testing.T) {
+	demo := func(foo string) *model.HTTPFilter {
+		return &model.HTTPFilter{Name: DEMO, Config: map[string]interface{}{"foo": foo}}
+	}
+	fm := NewFilterManagerWithChains([]*model.HTTPFilter{demo("default")}, []*model.HTTPFilterChain{
+		{Name: "admin", Match: model.HTTPFilterChainMatch{Hosts: []string{"admin.pixiu.com"}}, HTTPFilters: []*model.HTTPFilter{demo("admin"), {Name: demoAuth}}},
+		{Name: "public", Match: model.HTTPFilterChainMatch{Prefix: "/public"}, Overrides: []*model.HTTPFilterOverride{
+			{Op: model.FilterOverrideAppend, Filter: &model.HTTPFilter{Name: demoAuth}},
+		}},
+	})
+	assert.Nil(t, fm.Load())
+	auth := fm.GetFactoryFor("admin.pixiu.com", "/")[1]
+
+	assert.Nil(t, fm.PatchChainFilter("admin", DEMO, map[string]interface{}{"foo": "admin2"}))
+	factories := fm.GetFactoryFor("admin.pixiu.com", "/")
+	assert.Equal(t, "admin2", factories[0].Config().(*Config).Foo)
+	assert.True(t, auth == factories[1])
+	// the default filter of the name is untouched
+	assert.Equal(t, "default", fm.GetFactory()[0].Config().(*Config).Foo)
+
+	// the invalid config is rejected and the chain is kept
+	assert.Error(t, fm.PatchChainFilter("admin", DEMO, map[string]interface{}{"foo": map[string]interface{}{"a": "b"}}))
+	assert.Equal(t, "admin2", fm.GetFactoryFor("admin.pixiu.com", "/")[0].Config().(*Config).Foo)
+
+	err := fm.PatchChainFilter("admin", "dgp.filters.unknown", map[string]interface{}{})
+	assert.True(t, errors.Is(err, ErrFilterNotFound))
+	err = fm.PatchChainFilter("unknown", DEMO, map[string]interface{}{})
+	assert.True(t, errors.Is(err, ErrChainNotFound))
+	// the chain composed by overrides follows the default filters
+	assert.Error(t, fm.PatchChainFilter("public", DEMO, map[string]interface{}{"foo": "public"}))
+}
+
+var benchFilters = []*model.HTTPFilter{
+	{Name: DEMO, Config: map[string]interface{}{"foo": "Cat", "bar": "The Walnut"}},
+	{Name: DEMO, Config: map[string]interface{}{"foo": "Dog", "bar": "The Toilet"}},
+}
+
+// benchFiltersChanged the configs of benchFilters changed, so that the reload applies every filter again
+// instead of reusing the applied ones
+var benchFiltersChanged = []*model.HTTPFilter{
+	{Name: DEMO, Config: map[string]interface{}{"foo": "Bird", "bar": "The Walnut"}},
+	{Name: DEMO, Config: map[string]interface{}{"foo": "Fish", "bar": "The Toilet"}},
+}
+
+// BenchmarkReLoad reload the changed filters with the warm plugin cache of a long-living manager,
+// compare with BenchmarkReLoadColdCache to see the saving of plugin lookups:
+// go test -run ^$ -bench ReLoad -benchmem ./pkg/common/extension/filter
+func BenchmarkReLoad(b *testing.B) {
+	fm := NewEmptyFilterManager()
+	fm.ReLoad(benchFilters)
+	configs := [][]*model.HTTPFilter{benchFiltersChanged, benchFilters}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// alternate the configs, the same configs would be reused without applying nor looking up
+		fm.ReLoad(configs[i%2])
+	}
+}
+
+// BenchmarkReLoadColdCache reload filters with a new manager each time, so every plugin is resolved from registry
+func BenchmarkReLoadColdCache(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewEmptyFilterManager().ReLoad(benchFilters)
+	}
+}
+
 func runFilter(t *testing.T, fm *FilterManager, filtersConf []*model.HTTPFilter) {
 	fm.ReLoad(filtersConf)
 
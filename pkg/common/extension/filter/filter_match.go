@@ -0,0 +1,52 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// matchFactory wrap the filter factory with the compiled predicate, the filter is not added to
+// the chain of the unmatched requests
+type matchFactory struct {
+	HttpFilterFactory
+	match *model.HTTPFilterMatch
+}
+
+// Stage delegate to the wrapped factory
+func (f *matchFactory) Stage() FilterStage {
+	return stageOf(f.HttpFilterFactory)
+}
+
+// OrderHint delegate to the wrapped factory
+func (f *matchFactory) OrderHint() OrderHint {
+	return orderHintOf(f.HttpFilterFactory)
+}
+
+// Close delegate to the wrapped factory
+func (f *matchFactory) Close() error {
+	return closeFactory(f.HttpFilterFactory)
+}
+
+func (f *matchFactory) PrepareFilterChain(ctx *http.HttpContext, chain FilterChain) error {
+	if ctx.Request != nil && !f.match.Match(ctx.Request) {
+		return nil
+	}
+	return f.HttpFilterFactory.PrepareFilterChain(ctx, chain)
+}
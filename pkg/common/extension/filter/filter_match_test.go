@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestFilterMatch(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	fm.ReLoad([]*model.HTTPFilter{{
+		Name: demoAuth,
+		Match: &model.HTTPFilterMatch{
+			Prefix:  "/admin/",
+			Methods: []string{"POST", "delete"},
+			Headers: []*model.HeaderMatcher{{Name: "X-Env", Values: []string{"^prod", "^staging$"}, Regex: true}},
+		},
+	}})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		env    string
+		status int
+	}{
+		// the demo auth filter reply 401 to the request without Authorization header
+		{name: "matched", method: "POST", path: "/admin/users", env: "prod-1", status: http.StatusUnauthorized},
+		{name: "method case insensitive", method: "DELETE", path: "/admin/users", env: "staging", status: http.StatusUnauthorized},
+		{name: "path not matched", method: "POST", path: "/public/users", env: "prod-1"},
+		{name: "method not matched", method: "GET", path: "/admin/users", env: "prod-1"},
+		{name: "header not matched", method: "POST", path: "/admin/users", env: "dev"},
+		{name: "header absent", method: "POST", path: "/admin/users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, "http://www.dubbogopixiu.com"+tt.path, nil)
+			assert.NoError(t, err)
+			if tt.env != "" {
+				request.Header.Set("X-Env", tt.env)
+			}
+			ctx := &contexthttp.HttpContext{Request: request}
+			ctx.Reset()
+			fm.CreateFilterChain(ctx).OnDecode(ctx)
+			assert.Equal(t, tt.status, ctx.GetStatusCode())
+		})
+	}
+
+	// the stage of the wrapped factory is kept
+	assert.Equal(t, StageAuth, stageOf(fm.GetFactory()[0]))
+}
+
+func TestFilterMatchInvalid(t *testing.T) {
+	m := &model.HTTPFilterMatch{Headers: []*model.HeaderMatcher{{Name: "X-Env", Values: []string{"("}, Regex: true}}}
+	assert.Error(t, m.Compile())
+
+	fm := NewEmptyFilterManager()
+	// never apply the filter without its predicate
+	assert.Error(t, fm.ReLoad([]*model.HTTPFilter{{Name: demoAuth, Match: m}}))
+	assert.Empty(t, fm.GetFactory())
+}
@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+func orderHintOf(factory HttpFilterFactory) OrderHint {
+	if o, ok := factory.(HttpFilterOrderer); ok {
+		return o.OrderHint()
+	}
+	return OrderHint{}
+}
+
+// orderByHint reorder the filters to satisfy the order hints with the least movement, the filters without
+// constraint keep their relative order. It returns error naming the filters if the hints conflict.
+func orderByHint(factories []*HttpFilterFactory, names map[*HttpFilterFactory]string) ([]*HttpFilterFactory, error) {
+	n := len(factories)
+	// edges[i][j] means filter i must run before filter j
+	edges := make([][]bool, n)
+	indegree := make([]int, n)
+	hinted := false
+	for i := range factories {
+		edges[i] = make([]bool, n)
+	}
+	addEdge := func(from, to int) {
+		if from != to && !edges[from][to] {
+			edges[from][to] = true
+			indegree[to]++
+			hinted = true
+		}
+	}
+	for i, f := range factories {
+		if *f == nil {
+			continue
+		}
+		hint := orderHintOf(*f)
+		for j, other := range factories {
+			name := names[other]
+			if contains(hint.Before, name) {
+				addEdge(i, j)
+			}
+			if contains(hint.After, name) {
+				addEdge(j, i)
+			}
+		}
+	}
+	if !hinted {
+		return factories, nil
+	}
+
+	ordered := make([]*HttpFilterFactory, 0, n)
+	done := make([]bool, n)
+	for len(ordered) < n {
+		next := -1
+		for i := range factories {
+			if !done[i] && indegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, conflictError(factories, names, edges, done)
+		}
+		done[next] = true
+		ordered = append(ordered, factories[next])
+		for j := range factories {
+			if edges[next][j] {
+				indegree[j]--
+			}
+		}
+	}
+	for i := range ordered {
+		if ordered[i] != factories[i] {
+			logger.Warnw("filters are reordered by the order hints", "order", filterNames(ordered, names))
+			break
+		}
+	}
+	return ordered, nil
+}
+
+func filterNames(factories []*HttpFilterFactory, names map[*HttpFilterFactory]string) string {
+	list := make([]string, 0, len(factories))
+	for _, f := range factories {
+		list = append(list, names[f])
+	}
+	return strings.Join(list, ", ")
+}
+
+func conflictError(factories []*HttpFilterFactory, names map[*HttpFilterFactory]string, edges [][]bool, done []bool) error {
+	var conflicts []string
+	for i := range factories {
+		for j := range factories {
+			if !done[i] && !done[j] && edges[i][j] {
+				conflicts = append(conflicts, fmt.Sprintf("%s before %s", names[factories[i]], names[factories[j]]))
+			}
+		}
+	}
+	return errors.Errorf("impossible filter order: %s", strings.Join(conflicts, ", "))
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
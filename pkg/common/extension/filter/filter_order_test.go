@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	demoBreaker = "dgp.filters.demo.breaker"
+	demoRetry   = "dgp.filters.demo.retry"
+	demoGreedy  = "dgp.filters.demo.greedy"
+)
+
+func init() {
+	RegisterHttpFilter(&orderPlugin{kind: demoBreaker, hint: OrderHint{Before: []string{demoRetry}}})
+	RegisterHttpFilter(&orderPlugin{kind: demoRetry})
+	RegisterHttpFilter(&orderPlugin{kind: demoGreedy, hint: OrderHint{Before: []string{demoBreaker}, After: []string{demoRetry}}})
+}
+
+// orderPlugin create the demo filter declaring the order hint
+type orderPlugin struct {
+	kind string
+	hint OrderHint
+}
+
+func (p *orderPlugin) Kind() string {
+	return p.kind
+}
+
+func (p *orderPlugin) CreateFilterFactory() (HttpFilterFactory, error) {
+	return &orderFilterFactory{kind: p.kind, hint: p.hint}, nil
+}
+
+type orderFilterFactory struct {
+	kind string
+	hint OrderHint
+}
+
+func (f *orderFilterFactory) Config() interface{} {
+	return &Config{}
+}
+
+func (f *orderFilterFactory) Apply() error {
+	return nil
+}
+
+func (f *orderFilterFactory) OrderHint() OrderHint {
+	return f.hint
+}
+
+func (f *orderFilterFactory) PrepareFilterChain(ctx *contexthttp.HttpContext, chain FilterChain) error {
+	return nil
+}
+
+func kinds(factories []HttpFilterFactory) []string {
+	list := make([]string, 0, len(factories))
+	for _, f := range factories {
+		inner := f.(*recoverFactory).HttpFilterFactory
+		if o, ok := inner.(*orderFilterFactory); ok {
+			list = append(list, o.kind)
+		} else {
+			list = append(list, DEMO)
+		}
+	}
+	return list
+}
+
+func TestOrderByHint(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	// the retry is configured outside the breaker, it should be moved after the breaker
+	err := fm.ReLoad([]*model.HTTPFilter{{Name: demoRetry}, {Name: DEMO}, {Name: demoBreaker}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{DEMO, demoBreaker, demoRetry}, kinds(fm.GetFactory()))
+
+	// the absent filter in hint is ignored
+	err = fm.ReLoad([]*model.HTTPFilter{{Name: DEMO}, {Name: demoBreaker}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{DEMO, demoBreaker}, kinds(fm.GetFactory()))
+}
+
+func TestOrderByHintConflict(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	assert.Nil(t, fm.ReLoad([]*model.HTTPFilter{{Name: demoBreaker}, {Name: demoRetry}}))
+
+	err := fm.ReLoad([]*model.HTTPFilter{{Name: demoBreaker}, {Name: demoRetry}, {Name: demoGreedy}})
+	assert.EqualError(t, err, "impossible filter order: "+
+		"dgp.filters.demo.breaker before dgp.filters.demo.retry, "+
+		"dgp.filters.demo.retry before dgp.filters.demo.greedy, "+
+		"dgp.filters.demo.greedy before dgp.filters.demo.breaker")
+	// the loaded filters are kept
+	assert.Equal(t, []string{demoBreaker, demoRetry}, kinds(fm.GetFactory()))
+
+	fm = NewFilterManagerWithChains(nil, []*model.HTTPFilterChain{{
+		Name:        "api",
+		HTTPFilters: []*model.HTTPFilter{{Name: demoGreedy}, {Name: demoBreaker}, {Name: demoRetry}},
+	}})
+	assert.Error(t, fm.Load())
+}
@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// chainFilters the filters of the chain, composed from the default filters if the chain has overrides
+func chainFilters(c *model.HTTPFilterChain, base []*model.HTTPFilter) ([]*model.HTTPFilter, error) {
+	if len(c.Overrides) == 0 {
+		return c.HTTPFilters, nil
+	}
+	if len(c.HTTPFilters) > 0 {
+		return nil, errors.New("http_filters and overrides can not be both set")
+	}
+	return composeFilters(base, c.Overrides)
+}
+
+// composeFilters apply the overrides to the default filters in order, the default filters are not modified
+func composeFilters(base []*model.HTTPFilter, overrides []*model.HTTPFilterOverride) ([]*model.HTTPFilter, error) {
+	filters := append([]*model.HTTPFilter(nil), base...)
+	for i, o := range overrides {
+		switch o.Op {
+		case model.FilterOverrideAppend:
+			if o.Filter == nil {
+				return nil, errors.Errorf("override %d: append requires filter", i)
+			}
+			filters = append(filters, o.Filter)
+		case model.FilterOverrideInsert:
+			if o.Filter == nil || (o.Before == "") == (o.After == "") {
+				return nil, errors.Errorf("override %d: insert requires filter and one of before and after", i)
+			}
+			target := o.Before
+			if target == "" {
+				target = o.After
+			}
+			index := indexOfFilter(filters, target)
+			if index < 0 {
+				return nil, errors.Wrapf(ErrFilterNotFound, "override %d: insert at http filter %s", i, target)
+			}
+			if o.After != "" {
+				index++
+			}
+			filters = append(filters[:index], append([]*model.HTTPFilter{o.Filter}, filters[index:]...)...)
+		case model.FilterOverrideRemove:
+			kept := filters[:0]
+			for _, f := range filters {
+				if f.Name != o.Name {
+					kept = append(kept, f)
+				}
+			}
+			if len(kept) == len(filters) {
+				return nil, errors.Wrapf(ErrFilterNotFound, "override %d: remove http filter %s", i, o.Name)
+			}
+			filters = kept
+		default:
+			return nil, errors.Errorf("override %d: unknown op %q, it should be append, insert or remove", i, o.Op)
+		}
+	}
+	return filters, nil
+}
+
+func indexOfFilter(filters []*model.HTTPFilter, name string) int {
+	for i, f := range filters {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,146 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"sort"
+	"sync"
+)
+
+// StatsFunc return a snapshot of the statistics of a filter, it is encoded as json
+type StatsFunc func() interface{}
+
+var (
+	statsMu    sync.RWMutex
+	statsFuncs = make(map[string]StatsFunc)
+)
+
+// RegisterStats register the statistics by name, e.g. the quota usage, they are served by the admin filter
+// instead of any listener, it should be called in init
+func RegisterStats(name string, fn StatsFunc) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	statsFuncs[name] = fn
+}
+
+// CollectStats return the snapshot of the registered statistics of the name, false if it is not registered
+func CollectStats(name string) (interface{}, bool) {
+	statsMu.RLock()
+	fn, ok := statsFuncs[name]
+	statsMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return fn(), true
+}
+
+// ListStats return the names of the registered statistics in order
+func ListStats() []string {
+	statsMu.RLock()
+	defer statsMu.RUnlock()
+	names := make([]string, 0, len(statsFuncs))
+	for name := range statsFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReloadStats the reload statistics of all filter managers
+type ReloadStats struct {
+	// Reloads the reloads of the default filters and the named chains, including the first load
+	Reloads int64
+	// Failures the reloads failed or having any filter failed to apply
+	Failures int64
+	// ApplyFailures the apply failures by filter name
+	ApplyFailures map[string]int64
+	// Loaded the filters loaded currently
+	Loaded int64
+}
+
+// reloadStats the counters shared by all filter managers, the manager is dropped from the loaded when closed
+var reloadStats = &filterReloadStats{applyFailures: make(map[string]int64), loaded: make(map[*FilterManager]int64)}
+
+type filterReloadStats struct {
+	mu            sync.Mutex
+	reloads       int64
+	failures      int64
+	applyFailures map[string]int64
+	loaded        map[*FilterManager]int64
+}
+
+// CollectReloadStats return a snapshot of the reload statistics
+func CollectReloadStats() ReloadStats {
+	reloadStats.mu.Lock()
+	defer reloadStats.mu.Unlock()
+	s := ReloadStats{
+		Reloads:       reloadStats.reloads,
+		Failures:      reloadStats.failures,
+		ApplyFailures: make(map[string]int64, len(reloadStats.applyFailures)),
+	}
+	for name, n := range reloadStats.applyFailures {
+		s.ApplyFailures[name] = n
+	}
+	for _, n := range reloadStats.loaded {
+		s.Loaded += n
+	}
+	return s
+}
+
+func (s *filterReloadStats) reloaded(failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reloads++
+	if failed {
+		s.failures++
+	}
+}
+
+func (s *filterReloadStats) applyFailed(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applyFailures[name]++
+}
+
+// setLoaded keep the filters loaded by the manager, the caller must hold the lock of the manager
+func (s *filterReloadStats) setLoaded(fm *FilterManager) {
+	n := countLoaded(fm.filtersArray)
+	for _, c := range fm.chains {
+		n += countLoaded(c.filtersArray)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loaded[fm] = n
+}
+
+// dropLoaded forget the filters loaded by the closed manager, so that it is not referenced any more
+func (s *filterReloadStats) dropLoaded(fm *FilterManager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.loaded, fm)
+}
+
+func countLoaded(factories []*HttpFilterFactory) int64 {
+	var n int64
+	for _, f := range factories {
+		if f != nil && *f != nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestReloadStats(t *testing.T) {
+	const unknown = "dgp.filters.demo.unknown"
+	fm := NewEmptyFilterManager()
+	before := CollectReloadStats()
+
+	assert.Nil(t, fm.ReLoad([]*model.HTTPFilter{{Name: DEMO}, {Name: demoAuth}}))
+	s := CollectReloadStats()
+	assert.Equal(t, before.Reloads+1, s.Reloads)
+	assert.Equal(t, before.Failures, s.Failures)
+	assert.Equal(t, before.Loaded+2, s.Loaded)
+
+	// the unknown filter fails to apply, the loaded filters are kept
+	assert.Error(t, fm.ReLoad([]*model.HTTPFilter{{Name: DEMO}, {Name: unknown}}))
+	s = CollectReloadStats()
+	assert.Equal(t, before.Reloads+2, s.Reloads)
+	assert.Equal(t, before.Failures+1, s.Failures)
+	assert.Equal(t, before.ApplyFailures[unknown]+1, s.ApplyFailures[unknown])
+	assert.Equal(t, before.Loaded+2, s.Loaded)
+
+	assert.Error(t, fm.ReLoadChains([]*model.HTTPFilterChain{{Name: "api", HTTPFilters: []*model.HTTPFilter{{Name: unknown}}}}))
+	s = CollectReloadStats()
+	assert.Equal(t, before.Reloads+3, s.Reloads)
+	assert.Equal(t, before.Failures+2, s.Failures)
+	assert.Equal(t, before.ApplyFailures[unknown]+2, s.ApplyFailures[unknown])
+}
+
+func TestReloadStatsClose(t *testing.T) {
+	fm := NewFilterManagerWithChains(closerFilters("v1"), []*model.HTTPFilterChain{{Name: "api", HTTPFilters: []*model.HTTPFilter{{Name: DEMO}}}})
+	before := CollectReloadStats()
+	assert.Nil(t, fm.Load())
+	assert.Equal(t, before.Loaded+2, CollectReloadStats().Loaded)
+	closer := closerOf(fm)
+
+	// the closed manager is not counted, and its filters are closed after drained
+	assert.Nil(t, fm.Close())
+	assert.Equal(t, before.Loaded, CollectReloadStats().Loaded)
+	assert.Nil(t, fm.WaitForDrain(time.Second))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&closer.closed))
+	assert.Empty(t, fm.GetFactory())
+}
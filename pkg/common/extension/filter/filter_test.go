@@ -0,0 +1,41 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListHttpFilterPlugins(t *testing.T) {
+	assert.Contains(t, ListHttpFilterPlugins(), DEMO)
+}
+
+func TestGetHttpFilterPluginNotFound(t *testing.T) {
+	_, err := GetHttpFilterPlugin("dgp.filters.unknown")
+	assert.True(t, errors.Is(err, ErrPluginNotFound))
+	assert.Contains(t, err.Error(), "dgp.filters.unknown")
+
+	_, err = NewEmptyFilterManager().Apply("dgp.filters.unknown", nil)
+	assert.True(t, errors.Is(err, ErrPluginNotFound))
+}
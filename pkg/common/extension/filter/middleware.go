@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	stdHttp "net/http"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// Middleware wrap the handler with the loaded filters, so that they can be embedded in a net/http server
+// without the listener stack. The decode filters run before the handler and the encode filters after it,
+// the handler is skipped when a filter stops the chain or replies itself, e.g. by setting the SourceResp.
+// The response of the handler is buffered for the encode filters, so streaming and hijacking are not supported.
+func (fm *FilterManager) Middleware(next stdHttp.Handler) stdHttp.Handler {
+	return stdHttp.HandlerFunc(func(w stdHttp.ResponseWriter, r *stdHttp.Request) {
+		hc := &http.HttpContext{Writer: w, Request: r, Params: make(map[string]interface{})}
+		hc.Reset()
+		hc.Ctx = context.Background()
+		fm.serve(hc, next)
+	})
+}
+
+func (fm *FilterManager) serve(hc *http.HttpContext, next stdHttp.Handler) {
+	chain := fm.CreateFilterChain(hc)
+	defer fm.ReleaseFilterChain(chain)
+
+	defer func() {
+		if err := recover(); err != nil {
+			logger.Warnw("filter middleware panic", "panic", fmt.Sprintf("%v", err))
+			hc.SendLocalReply(stdHttp.StatusInternalServerError, []byte(fmt.Sprintf("Occur An Unexpected Err: %v", err)))
+		}
+	}()
+
+	chain.OnDecode(hc)
+	if !hc.LocalReply() {
+		switch {
+		case hc.SourceResp != nil:
+			sourceResponse(hc)
+		case stopped(chain):
+			hc.TargetResp = &client.Response{}
+		default:
+			handle(hc, next)
+		}
+	}
+	chain.OnEncode(hc)
+
+	if hc.LocalReply() {
+		return
+	}
+	status := hc.GetStatusCode()
+	if status == 0 {
+		status = stdHttp.StatusOK
+	}
+	hc.Writer.WriteHeader(status)
+	if _, err := hc.Writer.Write(hc.TargetResp.Data); err != nil {
+		logger.Warnw("filter middleware write response fail", "url", hc.GetUrl(), "error", err.Error())
+	}
+}
+
+// stopped whether a decode filter stopped the chain
+func stopped(chain FilterChain) bool {
+	c, ok := chain.(*defaultFilterChain)
+	return ok && c.decodeFiltersIndex < len(c.decodeFilters)
+}
+
+// handle call the handler with the decoded request, its response is kept for the encode filters
+func handle(hc *http.HttpContext, next stdHttp.Handler) {
+	buf := &bufferedWriter{header: stdHttp.Header{}}
+	next.ServeHTTP(buf, hc.Request)
+	header := hc.Writer.Header()
+	for k, v := range buf.header {
+		header[k] = v
+	}
+	if buf.status == 0 {
+		buf.status = stdHttp.StatusOK
+	}
+	hc.StatusCode(buf.status)
+	hc.TargetResp = &client.Response{Data: buf.body.Bytes()}
+}
+
+// sourceResponse translate the response replied by a filter, only the http response and bytes are supported
+func sourceResponse(hc *http.HttpContext) {
+	switch res := hc.SourceResp.(type) {
+	case *stdHttp.Response:
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			hc.SendLocalReply(stdHttp.StatusBadGateway, []byte(fmt.Sprintf("read response body fail: %v", err)))
+			return
+		}
+		header := hc.Writer.Header()
+		for k, v := range res.Header {
+			header[k] = v
+		}
+		hc.StatusCode(res.StatusCode)
+		hc.TargetResp = &client.Response{Data: body}
+	case []byte:
+		hc.StatusCode(stdHttp.StatusOK)
+		hc.AddHeader(constant.HeaderKeyContextType, constant.HeaderValueTextPlain)
+		hc.TargetResp = &client.Response{Data: res}
+	default:
+		hc.SendLocalReply(stdHttp.StatusInternalServerError, []byte(fmt.Sprintf("unsupported response %T", res)))
+	}
+}
+
+// bufferedWriter keep the response of the wrapped handler
+type bufferedWriter struct {
+	header stdHttp.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() stdHttp.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = stdHttp.StatusOK
+	}
+	return w.body.Write(b)
+}
@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const demoUpper = "dgp.filters.demo.upper"
+
+func init() {
+	RegisterHttpFilter(&upperPlugin{})
+}
+
+// upperPlugin create the demo filter upper casing the response body
+type upperPlugin struct{}
+
+func (p *upperPlugin) Kind() string {
+	return demoUpper
+}
+
+func (p *upperPlugin) CreateFilterFactory() (HttpFilterFactory, error) {
+	return &upperFilter{}, nil
+}
+
+type upperFilter struct{}
+
+func (f *upperFilter) Config() interface{} {
+	return &Config{}
+}
+
+func (f *upperFilter) Apply() error {
+	return nil
+}
+
+func (f *upperFilter) PrepareFilterChain(ctx *contexthttp.HttpContext, chain FilterChain) error {
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+func (f *upperFilter) Encode(ctx *contexthttp.HttpContext) FilterStatus {
+	ctx.TargetResp.Data = bytes.ToUpper(ctx.TargetResp.Data)
+	return Continue
+}
+
+func TestMiddleware(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	assert.Nil(t, fm.ReLoad([]*model.HTTPFilter{{Name: demoAuth}, {Name: demoUpper}}))
+
+	called := 0
+	handler := fm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+		w.Header().Set("X-Handler", "yes")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+
+	// the auth filter stops the chain, the handler is skipped
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/student", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, 0, called)
+
+	rec = httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/api/v1/student", nil)
+	request.Header.Set("Authorization", "Bearer token")
+	handler.ServeHTTP(rec, request)
+	assert.Equal(t, 1, called)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "yes", rec.Header().Get("X-Handler"))
+	assert.Equal(t, "CREATED", rec.Body.String())
+}
+
+func TestMiddlewareSourceResponse(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	handler := fm.Middleware(http.NotFoundHandler())
+
+	hc := &contexthttp.HttpContext{Writer: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/", nil)}
+	hc.Reset()
+	hc.SourceResp = []byte("cached")
+	sourceResponse(hc)
+	assert.Equal(t, http.StatusOK, hc.GetStatusCode())
+	assert.Equal(t, "cached", string(hc.TargetResp.Data))
+
+	// no filter loaded, the handler replies directly
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// LoadPlugins open the go plugin .so files in the dir, each plugin registers its filters by RegisterHttpFilter
+// in its init, so that they can be configured by kind as the built-in filters. It should be called before the
+// filters are loaded. A plugin failing to open, e.g. built with different versions of the shared packages,
+// is skipped. It returns the kinds registered by the loaded plugins.
+func LoadPlugins(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read plugin dir %s fail", dir)
+	}
+	var kinds []string
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".so" {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		registered, err := openPlugin(path)
+		if err != nil {
+			logger.Warnw("skip filter plugin", "path", path, "error", err.Error())
+			continue
+		}
+		logger.Infow("filter plugin is loaded", "path", path, "filters", registered)
+		kinds = append(kinds, registered...)
+	}
+	sort.Strings(kinds)
+	return kinds, nil
+}
+
+// openPlugin open the plugin and return the kinds it registers, a panic in the plugin init like
+// registering a kind twice is returned as error
+func openPlugin(path string) (registered []string, err error) {
+	before := make(map[string]bool)
+	for _, kind := range ListHttpFilterPlugins() {
+		before[kind] = true
+	}
+	defer func() {
+		if re := recover(); re != nil {
+			err = fmt.Errorf("plugin init panic: %v", re)
+		}
+	}()
+
+	if _, err := plugin.Open(path); err != nil {
+		if strings.Contains(err.Error(), "different version of package") {
+			return nil, errors.Wrap(err, "plugin is built with different versions of the packages shared with pixiu, rebuild it with the same pixiu version")
+		}
+		return nil, err
+	}
+	for _, kind := range ListHttpFilterPlugins() {
+		if !before[kind] {
+			registered = append(registered, kind)
+		}
+	}
+	return registered, nil
+}
@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPlugins(t *testing.T) {
+	_, err := LoadPlugins(filepath.Join(os.TempDir(), "pixiu-plugin-not-exist"))
+	assert.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "pixiu-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the broken plugin is skipped, the other files are ignored
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "broken.so"), []byte("not a plugin"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0644))
+	before := ListHttpFilterPlugins()
+	kinds, err := LoadPlugins(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, kinds)
+	assert.Equal(t, before, ListHttpFilterPlugins())
+}
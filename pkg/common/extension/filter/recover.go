@@ -0,0 +1,216 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	stdHttp "net/http"
+	"runtime/debug"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+type (
+	// recoverFactory wrap the filter factory, so that the panic of its filters are recovered
+	// and handled by the on_panic policy instead of breaking the whole request
+	recoverFactory struct {
+		HttpFilterFactory
+		name     string
+		failOpen bool
+	}
+
+	// recoverChain wrap the filters appended by the factory
+	recoverChain struct {
+		FilterChain
+		factory *recoverFactory
+	}
+
+	recoverDecodeFilter struct {
+		HttpDecodeFilter
+		factory *recoverFactory
+	}
+
+	recoverEncodeFilter struct {
+		HttpEncodeFilter
+		factory *recoverFactory
+	}
+
+	recoverStreamEncodeFilter struct {
+		recoverEncodeFilter
+	}
+
+	// recoverWriter recover the panic of the writer returned by the stream filter, the streamed body can not be
+	// replied with 500 any more, so the panic interrupts the stream with an error whatever the on_panic policy
+	recoverWriter struct {
+		io.WriteCloser
+		factory *recoverFactory
+	}
+
+	// abortFilter abort the request when the factory of fail closed panics in PrepareFilterChain
+	abortFilter struct {
+		factory *recoverFactory
+	}
+)
+
+func newRecoverFactory(name string, onPanic string, factory HttpFilterFactory) *recoverFactory {
+	if onPanic != "" && onPanic != model.FilterPanicFailOpen && onPanic != model.FilterPanicFailClosed {
+		logger.Warnw("unknown on_panic policy, use fail_closed", "filter", name, "on_panic", onPanic)
+	}
+	return &recoverFactory{HttpFilterFactory: factory, name: name, failOpen: onPanic == model.FilterPanicFailOpen}
+}
+
+// Stage delegate to the wrapped factory
+func (f *recoverFactory) Stage() FilterStage {
+	return stageOf(f.HttpFilterFactory)
+}
+
+// OrderHint delegate to the wrapped factory
+func (f *recoverFactory) OrderHint() OrderHint {
+	return orderHintOf(f.HttpFilterFactory)
+}
+
+// Close delegate to the wrapped factory
+func (f *recoverFactory) Close() error {
+	return closeFactory(f.HttpFilterFactory)
+}
+
+func (f *recoverFactory) PrepareFilterChain(ctx *http.HttpContext, chain FilterChain) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.logPanic("prepare", r)
+			if !f.failOpen {
+				chain.AppendDecodeFilters(&abortFilter{factory: f})
+			}
+			err = fmt.Errorf("filter %s panic: %v", f.name, r)
+		}
+	}()
+	return f.HttpFilterFactory.PrepareFilterChain(ctx, &recoverChain{FilterChain: chain, factory: f})
+}
+
+func (c *recoverChain) Defer(fn func()) {
+	if !Defer(c.FilterChain, fn) {
+		logger.Warnw("filter defer on the chain not supporting it", "filter", c.factory.name)
+	}
+}
+
+func (c *recoverChain) AppendDecodeFilters(fs ...HttpDecodeFilter) {
+	wrapped := make([]HttpDecodeFilter, 0, len(fs))
+	for _, f := range fs {
+		wrapped = append(wrapped, &recoverDecodeFilter{HttpDecodeFilter: f, factory: c.factory})
+	}
+	c.FilterChain.AppendDecodeFilters(wrapped...)
+}
+
+func (c *recoverChain) AppendEncodeFilters(fs ...HttpEncodeFilter) {
+	wrapped := make([]HttpEncodeFilter, 0, len(fs))
+	for _, f := range fs {
+		// the stream filter is wrapped as stream filter only, so that the chain is still streamable
+		if _, ok := f.(HttpStreamEncodeFilter); ok {
+			wrapped = append(wrapped, &recoverStreamEncodeFilter{recoverEncodeFilter{HttpEncodeFilter: f, factory: c.factory}})
+			continue
+		}
+		wrapped = append(wrapped, &recoverEncodeFilter{HttpEncodeFilter: f, factory: c.factory})
+	}
+	c.FilterChain.AppendEncodeFilters(wrapped...)
+}
+
+func (f *recoverDecodeFilter) Decode(ctx *http.HttpContext) (status FilterStatus) {
+	defer func() {
+		if r := recover(); r != nil {
+			status = f.factory.handlePanic(ctx, "decode", r)
+		}
+	}()
+	return f.HttpDecodeFilter.Decode(ctx)
+}
+
+func (f *recoverEncodeFilter) Encode(ctx *http.HttpContext) (status FilterStatus) {
+	defer func() {
+		if r := recover(); r != nil {
+			status = f.factory.handlePanic(ctx, "encode", r)
+		}
+	}()
+	return f.HttpEncodeFilter.Encode(ctx)
+}
+
+func (f *recoverStreamEncodeFilter) EncodeStream(ctx *http.HttpContext, w io.Writer) (wc io.WriteCloser, status FilterStatus) {
+	defer func() {
+		if r := recover(); r != nil {
+			wc, status = NopWriteCloser(w), f.factory.handlePanic(ctx, "encode", r)
+		}
+	}()
+	wc, status = f.HttpEncodeFilter.(HttpStreamEncodeFilter).EncodeStream(ctx, w)
+	if wc != nil {
+		wc = &recoverWriter{WriteCloser: wc, factory: f.factory}
+	}
+	return wc, status
+}
+
+func (w *recoverWriter) Write(p []byte) (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = w.factory.streamPanic("write", r)
+		}
+	}()
+	return w.WriteCloser.Write(p)
+}
+
+func (w *recoverWriter) Close() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = w.factory.streamPanic("close", r)
+		}
+	}()
+	return w.WriteCloser.Close()
+}
+
+func (f *recoverFactory) streamPanic(phase string, r interface{}) error {
+	f.logPanic("encode stream "+phase, r)
+	return fmt.Errorf("filter %s panic: %v", f.name, r)
+}
+
+// handlePanic skip the filter when fail open, otherwise reply 500 and stop the chain
+func (f *recoverFactory) handlePanic(ctx *http.HttpContext, phase string, r interface{}) FilterStatus {
+	f.logPanic(phase, r)
+	if f.failOpen {
+		return Continue
+	}
+	return f.abort(ctx)
+}
+
+func (f *abortFilter) Decode(ctx *http.HttpContext) FilterStatus {
+	return f.factory.abort(ctx)
+}
+
+func (f *recoverFactory) abort(ctx *http.HttpContext) FilterStatus {
+	if !ctx.LocalReply() {
+		bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("filter %s internal error", f.name)})
+		ctx.SendLocalReply(stdHttp.StatusInternalServerError, bt)
+	}
+	return Stop
+}
+
+func (f *recoverFactory) logPanic(phase string, r interface{}) {
+	logger.Errorw("filter panic recovered", "filter", f.name, "phase", phase, "fail_open", f.failOpen,
+		"panic", fmt.Sprintf("%v", r), "stack", string(debug.Stack()))
+}
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	demoPanic        = "dgp.filters.demo.panic"
+	demoPanicPrepare = "dgp.filters.demo.panic.prepare"
+)
+
+func init() {
+	RegisterHttpFilter(&panicPlugin{kind: demoPanic})
+	RegisterHttpFilter(&panicPlugin{kind: demoPanicPrepare, prepare: true})
+}
+
+// panicPlugin create the filter panics in decode, or in PrepareFilterChain when prepare is true
+type panicPlugin struct {
+	kind    string
+	prepare bool
+}
+
+func (p *panicPlugin) Kind() string {
+	return p.kind
+}
+
+func (p *panicPlugin) CreateFilterFactory() (HttpFilterFactory, error) {
+	return &panicFilterFactory{prepare: p.prepare}, nil
+}
+
+type panicFilterFactory struct {
+	prepare bool
+}
+
+func (f *panicFilterFactory) Config() interface{} {
+	return &Config{}
+}
+
+func (f *panicFilterFactory) Apply() error {
+	return nil
+}
+
+func (f *panicFilterFactory) PrepareFilterChain(ctx *contexthttp.HttpContext, chain FilterChain) error {
+	if f.prepare {
+		panic("mock prepare panic")
+	}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *panicFilterFactory) Decode(ctx *contexthttp.HttpContext) FilterStatus {
+	var m map[string]string
+	m["nil"] = "panic"
+	return Continue
+}
+
+func TestFilterPanicRecover(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		onPanic string
+		status  int
+	}{
+		// the demo auth filter after the panicking one reply 401 when the chain continues
+		{name: "fail open", filter: demoPanic, onPanic: model.FilterPanicFailOpen, status: http.StatusUnauthorized},
+		{name: "fail closed", filter: demoPanic, onPanic: model.FilterPanicFailClosed, status: http.StatusInternalServerError},
+		{name: "default fail closed", filter: demoPanic, status: http.StatusInternalServerError},
+		{name: "prepare fail open", filter: demoPanicPrepare, onPanic: model.FilterPanicFailOpen, status: http.StatusUnauthorized},
+		{name: "prepare fail closed", filter: demoPanicPrepare, status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := NewEmptyFilterManager()
+			fm.ReLoad([]*model.HTTPFilter{{Name: tt.filter, OnPanic: tt.onPanic}, {Name: demoAuth}})
+
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock", nil)
+			assert.NoError(t, err)
+			ctx := &contexthttp.HttpContext{Request: request, Writer: httptest.NewRecorder()}
+			ctx.Reset()
+
+			assert.NotPanics(t, func() {
+				fm.CreateFilterChain(ctx).OnDecode(ctx)
+			})
+			assert.Equal(t, tt.status, ctx.GetStatusCode())
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// FetchFunc fetch the remote resource like jwks keys or allowlist
+type FetchFunc func() (interface{}, error)
+
+// Refresher keep a remote resource refreshed in background, it is shared by the filters fetching
+// the same resource, see AcquireRefresher
+type Refresher struct {
+	key   string
+	ttl   time.Duration
+	fetch FetchFunc
+
+	mu        sync.Mutex
+	value     interface{}
+	fetchedAt time.Time
+	triedAt   time.Time
+	err       error
+	// inflight closed when the running fetch finishes, nil if no fetch is running
+	inflight chan struct{}
+	refs     int
+	done     chan struct{}
+}
+
+const (
+	// refreshRetryInterval the min interval to fetch again after the first fetch fails
+	refreshRetryInterval = time.Second
+	defaultRefreshTTL    = 5 * time.Minute
+)
+
+var (
+	refreshersMu sync.Mutex
+	refreshers   = make(map[string]*Refresher)
+)
+
+// AcquireRefresher return the refresher of the key, the refresher is created and started on the first
+// acquire and the later ones share it, so the resource is fetched once however many filters use it.
+// The ttl and fetch of the first acquire are used, the ttl is 5m if not positive. The refresher should be released by Release.
+func AcquireRefresher(key string, ttl time.Duration, fetch FetchFunc) *Refresher {
+	if ttl <= 0 {
+		ttl = defaultRefreshTTL
+	}
+	refreshersMu.Lock()
+	defer refreshersMu.Unlock()
+	r, ok := refreshers[key]
+	if !ok {
+		r = &Refresher{key: key, ttl: ttl, fetch: fetch, done: make(chan struct{})}
+		refreshers[key] = r
+		go r.loop()
+	}
+	r.refs++
+	return r
+}
+
+// Release stop refreshing when the last user releases it, the refresher already stopped is not released again
+func (r *Refresher) Release() {
+	refreshersMu.Lock()
+	defer refreshersMu.Unlock()
+	if r.refs <= 0 {
+		return
+	}
+	r.refs--
+	if r.refs > 0 {
+		return
+	}
+	// the key may be acquired again by a new refresher
+	if refreshers[r.key] == r {
+		delete(refreshers, r.key)
+	}
+	close(r.done)
+}
+
+// Get return the resource, it is fetched at once if nothing is fetched yet. The last fetched value is
+// returned when the refresh fails, the error is returned only if nothing is ever fetched.
+// A refresh runs in background when the value is older than the ttl.
+func (r *Refresher) Get() (interface{}, error) {
+	r.mu.Lock()
+	if r.fetchedAt.IsZero() {
+		if r.err != nil && r.inflight == nil && time.Since(r.triedAt) < refreshRetryInterval {
+			defer r.mu.Unlock()
+			return nil, r.err
+		}
+		wait := r.startFetch()
+		r.mu.Unlock()
+		<-wait
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.value, r.err
+	}
+	defer r.mu.Unlock()
+	// the failed refresh is not retried until the ttl passes again
+	if time.Since(r.triedAt) > r.ttl {
+		r.startFetch()
+	}
+	return r.value, nil
+}
+
+func (r *Refresher) loop() {
+	ticker := time.NewTicker(r.ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			wait := r.startFetch()
+			r.mu.Unlock()
+			<-wait
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// startFetch start a fetch unless one is running, it returns the chan closed when the fetch finishes,
+// the caller must hold the lock
+func (r *Refresher) startFetch() <-chan struct{} {
+	if r.inflight != nil {
+		return r.inflight
+	}
+	wait := make(chan struct{})
+	r.inflight = wait
+	r.triedAt = time.Now()
+	go func() {
+		value, err := r.fetch()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err != nil {
+			logger.Warnw("refresh fail, keep the fetched one", "key", r.key, "error", err.Error())
+			if r.fetchedAt.IsZero() {
+				r.err = err
+			}
+		} else {
+			r.value, r.err, r.fetchedAt = value, nil, time.Now()
+		}
+		r.inflight = nil
+		close(wait)
+	}()
+	return wait
+}
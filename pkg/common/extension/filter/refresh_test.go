@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefresherShared(t *testing.T) {
+	var fetches int32
+	fetch := func() (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		return atomic.AddInt32(&fetches, 1), nil
+	}
+	r1 := AcquireRefresher("test:shared", time.Hour, fetch)
+	r2 := AcquireRefresher("test:shared", time.Hour, fetch)
+	assert.Same(t, r1, r2)
+
+	// the concurrent gets share one fetch
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := r1.Get()
+			assert.NoError(t, err)
+			assert.Equal(t, int32(1), v)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	r1.Release()
+	assert.Same(t, r2, AcquireRefresher("test:shared", time.Hour, fetch))
+	r2.Release()
+	r2.Release()
+	r3 := AcquireRefresher("test:shared", time.Hour, fetch)
+	defer r3.Release()
+	assert.NotSame(t, r2, r3)
+}
+
+func TestRefresherReleaseTwice(t *testing.T) {
+	fetch := func() (interface{}, error) {
+		return "v1", nil
+	}
+	r1 := AcquireRefresher("test:release", time.Hour, fetch)
+	r1.Release()
+	r2 := AcquireRefresher("test:release", time.Hour, fetch)
+	defer r2.Release()
+	assert.NotSame(t, r1, r2)
+
+	// the stopped refresher is not closed again, and the new one of the key is kept
+	assert.NotPanics(t, r1.Release)
+	assert.Same(t, r2, AcquireRefresher("test:release", time.Hour, fetch))
+	r2.Release()
+}
+
+func TestRefresherStale(t *testing.T) {
+	var fail atomic.Value
+	fail.Store(false)
+	fetch := func() (interface{}, error) {
+		if fail.Load().(bool) {
+			return nil, errors.New("unavailable")
+		}
+		return "v1", nil
+	}
+	r := AcquireRefresher("test:stale", 50*time.Millisecond, fetch)
+	defer r.Release()
+
+	v, err := r.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", v)
+
+	// the fetched value is kept while the refresh fails
+	fail.Store(true)
+	time.Sleep(120 * time.Millisecond)
+	v, err = r.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", v)
+}
+
+func TestRefresherFail(t *testing.T) {
+	var fetches int32
+	r := AcquireRefresher("test:fail", time.Hour, func() (interface{}, error) {
+		atomic.AddInt32(&fetches, 1)
+		return nil, errors.New("unavailable")
+	})
+	defer r.Release()
+
+	_, err := r.Get()
+	assert.EqualError(t, err, "unavailable")
+	// the failure is returned without fetching again within the retry interval
+	_, err = r.Get()
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
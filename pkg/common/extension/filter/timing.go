@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	phaseDecode = "decode"
+	phaseEncode = "encode"
+)
+
+type (
+	// FilterTiming the time a filter takes in one phase
+	FilterTiming struct {
+		Name     string
+		Phase    string
+		Duration time.Duration
+	}
+
+	// FilterTimings the timings of the filters in the executed order
+	FilterTimings struct {
+		Timings []FilterTiming
+	}
+
+	// namedFilter is implemented by the filters knowing the name of their factory
+	namedFilter interface {
+		filterName() string
+	}
+)
+
+func (t *FilterTimings) record(f interface{}, phase string, d time.Duration) {
+	name := fmt.Sprintf("%T", f)
+	if n, ok := f.(namedFilter); ok {
+		name = n.filterName()
+	}
+	t.Timings = append(t.Timings, FilterTiming{Name: name, Phase: phase, Duration: d})
+}
+
+// String format the timings as name/phase=duration joined by comma, the duration is in milliseconds
+func (t *FilterTimings) String() string {
+	parts := make([]string, 0, len(t.Timings))
+	for _, timing := range t.Timings {
+		ms := float64(timing.Duration) / float64(time.Millisecond)
+		parts = append(parts, fmt.Sprintf("%s/%s=%.3fms", timing.Name, timing.Phase, ms))
+	}
+	return strings.Join(parts, ",")
+}
+
+// GetFilterTimings get the filter timings of the request, nil if the tracing is disabled
+func GetFilterTimings(ctx *http.HttpContext) *FilterTimings {
+	t, _ := ctx.Params[constant.FilterTimingsParam].(*FilterTimings)
+	return t
+}
+
+func (f *recoverDecodeFilter) filterName() string {
+	return f.factory.name
+}
+
+func (f *recoverEncodeFilter) filterName() string {
+	return f.factory.name
+}
+
+func (f *abortFilter) filterName() string {
+	return f.factory.name
+}
@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestFilterTimings(t *testing.T) {
+	fm := NewEmptyFilterManager()
+	assert.Nil(t, fm.ReLoad([]*model.HTTPFilter{{Name: DEMO}, {Name: demoAuth}}))
+
+	run := func() *contexthttp.HttpContext {
+		request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock", nil)
+		assert.NoError(t, err)
+		request.Header.Set("Authorization", "Bearer token")
+		ctx := &contexthttp.HttpContext{Request: request, Params: make(map[string]interface{})}
+		ctx.Reset()
+		chain := fm.CreateFilterChain(ctx)
+		chain.OnDecode(ctx)
+		chain.OnEncode(ctx)
+		fm.ReleaseFilterChain(chain)
+		return ctx
+	}
+
+	// disabled by default
+	assert.Nil(t, GetFilterTimings(run()))
+
+	fm.SetFilterTimings(true)
+	timings := GetFilterTimings(run())
+	assert.NotNil(t, timings)
+
+	var names []string
+	for _, timing := range timings.Timings {
+		names = append(names, timing.Name+"/"+timing.Phase)
+		assert.True(t, timing.Duration >= 0)
+	}
+	assert.Equal(t, []string{DEMO + "/decode", demoAuth + "/decode", DEMO + "/encode"}, names)
+	assert.Equal(t, 3, len(strings.Split(timings.String(), ",")))
+	assert.True(t, strings.HasPrefix(timings.String(), DEMO+"/decode="))
+}
@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	pch "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	headerDeprecation = "Deprecation"
+	headerSunset      = "Sunset"
+	headerLink        = "Link"
+
+	// deprecationStats the name of the usage served by the admin stats api
+	deprecationStats = "deprecated_routes"
+)
+
+// deprecatedUsage the usage count of deprecated routes, keyed by the matched route pattern so the
+// path params and the paths under a prefix never grow the map
+var deprecatedUsage sync.Map
+
+func init() {
+	filter.RegisterStats(deprecationStats, func() interface{} { return DeprecatedRouteUsage() })
+}
+
+// markDeprecated attach the deprecation headers to response and count the usage
+func markDeprecated(hc *pch.HttpContext, d *model.Deprecation) {
+	date := d.Date
+	if date == "" {
+		date = "true"
+	}
+	hc.AddHeader(headerDeprecation, date)
+	if d.Sunset != "" {
+		hc.AddHeader(headerSunset, d.Sunset)
+	}
+	if d.Link != "" {
+		hc.AddHeader(headerLink, "<"+d.Link+`>; rel="deprecation"`)
+	}
+
+	key := hc.GetMethod() + " " + hc.Request.URL.Path
+	if ra := hc.GetRouteEntry(); ra != nil && ra.Pattern != "" {
+		key = ra.Pattern
+	}
+	counter, _ := deprecatedUsage.LoadOrStore(key, new(int64))
+	count := atomic.AddInt64(counter.(*int64), 1)
+	if d.Log {
+		logger.Warnf("[dubbo-go-pixiu] deprecated route %s is used by %s, total %d", key, hc.GetClientIP(), count)
+	}
+}
+
+// DeprecatedRouteUsage return the usage count of deprecated routes, keyed by the route pattern
+func DeprecatedRouteUsage() map[string]int64 {
+	usage := make(map[string]int64)
+	deprecatedUsage.Range(func(key, value interface{}) bool {
+		usage[key.(string)] = atomic.LoadInt64(value.(*int64))
+		return true
+	})
+	return usage
+}
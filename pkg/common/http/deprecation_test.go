@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/router/trie"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestDeprecatedRoute(t *testing.T) {
+	trieTree := trie.NewTrieWithDefault("GET/api/v1/legacy", model.RouteAction{
+		Cluster: "test_dubbo",
+		Deprecation: &model.Deprecation{
+			Date:   "Mon, 01 Aug 2022 00:00:00 GMT",
+			Sunset: "Sun, 01 Jan 2023 00:00:00 GMT",
+			Link:   "https://dubbo-go-pixiu.github.io/migration",
+			Log:    true,
+		},
+	})
+	_, _ = trieTree.Put("GET/api/v2/user", model.RouteAction{Cluster: "test_dubbo"})
+	hcmc := model.HttpConnectionManagerConfig{
+		RouteConfig: model.RouteConfiguration{RouteTrie: trieTree},
+	}
+	hcm, err := CreateHttpConnectionManager(&hcmc, nil)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/legacy", nil)
+		assert.NoError(t, err)
+		c := mock.GetMockHTTPContext(request)
+		assert.NoError(t, hcm.findRoute(c))
+
+		header := c.Writer.Header()
+		assert.Equal(t, "Mon, 01 Aug 2022 00:00:00 GMT", header.Get("Deprecation"))
+		assert.Equal(t, "Sun, 01 Jan 2023 00:00:00 GMT", header.Get("Sunset"))
+		assert.Equal(t, `<https://dubbo-go-pixiu.github.io/migration>; rel="deprecation"`, header.Get("Link"))
+	}
+
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v2/user", nil)
+	assert.NoError(t, err)
+	c := mock.GetMockHTTPContext(request)
+	assert.NoError(t, hcm.findRoute(c))
+	assert.Empty(t, c.Writer.Header().Get("Deprecation"))
+
+	usage := DeprecatedRouteUsage()
+	assert.Equal(t, int64(2), usage["GET /api/v1/legacy"])
+	_, ok := usage["GET /api/v2/user"]
+	assert.False(t, ok)
+}
+
+func TestDeprecatedRouteUsageByPattern(t *testing.T) {
+	hcmc := model.HttpConnectionManagerConfig{
+		RouteConfig: model.RouteConfiguration{
+			Routes: []*model.Router{{
+				Match: model.RouterMatch{Prefix: "/api/v3", Methods: []string{"GET"}},
+				Route: model.RouteAction{Cluster: "test_dubbo", Deprecation: &model.Deprecation{}},
+			}},
+		},
+	}
+	hcm, err := CreateHttpConnectionManager(&hcmc, nil)
+	assert.NoError(t, err)
+
+	for _, path := range []string{"/api/v3/user/1", "/api/v3/user/2", "/api/v3/order"} {
+		request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com"+path, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, hcm.findRoute(mock.GetMockHTTPContext(request)))
+	}
+
+	// the paths under the prefix share the usage of the route
+	usage := DeprecatedRouteUsage()
+	assert.Equal(t, int64(3), usage["GET /api/v3/**"])
+	_, ok := usage["GET /api/v3/user/1"]
+	assert.False(t, ok)
+
+	stats, ok := filter.CollectStats(deprecationStats)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), stats.(map[string]int64)["GET /api/v3/**"])
+}
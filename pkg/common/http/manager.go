@@ -34,7 +34,6 @@ import (
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	router2 "github.com/apache/dubbo-go-pixiu/pkg/common/router"
-	"github.com/apache/dubbo-go-pixiu/pkg/common/util"
 	pch "github.com/apache/dubbo-go-pixiu/pkg/context/http"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
@@ -49,16 +48,25 @@ type HttpConnectionManager struct {
 	pool              sync.Pool
 }
 
-// CreateHttpConnectionManager create http connection manager
-func CreateHttpConnectionManager(hcmc *model.HttpConnectionManagerConfig, bs *model.Bootstrap) *HttpConnectionManager {
+// CreateHttpConnectionManager create http connection manager, it fails if the http filters fail to load
+func CreateHttpConnectionManager(hcmc *model.HttpConnectionManagerConfig, bs *model.Bootstrap) (*HttpConnectionManager, error) {
 	hcm := &HttpConnectionManager{config: hcmc}
 	hcm.pool.New = func() interface{} {
 		return hcm.allocateContext()
 	}
+	if err := checkStreamPolicies(hcmc.RouteConfig.Routes); err != nil {
+		return nil, errors.Wrap(err, "check stream policy fail")
+	}
 	hcm.routerCoordinator = router2.CreateRouterCoordinator(&hcmc.RouteConfig)
-	hcm.filterManager = filter.NewFilterManager(hcmc.HTTPFilters)
-	hcm.filterManager.Load()
-	return hcm
+	hcm.filterManager = filter.NewFilterManagerWithChains(hcmc.HTTPFilters, hcmc.HTTPFilterChains)
+	hcm.filterManager.SetConfigMode(hcmc.FilterConfigMode)
+	hcm.filterManager.SetFilterTimings(hcmc.FilterTimings)
+	if err := hcm.filterManager.Load(); err != nil {
+		// the default filters may be loaded before the chains fail
+		_ = hcm.filterManager.Close()
+		return nil, errors.Wrap(err, "load http filters fail")
+	}
+	return hcm, nil
 }
 
 func (hcm *HttpConnectionManager) allocateContext() *pch.HttpContext {
@@ -69,6 +77,7 @@ func (hcm *HttpConnectionManager) allocateContext() *pch.HttpContext {
 
 func (hcm *HttpConnectionManager) Handle(hc *pch.HttpContext) error {
 	hc.Ctx = context.Background()
+	hc.Router = hcm.routerCoordinator
 	err := hcm.findRoute(hc)
 	if err != nil {
 		return err
@@ -94,6 +103,7 @@ func (hcm *HttpConnectionManager) ServeHTTP(w stdHttp.ResponseWriter, r *stdHttp
 // handleHTTPRequest handle http request
 func (hcm *HttpConnectionManager) handleHTTPRequest(c *pch.HttpContext) {
 	filterChain := hcm.filterManager.CreateFilterChain(c)
+	defer hcm.filterManager.ReleaseFilterChain(filterChain)
 
 	// recover any err when filterChain run
 	defer func() {
@@ -105,12 +115,27 @@ func (hcm *HttpConnectionManager) handleHTTPRequest(c *pch.HttpContext) {
 
 	//todo timeout
 	filterChain.OnDecode(c)
+	if res := hcm.streamThrough(c, filterChain); res != nil {
+		hcm.streamFilteredResponse(c, filterChain.(filter.StreamFilterChain), res)
+		return
+	}
 	hcm.buildTargetResponse(c)
 	filterChain.OnEncode(c)
 	hcm.writeResponse(c)
 }
 
 func (hcm *HttpConnectionManager) writeResponse(c *pch.HttpContext) {
+	writeFilterTimings(c)
+	if body, policy := streamBody(c); body != nil {
+		if c.LocalReply() {
+			_ = body.Close()
+			return
+		}
+		if err := streamResponse(c, body, policy); err != nil {
+			logger.Warnf("[dubbo-go-pixiu] stream response of %s fail: %v", c.GetUrl(), err)
+		}
+		return
+	}
 	if !c.LocalReply() {
 		writer := c.Writer
 		writer.WriteHeader(c.GetStatusCode())
@@ -127,10 +152,6 @@ func (hcm *HttpConnectionManager) buildTargetResponse(c *pch.HttpContext) {
 
 	switch res := c.SourceResp.(type) {
 	case *stdHttp.Response:
-		body, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			panic(err)
-		}
 		//Merge header
 		remoteHeader := res.Header
 		for k := range remoteHeader {
@@ -138,6 +159,15 @@ func (hcm *HttpConnectionManager) buildTargetResponse(c *pch.HttpContext) {
 		}
 		//status code
 		c.StatusCode(res.StatusCode)
+		if ra := c.GetRouteEntry(); ra != nil && ra.Stream != nil {
+			// the body is copied to client by writeResponse
+			c.TargetResp = &client.Response{}
+			break
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			panic(err)
+		}
 		c.TargetResp = &client.Response{Data: body}
 	case []byte:
 		c.StatusCode(stdHttp.StatusOK)
@@ -145,11 +175,14 @@ func (hcm *HttpConnectionManager) buildTargetResponse(c *pch.HttpContext) {
 		c.TargetResp = &client.Response{Data: res}
 	default:
 		//dubbo go generic invoke
-		response := util.NewDubboResponse(res, false)
+		response := dubboResponse(c, res)
 		c.StatusCode(stdHttp.StatusOK)
 		c.AddHeader(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
 		c.TargetResp = response
 	}
+	if ra := c.GetRouteEntry(); ra != nil && len(ra.StatusMapping) > 0 {
+		mapStatus(c, ra.StatusMapping)
+	}
 }
 
 func (hcm *HttpConnectionManager) findRoute(hc *pch.HttpContext) error {
@@ -163,5 +196,8 @@ func (hcm *HttpConnectionManager) findRoute(hc *pch.HttpContext) error {
 		// return 404
 	}
 	hc.RouteEntry(ra)
+	if ra.Deprecation != nil {
+		markDeprecated(hc, ra.Deprecation)
+	}
 	return nil
 }
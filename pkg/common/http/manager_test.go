@@ -126,7 +126,8 @@ func TestCreateHttpConnectionManager(t *testing.T) {
 		IdleTimeoutStr:    "100",
 	}
 
-	hcm := CreateHttpConnectionManager(&hcmc, nil)
+	hcm, err := CreateHttpConnectionManager(&hcmc, nil)
+	assert.NoError(t, err)
 	assert.Equal(t, len(hcm.filterManager.GetFactory()), 1)
 	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1?name=tc", bytes.NewReader([]byte("{\"id\":\"12345\"}")))
 	assert.NoError(t, err)
@@ -139,4 +140,9 @@ func TestCreateHttpConnectionManager(t *testing.T) {
 	assert.NoError(t, err)
 	err = hcm.Handle(c)
 	assert.NoError(t, err)
+
+	// the connection manager is not created with a partial chain
+	hcmc.HTTPFilters = append(hcmc.HTTPFilters, &model.HTTPFilter{Name: "dgp.filter.http.unknown"})
+	_, err = CreateHttpConnectionManager(&hcmc, nil)
+	assert.Error(t, err)
 }
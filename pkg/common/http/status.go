@@ -0,0 +1,34 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	pch "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// mapStatus replace the upstream status by the route status mapping, the unmapped status is kept
+func mapStatus(hc *pch.HttpContext, mapping map[int]int) {
+	from := hc.GetStatusCode()
+	to, ok := mapping[from]
+	if !ok || to == from {
+		return
+	}
+	logger.Debugf("[dubbo-go-pixiu] map upstream status %d to %d for %s", from, to, hc.GetUrl())
+	hc.StatusCode(to)
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/router/trie"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestStatusMapping(t *testing.T) {
+	trieTree := trie.NewTrieWithDefault("POST/api/v1/user", model.RouteAction{
+		Cluster:       "test_dubbo",
+		StatusMapping: map[int]int{http.StatusCreated: http.StatusOK, http.StatusAccepted: http.StatusOK},
+	})
+	hcmc := model.HttpConnectionManagerConfig{
+		RouteConfig: model.RouteConfiguration{RouteTrie: trieTree},
+	}
+	hcm, err := CreateHttpConnectionManager(&hcmc, nil)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		upstream int
+		expected int
+	}{
+		{upstream: http.StatusCreated, expected: http.StatusOK},
+		{upstream: http.StatusAccepted, expected: http.StatusOK},
+		{upstream: http.StatusNoContent, expected: http.StatusNoContent},
+		{upstream: http.StatusBadRequest, expected: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/user", nil)
+		assert.NoError(t, err)
+		c := mock.GetMockHTTPContext(request)
+		assert.NoError(t, hcm.findRoute(c))
+		c.SourceResp = &http.Response{
+			StatusCode: tt.upstream,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}
+
+		hcm.buildTargetResponse(c)
+		assert.Equal(t, tt.expected, c.GetStatusCode())
+		assert.Equal(t, []byte("{}"), c.TargetResp.Data)
+	}
+}
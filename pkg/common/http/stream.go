@@ -0,0 +1,249 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"io"
+	stdHttp "net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	pch "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	contentTypeEventStream = "text/event-stream"
+
+	sseHeartbeat = ":\n"
+)
+
+// streamBody return the unread upstream body when the route streams the response
+func streamBody(c *pch.HttpContext) (io.ReadCloser, *model.StreamPolicy) {
+	ra := c.GetRouteEntry()
+	if ra == nil || ra.Stream == nil {
+		return nil, nil
+	}
+	res, ok := c.SourceResp.(*stdHttp.Response)
+	if !ok {
+		return nil, nil
+	}
+	return res.Body, ra.Stream
+}
+
+// streamWriter serialize the writes of the upstream data and the heartbeats
+type streamWriter struct {
+	mu      sync.Mutex
+	w       stdHttp.ResponseWriter
+	flusher stdHttp.Flusher
+	// flushEach flush after each write when no flush interval
+	flushEach bool
+	dirty     bool
+	lastWrite time.Time
+	// boundary the written data ends at a line boundary, the heartbeat never splits a line
+	boundary bool
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	n, err := sw.w.Write(p)
+	if n > 0 {
+		sw.lastWrite = time.Now()
+		sw.boundary = p[n-1] == '\n'
+		sw.dirty = true
+	}
+	if err == nil && sw.flushEach {
+		sw.flushLocked()
+	}
+	return n, err
+}
+
+func (sw *streamWriter) flush() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.flushLocked()
+}
+
+func (sw *streamWriter) flushLocked() {
+	if sw.dirty && sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+	sw.dirty = false
+}
+
+// heartbeat write the payload if the stream is idle for the interval and at a line boundary
+func (sw *streamWriter) heartbeat(payload []byte, interval time.Duration) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if !sw.boundary || time.Since(sw.lastWrite) < interval {
+		return
+	}
+	if _, err := sw.w.Write(payload); err != nil {
+		return
+	}
+	sw.lastWrite = time.Now()
+	sw.dirty = true
+	sw.flushLocked()
+}
+
+// streamResponse copy the upstream body to client as it arrives, flushed by the policy
+// and kept alive by the heartbeats when idle
+func streamResponse(c *pch.HttpContext, body io.ReadCloser, policy *model.StreamPolicy) error {
+	defer body.Close()
+
+	flushInterval, heartbeatInterval, err := policy.Intervals()
+	if err != nil {
+		return err
+	}
+	// a comment line is ignored by the event stream clients, any other payload may corrupt the stream,
+	// so the heartbeat of the other content types must be configured
+	payload := policy.Heartbeat
+	if payload == "" {
+		if strings.HasPrefix(c.Writer.Header().Get(constant.HeaderKeyContextType), contentTypeEventStream) {
+			payload = sseHeartbeat
+		} else {
+			heartbeatInterval = 0
+		}
+	}
+
+	flusher, _ := c.Writer.(stdHttp.Flusher)
+	sw := &streamWriter{w: c.Writer, flusher: flusher, flushEach: flushInterval <= 0, lastWrite: time.Now(), boundary: true}
+	// the length is unknown once the heartbeats are mixed in, the response is chunked
+	c.Writer.Header().Del(constant.HeaderKeyContentLength)
+	c.Writer.WriteHeader(c.GetStatusCode())
+	sw.dirty = true
+	sw.flush()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	if flushInterval > 0 || heartbeatInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sw.tick(done, flushInterval, heartbeatInterval, []byte(payload))
+		}()
+	}
+	// the writer must not be touched after the handler returns
+	defer func() {
+		close(done)
+		wg.Wait()
+		sw.flush()
+	}()
+
+	if _, err := io.Copy(sw, body); err != nil {
+		logger.Debugf("[dubbo-go-pixiu] stream response of %s interrupted: %v", c.GetUrl(), err)
+		return err
+	}
+	return nil
+}
+
+func (sw *streamWriter) tick(done chan struct{}, flushInterval, heartbeatInterval time.Duration, payload []byte) {
+	var flushC, heartbeatC <-chan time.Time
+	if flushInterval > 0 {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		flushC = ticker.C
+	}
+	if heartbeatInterval > 0 {
+		// check the idleness more often than the interval, so that the heartbeat is not late by a whole interval
+		ticker := time.NewTicker(heartbeatInterval / 4)
+		defer ticker.Stop()
+		heartbeatC = ticker.C
+	}
+	for {
+		select {
+		case <-flushC:
+			sw.flush()
+		case <-heartbeatC:
+			sw.heartbeat(payload, heartbeatInterval)
+		case <-done:
+			return
+		}
+	}
+}
+
+// checkStreamPolicies compile the stream policies of the routes, so that an invalid one fails the config load
+func checkStreamPolicies(routes []*model.Router) error {
+	for _, r := range routes {
+		if r.Route.Stream == nil {
+			continue
+		}
+		if err := r.Route.Stream.Compile(); err != nil {
+			return errors.Wrapf(err, "route %s", r.ID)
+		}
+	}
+	return nil
+}
+
+// streamThrough return the upstream response if it should be streamed through the encode filters,
+// see filter.HttpStreamEncodeFilter
+func (hcm *HttpConnectionManager) streamThrough(c *pch.HttpContext, chain filter.FilterChain) *stdHttp.Response {
+	if hcm.config == nil || hcm.config.StreamThreshold <= 0 || c.LocalReply() {
+		return nil
+	}
+	// the route streaming policy copies the body as it is
+	if ra := c.GetRouteEntry(); ra != nil && ra.Stream != nil {
+		return nil
+	}
+	res, ok := c.SourceResp.(*stdHttp.Response)
+	if !ok || (res.ContentLength >= 0 && res.ContentLength <= hcm.config.StreamThreshold) {
+		return nil
+	}
+	if sc, ok := chain.(filter.StreamFilterChain); !ok || !sc.Streamable() {
+		return nil
+	}
+	return res
+}
+
+// streamFilteredResponse pipe the upstream body to client through the writers of the encode filters
+func (hcm *HttpConnectionManager) streamFilteredResponse(c *pch.HttpContext, chain filter.StreamFilterChain, res *stdHttp.Response) {
+	defer res.Body.Close()
+	for k := range res.Header {
+		c.AddHeader(k, res.Header.Get(k))
+	}
+	c.StatusCode(res.StatusCode)
+	if ra := c.GetRouteEntry(); ra != nil && len(ra.StatusMapping) > 0 {
+		mapStatus(c, ra.StatusMapping)
+	}
+	c.TargetResp = &client.Response{}
+
+	w := chain.OnEncodeStream(c, c.Writer)
+	if w == nil {
+		return
+	}
+	writeFilterTimings(c)
+	c.Writer.WriteHeader(c.GetStatusCode())
+	if _, err := io.Copy(w, res.Body); err != nil {
+		logger.Warnf("[dubbo-go-pixiu] stream response of %s through filters interrupted: %v", c.GetUrl(), err)
+	}
+	if err := w.Close(); err != nil {
+		logger.Warnf("[dubbo-go-pixiu] stream response of %s through filters fail: %v", c.GetUrl(), err)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// streamGateway proxy the upstream response by buildTargetResponse and writeResponse with the stream policy
+func streamGateway(t *testing.T, upstream string, policy *model.StreamPolicy) *httptest.Server {
+	hcm := &HttpConnectionManager{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := http.Get(upstream)
+		assert.NoError(t, err)
+		c := mock.GetMockHTTPContext(r)
+		c.Writer = w
+		c.RouteEntry(&model.RouteAction{Stream: policy})
+		c.SourceResp = resp
+		hcm.buildTargetResponse(c)
+		hcm.writeResponse(c)
+	}))
+}
+
+type streamLine struct {
+	text string
+	at   time.Duration
+}
+
+func readLines(t *testing.T, url string) []streamLine {
+	start := time.Now()
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var lines []streamLine
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, streamLine{text: scanner.Text(), at: time.Since(start)})
+	}
+	return lines
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		time.Sleep(550 * time.Millisecond)
+		_, _ = w.Write([]byte("data: second\n\n"))
+	}))
+	defer upstream.Close()
+	gateway := streamGateway(t, upstream.URL, &model.StreamPolicy{HeartbeatInterval: "100ms"})
+	defer gateway.Close()
+
+	lines := readLines(t, gateway.URL)
+	assert.True(t, len(lines) > 2, lines)
+	// the first event is not buffered until the stream ends
+	assert.Equal(t, "data: first", lines[0].text)
+	assert.True(t, lines[0].at < 300*time.Millisecond, lines[0].at)
+
+	var heartbeats []time.Duration
+	for _, l := range lines {
+		if l.text == ":" {
+			heartbeats = append(heartbeats, l.at)
+		}
+	}
+	// about 5 heartbeats at 100ms interval during the idle 550ms
+	assert.True(t, len(heartbeats) >= 3 && len(heartbeats) <= 6, heartbeats)
+	for i := 1; i < len(heartbeats); i++ {
+		assert.True(t, heartbeats[i]-heartbeats[i-1] >= 80*time.Millisecond, heartbeats)
+	}
+	assert.Equal(t, "data: second", lines[len(lines)-2].text)
+}
+
+func TestStreamNoHeartbeat(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte("{\"id\":1}\n"))
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("{\"id\":2}\n"))
+	}))
+	defer upstream.Close()
+	gateway := streamGateway(t, upstream.URL, &model.StreamPolicy{FlushInterval: "20ms"})
+	defer gateway.Close()
+
+	lines := readLines(t, gateway.URL)
+	texts := make([]string, 0, len(lines))
+	for _, l := range lines {
+		texts = append(texts, l.text)
+	}
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}", strings.Join(texts, "\n"))
+	assert.True(t, lines[0].at < 150*time.Millisecond, lines[0].at)
+}
+
+func TestStreamHeartbeatRequiresPayload(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte("{\"id\":1}\n"))
+		w.(http.Flusher).Flush()
+		time.Sleep(250 * time.Millisecond)
+		_, _ = w.Write([]byte("{\"id\":2}\n"))
+	}))
+	defer upstream.Close()
+	// no payload is guessed for the content types other than text/event-stream
+	gateway := streamGateway(t, upstream.URL, &model.StreamPolicy{HeartbeatInterval: "50ms"})
+	defer gateway.Close()
+
+	texts := make([]string, 0)
+	for _, l := range readLines(t, gateway.URL) {
+		texts = append(texts, l.text)
+	}
+	assert.Equal(t, []string{"{\"id\":1}", "{\"id\":2}"}, texts)
+}
+
+func TestCheckStreamPolicies(t *testing.T) {
+	hcmc := model.HttpConnectionManagerConfig{
+		RouteConfig: model.RouteConfiguration{Routes: []*model.Router{{
+			ID:    "sse",
+			Match: model.RouterMatch{Prefix: "/events"},
+			Route: model.RouteAction{Stream: &model.StreamPolicy{HeartbeatInterval: "10 seconds"}},
+		}}},
+	}
+	_, err := CreateHttpConnectionManager(&hcmc, nil)
+	assert.Error(t, err)
+
+	hcmc.RouteConfig.Routes[0].Route.Stream = &model.StreamPolicy{FlushInterval: "-1s"}
+	_, err = CreateHttpConnectionManager(&hcmc, nil)
+	assert.Error(t, err)
+
+	policy := &model.StreamPolicy{FlushInterval: "100ms", HeartbeatInterval: "15s"}
+	hcmc.RouteConfig.Routes[0].Route.Stream = policy
+	_, err = CreateHttpConnectionManager(&hcmc, nil)
+	assert.NoError(t, err)
+	flush, heartbeat, err := policy.Intervals()
+	assert.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, flush)
+	assert.Equal(t, 15*time.Second, heartbeat)
+}
+
+func TestStreamThroughFilters(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("first,"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("second"))
+	}))
+	defer upstream.Close()
+
+	hcm := &HttpConnectionManager{config: &model.HttpConnectionManagerConfig{StreamThreshold: 1}, filterManager: filter.NewEmptyFilterManager()}
+	resp, err := http.Get(upstream.URL)
+	assert.NoError(t, err)
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock", nil)
+	assert.NoError(t, err)
+	c := mock.GetMockHTTPContext(request)
+	recorder := httptest.NewRecorder()
+	c.Writer = recorder
+	c.SourceResp = resp
+	hcm.handleHTTPRequest(c)
+
+	// the chunked body is not buffered in the target response
+	assert.Empty(t, c.TargetResp.Data)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "first,second", recorder.Body.String())
+}
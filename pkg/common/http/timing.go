@@ -0,0 +1,39 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	pch "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// writeFilterTimings reply the filter timings in header when the tracing is enabled, the local reply
+// has been written by the filter, so the timings are only logged
+func writeFilterTimings(c *pch.HttpContext) {
+	timings := filter.GetFilterTimings(c)
+	if timings == nil {
+		return
+	}
+	if c.LocalReply() {
+		logger.Debugf("[dubbo-go-pixiu] filter timings of %s: %s", c.GetUrl(), timings)
+		return
+	}
+	c.AddHeader(constant.HeaderKeyFilterTimings, timings.String())
+}
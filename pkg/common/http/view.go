@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"mime"
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util"
+	pch "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	// viewQueryParam the query param to choose the view of dubbo result, e.g. ?view=flat
+	viewQueryParam = "view"
+	// mediaTypeFlatJson the media type in Accept header to choose the flat view
+	mediaTypeFlatJson = "application/vnd.pixiu.flat+json"
+)
+
+// dubboResponse render the dubbo result by the view negotiated with client
+func dubboResponse(c *pch.HttpContext, res interface{}) *client.Response {
+	ra := c.GetRouteEntry()
+	if ra == nil || ra.ResponseView == "" {
+		return util.NewDubboResponse(res, false)
+	}
+	c.AddHeader("Vary", "Accept")
+	if negotiateView(c, ra.ResponseView) == model.ResponseViewFlat {
+		return util.NewFlatDubboResponse(res)
+	}
+	return util.NewDubboResponse(res, false)
+}
+
+// negotiateView the query param takes precedence over the Accept header
+func negotiateView(c *pch.HttpContext, def string) string {
+	switch v := strings.ToLower(c.Request.URL.Query().Get(viewQueryParam)); v {
+	case model.ResponseViewFull, model.ResponseViewFlat:
+		return v
+	}
+	for _, part := range strings.Split(c.Request.Header.Get("Accept"), ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mt {
+		case mediaTypeFlatJson:
+			return model.ResponseViewFlat
+		case "application/json":
+			return model.ResponseViewFull
+		}
+	}
+	return def
+}
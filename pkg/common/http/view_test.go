@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestDubboResponseView(t *testing.T) {
+	result := map[interface{}]interface{}{
+		"class": "com.dubbogo.pixiu.Student",
+		"name":  "tc",
+		"age":   18,
+		"father": map[interface{}]interface{}{
+			"class": "com.dubbogo.pixiu.Student",
+			"name":  "bob",
+		},
+	}
+	full := `{"age":18,"father":{"name":"bob"},"name":"tc"}`
+	flat := `{"age":18,"father.name":"bob","name":"tc"}`
+
+	tests := []struct {
+		name     string
+		view     string
+		url      string
+		accept   string
+		expected string
+	}{
+		{name: "no negotiation", url: "/api/v1/user?view=flat", expected: full},
+		{name: "route default", view: model.ResponseViewFlat, url: "/api/v1/user", expected: flat},
+		{name: "query flag", view: model.ResponseViewFull, url: "/api/v1/user?view=flat", expected: flat},
+		{name: "accept header", view: model.ResponseViewFull, url: "/api/v1/user", accept: "application/vnd.pixiu.flat+json", expected: flat},
+		{name: "accept json", view: model.ResponseViewFlat, url: "/api/v1/user", accept: "text/html, application/json;q=0.9", expected: full},
+		{name: "query over accept", view: model.ResponseViewFull, url: "/api/v1/user?view=full", accept: "application/vnd.pixiu.flat+json", expected: full},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hcm, err := CreateHttpConnectionManager(&model.HttpConnectionManagerConfig{}, nil)
+			assert.NoError(t, err)
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com"+tt.url, nil)
+			assert.NoError(t, err)
+			if tt.accept != "" {
+				request.Header.Set("Accept", tt.accept)
+			}
+			c := mock.GetMockHTTPContext(request)
+			c.RouteEntry(&model.RouteAction{Cluster: "test_dubbo", ResponseView: tt.view})
+			c.SourceResp = result
+
+			hcm.buildTargetResponse(c)
+			assert.Equal(t, http.StatusOK, c.GetStatusCode())
+			assert.JSONEq(t, tt.expected, string(c.TargetResp.Data))
+		})
+	}
+}
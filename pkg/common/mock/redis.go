@@ -0,0 +1,217 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mock
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/redis"
+)
+
+type (
+	// RedisServer the in-memory redis speaking RESP on a local port for tests, it supports
+	// GET, SET with NX and PX, DEL, INCR, PEXPIRE, PTTL and EVAL of redis.DelIfEqualScript
+	RedisServer struct {
+		ln net.Listener
+
+		mu      sync.Mutex
+		values  map[string]string
+		expires map[string]time.Time
+	}
+)
+
+// NewRedisServer start the server, it should be closed by Close
+func NewRedisServer() (*RedisServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &RedisServer{ln: ln, values: make(map[string]string), expires: make(map[string]time.Time)}
+	go s.serve()
+	return s, nil
+}
+
+// Addr the address of the server
+func (s *RedisServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stop the server
+func (s *RedisServer) Close() error {
+	return s.ln.Close()
+}
+
+func (s *RedisServer) serve() {
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(c)
+	}
+}
+
+func (s *RedisServer) handle(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(c, s.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (s *RedisServer) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(args) == 0 {
+		return "-ERR empty command\r\n"
+	}
+	switch strings.ToUpper(args[0]) {
+	case "PING", "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := s.get(args[1])
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "SET":
+		nx := false
+		var expireAt time.Time
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "PX":
+				i++
+				ms, _ := strconv.ParseInt(args[i], 10, 64)
+				expireAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			}
+		}
+		if _, ok := s.get(args[1]); ok && nx {
+			return "$-1\r\n"
+		}
+		s.values[args[1]] = args[2]
+		delete(s.expires, args[1])
+		if !expireAt.IsZero() {
+			s.expires[args[1]] = expireAt
+		}
+		return "+OK\r\n"
+	case "DEL":
+		n := 0
+		for _, k := range args[1:] {
+			if _, ok := s.get(k); ok {
+				s.del(k)
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "INCR":
+		v, _ := s.get(args[1])
+		n, _ := strconv.ParseInt(v, 10, 64)
+		n++
+		s.values[args[1]] = strconv.FormatInt(n, 10)
+		return fmt.Sprintf(":%d\r\n", n)
+	case "PEXPIRE":
+		if _, ok := s.get(args[1]); !ok {
+			return ":0\r\n"
+		}
+		ms, _ := strconv.ParseInt(args[2], 10, 64)
+		s.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return ":1\r\n"
+	case "PTTL":
+		if _, ok := s.get(args[1]); !ok {
+			return ":-2\r\n"
+		}
+		expireAt, ok := s.expires[args[1]]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", time.Until(expireAt).Milliseconds())
+	case "EVAL":
+		if args[1] != redis.DelIfEqualScript {
+			return "-ERR unsupported script\r\n"
+		}
+		if v, ok := s.get(args[3]); ok && v == args[4] {
+			s.del(args[3])
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+// get the value of the key not expired, the caller must hold the lock
+func (s *RedisServer) get(key string) (string, bool) {
+	if expireAt, ok := s.expires[key]; ok && !time.Now().Before(expireAt) {
+		s.del(key)
+	}
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func (s *RedisServer) del(key string) {
+	delete(s.values, key)
+	delete(s.expires, key)
+}
+
+func bulk(v string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+}
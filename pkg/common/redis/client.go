@@ -0,0 +1,248 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package redis is the minimal redis client of the RESP protocol shared by the filters keeping their state in redis.
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout  = time.Second
+	defaultPoolSize = 10
+
+	// DelIfEqualScript delete the key only if its value equals the argument
+	DelIfEqualScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`
+)
+
+type (
+	// Config the config of redis client
+	Config struct {
+		// Address the host:port of redis
+		Address  string `yaml:"address" json:"address" mapstructure:"address"`
+		Password string `yaml:"password" json:"password" mapstructure:"password"`
+		DB       int    `yaml:"db" json:"db" mapstructure:"db"`
+		// Timeout the dial, read and write timeout, 1s by default
+		Timeout string `yaml:"timeout" json:"timeout" mapstructure:"timeout"`
+		// PoolSize the max connections, 10 by default
+		PoolSize int `yaml:"pool_size" json:"pool_size" mapstructure:"pool_size"`
+	}
+
+	// Client the redis client with a bounded connection pool, it is safe for concurrent use
+	Client struct {
+		cfg     *Config
+		timeout time.Duration
+		// slots bound the open connections, idle keep the reusable ones
+		slots chan struct{}
+		idle  chan *conn
+	}
+
+	// Error the error replied by redis, the connection is still usable
+	Error string
+
+	conn struct {
+		net.Conn
+		r *bufio.Reader
+	}
+)
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// NewClient create the client, the connections are dialed on demand
+func NewClient(cfg *Config) (*Client, error) {
+	if cfg == nil || cfg.Address == "" {
+		return nil, errors.New("redis address is required")
+	}
+	timeout := defaultTimeout
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "redis timeout parse fail")
+		}
+		timeout = d
+	}
+	size := cfg.PoolSize
+	if size <= 0 {
+		size = defaultPoolSize
+	}
+	return &Client{
+		cfg:     cfg,
+		timeout: timeout,
+		slots:   make(chan struct{}, size),
+		idle:    make(chan *conn, size),
+	}, nil
+}
+
+// Do send the command and return the reply: string for the simple and bulk strings, int64 for the integers,
+// []interface{} for the arrays and nil for the nil bulk string. The error reply is returned as Error.
+func (c *Client) Do(args ...string) (interface{}, error) {
+	c.slots <- struct{}{}
+	defer func() { <-c.slots }()
+
+	cn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.do(c.timeout, args)
+	if _, ok := err.(Error); err != nil && !ok {
+		_ = cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// DelIfEqual delete the key only if its value equals value atomically, it returns whether the key is deleted
+func (c *Client) DelIfEqual(key, value string) (bool, error) {
+	reply, err := c.Do("EVAL", DelIfEqualScript, "1", key, value)
+	if err != nil {
+		return false, err
+	}
+	n, _ := reply.(int64)
+	return n == 1, nil
+}
+
+// Close close the idle connections
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.idle:
+			_ = cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Client) get() (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+	nc, err := net.DialTimeout("tcp", c.cfg.Address, c.timeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dial redis %s fail", c.cfg.Address)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.cfg.Password != "" {
+		if _, err := cn.do(c.timeout, []string{"AUTH", c.cfg.Password}); err != nil {
+			_ = cn.Close()
+			return nil, errors.Wrap(err, "redis auth fail")
+		}
+	}
+	if c.cfg.DB != 0 {
+		if _, err := cn.do(c.timeout, []string{"SELECT", strconv.Itoa(c.cfg.DB)}); err != nil {
+			_ = cn.Close()
+			return nil, errors.Wrap(err, "redis select db fail")
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		_ = cn.Close()
+	}
+}
+
+func (cn *conn) do(timeout time.Duration, args []string) (interface{}, error) {
+	if err := cn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := cn.Write(encodeCommand(args)); err != nil {
+		return nil, err
+	}
+	return readReply(cn.r)
+}
+
+// encodeCommand encode the command as the array of bulk strings
+func encodeCommand(args []string) []byte {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, fmt.Sprintf("*%d\r\n", len(args))...)
+	for _, arg := range args {
+		buf = append(buf, fmt.Sprintf("$%d\r\n", len(arg))...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.Errorf("invalid redis reply %q", line)
+	}
+	line = line[:len(line)-2]
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.Errorf("invalid redis bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.Errorf("invalid redis array length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				if _, ok := err.(Error); !ok {
+					return nil, err
+				}
+				items[i] = err
+			}
+		}
+		return items, nil
+	}
+	return nil, errors.Errorf("invalid redis reply %q", line)
+}
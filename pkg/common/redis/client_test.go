@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redis_test
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/redis"
+)
+
+func TestClient(t *testing.T) {
+	s, err := mock.NewRedisServer()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	c, err := redis.NewClient(&redis.Config{Address: s.Addr(), Password: "secret", DB: 1, PoolSize: 2})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	reply, err := c.Do("SET", "k", "v\r\nwith crlf", "NX", "PX", "60000")
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", reply)
+	reply, err = c.Do("SET", "k", "other", "NX")
+	assert.NoError(t, err)
+	assert.Nil(t, reply)
+	reply, err = c.Do("GET", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v\r\nwith crlf", reply)
+
+	deleted, err := c.DelIfEqual("k", "other")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+	deleted, err = c.DelIfEqual("k", "v\r\nwith crlf")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	reply, err = c.Do("INCR", "n")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), reply)
+
+	// the error reply keeps the connection usable
+	_, err = c.Do("UNKNOWN")
+	_, ok := err.(redis.Error)
+	assert.True(t, ok)
+	reply, err = c.Do("GET", "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, reply)
+
+	_, err = redis.NewClient(&redis.Config{})
+	assert.Error(t, err)
+}
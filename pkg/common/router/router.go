@@ -91,6 +91,10 @@ func (rm *RouterCoordinator) OnAddRouter(r *model.Router) {
 	if r.Match.Methods == nil {
 		r.Match.Methods = []string{constant.Get, constant.Put, constant.Delete, constant.Post}
 	}
+	if r.Route.Stream != nil {
+		// the invalid policy fails the stream when used
+		_ = r.Route.Stream.Compile()
+	}
 	isPrefix := r.Match.Prefix != ""
 	for _, method := range r.Match.Methods {
 		var key string
@@ -99,7 +103,9 @@ func (rm *RouterCoordinator) OnAddRouter(r *model.Router) {
 		} else {
 			key = getTrieKey(method, r.Match.Path, isPrefix)
 		}
-		_, _ = rm.activeConfig.RouteTrie.Put(key, r.Route)
+		route := r.Route
+		route.Pattern = method + " " + strings.TrimPrefix(key, method)
+		_, _ = rm.activeConfig.RouteTrie.Put(key, route)
 	}
 }
 
@@ -34,6 +34,36 @@ func NewDubboResponse(data interface{}, hump bool) *client.Response {
 	return &client.Response{Data: bytes}
 }
 
+// NewFlatDubboResponse create the flattened view of dubbo result, the nested keys are joined by dot,
+// e.g. {"father":{"name":"bob"}} turns to {"father.name":"bob"}, the list is kept as it is
+func NewFlatDubboResponse(data interface{}) *client.Response {
+	r, _ := dealResp(data, false)
+	if m, ok := r.(map[string]interface{}); ok {
+		flat := make(map[string]interface{}, len(m))
+		flatten("", m, flat)
+		r = flat
+	}
+	bytes, _ := json.Marshal(r)
+	return &client.Response{Data: bytes}
+}
+
+func flatten(prefix string, in interface{}, out map[string]interface{}) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch v := in.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			flatten(join(k), e, out)
+		}
+	default:
+		out[prefix] = in
+	}
+}
+
 func dealResp(in interface{}, HumpToLine bool) (interface{}, error) {
 	if in == nil {
 		return in, nil
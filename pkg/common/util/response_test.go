@@ -93,3 +93,21 @@ func TestNewDubboSliceResponse(t *testing.T) {
 	assert.Equal(t, "tc", r["name"])
 	assert.Equal(t, "bob", r["father"].(map[string]interface{})["name"])
 }
+
+func TestNewFlatDubboResponse(t *testing.T) {
+	resp := map[string]interface{}{
+		"name":  "tc",
+		"hobby": []string{"read"},
+		"father": map[string]interface{}{
+			"name": "bob",
+			"address": map[string]interface{}{
+				"city": "hz",
+			},
+		},
+	}
+	result := NewFlatDubboResponse(resp)
+	assert.JSONEq(t, `{"name":"tc","hobby":["read"],"father.name":"bob","father.address.city":"hz"}`, string(result.Data))
+
+	result = NewFlatDubboResponse("tc")
+	assert.Equal(t, `"tc"`, string(result.Data))
+}
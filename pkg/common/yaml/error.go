@@ -0,0 +1,146 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package yaml
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+import (
+	"gopkg.in/yaml.v2"
+)
+
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+type (
+	// FieldError locate one unmarshal failure in the config, Key is the dotted path of the offending key.
+	// The config reaches here as a map, the positions in the source file are lost, so only the path is reported.
+	FieldError struct {
+		Key     string
+		Message string
+	}
+
+	// ConfigError the config can not be unmarshalled into the config struct
+	ConfigError struct {
+		Fields []*FieldError
+	}
+)
+
+func (e *FieldError) Error() string {
+	if e.Key == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("key %s: %s", e.Key, e.Message)
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		msgs = append(msgs, field.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// newConfigError locate the keys of yaml.TypeError by the lines of the marshalled config,
+// the other errors are returned as they are
+func newConfigError(err error, yamlBytes []byte) error {
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return err
+	}
+	lines := strings.Split(string(yamlBytes), "\n")
+	ce := &ConfigError{}
+	for _, msg := range typeErr.Errors {
+		m := typeErrorLine.FindStringSubmatch(msg)
+		if m == nil {
+			ce.Fields = append(ce.Fields, &FieldError{Message: msg})
+			continue
+		}
+		line, _ := strconv.Atoi(m[1])
+		ce.Fields = append(ce.Fields, &FieldError{Key: locateKey(lines, line-1), Message: m[2]})
+	}
+	return ce
+}
+
+// locateKey return the dotted key path of the line by walking up the indentation,
+// the index of sequence item is written as key[i]
+func locateKey(lines []string, index int) string {
+	if index < 0 || index >= len(lines) {
+		return ""
+	}
+	indent, key, item := splitLine(lines[index])
+	path := []string{key}
+	for i := index - 1; i >= 0 && indent > 0; i-- {
+		lineIndent, lineKey, lineItem := splitLine(lines[i])
+		if lineItem && lineIndent == indent {
+			// the sequence item holding the key, the siblings above are counted by countItems
+			item = true
+			continue
+		}
+		if lineIndent >= indent || lineKey == "" {
+			continue
+		}
+		if item {
+			path[0] = fmt.Sprintf("%s[%d]", lineKey, countItems(lines, i, index, indent)) + trimKey(path[0])
+		} else {
+			path = append([]string{lineKey}, path...)
+		}
+		indent, item = lineIndent, lineItem
+	}
+	return strings.Trim(strings.Join(path, "."), ".")
+}
+
+func trimKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	return "." + key
+}
+
+// countItems count the sequence items of the parent at line from, before line to
+func countItems(lines []string, from, to, indent int) int {
+	n := -1
+	for i := from + 1; i <= to; i++ {
+		if lineIndent, _, lineItem := splitLine(lines[i]); lineItem && lineIndent == indent {
+			n++
+		}
+	}
+	return n
+}
+
+// splitLine return the indentation of the key, the key and whether the line starts a sequence item
+func splitLine(line string) (int, string, bool) {
+	content := strings.TrimLeft(line, " ")
+	indent := len(line) - len(content)
+	item := false
+	if strings.HasPrefix(content, "- ") || content == "-" {
+		item = true
+		content = strings.TrimLeft(strings.TrimPrefix(content, "-"), " ")
+		indent = len(line) - len(content)
+	}
+	key := content
+	if i := strings.Index(content, ":"); i >= 0 {
+		key = content[:i]
+	} else {
+		key = ""
+	}
+	return indent, strings.Trim(key, `"'`), item
+}
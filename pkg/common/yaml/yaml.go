@@ -18,7 +18,6 @@
 package yaml
 
 import (
-	"fmt"
 	"io/ioutil"
 	"path"
 )
@@ -60,7 +59,8 @@ func MarshalYML(in interface{}) ([]byte, error) {
 	return yaml.Marshal(in)
 }
 
-// ParseConfig get config struct from map[string]interface{}
+// ParseConfig get config struct from map[string]interface{}, the unmarshal failure is returned
+// as *ConfigError which locates the offending keys
 func ParseConfig(factoryConfStruct interface{}, conf map[string]interface{}) error {
 	// conf will be map, convert to yaml
 	yamlBytes, err := yaml.Marshal(conf)
@@ -68,10 +68,20 @@ func ParseConfig(factoryConfStruct interface{}, conf map[string]interface{}) err
 		return err
 	}
 	// Unmarshal yamlStr to factoryConf
-	err = yaml.Unmarshal(yamlBytes, factoryConfStruct)
+	if err = yaml.Unmarshal(yamlBytes, factoryConfStruct); err != nil {
+		return newConfigError(err, yamlBytes)
+	}
+	return nil
+}
+
+// ParseConfigStrict is like ParseConfig, but the key not found in the config struct is an error
+func ParseConfigStrict(factoryConfStruct interface{}, conf map[string]interface{}) error {
+	yamlBytes, err := yaml.Marshal(conf)
 	if err != nil {
-		fmt.Println(err)
 		return err
 	}
+	if err = yaml.UnmarshalStrict(yamlBytes, factoryConfStruct); err != nil {
+		return newConfigError(err, yamlBytes)
+	}
 	return nil
 }
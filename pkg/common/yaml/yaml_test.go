@@ -68,3 +68,50 @@ type Config struct {
 type ChildConfig struct {
 	StrTest string `default:"strTest" yaml:"strTest"  json:"strTest,omitempty"`
 }
+
+func TestParseConfigError(t *testing.T) {
+	type rule struct {
+		Name  string `yaml:"name"`
+		Burst int    `yaml:"burst"`
+	}
+	type limit struct {
+		Rate  int     `yaml:"rate"`
+		Rules []*rule `yaml:"rules"`
+	}
+	conf := map[string]interface{}{
+		"rate": 10,
+		"rules": []interface{}{
+			map[string]interface{}{"name": "a", "burst": 1},
+			map[string]interface{}{"name": "b", "burst": "abc"},
+		},
+	}
+	err := ParseConfig(&limit{}, conf)
+	ce, ok := err.(*ConfigError)
+	assert.True(t, ok, err)
+	assert.Len(t, ce.Fields, 1)
+	assert.Equal(t, "rules[1].burst", ce.Fields[0].Key)
+	assert.Contains(t, err.Error(), "key rules[1].burst")
+	assert.NotContains(t, err.Error(), "line")
+
+	err = ParseConfig(&struct {
+		Limit limit `yaml:"limit"`
+	}{}, map[string]interface{}{"limit": map[string]interface{}{"rate": "fast"}})
+	ce, ok = err.(*ConfigError)
+	assert.True(t, ok, err)
+	assert.Equal(t, "limit.rate", ce.Fields[0].Key)
+
+	assert.Nil(t, ParseConfig(&limit{}, map[string]interface{}{"rate": 1}))
+}
+
+func TestParseConfigStrict(t *testing.T) {
+	conf := map[string]interface{}{"strTest": "a", "intTestt": 1}
+	c := &Config{}
+	assert.Nil(t, ParseConfig(c, conf))
+	assert.Equal(t, "a", c.StrTest)
+
+	err := ParseConfigStrict(&Config{}, conf)
+	ce, ok := err.(*ConfigError)
+	assert.True(t, ok, err)
+	assert.Equal(t, "intTestt", ce.Fields[0].Key)
+	assert.Contains(t, ce.Fields[0].Message, "field intTestt not found")
+}
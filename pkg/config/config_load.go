@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"log"
 	"path/filepath"
+	"sync"
 )
 
 import (
@@ -29,6 +30,8 @@ import (
 	"github.com/ghodss/yaml"
 
 	"github.com/goinggo/mapstructure"
+
+	perrors "github.com/pkg/errors"
 )
 
 import (
@@ -38,7 +41,9 @@ import (
 )
 
 var (
-	configPath     string
+	configPath string
+	// configLock guard config, it is replaced by the remote config poller while the server is running
+	configLock     sync.RWMutex
 	config         *model.Bootstrap
 	configLoadFunc LoadFunc = LoadYAMLConfig
 )
@@ -48,9 +53,17 @@ type LoadFunc func(path string) *model.Bootstrap
 
 // GetBootstrap get config global, need a better name
 func GetBootstrap() *model.Bootstrap {
+	configLock.RLock()
+	defer configLock.RUnlock()
 	return config
 }
 
+func setBootstrap(bs *model.Bootstrap) {
+	configLock.Lock()
+	defer configLock.Unlock()
+	config = bs
+}
+
 // Load config file and parse
 func Load(path string) *model.Bootstrap {
 	logger.Infof("[dubbopixiu go] load path:%s", path)
@@ -59,9 +72,9 @@ func Load(path string) *model.Bootstrap {
 		RegisterConfigLoadFunc(LoadYAMLConfig)
 	}
 	if cfg := configLoadFunc(configPath); cfg != nil {
-		config = cfg
+		setBootstrap(cfg)
 	}
-	return config
+	return GetBootstrap()
 }
 
 // RegisterConfigLoadFunc can replace a new config load function instead of default
@@ -84,19 +97,29 @@ func LoadYAMLConfig(path string) *model.Bootstrap {
 	if err != nil {
 		log.Fatalln("[config] [yaml load] load config failed, ", err)
 	}
+	cfg, err := ParseYAMLConfig(content)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return cfg
+}
+
+// ParseYAMLConfig parse yaml content into bootstrap config, the anchors, aliases and merge keys are resolved
+// here, so the block shared by filters, e.g. under an unknown top level key, is copied into each filter config
+func ParseYAMLConfig(content []byte) (*model.Bootstrap, error) {
 	cfg := &model.Bootstrap{}
-	err = yaml.Unmarshal(content, cfg)
+	err := yaml.Unmarshal(content, cfg)
 	if err != nil {
-		log.Fatalln("[config] [yaml load] convert YAML to JSON failed, ", err)
+		return nil, perrors.Wrap(err, "[config] [yaml load] convert YAML to JSON failed")
 	}
 	if err = defaults.Set(cfg); err != nil {
-		log.Fatalln("[config] [yaml load] initialize structs with default value failed, ", err)
+		return nil, perrors.Wrap(err, "[config] [yaml load] initialize structs with default value failed")
 	}
 	err = Adapter(cfg)
 	if err != nil {
-		log.Fatalln("[config] [yaml load] yaml unmarshal config failed, ", err)
+		return nil, perrors.Wrap(err, "[config] [yaml load] yaml unmarshal config failed")
 	}
-	return cfg
+	return cfg, nil
 }
 
 func Adapter(cfg *model.Bootstrap) (err error) {
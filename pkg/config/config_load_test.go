@@ -30,6 +30,7 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/yaml"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
 )
 
@@ -154,3 +155,58 @@ func TestStruct2JSON(t *testing.T) {
 		t.Log(string(bytes))
 	}
 }
+
+const anchorConfig = `
+shared:
+  redis: &redis
+    address: "127.0.0.1:6379"
+    db: 0
+    timeout: "1s"
+static_resources:
+  listeners:
+    - name: "net/http"
+      protocol_type: "HTTP"
+      address:
+        socket_address:
+          address: "0.0.0.0"
+          port: 8888
+      filter_chains:
+        filters:
+          - name: dgp.filter.httpconnectionmanager
+            config:
+              http_filters:
+                - name: dgp.filter.http.ratelimit
+                  config:
+                    redis: *redis
+                - name: dgp.filter.http.cache
+                  config:
+                    redis:
+                      <<: *redis
+                      db: 2
+`
+
+func TestParseYAMLConfigAnchor(t *testing.T) {
+	type redisConfig struct {
+		Address string `yaml:"address"`
+		DB      int    `yaml:"db"`
+		Timeout string `yaml:"timeout"`
+	}
+	type filterConfig struct {
+		Redis redisConfig `yaml:"redis"`
+	}
+
+	bs, err := ParseYAMLConfig([]byte(anchorConfig))
+	assert.Nil(t, err)
+
+	hcmc := &model.HttpConnectionManagerConfig{}
+	assert.Nil(t, yaml.ParseConfig(hcmc, bs.StaticResources.Listeners[0].FilterChain.Filters[0].Config))
+	assert.Equal(t, 2, len(hcmc.HTTPFilters))
+
+	// the alias is resolved before each filter parses its config
+	rateLimit, cache := &filterConfig{}, &filterConfig{}
+	assert.Nil(t, yaml.ParseConfig(rateLimit, hcmc.HTTPFilters[0].Config))
+	assert.Nil(t, yaml.ParseConfig(cache, hcmc.HTTPFilters[1].Config))
+	assert.Equal(t, redisConfig{Address: "127.0.0.1:6379", DB: 0, Timeout: "1s"}, rateLimit.Redis)
+	// the merged block overrides the shared keys
+	assert.Equal(t, redisConfig{Address: "127.0.0.1:6379", DB: 2, Timeout: "1s"}, cache.Redis)
+}
@@ -0,0 +1,250 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	etcdv3 "github.com/dubbogo/gost/database/kv/etcd/v3"
+
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const defaultRemotePollInterval = 30 * time.Second
+
+type (
+	// RemoteSource the remote source where the bootstrap config is stored
+	RemoteSource interface {
+		// Fetch return the config content and its version. If the remote version equals to
+		// the given version, changed is false and the content is nil, so that redundant reload is avoided.
+		Fetch(version string) (content []byte, newVersion string, changed bool, err error)
+	}
+
+	// HTTPRemoteSource fetch config from a http url, the ETag header is used as version
+	HTTPRemoteSource struct {
+		URL    string
+		Client *http.Client
+	}
+
+	// EtcdRemoteSource fetch config from an etcd key, the revision of the key is used as version
+	EtcdRemoteSource struct {
+		Key    string
+		client *etcdv3.Client
+	}
+
+	// ApplyFunc apply the new bootstrap config to the running server
+	ApplyFunc func(bs *model.Bootstrap) error
+
+	// ValidateFunc check the new bootstrap config before it is applied
+	ValidateFunc func(bs *model.Bootstrap) error
+
+	// RemoteConfigPoller poll the remote source and apply the changed config atomically,
+	// the invalid config will be dropped, and the previous config will be re-applied when apply fail.
+	RemoteConfigPoller struct {
+		source   RemoteSource
+		interval time.Duration
+		validate ValidateFunc
+		apply    ApplyFunc
+
+		mu      sync.Mutex
+		version string
+		current *model.Bootstrap
+
+		stopOnce sync.Once
+		done     chan struct{}
+	}
+)
+
+// Fetch fetch config by http GET with If-None-Match header
+func (s *HTTPRemoteSource) Fetch(version string) ([]byte, string, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if version != "" {
+		req.Header.Set("If-None-Match", version)
+	}
+
+	cli := s.Client
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, "", false, perrors.Wrapf(err, "fetch remote config from %s fail", s.URL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, version, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false, perrors.Errorf("fetch remote config from %s fail, status code %d", s.URL, resp.StatusCode)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag != "" && etag == version {
+		return nil, version, false, nil
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return content, etag, true, nil
+}
+
+// NewEtcdRemoteSource create etcd remote source, address is split by comma
+func NewEtcdRemoteSource(address, key string) (*EtcdRemoteSource, error) {
+	cli, err := etcdv3.NewConfigClientWithErr(
+		etcdv3.WithName(etcdv3.RegistryETCDV3Client),
+		etcdv3.WithTimeout(10*time.Second),
+		etcdv3.WithEndpoints(strings.Split(address, ",")...),
+	)
+	if err != nil {
+		return nil, perrors.Errorf("Init etcd client fail error %s", err)
+	}
+	return &EtcdRemoteSource{Key: key, client: cli}, nil
+}
+
+// Fetch get the value of key and use its revision as version
+func (s *EtcdRemoteSource) Fetch(version string) ([]byte, string, bool, error) {
+	val, rev, err := s.client.GetValAndRev(s.Key)
+	if err != nil {
+		return nil, "", false, perrors.Wrapf(err, "fetch remote config from etcd key %s fail", s.Key)
+	}
+	newVersion := strconv.FormatInt(rev, 10)
+	if newVersion == version {
+		return nil, version, false, nil
+	}
+	return []byte(val), newVersion, true, nil
+}
+
+// NewRemoteSource create the remote source of the config
+func NewRemoteSource(cfg *model.RemoteConfigSource) (RemoteSource, error) {
+	switch cfg.Type {
+	case "http":
+		return &HTTPRemoteSource{URL: cfg.Address}, nil
+	case "etcd":
+		return NewEtcdRemoteSource(cfg.Address, cfg.Key)
+	default:
+		return nil, perrors.Errorf("unknown remote config source type %s", cfg.Type)
+	}
+}
+
+// NewRemoteConfigPoller create remote config poller, validate and apply can be nil
+func NewRemoteConfigPoller(source RemoteSource, interval time.Duration, validate ValidateFunc, apply ApplyFunc) *RemoteConfigPoller {
+	if interval <= 0 {
+		interval = defaultRemotePollInterval
+	}
+	return &RemoteConfigPoller{
+		source:   source,
+		interval: interval,
+		validate: validate,
+		apply:    apply,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start fetch the config and poll the remote source in background
+func (p *RemoteConfigPoller) Start() error {
+	if err := p.Poll(); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.Poll(); err != nil {
+					logger.Warnf("[config] [remote] poll config fail, keep version %s: %v", p.Version(), err)
+				}
+			case <-p.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stop polling
+func (p *RemoteConfigPoller) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// Version return the version of the applied config
+func (p *RemoteConfigPoller) Version() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.version
+}
+
+// Poll fetch the remote config once, the config is applied only when the version changed
+// and it is valid. If apply fail, the previous config is applied again.
+func (p *RemoteConfigPoller) Poll() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	content, version, changed, err := p.source.Fetch(p.version)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	bs, err := ParseYAMLConfig(content)
+	if err != nil {
+		return perrors.Wrapf(err, "remote config version %s is invalid", version)
+	}
+	if p.validate != nil {
+		if err := p.validate(bs); err != nil {
+			return perrors.Wrapf(err, "remote config version %s is invalid", version)
+		}
+	}
+
+	if p.apply != nil {
+		if err := p.apply(bs); err != nil {
+			if p.current != nil {
+				if rbErr := p.apply(p.current); rbErr != nil {
+					logger.Errorf("[config] [remote] rollback to version %s fail: %v", p.version, rbErr)
+				}
+			}
+			return perrors.Wrapf(err, "apply remote config version %s fail, rollback to version %s", version, p.version)
+		}
+	}
+
+	p.version = version
+	p.current = bs
+	setBootstrap(bs)
+	logger.Infof("[config] [remote] apply remote config version %s", version)
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	remoteConfigV1 = `
+static_resources:
+  clusters:
+    - name: "cluster_v1"
+`
+	remoteConfigV2 = `
+static_resources:
+  clusters:
+    - name: "cluster_v2"
+`
+)
+
+// stubRemoteSource deliver the configs one by one
+type stubRemoteSource struct {
+	contents []string
+	versions []string
+	index    int
+	fetched  int
+}
+
+func (s *stubRemoteSource) Fetch(version string) ([]byte, string, bool, error) {
+	s.fetched++
+	if s.index >= len(s.contents) {
+		return nil, version, false, nil
+	}
+	content, newVersion := s.contents[s.index], s.versions[s.index]
+	s.index++
+	if newVersion == version {
+		return nil, version, false, nil
+	}
+	return []byte(content), newVersion, true, nil
+}
+
+func clusterName(bs *model.Bootstrap) string {
+	return bs.StaticResources.Clusters[0].Name
+}
+
+func TestRemoteConfigPollerApply(t *testing.T) {
+	source := &stubRemoteSource{
+		contents: []string{remoteConfigV1, remoteConfigV1, "static_resources: [", remoteConfigV2},
+		versions: []string{"1", "1", "2", "3"},
+	}
+	var applied []string
+	p := NewRemoteConfigPoller(source, 0, nil, func(bs *model.Bootstrap) error {
+		applied = append(applied, clusterName(bs))
+		return nil
+	})
+
+	assert.Nil(t, p.Poll())
+	assert.Equal(t, "1", p.Version())
+	assert.Equal(t, "cluster_v1", clusterName(GetBootstrap()))
+
+	// same version, skip reload
+	assert.Nil(t, p.Poll())
+	assert.Equal(t, []string{"cluster_v1"}, applied)
+
+	// invalid config, keep the applied one
+	assert.Error(t, p.Poll())
+	assert.Equal(t, "1", p.Version())
+	assert.Equal(t, "cluster_v1", clusterName(GetBootstrap()))
+
+	assert.Nil(t, p.Poll())
+	assert.Equal(t, "3", p.Version())
+	assert.Equal(t, "cluster_v2", clusterName(GetBootstrap()))
+	assert.Equal(t, []string{"cluster_v1", "cluster_v2"}, applied)
+}
+
+func TestRemoteConfigPollerRollback(t *testing.T) {
+	source := &stubRemoteSource{
+		contents: []string{remoteConfigV1, remoteConfigV2, remoteConfigV2},
+		versions: []string{"1", "2", "3"},
+	}
+	var applied []string
+	apply := func(bs *model.Bootstrap) error {
+		applied = append(applied, clusterName(bs))
+		if len(applied) == 2 {
+			return errors.New("mock apply fail")
+		}
+		return nil
+	}
+	validate := func(bs *model.Bootstrap) error {
+		if len(bs.StaticResources.Clusters) == 0 {
+			return errors.New("no cluster")
+		}
+		return nil
+	}
+	p := NewRemoteConfigPoller(source, 0, validate, apply)
+
+	assert.Nil(t, p.Poll())
+	assert.Error(t, p.Poll())
+	assert.Equal(t, "1", p.Version())
+	assert.Equal(t, "cluster_v1", clusterName(GetBootstrap()))
+	// apply v2 fail, then rollback to v1
+	assert.Equal(t, []string{"cluster_v1", "cluster_v2", "cluster_v1"}, applied)
+
+	assert.Nil(t, p.Poll())
+	assert.Equal(t, "3", p.Version())
+}
+
+func TestHTTPRemoteSource(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == "v1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "v1")
+		_, _ = w.Write([]byte(remoteConfigV1))
+	}))
+	defer s.Close()
+
+	source := &HTTPRemoteSource{URL: s.URL}
+	content, version, changed, err := source.Fetch("")
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "v1", version)
+	assert.Equal(t, remoteConfigV1, string(content))
+
+	content, version, changed, err = source.Fetch("v1")
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "v1", version)
+	assert.Nil(t, content)
+}
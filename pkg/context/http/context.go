@@ -18,6 +18,7 @@
 package http
 
 import (
+	"bufio"
 	"context"
 	"math"
 	"net"
@@ -29,11 +30,14 @@ import (
 
 import (
 	"github.com/dubbogo/dubbo-go-pixiu-filter/pkg/router"
+
+	"github.com/pkg/errors"
 )
 
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/client"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	ct "github.com/apache/dubbo-go-pixiu/pkg/context"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
 )
@@ -57,6 +61,8 @@ type HttpContext struct {
 	statusCode int
 	// localReplyBody: happen error
 	localReplyBody []byte
+	// abortResp the response of the filter aborting the chain, rendered by filter chain
+	abortResp *AbortResponse
 	// the response context will return.
 	TargetResp *client.Response
 	// client call response.
@@ -65,6 +71,8 @@ type HttpContext struct {
 	HttpConnectionManager model.HttpConnectionManagerConfig
 	Route                 *model.RouteAction
 	Api                   *router.API
+	// Router find the route of the request again when it is changed by filters, see Reroute
+	Router Router
 
 	Request *http.Request
 	Writer  http.ResponseWriter
@@ -76,6 +84,19 @@ type (
 		Message string `json:"message"`
 	}
 
+	// AbortResponse the response of the filter short-circuiting the chain
+	AbortResponse struct {
+		Status int
+		// Body the response body, the body of ErrResponse with the status text is used if empty
+		Body    []byte
+		Headers map[string]string
+	}
+
+	// Router find the route of the request
+	Router interface {
+		Route(hc *HttpContext) (*model.RouteAction, error)
+	}
+
 	// FilterFunc filter func, filter
 	FilterFunc func(c *HttpContext)
 
@@ -100,6 +121,7 @@ func (hc *HttpContext) Reset() {
 	hc.statusCode = 0
 	hc.localReply = false
 	hc.localReplyBody = nil
+	hc.abortResp = nil
 }
 
 // RouteEntry set route
@@ -107,6 +129,19 @@ func (hc *HttpContext) RouteEntry(r *model.RouteAction) {
 	hc.Route = r
 }
 
+// Reroute find the route again after the request is changed, like the path rewritten by filter
+func (hc *HttpContext) Reroute() error {
+	if hc.Router == nil {
+		return errors.New("no router to reroute the request")
+	}
+	ra, err := hc.Router.Route(hc)
+	if err != nil {
+		return err
+	}
+	hc.Route = ra
+	return nil
+}
+
 // GetRouteEntry get route
 func (hc *HttpContext) GetRouteEntry() *model.RouteAction {
 	return hc.Route
@@ -161,6 +196,66 @@ func (hc *HttpContext) GetClientIP() string {
 	return ""
 }
 
+// ParseTrustedProxies parse the addresses or CIDRs of the trusted proxies for GetTrustedClientIP
+func ParseTrustedProxies(cidrs []string) ([]*net.IPNet, error) {
+	trusted := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy %q", cidr)
+		}
+		trusted = append(trusted, ipNet)
+	}
+	return trusted, nil
+}
+
+// GetTrustedClientIP return the peer address, the X-Forwarded-For and X-Real-Ip headers are only read when the peer
+// is trusted, and X-Forwarded-For is walked from the right to the first address not added by a trusted proxy.
+// Unlike GetClientIP, the client can not spoof it by the headers.
+func (hc *HttpContext) GetTrustedClientIP(trusted []*net.IPNet) string {
+	peer := strings.TrimSpace(hc.Request.RemoteAddr)
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	if !isTrusted(peer, trusted) {
+		return peer
+	}
+	if xff := hc.Request.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop != "" && (i == 0 || !isTrusted(hop, trusted)) {
+				return hop
+			}
+		}
+	}
+	if ip := strings.TrimSpace(hc.Request.Header.Get("X-Real-Ip")); ip != "" {
+		return ip
+	}
+	return peer
+}
+
+func isTrusted(ip string, trusted []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range trusted {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetApplicationName get application name
 func (hc *HttpContext) GetApplicationName() string {
 	if u, err := url.Parse(hc.Request.RequestURI); err == nil {
@@ -177,7 +272,9 @@ func (hc *HttpContext) SendLocalReply(status int, body []byte) {
 	hc.statusCode = status
 	hc.localReplyBody = body
 	hc.TargetResp = &client.Response{Data: body}
-	hc.AddHeader(constant.HeaderKeyContextType, constant.HeaderValueTextPlain)
+	if hc.Writer.Header().Get(constant.HeaderKeyContextType) == "" {
+		hc.AddHeader(constant.HeaderKeyContextType, constant.HeaderValueTextPlain)
+	}
 
 	writer := hc.Writer
 	writer.WriteHeader(status)
@@ -187,6 +284,31 @@ func (hc *HttpContext) SendLocalReply(status int, body []byte) {
 	}
 }
 
+// SetAbortResponse keep the response of the aborting filter, it is written by the filter chain when the filter stops
+func (hc *HttpContext) SetAbortResponse(resp *AbortResponse) {
+	hc.abortResp = resp
+}
+
+// GetAbortResponse get the response of the aborting filter, nil if no filter aborts
+func (hc *HttpContext) GetAbortResponse() *AbortResponse {
+	return hc.abortResp
+}
+
+// Hijack take over the connection from gateway, the response will not be written by gateway any more
+func (hc *HttpContext) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := hc.Writer.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijack")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	hc.localReply = true
+	hc.statusCode = http.StatusSwitchingProtocols
+	return conn, rw, nil
+}
+
 func (hc *HttpContext) GetLocalReplyBody() []byte {
 	return hc.localReplyBody
 }
@@ -214,6 +336,24 @@ func (hc *HttpContext) GetAPI() *router.API {
 	return hc.Api
 }
 
+// SetRequestID sets the request id into context, so that it can be shared among filters
+func (hc *HttpContext) SetRequestID(id string) {
+	parent := hc.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	hc.Ctx = context.WithValue(parent, ct.ContextKey(constant.RequestIDContextKey), id)
+}
+
+// GetRequestID get request id, empty if it has not been set
+func (hc *HttpContext) GetRequestID() string {
+	if hc.Ctx == nil {
+		return ""
+	}
+	id, _ := hc.Ctx.Value(ct.ContextKey(constant.RequestIDContextKey)).(string)
+	return id
+}
+
 // Deprecated: Abort  filter chain break , filter after the current filter will not executed.
 func (hc *HttpContext) Abort() {
 	hc.Index = abortIndex
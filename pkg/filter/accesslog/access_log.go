@@ -21,11 +21,18 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"io"
+	"math/rand"
+	stdHttp "net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
+import (
+	"github.com/pkg/errors"
+)
+
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
@@ -47,12 +54,16 @@ type (
 	}
 	// FilterFactory is http filter instance
 	FilterFactory struct {
-		conf *AccessLogConfig
-		alw  *AccessLogWriter
+		conf     *AccessLogConfig
+		alw      *AccessLogWriter
+		template string
+		masker   *masker
 	}
 	Filter struct {
-		conf *AccessLogConfig
-		alw  *AccessLogWriter
+		conf     *AccessLogConfig
+		alw      *AccessLogWriter
+		template string
+		masker   *masker
 
 		start time.Time
 	}
@@ -75,7 +86,7 @@ func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
 
 // PrepareFilterChain prepare chain when http context init
 func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
-	f := &Filter{alw: factory.alw}
+	f := &Filter{alw: factory.alw, conf: factory.conf, template: factory.template, masker: factory.masker}
 	chain.AppendDecodeFilters(f)
 	chain.AppendEncodeFilters(f)
 	return nil
@@ -89,14 +100,42 @@ func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 
 func (f *Filter) Encode(c *http.HttpContext) filter.FilterStatus {
 	latency := time.Since(f.start)
+	if !f.sampled(c) {
+		return filter.Continue
+	}
 	// build access_log message
-	accessLogMsg := buildAccessLogMsg(c, latency)
+	var resp []byte
+	if c.TargetResp != nil {
+		resp = f.masker.mask(c.TargetResp.Data)
+	}
+	var accessLogMsg string
+	if f.template != "" {
+		accessLogMsg = buildTemplateMsg(f.template, c, latency, resp)
+	} else {
+		accessLogMsg = buildAccessLogMsg(c, latency, resp)
+	}
 	if len(accessLogMsg) > 0 {
 		f.alw.Writer(AccessLogData{AccessLogConfig: *f.conf, AccessLogMsg: accessLogMsg})
 	}
 	return filter.Continue
 }
 
+// EncodeStream log the streamed response once its body is written, the body is not logged
+func (f *Filter) EncodeStream(c *http.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	return filter.CloseFuncWriter(w, func() { f.Encode(c) }), filter.Continue
+}
+
+// sampled check whether the request should be logged, the 4xx/5xx responses are always logged if AlwaysLogErrors
+func (f *Filter) sampled(c *http.HttpContext) bool {
+	if f.conf.SampleRate <= 0 || f.conf.SampleRate >= 1 {
+		return true
+	}
+	if f.conf.AlwaysLogErrors && c.GetStatusCode() >= stdHttp.StatusBadRequest {
+		return true
+	}
+	return rand.Float64() < f.conf.SampleRate
+}
+
 // Config return config of filter
 func (factory *FilterFactory) Config() interface{} {
 	return factory.conf
@@ -104,12 +143,22 @@ func (factory *FilterFactory) Config() interface{} {
 
 // Apply init after config set
 func (factory *FilterFactory) Apply() error {
+	if factory.conf.SampleRate < 0 || factory.conf.SampleRate > 1 {
+		return errors.Errorf("access log sample rate %v must be in [0, 1]", factory.conf.SampleRate)
+	}
+	factory.template = resolveTemplate(factory.conf.Format)
+	m, err := newMasker(factory.conf.MaskFields, factory.conf.MaskReplacement)
+	if err != nil {
+		return err
+	}
+	factory.masker = m
 	// init
 	factory.alw.Write()
 	return nil
 }
 
-func buildAccessLogMsg(c *http.HttpContext, cost time.Duration) string {
+// buildAccessLogMsg build the legacy message, resp is the logged copy of response body
+func buildAccessLogMsg(c *http.HttpContext, cost time.Duration, resp []byte) string {
 	req := c.Request
 	valueStr := req.URL.Query().Encode()
 	if len(valueStr) != 0 {
@@ -136,7 +185,6 @@ func buildAccessLogMsg(c *http.HttpContext, cost time.Duration) string {
 		builder.WriteString(fmt.Sprintf("invoke err [ %v", err))
 		builder.WriteString("] ")
 	}
-	resp := c.TargetResp.Data
 	if err != nil {
 		builder.WriteString(fmt.Sprintf(" response can not convert to string"))
 		builder.WriteString("] ")
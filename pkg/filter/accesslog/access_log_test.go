@@ -19,8 +19,11 @@ package accesslog
 
 import (
 	"bytes"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -52,6 +55,22 @@ func TestAccessLog_Write_to_file(t *testing.T) {
 	assert.FileExists(t, filePath, nil)
 }
 
+func TestWriteToFileWithRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "access-log")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "access.log")
+
+	// each write after the first one finds the file full, the files rotated in the same second are all kept
+	msg := strings.Repeat("x", 1024*1024)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, WriteToFileWithRotation(msg, filePath, 1))
+	}
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(files))
+}
+
 func TestApply(t *testing.T) {
 	filePath := "dubbo-go-pixiu/logs/dubbo-go-access"
 	msg := "this is test msg"
@@ -77,3 +96,62 @@ func TestApply(t *testing.T) {
 	}
 	assert.FileExists(t, filePath, nil)
 }
+
+func TestBuildTemplateMsg(t *testing.T) {
+	request, _ := http.NewRequest("POST", "http://www.dubbogopixiu.com/mock/test?name=tc", bytes.NewReader([]byte("{\"id\":\"12345\"}")))
+	request.Header.Set("X-Real-Ip", "127.0.0.1")
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.StatusCode(http.StatusOK)
+	ctx.TargetResp = client.NewResponse([]byte("hello"))
+
+	msg := buildTemplateMsg(resolveTemplate("%method% %path% %status% %latency% %bytes%"), ctx, time.Second, nil)
+	assert.Equal(t, "POST /mock/test 200 1s 5", msg)
+
+	msg = buildTemplateMsg(resolveTemplate(FormatCommon), ctx, time.Second, nil)
+	assert.Regexp(t, `^127\.0\.0\.1 - - \[.+\] "POST /mock/test HTTP/1\.1" 200 5$`, msg)
+
+	request.Header.Set("User-Agent", "pixiu-test")
+	msg = buildTemplateMsg(resolveTemplate(FormatCombined), ctx, time.Second, nil)
+	assert.Regexp(t, `" 200 5 "" "pixiu-test"$`, msg)
+}
+
+func TestSampleRate(t *testing.T) {
+	factory := &FilterFactory{
+		conf: &AccessLogConfig{Format: FormatCommon, SampleRate: 2},
+		alw:  &AccessLogWriter{AccessLogDataChan: make(chan AccessLogData, constant.LogDataBuffer)},
+	}
+	assert.Error(t, factory.Apply())
+
+	accessLogWriter := &AccessLogWriter{AccessLogDataChan: make(chan AccessLogData, constant.LogDataBuffer)}
+	f := &Filter{alw: accessLogWriter, conf: &AccessLogConfig{SampleRate: 0.000001}, template: commonTemplate}
+
+	request, _ := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	ctx := mock.GetMockHTTPContext(request)
+	for i := 0; i < 10; i++ {
+		f.Decode(ctx)
+		f.Encode(ctx)
+	}
+	assert.True(t, len(accessLogWriter.AccessLogDataChan) < 10)
+}
+
+func TestAlwaysLogErrors(t *testing.T) {
+	accessLogWriter := &AccessLogWriter{AccessLogDataChan: make(chan AccessLogData, constant.LogDataBuffer)}
+	f := &Filter{alw: accessLogWriter, conf: &AccessLogConfig{SampleRate: 0.000001, AlwaysLogErrors: true}, template: commonTemplate}
+
+	request, _ := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	for _, status := range []int{http.StatusNotFound, http.StatusInternalServerError, http.StatusBadGateway} {
+		ctx := mock.GetMockHTTPContext(request)
+		ctx.StatusCode(status)
+		f.Decode(ctx)
+		f.Encode(ctx)
+	}
+	assert.Equal(t, 3, len(accessLogWriter.AccessLogDataChan))
+
+	for i := 0; i < 10; i++ {
+		ctx := mock.GetMockHTTPContext(request)
+		ctx.StatusCode(http.StatusOK)
+		f.Decode(ctx)
+		f.Encode(ctx)
+	}
+	assert.True(t, len(accessLogWriter.AccessLogDataChan) < 13)
+}
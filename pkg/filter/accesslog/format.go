@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package accesslog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	// FormatCommon the NCSA common log format
+	FormatCommon = "common"
+	// FormatCombined the NCSA combined log format
+	FormatCombined = "combined"
+
+	commonTemplate   = `%remote% - - [%time%] "%method% %path% %proto%" %status% %bytes%`
+	combinedTemplate = commonTemplate + ` "%referer%" "%user_agent%"`
+
+	commonTimeLayout = "02/Jan/2006:15:04:05 -0700"
+)
+
+// resolveTemplate return the template of format, empty means the legacy format
+func resolveTemplate(format string) string {
+	switch format {
+	case FormatCommon:
+		return commonTemplate
+	case FormatCombined:
+		return combinedTemplate
+	default:
+		return format
+	}
+}
+
+// buildTemplateMsg replace the variables in template with the request and response info,
+// resp is the logged copy of response body
+func buildTemplateMsg(tpl string, c *http.HttpContext, cost time.Duration, resp []byte) string {
+	req := c.Request
+	bytes := 0
+	if c.TargetResp != nil {
+		bytes = len(c.TargetResp.Data)
+	}
+
+	replacer := strings.NewReplacer(
+		"%remote%", c.GetClientIP(),
+		"%host%", req.Host,
+		"%time%", time.Now().Format(commonTimeLayout),
+		"%method%", req.Method,
+		"%path%", req.URL.Path,
+		"%query%", req.URL.RawQuery,
+		"%proto%", req.Proto,
+		"%status%", strconv.Itoa(c.GetStatusCode()),
+		"%bytes%", strconv.Itoa(bytes),
+		"%latency%", cost.String(),
+		"%referer%", req.Referer(),
+		"%user_agent%", req.UserAgent(),
+		"%request_id%", c.GetRequestID(),
+		"%response_body%", string(resp),
+	)
+	return replacer.Replace(tpl)
+}
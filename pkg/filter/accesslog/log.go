@@ -18,6 +18,7 @@
 package accesslog
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -32,6 +33,20 @@ import (
 // AccessLogConfig access log will out put into console
 type AccessLogConfig struct {
 	OutPutPath string `yaml:"outPutPath" json:"outPutPath" mapstructure:"outPutPath" default:"console"`
+	// Format common, combined or a custom template such as "%method% %path% %status% %latency% %bytes%",
+	// the legacy format is used when it is empty
+	Format string `yaml:"format" json:"format" mapstructure:"format"`
+	// SampleRate the ratio (0, 1] of requests to be logged, 0 means log all requests
+	SampleRate float64 `yaml:"sampleRate" json:"sampleRate" mapstructure:"sampleRate"`
+	// AlwaysLogErrors the 4xx and 5xx responses are never dropped by sampling
+	AlwaysLogErrors bool `yaml:"alwaysLogErrors" json:"alwaysLogErrors" mapstructure:"alwaysLogErrors"`
+	// MaxSize the max size in megabytes of the log file before it gets rotated, 0 means only rotate by day
+	MaxSize int64 `yaml:"maxSize" json:"maxSize" mapstructure:"maxSize"`
+	// MaskFields the json paths of the logged response body to mask, e.g. $.password, $.data[*].token or $..secret,
+	// only the logged copy is masked, the body replied to client is not changed
+	MaskFields []string `yaml:"maskFields" json:"maskFields" mapstructure:"maskFields"`
+	// MaskReplacement replace the masked values, ****** by default
+	MaskReplacement string `yaml:"maskReplacement" json:"maskReplacement" mapstructure:"maskReplacement"`
 }
 
 // AccessLogWriter access log chan
@@ -73,31 +88,38 @@ func (alw *AccessLogWriter) writeLogToFile(ald AccessLogData) {
 		logger.Info(alm)
 		return
 	}
-	_ = WriteToFile(alm, alc.OutPutPath)
+	_ = WriteToFileWithRotation(alm, alc.OutPutPath, alc.MaxSize)
 }
 
 // WriteToFile write message to access log file
 func WriteToFile(accessLogMsg string, filePath string) error {
+	return WriteToFileWithRotation(accessLogMsg, filePath, 0)
+}
+
+// WriteToFileWithRotation write message to access log file, the file is rotated by day,
+// or when its size reaches maxSize megabytes
+func WriteToFileWithRotation(accessLogMsg string, filePath string, maxSize int64) error {
 	pd := filepath.Dir(filePath)
 	if _, err := os.Stat(pd); err != nil {
 		if os.IsExist(err) {
-			logger.Warnf("can not open log dir: %s, %v", filePath, err)
+			logger.Warnw("can not open log dir", "path", filePath, "error", err.Error())
 		}
 		err = os.MkdirAll(pd, os.ModePerm)
 		if err != nil {
-			logger.Warnf("can not create log dir: %s, %v", filePath, err)
+			logger.Warnw("can not create log dir", "path", filePath, "error", err.Error())
 			return err
 		}
 	}
 	logFile, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_RDWR, constant.LogFileMode)
 	if err != nil {
-		logger.Warnf("can not open the access log file: %s, %v", filePath, err)
+		logger.Warnw("can not open access log file", "path", filePath, "error", err.Error())
 		return err
 	}
 	now := time.Now().Format(constant.FileDateFormat)
 	fileInfo, err := logFile.Stat()
 	if err != nil {
-		logger.Warnf("can not get the info of access log file: %s, %v", filePath, err)
+		logFile.Close()
+		logger.Warnw("can not get the info of access log file", "path", filePath, "error", err.Error())
 		return err
 	}
 	last := fileInfo.ModTime().Format(constant.FileDateFormat)
@@ -107,22 +129,42 @@ func WriteToFile(accessLogMsg string, filePath string) error {
 	// and today is '2020-03-05'
 	// we will create one new file to log access data
 	// By this way, we can split the access log based on days.
+	suffix := ""
 	if now != last {
-		err = os.Rename(fileInfo.Name(), fileInfo.Name()+"."+now)
+		suffix = now
+	} else if maxSize > 0 && fileInfo.Size() >= maxSize*1024*1024 {
+		suffix = time.Now().Format(constant.FileDateFormat + ".150405")
+	}
+	if suffix != "" {
+		logFile.Close()
+		err = os.Rename(filePath, rotatedPath(filePath, suffix))
 		if err != nil {
-			logger.Warnf("can not rename access log file: %s, %v", fileInfo.Name(), err)
+			logger.Warnw("can not rename access log file", "path", filePath, "error", err.Error())
 			return err
 		}
-		logFile, err = os.OpenFile(fileInfo.Name(), os.O_CREATE|os.O_APPEND|os.O_RDWR, constant.LogFileMode)
+		logFile, err = os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_RDWR, constant.LogFileMode)
 		if err != nil {
-			logger.Warnf("can not open access log file: %s, %v", fileInfo.Name(), err)
+			logger.Warnw("can not open access log file", "path", filePath, "error", err.Error())
 			return err
 		}
 	}
+	defer logFile.Close()
 	_, err = logFile.WriteString(accessLogMsg + "\n")
 	if err != nil {
-		logger.Warnf("can not write to access log file: %s, v%", fileInfo.Name(), err)
+		logger.Warnw("can not write to access log file", "path", filePath, "error", err.Error())
 		return err
 	}
 	return nil
 }
+
+// rotatedPath the path the rotated file is renamed to, a sequence is appended if the path is taken,
+// e.g. the file reaches maxSize more than once in a second
+func rotatedPath(filePath, suffix string) string {
+	path := filePath + "." + suffix
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s.%s.%d", filePath, suffix, i)
+	}
+}
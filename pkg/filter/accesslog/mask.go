@@ -0,0 +1,181 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+const defaultMaskReplacement = "******"
+
+type (
+	// masker redact the fields of the json body in the logged copy
+	masker struct {
+		paths       [][]pathToken
+		replacement string
+	}
+
+	// pathToken one step of the json path, e.g. .name, ..name, [1] or [*]
+	pathToken struct {
+		key       string
+		recursive bool
+		index     int
+		all       bool
+	}
+)
+
+// newMasker parse the json paths, the supported syntax is $.a.b, $.a[0].b, $.a[*].b and $..b for any depth
+func newMasker(fields []string, replacement string) (*masker, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if replacement == "" {
+		replacement = defaultMaskReplacement
+	}
+	m := &masker{replacement: replacement}
+	for _, field := range fields {
+		path, err := parsePath(field)
+		if err != nil {
+			return nil, err
+		}
+		m.paths = append(m.paths, path)
+	}
+	return m, nil
+}
+
+func parsePath(field string) ([]pathToken, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(field), "$")
+	var path []pathToken
+	for len(s) > 0 {
+		tok := pathToken{index: -1}
+		switch {
+		case strings.HasPrefix(s, ".."):
+			tok.recursive = true
+			s = s[2:]
+		case s[0] == '.':
+			s = s[1:]
+		}
+		if len(s) > 0 && s[0] == '[' {
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, errors.Errorf("mask field %s has unclosed bracket", field)
+			}
+			if inner := s[1:end]; inner == "*" {
+				tok.all = true
+			} else {
+				i, err := strconv.Atoi(inner)
+				if err != nil || i < 0 {
+					return nil, errors.Errorf("mask field %s has invalid index %s", field, inner)
+				}
+				tok.index = i
+			}
+			s = s[end+1:]
+		} else {
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			tok.key = s[:end]
+			s = s[end:]
+			if tok.key == "" {
+				return nil, errors.Errorf("mask field %s has empty key", field)
+			}
+		}
+		path = append(path, tok)
+	}
+	if len(path) == 0 {
+		return nil, errors.Errorf("mask field %q is empty", field)
+	}
+	return path, nil
+}
+
+// mask return the masked copy of the body, the body which is not json is returned as it is
+func (m *masker) mask(body []byte) []byte {
+	if m == nil || len(body) == 0 {
+		return body
+	}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return body
+	}
+	for _, path := range m.paths {
+		v = m.apply(v, path)
+	}
+	masked, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return masked
+}
+
+func (m *masker) apply(v interface{}, path []pathToken) interface{} {
+	if len(path) == 0 {
+		return m.replacement
+	}
+	tok, rest := path[0], path[1:]
+	if tok.recursive {
+		// match the token at any depth below the current value
+		v = m.descend(v, pathToken{key: tok.key, index: tok.index, all: tok.all}, rest)
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, child := range val {
+				val[k] = m.apply(child, path)
+			}
+		case []interface{}:
+			for i, child := range val {
+				val[i] = m.apply(child, path)
+			}
+		}
+		return v
+	}
+	return m.descend(v, tok, rest)
+}
+
+// descend apply the rest of path to the children matching the token
+func (m *masker) descend(v interface{}, tok pathToken, rest []pathToken) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if tok.key != "" {
+			if child, ok := val[tok.key]; ok {
+				val[tok.key] = m.apply(child, rest)
+			}
+		} else if tok.all {
+			for k, child := range val {
+				val[k] = m.apply(child, rest)
+			}
+		}
+	case []interface{}:
+		if tok.all {
+			for i, child := range val {
+				val[i] = m.apply(child, rest)
+			}
+		} else if tok.index >= 0 && tok.index < len(val) {
+			val[tok.index] = m.apply(val[tok.index], rest)
+		}
+	}
+	return v
+}
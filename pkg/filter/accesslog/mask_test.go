@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package accesslog
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestMask(t *testing.T) {
+	m, err := newMasker([]string{"$.password", "$.user.token", "$.cards[*].number", "$.phones[0]", "$..secret"}, "")
+	assert.Nil(t, err)
+
+	body := `{"name":"tc","password":"p1","user":{"token":"t1","id":1},"cards":[{"number":"6222"},{"number":"6228"}],` +
+		`"phones":["123","456"],"deep":{"a":[{"secret":"s1"}]},"secret":"s2"}`
+	want := `{"cards":[{"number":"******"},{"number":"******"}],"deep":{"a":[{"secret":"******"}]},"name":"tc",` +
+		`"password":"******","phones":["******","456"],"secret":"******","user":{"id":1,"token":"******"}}`
+	assert.Equal(t, want, string(m.mask([]byte(body))))
+
+	// not json, kept as it is
+	assert.Equal(t, "password=p1", string(m.mask([]byte("password=p1"))))
+
+	for _, field := range []string{"$", "$.a[", "$.a[x]", "$.a..", "$.a[-1]"} {
+		_, err := newMasker([]string{field}, "")
+		assert.Error(t, err, field)
+	}
+}
+
+func TestMaskLoggedCopy(t *testing.T) {
+	factory := &FilterFactory{
+		conf: &AccessLogConfig{Format: "%status% %response_body%", MaskFields: []string{"$.token"}, MaskReplacement: "[REDACTED]"},
+		alw:  &AccessLogWriter{AccessLogDataChan: make(chan AccessLogData, constant.LogDataBuffer)},
+	}
+	assert.Nil(t, factory.Apply())
+	// consume nothing, so the logged message stays in the channel
+	alw := &AccessLogWriter{AccessLogDataChan: make(chan AccessLogData, constant.LogDataBuffer)}
+	f := &Filter{alw: alw, conf: factory.conf, template: factory.template, masker: factory.masker}
+
+	request, _ := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/login", nil)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.StatusCode(http.StatusOK)
+	ctx.TargetResp = client.NewResponse([]byte(`{"token":"abc","user":"tc"}`))
+	f.Decode(ctx)
+	f.Encode(ctx)
+
+	data := <-alw.AccessLogDataChan
+	assert.Equal(t, `200 {"token":"[REDACTED]","user":"tc"}`, data.AccessLogMsg)
+	// the forwarded body is not changed
+	assert.Equal(t, `{"token":"abc","user":"tc"}`, string(ctx.TargetResp.Data))
+}
@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apikey
+
+import (
+	"encoding/json"
+	stdHttp "net/http"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPAuthAPIKeyFilter
+
+	locationHeader = "header"
+	locationQuery  = "query"
+
+	defaultKeyLocation  = locationHeader + ":X-API-Key"
+	defaultClientHeader = "X-Client-Name"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance, the keys are rotated by reloading the config
+	FilterFactory struct {
+		cfg      *Config
+		location string
+		name     string
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg      *Config
+		location string
+		name     string
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// KeyLocation where the key is extracted, header:<name> or query:<name>, header:X-API-Key by default
+		KeyLocation string `yaml:"key_location" json:"key_location" mapstructure:"key_location"`
+		// Keys the valid keys mapped to client names
+		Keys map[string]string `yaml:"keys" json:"keys" mapstructure:"keys"`
+		// InjectClient inject the client name of the key as ClientHeader to upstream
+		InjectClient bool `yaml:"inject_client" json:"inject_client" mapstructure:"inject_client"`
+		// ClientHeader the header of client name, X-Client-Name by default
+		ClientHeader string `yaml:"client_header" json:"client_header" mapstructure:"client_header"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter authenticates the request before the body is read
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageAuth
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.KeyLocation == "" {
+		cfg.KeyLocation = defaultKeyLocation
+	}
+	if cfg.ClientHeader == "" {
+		cfg.ClientHeader = defaultClientHeader
+	}
+
+	parts := strings.SplitN(cfg.KeyLocation, ":", 2)
+	if len(parts) != 2 || parts[1] == "" || (parts[0] != locationHeader && parts[0] != locationQuery) {
+		return errors.Errorf("invalid key location %s, header:<name> or query:<name> expected", cfg.KeyLocation)
+	}
+	factory.location, factory.name = parts[0], parts[1]
+	if len(cfg.Keys) == 0 {
+		return errors.New("no api key configured")
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, location: factory.location, name: factory.name}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	key := f.extract(ctx)
+	client, ok := f.cfg.Keys[key]
+	if key == "" || !ok {
+		bt, _ := json.Marshal(http.ErrResponse{Message: "invalid api key"})
+		ctx.SendLocalReply(stdHttp.StatusUnauthorized, bt)
+		return filter.Stop
+	}
+	logger.Debugw("api key accessed", "client", client, "url", ctx.GetUrl())
+	if ctx.Params == nil {
+		ctx.Params = make(map[string]interface{})
+	}
+	ctx.Params[constant.AuthUserParam] = client
+
+	if f.cfg.InjectClient {
+		ctx.Request.Header.Set(f.cfg.ClientHeader, client)
+	}
+	return filter.Continue
+}
+
+func (f *Filter) extract(ctx *http.HttpContext) string {
+	if f.location == locationQuery {
+		return ctx.Request.URL.Query().Get(f.name)
+	}
+	return ctx.GetHeader(f.name)
+}
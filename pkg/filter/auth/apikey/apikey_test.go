@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apikey
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func newFilter(t *testing.T, cfg *Config) *Filter {
+	factory := &FilterFactory{cfg: cfg}
+	assert.Nil(t, factory.Apply())
+	return &Filter{cfg: factory.cfg, location: factory.location, name: factory.name}
+}
+
+func TestAPIKey(t *testing.T) {
+	keys := map[string]string{"key-a": "app-a"}
+	tests := []struct {
+		name     string
+		cfg      *Config
+		url      string
+		header   string
+		status   filter.FilterStatus
+		injected string
+	}{
+		{name: "header", cfg: &Config{Keys: keys, InjectClient: true}, header: "key-a", status: filter.Continue, injected: "app-a"},
+		{name: "no inject", cfg: &Config{Keys: keys}, header: "key-a", status: filter.Continue},
+		{name: "invalid", cfg: &Config{Keys: keys}, header: "key-b", status: filter.Stop},
+		{name: "missing", cfg: &Config{Keys: keys}, status: filter.Stop},
+		{name: "query", cfg: &Config{Keys: keys, KeyLocation: "query:api_key"}, url: "?api_key=key-a", status: filter.Continue},
+		{name: "query in header", cfg: &Config{Keys: keys, KeyLocation: "query:api_key"}, header: "key-a", status: filter.Stop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFilter(t, tt.cfg)
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user"+tt.url, nil)
+			assert.NoError(t, err)
+			if tt.header != "" {
+				request.Header.Set("X-API-Key", tt.header)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+
+			assert.Equal(t, tt.status, f.Decode(ctx))
+			if tt.status == filter.Stop {
+				assert.Equal(t, http.StatusUnauthorized, ctx.GetStatusCode())
+			} else {
+				assert.Equal(t, "app-a", ctx.Params[constant.AuthUserParam])
+			}
+			assert.Equal(t, tt.injected, request.Header.Get(defaultClientHeader))
+		})
+	}
+}
+
+func TestAPIKeyApplyInvalid(t *testing.T) {
+	keys := map[string]string{"key-a": "app-a"}
+	assert.Error(t, (&FilterFactory{cfg: &Config{Keys: keys, KeyLocation: "cookie:key"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Keys: keys, KeyLocation: "header:"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{}}).Apply())
+}
@@ -0,0 +1,292 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package basic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	stdHttp "net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPAuthBasicFilter
+
+	defaultRealm          = "pixiu"
+	defaultReloadInterval = 10 * time.Second
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg   *Config
+		store *credentialStore
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		realm string
+		store *credentialStore
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Realm the realm of WWW-Authenticate header
+		Realm string `yaml:"realm" json:"realm" mapstructure:"realm"`
+		// Users the inline credentials, username to bcrypt hash
+		Users map[string]string `yaml:"users" json:"users" mapstructure:"users"`
+		// HtpasswdFile the htpasswd file with bcrypt hashes, merged with Users and reloaded when modified
+		HtpasswdFile string `yaml:"htpasswd_file" json:"htpasswd_file" mapstructure:"htpasswd_file"`
+		// ReloadInterval the interval to check the modification of htpasswd file, 10s by default
+		ReloadInterval string `yaml:"reload_interval" json:"reload_interval" mapstructure:"reload_interval"`
+	}
+
+	// credentialStore hold the credentials, reload the htpasswd file lazily when it is modified
+	credentialStore struct {
+		inline   map[string][]byte
+		path     string
+		interval time.Duration
+
+		mu    sync.RWMutex
+		users map[string][]byte
+		// dummy is compared for the unknown users, it has the highest cost of the loaded hashes, so that
+		// the response time does not reveal whether the user exists
+		dummy     []byte
+		modTime   time.Time
+		lastCheck time.Time
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter authenticates the request before the body is read
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageAuth
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Realm == "" {
+		cfg.Realm = defaultRealm
+	}
+	if len(cfg.Users) == 0 && cfg.HtpasswdFile == "" {
+		return errors.New("users or htpasswd file is required")
+	}
+
+	interval := defaultReloadInterval
+	if cfg.ReloadInterval != "" {
+		d, err := time.ParseDuration(cfg.ReloadInterval)
+		if err != nil {
+			return errors.Wrap(err, "reload interval parse fail")
+		}
+		interval = d
+	}
+
+	store := &credentialStore{inline: make(map[string][]byte, len(cfg.Users)), path: cfg.HtpasswdFile, interval: interval}
+	for user, hash := range cfg.Users {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return errors.Wrapf(err, "hash of user %s is not a bcrypt hash", user)
+		}
+		store.inline[user] = []byte(hash)
+	}
+	if err := store.load(); err != nil {
+		return err
+	}
+	factory.store = store
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{realm: factory.cfg.Realm, store: factory.store}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	user, password, ok := ctx.Request.BasicAuth()
+	if !ok || !f.store.verify(user, password) {
+		ctx.AddHeader("WWW-Authenticate", `Basic realm="`+f.realm+`"`)
+		bt, _ := json.Marshal(http.ErrResponse{Message: "unauthorized"})
+		ctx.SendLocalReply(stdHttp.StatusUnauthorized, bt)
+		return filter.Stop
+	}
+	if ctx.Params == nil {
+		ctx.Params = make(map[string]interface{})
+	}
+	ctx.Params[constant.AuthUserParam] = user
+	return filter.Continue
+}
+
+// verify compare the password with bcrypt, which is constant-time for the same hash cost
+func (s *credentialStore) verify(user, password string) bool {
+	s.reloadIfModified()
+
+	s.mu.RLock()
+	hash, ok := s.users[user]
+	dummy := s.dummy
+	s.mu.RUnlock()
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword(dummy, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+}
+
+// reloadIfModified check the htpasswd file at most once per interval, the old credentials are kept if reload fail
+func (s *credentialStore) reloadIfModified() {
+	if s.path == "" {
+		return
+	}
+	s.mu.Lock()
+	if time.Since(s.lastCheck) < s.interval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCheck = time.Now()
+	modTime := s.modTime
+	s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		logger.Warnw("stat htpasswd file fail", "path", s.path, "error", err.Error())
+		return
+	}
+	if info.ModTime().Equal(modTime) {
+		return
+	}
+	if err := s.load(); err != nil {
+		logger.Warnw("reload htpasswd file fail", "path", s.path, "error", err.Error())
+		return
+	}
+	logger.Infow("htpasswd file reloaded", "path", s.path)
+}
+
+// load merge the inline credentials and the htpasswd file
+func (s *credentialStore) load() error {
+	users := make(map[string][]byte, len(s.inline))
+	var modTime time.Time
+	if s.path != "" {
+		info, err := os.Stat(s.path)
+		if err != nil {
+			return errors.Wrapf(err, "stat htpasswd file %s fail", s.path)
+		}
+		content, err := ioutil.ReadFile(s.path)
+		if err != nil {
+			return errors.Wrapf(err, "read htpasswd file %s fail", s.path)
+		}
+		if users, err = parseHtpasswd(content); err != nil {
+			return err
+		}
+		modTime = info.ModTime()
+	}
+	for user, hash := range s.inline {
+		users[user] = hash
+	}
+	dummy, err := s.dummyFor(users)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = users
+	s.dummy = dummy
+	s.modTime = modTime
+	s.lastCheck = time.Now()
+	return nil
+}
+
+// dummyFor return the dummy hash of the highest cost of the users, the current one is reused if the cost is the same
+func (s *credentialStore) dummyFor(users map[string][]byte) ([]byte, error) {
+	cost := bcrypt.MinCost
+	for _, hash := range users {
+		if c, err := bcrypt.Cost(hash); err == nil && c > cost {
+			cost = c
+		}
+	}
+	s.mu.RLock()
+	dummy := s.dummy
+	s.mu.RUnlock()
+	if c, err := bcrypt.Cost(dummy); err == nil && c == cost {
+		return dummy, nil
+	}
+	dummy, err := bcrypt.GenerateFromPassword([]byte("pixiu"), cost)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate dummy hash fail")
+	}
+	return dummy, nil
+}
+
+// parseHtpasswd parse the lines of user:bcrypt-hash, the blank lines and comments are ignored
+func parseHtpasswd(content []byte) (map[string][]byte, error) {
+	users := make(map[string][]byte)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.Index(text, ":")
+		if i <= 0 {
+			return nil, errors.Errorf("invalid htpasswd line %d", line)
+		}
+		hash := text[i+1:]
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, errors.Wrapf(err, "htpasswd line %d is not a bcrypt hash", line)
+		}
+		users[text[:i]] = []byte(hash)
+	}
+	return users, scanner.Err()
+}
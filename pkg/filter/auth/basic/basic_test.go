@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package basic
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func hash(t *testing.T, password string) string {
+	bt, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+	return string(bt)
+}
+
+func decode(t *testing.T, f *Filter, user, password string) (filter.FilterStatus, http.Header) {
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+	assert.NoError(t, err)
+	if user != "" {
+		request.SetBasicAuth(user, password)
+	}
+	ctx := mock.GetMockHTTPContext(request)
+	return f.Decode(ctx), ctx.Writer.Header()
+}
+
+func TestBasicAuthInline(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Realm: "test", Users: map[string]string{"tc": hash(t, "123456")}}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{realm: factory.cfg.Realm, store: factory.store}
+
+	status, _ := decode(t, f, "tc", "123456")
+	assert.Equal(t, filter.Continue, status)
+
+	status, header := decode(t, f, "tc", "654321")
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, `Basic realm="test"`, header.Get("WWW-Authenticate"))
+
+	status, _ = decode(t, f, "unknown", "123456")
+	assert.Equal(t, filter.Stop, status)
+
+	status, _ = decode(t, f, "", "")
+	assert.Equal(t, filter.Stop, status)
+}
+
+func TestBasicAuthUserParam(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Users: map[string]string{"tc": hash(t, "123456")}}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{realm: factory.cfg.Realm, store: factory.store}
+
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+	assert.NoError(t, err)
+	request.SetBasicAuth("tc", "123456")
+	ctx := mock.GetMockHTTPContext(request)
+	assert.Equal(t, filter.Continue, f.Decode(ctx))
+	assert.Equal(t, "tc", ctx.Params[constant.AuthUserParam])
+}
+
+func TestBasicAuthHtpasswdReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htpasswd")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".htpasswd")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("# users\ntc:"+hash(t, "v1")+"\n"), 0600))
+
+	factory := &FilterFactory{cfg: &Config{HtpasswdFile: path, ReloadInterval: "1ms"}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{realm: factory.cfg.Realm, store: factory.store}
+
+	status, _ := decode(t, f, "tc", "v1")
+	assert.Equal(t, filter.Continue, status)
+
+	// rotate the password without restart
+	assert.NoError(t, ioutil.WriteFile(path, []byte("tc:"+hash(t, "v2")+"\n"), 0600))
+	modTime := time.Now().Add(time.Second)
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	time.Sleep(5 * time.Millisecond)
+
+	status, _ = decode(t, f, "tc", "v2")
+	assert.Equal(t, filter.Continue, status)
+	status, _ = decode(t, f, "tc", "v1")
+	assert.Equal(t, filter.Stop, status)
+
+	// the invalid file is ignored and the loaded credentials are kept
+	assert.NoError(t, ioutil.WriteFile(path, []byte("tc:plain"), 0600))
+	modTime = modTime.Add(time.Second)
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	time.Sleep(5 * time.Millisecond)
+
+	status, _ = decode(t, f, "tc", "v2")
+	assert.Equal(t, filter.Continue, status)
+}
+
+func TestBasicAuthApplyInvalid(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{HtpasswdFile: "/not/exist/.htpasswd"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Users: map[string]string{"tc": "123456"}}}).Apply())
+}
+
+func TestBasicAuthDummyCost(t *testing.T) {
+	bt, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost+1)
+	assert.NoError(t, err)
+	factory := &FilterFactory{cfg: &Config{Users: map[string]string{"tc": hash(t, "123456"), "pixiu": string(bt)}}}
+	assert.Nil(t, factory.Apply())
+	// the unknown user costs as the most expensive configured one
+	cost, err := bcrypt.Cost(factory.store.dummy)
+	assert.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost+1, cost)
+}
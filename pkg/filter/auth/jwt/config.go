@@ -21,6 +21,10 @@ import (
 	"github.com/MicahParks/keyfunc"
 )
 
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+)
+
 type (
 	// FromHeaders Get the token from a field in the header，default Authorization: Bearer <token>
 	FromHeaders struct {
@@ -68,11 +72,13 @@ type (
 		Uri     string `yaml:"uri" json:"uri" mapstructure:"uri"`
 		Cluster string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
 		TimeOut string `default:"5s" yaml:"timeout" json:"timeout" mapstructure:"timeout"`
+		Refresh string `default:"5m" yaml:"refresh" json:"refresh" mapstructure:"refresh"` // jwks refresh interval, shared by the providers of the same uri
 	}
 )
 
 type Provider struct {
 	jwk                  *keyfunc.JWKS
+	refresher            *filter.Refresher // keep the remote jwks refreshed, nil for the local jwks
 	issuer               string
 	forwardPayloadHeader string
 	headers              FromHeaders
@@ -20,6 +20,7 @@ package jwt
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	stdHttp "net/http"
 	"strings"
 	"time"
@@ -56,6 +57,7 @@ type (
 		cfg          *Config
 		errMsg       []byte
 		providerJwks map[string]Provider
+		refreshers   []*filter.Refresher
 	}
 
 	Filter struct {
@@ -141,6 +143,11 @@ func (f *Filter) validAll(rule Rules, ctx *http.HttpContext) bool {
 	return false
 }
 
+// Stage the filter authenticates the request before the body is read
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageAuth
+}
+
 func (factory *FilterFactory) Apply() error {
 
 	if len(factory.cfg.Providers) == 0 {
@@ -153,7 +160,7 @@ func (factory *FilterFactory) Apply() error {
 			jwksJSON := json.RawMessage(provider.Local.InlineString)
 			jwks, err := keyfunc.NewJSON(jwksJSON)
 			if err != nil {
-				logger.Warnf("failed to create JWKs from JSON. provider：%s Error: %s", provider.Name, err.Error())
+				logger.Warnw("failed to create JWKs from JSON", "provider", provider.Name, "error", err.Error())
 			} else {
 				provider.FromHeaders.setDefault()
 				factory.providerJwks[provider.Name] = Provider{jwk: jwks, headers: provider.FromHeaders,
@@ -166,17 +173,26 @@ func (factory *FilterFactory) Apply() error {
 			uri := provider.Remote.HttpURI
 			timeout, err := time.ParseDuration(uri.TimeOut)
 			if err != nil {
-				logger.Warnf("jwt provides timeout parse fail: %s", err.Error())
+				logger.Warnw("jwt provider timeout parse fail", "provider", provider.Name, "error", err.Error())
 				continue
 			}
 
-			options := keyfunc.Options{RefreshTimeout: timeout}
-			jwks, err := keyfunc.Get(uri.Uri, options)
-			if err != nil {
-				logger.Warnf("failed to create JWKs from resource at the given URL. provider：%s Error: %s", provider.Name, err.Error())
+			var refresh time.Duration
+			if uri.Refresh != "" {
+				if refresh, err = time.ParseDuration(uri.Refresh); err != nil {
+					logger.Warnw("jwt provider refresh parse fail", "provider", provider.Name, "error", err.Error())
+					continue
+				}
+			}
+
+			refresher := filter.AcquireRefresher("jwks:"+uri.Uri, refresh, fetchJWKS(uri.Uri, timeout))
+			if _, err := refresher.Get(); err != nil {
+				refresher.Release()
+				logger.Warnw("failed to create JWKs from the remote url", "provider", provider.Name, "error", err.Error())
 			} else {
 				provider.FromHeaders.setDefault()
-				factory.providerJwks[provider.Name] = Provider{jwk: jwks, headers: provider.FromHeaders,
+				factory.refreshers = append(factory.refreshers, refresher)
+				factory.providerJwks[provider.Name] = Provider{refresher: refresher, headers: provider.FromHeaders,
 					issuer: provider.Issuer, forwardPayloadHeader: provider.ForwardPayloadHeader}
 			}
 		}
@@ -212,9 +228,14 @@ func checkToken(value, prefix, providerName string, provider Provider) bool {
 		return false
 	}
 
-	token, err := jwt4.Parse(value[len(prefix):], provider.jwk.Keyfunc)
+	jwks, err := provider.keys()
 	if err != nil {
-		logger.Warnf("failed to parse JWKs from JSON. provider：%s Error: %s", providerName, err.Error())
+		logger.Warnw("failed to get JWKs", "provider", providerName, "error", err.Error())
+		return false
+	}
+	token, err := jwt4.Parse(value[len(prefix):], jwks.Keyfunc)
+	if err != nil {
+		logger.Warnw("failed to parse JWKs from JSON", "provider", providerName, "error", err.Error())
 		return false
 	}
 
@@ -224,3 +245,44 @@ func checkToken(value, prefix, providerName string, provider Provider) bool {
 func (factory *FilterFactory) Config() interface{} {
 	return factory.cfg
 }
+
+// Close stop refreshing the remote jwks unless other filters still use them
+func (factory *FilterFactory) Close() error {
+	for _, r := range factory.refreshers {
+		r.Release()
+	}
+	factory.refreshers = nil
+	return nil
+}
+
+// keys return the local jwks, or the last fetched remote jwks
+func (p Provider) keys() (*keyfunc.JWKS, error) {
+	if p.refresher == nil {
+		return p.jwk, nil
+	}
+	v, err := p.refresher.Get()
+	if err != nil {
+		return nil, err
+	}
+	return v.(*keyfunc.JWKS), nil
+}
+
+// fetchJWKS fetch the jwks from the uri
+func fetchJWKS(uri string, timeout time.Duration) filter.FetchFunc {
+	cli := &stdHttp.Client{Timeout: timeout}
+	return func() (interface{}, error) {
+		resp, err := cli.Get(uri)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != stdHttp.StatusOK {
+			return nil, fmt.Errorf("fetch jwks from %s fail, status code %d", uri, resp.StatusCode)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return keyfunc.NewJSON(body)
+	}
+}
@@ -0,0 +1,344 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	stdHttp "net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPAuthMTLSFilter
+
+	wildcard = "*"
+)
+
+// now the clock of the certificate validity, replaced in tests
+var now = time.Now
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		Rules []*Rule `yaml:"rules" json:"rules" mapstructure:"rules"`
+		// AllowedSubjects the allowed subject DN or CN of the client certificate for all requests, * allows any
+		AllowedSubjects []string `yaml:"allowed_subjects" json:"allowed_subjects" mapstructure:"allowed_subjects"`
+		// AllowedSANs the allowed SAN(dns, uri, email, ip) of the client certificate for all requests, * allows any
+		AllowedSANs []string `yaml:"allowed_sans" json:"allowed_sans" mapstructure:"allowed_sans"`
+		// CAFile the path of the trusted CA bundle, the client certificate must be signed by one of them
+		CAFile string `yaml:"ca_file" json:"ca_file" mapstructure:"ca_file"`
+		// Header the header carrying the url encoded PEM client certificate when TLS is terminated before pixiu,
+		// the terminating proxy must overwrite it so that clients can not forge it, CAFile is required with it
+		Header string `yaml:"header" json:"header" mapstructure:"header"`
+
+		subjects map[string]struct{}
+		sans     map[string]struct{}
+		roots    *x509.CertPool
+	}
+
+	// Rule the identities allowed to access the route
+	Rule struct {
+		Match Match `yaml:"match" json:"match" mapstructure:"match"`
+		// Identities the allowed CN or SAN(dns, uri, email, ip) of the client certificate, * allows any verified identity
+		Identities []string `yaml:"identities" json:"identities" mapstructure:"identities"`
+
+		allowed map[string]struct{}
+	}
+
+	// Match router match
+	Match struct {
+		Prefix string `yaml:"prefix" json:"prefix" mapstructure:"prefix"` // url
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter authorizes the request before the body is read
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageAuth
+}
+
+func (factory *FilterFactory) Apply() error {
+	for _, rule := range factory.cfg.Rules {
+		if len(rule.Identities) == 0 {
+			return errors.Errorf("no identity allowed for prefix %s", rule.Match.Prefix)
+		}
+		rule.allowed = make(map[string]struct{}, len(rule.Identities))
+		for _, id := range rule.Identities {
+			rule.allowed[id] = struct{}{}
+		}
+	}
+
+	cfg := factory.cfg
+	if cfg.Header != "" && cfg.CAFile == "" {
+		return errors.New("ca file is required to verify the certificate header")
+	}
+	cfg.subjects = toSet(cfg.AllowedSubjects)
+	cfg.sans = toSet(cfg.AllowedSANs)
+	if cfg.CAFile != "" {
+		bundle, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return errors.Wrap(err, "ca file read fail")
+		}
+		cfg.roots = x509.NewCertPool()
+		if !cfg.roots.AppendCertsFromPEM(bundle) {
+			return errors.Errorf("no certificate found in ca file %s", cfg.CAFile)
+		}
+	}
+	return nil
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	rule := f.match(ctx.Request.URL.Path)
+	if rule == nil && !f.cfg.restricted() {
+		return filter.Continue
+	}
+
+	cert, err := f.cfg.clientCert(ctx.Request)
+	if cert == nil {
+		if err != nil {
+			logger.Debugw("client certificate invalid", "url", ctx.GetUrl(), "error", err.Error())
+		}
+		bt, _ := json.Marshal(http.ErrResponse{Message: "verified client certificate required"})
+		ctx.SendLocalReply(stdHttp.StatusForbidden, bt)
+		return filter.Stop
+	}
+	if !f.cfg.permit(cert) || rule != nil && !rule.permit(cert) {
+		logger.Debugw("client identity not allowed", "identity", cert.Subject.CommonName, "url", ctx.GetUrl())
+		bt, _ := json.Marshal(http.ErrResponse{Message: "client identity not allowed"})
+		ctx.SendLocalReply(stdHttp.StatusForbidden, bt)
+		return filter.Stop
+	}
+	return filter.Continue
+}
+
+func (f *Filter) match(path string) *Rule {
+	for _, rule := range f.cfg.Rules {
+		if strings.HasPrefix(path, rule.Match.Prefix) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// restricted whether all requests require the client certificate
+func (c *Config) restricted() bool {
+	return len(c.subjects) > 0 || len(c.sans) > 0 || c.roots != nil
+}
+
+// clientCert return the client certificate from the TLS connection, or from the header when TLS is
+// not terminated by pixiu. With the CA bundle, the certificate is verified against it, otherwise only the
+// certificate verified by the listener is accepted. The certificate must be valid now, the connection may
+// outlive the certificate verified at handshake.
+func (c *Config) clientCert(r *stdHttp.Request) (*x509.Certificate, error) {
+	var (
+		cert          *x509.Certificate
+		intermediates []*x509.Certificate
+	)
+	switch {
+	case r.TLS != nil:
+		if c.roots == nil {
+			cert = verifiedCert(r)
+			if cert == nil {
+				return nil, nil
+			}
+			if err := checkValidity(cert); err != nil {
+				return nil, err
+			}
+			return cert, nil
+		}
+		if len(r.TLS.PeerCertificates) == 0 {
+			return nil, nil
+		}
+		cert, intermediates = r.TLS.PeerCertificates[0], r.TLS.PeerCertificates[1:]
+	case c.Header != "":
+		value := r.Header.Get(c.Header)
+		if value == "" {
+			return nil, nil
+		}
+		var err error
+		if cert, err = parseHeaderCert(value); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         c.roots,
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, inter := range intermediates {
+		opts.Intermediates.AddCert(inter)
+	}
+	if _, err := cert.Verify(opts); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// checkValidity check the certificate is in its validity period
+func checkValidity(cert *x509.Certificate) error {
+	t := now()
+	if t.Before(cert.NotBefore) || t.After(cert.NotAfter) {
+		return errors.Errorf("certificate %s is not valid at %s", cert.Subject.CommonName, t.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// verifiedCert return the leaf of the verified chain, the chain is only present
+// when the listener verified the client certificate against its client CAs
+func verifiedCert(r *stdHttp.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// parseHeaderCert parse the url encoded PEM certificate, e.g. $ssl_client_escaped_cert of nginx
+func parseHeaderCert(value string) (*x509.Certificate, error) {
+	decoded, err := url.PathUnescape(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "certificate header unescape fail")
+	}
+	block, _ := pem.Decode([]byte(decoded))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no certificate found in header")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// permit check the certificate against the allowed subjects and SANs, nothing allowed means any certificate
+func (c *Config) permit(cert *x509.Certificate) bool {
+	if len(c.subjects) == 0 && len(c.sans) == 0 {
+		return true
+	}
+	if _, ok := c.subjects[wildcard]; ok {
+		return true
+	}
+	if _, ok := c.sans[wildcard]; ok {
+		return true
+	}
+	for _, subject := range []string{cert.Subject.String(), cert.Subject.CommonName} {
+		if _, ok := c.subjects[subject]; ok && subject != "" {
+			return true
+		}
+	}
+	for _, san := range subjectAltNames(cert) {
+		if _, ok := c.sans[san]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) permit(cert *x509.Certificate) bool {
+	if _, ok := r.allowed[wildcard]; ok {
+		return true
+	}
+	for _, id := range identities(cert) {
+		if _, ok := r.allowed[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// identities the CN and SANs of the certificate
+func identities(cert *x509.Certificate) []string {
+	sans := subjectAltNames(cert)
+	if cert.Subject.CommonName == "" {
+		return sans
+	}
+	return append([]string{cert.Subject.CommonName}, sans...)
+}
+
+// subjectAltNames the dns, uri, email and ip SANs of the certificate
+func subjectAltNames(cert *x509.Certificate) []string {
+	ids := make([]string, 0, len(cert.DNSNames)+len(cert.URIs)+len(cert.EmailAddresses)+len(cert.IPAddresses))
+	ids = append(ids, cert.DNSNames...)
+	for _, u := range cert.URIs {
+		ids = append(ids, u.String())
+	}
+	ids = append(ids, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		ids = append(ids, ip.String())
+	}
+	return ids
+}
@@ -0,0 +1,284 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "pixiu test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, cn string, dnsNames ...string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// caFile write the CA to a bundle file
+func (ca *testCA) caFile(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600))
+	return file
+}
+
+func TestIdentityAllowlist(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Rules: []*Rule{
+		{Match: Match{Prefix: "/api/order"}, Identities: []string{"order.svc.local"}},
+		{Match: Match{Prefix: "/api/any"}, Identities: []string{"*"}},
+	}}}
+	assert.Nil(t, factory.Apply())
+
+	ca := newTestCA(t)
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := mock.GetMockHTTPContext(r)
+		f := &Filter{cfg: factory.cfg}
+		if f.Decode(ctx) == filter.Stop {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	s.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: ca.pool}
+	s.StartTLS()
+	defer s.Close()
+
+	tests := []struct {
+		name   string
+		cert   tls.Certificate
+		path   string
+		status int
+	}{
+		{name: "allowed san", cert: ca.issue(t, 2, "order", "order.svc.local"), path: "/api/order/1", status: http.StatusOK},
+		{name: "allowed cn", cert: ca.issue(t, 3, "order.svc.local"), path: "/api/order/1", status: http.StatusOK},
+		{name: "valid but not allowed", cert: ca.issue(t, 4, "user", "user.svc.local"), path: "/api/order/1", status: http.StatusForbidden},
+		{name: "wildcard", cert: ca.issue(t, 5, "user", "user.svc.local"), path: "/api/any", status: http.StatusOK},
+		{name: "no rule", cert: ca.issue(t, 6, "user", "user.svc.local"), path: "/api/user", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := s.Client()
+			transport := cli.Transport.(*http.Transport).Clone()
+			transport.TLSClientConfig.Certificates = []tls.Certificate{tt.cert}
+			cli.Transport = transport
+
+			resp, err := cli.Get(s.URL + tt.path)
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestNoVerifiedCert(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Rules: []*Rule{{Match: Match{Prefix: "/"}, Identities: []string{"*"}}}}}
+	assert.Nil(t, factory.Apply())
+
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	f := &Filter{cfg: factory.cfg}
+	assert.Equal(t, filter.Stop, f.Decode(ctx))
+
+	factory = &FilterFactory{cfg: &Config{Rules: []*Rule{{Match: Match{Prefix: "/"}}}}}
+	assert.Error(t, factory.Apply())
+}
+
+func TestAllowlistWithCABundle(t *testing.T) {
+	ca, other := newTestCA(t), newTestCA(t)
+	factory := &FilterFactory{cfg: &Config{
+		AllowedSubjects: []string{"CN=billing"},
+		AllowedSANs:     []string{"order.svc.local"},
+		CAFile:          ca.caFile(t),
+	}}
+	assert.Nil(t, factory.Apply())
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := mock.GetMockHTTPContext(r)
+		f := &Filter{cfg: factory.cfg}
+		if f.Decode(ctx) == filter.Stop {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	// the listener does not verify, the filter verifies against the bundle
+	s.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	s.StartTLS()
+	defer s.Close()
+
+	tests := []struct {
+		name   string
+		cert   *tls.Certificate
+		status int
+	}{
+		{name: "allowed san", cert: certOf(ca.issue(t, 2, "order", "order.svc.local")), status: http.StatusOK},
+		{name: "allowed subject", cert: certOf(ca.issue(t, 3, "billing")), status: http.StatusOK},
+		{name: "not allowed", cert: certOf(ca.issue(t, 4, "user", "user.svc.local")), status: http.StatusForbidden},
+		{name: "untrusted ca", cert: certOf(other.issue(t, 5, "order", "order.svc.local")), status: http.StatusForbidden},
+		{name: "no cert", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := s.Client()
+			transport := cli.Transport.(*http.Transport).Clone()
+			if tt.cert != nil {
+				transport.TLSClientConfig.Certificates = []tls.Certificate{*tt.cert}
+			}
+			cli.Transport = transport
+
+			resp, err := cli.Get(s.URL + "/api/order/1")
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestHeaderCert(t *testing.T) {
+	ca, other := newTestCA(t), newTestCA(t)
+	factory := &FilterFactory{cfg: &Config{AllowedSANs: []string{"order.svc.local"}, CAFile: ca.caFile(t), Header: "X-Client-Cert"}}
+	assert.Nil(t, factory.Apply())
+
+	escaped := func(cert tls.Certificate) string {
+		return url.PathEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})))
+	}
+	tests := []struct {
+		name   string
+		header string
+		status filter.FilterStatus
+	}{
+		{name: "forwarded", header: escaped(ca.issue(t, 2, "order", "order.svc.local")), status: filter.Continue},
+		{name: "untrusted ca", header: escaped(other.issue(t, 3, "order", "order.svc.local")), status: filter.Stop},
+		{name: "not allowed", header: escaped(ca.issue(t, 4, "user", "user.svc.local")), status: filter.Stop},
+		{name: "malformed", header: "not a certificate", status: filter.Stop},
+		{name: "missing", status: filter.Stop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/order/1", nil)
+			assert.NoError(t, err)
+			if tt.header != "" {
+				request.Header.Set("X-Client-Cert", tt.header)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			f := &Filter{cfg: factory.cfg}
+			assert.Equal(t, tt.status, f.Decode(ctx))
+			if tt.status == filter.Stop {
+				assert.Equal(t, http.StatusForbidden, ctx.GetStatusCode())
+			}
+		})
+	}
+
+	// the forwarded certificate expired
+	now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	defer func() { now = time.Now }()
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/order/1", nil)
+	assert.NoError(t, err)
+	request.Header.Set("X-Client-Cert", escaped(ca.issue(t, 5, "order", "order.svc.local")))
+	assert.Equal(t, filter.Stop, (&Filter{cfg: factory.cfg}).Decode(mock.GetMockHTTPContext(request)))
+
+	factory = &FilterFactory{cfg: &Config{CAFile: filepath.Join(t.TempDir(), "missing.pem")}}
+	assert.Error(t, factory.Apply())
+	// the forwarded certificate can not be trusted without the ca
+	factory = &FilterFactory{cfg: &Config{Header: "X-Client-Cert"}}
+	assert.Error(t, factory.Apply())
+}
+
+func TestVerifiedCertExpired(t *testing.T) {
+	ca := newTestCA(t)
+	cert, err := x509.ParseCertificate(ca.issue(t, 2, "order").Certificate[0])
+	assert.NoError(t, err)
+	cfg := &Config{}
+	request, err := http.NewRequest("GET", "https://www.dubbogopixiu.com/api/order/1", nil)
+	assert.NoError(t, err)
+	request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert, ca.cert}}}
+
+	got, err := cfg.clientCert(request)
+	assert.NoError(t, err)
+	assert.Equal(t, cert, got)
+
+	// the long lived connection outlives the certificate verified at handshake
+	now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	defer func() { now = time.Now }()
+	got, err = cfg.clientCert(request)
+	assert.Error(t, err)
+	assert.Nil(t, got)
+}
+
+func certOf(cert tls.Certificate) *tls.Certificate {
+	return &cert
+}
@@ -0,0 +1,226 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io/ioutil"
+	stdHttp "net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPAuthSignatureFilter
+
+	defaultHeader          = "X-Signature"
+	defaultTimestampHeader = "X-Timestamp"
+	defaultMaxSkew         = 5 * time.Minute
+
+	encodingHex    = "hex"
+	encodingBase64 = "base64"
+)
+
+// now the clock, replaced in tests
+var now = time.Now
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg     *Config
+		secret  []byte
+		hash    func() hash.Hash
+		maxSkew time.Duration
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		factory *FilterFactory
+	}
+
+	// Config describe the config of FilterFactory. The signature is the hmac of
+	// <method>\n<path and query>\n<timestamp>\n<body>, the timestamp is the unix seconds in TimestampHeader.
+	// Use it with the nonce filter to reject the replay within the skew.
+	Config struct {
+		// Header the header carrying the signature, X-Signature by default
+		Header string `yaml:"header" json:"header" mapstructure:"header"`
+		// TimestampHeader the header carrying the signed timestamp, X-Timestamp by default
+		TimestampHeader string `yaml:"timestamp_header" json:"timestamp_header" mapstructure:"timestamp_header"`
+		// Secret the shared secret, the env like ${HMAC_SECRET} is expanded
+		Secret string `yaml:"secret" json:"secret" mapstructure:"secret"`
+		// Algorithm sha1, sha256 or sha512, sha256 by default
+		Algorithm string `yaml:"algorithm" json:"algorithm" mapstructure:"algorithm"`
+		// Encoding hex or base64 of the signature, hex by default
+		Encoding string `yaml:"encoding" json:"encoding" mapstructure:"encoding"`
+		// MaxSkew the max difference between the timestamp and the gateway clock, 5m by default
+		MaxSkew string `yaml:"max_skew" json:"max_skew" mapstructure:"max_skew"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Header == "" {
+		cfg.Header = defaultHeader
+	}
+	if cfg.TimestampHeader == "" {
+		cfg.TimestampHeader = defaultTimestampHeader
+	}
+	factory.secret = []byte(os.ExpandEnv(cfg.Secret))
+	if len(factory.secret) == 0 {
+		return errors.New("secret is required")
+	}
+	switch cfg.Algorithm {
+	case "sha1":
+		factory.hash = sha1.New
+	case "sha256", "":
+		factory.hash = sha256.New
+	case "sha512":
+		factory.hash = sha512.New
+	default:
+		return errors.Errorf("unsupported algorithm %s", cfg.Algorithm)
+	}
+	if cfg.Encoding == "" {
+		cfg.Encoding = encodingHex
+	}
+	if cfg.Encoding != encodingHex && cfg.Encoding != encodingBase64 {
+		return errors.Errorf("unsupported encoding %s", cfg.Encoding)
+	}
+	factory.maxSkew = defaultMaxSkew
+	if cfg.MaxSkew != "" {
+		skew, err := time.ParseDuration(cfg.MaxSkew)
+		if err != nil {
+			return errors.Wrap(err, "max skew parse fail")
+		}
+		if skew <= 0 {
+			return errors.New("max skew should be positive")
+		}
+		factory.maxSkew = skew
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	chain.AppendDecodeFilters(&Filter{factory: factory})
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	cfg := f.factory.cfg
+	ts := ctx.Request.Header.Get(cfg.TimestampHeader)
+	if err := f.factory.checkTimestamp(ts); err != nil {
+		logger.Debugw("signature rejected", "url", ctx.GetUrl(), "error", err.Error())
+		return reject(ctx, "invalid or stale timestamp")
+	}
+	signature, err := f.factory.decode(ctx.Request.Header.Get(cfg.Header))
+	if err != nil {
+		return reject(ctx, "invalid signature")
+	}
+
+	var body []byte
+	if ctx.Request.Body != nil {
+		if body, err = ioutil.ReadAll(ctx.Request.Body); err != nil {
+			return reject(ctx, "read body fail")
+		}
+		ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	if !hmac.Equal(signature, f.factory.sign(ctx.Request.Method, ctx.Request.URL.RequestURI(), ts, body)) {
+		logger.Debugw("signature mismatch", "url", ctx.GetUrl())
+		return reject(ctx, "invalid signature")
+	}
+	return filter.Continue
+}
+
+func (factory *FilterFactory) checkTimestamp(ts string) error {
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.Errorf("invalid timestamp %q", ts)
+	}
+	skew := now().Sub(time.Unix(sec, 0))
+	if skew > factory.maxSkew || skew < -factory.maxSkew {
+		return errors.Errorf("timestamp %s is out of skew", ts)
+	}
+	return nil
+}
+
+func (factory *FilterFactory) decode(signature string) ([]byte, error) {
+	if signature == "" {
+		return nil, errors.New("signature is missing")
+	}
+	if factory.cfg.Encoding == encodingBase64 {
+		return base64.StdEncoding.DecodeString(signature)
+	}
+	return hex.DecodeString(signature)
+}
+
+// sign the hmac of the request, the parts are separated by new line so that they can not be shifted
+func (factory *FilterFactory) sign(method, uri, ts string, body []byte) []byte {
+	mac := hmac.New(factory.hash, factory.secret)
+	mac.Write([]byte(method + "\n" + uri + "\n" + ts + "\n"))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func reject(ctx *http.HttpContext, message string) filter.FilterStatus {
+	bt, _ := json.Marshal(http.ErrResponse{Message: message})
+	return filter.Abort(ctx, &filter.AbortResponse{
+		Status:  stdHttp.StatusUnauthorized,
+		Body:    bt,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	})
+}
@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+const (
+	secret  = "signature-secret"
+	payload = `{"name":"tc","id":"0001"}`
+	uri     = "/api/v1/test-dubbo/student/create?version=1"
+)
+
+func sign(method, uri, ts, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + uri + "\n" + ts + "\n" + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSignature(t *testing.T) {
+	signedAt := time.Unix(1650000000, 0)
+	now = func() time.Time { return signedAt.Add(time.Minute) }
+	defer func() { now = time.Now }()
+	ts := strconv.FormatInt(signedAt.Unix(), 10)
+	stale := strconv.FormatInt(signedAt.Add(-time.Hour).Unix(), 10)
+
+	os.Setenv("PIXIU_TEST_HMAC_SECRET", secret)
+	defer os.Unsetenv("PIXIU_TEST_HMAC_SECRET")
+	factory := &FilterFactory{cfg: &Config{Secret: "${PIXIU_TEST_HMAC_SECRET}"}}
+	assert.Nil(t, factory.Apply())
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		headers map[string]string
+		status  int
+	}{
+		{name: "signed", headers: map[string]string{"X-Timestamp": ts, "X-Signature": sign("POST", uri, ts, payload)}},
+		{
+			name:    "tampered body",
+			body:    `{"name":"tc","id":"0002"}`,
+			headers: map[string]string{"X-Timestamp": ts, "X-Signature": sign("POST", uri, ts, payload)},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "tampered method",
+			method:  "PUT",
+			headers: map[string]string{"X-Timestamp": ts, "X-Signature": sign("POST", uri, ts, payload)},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "stale",
+			headers: map[string]string{"X-Timestamp": stale, "X-Signature": sign("POST", uri, stale, payload)},
+			status:  http.StatusUnauthorized,
+		},
+		{name: "unsigned", headers: map[string]string{"X-Timestamp": ts}, status: http.StatusUnauthorized},
+		{name: "no timestamp", headers: map[string]string{"X-Signature": sign("POST", uri, ts, payload)}, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, body := tt.method, tt.body
+			if method == "" {
+				method = "POST"
+			}
+			if body == "" {
+				body = payload
+			}
+			request, err := http.NewRequest(method, "http://www.dubbogopixiu.com"+uri, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			for k, v := range tt.headers {
+				request.Header.Set(k, v)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			chain := filter.NewDefaultFilterChain()
+			_ = factory.PrepareFilterChain(ctx, chain)
+			chain.OnDecode(ctx)
+
+			if tt.status != 0 {
+				assert.Equal(t, tt.status, ctx.GetStatusCode())
+				assert.True(t, ctx.LocalReply())
+				return
+			}
+			assert.False(t, ctx.LocalReply())
+			forwarded, err := ioutil.ReadAll(ctx.Request.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, body, string(forwarded))
+		})
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	invalid := []*Config{
+		{},
+		{Secret: "${PIXIU_TEST_HMAC_SECRET_NOT_SET}"},
+		{Secret: secret, Algorithm: "md5"},
+		{Secret: secret, Encoding: "base32"},
+		{Secret: secret, MaxSkew: "-1m"},
+	}
+	for _, cfg := range invalid {
+		assert.Error(t, (&FilterFactory{cfg: cfg}).Apply())
+	}
+}
@@ -0,0 +1,345 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io/ioutil"
+	stdHttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPAuthWebhookFilter
+
+	// PresetGitHub sha256 hmac of body in X-Hub-Signature-256 header
+	PresetGitHub = "github"
+	// PresetStripe sha256 hmac of timestamp and body in Stripe-Signature header
+	PresetStripe = "stripe"
+	// PresetSlack sha256 hmac of version, timestamp and body in X-Slack-Signature header
+	PresetSlack = "slack"
+	// PresetShopify base64 sha256 hmac of body in X-Shopify-Hmac-Sha256 header
+	PresetShopify = "shopify"
+
+	// SchemeBody the signature is the hmac of body
+	SchemeBody = "body"
+	// SchemeStripe the signature header is t=<timestamp>,v1=<signature>, the hmac is over <timestamp>.<body>
+	SchemeStripe = "stripe"
+	// SchemeSlack the timestamp is in TimestampHeader, the hmac is over v0:<timestamp>:<body>
+	SchemeSlack = "slack"
+
+	encodingHex    = "hex"
+	encodingBase64 = "base64"
+
+	defaultTolerance = 5 * time.Minute
+)
+
+// now is replaced in test
+var now = time.Now
+
+var presets = map[string]Provider{
+	PresetGitHub:  {Header: "X-Hub-Signature-256", Algorithm: "sha256", Prefix: "sha256=", Encoding: encodingHex, Scheme: SchemeBody},
+	PresetStripe:  {Header: "Stripe-Signature", Algorithm: "sha256", Encoding: encodingHex, Scheme: SchemeStripe},
+	PresetSlack:   {Header: "X-Slack-Signature", TimestampHeader: "X-Slack-Request-Timestamp", Algorithm: "sha256", Prefix: "v0=", Encoding: encodingHex, Scheme: SchemeSlack},
+	PresetShopify: {Header: "X-Shopify-Hmac-Sha256", Algorithm: "sha256", Encoding: encodingBase64, Scheme: SchemeBody},
+}
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Providers the webhook providers, the request is verified by the first provider matching its path,
+		// the request matching no provider is passed through
+		Providers []*Provider `yaml:"providers" json:"providers" mapstructure:"providers"`
+	}
+
+	// Provider the signature scheme of a webhook provider, the empty fields are filled by the preset
+	Provider struct {
+		Name string `yaml:"name" json:"name" mapstructure:"name"`
+		// Path the path prefix of the webhook
+		Path string `yaml:"path" json:"path" mapstructure:"path"`
+		// Preset github, stripe, slack or shopify
+		Preset string `yaml:"preset" json:"preset" mapstructure:"preset"`
+		Secret string `yaml:"secret" json:"secret" mapstructure:"secret"`
+		// Header the header carrying the signature
+		Header string `yaml:"header" json:"header" mapstructure:"header"`
+		// TimestampHeader the header carrying the timestamp of slack scheme
+		TimestampHeader string `yaml:"timestamp_header" json:"timestamp_header" mapstructure:"timestamp_header"`
+		// Algorithm sha1, sha256 or sha512
+		Algorithm string `yaml:"algorithm" json:"algorithm" mapstructure:"algorithm"`
+		// Prefix the prefix of the signature like sha256=
+		Prefix string `yaml:"prefix" json:"prefix" mapstructure:"prefix"`
+		// Encoding hex or base64
+		Encoding string `yaml:"encoding" json:"encoding" mapstructure:"encoding"`
+		// Scheme body, stripe or slack
+		Scheme string `yaml:"scheme" json:"scheme" mapstructure:"scheme"`
+		// Tolerance the max age of the signed timestamp, 5m by default
+		Tolerance string `yaml:"tolerance" json:"tolerance" mapstructure:"tolerance"`
+
+		hash      func() hash.Hash
+		tolerance time.Duration
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	if len(factory.cfg.Providers) == 0 {
+		return errors.New("no webhook provider configured")
+	}
+	for _, p := range factory.cfg.Providers {
+		if err := p.init(); err != nil {
+			return errors.Wrapf(err, "webhook provider %s", p.Name)
+		}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// init fill the provider by preset and check it
+func (p *Provider) init() error {
+	if p.Preset != "" {
+		preset, ok := presets[p.Preset]
+		if !ok {
+			return errors.Errorf("unknown preset %s", p.Preset)
+		}
+		p.Header = orDefault(p.Header, preset.Header)
+		p.TimestampHeader = orDefault(p.TimestampHeader, preset.TimestampHeader)
+		p.Algorithm = orDefault(p.Algorithm, preset.Algorithm)
+		p.Prefix = orDefault(p.Prefix, preset.Prefix)
+		p.Encoding = orDefault(p.Encoding, preset.Encoding)
+		p.Scheme = orDefault(p.Scheme, preset.Scheme)
+	}
+	p.Encoding = orDefault(p.Encoding, encodingHex)
+	p.Scheme = orDefault(p.Scheme, SchemeBody)
+
+	if p.Secret == "" || p.Header == "" {
+		return errors.New("secret and header are required")
+	}
+	switch p.Algorithm {
+	case "sha1":
+		p.hash = sha1.New
+	case "sha256", "":
+		p.hash = sha256.New
+	case "sha512":
+		p.hash = sha512.New
+	default:
+		return errors.Errorf("unsupported algorithm %s", p.Algorithm)
+	}
+	if p.Encoding != encodingHex && p.Encoding != encodingBase64 {
+		return errors.Errorf("unsupported encoding %s", p.Encoding)
+	}
+	if p.Scheme == SchemeSlack && p.TimestampHeader == "" {
+		return errors.New("timestamp header is required by slack scheme")
+	}
+	if p.Scheme != SchemeBody && p.Scheme != SchemeStripe && p.Scheme != SchemeSlack {
+		return errors.Errorf("unsupported scheme %s", p.Scheme)
+	}
+
+	p.tolerance = defaultTolerance
+	if p.Tolerance != "" {
+		tolerance, err := time.ParseDuration(p.Tolerance)
+		if err != nil {
+			return errors.Wrap(err, "tolerance parse fail")
+		}
+		p.tolerance = tolerance
+	}
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	p := f.match(ctx.GetUrl())
+	if p == nil {
+		return filter.Continue
+	}
+
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return reject(ctx, "read webhook body fail")
+	}
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if err := p.verify(ctx.Request.Header, body); err != nil {
+		logger.Debugw("webhook rejected", "url", ctx.GetUrl(), "provider", p.Name, "error", err.Error())
+		return reject(ctx, "invalid webhook signature")
+	}
+	return filter.Continue
+}
+
+func (f *Filter) match(path string) *Provider {
+	for _, p := range f.cfg.Providers {
+		if strings.HasPrefix(path, p.Path) {
+			return p
+		}
+	}
+	return nil
+}
+
+func reject(ctx *http.HttpContext, message string) filter.FilterStatus {
+	bt, _ := json.Marshal(http.ErrResponse{Message: message})
+	return filter.Abort(ctx, &filter.AbortResponse{
+		Status:  stdHttp.StatusUnauthorized,
+		Body:    bt,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	})
+}
+
+// verify the signature in headers
+func (p *Provider) verify(header stdHttp.Header, body []byte) error {
+	switch p.Scheme {
+	case SchemeStripe:
+		return p.verifyStripe(header.Get(p.Header), body)
+	case SchemeSlack:
+		ts := header.Get(p.TimestampHeader)
+		if err := p.checkTimestamp(ts); err != nil {
+			return err
+		}
+		return p.compare(header.Get(p.Header), []byte("v0:"+ts+":"), body)
+	default:
+		return p.compare(header.Get(p.Header), body)
+	}
+}
+
+// verifyStripe the header may carry several v1 signatures while the secret is rolled
+func (p *Provider) verifyStripe(value string, body []byte) error {
+	var ts string
+	var signatures []string
+	for _, item := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+	if err := p.checkTimestamp(ts); err != nil {
+		return err
+	}
+	for _, sig := range signatures {
+		if p.compare(sig, []byte(ts+"."), body) == nil {
+			return nil
+		}
+	}
+	return errors.New("signature mismatch")
+}
+
+func (p *Provider) checkTimestamp(ts string) error {
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.Errorf("invalid timestamp %q", ts)
+	}
+	age := now().Sub(time.Unix(sec, 0))
+	if age > p.tolerance || age < -p.tolerance {
+		return errors.Errorf("timestamp %s is out of tolerance", ts)
+	}
+	return nil
+}
+
+// compare the signature with the hmac of the payload parts in constant time
+func (p *Provider) compare(signature string, parts ...[]byte) error {
+	if !strings.HasPrefix(signature, p.Prefix) {
+		return errors.New("signature prefix mismatch")
+	}
+	var got []byte
+	var err error
+	if p.Encoding == encodingBase64 {
+		got, err = base64.StdEncoding.DecodeString(signature[len(p.Prefix):])
+	} else {
+		got, err = hex.DecodeString(signature[len(p.Prefix):])
+	}
+	if err != nil {
+		return errors.Wrap(err, "signature decode fail")
+	}
+	if !hmac.Equal(got, p.sign(parts...)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func (p *Provider) sign(parts ...[]byte) []byte {
+	mac := hmac.New(p.hash, []byte(p.Secret))
+	for _, part := range parts {
+		mac.Write(part)
+	}
+	return mac.Sum(nil)
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+const (
+	secret  = "webhook-secret"
+	payload = `{"action":"opened","number":1}`
+)
+
+func sign(parts ...string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, p := range parts {
+		mac.Write([]byte(p))
+	}
+	return mac.Sum(nil)
+}
+
+func TestWebhook(t *testing.T) {
+	signedAt := time.Unix(1650000000, 0)
+	origin := now
+	now = func() time.Time { return signedAt.Add(time.Minute) }
+	defer func() { now = origin }()
+	ts := strconv.FormatInt(signedAt.Unix(), 10)
+	expired := strconv.FormatInt(signedAt.Add(-time.Hour).Unix(), 10)
+
+	factory := &FilterFactory{cfg: &Config{Providers: []*Provider{
+		{Name: "github", Path: "/webhook/github", Preset: PresetGitHub, Secret: secret},
+		{Name: "stripe", Path: "/webhook/stripe", Preset: PresetStripe, Secret: secret},
+		{Name: "slack", Path: "/webhook/slack", Preset: PresetSlack, Secret: secret},
+		{Name: "shopify", Path: "/webhook/shopify", Preset: PresetShopify, Secret: secret},
+	}}}
+	assert.Nil(t, factory.Apply())
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		headers map[string]string
+		status  int
+	}{
+		{
+			name:    "github",
+			path:    "/webhook/github",
+			headers: map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(payload))},
+		},
+		{
+			name:    "github tampered",
+			path:    "/webhook/github",
+			body:    `{"action":"closed","number":1}`,
+			headers: map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(payload))},
+			status:  http.StatusUnauthorized,
+		},
+		{name: "github unsigned", path: "/webhook/github", status: http.StatusUnauthorized},
+		{
+			name:    "stripe",
+			path:    "/webhook/stripe",
+			headers: map[string]string{"Stripe-Signature": "t=" + ts + ",v1=deadbeef,v1=" + hex.EncodeToString(sign(ts, ".", payload))},
+		},
+		{
+			name:    "stripe expired",
+			path:    "/webhook/stripe",
+			headers: map[string]string{"Stripe-Signature": "t=" + expired + ",v1=" + hex.EncodeToString(sign(expired, ".", payload))},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name: "slack",
+			path: "/webhook/slack",
+			headers: map[string]string{
+				"X-Slack-Request-Timestamp": ts,
+				"X-Slack-Signature":         "v0=" + hex.EncodeToString(sign("v0:", ts, ":", payload)),
+			},
+		},
+		{
+			name:    "shopify",
+			path:    "/webhook/shopify",
+			headers: map[string]string{"X-Shopify-Hmac-Sha256": base64.StdEncoding.EncodeToString(sign(payload))},
+		},
+		{name: "not webhook", path: "/api/user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			if body == "" {
+				body = payload
+			}
+			request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com"+tt.path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			for k, v := range tt.headers {
+				request.Header.Set(k, v)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			chain := filter.NewDefaultFilterChain()
+			_ = factory.PrepareFilterChain(ctx, chain)
+			chain.OnDecode(ctx)
+
+			if tt.status != 0 {
+				assert.Equal(t, tt.status, ctx.GetStatusCode())
+				assert.True(t, ctx.LocalReply())
+				return
+			}
+			assert.False(t, ctx.LocalReply())
+			// the body is still readable by upstream
+			forwarded, err := ioutil.ReadAll(ctx.Request.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, body, string(forwarded))
+		})
+	}
+}
+
+func TestApplyInvalidProvider(t *testing.T) {
+	invalid := []*Provider{
+		{Preset: "gitee", Secret: secret},
+		{Preset: PresetGitHub},
+		{Header: "X-Signature", Secret: secret, Algorithm: "md5"},
+		{Header: "X-Signature", Secret: secret, Scheme: SchemeSlack},
+	}
+	for _, p := range invalid {
+		factory := &FilterFactory{cfg: &Config{Providers: []*Provider{p}}}
+		assert.Error(t, factory.Apply())
+	}
+	assert.Error(t, (&FilterFactory{cfg: &Config{}}).Apply())
+}
@@ -60,6 +60,11 @@ func (factory *FilterFactory) Config() interface{} {
 	return factory.cfg
 }
 
+// Stage the filter authorizes the request before the body is read
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageAuth
+}
+
 func (factory *FilterFactory) Apply() error {
 	return nil
 }
@@ -18,10 +18,20 @@
 package csrf
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	stdHttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
 )
 
 import (
@@ -38,8 +48,20 @@ const (
 const (
 	csrfSecret = "csrfSecret"
 	csrfSalt   = "csrfSalt"
+
+	defaultCookieName = "pixiu_csrf"
+	defaultHeaderName = "X-CSRF-Token"
+	defaultErrorMsg   = "CSRF token mismatch"
+	defaultTTL        = 12 * time.Hour
+	tokenBytes        = 32
 )
 
+// defaultMethods the state changing methods protected by default
+var defaultMethods = []string{stdHttp.MethodPost, stdHttp.MethodPut, stdHttp.MethodPatch, stdHttp.MethodDelete}
+
+// now the clock, replaced in tests
+var now = time.Now
+
 func init() {
 	filter.RegisterHttpFilter(&Plugin{})
 }
@@ -52,17 +74,32 @@ type (
 	// FilterFactory is http filter instance
 	FilterFactory struct {
 		cfg *Config
+		ttl time.Duration
 	}
 	Filter struct {
 		cfg *Config
+		ttl time.Duration
 	}
 
-	// Config describe the config of FilterFactory
+	// Config describe the config of FilterFactory. The token is validated by the salt and secret when Secret is set,
+	// otherwise the filter issues the token in cookie and validates it by double submit: the state changing request
+	// should send the token of the cookie in HeaderName, which a cross site page can not read.
 	Config struct {
 		Key           string   `yaml:"key" json:"key" mapstructure:"key"`                                  // get request key
 		Secret        string   `yaml:"secret" json:"secret" mapstructure:"secret"`                         // private key
 		ErrorMsg      string   `yaml:"error_msg" json:"error_msg" mapstructure:"error_msg"`                // hint error info
 		IgnoreMethods []string `yaml:"ignore_methods" json:"ignore_methods" mapstructure:"ignore_methods"` // ignore request method
+
+		// CookieName the cookie issuing the token, pixiu_csrf by default
+		CookieName string `yaml:"cookie_name" json:"cookie_name" mapstructure:"cookie_name"`
+		// HeaderName the header submitting the token, X-CSRF-Token by default
+		HeaderName string `yaml:"header_name" json:"header_name" mapstructure:"header_name"`
+		// Methods the methods to protect, POST, PUT, PATCH and DELETE by default, the safe methods are always exempt
+		Methods []string `yaml:"methods" json:"methods" mapstructure:"methods"`
+		// TTL how long the issued token is valid, 12h by default
+		TTL string `yaml:"ttl" json:"ttl" mapstructure:"ttl"`
+		// Secure issue the cookie for https only
+		Secure bool `yaml:"secure" json:"secure" mapstructure:"secure"`
 	}
 )
 
@@ -75,12 +112,15 @@ func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
 }
 
 func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
-	f := &Filter{cfg: factory.cfg}
+	f := &Filter{cfg: factory.cfg, ttl: factory.ttl}
 	chain.AppendDecodeFilters(f)
 	return nil
 }
 
 func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.cfg.Secret == "" {
+		return f.doubleSubmit(ctx)
+	}
 	ctx.Request.Header.Set(csrfSecret, f.cfg.Secret)
 
 	if inMethod(f.cfg.IgnoreMethods, ctx.Request.Method) {
@@ -131,7 +171,102 @@ func tokenize(secret, salt string) string {
 	return base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%s", salt, secret)))
 }
 
+// doubleSubmit validate the token of the header against the cookie for the protected methods,
+// and issue a new token if the cookie has no valid one
+func (f *Filter) doubleSubmit(ctx *http.HttpContext) filter.FilterStatus {
+	var token string
+	if c, err := ctx.Request.Cookie(f.cfg.CookieName); err == nil && validToken(c.Value) {
+		token = c.Value
+	}
+	if inMethod(f.cfg.Methods, ctx.Request.Method) && !safeMethod(ctx.Request.Method) {
+		submitted := ctx.Request.Header.Get(f.cfg.HeaderName)
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(submitted)) != 1 {
+			bt, _ := json.Marshal(http.ErrResponse{Message: f.cfg.ErrorMsg})
+			return filter.Abort(ctx, &filter.AbortResponse{
+				Status:  stdHttp.StatusForbidden,
+				Body:    bt,
+				Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+			})
+		}
+		return filter.Continue
+	}
+	if token != "" {
+		return filter.Continue
+	}
+
+	token, err := newToken(f.ttl)
+	if err != nil {
+		bt, _ := json.Marshal(http.ErrResponse{Message: "issue csrf token fail"})
+		ctx.SendLocalReply(stdHttp.StatusInternalServerError, bt)
+		return filter.Stop
+	}
+	cookie := &stdHttp.Cookie{
+		Name:     f.cfg.CookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(f.ttl / time.Second),
+		Secure:   f.cfg.Secure,
+		SameSite: stdHttp.SameSiteLaxMode,
+	}
+	ctx.Writer.Header().Add("Set-Cookie", cookie.String())
+	return filter.Continue
+}
+
+// newToken the random token with its expiry, <random hex>.<expiry unix seconds>
+func newToken(ttl time.Duration) (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b) + "." + strconv.FormatInt(now().Add(ttl).Unix(), 10), nil
+}
+
+// validToken whether the token is issued by newToken and not expired
+func validToken(token string) bool {
+	i := strings.LastIndexByte(token, '.')
+	if i != tokenBytes*2 {
+		return false
+	}
+	expiry, err := strconv.ParseInt(token[i+1:], 10, 64)
+	return err == nil && now().Unix() < expiry
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case stdHttp.MethodGet, stdHttp.MethodHead, stdHttp.MethodOptions, stdHttp.MethodTrace:
+		return true
+	}
+	return false
+}
+
 func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.ErrorMsg == "" {
+		cfg.ErrorMsg = defaultErrorMsg
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = defaultCookieName
+	}
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = defaultHeaderName
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = append([]string(nil), defaultMethods...)
+	}
+	for i, m := range cfg.Methods {
+		cfg.Methods[i] = strings.ToUpper(m)
+	}
+	factory.ttl = defaultTTL
+	if cfg.TTL != "" {
+		ttl, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return errors.Wrap(err, "csrf ttl parse fail")
+		}
+		if ttl < time.Second {
+			return errors.New("csrf ttl should be at least 1s")
+		}
+		factory.ttl = ttl
+	}
 	return nil
 }
 
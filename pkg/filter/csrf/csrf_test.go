@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package csrf
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func decode(t *testing.T, factory *FilterFactory, method, cookie, header string) *contexthttp.HttpContext {
+	request, err := http.NewRequest(method, "http://www.dubbogopixiu.com/api/v1/test-dubbo/student/create", nil)
+	assert.NoError(t, err)
+	if cookie != "" {
+		request.AddCookie(&http.Cookie{Name: defaultCookieName, Value: cookie})
+	}
+	if header != "" {
+		request.Header.Set(defaultHeaderName, header)
+	}
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+	return ctx
+}
+
+// issued the token of the Set-Cookie response header
+func issued(ctx *contexthttp.HttpContext) string {
+	resp := http.Response{Header: ctx.Writer.Header()}
+	for _, c := range resp.Cookies() {
+		if c.Name == defaultCookieName {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func TestDoubleSubmit(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{TTL: "1h"}}
+	assert.Nil(t, factory.Apply())
+
+	// the safe request is issued a token
+	ctx := decode(t, factory, "GET", "", "")
+	assert.False(t, ctx.LocalReply())
+	token := issued(ctx)
+	assert.True(t, validToken(token))
+	assert.NotEqual(t, token, issued(decode(t, factory, "GET", "", "")))
+	// the valid token is not issued again
+	assert.Empty(t, issued(decode(t, factory, "GET", token, "")))
+
+	assert.False(t, decode(t, factory, "POST", token, token).LocalReply())
+	tampered := "0" + token[1:]
+	if token[0] == '0' {
+		tampered = "1" + token[1:]
+	}
+	for _, tt := range []struct{ cookie, header string }{{token, ""}, {"", token}, {token, tampered}} {
+		ctx = decode(t, factory, "POST", tt.cookie, tt.header)
+		assert.True(t, ctx.LocalReply())
+		assert.Equal(t, http.StatusForbidden, ctx.GetStatusCode())
+	}
+
+	// the expired token is rejected
+	now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	defer func() { now = time.Now }()
+	assert.True(t, decode(t, factory, "PUT", token, token).LocalReply())
+	assert.NotEmpty(t, issued(decode(t, factory, "GET", token, "")))
+}
+
+func TestSafeMethodsExempt(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Methods: []string{"get", "post"}}}
+	assert.Nil(t, factory.Apply())
+	assert.False(t, decode(t, factory, "GET", "", "").LocalReply())
+	assert.True(t, decode(t, factory, "POST", "", "").LocalReply())
+	// the methods not protected pass
+	assert.False(t, decode(t, factory, "DELETE", "", "").LocalReply())
+}
+
+func TestApplyInvalidTTL(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{TTL: "forever"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{TTL: "1ms"}}).Apply())
+}
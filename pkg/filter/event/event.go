@@ -74,11 +74,11 @@ func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
 	req := client.NewReq(ctx.Request.Context(), ctx.Request, *ctx.GetAPI())
 	resp, err := mqClient.Call(req)
 	if err != nil {
-		logger.Errorf("[dubbo-go-pixiu] event client call err:%v!", err)
+		logger.Errorw("event client call fail", "error", err.Error())
 		ctx.SendLocalReply(sdkhttp.StatusInternalServerError, []byte(fmt.Sprintf("event client call err:%v", err)))
 		return filter.Stop
 	}
-	logger.Debugf("[dubbo-go-pixiu] event client call resp:%v", resp)
+	logger.Debugw("event client call", "response", resp)
 	ctx.SourceResp = resp
 	return filter.Continue
 }
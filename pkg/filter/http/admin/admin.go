@@ -0,0 +1,204 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	stdHttp "net/http"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPAdminFilter
+
+	defaultPathPrefix = "/admin/filters/"
+	defaultStatsPath  = "/admin/stats/"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+		fm  *filter.FilterManager
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg *Config
+		fm  *filter.FilterManager
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// PathPrefix the prefix of the patch api, the filter name follows it
+		PathPrefix string `yaml:"path_prefix" json:"path_prefix" mapstructure:"path_prefix"`
+		// StatsPath the prefix of the stats api, GET it for the names of the statistics registered by the filters,
+		// or followed by a name for the statistics, e.g. /admin/stats/quota
+		StatsPath string `yaml:"stats_path" json:"stats_path" mapstructure:"stats_path"`
+		// Token the bearer token of the admin api, it is required
+		Token string `yaml:"token" json:"token" mapstructure:"token"`
+	}
+
+	// PatchResponse the response of the patch api
+	PatchResponse struct {
+		Filter  string `json:"filter"`
+		Patched bool   `json:"patched"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.PathPrefix == "" {
+		cfg.PathPrefix = defaultPathPrefix
+	}
+	if !strings.HasSuffix(cfg.PathPrefix, "/") {
+		cfg.PathPrefix += "/"
+	}
+	if cfg.StatsPath == "" {
+		cfg.StatsPath = defaultStatsPath
+	}
+	if !strings.HasSuffix(cfg.StatsPath, "/") {
+		cfg.StatsPath += "/"
+	}
+	if cfg.Token == "" {
+		return errors.New("admin token is required")
+	}
+	return nil
+}
+
+// SetFilterManager inject the manager whose filters are patched
+func (factory *FilterFactory) SetFilterManager(fm *filter.FilterManager) {
+	factory.fm = fm
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, fm: factory.fm}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// Decode serve PATCH {path_prefix}{filter name}[?chain={chain name}] with the json config of the filter,
+// and GET {stats_path}{name}
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	path := ctx.Request.URL.Path
+	stats := strings.HasPrefix(path, f.cfg.StatsPath) || path+"/" == f.cfg.StatsPath
+	if !stats && !strings.HasPrefix(path, f.cfg.PathPrefix) {
+		return filter.Continue
+	}
+
+	token := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(f.cfg.Token)) != 1 {
+		return reply(ctx, stdHttp.StatusUnauthorized, http.ErrResponse{Message: "invalid admin token"})
+	}
+	if stats {
+		return f.serveStats(ctx, strings.TrimPrefix(strings.TrimPrefix(path, f.cfg.StatsPath), "/"))
+	}
+	if ctx.GetMethod() != stdHttp.MethodPatch {
+		return reply(ctx, stdHttp.StatusMethodNotAllowed, http.ErrResponse{Message: "PATCH is required"})
+	}
+	name := strings.TrimPrefix(path, f.cfg.PathPrefix)
+	if name == "" {
+		return reply(ctx, stdHttp.StatusBadRequest, http.ErrResponse{Message: "filter name is required"})
+	}
+	if f.fm == nil {
+		return reply(ctx, stdHttp.StatusServiceUnavailable, http.ErrResponse{Message: "filter manager is not ready"})
+	}
+
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return reply(ctx, stdHttp.StatusBadRequest, http.ErrResponse{Message: err.Error()})
+	}
+	conf := make(map[string]interface{})
+	if err := json.Unmarshal(body, &conf); err != nil {
+		return reply(ctx, stdHttp.StatusBadRequest, http.ErrResponse{Message: "invalid json config: " + err.Error()})
+	}
+
+	// the filter of the named chain is patched by the chain query, the default filter otherwise
+	if chain := ctx.Request.URL.Query().Get("chain"); chain != "" {
+		err = f.fm.PatchChainFilter(chain, name, conf)
+	} else {
+		err = f.fm.PatchFilter(name, conf)
+	}
+	if err != nil {
+		logger.Warnw("admin patch filter fail", "filter", name, "error", err.Error())
+		status := stdHttp.StatusBadRequest
+		if errors.Is(err, filter.ErrFilterNotFound) || errors.Is(err, filter.ErrChainNotFound) {
+			status = stdHttp.StatusNotFound
+		}
+		return reply(ctx, status, http.ErrResponse{Message: err.Error()})
+	}
+	return reply(ctx, stdHttp.StatusOK, PatchResponse{Filter: name, Patched: true})
+}
+
+// serveStats reply the registered statistics of the name, or the names if name is empty
+func (f *Filter) serveStats(ctx *http.HttpContext, name string) filter.FilterStatus {
+	if ctx.GetMethod() != stdHttp.MethodGet {
+		return reply(ctx, stdHttp.StatusMethodNotAllowed, http.ErrResponse{Message: "GET is required"})
+	}
+	if name == "" {
+		return reply(ctx, stdHttp.StatusOK, filter.ListStats())
+	}
+	stats, ok := filter.CollectStats(name)
+	if !ok {
+		return reply(ctx, stdHttp.StatusNotFound, http.ErrResponse{Message: "stats " + name + " not found"})
+	}
+	return reply(ctx, stdHttp.StatusOK, stats)
+}
+
+func reply(ctx *http.HttpContext, status int, body interface{}) filter.FilterStatus {
+	bt, _ := json.Marshal(body)
+	return filter.Abort(ctx, &filter.AbortResponse{
+		Status:  status,
+		Body:    bt,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	})
+}
@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"bytes"
+	stdHttp "net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/delay"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func patch(t *testing.T, factory *FilterFactory, method, path, token, body string) *http.HttpContext {
+	request, err := stdHttp.NewRequest(method, "http://www.dubbogopixiu.com"+path, bytes.NewReader([]byte(body)))
+	assert.NoError(t, err)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+	return ctx
+}
+
+func TestPatchFilter(t *testing.T) {
+	fm := filter.NewFilterManager([]*model.HTTPFilter{
+		{Name: constant.HTTPDelayFilter, Config: map[string]interface{}{"delay": "10ms"}},
+	})
+	assert.Nil(t, fm.Load())
+	origin, _ := fm.GetFilterByName(constant.HTTPDelayFilter)
+
+	factory := &FilterFactory{cfg: &Config{Token: "secret"}}
+	assert.Nil(t, factory.Apply())
+	factory.SetFilterManager(fm)
+	path := defaultPathPrefix + constant.HTTPDelayFilter
+
+	ctx := patch(t, factory, stdHttp.MethodPatch, path, "wrong", `{"delay":"20ms"}`)
+	assert.Equal(t, stdHttp.StatusUnauthorized, ctx.GetStatusCode())
+
+	ctx = patch(t, factory, stdHttp.MethodGet, path, "secret", "")
+	assert.Equal(t, stdHttp.StatusMethodNotAllowed, ctx.GetStatusCode())
+
+	ctx = patch(t, factory, stdHttp.MethodPatch, defaultPathPrefix+"dgp.filter.http.unknown", "secret", `{}`)
+	assert.Equal(t, stdHttp.StatusNotFound, ctx.GetStatusCode())
+	ctx = patch(t, factory, stdHttp.MethodPatch, path+"?chain=unknown", "secret", `{"delay":"20ms"}`)
+	assert.Equal(t, stdHttp.StatusNotFound, ctx.GetStatusCode())
+
+	// the invalid config is rejected, the loaded filter is kept
+	ctx = patch(t, factory, stdHttp.MethodPatch, path, "secret", `{"delay":"forever"}`)
+	assert.Equal(t, stdHttp.StatusBadRequest, ctx.GetStatusCode())
+	current, _ := fm.GetFilterByName(constant.HTTPDelayFilter)
+	assert.True(t, origin == current)
+
+	ctx = patch(t, factory, stdHttp.MethodPatch, path, "secret", `{"delay":"20ms"`)
+	assert.Equal(t, stdHttp.StatusBadRequest, ctx.GetStatusCode())
+
+	ctx = patch(t, factory, stdHttp.MethodPatch, path, "secret", `{"delay":"20ms"}`)
+	assert.Equal(t, stdHttp.StatusOK, ctx.GetStatusCode())
+	current, _ = fm.GetFilterByName(constant.HTTPDelayFilter)
+	assert.False(t, origin == current)
+
+	// the other paths pass through
+	ctx = patch(t, factory, stdHttp.MethodGet, "/api/v1/user", "", "")
+	assert.False(t, ctx.LocalReply())
+}
+
+func TestServeStats(t *testing.T) {
+	filter.RegisterStats("admin_test", func() interface{} { return map[string]int{"count": 1} })
+	factory := &FilterFactory{cfg: &Config{Token: "secret"}}
+	assert.Nil(t, factory.Apply())
+
+	ctx := patch(t, factory, stdHttp.MethodGet, defaultStatsPath+"admin_test", "", "")
+	assert.Equal(t, stdHttp.StatusUnauthorized, ctx.GetStatusCode())
+
+	ctx = patch(t, factory, stdHttp.MethodGet, defaultStatsPath+"admin_test", "secret", "")
+	assert.Equal(t, stdHttp.StatusOK, ctx.GetStatusCode())
+
+	ctx = patch(t, factory, stdHttp.MethodGet, "/admin/stats", "secret", "")
+	assert.Equal(t, stdHttp.StatusOK, ctx.GetStatusCode())
+
+	ctx = patch(t, factory, stdHttp.MethodGet, defaultStatsPath+"unknown", "secret", "")
+	assert.Equal(t, stdHttp.StatusNotFound, ctx.GetStatusCode())
+
+	ctx = patch(t, factory, stdHttp.MethodPost, defaultStatsPath+"admin_test", "secret", "")
+	assert.Equal(t, stdHttp.StatusMethodNotAllowed, ctx.GetStatusCode())
+}
+
+func TestApplyRequireToken(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{PathPrefix: "/ops/filters"}}
+	assert.Error(t, factory.Apply())
+
+	factory.cfg.Token = "secret"
+	assert.Nil(t, factory.Apply())
+	assert.Equal(t, "/ops/filters/", factory.cfg.PathPrefix)
+}
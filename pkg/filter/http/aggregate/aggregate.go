@@ -0,0 +1,339 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	stdHttp "net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	fc "github.com/dubbogo/dubbo-go-pixiu-filter/pkg/api/config"
+	"github.com/dubbogo/dubbo-go-pixiu-filter/pkg/router"
+
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/client/dubbo"
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPAggregateFilter
+
+	upstreamTypeHTTP  = "http"
+	upstreamTypeDubbo = "dubbo"
+
+	defaultTimeout   = 3 * time.Second
+	defaultErrorsKey = "errors"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+// pickEndpoint pick an endpoint from the cluster manager
+var pickEndpoint = func(clusterName string) *model.Endpoint {
+	return server.GetClusterManager().PickEndpoint(clusterName)
+}
+
+// callDubbo generic invoke the dubbo service, the dubbo client is initialized by the dubbo proxy filter
+var callDubbo = func(ctx context.Context, r *stdHttp.Request, ir *fc.IntegrationRequest) (interface{}, error) {
+	api := router.API{Method: fc.Method{IntegrationRequest: *ir}}
+	return dubbo.SingletonDubboClient().Call(client.NewReq(ctx, r, api))
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg     *Config
+		timeout time.Duration
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg     *Config
+		timeout time.Duration
+		client  *stdHttp.Client
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Upstreams the upstreams called in parallel, their results are composed into one json object
+		Upstreams []*Upstream `yaml:"upstreams" json:"upstreams" mapstructure:"upstreams"`
+		// Timeout the timeout of each upstream call
+		Timeout string `yaml:"timeout" json:"timeout" mapstructure:"timeout"`
+		// ErrorsKey the key of the failed upstreams in the composed response
+		ErrorsKey string `yaml:"errors_key" json:"errors_key" mapstructure:"errors_key"`
+	}
+
+	// Upstream the upstream call and where its result placed
+	Upstream struct {
+		// Name the key of the result in composed response, empty means merging the fields of result object into root
+		Name string `yaml:"name" json:"name" mapstructure:"name"`
+		// Type http or dubbo, default is http
+		Type string `yaml:"type" json:"type" mapstructure:"type"`
+		// Cluster the cluster of http upstream
+		Cluster string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+		// Method the method of http upstream, default is the method of request
+		Method string `yaml:"method" json:"method" mapstructure:"method"`
+		// Path the path of http upstream, default is the path of request
+		Path string `yaml:"path" json:"path" mapstructure:"path"`
+		// Dubbo the dubbo backend and the param mappings
+		Dubbo *fc.IntegrationRequest `yaml:"dubbo" json:"dubbo" mapstructure:"dubbo"`
+		// Required the whole request fails with 502 when the upstream fails,
+		// otherwise the failure is reported in the errors of composed response
+		Required bool `yaml:"required" json:"required" mapstructure:"required"`
+	}
+
+	// result the result of one upstream call
+	result struct {
+		data json.RawMessage
+		err  error
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter reads the request body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if len(cfg.Upstreams) == 0 {
+		return errors.New("aggregate upstreams is empty")
+	}
+	if cfg.ErrorsKey == "" {
+		cfg.ErrorsKey = defaultErrorsKey
+	}
+	factory.timeout = defaultTimeout
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return errors.Wrap(err, "aggregate timeout parse fail")
+		}
+		factory.timeout = timeout
+	}
+
+	names := make(map[string]struct{}, len(cfg.Upstreams))
+	for i, u := range cfg.Upstreams {
+		if u.Name != "" {
+			if _, ok := names[u.Name]; ok {
+				return errors.Errorf("aggregate upstream name %s is duplicated", u.Name)
+			}
+			names[u.Name] = struct{}{}
+		}
+		if u.Type == "" {
+			u.Type = upstreamTypeHTTP
+		}
+		switch u.Type {
+		case upstreamTypeHTTP:
+			if u.Cluster == "" {
+				return errors.Errorf("aggregate upstream %d has no cluster", i)
+			}
+		case upstreamTypeDubbo:
+			if u.Dubbo == nil {
+				return errors.Errorf("aggregate upstream %d has no dubbo config", i)
+			}
+		default:
+			return errors.Errorf("aggregate upstream %d has invalid type %s", i, u.Type)
+		}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, timeout: factory.timeout, client: &stdHttp.Client{}}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	var body []byte
+	if ctx.Request.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(ctx.Request.Body); err != nil {
+			bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("read request body failed: %v", err)})
+			ctx.SendLocalReply(stdHttp.StatusBadRequest, bt)
+			return filter.Stop
+		}
+	}
+
+	results := make([]*result, len(f.cfg.Upstreams))
+	var wg sync.WaitGroup
+	for i, u := range f.cfg.Upstreams {
+		wg.Add(1)
+		go func(i int, u *Upstream) {
+			defer wg.Done()
+			// a panic of one upstream, e.g. a bad dubbo reply, fails that upstream instead of the gateway
+			defer func() {
+				if r := recover(); r != nil {
+					results[i] = &result{err: errors.Errorf("panic: %v", r)}
+				}
+			}()
+			results[i] = f.call(ctx, u, body)
+		}(i, u)
+	}
+	wg.Wait()
+
+	composed := make(map[string]interface{}, len(results)+1)
+	failures := make(map[string]string)
+	for i, u := range f.cfg.Upstreams {
+		res := results[i]
+		if res.err == nil && u.Name == "" {
+			res.err = mergeInto(composed, res.data)
+		}
+		if res.err != nil {
+			logger.Warnw("aggregate upstream fail", "upstream", upstreamName(u, i), "url", ctx.GetUrl(), "error", res.err.Error())
+			if u.Required {
+				bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("upstream %s fail: %v", upstreamName(u, i), res.err)})
+				ctx.SendLocalReply(stdHttp.StatusBadGateway, bt)
+				return filter.Stop
+			}
+			failures[upstreamName(u, i)] = res.err.Error()
+			continue
+		}
+		if u.Name != "" {
+			composed[u.Name] = res.data
+		}
+	}
+	if len(failures) > 0 {
+		composed[f.cfg.ErrorsKey] = failures
+	}
+
+	data, err := json.Marshal(composed)
+	if err != nil {
+		bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("compose response failed: %v", err)})
+		ctx.SendLocalReply(stdHttp.StatusInternalServerError, bt)
+		return filter.Stop
+	}
+	header := stdHttp.Header{}
+	header.Set(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+	ctx.SourceResp = &stdHttp.Response{
+		StatusCode: stdHttp.StatusOK,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(data)),
+	}
+	return filter.Stop
+}
+
+func (f *Filter) call(ctx *http.HttpContext, u *Upstream, body []byte) *result {
+	c, cancel := context.WithTimeout(ctx.Request.Context(), f.timeout)
+	defer cancel()
+
+	if u.Type == upstreamTypeDubbo {
+		r := ctx.Request.Clone(c)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		rst, err := callDubbo(c, r, u.Dubbo)
+		if err != nil {
+			return &result{err: err}
+		}
+		return &result{data: util.NewDubboResponse(rst, false).Data}
+	}
+
+	endpoint := pickEndpoint(u.Cluster)
+	if endpoint == nil {
+		return &result{err: errors.Errorf("cluster %s not found endpoint", u.Cluster)}
+	}
+	defer loadbalancer.Begin(u.Cluster, endpoint)()
+	r := ctx.Request
+	method, path := u.Method, u.Path
+	if method == "" {
+		method = r.Method
+	}
+	if path == "" {
+		path = r.URL.Path
+	}
+	target := url.URL{Scheme: "http", Host: endpoint.Address.GetAddress(), Path: path, RawQuery: r.URL.RawQuery}
+	req, err := stdHttp.NewRequestWithContext(c, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return &result{err: err}
+	}
+	req.Header = r.Header.Clone()
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return &result{err: err}
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return &result{err: err}
+	}
+	if resp.StatusCode >= stdHttp.StatusBadRequest {
+		return &result{err: errors.Errorf("upstream status code %d", resp.StatusCode)}
+	}
+	if !json.Valid(data) {
+		// the non json body is placed as string
+		data, _ = json.Marshal(strings.TrimSpace(string(data)))
+	}
+	return &result{data: data}
+}
+
+// mergeInto merge the fields of result object into the composed response
+func mergeInto(composed map[string]interface{}, data json.RawMessage) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return errors.New("result is not json object, it can not be merged")
+	}
+	for k, v := range fields {
+		composed[k] = v
+	}
+	return nil
+}
+
+func upstreamName(u *Upstream, i int) string {
+	if u.Name != "" {
+		return u.Name
+	}
+	return fmt.Sprintf("#%d", i)
+}
@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+import (
+	fc "github.com/dubbogo/dubbo-go-pixiu-filter/pkg/api/config"
+
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestAggregate(t *testing.T) {
+	user := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/1", r.URL.Path)
+		_, _ = w.Write([]byte(`{"id":1,"name":"joe"}`))
+	}))
+	defer user.Close()
+	order := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer order.Close()
+	mockEndpoints(t, map[string]*httptest.Server{"user": user, "order": order})
+
+	origin := callDubbo
+	callDubbo = func(ctx context.Context, r *http.Request, ir *fc.IntegrationRequest) (interface{}, error) {
+		return map[interface{}]interface{}{"class": "com.dubbogo.pixiu.Level", "level": 3}, nil
+	}
+	defer func() { callDubbo = origin }()
+
+	factory := &FilterFactory{cfg: &Config{Upstreams: []*Upstream{
+		{Name: "user", Cluster: "user", Path: "/user/1", Required: true},
+		{Name: "orders", Cluster: "order", Path: "/orders"},
+		{Type: upstreamTypeDubbo, Dubbo: &fc.IntegrationRequest{}},
+	}}}
+	assert.Nil(t, factory.Apply())
+
+	resp := decode(t, factory)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"user":{"id":1,"name":"joe"},"level":3,"errors":{"orders":"upstream status code 500"}}`, string(body))
+}
+
+func TestAggregateRequiredFail(t *testing.T) {
+	mockEndpoints(t, map[string]*httptest.Server{})
+	origin := callDubbo
+	callDubbo = func(ctx context.Context, r *http.Request, ir *fc.IntegrationRequest) (interface{}, error) {
+		return nil, errors.New("mock dubbo fail")
+	}
+	defer func() { callDubbo = origin }()
+
+	factory := &FilterFactory{cfg: &Config{Upstreams: []*Upstream{
+		{Name: "user", Cluster: "user"},
+		{Name: "level", Type: upstreamTypeDubbo, Dubbo: &fc.IntegrationRequest{}, Required: true},
+	}}}
+	assert.Nil(t, factory.Apply())
+
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+
+	assert.Equal(t, http.StatusBadGateway, ctx.GetStatusCode())
+	msg := map[string]string{}
+	assert.Nil(t, json.Unmarshal(ctx.GetLocalReplyBody(), &msg))
+	assert.Equal(t, "upstream level fail: mock dubbo fail", msg["message"])
+}
+
+func TestAggregateUpstreamPanic(t *testing.T) {
+	mockEndpoints(t, map[string]*httptest.Server{})
+	origin := callDubbo
+	callDubbo = func(ctx context.Context, r *http.Request, ir *fc.IntegrationRequest) (interface{}, error) {
+		panic("mock dubbo panic")
+	}
+	defer func() { callDubbo = origin }()
+
+	factory := &FilterFactory{cfg: &Config{Upstreams: []*Upstream{
+		{Name: "level", Type: upstreamTypeDubbo, Dubbo: &fc.IntegrationRequest{}},
+	}}}
+	assert.Nil(t, factory.Apply())
+
+	// the panic is the error of the optional upstream, the composed response is still replied
+	resp := decode(t, factory)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"errors":{"level":"panic: mock dubbo panic"}}`, string(body))
+}
+
+func TestApply(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Error(t, factory.Apply())
+
+	factory = &FilterFactory{cfg: &Config{Upstreams: []*Upstream{{Name: "a", Cluster: "a"}, {Name: "a", Cluster: "b"}}}}
+	assert.Error(t, factory.Apply())
+
+	factory = &FilterFactory{cfg: &Config{Upstreams: []*Upstream{{Name: "a", Type: upstreamTypeDubbo}}}}
+	assert.Error(t, factory.Apply())
+}
+
+func decode(t *testing.T, factory *FilterFactory) *http.Response {
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+	assert.False(t, ctx.LocalReply())
+	return ctx.SourceResp.(*http.Response)
+}
+
+func mockEndpoints(t *testing.T, servers map[string]*httptest.Server) {
+	endpoints := make(map[string]*model.Endpoint, len(servers))
+	for name, s := range servers {
+		host, port, err := net.SplitHostPort(s.Listener.Addr().String())
+		assert.NoError(t, err)
+		p, err := strconv.Atoi(port)
+		assert.NoError(t, err)
+		endpoints[name] = &model.Endpoint{Address: model.SocketAddress{Address: host, Port: p}}
+	}
+	origin := pickEndpoint
+	pickEndpoint = func(clusterName string) *model.Endpoint {
+		return endpoints[clusterName]
+	}
+	t.Cleanup(func() { pickEndpoint = origin })
+}
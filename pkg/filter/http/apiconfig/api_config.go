@@ -18,6 +18,8 @@
 package apiconfig
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 )
 
@@ -57,6 +59,7 @@ type (
 	}
 	Filter struct {
 		apiService api.APIDiscoveryService
+		versions   *VersionConfig
 	}
 )
 
@@ -74,6 +77,9 @@ func (factory *FilterFactory) Config() interface{} {
 
 func (factory *FilterFactory) Apply() error {
 	factory.apiService = api.NewLocalMemoryAPIDiscoveryService()
+	if factory.cfg.Versions != nil {
+		factory.cfg.Versions.setDefault()
+	}
 
 	if factory.cfg.Dynamic {
 		server.GetApiConfigManager().AddApiConfigListener(factory.cfg.DynamicAdapter, factory)
@@ -82,10 +88,10 @@ func (factory *FilterFactory) Apply() error {
 
 	config, err := initApiConfig(factory.cfg)
 	if err != nil {
-		logger.Errorf("Get ApiConfig fail: %v", err)
+		logger.Errorw("get api config fail", "error", err.Error())
 	}
 	if err := factory.apiService.InitAPIsFromConfig(*config); err != nil {
-		logger.Errorf("InitAPIsFromConfig fail: %v", err)
+		logger.Errorw("init apis from config fail", "error", err.Error())
 	}
 
 	return nil
@@ -107,7 +113,7 @@ func (factory *FilterFactory) GetAPIService() api.APIDiscoveryService {
 }
 
 func (factory *FilterFactory) PrepareFilterChain(ctx *contexthttp.HttpContext, chain filter.FilterChain) error {
-	f := &Filter{apiService: factory.apiService}
+	f := &Filter{apiService: factory.apiService, versions: factory.cfg.Versions}
 	chain.AppendDecodeFilters(f)
 	return nil
 }
@@ -128,6 +134,14 @@ func (f *Filter) Decode(ctx *contexthttp.HttpContext) filter.FilterStatus {
 		logger.Debug(e.Error())
 		return filter.Stop
 	}
+
+	if f.versions != nil {
+		if version, ok := f.versions.resolve(ctx, &v); !ok {
+			bt, _ := json.Marshal(contexthttp.ErrResponse{Message: fmt.Sprintf("unsupported api version %s", version)})
+			ctx.SendLocalReply(http.StatusBadRequest, bt)
+			return filter.Stop
+		}
+	}
 	ctx.API(v)
 	return filter.Continue
 }
@@ -141,7 +155,7 @@ func initApiConfig(cf *ApiConfigConfig) (*fc.APIConfig, error) {
 	if cf.APIMetaConfig != nil {
 		a, err := config.LoadAPIConfig(cf.APIMetaConfig)
 		if err != nil {
-			logger.Warnf("load api config from etcd error:%+v", err)
+			logger.Warnw("load api config from etcd fail", "error", err.Error())
 			return nil, err
 		}
 		return a, nil
@@ -149,7 +163,7 @@ func initApiConfig(cf *ApiConfigConfig) (*fc.APIConfig, error) {
 
 	a, err := config.LoadAPIConfigFromFile(cf.Path)
 	if err != nil {
-		logger.Errorf("load api config error:%+v", err)
+		logger.Errorw("load api config fail", "error", err.Error())
 		return nil, err
 	}
 	return a, nil
@@ -27,4 +27,5 @@ type ApiConfigConfig struct {
 	Path           string               `yaml:"path" json:"path,omitempty"`
 	Dynamic        bool                 `yaml:"dynamic" json:"dynamic,omitempty"`
 	DynamicAdapter string               `yaml:"dynamic_adapter" json:"dynamic_adapter,omitempty"`
+	Versions       *VersionConfig       `yaml:"versions" json:"versions,omitempty"`
 }
@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apiconfig
+
+import (
+	"strings"
+)
+
+import (
+	fc "github.com/dubbogo/dubbo-go-pixiu-filter/pkg/api/config"
+	"github.com/dubbogo/dubbo-go-pixiu-filter/pkg/router"
+)
+
+import (
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const defaultVersionHeader = "X-API-Version"
+
+type (
+	// VersionConfig select the integration request variant of the matched api by the version header
+	VersionConfig struct {
+		Header string          `yaml:"header" json:"header,omitempty"`
+		Routes []*VersionRoute `yaml:"routes" json:"routes,omitempty"`
+	}
+
+	// VersionRoute the integration request variants of an api, keyed by version
+	VersionRoute struct {
+		// Path the url pattern of the api, such as /api/v1/user/:id
+		Path string `yaml:"path" json:"path,omitempty"`
+		// Method the http verb of the api, empty matches all verbs
+		Method string `yaml:"method" json:"method,omitempty"`
+		// Default the version used when the request has no version header,
+		// the integration request of api config is used if it is empty
+		Default  string                           `yaml:"default" json:"default,omitempty"`
+		Variants map[string]fc.IntegrationRequest `yaml:"variants" json:"variants,omitempty"`
+	}
+)
+
+func (vc *VersionConfig) setDefault() {
+	if vc.Header == "" {
+		vc.Header = defaultVersionHeader
+	}
+}
+
+func (vc *VersionConfig) match(api *router.API) *VersionRoute {
+	for _, r := range vc.Routes {
+		if r.Path == api.URLPattern && (r.Method == "" || strings.EqualFold(r.Method, string(api.Method.HTTPVerb))) {
+			return r
+		}
+	}
+	return nil
+}
+
+// resolve replace the integration request of api with the variant selected by the version header,
+// the version is returned with false if it is not supported
+func (vc *VersionConfig) resolve(ctx *contexthttp.HttpContext, api *router.API) (string, bool) {
+	route := vc.match(api)
+	if route == nil {
+		return "", true
+	}
+
+	version := ctx.GetHeader(vc.Header)
+	if version == "" {
+		if route.Default == "" {
+			return "", true
+		}
+		version = route.Default
+	}
+
+	variant, ok := route.Variants[version]
+	if !ok {
+		return version, false
+	}
+	api.Method.IntegrationRequest = variant
+	return version, true
+}
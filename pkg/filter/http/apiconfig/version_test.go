@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apiconfig
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	fc "github.com/dubbogo/dubbo-go-pixiu-filter/pkg/api/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/mock"
+	contextmock "github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/http/apiconfig/api"
+)
+
+func TestVersionSelectMapping(t *testing.T) {
+	svc := api.NewLocalMemoryAPIDiscoveryService()
+	assert.Nil(t, svc.AddAPI(mock.GetMockAPI(fc.MethodGet, "/api/v1/user")))
+
+	versions := &VersionConfig{Routes: []*VersionRoute{{
+		Path:    "/api/v1/user",
+		Method:  "get",
+		Default: "1",
+		Variants: map[string]fc.IntegrationRequest{
+			"1": {RequestType: fc.DubboRequest, DubboBackendConfig: fc.DubboBackendConfig{Interface: "com.pixiu.UserServiceV1"}},
+			"2": {RequestType: fc.DubboRequest, DubboBackendConfig: fc.DubboBackendConfig{Interface: "com.pixiu.UserServiceV2"}},
+		},
+	}}}
+	versions.setDefault()
+	f := &Filter{apiService: svc, versions: versions}
+
+	tests := []struct {
+		name      string
+		version   string
+		status    filter.FilterStatus
+		interfaze string
+	}{
+		{name: "default", status: filter.Continue, interfaze: "com.pixiu.UserServiceV1"},
+		{name: "v1", version: "1", status: filter.Continue, interfaze: "com.pixiu.UserServiceV1"},
+		{name: "v2", version: "2", status: filter.Continue, interfaze: "com.pixiu.UserServiceV2"},
+		{name: "unsupported", version: "3", status: filter.Stop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+			assert.NoError(t, err)
+			if tt.version != "" {
+				request.Header.Set(defaultVersionHeader, tt.version)
+			}
+			ctx := contextmock.GetMockHTTPContext(request)
+
+			assert.Equal(t, tt.status, f.Decode(ctx))
+			if tt.status == filter.Stop {
+				assert.Equal(t, http.StatusBadRequest, ctx.GetStatusCode())
+				return
+			}
+			assert.Equal(t, tt.interfaze, ctx.GetAPI().IntegrationRequest.Interface)
+		})
+	}
+}
+
+func TestVersionRouteNotMatch(t *testing.T) {
+	versions := &VersionConfig{Routes: []*VersionRoute{{Path: "/api/v1/user", Method: "POST"}}}
+	versions.setDefault()
+
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+	assert.NoError(t, err)
+	request.Header.Set(defaultVersionHeader, "9")
+	ctx := contextmock.GetMockHTTPContext(request)
+
+	v := mock.GetMockAPI(fc.MethodGet, "/api/v1/user")
+	_, ok := versions.resolve(ctx, &v)
+	assert.True(t, ok)
+	assert.Equal(t, mock.GetMockAPI(fc.MethodGet, "/api/v1/user"), v)
+}
@@ -0,0 +1,257 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	stdHttp "net/http"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPCacheFilter
+
+	defaultTTL        = time.Minute
+	defaultMaxEntries = 1024
+	defaultAdminPath  = "/admin/cache/invalidate"
+
+	cacheHeader = "X-Pixiu-Cache"
+	cacheHit    = "HIT"
+	cacheMiss   = "MISS"
+)
+
+// now is replaced in test
+var now = time.Now
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance, the cached entries are shared by the requests
+	FilterFactory struct {
+		cfg   *Config
+		ttl   time.Duration
+		store *store
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg   *Config
+		ttl   time.Duration
+		store *store
+		// base the key of the missed response to store
+		base string
+		// credentialed whether the request carries Authorization or Cookie
+		credentialed bool
+		// reqHeader the request headers on decode, the vary headers of the response select from them
+		reqHeader stdHttp.Header
+	}
+
+	// Config describe the config of FilterFactory. The successful GET responses with cache directives are cached
+	// by host, tenant, request uri and the headers in their Vary, the filter stops the chain on hit, so the filters
+	// must run for every request should be placed before it. The requests with Authorization or Cookie
+	// are served and stored only for the public responses, the Set-Cookie header is never stored.
+	Config struct {
+		// TTL how long the public response is cached if it has no Cache-Control max-age or Expires header, 1m by default
+		TTL string `yaml:"ttl" json:"ttl" mapstructure:"ttl"`
+		// MaxEntries the least recently used entries are evicted beyond it, 1024 by default
+		MaxEntries int `yaml:"max_entries" json:"max_entries" mapstructure:"max_entries"`
+		// AdminPath the path of invalidation api, POST with pattern or tag query param.
+		// The pattern is the glob of request uri like /api/v1/user*, the tags are set by route cache_tags
+		AdminPath string `yaml:"admin_path" json:"admin_path" mapstructure:"admin_path"`
+		// AdminToken the bearer token of invalidation api, the api is disabled if empty
+		AdminToken string `yaml:"admin_token" json:"admin_token" mapstructure:"admin_token"`
+	}
+
+	// InvalidateResponse the response body of invalidation api
+	InvalidateResponse struct {
+		Invalidated int `json:"invalidated"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultMaxEntries
+	}
+	if cfg.AdminPath == "" {
+		cfg.AdminPath = defaultAdminPath
+	}
+	factory.ttl = defaultTTL
+	if cfg.TTL != "" {
+		ttl, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return errors.Wrap(err, "cache ttl parse fail")
+		}
+		if ttl <= 0 {
+			return errors.Errorf("invalid cache ttl %s", ttl)
+		}
+		factory.ttl = ttl
+	}
+	factory.store = newStore(cfg.MaxEntries)
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, ttl: factory.ttl, store: factory.store}
+	chain.AppendDecodeFilters(f)
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.cfg.AdminToken != "" && ctx.GetUrl() == f.cfg.AdminPath {
+		return f.invalidate(ctx)
+	}
+	if ctx.GetMethod() != stdHttp.MethodGet {
+		return filter.Continue
+	}
+
+	base := baseKey(ctx)
+	f.credentialed = ctx.GetHeader("Authorization") != "" || ctx.GetHeader("Cookie") != ""
+	if !strings.Contains(ctx.GetHeader("Cache-Control"), "no-cache") {
+		if e, ok := f.store.lookup(base, ctx.Request.Header, now()); ok && (e.public || !f.credentialed) {
+			header := e.header.Clone()
+			header.Set(cacheHeader, cacheHit)
+			ctx.SourceResp = &stdHttp.Response{
+				StatusCode: e.status,
+				Header:     header,
+				Body:       ioutil.NopCloser(bytes.NewReader(e.body)),
+			}
+			return filter.Stop
+		}
+	}
+	f.base = base
+	f.reqHeader = ctx.Request.Header.Clone()
+	return filter.Continue
+}
+
+// baseKey the key of the request by host, tenant and request uri
+func baseKey(ctx *http.HttpContext) string {
+	tenant, _ := ctx.Params[constant.TenantParam].(string)
+	return ctx.Request.Host + "\n" + tenant + "\n" + ctx.Request.URL.RequestURI()
+}
+
+// Encode store the successful response of the missed request
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.base == "" || ctx.GetStatusCode() != stdHttp.StatusOK || ctx.TargetResp == nil {
+		return filter.Continue
+	}
+	// the directives may come from the upstream or be set by the filters like the dubbo mapping
+	header := ctx.Writer.Header()
+	ttl, public, ok := responseTTL(header, now(), f.ttl)
+	if !ok || (f.credentialed && !public) {
+		return filter.Continue
+	}
+	vary, ok := varyNames(header)
+	if !ok {
+		return filter.Continue
+	}
+
+	stored := header.Clone()
+	stored.Del("Set-Cookie")
+	e := &entry{
+		key:      variantKey(f.base, vary, f.reqHeader),
+		base:     f.base,
+		uri:      ctx.Request.URL.RequestURI(),
+		vary:     vary,
+		public:   public,
+		status:   stdHttp.StatusOK,
+		header:   stored,
+		body:     append([]byte(nil), ctx.TargetResp.Data...),
+		expireAt: now().Add(ttl),
+	}
+	if ra := ctx.GetRouteEntry(); ra != nil {
+		e.tags = ra.CacheTags
+	}
+	f.store.set(e)
+	header.Set(cacheHeader, cacheMiss)
+	return filter.Continue
+}
+
+// invalidate remove the entries matching the pattern or tag immediately
+func (f *Filter) invalidate(ctx *http.HttpContext) filter.FilterStatus {
+	token := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(f.cfg.AdminToken)) != 1 {
+		return reply(ctx, stdHttp.StatusUnauthorized, http.ErrResponse{Message: "invalid admin token"})
+	}
+	if ctx.GetMethod() != stdHttp.MethodPost {
+		return reply(ctx, stdHttp.StatusMethodNotAllowed, http.ErrResponse{Message: "POST is required"})
+	}
+
+	query := ctx.Request.URL.Query()
+	pattern, tag := query.Get("pattern"), query.Get("tag")
+	if pattern == "" && tag == "" {
+		return reply(ctx, stdHttp.StatusBadRequest, http.ErrResponse{Message: "pattern or tag is required"})
+	}
+	n := 0
+	if pattern != "" {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return reply(ctx, stdHttp.StatusBadRequest, http.ErrResponse{Message: err.Error()})
+		}
+		n += f.store.invalidatePattern(re)
+	}
+	if tag != "" {
+		n += f.store.invalidateTag(tag)
+	}
+	logger.Infow("cache entries invalidated", "count", n, "pattern", pattern, "tag", tag)
+	return reply(ctx, stdHttp.StatusOK, InvalidateResponse{Invalidated: n})
+}
+
+func reply(ctx *http.HttpContext, status int, body interface{}) filter.FilterStatus {
+	bt, _ := json.Marshal(body)
+	return filter.Abort(ctx, &filter.AbortResponse{
+		Status:  status,
+		Body:    bt,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	})
+}
@@ -0,0 +1,280 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"io/ioutil"
+	stdHttp "net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// gateway run the cache filter like http connection manager, the upstream is called on miss
+type gateway struct {
+	t        *testing.T
+	factory  *FilterFactory
+	upstream int
+	// respHeader the headers of the upstream response
+	respHeader map[string]string
+}
+
+func (g *gateway) do(method, uri string, route *model.RouteAction, header map[string]string) *http.HttpContext {
+	request, err := stdHttp.NewRequest(method, "http://www.dubbogopixiu.com"+uri, nil)
+	assert.NoError(g.t, err)
+	for k, v := range header {
+		request.Header.Set(k, v)
+	}
+	if host, ok := header["Host"]; ok {
+		request.Host = host
+	}
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(route)
+
+	chain := filter.NewDefaultFilterChain()
+	_ = g.factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+	if !ctx.LocalReply() {
+		if resp, ok := ctx.SourceResp.(*stdHttp.Response); ok {
+			body, _ := ioutil.ReadAll(resp.Body)
+			for k := range resp.Header {
+				ctx.AddHeader(k, resp.Header.Get(k))
+			}
+			ctx.StatusCode(resp.StatusCode)
+			ctx.TargetResp = &client.Response{Data: body}
+		} else {
+			g.upstream++
+			for k, v := range g.respHeader {
+				ctx.AddHeader(k, v)
+			}
+			ctx.StatusCode(stdHttp.StatusOK)
+			ctx.TargetResp = &client.Response{Data: []byte(uri)}
+		}
+	}
+	chain.OnEncode(ctx)
+	return ctx
+}
+
+func newGateway(t *testing.T, cfg *Config) *gateway {
+	factory := &FilterFactory{cfg: cfg}
+	assert.Nil(t, factory.Apply())
+	return &gateway{t: t, factory: factory, respHeader: map[string]string{"Cache-Control": "public"}}
+}
+
+func TestCacheHitAndExpire(t *testing.T) {
+	current := time.Now()
+	origin := now
+	now = func() time.Time { return current }
+	defer func() { now = origin }()
+
+	g := newGateway(t, &Config{TTL: "10s"})
+	route := &model.RouteAction{}
+
+	ctx := g.do("GET", "/api/v1/user/1", route, nil)
+	assert.Equal(t, cacheMiss, ctx.Writer.Header().Get(cacheHeader))
+	ctx = g.do("GET", "/api/v1/user/1", route, nil)
+	assert.Equal(t, cacheHit, ctx.SourceResp.(*stdHttp.Response).Header.Get(cacheHeader))
+	assert.Equal(t, "/api/v1/user/1", string(ctx.TargetResp.Data))
+	assert.Equal(t, 1, g.upstream)
+
+	// bypass and not cached methods
+	g.do("GET", "/api/v1/user/1", route, map[string]string{"Cache-Control": "no-cache"})
+	g.do("POST", "/api/v1/user/1", route, nil)
+	assert.Equal(t, 3, g.upstream)
+
+	current = current.Add(11 * time.Second)
+	g.do("GET", "/api/v1/user/1", route, nil)
+	assert.Equal(t, 4, g.upstream)
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	g := newGateway(t, &Config{AdminToken: "secret"})
+	userRoute := &model.RouteAction{CacheTags: []string{"user"}}
+	orderRoute := &model.RouteAction{CacheTags: []string{"order"}}
+	admin := map[string]string{"Authorization": "Bearer secret"}
+
+	populate := func() {
+		g.upstream = 0
+		for _, uri := range []string{"/api/v1/user/1", "/api/v1/user/2?detail=true"} {
+			g.do("GET", uri, userRoute, nil)
+		}
+		g.do("GET", "/api/v1/order/1", orderRoute, nil)
+	}
+	hits := func() int {
+		before := g.upstream
+		for _, uri := range []string{"/api/v1/user/1", "/api/v1/user/2?detail=true"} {
+			g.do("GET", uri, userRoute, nil)
+		}
+		g.do("GET", "/api/v1/order/1", orderRoute, nil)
+		return 3 - (g.upstream - before)
+	}
+
+	populate()
+	assert.Equal(t, 3, hits())
+
+	// by pattern
+	ctx := g.do("POST", defaultAdminPath+"?pattern=/api/v1/user/*", nil, admin)
+	assert.Equal(t, stdHttp.StatusOK, ctx.GetStatusCode())
+	assert.JSONEq(t, `{"invalidated":2}`, string(ctx.GetLocalReplyBody()))
+	// the user entries miss and are cached again
+	assert.Equal(t, 1, hits())
+	assert.Equal(t, 3, hits())
+
+	// by tag
+	ctx = g.do("POST", defaultAdminPath+"?tag=order", nil, admin)
+	assert.JSONEq(t, `{"invalidated":1}`, string(ctx.GetLocalReplyBody()))
+	assert.Equal(t, 2, hits())
+
+	ctx = g.do("POST", defaultAdminPath+"?tag=user", nil, map[string]string{"Authorization": "Bearer wrong"})
+	assert.Equal(t, stdHttp.StatusUnauthorized, ctx.GetStatusCode())
+	ctx = g.do("POST", defaultAdminPath, nil, admin)
+	assert.Equal(t, stdHttp.StatusBadRequest, ctx.GetStatusCode())
+	assert.Equal(t, 3, hits())
+}
+
+func TestCacheControl(t *testing.T) {
+	current := time.Now()
+	origin := now
+	now = func() time.Time { return current }
+	defer func() { now = origin }()
+
+	g := newGateway(t, &Config{TTL: "10s"})
+	route := &model.RouteAction{}
+
+	g.respHeader = map[string]string{"Cache-Control": "no-store"}
+	g.do("GET", "/api/v1/user/1", route, nil)
+	g.do("GET", "/api/v1/user/1", route, nil)
+	assert.Equal(t, 2, g.upstream)
+
+	// max-age overrides the configured ttl
+	g.respHeader = map[string]string{"Cache-Control": "public, max-age=30"}
+	g.do("GET", "/api/v1/user/2", route, nil)
+	current = current.Add(20 * time.Second)
+	g.do("GET", "/api/v1/user/2", route, nil)
+	assert.Equal(t, 3, g.upstream)
+
+	g.respHeader = map[string]string{"Expires": current.Add(5 * time.Second).UTC().Format(stdHttp.TimeFormat)}
+	g.do("GET", "/api/v1/user/3", route, nil)
+	current = current.Add(6 * time.Second)
+	g.do("GET", "/api/v1/user/3", route, nil)
+	assert.Equal(t, 5, g.upstream)
+}
+
+func TestCacheShared(t *testing.T) {
+	g := newGateway(t, &Config{})
+	route := &model.RouteAction{}
+	user := map[string]string{"Authorization": "Bearer user"}
+
+	// the response for the request with credentials is stored only if public
+	g.respHeader = map[string]string{"Cache-Control": "max-age=60"}
+	g.do("GET", "/api/v1/me", route, user)
+	g.do("GET", "/api/v1/me", route, map[string]string{"Cookie": "session=other"})
+	assert.Equal(t, 2, g.upstream)
+	// the anonymous response is not served to the request with credentials
+	g.do("GET", "/api/v1/me", route, nil)
+	g.do("GET", "/api/v1/me", route, user)
+	g.do("GET", "/api/v1/me", route, nil)
+	assert.Equal(t, 4, g.upstream)
+
+	g.respHeader = map[string]string{"Cache-Control": "public, max-age=60", "Set-Cookie": "session=user"}
+	g.do("GET", "/api/v1/news", route, user)
+	ctx := g.do("GET", "/api/v1/news", route, map[string]string{"Cookie": "session=other"})
+	assert.Equal(t, 5, g.upstream)
+	assert.Empty(t, ctx.SourceResp.(*stdHttp.Response).Header.Get("Set-Cookie"))
+
+	// the variants by the vary headers
+	g.respHeader = map[string]string{"Cache-Control": "public", "Vary": "Accept"}
+	g.do("GET", "/api/v1/user/1", route, map[string]string{"Accept": "application/xml"})
+	g.do("GET", "/api/v1/user/1", route, map[string]string{"Accept": "application/json"})
+	g.do("GET", "/api/v1/user/1", route, map[string]string{"Accept": "application/xml"})
+	g.do("GET", "/api/v1/user/1", route, map[string]string{"Accept": "application/json"})
+	assert.Equal(t, 7, g.upstream)
+
+	g.respHeader = map[string]string{"Cache-Control": "public", "Vary": "*"}
+	g.do("GET", "/api/v1/user/2", route, nil)
+	g.do("GET", "/api/v1/user/2", route, nil)
+	assert.Equal(t, 9, g.upstream)
+
+	// the hosts are cached apart
+	g.respHeader = map[string]string{"Cache-Control": "public"}
+	g.do("GET", "/api/v1/user/3", route, map[string]string{"Host": "a.dubbogopixiu.com"})
+	g.do("GET", "/api/v1/user/3", route, map[string]string{"Host": "b.dubbogopixiu.com"})
+	g.do("GET", "/api/v1/user/3", route, map[string]string{"Host": "a.dubbogopixiu.com"})
+	assert.Equal(t, 11, g.upstream)
+}
+
+func TestResponseTTL(t *testing.T) {
+	current := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header map[string]string
+		ttl    time.Duration
+		public bool
+		cached bool
+	}{
+		{name: "no directives"},
+		{name: "public", header: map[string]string{"Cache-Control": "public"}, ttl: time.Minute, public: true, cached: true},
+		{name: "max-age", header: map[string]string{"Cache-Control": "max-age=120"}, ttl: 2 * time.Minute, cached: true},
+		{name: "s-maxage first", header: map[string]string{"Cache-Control": `max-age=120, s-maxage="30"`}, ttl: 30 * time.Second, cached: true},
+		{name: "max-age zero", header: map[string]string{"Cache-Control": "max-age=0"}},
+		{name: "private", header: map[string]string{"Cache-Control": "Private"}},
+		{name: "max-age before expires", header: map[string]string{"Cache-Control": "max-age=10", "Expires": "Sat, 01 Jan 2022 01:00:00 GMT"}, ttl: 10 * time.Second, cached: true},
+		{name: "expires", header: map[string]string{"Expires": "Sat, 01 Jan 2022 01:00:00 GMT"}, ttl: time.Hour, cached: true},
+		{name: "expires by date", header: map[string]string{"Expires": "Sat, 01 Jan 2022 01:00:00 GMT", "Date": "Sat, 01 Jan 2022 00:30:00 GMT"}, ttl: 30 * time.Minute, cached: true},
+		{name: "invalid expires", header: map[string]string{"Expires": "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := stdHttp.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			ttl, public, cached := responseTTL(header, current, time.Minute)
+			assert.Equal(t, tt.cached, cached)
+			assert.Equal(t, tt.public, public)
+			assert.Equal(t, tt.ttl, ttl)
+		})
+	}
+}
+
+func TestStoreEvict(t *testing.T) {
+	s := newStore(2)
+	expireAt := time.Now().Add(time.Minute)
+	for _, key := range []string{"a", "b"} {
+		s.set(&entry{key: key, tags: []string{"t"}, expireAt: expireAt})
+	}
+	_, ok := s.get("a", time.Now())
+	assert.True(t, ok)
+	// b is the least recently used
+	s.set(&entry{key: "c", expireAt: expireAt})
+	_, ok = s.get("b", time.Now())
+	assert.False(t, ok)
+	assert.Equal(t, 1, s.invalidateTag("t"))
+	assert.Equal(t, 0, len(s.tags))
+}
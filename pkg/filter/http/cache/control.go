@@ -0,0 +1,124 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	stdHttp "net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// responseTTL how long the response can be cached by its Cache-Control and Expires headers, and whether it is
+// public. The public response without max-age or Expires is cached for the configured ttl, the response without
+// any of them is not cached. It returns false if the response must not be cached.
+func responseTTL(header stdHttp.Header, current time.Time, def time.Duration) (time.Duration, bool, bool) {
+	directives := parseCacheControl(header.Values("Cache-Control"))
+	// the filter is a shared cache, the private responses are not stored either
+	for _, d := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[d]; ok {
+			return 0, false, false
+		}
+	}
+	_, public := directives["public"]
+	for _, d := range []string{"s-maxage", "max-age"} {
+		if v, ok := directives[d]; ok {
+			secs, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || secs <= 0 {
+				return 0, false, false
+			}
+			return time.Duration(secs) * time.Second, public, true
+		}
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := stdHttp.ParseTime(expires)
+		if err != nil {
+			// the invalid date means already expired, see RFC 7234 section 5.3
+			return 0, false, false
+		}
+		if date, err := stdHttp.ParseTime(header.Get("Date")); err == nil {
+			current = date
+		}
+		if ttl := t.Sub(current); ttl > 0 {
+			return ttl, public, true
+		}
+		return 0, false, false
+	}
+	if public {
+		return def, true, true
+	}
+	return 0, false, false
+}
+
+// varyNames the sorted canonical names of the request headers the response varies by,
+// it returns false for Vary: * which can not be cached
+func varyNames(header stdHttp.Header) ([]string, bool) {
+	seen := make(map[string]struct{})
+	var names []string
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = stdHttp.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if name == "*" {
+				return nil, false
+			}
+			if _, ok := seen[name]; !ok {
+				seen[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+// variantKey the key of the response variant selected by the values of the vary headers
+func variantKey(base string, names []string, header stdHttp.Header) string {
+	var b strings.Builder
+	b.WriteString(base)
+	for _, name := range names {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(strings.Join(header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// parseCacheControl parse the directives to lower case names and their unquoted values
+func parseCacheControl(values []string) map[string]string {
+	directives := make(map[string]string)
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value := part, ""
+			if i := strings.IndexByte(part, '='); i >= 0 {
+				name, value = part[:i], strings.Trim(strings.TrimSpace(part[i+1:]), `"`)
+			}
+			directives[strings.ToLower(strings.TrimSpace(name))] = value
+		}
+	}
+	return directives
+}
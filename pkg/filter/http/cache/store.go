@@ -0,0 +1,195 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"container/list"
+	stdHttp "net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// entry the cached response
+	entry struct {
+		// key the variant key, see variantKey
+		key string
+		// base the key of the request regardless of the vary headers
+		base string
+		// uri the request uri matched by the invalidation pattern
+		uri string
+		// vary the names of the headers the response varies by
+		vary []string
+		// public whether the response can be replied to the requests with credentials
+		public   bool
+		status   int
+		header   stdHttp.Header
+		body     []byte
+		tags     []string
+		expireAt time.Time
+	}
+
+	// store the lru cache of responses, indexed by tag for invalidation
+	store struct {
+		maxEntries int
+
+		mu      sync.Mutex
+		entries map[string]*list.Element
+		lru     *list.List
+		tags    map[string]map[string]struct{}
+		varies  map[string]*variance
+	}
+
+	// variance the vary headers of the responses of a base key
+	variance struct {
+		names []string
+		// refs the count of the stored variants
+		refs int
+	}
+)
+
+func newStore(maxEntries int) *store {
+	return &store{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		tags:       make(map[string]map[string]struct{}),
+		varies:     make(map[string]*variance),
+	}
+}
+
+// lookup the entry of the variant selected by the request headers
+func (s *store) lookup(base string, header stdHttp.Header, now time.Time) (*entry, bool) {
+	s.mu.Lock()
+	v, ok := s.varies[base]
+	s.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return s.get(variantKey(base, v.names, header), now)
+}
+
+// get the entry of key, the expired entry is removed
+func (s *store) get(key string, now time.Time) (*entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !now.Before(e.expireAt) {
+		s.remove(el)
+		return nil, false
+	}
+	s.lru.MoveToFront(el)
+	return e, true
+}
+
+// set the entry, the least recently used entry is evicted when the store is full
+func (s *store) set(e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[e.key]; ok {
+		s.remove(el)
+	}
+	s.entries[e.key] = s.lru.PushFront(e)
+	v, ok := s.varies[e.base]
+	if !ok {
+		v = &variance{}
+		s.varies[e.base] = v
+	}
+	// the variants stored by the previous vary headers are unreachable and evicted in time
+	v.names = e.vary
+	v.refs++
+	for _, tag := range e.tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+		keys[e.key] = struct{}{}
+	}
+	for s.maxEntries > 0 && s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+}
+
+// invalidateTag remove the entries of the tag, return the count of removed entries
+func (s *store) invalidateTag(tag string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for key := range s.tags[tag] {
+		if el, ok := s.entries[key]; ok {
+			s.remove(el)
+			n++
+		}
+	}
+	return n
+}
+
+// invalidatePattern remove the entries whose request uri match the pattern, return the count of removed entries
+func (s *store) invalidatePattern(re *regexp.Regexp) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, el := range s.entries {
+		if re.MatchString(el.Value.(*entry).uri) {
+			s.remove(el)
+			n++
+		}
+	}
+	return n
+}
+
+// remove the entry element, the caller must hold the lock
+func (s *store) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	s.lru.Remove(el)
+	delete(s.entries, e.key)
+	if v, ok := s.varies[e.base]; ok {
+		v.refs--
+		if v.refs <= 0 {
+			delete(s.varies, e.base)
+		}
+	}
+	for _, tag := range e.tags {
+		if keys, ok := s.tags[tag]; ok {
+			delete(keys, e.key)
+			if len(keys) == 0 {
+				delete(s.tags, tag)
+			}
+		}
+	}
+}
+
+// compilePattern compile the glob pattern, * matches any characters including /
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
@@ -0,0 +1,174 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package canary
+
+import (
+	"math/rand"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPCanaryFilter
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg         *Config
+		totalWeight int
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg         *Config
+		totalWeight int
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Targets the clusters the traffic is split to by weight
+		Targets []*Target `yaml:"targets" json:"targets" mapstructure:"targets"`
+		// Headers the header matches override the weighted selection, the first matched one wins
+		Headers []*HeaderMatch `yaml:"headers" json:"headers" mapstructure:"headers"`
+	}
+
+	// Target the cluster with its weight
+	Target struct {
+		Cluster string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+		Weight  int    `yaml:"weight" json:"weight" mapstructure:"weight"`
+	}
+
+	// HeaderMatch route the request carrying the header to the cluster, empty value matches any value
+	HeaderMatch struct {
+		Name    string `yaml:"name" json:"name" mapstructure:"name"`
+		Value   string `yaml:"value" json:"value" mapstructure:"value"`
+		Cluster string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	total := 0
+	for _, t := range factory.cfg.Targets {
+		if t.Cluster == "" {
+			return errors.New("canary target cluster is empty")
+		}
+		if t.Weight < 0 {
+			return errors.Errorf("canary target %s weight %d is negative", t.Cluster, t.Weight)
+		}
+		total += t.Weight
+	}
+	for _, h := range factory.cfg.Headers {
+		if h.Name == "" || h.Cluster == "" {
+			return errors.New("canary header match requires name and cluster")
+		}
+	}
+	factory.totalWeight = total
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, totalWeight: factory.totalWeight}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// Decode replace the cluster of route entry of this request, the upstream filters pick endpoint from it
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	route := ctx.GetRouteEntry()
+	if route == nil {
+		return filter.Continue
+	}
+	cluster := f.pick(ctx)
+	if cluster == "" || cluster == route.Cluster {
+		return filter.Continue
+	}
+	logger.Debugw("canary filter route", "url", ctx.GetUrl(), "cluster", cluster)
+
+	// copy the route entry, for it is shared by all requests of the route
+	hint := *route
+	hint.Cluster = cluster
+	ctx.RouteEntry(&hint)
+	return filter.Continue
+}
+
+func (f *Filter) pick(ctx *http.HttpContext) string {
+	for _, h := range f.cfg.Headers {
+		v := ctx.GetHeader(h.Name)
+		if v != "" && (h.Value == "" || h.Value == v) {
+			return h.Cluster
+		}
+	}
+	if f.totalWeight <= 0 {
+		return ""
+	}
+
+	var n int
+	if id := requestID(ctx); id != "" {
+		// the same request id always hits the same target, so that the retries are consistent, and the
+		// consistent hashing cluster of the target picks the same endpoint by the request id as well
+		n = int(loadbalancer.Hash(id) % uint64(f.totalWeight))
+	} else {
+		n = rand.Intn(f.totalWeight)
+	}
+	for _, t := range f.cfg.Targets {
+		if n < t.Weight {
+			return t.Cluster
+		}
+		n -= t.Weight
+	}
+	return ""
+}
+
+func requestID(ctx *http.HttpContext) string {
+	if id := ctx.GetRequestID(); id != "" {
+		return id
+	}
+	return ctx.GetHeader(constant.HeaderKeyRequestID)
+}
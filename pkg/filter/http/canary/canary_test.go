@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package canary
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func newFilter(t *testing.T, cfg *Config) *Filter {
+	factory := &FilterFactory{cfg: cfg}
+	assert.Nil(t, factory.Apply())
+	return &Filter{cfg: factory.cfg, totalWeight: factory.totalWeight}
+}
+
+func decode(t *testing.T, f *Filter, header http.Header) string {
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+	assert.NoError(t, err)
+	for k, v := range header {
+		request.Header[k] = v
+	}
+	ctx := mock.GetMockHTTPContext(request)
+	route := &model.RouteAction{Cluster: "stable"}
+	ctx.RouteEntry(route)
+	f.Decode(ctx)
+	// the shared route entry must not be modified
+	assert.Equal(t, "stable", route.Cluster)
+	return ctx.GetRouteEntry().Cluster
+}
+
+func TestCanaryWeightDeterministic(t *testing.T) {
+	f := newFilter(t, &Config{Targets: []*Target{{Cluster: "stable", Weight: 80}, {Cluster: "canary", Weight: 20}}})
+
+	hits := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		header := http.Header{constant.HeaderKeyRequestID: []string{fmt.Sprintf("req-%d", i)}}
+		cluster := decode(t, f, header)
+		// retries with the same request id hit the same target
+		assert.Equal(t, cluster, decode(t, f, header))
+		hits[cluster]++
+	}
+	assert.Equal(t, 1000, hits["stable"]+hits["canary"])
+	assert.True(t, hits["canary"] > 100 && hits["canary"] < 300, hits)
+}
+
+func TestCanaryHeaderOverride(t *testing.T) {
+	f := newFilter(t, &Config{
+		Targets: []*Target{{Cluster: "stable", Weight: 1}, {Cluster: "canary", Weight: 0}},
+		Headers: []*HeaderMatch{{Name: "X-Canary", Value: "on", Cluster: "canary"}},
+	})
+
+	assert.Equal(t, "canary", decode(t, f, http.Header{"X-Canary": []string{"on"}}))
+	assert.Equal(t, "stable", decode(t, f, http.Header{"X-Canary": []string{"off"}}))
+	assert.Equal(t, "stable", decode(t, f, nil))
+}
+
+func TestCanaryApplyInvalid(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{Targets: []*Target{{Weight: 1}}}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Targets: []*Target{{Cluster: "a", Weight: -1}}}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Headers: []*HeaderMatch{{Name: "X-Canary"}}}}).Apply())
+}
@@ -0,0 +1,190 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package concurrency
+
+import (
+	"encoding/json"
+	"io"
+	stdHttp "net/http"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPConcurrencyFilter
+
+	defaultMaxQueueWait = time.Second
+)
+
+var (
+	errRejected     = errors.New("too many concurrent requests")
+	errQueueFull    = errors.New("concurrency queue is full")
+	errQueueTimeout = errors.New("wait in concurrency queue timeout")
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance, the requests passing the filter share its permits,
+	// so the limit is global, or per route when the filter is scoped by match or filter chain
+	FilterFactory struct {
+		cfg          *Config
+		permits      chan struct{}
+		queue        chan struct{}
+		maxQueueWait time.Duration
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		factory *FilterFactory
+		// held whether the request holds a permit, it is released once
+		held int32
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// MaxConcurrent the max in-flight requests
+		MaxConcurrent int `yaml:"max_concurrent" json:"max_concurrent" mapstructure:"max_concurrent"`
+		// QueueSize the max requests waiting for the permit, the excess is rejected at once, 0 means no queue
+		QueueSize int `yaml:"queue_size" json:"queue_size" mapstructure:"queue_size"`
+		// MaxQueueWait how long the queued request waits before rejected with 503, 1s by default
+		MaxQueueWait string `yaml:"max_queue_wait" json:"max_queue_wait" mapstructure:"max_queue_wait"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.MaxConcurrent <= 0 {
+		return errors.Errorf("max concurrent %d must be positive", cfg.MaxConcurrent)
+	}
+	if cfg.QueueSize < 0 {
+		return errors.Errorf("queue size %d must not be negative", cfg.QueueSize)
+	}
+	factory.maxQueueWait = defaultMaxQueueWait
+	if cfg.MaxQueueWait != "" {
+		wait, err := time.ParseDuration(cfg.MaxQueueWait)
+		if err != nil {
+			return errors.Wrap(err, "max queue wait parse fail")
+		}
+		factory.maxQueueWait = wait
+	}
+	factory.permits = make(chan struct{}, cfg.MaxConcurrent)
+	if cfg.QueueSize > 0 {
+		factory.queue = make(chan struct{}, cfg.QueueSize)
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{factory: factory}
+	chain.AppendDecodeFilters(f)
+	chain.AppendEncodeFilters(f)
+	// the encode filters are skipped when the request panics, release the permit with the chain as well
+	filter.Defer(chain, f.release)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	if err := f.factory.acquire(ctx); err != nil {
+		logger.Debugw("concurrency filter reject", "url", ctx.GetUrl(), "error", err.Error())
+		bt, _ := json.Marshal(http.ErrResponse{Message: err.Error()})
+		return filter.Abort(ctx, &filter.AbortResponse{
+			Status:  stdHttp.StatusServiceUnavailable,
+			Body:    bt,
+			Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+		})
+	}
+	atomic.StoreInt32(&f.held, 1)
+	return filter.Continue
+}
+
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	f.release()
+	return filter.Continue
+}
+
+// EncodeStream hold the permit until the streamed body is written
+func (f *Filter) EncodeStream(ctx *http.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	return filter.CloseFuncWriter(w, f.release), filter.Continue
+}
+
+// release return the permit if the request holds it, it is safe to be called more than once
+func (f *Filter) release() {
+	if atomic.CompareAndSwapInt32(&f.held, 1, 0) {
+		<-f.factory.permits
+	}
+}
+
+// acquire take a permit, or wait in the queue for it until the max queue wait or the request is canceled
+func (factory *FilterFactory) acquire(ctx *http.HttpContext) error {
+	select {
+	case factory.permits <- struct{}{}:
+		return nil
+	default:
+	}
+	if factory.queue == nil {
+		return errRejected
+	}
+	select {
+	case factory.queue <- struct{}{}:
+	default:
+		return errQueueFull
+	}
+	defer func() { <-factory.queue }()
+
+	timer := time.NewTimer(factory.maxQueueWait)
+	defer timer.Stop()
+	select {
+	case factory.permits <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return errQueueTimeout
+	case <-ctx.Request.Context().Done():
+		return ctx.Request.Context().Err()
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package concurrency
+
+import (
+	stdHttp "net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+type panicFilter struct{}
+
+func (f *panicFilter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	panic("mock downstream panic")
+}
+
+func newRequest(t *testing.T, factory *FilterFactory) (*http.HttpContext, filter.FilterChain) {
+	request, err := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+	return ctx, chain
+}
+
+func TestReject(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{MaxConcurrent: 1}}
+	assert.Nil(t, factory.Apply())
+
+	first, firstChain := newRequest(t, factory)
+	firstChain.OnDecode(first)
+	assert.False(t, first.LocalReply())
+
+	second, secondChain := newRequest(t, factory)
+	secondChain.OnDecode(second)
+	assert.True(t, second.LocalReply())
+	assert.Equal(t, stdHttp.StatusServiceUnavailable, second.GetStatusCode())
+
+	firstChain.OnEncode(first)
+	third, thirdChain := newRequest(t, factory)
+	thirdChain.OnDecode(third)
+	assert.False(t, third.LocalReply())
+}
+
+func TestQueue(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{MaxConcurrent: 1, QueueSize: 1, MaxQueueWait: "200ms"}}
+	assert.Nil(t, factory.Apply())
+
+	first, firstChain := newRequest(t, factory)
+	firstChain.OnDecode(first)
+
+	queued, queuedChain := newRequest(t, factory)
+	done := make(chan struct{})
+	go func() {
+		queuedChain.OnDecode(queued)
+		close(done)
+	}()
+	// wait until the request is queued
+	assert.Eventually(t, func() bool { return len(factory.queue) == 1 }, time.Second, time.Millisecond)
+
+	// the queue is full
+	full, fullChain := newRequest(t, factory)
+	fullChain.OnDecode(full)
+	assert.Equal(t, stdHttp.StatusServiceUnavailable, full.GetStatusCode())
+
+	firstChain.OnEncode(first)
+	<-done
+	assert.False(t, queued.LocalReply())
+
+	// nobody releases, the queued request times out
+	timeout, timeoutChain := newRequest(t, factory)
+	start := time.Now()
+	timeoutChain.OnDecode(timeout)
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	assert.Equal(t, stdHttp.StatusServiceUnavailable, timeout.GetStatusCode())
+}
+
+func TestReleaseOnPanic(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{MaxConcurrent: 1}}
+	assert.Nil(t, factory.Apply())
+	fm := filter.NewEmptyFilterManager()
+
+	ctx, chain := newRequest(t, factory)
+	chain.AppendDecodeFilters(&panicFilter{})
+	func() {
+		defer fm.ReleaseFilterChain(chain)
+		defer func() {
+			assert.NotNil(t, recover())
+		}()
+		chain.OnDecode(ctx)
+		chain.OnEncode(ctx)
+	}()
+	assert.Len(t, factory.permits, 0)
+
+	next, nextChain := newRequest(t, factory)
+	nextChain.OnDecode(next)
+	assert.False(t, next.LocalReply())
+	// released by encode and the chain, the permit is returned once
+	nextChain.OnEncode(next)
+	fm.ReleaseFilterChain(nextChain)
+	assert.Len(t, factory.permits, 0)
+}
+
+func TestApplyInvalid(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{MaxConcurrent: 1, QueueSize: -1}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{MaxConcurrent: 1, MaxQueueWait: "soon"}}).Apply())
+}
@@ -0,0 +1,206 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contenttype
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	stdHttp "net/http"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPContentTypeFilter
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Rules the allowed content types of the routes, the first rule matching the request is used
+		Rules []*Rule `yaml:"rules" json:"rules" mapstructure:"rules"`
+	}
+
+	// Rule the allowed content types of a route
+	Rule struct {
+		// Path the exact path of the route
+		Path string `yaml:"path" json:"path" mapstructure:"path"`
+		// Method the method of the route, empty means any method
+		Method string `yaml:"method" json:"method" mapstructure:"method"`
+		// Allowed the allowed content types like application/json, the subtype can be * like text/*
+		Allowed []string `yaml:"allowed" json:"allowed" mapstructure:"allowed"`
+		// Strict the parameters like charset must equal those of the allowed type,
+		// otherwise only the media type is compared
+		Strict bool `yaml:"strict" json:"strict" mapstructure:"strict"`
+
+		allowed []*mediaType
+	}
+
+	// mediaType the normalized content type, the type and parameter names are lower case
+	mediaType struct {
+		typ    string
+		params map[string]string
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	for _, r := range factory.cfg.Rules {
+		if r.Path == "" || len(r.Allowed) == 0 {
+			return errors.Errorf("content type rule of path %s requires path and allowed types", r.Path)
+		}
+		r.allowed = make([]*mediaType, 0, len(r.Allowed))
+		for _, a := range r.Allowed {
+			mt, err := parseMediaType(a)
+			if err != nil {
+				return errors.Wrapf(err, "content type rule of path %s", r.Path)
+			}
+			r.allowed = append(r.allowed, mt)
+		}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// Decode reject the request carrying a body of the content type not allowed with 415
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	req := ctx.Request
+	if !hasBody(req) {
+		return filter.Continue
+	}
+	for _, r := range f.cfg.Rules {
+		if r.Path != req.URL.Path || (r.Method != "" && !strings.EqualFold(r.Method, req.Method)) {
+			continue
+		}
+		contentType := req.Header.Get(constant.HeaderKeyContextType)
+		if mt, err := parseMediaType(contentType); err == nil && r.allow(mt) {
+			return filter.Continue
+		}
+		body, _ := json.Marshal(http.ErrResponse{
+			Message: fmt.Sprintf("content type %q is not allowed, expect %s", contentType, strings.Join(r.Allowed, ", ")),
+		})
+		return filter.Abort(ctx, &filter.AbortResponse{
+			Status:  stdHttp.StatusUnsupportedMediaType,
+			Body:    body,
+			Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+		})
+	}
+	return filter.Continue
+}
+
+func (r *Rule) allow(mt *mediaType) bool {
+	for _, a := range r.allowed {
+		if a.match(mt, r.Strict) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMediaType normalize the content type, the case and the whitespace around parameters are ignored
+func parseMediaType(s string) (*mediaType, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, errors.New("empty content type")
+	}
+	typ, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid content type %s", s)
+	}
+	if !strings.Contains(typ, "/") {
+		return nil, errors.Errorf("invalid content type %s", s)
+	}
+	return &mediaType{typ: typ, params: params}, nil
+}
+
+// match whether the allowed type matches the request type, the parameters are compared only if strict
+func (a *mediaType) match(mt *mediaType, strict bool) bool {
+	if !matchType(a.typ, mt.typ) {
+		return false
+	}
+	if !strict {
+		return true
+	}
+	if len(a.params) != len(mt.params) {
+		return false
+	}
+	for k, v := range a.params {
+		if !strings.EqualFold(mt.params[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchType(allowed, typ string) bool {
+	if allowed == "*/*" || allowed == typ {
+		return true
+	}
+	if strings.HasSuffix(allowed, "/*") {
+		return strings.HasPrefix(typ, strings.TrimSuffix(allowed, "*"))
+	}
+	return false
+}
+
+func hasBody(req *stdHttp.Request) bool {
+	return req.Body != nil && req.Body != stdHttp.NoBody && req.ContentLength != 0
+}
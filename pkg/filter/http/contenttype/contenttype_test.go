@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contenttype
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestContentType(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Rules: []*Rule{
+		{Path: "/api/v1/student", Method: "POST", Allowed: []string{"application/json"}},
+		{Path: "/api/v1/upload", Allowed: []string{"text/*", "application/json; charset=utf-8"}, Strict: true},
+	}}}
+	assert.Nil(t, factory.Apply())
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		allowed     bool
+	}{
+		{name: "json", method: "POST", path: "/api/v1/student", contentType: "application/json", body: "{}", allowed: true},
+		{name: "case and charset", method: "POST", path: "/api/v1/student", contentType: "Application/JSON ; charset=UTF-8", body: "{}", allowed: true},
+		{name: "wrong type", method: "POST", path: "/api/v1/student", contentType: "text/xml", body: "<a/>", allowed: false},
+		{name: "missing", method: "POST", path: "/api/v1/student", body: "{}", allowed: false},
+		{name: "malformed", method: "POST", path: "/api/v1/student", contentType: "json", body: "{}", allowed: false},
+		{name: "no body", method: "POST", path: "/api/v1/student", allowed: true},
+		{name: "other method", method: "PUT", path: "/api/v1/student", contentType: "text/xml", body: "<a/>", allowed: true},
+		{name: "strict charset", method: "POST", path: "/api/v1/upload", contentType: "application/json;charset=\"utf-8\"", body: "{}", allowed: true},
+		{name: "strict other charset", method: "POST", path: "/api/v1/upload", contentType: "application/json; charset=latin1", body: "{}", allowed: false},
+		{name: "strict no charset", method: "POST", path: "/api/v1/upload", contentType: "application/json", body: "{}", allowed: false},
+		{name: "wildcard", method: "POST", path: "/api/v1/upload", contentType: "text/csv", body: "a,b", allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, "http://www.dubbogopixiu.com"+tt.path, bytes.NewReader([]byte(tt.body)))
+			assert.NoError(t, err)
+			if tt.contentType != "" {
+				request.Header.Set("Content-Type", tt.contentType)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			chain := filter.NewDefaultFilterChain()
+			assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+			chain.OnDecode(ctx)
+
+			assert.Equal(t, !tt.allowed, ctx.LocalReply())
+			if !tt.allowed {
+				assert.Equal(t, http.StatusUnsupportedMediaType, ctx.GetStatusCode())
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{Rules: []*Rule{{Path: "/a"}}}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Rules: []*Rule{{Path: "/a", Allowed: []string{"json"}}}}}).Apply())
+}
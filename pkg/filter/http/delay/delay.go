@@ -0,0 +1,177 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package delay
+
+import (
+	"math/rand"
+	"net"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPDelayFilter
+
+	defaultHeader = "X-Pixiu-Delay"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg      *Config
+		minDelay time.Duration
+		maxDelay time.Duration
+		allowIPs map[string]struct{}
+		trusted  []*net.IPNet
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		header   string
+		minDelay time.Duration
+		maxDelay time.Duration
+		allowIPs map[string]struct{}
+		trusted  []*net.IPNet
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Header the debug header gates the delay, only the request carrying it will be delayed
+		Header string `yaml:"header" json:"header" mapstructure:"header"`
+		// AllowIPs the client ips allowed to trigger the delay, empty means all clients
+		AllowIPs []string `yaml:"allow_ips" json:"allow_ips" mapstructure:"allow_ips"`
+		// TrustedProxies the addresses or CIDRs of the proxies whose X-Forwarded-For is trusted for AllowIPs,
+		// the peer address is checked if empty
+		TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies" mapstructure:"trusted_proxies"`
+		// Delay the fixed delay, or the lower bound of the random delay when MaxDelay is set
+		Delay string `yaml:"delay" json:"delay" mapstructure:"delay"`
+		// MaxDelay the upper bound of the random delay
+		MaxDelay string `yaml:"max_delay" json:"max_delay" mapstructure:"max_delay"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Header == "" {
+		cfg.Header = defaultHeader
+	}
+
+	minDelay, err := time.ParseDuration(cfg.Delay)
+	if err != nil {
+		return errors.Wrap(err, "delay parse fail")
+	}
+	maxDelay := minDelay
+	if cfg.MaxDelay != "" {
+		if maxDelay, err = time.ParseDuration(cfg.MaxDelay); err != nil {
+			return errors.Wrap(err, "max delay parse fail")
+		}
+	}
+	if minDelay < 0 || maxDelay < minDelay {
+		return errors.Errorf("invalid delay range [%s, %s]", minDelay, maxDelay)
+	}
+	factory.minDelay, factory.maxDelay = minDelay, maxDelay
+
+	factory.allowIPs = make(map[string]struct{}, len(cfg.AllowIPs))
+	for _, ip := range cfg.AllowIPs {
+		factory.allowIPs[ip] = struct{}{}
+	}
+	if factory.trusted, err = http.ParseTrustedProxies(cfg.TrustedProxies); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{
+		header:   factory.cfg.Header,
+		minDelay: factory.minDelay,
+		maxDelay: factory.maxDelay,
+		allowIPs: factory.allowIPs,
+		trusted:  factory.trusted,
+	}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	if !f.gated(ctx) {
+		return filter.Continue
+	}
+
+	d := f.delay()
+	logger.Debugw("delay filter inject delay", "delay", d.String(), "url", ctx.GetUrl())
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Request.Context().Done():
+	}
+	return filter.Continue
+}
+
+// gated check whether the request should be delayed
+func (f *Filter) gated(ctx *http.HttpContext) bool {
+	if ctx.GetHeader(f.header) == "" {
+		return false
+	}
+	if len(f.allowIPs) == 0 {
+		return true
+	}
+	// the forwarded headers are spoofable unless set by the trusted proxies
+	_, ok := f.allowIPs[ctx.GetTrustedClientIP(f.trusted)]
+	return ok
+}
+
+func (f *Filter) delay() time.Duration {
+	if f.maxDelay <= f.minDelay {
+		return f.minDelay
+	}
+	return f.minDelay + time.Duration(rand.Int63n(int64(f.maxDelay-f.minDelay)))
+}
@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package delay
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestDelay(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Delay: "50ms", MaxDelay: "100ms", AllowIPs: []string{"10.0.0.1"},
+		TrustedProxies: []string{"192.168.0.1"}}}
+	assert.Nil(t, factory.Apply())
+
+	tests := []struct {
+		name    string
+		header  string
+		remote  string
+		ip      string
+		delayed bool
+	}{
+		{name: "gated", header: "on", remote: "10.0.0.1:80", delayed: true},
+		{name: "no header", remote: "10.0.0.1:80", delayed: false},
+		{name: "not allowed ip", header: "on", remote: "10.0.0.2:80", delayed: false},
+		{name: "spoofed ip", header: "on", remote: "10.0.0.2:80", ip: "10.0.0.1", delayed: false},
+		{name: "forwarded by trusted proxy", header: "on", remote: "192.168.0.1:80", ip: "10.0.0.1", delayed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+			assert.NoError(t, err)
+			request.RemoteAddr = tt.remote
+			if tt.ip != "" {
+				request.Header.Set("X-Forwarded-For", tt.ip)
+			}
+			if tt.header != "" {
+				request.Header.Set(defaultHeader, tt.header)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			chain := filter.NewDefaultFilterChain()
+			_ = factory.PrepareFilterChain(ctx, chain)
+
+			start := time.Now()
+			chain.OnDecode(ctx)
+			cost := time.Since(start)
+
+			if tt.delayed {
+				assert.True(t, cost >= 50*time.Millisecond, cost)
+				assert.True(t, cost < 500*time.Millisecond, cost)
+			} else {
+				assert.True(t, cost < 50*time.Millisecond, cost)
+			}
+		})
+	}
+}
+
+func TestApplyInvalidRange(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Delay: "100ms", MaxDelay: "10ms"}}
+	assert.Error(t, factory.Apply())
+
+	factory = &FilterFactory{cfg: &Config{}}
+	assert.Error(t, factory.Apply())
+
+	factory = &FilterFactory{cfg: &Config{Delay: "10ms", TrustedProxies: []string{"invalid"}}}
+	assert.Error(t, factory.Apply())
+}
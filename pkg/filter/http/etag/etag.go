@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	stdHttp "net/http"
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPETagFilter
+
+	headerETag            = "ETag"
+	headerIfNoneMatch     = "If-None-Match"
+	headerContentEncoding = "Content-Encoding"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter compute the strong ETag over the response body in encode stage.
+	// The encode filters run in the reverse order of config, so config this filter before
+	// the compression filter to hash the encoded body.
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Overwrite replace the ETag provided by upstream
+		Overwrite bool `yaml:"overwrite" json:"overwrite" mapstructure:"overwrite"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if ctx.LocalReply() || ctx.TargetResp == nil || ctx.GetStatusCode() != stdHttp.StatusOK {
+		return filter.Continue
+	}
+
+	header := ctx.Writer.Header()
+	etag := header.Get(headerETag)
+	if etag == "" || f.cfg.Overwrite {
+		etag = computeETag(header.Get(headerContentEncoding), ctx.TargetResp.Data)
+		header.Set(headerETag, etag)
+	}
+
+	method := ctx.Request.Method
+	if (method == stdHttp.MethodGet || method == stdHttp.MethodHead) && matchETag(ctx.GetHeader(headerIfNoneMatch), etag) {
+		ctx.StatusCode(stdHttp.StatusNotModified)
+		ctx.TargetResp.Data = nil
+	}
+	return filter.Continue
+}
+
+// computeETag return the strong ETag of body, the content encoding is hashed too,
+// so that the compressed and the identity representation have different ETags.
+func computeETag(contentEncoding string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(contentEncoding))
+	h.Write([]byte{0})
+	h.Write(body)
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// matchETag check the If-None-Match header with weak comparison, see RFC 7232 section 3.2
+func matchETag(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
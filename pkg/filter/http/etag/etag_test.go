@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package etag
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func encode(t *testing.T, body string, header http.Header) *contexthttp.HttpContext {
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	for k, v := range header {
+		request.Header[k] = v
+	}
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.StatusCode(http.StatusOK)
+	ctx.TargetResp = &client.Response{Data: []byte(body)}
+
+	f := &Filter{cfg: &Config{}}
+	f.Encode(ctx)
+	return ctx
+}
+
+func TestETagStable(t *testing.T) {
+	first := encode(t, `{"name":"tc"}`, nil).Writer.Header().Get(headerETag)
+	second := encode(t, `{"name":"tc"}`, nil).Writer.Header().Get(headerETag)
+	changed := encode(t, `{"name":"ic"}`, nil).Writer.Header().Get(headerETag)
+
+	assert.NotEmpty(t, first)
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, changed)
+	assert.NotEqual(t, computeETag("", []byte("a")), computeETag("gzip", []byte("a")))
+}
+
+func TestETagNotModified(t *testing.T) {
+	etag := computeETag("", []byte(`{"name":"tc"}`))
+
+	ctx := encode(t, `{"name":"tc"}`, http.Header{headerIfNoneMatch: []string{`"other", ` + etag}})
+	assert.Equal(t, http.StatusNotModified, ctx.GetStatusCode())
+	assert.Empty(t, ctx.TargetResp.Data)
+
+	ctx = encode(t, `{"name":"ic"}`, http.Header{headerIfNoneMatch: []string{etag}})
+	assert.Equal(t, http.StatusOK, ctx.GetStatusCode())
+	assert.Equal(t, `{"name":"ic"}`, string(ctx.TargetResp.Data))
+}
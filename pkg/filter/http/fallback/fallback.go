@@ -0,0 +1,328 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fallback
+
+import (
+	stdHttp "net/http"
+	"strings"
+	"sync"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPFallbackFilter
+
+	// OnUpstreamError the upstream fails with 500, 502 or 503
+	OnUpstreamError = "upstream_error"
+	// OnTimeout the upstream times out with 504
+	OnTimeout = "timeout"
+	// OnCircuitOpen the circuit breaker rejects the request
+	OnCircuitOpen = "circuit_open"
+
+	defaultHeader     = "X-Pixiu-Fallback"
+	defaultMaxEntries = 1000
+
+	sourceStatic = "static"
+	sourceCached = "cached"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg  *Config
+		rule *Rule
+		w    *holdWriter
+	}
+
+	// Config describe the config of FilterFactory, the filter should be configured before the filters it falls back
+	Config struct {
+		// Header the response header telling the fallback source, static or cached
+		Header string `yaml:"header" json:"header" mapstructure:"header"`
+		// Rules the fallback of the routes, the first rule matching the request is used
+		Rules []*Rule `yaml:"rules" json:"rules" mapstructure:"rules"`
+	}
+
+	// Rule the fallback of a route
+	Rule struct {
+		// Prefix the path prefix of the route
+		Prefix string `yaml:"prefix" json:"prefix" mapstructure:"prefix"`
+		// Method the method of the route, empty means any method
+		Method string `yaml:"method" json:"method" mapstructure:"method"`
+		// On the failure conditions triggering the fallback: upstream_error, timeout or circuit_open, empty means all
+		On []string `yaml:"on" json:"on" mapstructure:"on"`
+		// CacheLastGood reply the last 2xx response of the same host and uri, the static response is used if nothing
+		// cached. The cache is shared by callers, so the requests with Authorization or Cookie and the responses
+		// varying by request headers, setting cookies or marked private are never cached or replied from it
+		CacheLastGood bool `yaml:"cache_last_good" json:"cache_last_good" mapstructure:"cache_last_good"`
+		// VaryHeaders the request headers telling the callers apart besides the host, such as the tenant header,
+		// their values are part of the cache key
+		VaryHeaders []string `yaml:"vary_headers" json:"vary_headers" mapstructure:"vary_headers"`
+		// MaxEntries the max responses cached of the rule, 1000 by default
+		MaxEntries int `yaml:"max_entries" json:"max_entries" mapstructure:"max_entries"`
+		// Status the status of the static response, 200 by default
+		Status int `yaml:"status" json:"status" mapstructure:"status"`
+		// Headers the headers of the static response
+		Headers map[string]string `yaml:"headers" json:"headers" mapstructure:"headers"`
+		// Body the body of the static response, empty means no static response
+		Body string `yaml:"body" json:"body" mapstructure:"body"`
+
+		on       map[string]bool
+		mu       sync.RWMutex
+		lastGood map[string]*response
+	}
+
+	// response the fallback response
+	response struct {
+		status  int
+		headers map[string]string
+		body    []byte
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Header == "" {
+		cfg.Header = defaultHeader
+	}
+	for _, r := range cfg.Rules {
+		if err := r.init(); err != nil {
+			return errors.Wrapf(err, "fallback rule of prefix %s", r.Prefix)
+		}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendDecodeFilters(f)
+	chain.AppendEncodeFilters(f)
+	// the held response is still written when the chain panics before encoding
+	filter.Defer(chain, f.release)
+	return nil
+}
+
+func (r *Rule) init() error {
+	if r.Body == "" && !r.CacheLastGood {
+		return errors.New("neither body nor cache_last_good is configured")
+	}
+	r.on = make(map[string]bool, len(r.On))
+	for _, on := range r.On {
+		switch on {
+		case OnUpstreamError, OnTimeout, OnCircuitOpen:
+			r.on[on] = true
+		default:
+			return errors.Errorf("unknown condition %s", on)
+		}
+	}
+	if len(r.on) == 0 {
+		r.on = map[string]bool{OnUpstreamError: true, OnTimeout: true, OnCircuitOpen: true}
+	}
+	if r.Status == 0 {
+		r.Status = stdHttp.StatusOK
+	}
+	if r.MaxEntries <= 0 {
+		r.MaxEntries = defaultMaxEntries
+	}
+	r.lastGood = make(map[string]*response)
+	return nil
+}
+
+func (r *Rule) match(method, path string) bool {
+	return strings.HasPrefix(path, r.Prefix) && (r.Method == "" || strings.EqualFold(r.Method, method))
+}
+
+// cacheKey the key of the last good response, the hosts or tenants sharing a path do not share the response
+func (r *Rule) cacheKey(req *stdHttp.Request) string {
+	var b strings.Builder
+	b.WriteString(req.Host)
+	b.WriteString(req.URL.RequestURI())
+	for _, h := range r.VaryHeaders {
+		b.WriteByte('\n')
+		b.WriteString(h)
+		b.WriteByte(':')
+		b.WriteString(req.Header.Get(h))
+	}
+	return b.String()
+}
+
+// fallback return the cached last good response if enabled and shareable, or the static one
+func (r *Rule) fallback(key string, shared bool) (*response, string) {
+	if r.CacheLastGood && shared {
+		r.mu.RLock()
+		resp, ok := r.lastGood[key]
+		r.mu.RUnlock()
+		if ok {
+			return resp, sourceCached
+		}
+	}
+	if r.Body == "" {
+		return nil, ""
+	}
+	return &response{status: r.Status, headers: r.Headers, body: []byte(r.Body)}, sourceStatic
+}
+
+// remember cache the good response, the new keys are dropped when the cache is full
+func (r *Rule) remember(key string, resp *response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.lastGood[key]; !ok && len(r.lastGood) >= r.MaxEntries {
+		return
+	}
+	r.lastGood[key] = resp
+}
+
+// Decode hold the response written by the later filters, so that a failure reply can be replaced
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	for _, r := range f.cfg.Rules {
+		if r.match(ctx.Request.Method, ctx.Request.URL.Path) {
+			f.rule = r
+			break
+		}
+	}
+	if f.rule == nil {
+		return filter.Continue
+	}
+	f.w = &holdWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer = f.w
+	return filter.Continue
+}
+
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.rule == nil {
+		return filter.Continue
+	}
+	ctx.Writer = f.w.ResponseWriter
+
+	on := condition(ctx)
+	if on == "" {
+		f.release()
+		if f.rule.CacheLastGood && !ctx.LocalReply() && ctx.TargetResp != nil && isSuccess(ctx.GetStatusCode()) &&
+			sharedRequest(ctx.Request) && sharedResponse(ctx.Writer.Header()) {
+			f.rule.remember(f.rule.cacheKey(ctx.Request), &response{
+				status:  ctx.GetStatusCode(),
+				headers: map[string]string{constant.HeaderKeyContextType: ctx.Writer.Header().Get(constant.HeaderKeyContextType)},
+				body:    append([]byte(nil), ctx.TargetResp.Data...),
+			})
+		}
+		return filter.Continue
+	}
+
+	var resp *response
+	source := ""
+	if f.rule.on[on] {
+		resp, source = f.rule.fallback(f.rule.cacheKey(ctx.Request), sharedRequest(ctx.Request))
+	}
+	if resp == nil {
+		f.release()
+		return filter.Continue
+	}
+	logger.Warnw("fallback filter reply", "source", source, "url", ctx.GetUrl(), "on", on)
+	f.w.drop()
+
+	header := ctx.Writer.Header()
+	header.Del("Content-Length")
+	for k, v := range resp.headers {
+		header.Set(k, v)
+	}
+	header.Set(f.cfg.Header, source)
+	if ctx.LocalReply() {
+		// the failure is replied locally, the chain writes nothing more, so write the fallback here
+		ctx.SendLocalReply(resp.status, resp.body)
+		return filter.Continue
+	}
+	ctx.StatusCode(resp.status)
+	ctx.TargetResp = &client.Response{Data: resp.body}
+	return filter.Continue
+}
+
+// release write the held response
+func (f *Filter) release() {
+	if f.w != nil {
+		f.w.release()
+	}
+}
+
+// condition return the failure condition of the response, empty if it is not a failure
+func condition(ctx *http.HttpContext) string {
+	if open, _ := ctx.Params[constant.CircuitOpenParam].(bool); open {
+		return OnCircuitOpen
+	}
+	switch ctx.GetStatusCode() {
+	case stdHttp.StatusGatewayTimeout:
+		return OnTimeout
+	case stdHttp.StatusInternalServerError, stdHttp.StatusBadGateway, stdHttp.StatusServiceUnavailable:
+		return OnUpstreamError
+	}
+	return ""
+}
+
+// sharedRequest whether the response of the request can be shared by callers, it is keyed by the host, uri and
+// the vary headers only
+func sharedRequest(r *stdHttp.Request) bool {
+	return r.Header.Get("Authorization") == "" && r.Header.Get("Cookie") == ""
+}
+
+// sharedResponse whether the response is the same for any caller of the uri
+func sharedResponse(header stdHttp.Header) bool {
+	if header.Get("Vary") != "" || header.Get("Set-Cookie") != "" {
+		return false
+	}
+	cc := strings.ToLower(header.Get("Cache-Control"))
+	return !strings.Contains(cc, "private") && !strings.Contains(cc, "no-store")
+}
+
+func isSuccess(status int) bool {
+	return status >= stdHttp.StatusOK && status < stdHttp.StatusMultipleChoices
+}
@@ -0,0 +1,190 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fallback
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+// upstreamFilter reply as the upstream filter does, a local reply of the status if failed
+type upstreamFilter struct {
+	status      int
+	body        string
+	headers     map[string]string
+	circuitOpen bool
+}
+
+func (f *upstreamFilter) Decode(ctx *contexthttp.HttpContext) filter.FilterStatus {
+	if f.circuitOpen {
+		ctx.Params[constant.CircuitOpenParam] = true
+	}
+	if f.status >= http.StatusInternalServerError {
+		ctx.SendLocalReply(f.status, []byte(f.body))
+		return filter.Stop
+	}
+	ctx.StatusCode(f.status)
+	ctx.AddHeader(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+	for k, v := range f.headers {
+		ctx.AddHeader(k, v)
+	}
+	ctx.TargetResp = &client.Response{Data: []byte(f.body)}
+	return filter.Continue
+}
+
+// serve run the chain and write the response as the http connection manager does
+func serve(t *testing.T, factory *FilterFactory, method string, upstream *upstreamFilter) *httptest.ResponseRecorder {
+	return serveWith(t, factory, method, nil, upstream)
+}
+
+func serveWith(t *testing.T, factory *FilterFactory, method string, header http.Header, upstream *upstreamFilter) *httptest.ResponseRecorder {
+	request, err := http.NewRequest(method, "http://www.dubbogopixiu.com/api/v1/student?id=1", nil)
+	assert.NoError(t, err)
+	for k := range header {
+		request.Header.Set(k, header.Get(k))
+	}
+	if host := header.Get("Host"); host != "" {
+		request.Host = host
+	}
+	rec := httptest.NewRecorder()
+	ctx := &contexthttp.HttpContext{Request: request, Writer: rec, Params: map[string]interface{}{}}
+	ctx.Reset()
+
+	chain := filter.NewDefaultFilterChain()
+	assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+	chain.AppendDecodeFilters(upstream)
+	chain.OnDecode(ctx)
+	chain.OnEncode(ctx)
+	if !ctx.LocalReply() {
+		ctx.Writer.WriteHeader(ctx.GetStatusCode())
+		_, _ = ctx.Writer.Write(ctx.TargetResp.Data)
+	}
+	return rec
+}
+
+func TestStaticFallback(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Rules: []*Rule{{
+		Prefix:  "/api/v1/student",
+		Method:  "GET",
+		On:      []string{OnUpstreamError, OnCircuitOpen},
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+		Body:    `{"students":[]}`,
+	}}}}
+	assert.Nil(t, factory.Apply())
+
+	tests := []struct {
+		name     string
+		method   string
+		upstream *upstreamFilter
+		status   int
+		body     string
+		source   string
+	}{
+		{name: "ok", method: "GET", upstream: &upstreamFilter{status: http.StatusOK, body: "ok"}, status: http.StatusOK, body: "ok"},
+		{name: "upstream error", method: "GET", upstream: &upstreamFilter{status: http.StatusBadGateway, body: "down"},
+			status: http.StatusOK, body: `{"students":[]}`, source: sourceStatic},
+		{name: "circuit open", method: "GET", upstream: &upstreamFilter{status: http.StatusServiceUnavailable, circuitOpen: true},
+			status: http.StatusOK, body: `{"students":[]}`, source: sourceStatic},
+		{name: "timeout not configured", method: "GET", upstream: &upstreamFilter{status: http.StatusGatewayTimeout, body: "timeout"},
+			status: http.StatusGatewayTimeout, body: "timeout"},
+		{name: "other route", method: "POST", upstream: &upstreamFilter{status: http.StatusBadGateway, body: "down"},
+			status: http.StatusBadGateway, body: "down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, factory, tt.method, tt.upstream)
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.body, rec.Body.String())
+			assert.Equal(t, tt.source, rec.Header().Get(defaultHeader))
+		})
+	}
+}
+
+func TestCachedFallback(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Rules: []*Rule{{Prefix: "/api", CacheLastGood: true}}}}
+	assert.Nil(t, factory.Apply())
+
+	// nothing cached and no static body, the failure is passed through
+	rec := serve(t, factory, "GET", &upstreamFilter{status: http.StatusServiceUnavailable, body: "down"})
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = serve(t, factory, "GET", &upstreamFilter{status: http.StatusOK, body: `{"name":"tc"}`})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(t, factory, "GET", &upstreamFilter{status: http.StatusGatewayTimeout, body: "timeout"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"name":"tc"}`, rec.Body.String())
+	assert.Equal(t, constant.HeaderValueJsonUtf8, rec.Header().Get(constant.HeaderKeyContextType))
+	assert.Equal(t, sourceCached, rec.Header().Get(defaultHeader))
+}
+
+func TestCachedFallbackNotShared(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Rules: []*Rule{{Prefix: "/api", CacheLastGood: true, Body: "static"}}}}
+	assert.Nil(t, factory.Apply())
+	auth := http.Header{"Authorization": {"Bearer alice"}}
+
+	// the credentialed and the private responses are not cached
+	serveWith(t, factory, "GET", auth, &upstreamFilter{status: http.StatusOK, body: "alice"})
+	serve(t, factory, "GET", &upstreamFilter{status: http.StatusOK, body: "private", headers: map[string]string{"Cache-Control": "private"}})
+	serve(t, factory, "GET", &upstreamFilter{status: http.StatusOK, body: "varied", headers: map[string]string{"Vary": "Accept-Language"}})
+	rec := serve(t, factory, "GET", &upstreamFilter{status: http.StatusBadGateway})
+	assert.Equal(t, "static", rec.Body.String())
+
+	// the cached response is not replied to the credentialed request
+	serve(t, factory, "GET", &upstreamFilter{status: http.StatusOK, body: "public"})
+	rec = serve(t, factory, "GET", &upstreamFilter{status: http.StatusBadGateway})
+	assert.Equal(t, "public", rec.Body.String())
+	rec = serveWith(t, factory, "GET", http.Header{"Cookie": {"session=1"}}, &upstreamFilter{status: http.StatusBadGateway})
+	assert.Equal(t, "static", rec.Body.String())
+	assert.Equal(t, sourceStatic, rec.Header().Get(defaultHeader))
+}
+
+func TestCachedFallbackKey(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Rules: []*Rule{{Prefix: "/api", CacheLastGood: true, Body: "static",
+		VaryHeaders: []string{"X-Tenant"}}}}}
+	assert.Nil(t, factory.Apply())
+	tenantA := http.Header{"X-Tenant": {"a"}}
+	other := http.Header{"X-Tenant": {"a"}, "Host": {"other.dubbogopixiu.com"}}
+
+	serveWith(t, factory, "GET", tenantA, &upstreamFilter{status: http.StatusOK, body: "tenant a"})
+	rec := serveWith(t, factory, "GET", tenantA, &upstreamFilter{status: http.StatusBadGateway})
+	assert.Equal(t, "tenant a", rec.Body.String())
+
+	// the other tenant and the other host sharing the path are not replied the cached response
+	rec = serveWith(t, factory, "GET", http.Header{"X-Tenant": {"b"}}, &upstreamFilter{status: http.StatusBadGateway})
+	assert.Equal(t, "static", rec.Body.String())
+	rec = serveWith(t, factory, "GET", other, &upstreamFilter{status: http.StatusBadGateway})
+	assert.Equal(t, "static", rec.Body.String())
+}
+
+func TestApply(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{Rules: []*Rule{{Prefix: "/"}}}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Rules: []*Rule{{Prefix: "/", Body: "{}", On: []string{"slow"}}}}}).Apply())
+}
@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fallback
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	stdHttp "net/http"
+	"sync"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// holdWriter hold the status and body written during decoding until the fallback filter decides
+// to release or drop them, the header map is shared with the origin writer
+type holdWriter struct {
+	stdHttp.ResponseWriter
+
+	mu     sync.Mutex
+	done   bool
+	status int
+	body   bytes.Buffer
+}
+
+func (w *holdWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *holdWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.status == 0 {
+		w.status = stdHttp.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Hijack pass through to the origin writer, nothing is held after hijacked
+func (w *holdWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(stdHttp.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijack")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.done = true
+	return hj.Hijack()
+}
+
+// release write the held response to the origin writer, the later writes pass through
+func (w *holdWriter) release() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return
+	}
+	w.done = true
+	if w.status == 0 {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		logger.Warnw("fallback filter write held response fail", "error", err.Error())
+	}
+}
+
+// drop discard the held response
+func (w *holdWriter) drop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.done = true
+	w.body.Reset()
+}
@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fault
+
+import (
+	"encoding/json"
+	"math/rand"
+	stdHttp "net/http"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPFaultFilter
+
+	defaultAbortStatus = stdHttp.StatusServiceUnavailable
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg   *Config
+		delay time.Duration
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg   *Config
+		delay time.Duration
+	}
+
+	// Config describe the config of FilterFactory, the delay and the abort are decided independently,
+	// so a request may be delayed and then aborted.
+	Config struct {
+		// DelayPercent the percentage of requests to delay, in [0, 100]
+		DelayPercent float64 `yaml:"delay_percent" json:"delay_percent" mapstructure:"delay_percent"`
+		// DelayDuration the injected delay, such as 3s
+		DelayDuration string `yaml:"delay_duration" json:"delay_duration" mapstructure:"delay_duration"`
+		// AbortPercent the percentage of requests to abort, in [0, 100]
+		AbortPercent float64 `yaml:"abort_percent" json:"abort_percent" mapstructure:"abort_percent"`
+		// AbortStatus the status code of aborted requests, 503 by default
+		AbortStatus int `yaml:"abort_status" json:"abort_status" mapstructure:"abort_status"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.DelayPercent < 0 || cfg.DelayPercent > 100 {
+		return errors.Errorf("delay percent %v out of range [0, 100]", cfg.DelayPercent)
+	}
+	if cfg.AbortPercent < 0 || cfg.AbortPercent > 100 {
+		return errors.Errorf("abort percent %v out of range [0, 100]", cfg.AbortPercent)
+	}
+
+	if cfg.DelayPercent > 0 {
+		d, err := time.ParseDuration(cfg.DelayDuration)
+		if err != nil {
+			return errors.Wrap(err, "delay duration parse fail")
+		}
+		factory.delay = d
+	}
+
+	if cfg.AbortStatus == 0 {
+		cfg.AbortStatus = defaultAbortStatus
+	}
+	if cfg.AbortStatus < stdHttp.StatusContinue || cfg.AbortStatus > 599 {
+		return errors.Errorf("invalid abort status %d", cfg.AbortStatus)
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, delay: factory.delay}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	if hit(f.cfg.DelayPercent) && f.delay > 0 {
+		logger.Debugw("fault filter inject delay", "delay", f.delay.String(), "url", ctx.GetUrl())
+
+		timer := time.NewTimer(f.delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Request.Context().Done():
+			// stop waiting once the request is canceled or its deadline is exceeded
+			timer.Stop()
+			bt, _ := json.Marshal(http.ErrResponse{Message: ctx.Request.Context().Err().Error()})
+			ctx.SendLocalReply(stdHttp.StatusGatewayTimeout, bt)
+			return filter.Stop
+		}
+	}
+
+	if hit(f.cfg.AbortPercent) {
+		logger.Debugw("fault filter inject abort", "status", f.cfg.AbortStatus, "url", ctx.GetUrl())
+
+		bt, _ := json.Marshal(http.ErrResponse{Message: "fault filter abort"})
+		ctx.SendLocalReply(f.cfg.AbortStatus, bt)
+		return filter.Stop
+	}
+	return filter.Continue
+}
+
+func hit(percent float64) bool {
+	if percent <= 0 {
+		return false
+	}
+	return percent >= 100 || rand.Float64()*100 < percent
+}
@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func decode(t *testing.T, cfg *Config, ctx context.Context) (filter.FilterStatus, int, time.Duration) {
+	factory := &FilterFactory{cfg: cfg}
+	assert.Nil(t, factory.Apply())
+
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/test-dubbo/user/getStudentTimeout", nil)
+	assert.NoError(t, err)
+	hc := mock.GetMockHTTPContext(request.WithContext(ctx))
+	f := &Filter{cfg: factory.cfg, delay: factory.delay}
+
+	start := time.Now()
+	status := f.Decode(hc)
+	return status, hc.GetStatusCode(), time.Since(start)
+}
+
+func TestFault(t *testing.T) {
+	status, _, cost := decode(t, &Config{DelayPercent: 100, DelayDuration: "50ms"}, context.Background())
+	assert.Equal(t, filter.Continue, status)
+	assert.True(t, cost >= 50*time.Millisecond, cost)
+
+	status, code, cost := decode(t, &Config{AbortPercent: 100, AbortStatus: http.StatusInternalServerError}, context.Background())
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.True(t, cost < 50*time.Millisecond, cost)
+
+	status, code, cost = decode(t, &Config{DelayPercent: 100, DelayDuration: "50ms", AbortPercent: 100}, context.Background())
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, cost >= 50*time.Millisecond, cost)
+
+	status, _, _ = decode(t, &Config{DelayPercent: 0, AbortPercent: 0}, context.Background())
+	assert.Equal(t, filter.Continue, status)
+}
+
+func TestFaultDelayRespectDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	status, code, cost := decode(t, &Config{DelayPercent: 100, DelayDuration: "1s"}, ctx)
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusGatewayTimeout, code)
+	assert.True(t, cost < 500*time.Millisecond, cost)
+}
+
+func TestFaultApplyInvalid(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{DelayPercent: 120}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{AbortPercent: 10, AbortStatus: 1000}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{DelayPercent: 10}}).Apply())
+}
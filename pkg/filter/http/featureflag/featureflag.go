@@ -0,0 +1,216 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package featureflag
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPFeatureFlagFilter
+
+	defaultHeader     = "X-Features"
+	defaultUserHeader = "X-User-Id"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter evaluate the flags of the request, the results are put in the context param feature_flags
+	// and the enabled flags are sent to upstream in Header
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		Flags []*Flag `yaml:"flags" json:"flags" mapstructure:"flags"`
+		// Header the header carrying the enabled flags to upstream separated by comma, X-Features by default,
+		// the one sent by client is replaced
+		Header string `yaml:"header" json:"header" mapstructure:"header"`
+		// UserHeader the header of the user id, X-User-Id by default. The user authenticated by the basic or apikey
+		// filter is preferred, the header is sent by client otherwise, so an auth filter in front must overwrite it
+		UserHeader string `yaml:"user_header" json:"user_header" mapstructure:"user_header"`
+		// Environment the environment of the gateway, e.g. staging
+		Environment string `yaml:"environment" json:"environment" mapstructure:"environment"`
+	}
+
+	// Flag is enabled when the environment matches and any of the targeting rules matches
+	Flag struct {
+		Name string `yaml:"name" json:"name" mapstructure:"name"`
+		// Environments the flag is disabled in the other environments, empty means all
+		Environments []string `yaml:"environments" json:"environments" mapstructure:"environments"`
+		// Allowlist the user ids the flag is enabled for
+		Allowlist []string `yaml:"allowlist" json:"allowlist" mapstructure:"allowlist"`
+		// Headers the flag is enabled for the request carrying any of the headers
+		Headers []*HeaderMatch `yaml:"headers" json:"headers" mapstructure:"headers"`
+		// Percentage the percentage of users the flag is enabled for, a user always gets the same result
+		// while the percentage is not lowered. The request without user id is enabled randomly.
+		Percentage int `yaml:"percentage" json:"percentage" mapstructure:"percentage"`
+
+		allowlist map[string]struct{}
+	}
+
+	// HeaderMatch match the request header, empty value matches any value
+	HeaderMatch struct {
+		Name  string `yaml:"name" json:"name" mapstructure:"name"`
+		Value string `yaml:"value" json:"value" mapstructure:"value"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Header == "" {
+		cfg.Header = defaultHeader
+	}
+	if cfg.UserHeader == "" {
+		cfg.UserHeader = defaultUserHeader
+	}
+	names := make(map[string]struct{}, len(cfg.Flags))
+	for _, f := range cfg.Flags {
+		if f.Name == "" || strings.Contains(f.Name, ",") {
+			return errors.Errorf("invalid flag name %q", f.Name)
+		}
+		if _, ok := names[f.Name]; ok {
+			return errors.Errorf("flag %s is configured more than once", f.Name)
+		}
+		names[f.Name] = struct{}{}
+		if f.Percentage < 0 || f.Percentage > 100 {
+			return errors.Errorf("flag %s percentage %d should be in [0, 100]", f.Name, f.Percentage)
+		}
+		for _, h := range f.Headers {
+			if h.Name == "" {
+				return errors.Errorf("flag %s header match requires name", f.Name)
+			}
+		}
+		f.allowlist = make(map[string]struct{}, len(f.Allowlist))
+		for _, id := range f.Allowlist {
+			f.allowlist[id] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	user, _ := ctx.Params[constant.AuthUserParam].(string)
+	if user == "" {
+		user = ctx.GetHeader(f.cfg.UserHeader)
+	}
+	results := make(map[string]bool, len(f.cfg.Flags))
+	var enabled []string
+	for _, flag := range f.cfg.Flags {
+		on := flag.evaluate(ctx, f.cfg.Environment, user)
+		results[flag.Name] = on
+		if on {
+			enabled = append(enabled, flag.Name)
+		}
+	}
+
+	if ctx.Params == nil {
+		ctx.Params = make(map[string]interface{})
+	}
+	ctx.Params[constant.FeatureFlagsParam] = results
+	if len(enabled) == 0 {
+		ctx.Request.Header.Del(f.cfg.Header)
+		return filter.Continue
+	}
+	sort.Strings(enabled)
+	ctx.Request.Header.Set(f.cfg.Header, strings.Join(enabled, ","))
+	return filter.Continue
+}
+
+func (flag *Flag) evaluate(ctx *http.HttpContext, env, user string) bool {
+	if len(flag.Environments) > 0 && !contains(flag.Environments, env) {
+		return false
+	}
+	if _, ok := flag.allowlist[user]; ok && user != "" {
+		return true
+	}
+	for _, h := range flag.Headers {
+		v := ctx.GetHeader(h.Name)
+		if v != "" && (h.Value == "" || h.Value == v) {
+			return true
+		}
+	}
+	if flag.Percentage <= 0 {
+		return false
+	}
+	if user == "" {
+		return rand.Intn(100) < flag.Percentage
+	}
+	// hash with the flag name, so that the flags are not enabled for the same users
+	return int(loadbalancer.Hash(flag.Name+":"+user)%100) < flag.Percentage
+}
+
+// Enabled whether the flag is enabled for the request, it is false if the flag is not evaluated
+func Enabled(ctx *http.HttpContext, name string) bool {
+	results, _ := ctx.Params[constant.FeatureFlagsParam].(map[string]bool)
+	return results[name]
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
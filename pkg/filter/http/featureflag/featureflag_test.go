@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package featureflag
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func decode(t *testing.T, factory *FilterFactory, headers map[string]string) *contexthttp.HttpContext {
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/test-dubbo/student", nil)
+	assert.NoError(t, err)
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+	return ctx
+}
+
+func TestFeatureFlag(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{
+		Environment: "staging",
+		Flags: []*Flag{
+			{Name: "new-student-page", Allowlist: []string{"1001"}, Headers: []*HeaderMatch{{Name: "X-Beta", Value: "true"}}},
+			{Name: "prod-only", Environments: []string{"prod"}, Percentage: 100},
+			{Name: "everyone", Percentage: 100},
+		},
+	}}
+	assert.Nil(t, factory.Apply())
+
+	ctx := decode(t, factory, map[string]string{"X-User-Id": "1001", "X-Features": "prod-only"})
+	assert.True(t, Enabled(ctx, "new-student-page"))
+	assert.False(t, Enabled(ctx, "prod-only"))
+	assert.False(t, Enabled(ctx, "unknown"))
+	// the flags claimed by client are replaced
+	assert.Equal(t, "everyone,new-student-page", ctx.Request.Header.Get("X-Features"))
+
+	ctx = decode(t, factory, map[string]string{"X-User-Id": "1002"})
+	assert.False(t, Enabled(ctx, "new-student-page"))
+	assert.Equal(t, "everyone", ctx.Request.Header.Get("X-Features"))
+
+	ctx = decode(t, factory, map[string]string{"X-Beta": "true"})
+	assert.True(t, Enabled(ctx, "new-student-page"))
+}
+
+func TestFeatureFlagAuthUser(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Flags: []*Flag{{Name: "new-student-page", Allowlist: []string{"1001"}}}}}
+	assert.Nil(t, factory.Apply())
+
+	request, _ := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/student", nil)
+	// the user claimed by client does not override the authenticated one
+	request.Header.Set("X-User-Id", "1001")
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.Params = map[string]interface{}{constant.AuthUserParam: "1002"}
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+	assert.False(t, Enabled(ctx, "new-student-page"))
+}
+
+func TestFeatureFlagPercentage(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Flags: []*Flag{{Name: "rollout", Percentage: 30}}}}
+	assert.Nil(t, factory.Apply())
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		user := map[string]string{"X-User-Id": strconv.Itoa(i)}
+		on := Enabled(decode(t, factory, user), "rollout")
+		// a user always gets the same result
+		assert.Equal(t, on, Enabled(decode(t, factory, user), "rollout"))
+		if on {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 300, enabled, 60)
+}
+
+func TestApplyInvalid(t *testing.T) {
+	invalid := []*Config{
+		{Flags: []*Flag{{}}},
+		{Flags: []*Flag{{Name: "a,b"}}},
+		{Flags: []*Flag{{Name: "a"}, {Name: "a"}}},
+		{Flags: []*Flag{{Name: "a", Percentage: 101}}},
+		{Flags: []*Flag{{Name: "a", Headers: []*HeaderMatch{{Value: "true"}}}}},
+	}
+	for _, cfg := range invalid {
+		assert.Error(t, (&FilterFactory{cfg: cfg}).Apply())
+	}
+}
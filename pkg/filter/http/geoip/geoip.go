@@ -0,0 +1,244 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package geoip
+
+import (
+	"context"
+	"net"
+)
+
+import (
+	maxminddb "github.com/oschwald/maxminddb-golang"
+
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	ct "github.com/apache/dubbo-go-pixiu/pkg/context"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPGeoIPFilter
+
+	// ContextKey the context key of the *Location
+	ContextKey = "geoip"
+
+	defaultCountryHeader = "X-Geo-Country"
+	defaultRegionHeader  = "X-Geo-Region"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg     *Config
+		db      *maxminddb.Reader
+		trusted []*net.IPNet
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg     *Config
+		db      *maxminddb.Reader
+		trusted []*net.IPNet
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Database the path of the GeoIP2/GeoLite2 mmdb, such as GeoLite2-City.mmdb
+		Database string `yaml:"database" json:"database" mapstructure:"database"`
+		// InjectHeaders inject the country and region as headers to upstream
+		InjectHeaders bool `yaml:"inject_headers" json:"inject_headers" mapstructure:"inject_headers"`
+		// CountryHeader the header of the country iso code, X-Geo-Country by default
+		CountryHeader string `yaml:"country_header" json:"country_header" mapstructure:"country_header"`
+		// RegionHeader the header of the region iso code, X-Geo-Region by default
+		RegionHeader string `yaml:"region_header" json:"region_header" mapstructure:"region_header"`
+		// TrustedProxies the addresses or CIDRs of the proxies whose X-Forwarded-For is trusted for the client ip,
+		// the peer address is looked up if empty
+		TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies" mapstructure:"trusted_proxies"`
+	}
+
+	// Location the geo data of the client ip
+	Location struct {
+		Country string
+		Region  string
+		City    string
+	}
+
+	// cityRecord the fields of the GeoIP2/GeoLite2 record mapped to Location
+	cityRecord struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+		Subdivisions []struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"subdivisions"`
+		City struct {
+			Names map[string]string `maxminddb:"names"`
+		} `maxminddb:"city"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Apply open the database, the filter is disabled rather than failing the startup when the database is unavailable
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.CountryHeader == "" {
+		cfg.CountryHeader = defaultCountryHeader
+	}
+	if cfg.RegionHeader == "" {
+		cfg.RegionHeader = defaultRegionHeader
+	}
+
+	trusted, err := http.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	factory.trusted = trusted
+
+	db, err := maxminddb.Open(cfg.Database)
+	if err != nil {
+		logger.Warnw("geoip filter disabled", "database", cfg.Database, "error", err.Error())
+		factory.db = nil
+		return nil
+	}
+	factory.db = db
+	return nil
+}
+
+// Close close the database
+func (factory *FilterFactory) Close() error {
+	if factory.db == nil {
+		return nil
+	}
+	return factory.db.Close()
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	if factory.db == nil {
+		return nil
+	}
+	f := &Filter{cfg: factory.cfg, db: factory.db, trusted: factory.trusted}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.cfg.InjectHeaders {
+		// the upstream trusts the injected headers, never the ones sent by the client
+		ctx.Request.Header.Del(f.cfg.CountryHeader)
+		ctx.Request.Header.Del(f.cfg.RegionHeader)
+	}
+	// the forwarded headers are spoofable unless set by the trusted proxies
+	ip := net.ParseIP(ctx.GetTrustedClientIP(f.trusted))
+	if ip == nil {
+		return filter.Continue
+	}
+	loc, err := f.lookup(ip)
+	if err != nil {
+		logger.Debugw("geoip lookup fail", "ip", ip, "error", err.Error())
+		return filter.Continue
+	}
+	if loc == nil {
+		return filter.Continue
+	}
+
+	parent := ctx.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx.Ctx = context.WithValue(parent, ct.ContextKey(ContextKey), loc)
+	if f.cfg.InjectHeaders {
+		if loc.Country != "" {
+			ctx.Request.Header.Set(f.cfg.CountryHeader, loc.Country)
+		}
+		if loc.Region != "" {
+			ctx.Request.Header.Set(f.cfg.RegionHeader, loc.Region)
+		}
+	}
+	routeByGeo(ctx, loc)
+	return filter.Continue
+}
+
+// routeByGeo switch the cluster of route entry by the first matched geo route
+func routeByGeo(ctx *http.HttpContext, loc *Location) {
+	rEntry := ctx.GetRouteEntry()
+	if rEntry == nil {
+		return
+	}
+	for _, g := range rEntry.GeoRoutes {
+		if g.Match(loc.Country, loc.Region) {
+			logger.Debugw("geoip route", "url", ctx.GetUrl(), "country", loc.Country, "region", loc.Region, "cluster", g.Cluster)
+			route := *rEntry
+			route.Cluster = g.Cluster
+			ctx.RouteEntry(&route)
+			return
+		}
+	}
+}
+
+// lookup map the GeoIP2 record to Location, nil if the ip is unknown
+func (f *Filter) lookup(ip net.IP) (*Location, error) {
+	var record cityRecord
+	if err := f.db.Lookup(ip, &record); err != nil {
+		return nil, errors.Wrap(err, "geoip db lookup fail")
+	}
+	loc := &Location{
+		Country: record.Country.ISOCode,
+		City:    record.City.Names["en"],
+	}
+	if len(record.Subdivisions) > 0 {
+		loc.Region = record.Subdivisions[0].ISOCode
+	}
+	if loc.Country == "" && loc.Region == "" && loc.City == "" {
+		return nil, nil
+	}
+	return loc, nil
+}
+
+// FromContext return the location injected by the filter, nil if not found
+func FromContext(ctx *http.HttpContext) *Location {
+	if ctx.Ctx == nil {
+		return nil
+	}
+	loc, _ := ctx.Ctx.Value(ct.ContextKey(ContextKey)).(*Location)
+	return loc
+}
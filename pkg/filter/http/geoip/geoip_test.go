@@ -0,0 +1,312 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// the data section field types used by the tests, https://maxmind.github.io/MaxMind-DB/
+const (
+	typeString = 2
+	typeUint32 = 6
+	typeMap    = 7
+	typeArray  = 11
+)
+
+// dataSectionSeparator the 16 zero bytes between the search tree and the data section
+const dataSectionSeparator = 16
+
+// metadataMarker the metadata section starts after the last marker of the file
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// encodeValue encode the value in the data section format, only the types used by the tests
+func encodeValue(buf *bytes.Buffer, v interface{}) {
+	switch val := v.(type) {
+	case string:
+		encodeControl(buf, typeString, len(val))
+		buf.WriteString(val)
+	case int:
+		encodeControl(buf, typeUint32, 4)
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(val))
+		buf.Write(b[:])
+	case []interface{}:
+		encodeControl(buf, typeArray, len(val))
+		for _, e := range val {
+			encodeValue(buf, e)
+		}
+	case map[string]interface{}:
+		encodeControl(buf, typeMap, len(val))
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			encodeValue(buf, k)
+			encodeValue(buf, val[k])
+		}
+	}
+}
+
+func encodeControl(buf *bytes.Buffer, typ, size int) {
+	var ctrl byte
+	if typ > 7 {
+		ctrl = 0
+	} else {
+		ctrl = byte(typ) << 5
+	}
+	var ext []byte
+	switch {
+	case size < 29:
+		ctrl |= byte(size)
+	case size < 285:
+		ctrl |= 29
+		ext = []byte{byte(size - 29)}
+	default:
+		ctrl |= 30
+		ext = []byte{byte((size - 285) >> 8), byte(size - 285)}
+	}
+	buf.WriteByte(ctrl)
+	if typ > 7 {
+		buf.WriteByte(byte(typ - 7))
+	}
+	buf.Write(ext)
+}
+
+type testNode struct {
+	// records: 0 empty, > 0 the child node index + 1, < 0 the data offset - 1
+	records [2]int
+}
+
+// buildDB build an ipv4 mmdb with 24 bits records
+func buildDB(networks map[string]map[string]interface{}) []byte {
+	var data bytes.Buffer
+	nodes := []*testNode{{}}
+	for cidr, record := range networks {
+		_, ipNet, _ := net.ParseCIDR(cidr)
+		ones, _ := ipNet.Mask.Size()
+		ip := ipNet.IP.To4()
+		offset := data.Len()
+		encodeValue(&data, record)
+
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := int(ip[i>>3]>>(7-uint(i&7))) & 1
+			if i == ones-1 {
+				nodes[node].records[bit] = -offset - 1
+				break
+			}
+			if nodes[node].records[bit] <= 0 {
+				nodes = append(nodes, &testNode{})
+				nodes[node].records[bit] = len(nodes)
+			}
+			node = nodes[node].records[bit] - 1
+		}
+	}
+
+	var buf bytes.Buffer
+	count := len(nodes)
+	for _, n := range nodes {
+		for _, r := range n.records {
+			v := count
+			if r > 0 {
+				v = r - 1
+			} else if r < 0 {
+				v = count + dataSectionSeparator - r - 1
+			}
+			buf.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+	buf.Write(make([]byte, dataSectionSeparator))
+	buf.Write(data.Bytes())
+	buf.Write(metadataMarker)
+	encodeValue(&buf, map[string]interface{}{
+		"node_count":                  count,
+		"record_size":                 24,
+		"ip_version":                  4,
+		"database_type":               "GeoIP2-City",
+		"binary_format_major_version": 2,
+		"binary_format_minor_version": 0,
+		"languages":                   []interface{}{"en"},
+	})
+	return buf.Bytes()
+}
+
+func writeDB(t *testing.T) string {
+	db := buildDB(map[string]map[string]interface{}{
+		"1.2.3.0/24": {
+			"country":      map[string]interface{}{"iso_code": "CN"},
+			"subdivisions": []interface{}{map[string]interface{}{"iso_code": "ZJ"}},
+			"city":         map[string]interface{}{"names": map[string]interface{}{"en": "Hangzhou"}},
+		},
+		"5.6.7.0/24": {
+			"country":      map[string]interface{}{"iso_code": "DE"},
+			"subdivisions": []interface{}{map[string]interface{}{"iso_code": "BY"}},
+		},
+		"10.0.0.0/8": {
+			"country": map[string]interface{}{"iso_code": "US"},
+			// long enough to use the extended size
+			"city": map[string]interface{}{"names": map[string]interface{}{"en": strings.Repeat("x", 40)}},
+		},
+	})
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	assert.NoError(t, ioutil.WriteFile(path, db, 0600))
+	return path
+}
+
+func TestGeoIP(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Database: writeDB(t), InjectHeaders: true}}
+	assert.Nil(t, factory.Apply())
+	defer factory.Close()
+	assert.NotNil(t, factory.db)
+
+	tests := []struct {
+		name     string
+		ip       string
+		location *Location
+	}{
+		{name: "city", ip: "1.2.3.4", location: &Location{Country: "CN", Region: "ZJ", City: "Hangzhou"}},
+		{name: "country", ip: "10.1.2.3", location: &Location{Country: "US", City: strings.Repeat("x", 40)}},
+		{name: "unknown", ip: "8.8.8.8"},
+		{name: "ipv6 in ipv4 db", ip: "2001:db8::1"},
+		{name: "invalid ip", ip: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+			assert.NoError(t, err)
+			request.RemoteAddr = net.JoinHostPort(tt.ip, "80")
+			// the spoofed headers are dropped
+			request.Header.Set(defaultCountryHeader, "XX")
+			request.Header.Set(defaultRegionHeader, "XX")
+			ctx := mock.GetMockHTTPContext(request)
+			f := &Filter{cfg: factory.cfg, db: factory.db}
+
+			assert.Equal(t, filter.Continue, f.Decode(ctx))
+			assert.Equal(t, tt.location, FromContext(ctx))
+			if tt.location == nil {
+				assert.Empty(t, request.Header.Get(defaultCountryHeader))
+				assert.Empty(t, request.Header.Get(defaultRegionHeader))
+				return
+			}
+			assert.Equal(t, tt.location.Country, request.Header.Get(defaultCountryHeader))
+			assert.Equal(t, tt.location.Region, request.Header.Get(defaultRegionHeader))
+		})
+	}
+}
+
+func TestGeoIPTrustedProxies(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Database: writeDB(t), TrustedProxies: []string{"192.168.0.0/16"}}}
+	assert.Nil(t, factory.Apply())
+	defer factory.Close()
+
+	lookup := func(remote, xff string) *Location {
+		request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+		assert.NoError(t, err)
+		request.RemoteAddr = remote
+		request.Header.Set("X-Forwarded-For", xff)
+		ctx := mock.GetMockHTTPContext(request)
+		f := &Filter{cfg: factory.cfg, db: factory.db, trusted: factory.trusted}
+		assert.Equal(t, filter.Continue, f.Decode(ctx))
+		return FromContext(ctx)
+	}
+
+	// the client ip forwarded by the trusted proxy is looked up
+	assert.Equal(t, "CN", lookup("192.168.0.1:80", "1.2.3.4").Country)
+	// the spoofed header of an untrusted peer is ignored
+	assert.Equal(t, "DE", lookup("5.6.7.8:80", "1.2.3.4").Country)
+	assert.Nil(t, lookup("8.8.8.8:80", "1.2.3.4"))
+}
+
+func TestGeoRoute(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Database: writeDB(t)}}
+	assert.Nil(t, factory.Apply())
+	defer factory.Close()
+
+	route := &model.RouteAction{Cluster: "default", GeoRoutes: []*model.GeoRoute{
+		{Regions: []string{"DE-BY"}, Cluster: "eu-bavaria"},
+		{Countries: []string{"de", "FR"}, Cluster: "eu"},
+		{Countries: []string{"CN"}, Regions: []string{"ZJ"}, Cluster: "cn-east"},
+	}}
+	tests := []struct {
+		ip      string
+		cluster string
+	}{
+		{ip: "5.6.7.8", cluster: "eu-bavaria"},
+		{ip: "1.2.3.4", cluster: "cn-east"},
+		// US is not routed by geo
+		{ip: "10.1.2.3", cluster: "default"},
+		{ip: "8.8.8.8", cluster: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+			assert.NoError(t, err)
+			request.RemoteAddr = net.JoinHostPort(tt.ip, "80")
+			ctx := mock.GetMockHTTPContext(request)
+			ctx.RouteEntry(route)
+			f := &Filter{cfg: factory.cfg, db: factory.db}
+
+			assert.Equal(t, filter.Continue, f.Decode(ctx))
+			assert.Equal(t, tt.cluster, ctx.GetRouteEntry().Cluster)
+		})
+	}
+	// the shared route config is not modified
+	assert.Equal(t, "default", route.Cluster)
+
+	g := &model.GeoRoute{Countries: []string{"DE"}}
+	assert.True(t, g.Match("DE", ""))
+	assert.False(t, (&model.GeoRoute{}).Match("DE", ""))
+	assert.False(t, (&model.GeoRoute{Regions: []string{"BY"}}).Match("DE", ""))
+}
+
+func TestInvalidDatabase(t *testing.T) {
+	garbage := filepath.Join(t.TempDir(), "garbage.mmdb")
+	assert.NoError(t, ioutil.WriteFile(garbage, []byte("not a mmdb"), 0600))
+
+	for _, path := range []string{"not-exist.mmdb", garbage} {
+		factory := &FilterFactory{cfg: &Config{Database: path}}
+		assert.Nil(t, factory.Apply())
+		assert.Nil(t, factory.db)
+
+		request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+		assert.NoError(t, err)
+		chain := filter.NewDefaultFilterChain()
+		assert.Nil(t, factory.PrepareFilterChain(mock.GetMockHTTPContext(request), chain))
+	}
+}
@@ -77,7 +77,7 @@ func (dr *Descriptor) getDescriptorSource(ctx context.Context, cfg *Config) (Des
 		ds, err = dr.getDescriptorCompose(ctx, cfg)
 	case NONE:
 		// nope
-		logger.Warnf("%s grpc descriptor source is none config , check descriptor_source_strategy %s ", loggerHeader, cfg.DescriptorSourceStrategy.String())
+		logger.Warnw("grpc proxy descriptor source is none, check descriptor_source_strategy", "strategy", cfg.DescriptorSourceStrategy.String())
 	default:
 		err = errors.Errorf("grpc descriptor source not initialized cause the config of `descriptor_source_strategy` is %s, maybe set it `AUTO`", cfg.DescriptorSourceStrategy)
 	}
@@ -144,7 +144,7 @@ func (dr *Descriptor) initFileDescriptorSource(cfg *Config) *Descriptor {
 	descriptor, err := loadFileSource(cfg)
 
 	if err != nil {
-		logger.Errorf("%s init gRPC descriptor by local file error: %v", loggerHeader, err)
+		logger.Errorw("grpc proxy init descriptor by local file fail", "error", err.Error())
 		return dr
 	}
 
@@ -166,7 +166,7 @@ func loadFileSource(gc *Config) (*fileSource, error) {
 		cur = filepath.Dir(ex) + string(os.PathSeparator) + gc.Path
 	}
 
-	logger.Infof("%s load proto files from %s", loggerHeader, cur)
+	logger.Infow("grpc proxy load proto files", "path", cur)
 
 	fileLists := make([]string, 0)
 	items, err := ioutil.ReadDir(cur)
@@ -155,7 +155,7 @@ func (cs *compositeSource) FindSymbol(fullyQualifiedName string) (desc.Descripto
 	if cs.reflection != nil {
 		descriptor, err := cs.reflection.FindSymbol(fullyQualifiedName)
 		if err == nil {
-			logger.Debugf("%s find symbol by reflection : %v", loggerHeader, descriptor)
+			logger.Debugw("grpc proxy find symbol by reflection", "symbol", descriptor)
 			return descriptor, nil
 		}
 	}
@@ -19,9 +19,7 @@ package grpcproxy
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	stdHttp "net/http"
 	"strings"
@@ -29,9 +27,6 @@ import (
 )
 
 import (
-	"github.com/golang/protobuf/jsonpb" //nolint
-	"github.com/golang/protobuf/proto"  //nolint
-
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
@@ -46,6 +41,7 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	ct "github.com/apache/dubbo-go-pixiu/pkg/context"
@@ -58,8 +54,6 @@ const (
 	// Kind is the kind of Fallback.
 	Kind = constant.HTTPGrpcProxyFilter
 
-	loggerHeader = "[grpc-proxy]"
-
 	// DescriptorSourceKey current ds
 	DescriptorSourceKey = "DescriptorSource"
 
@@ -112,6 +106,12 @@ type (
 		DescriptorSourceStrategy DescriptorSourceStrategy `yaml:"descriptor_source_strategy" json:"descriptor_source_strategy" default:"auto"`
 		Path                     string                   `yaml:"path" json:"path"`
 		Rules                    []*Rule                  `yaml:"rules" json:"rules"` //nolint
+		// EnumsAsInts render the enums of response by number instead of name
+		EnumsAsInts bool `yaml:"enums_as_ints" json:"enums_as_ints"`
+		// EmitDefaults render the fields with zero values of response
+		EmitDefaults bool `yaml:"emit_defaults" json:"emit_defaults"`
+		// AllowUnknownFields ignore the request body fields not in the message instead of rejecting the request
+		AllowUnknownFields bool `yaml:"allow_unknown_fields" json:"allow_unknown_fields"`
 	}
 
 	Rule struct {
@@ -183,14 +183,15 @@ func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 	var err error
 
 	re := c.GetRouteEntry()
-	logger.Debugf("%s client choose endpoint from cluster :%v", loggerHeader, re.Cluster)
+	logger.Debugw("grpc proxy choose endpoint from cluster", "cluster", re.Cluster)
 
 	e := server.GetClusterManager().PickEndpoint(re.Cluster)
 	if e == nil {
-		logger.Errorf("%s err {cluster not exists}", loggerHeader)
+		logger.Errorw("grpc proxy cluster not exists", "cluster", re.Cluster)
 		c.SendLocalReply(stdHttp.StatusServiceUnavailable, []byte("cluster not exists"))
 		return filter.Stop
 	}
+	defer loadbalancer.Begin(re.Cluster, e)()
 
 	ep := e.Address.GetAddress()
 
@@ -204,7 +205,7 @@ func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 		// TODO(Kenway): Support Credential and TLS
 		clientConn, err = grpc.DialContext(c.Ctx, ep, grpc.WithTransportCredentials(insecure.NewCredentials()))
 		if err != nil || clientConn == nil {
-			logger.Errorf("%s err {failed to connect to grpc service provider}", loggerHeader)
+			logger.Errorw("grpc proxy fail to connect to service provider", "endpoint", ep, "error", err)
 			c.SendLocalReply(stdHttp.StatusServiceUnavailable, []byte((fmt.Sprintf("%s", err))))
 			return filter.Stop
 		}
@@ -213,7 +214,7 @@ func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 	// get DescriptorSource, contain file and reflection
 	source, err := f.descriptor.getDescriptorSource(context.WithValue(c.Ctx, ct.ContextKey(GrpcClientConnKey), clientConn), f.cfg)
 	if err != nil {
-		logger.Errorf("%s err %s : %s ", loggerHeader, "get desc source fail", err)
+		logger.Errorw("grpc proxy get descriptor source fail", "error", err.Error())
 		c.SendLocalReply(stdHttp.StatusInternalServerError, []byte("service not config proto file or the server not support reflection API"))
 		return filter.Stop
 	}
@@ -222,14 +223,14 @@ func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 
 	dscp, err := source.FindSymbol(svc)
 	if err != nil {
-		logger.Errorf("%s err {%s}", loggerHeader, "request path invalid")
+		logger.Errorw("grpc proxy request path invalid", "service", svc)
 		c.SendLocalReply(stdHttp.StatusBadRequest, []byte("method not allow"))
 		return filter.Stop
 	}
 
 	svcDesc, ok := dscp.(*desc.ServiceDescriptor)
 	if !ok {
-		logger.Errorf("%s err {service not expose, %s}", loggerHeader, svc)
+		logger.Errorw("grpc proxy service not exposed", "service", svc)
 		c.SendLocalReply(stdHttp.StatusBadRequest, []byte(fmt.Sprintf("service not expose, %s", svc)))
 		return filter.Stop
 	}
@@ -238,20 +239,26 @@ func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 
 	err = f.registerExtension(source, mthDesc)
 	if err != nil {
-		logger.Errorf("%s err {%s}", loggerHeader, "register extension failed")
+		logger.Errorw("grpc proxy register extension fail", "error", err.Error())
 		c.SendLocalReply(stdHttp.StatusInternalServerError, []byte(fmt.Sprintf("%s", err)))
 		return filter.Stop
 	}
 
 	msgFac := dynamic.NewMessageFactoryWithExtensionRegistry(f.extReg)
 	grpcReq := msgFac.NewMessage(mthDesc.GetInputType())
+	marshaller := newJSONMarshaller(source, msgFac, f.cfg)
 
-	err = jsonToProtoMsg(c.Request.Body, grpcReq)
-	if err != nil && !errors.Is(err, io.EOF) {
-		logger.Errorf("%s err {failed to convert json to proto msg, %s}", loggerHeader, err.Error())
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		logger.Errorw("grpc proxy fail to read request body", "error", err.Error())
 		c.SendLocalReply(stdHttp.StatusInternalServerError, []byte(fmt.Sprintf("%s", err)))
 		return filter.Stop
 	}
+	if err = marshaller.Unmarshal(body, grpcReq); err != nil {
+		logger.Errorw("grpc proxy fail to convert json to proto message", "error", err.Error())
+		c.SendLocalReply(stdHttp.StatusBadRequest, []byte(fmt.Sprintf("%s", err)))
+		return filter.Stop
+	}
 
 	stub := grpcdynamic.NewStubWithMessageFactory(clientConn, msgFac)
 
@@ -265,14 +272,14 @@ func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 	resp, err := Invoke(ctx, stub, mthDesc, grpcReq, grpc.Header(&md), grpc.Trailer(&t))
 	// judge err is server side error or not
 	if st, ok := status.FromError(err); !ok || isServerError(st) {
-		logger.Errorf("%s err {failed to invoke grpc service provider, %s}", loggerHeader, err.Error())
+		logger.Errorw("grpc proxy fail to invoke service provider", "error", err.Error())
 		c.SendLocalReply(stdHttp.StatusServiceUnavailable, []byte(fmt.Sprintf("%s", err)))
 		return filter.Stop
 	}
 
-	res, err := protoMsgToJson(resp)
+	res, err := marshaller.Marshal(resp)
 	if err != nil {
-		logger.Errorf("%s err {failed to convert proto msg to json, %s}", loggerHeader, err.Error())
+		logger.Errorw("grpc proxy fail to convert proto message to json", "error", err.Error())
 		c.SendLocalReply(stdHttp.StatusInternalServerError, []byte(fmt.Sprintf("%s", err)))
 		return filter.Stop
 	}
@@ -353,19 +360,6 @@ func mapMetadataToHeader(md metadata.MD) stdHttp.Header {
 	return h
 }
 
-func jsonToProtoMsg(reader io.Reader, msg proto.Message) error {
-	body, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return err
-	}
-	return jsonpb.UnmarshalString(string(body), msg)
-}
-
-func protoMsgToJson(msg proto.Message) (string, error) {
-	m := jsonpb.Marshaler{}
-	return m.MarshalToString(msg)
-}
-
 func isServerError(st *status.Status) bool {
 	return st.Code() == codes.DeadlineExceeded || st.Code() == codes.ResourceExhausted || st.Code() == codes.Internal ||
 		st.Code() == codes.Unavailable
@@ -375,6 +369,11 @@ func (factory *FilterFactory) Config() interface{} {
 	return factory.cfg
 }
 
+// Stage the filter reads the request body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
 func (factory *FilterFactory) Apply() error {
 
 	err := configCheck(factory.cfg)
@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcproxy
+
+import (
+	"bytes"
+	"strings"
+)
+
+import (
+	"github.com/golang/protobuf/jsonpb" //nolint
+	"github.com/golang/protobuf/proto"  //nolint
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+
+	perrors "github.com/pkg/errors"
+)
+
+type (
+	// jsonMarshaller convert between the json body and the protobuf message of the loaded descriptors,
+	// the well-known types are in their json mapping, and the enums are accepted by name or number
+	jsonMarshaller struct {
+		marshaler   jsonpb.Marshaler
+		unmarshaler jsonpb.Unmarshaler
+	}
+
+	// anyResolver resolve the message type of google.protobuf.Any by the descriptor source
+	anyResolver struct {
+		source DescriptorSource
+		mf     *dynamic.MessageFactory
+	}
+)
+
+func newJSONMarshaller(source DescriptorSource, mf *dynamic.MessageFactory, cfg *Config) *jsonMarshaller {
+	resolver := &anyResolver{source: source, mf: mf}
+	return &jsonMarshaller{
+		marshaler: jsonpb.Marshaler{
+			EnumsAsInts:  cfg.EnumsAsInts,
+			EmitDefaults: cfg.EmitDefaults,
+			AnyResolver:  resolver,
+		},
+		unmarshaler: jsonpb.Unmarshaler{
+			AllowUnknownFields: cfg.AllowUnknownFields,
+			AnyResolver:        resolver,
+		},
+	}
+}
+
+// Unmarshal fill the message with the json body, the empty body leaves the message empty
+func (m *jsonMarshaller) Unmarshal(body []byte, msg proto.Message) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return m.unmarshaler.Unmarshal(bytes.NewReader(body), msg)
+}
+
+// Marshal the message to json
+func (m *jsonMarshaller) Marshal(msg proto.Message) (string, error) {
+	return m.marshaler.MarshalToString(msg)
+}
+
+// Resolve the type url like type.googleapis.com/package.Message
+func (r *anyResolver) Resolve(typeURL string) (proto.Message, error) {
+	name := typeURL[strings.LastIndex(typeURL, "/")+1:]
+	d, err := r.source.FindSymbol(name)
+	if err != nil {
+		return nil, perrors.Wrapf(err, "resolve any type %s fail", typeURL)
+	}
+	md, ok := d.(*desc.MessageDescriptor)
+	if !ok {
+		return nil, perrors.Errorf("any type %s is not a message", typeURL)
+	}
+	return r.mf.NewMessage(md), nil
+}
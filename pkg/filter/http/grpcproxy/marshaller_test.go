@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcproxy
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const studentProto = `
+syntax = "proto3";
+
+package pixiu.test;
+
+import "google/protobuf/any.proto";
+import "google/protobuf/duration.proto";
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/wrappers.proto";
+
+enum Grade {
+  GRADE_UNKNOWN = 0;
+  GRADE_A = 1;
+  GRADE_B = 2;
+}
+
+message Course {
+  string name = 1;
+}
+
+message Student {
+  string name = 1;
+  int64 code = 2;
+  Grade grade = 3;
+  google.protobuf.Timestamp enrolled_at = 4;
+  google.protobuf.Duration study_time = 5;
+  google.protobuf.Int32Value age = 6;
+  repeated Course courses = 7;
+  map<string, Grade> scores = 8;
+  google.protobuf.Any extra = 9;
+}
+`
+
+func studentSource(t *testing.T) (DescriptorSource, *desc.MessageDescriptor) {
+	p := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"student.proto": studentProto})}
+	fds, err := p.ParseFiles("student.proto")
+	assert.NoError(t, err)
+	source, err := DescriptorSourceFromFileDescriptors(fds...)
+	assert.NoError(t, err)
+	return source, fds[0].FindMessage("pixiu.test.Student")
+}
+
+func TestJSONMarshallerRoundTrip(t *testing.T) {
+	source, md := studentSource(t)
+	mf := dynamic.NewMessageFactoryWithDefaults()
+	m := newJSONMarshaller(source, mf, &Config{})
+
+	body := `{
+		"name": "Lily",
+		"code": "12345",
+		"grade": "GRADE_A",
+		"enrolledAt": "2022-01-02T03:04:05Z",
+		"studyTime": "1.500s",
+		"age": 18,
+		"courses": [{"name": "math"}],
+		"scores": {"math": 2},
+		"extra": {"@type": "type.googleapis.com/pixiu.test.Course", "name": "art"}
+	}`
+	msg := mf.NewMessage(md)
+	assert.NoError(t, m.Unmarshal([]byte(body), msg))
+
+	dm := msg.(*dynamic.Message)
+	assert.Equal(t, "Lily", dm.GetFieldByName("name"))
+	assert.Equal(t, int64(12345), dm.GetFieldByName("code"))
+	// the enum is accepted by name or number
+	assert.Equal(t, int32(1), dm.GetFieldByName("grade"))
+	assert.Equal(t, int32(2), dm.GetMapFieldByName("scores", "math"))
+
+	res, err := m.Marshal(msg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "Lily",
+		"code": "12345",
+		"grade": "GRADE_A",
+		"enrolledAt": "2022-01-02T03:04:05Z",
+		"studyTime": "1.500s",
+		"age": 18,
+		"courses": [{"name": "math"}],
+		"scores": {"math": "GRADE_B"},
+		"extra": {"@type": "type.googleapis.com/pixiu.test.Course", "name": "art"}
+	}`, res)
+}
+
+func TestJSONMarshallerOptions(t *testing.T) {
+	source, md := studentSource(t)
+	mf := dynamic.NewMessageFactoryWithDefaults()
+
+	m := newJSONMarshaller(source, mf, &Config{EnumsAsInts: true})
+	msg := mf.NewMessage(md)
+	assert.NoError(t, m.Unmarshal([]byte(`{"grade": 2}`), msg))
+	res, err := m.Marshal(msg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"grade": 2}`, res)
+
+	// the empty body leaves the message empty
+	msg = mf.NewMessage(md)
+	assert.NoError(t, m.Unmarshal(nil, msg))
+
+	assert.Error(t, m.Unmarshal([]byte(`{"unknown": 1}`), mf.NewMessage(md)))
+	m = newJSONMarshaller(source, mf, &Config{AllowUnknownFields: true})
+	assert.NoError(t, m.Unmarshal([]byte(`{"unknown": 1}`), mf.NewMessage(md)))
+
+	assert.Error(t, m.Unmarshal([]byte(`{"grade": "GRADE_C"}`), mf.NewMessage(md)))
+}
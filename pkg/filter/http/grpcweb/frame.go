@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	// frameHeaderLen 1 byte flag and 4 bytes big endian length
+	frameHeaderLen = 5
+
+	// flagTrailer the msb of the flag marks the trailer frame of grpc-web
+	flagTrailer byte = 0x80
+)
+
+var errMessageTooLarge = errors.New("grpc message exceeds the max message size")
+
+// frame the length-prefixed message of grpc
+type frame struct {
+	flag    byte
+	payload []byte
+}
+
+// parseFrames split the body into frames, an incomplete frame is an error
+func parseFrames(body []byte, maxSize int) ([]frame, error) {
+	var frames []frame
+	for len(body) > 0 {
+		if len(body) < frameHeaderLen {
+			return nil, errors.New("incomplete grpc frame header")
+		}
+		length := int(binary.BigEndian.Uint32(body[1:frameHeaderLen]))
+		if maxSize > 0 && length > maxSize {
+			return nil, errMessageTooLarge
+		}
+		if len(body) < frameHeaderLen+length {
+			return nil, errors.New("incomplete grpc frame payload")
+		}
+		frames = append(frames, frame{flag: body[0], payload: body[frameHeaderLen : frameHeaderLen+length]})
+		body = body[frameHeaderLen+length:]
+	}
+	return frames, nil
+}
+
+func writeFrame(buf *bytes.Buffer, flag byte, payload []byte) {
+	var header [frameHeaderLen]byte
+	header[0] = flag
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	buf.Write(header[:])
+	buf.Write(payload)
+}
+
+// encodeTrailer encode the trailers as http/1 headers in the trailer frame, the keys are lower case
+func encodeTrailer(trailer http.Header) []byte {
+	keys := make([]string, 0, len(trailer))
+	for k := range trailer {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	for _, k := range keys {
+		for _, v := range trailer[k] {
+			b.WriteString(strings.ToLower(k))
+			b.WriteString(": ")
+			b.WriteString(v)
+			b.WriteString("\r\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// decodeText decode the base64 body of grpc-web-text, the body may be the concatenation
+// of several padded base64 chunks when the client streams
+func decodeText(body []byte) ([]byte, error) {
+	body = bytes.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, body)
+
+	var out []byte
+	for len(body) > 0 {
+		// a chunk ends after its padding
+		end := len(body)
+		if i := bytes.IndexByte(body, '='); i >= 0 {
+			end = i
+			for end < len(body) && body[end] == '=' {
+				end++
+			}
+		}
+		chunk := make([]byte, base64.StdEncoding.DecodedLen(end))
+		n, err := base64.StdEncoding.Decode(chunk, body[:end])
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid grpc-web-text body")
+		}
+		out = append(out, chunk[:n]...)
+		body = body[end:]
+	}
+	return out, nil
+}
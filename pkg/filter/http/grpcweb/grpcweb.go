@@ -0,0 +1,295 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcweb
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	stdHttp "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+import (
+	"golang.org/x/net/http2"
+
+	"google.golang.org/grpc/codes"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPGrpcWebFilter
+
+	contentTypeGrpc    = "application/grpc"
+	contentTypeWeb     = "application/grpc-web"
+	contentTypeWebText = "application/grpc-web-text"
+
+	headerGrpcStatus  = "Grpc-Status"
+	headerGrpcMessage = "Grpc-Message"
+
+	// defaultMaxMessageSize same as the default max receive message size of grpc
+	defaultMaxMessageSize = 4 << 20
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+// pickEndpoint pick an endpoint from the cluster manager
+var pickEndpoint = func(clusterName string) *model.Endpoint {
+	return server.GetClusterManager().PickEndpoint(clusterName)
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg       *Config
+		transport stdHttp.RoundTripper
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg       *Config
+		transport stdHttp.RoundTripper
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// MaxMessageSize the max size of a single message in bytes, 4MB by default
+		MaxMessageSize int `yaml:"max_message_size" json:"max_message_size" mapstructure:"max_message_size"`
+		// AllowText accept the base64 framing of application/grpc-web-text
+		AllowText bool `yaml:"allow_text" json:"allow_text" mapstructure:"allow_text"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter reads the request body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
+func (factory *FilterFactory) Apply() error {
+	if factory.cfg.MaxMessageSize <= 0 {
+		factory.cfg.MaxMessageSize = defaultMaxMessageSize
+	}
+	// grpc over cleartext http2, the same as the grpc connection manager
+	factory.transport = &http2.Transport{
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+		AllowHTTP: true,
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, transport: factory.transport}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	base, subtype, text, ok := parseContentType(ctx.GetHeader(constant.HeaderKeyContextType))
+	if !ok {
+		return filter.Continue
+	}
+	if text && !f.cfg.AllowText {
+		f.replyStatus(ctx, base, subtype, codes.Unimplemented, "grpc-web-text is not allowed")
+		return filter.Stop
+	}
+
+	// the grpc-web request carries a single message, never read more than it can take
+	maxBody := frameHeaderLen + f.cfg.MaxMessageSize
+	if text {
+		maxBody = base64.StdEncoding.EncodedLen(maxBody)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(ctx.Request.Body, int64(maxBody)+1))
+	if err != nil {
+		f.replyStatus(ctx, base, subtype, codes.Internal, fmt.Sprintf("read request body failed: %v", err))
+		return filter.Stop
+	}
+	if len(body) > maxBody {
+		f.replyStatus(ctx, base, subtype, codes.ResourceExhausted, errMessageTooLarge.Error())
+		return filter.Stop
+	}
+	if text {
+		if body, err = decodeText(body); err != nil {
+			f.replyStatus(ctx, base, subtype, codes.InvalidArgument, err.Error())
+			return filter.Stop
+		}
+	}
+	if _, err = parseFrames(body, f.cfg.MaxMessageSize); err != nil {
+		code := codes.InvalidArgument
+		if err == errMessageTooLarge {
+			code = codes.ResourceExhausted
+		}
+		f.replyStatus(ctx, base, subtype, code, err.Error())
+		return filter.Stop
+	}
+
+	rEntry := ctx.GetRouteEntry()
+	if rEntry == nil {
+		f.replyStatus(ctx, base, subtype, codes.Unimplemented, "no route entry")
+		return filter.Stop
+	}
+	endpoint := pickEndpoint(rEntry.Cluster)
+	if endpoint == nil {
+		f.replyStatus(ctx, base, subtype, codes.Unavailable, "cluster not found endpoint")
+		return filter.Stop
+	}
+	// the response is buffered by forward, so the call ends with it
+	defer loadbalancer.Begin(rEntry.Cluster, endpoint)()
+
+	resp, err := f.forward(ctx.Request, endpoint.Address.GetAddress(), base, subtype, body)
+	if err != nil {
+		logger.Warnw("grpc-web forward fail", "cluster", rEntry.Cluster, "error", err.Error())
+		f.replyStatus(ctx, base, subtype, codes.Unavailable, err.Error())
+		return filter.Stop
+	}
+	// the request is translated and answered here, the proxy filters after it must not forward it again
+	ctx.SourceResp = resp
+	return filter.Stop
+}
+
+// forward send the de-framed request to upstream as standard grpc, and re-frame the response
+func (f *Filter) forward(r *stdHttp.Request, address, base, subtype string, body []byte) (*stdHttp.Response, error) {
+	u := url.URL{Scheme: "http", Host: address, Path: r.URL.Path}
+	req, err := stdHttp.NewRequest(stdHttp.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(r.Context())
+	for k, vv := range r.Header {
+		if isHopHeader(k) {
+			continue
+		}
+		req.Header[k] = vv
+	}
+	req.Header.Set(constant.HeaderKeyContextType, contentTypeGrpc+subtype)
+	req.Header.Set("Te", "trailers")
+
+	resp, err := f.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	payload, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != stdHttp.StatusOK {
+		return nil, fmt.Errorf("upstream respond http status %d", resp.StatusCode)
+	}
+
+	// the trailers only response carries the status in the headers
+	header, trailer := stdHttp.Header{}, stdHttp.Header{}
+	for k, vv := range resp.Header {
+		if strings.HasPrefix(strings.ToLower(k), "grpc-") {
+			trailer[k] = vv
+			continue
+		}
+		if isHopHeader(k) {
+			continue
+		}
+		header[k] = vv
+	}
+	for k, vv := range resp.Trailer {
+		trailer[k] = vv
+	}
+	return webResponse(r, header, base, subtype, payload, trailer), nil
+}
+
+// replyStatus reply the grpc status in the trailer frame, grpc-web clients read the status from it
+func (f *Filter) replyStatus(ctx *http.HttpContext, base, subtype string, code codes.Code, msg string) {
+	trailer := stdHttp.Header{}
+	trailer.Set(headerGrpcStatus, strconv.Itoa(int(code)))
+	trailer.Set(headerGrpcMessage, url.PathEscape(msg))
+	ctx.SourceResp = webResponse(ctx.Request, stdHttp.Header{}, base, subtype, nil, trailer)
+}
+
+// webResponse build the grpc-web response, the trailers are appended to the body as the trailer frame
+func webResponse(r *stdHttp.Request, header stdHttp.Header, base, subtype string, payload []byte, trailer stdHttp.Header) *stdHttp.Response {
+	var buf bytes.Buffer
+	buf.Write(payload)
+	writeFrame(&buf, flagTrailer, encodeTrailer(trailer))
+
+	body := buf.Bytes()
+	if base == contentTypeWebText {
+		body = []byte(base64.StdEncoding.EncodeToString(body))
+	}
+	header.Set(constant.HeaderKeyContextType, base+subtype)
+	return &stdHttp.Response{
+		StatusCode: stdHttp.StatusOK,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    r,
+	}
+}
+
+// parseContentType split the grpc-web content type into base and subtype like +proto
+func parseContentType(ct string) (base, subtype string, text, ok bool) {
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	ct = strings.TrimSpace(strings.ToLower(ct))
+	for _, b := range []string{contentTypeWebText, contentTypeWeb} {
+		if ct == b || strings.HasPrefix(ct, b+"+") {
+			return b, strings.TrimPrefix(ct, b), b == contentTypeWebText, true
+		}
+	}
+	return "", "", false, false
+}
+
+func isHopHeader(k string) bool {
+	switch stdHttp.CanonicalHeaderKey(k) {
+	case "Connection", "Keep-Alive", "Proxy-Connection", "Transfer-Encoding", "Upgrade", "Content-Length", "Te", "Trailer":
+		return true
+	}
+	return false
+}
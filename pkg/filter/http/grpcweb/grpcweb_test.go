@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func framed(flag byte, payload string) []byte {
+	var buf bytes.Buffer
+	writeFrame(&buf, flag, []byte(payload))
+	return buf.Bytes()
+}
+
+// newEchoServer a grpc server over h2c which echo the request message
+func newEchoServer(t *testing.T) *httptest.Server {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 2, r.ProtoMajor)
+		assert.Equal(t, "application/grpc+proto", r.Header.Get("Content-Type"))
+		assert.Equal(t, "trailers", r.Header.Get("Te"))
+		body, _ := ioutil.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/grpc+proto")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+		w.Header().Set("Grpc-Status", "0")
+		w.Header().Set("Grpc-Message", "ok")
+	})
+	return httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+}
+
+func newFilter(t *testing.T, cfg *Config, s *httptest.Server) *Filter {
+	factory := &FilterFactory{cfg: cfg}
+	assert.Nil(t, factory.Apply())
+
+	host, port, err := net.SplitHostPort(s.Listener.Addr().String())
+	assert.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	assert.NoError(t, err)
+	origin := pickEndpoint
+	pickEndpoint = func(string) *model.Endpoint {
+		return &model.Endpoint{Address: model.SocketAddress{Address: host, Port: p}}
+	}
+	t.Cleanup(func() { pickEndpoint = origin })
+	return &Filter{cfg: factory.cfg, transport: factory.transport}
+}
+
+func decode(t *testing.T, f *Filter, contentType string, body []byte) (filter.FilterStatus, *http.Response, []byte) {
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/helloworld.Greeter/SayHello", bytes.NewReader(body))
+	assert.NoError(t, err)
+	request.Header.Set("Content-Type", contentType)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(&model.RouteAction{Cluster: "grpc"})
+
+	status := f.Decode(ctx)
+	if ctx.SourceResp == nil {
+		return status, nil, nil
+	}
+	resp := ctx.SourceResp.(*http.Response)
+	respBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return status, resp, respBody
+}
+
+func TestGrpcWebTranslate(t *testing.T) {
+	s := newEchoServer(t)
+	defer s.Close()
+	f := newFilter(t, &Config{AllowText: true}, s)
+
+	msg := framed(0, "hello")
+	expected := append(framed(0, "hello"), framed(flagTrailer, "grpc-message: ok\r\ngrpc-status: 0\r\n")...)
+
+	status, resp, body := decode(t, f, "application/grpc-web+proto", msg)
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/grpc-web+proto", resp.Header.Get("Content-Type"))
+	assert.Equal(t, expected, body)
+
+	status, resp, body = decode(t, f, "application/grpc-web-text+proto", []byte(base64.StdEncoding.EncodeToString(msg)))
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, "application/grpc-web-text+proto", resp.Header.Get("Content-Type"))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(expected), string(body))
+
+	// not grpc-web, pass through
+	status, resp, _ = decode(t, f, "application/json", []byte("{}"))
+	assert.Equal(t, filter.Continue, status)
+	assert.Nil(t, resp)
+}
+
+func TestGrpcWebRejected(t *testing.T) {
+	s := newEchoServer(t)
+	defer s.Close()
+	f := newFilter(t, &Config{MaxMessageSize: 4}, s)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		trailer     string
+	}{
+		{name: "text not allowed", contentType: "application/grpc-web-text", body: []byte("AAAAAAA="), trailer: "grpc-status: 12"},
+		{name: "too large", contentType: "application/grpc-web+proto", body: framed(0, "hello"), trailer: "grpc-status: 8"},
+		{name: "incomplete frame", contentType: "application/grpc-web+proto", body: framed(0, "hi")[:4], trailer: "grpc-status: 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp, body := decode(t, f, tt.contentType, tt.body)
+			assert.Equal(t, filter.Stop, status)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			if resp.Header.Get("Content-Type") == contentTypeWebText {
+				body, _ = base64.StdEncoding.DecodeString(string(body))
+			}
+			frames, err := parseFrames(body, 0)
+			assert.NoError(t, err)
+			assert.Len(t, frames, 1)
+			assert.Equal(t, flagTrailer, frames[0].flag)
+			assert.Contains(t, string(frames[0].payload), tt.trailer)
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	first, second := framed(0, "a"), framed(0, "bc")
+	chunks := base64.StdEncoding.EncodeToString(first) + base64.StdEncoding.EncodeToString(second)
+	body, err := decodeText([]byte(chunks))
+	assert.NoError(t, err)
+	assert.Equal(t, append(first, second...), body)
+
+	_, err = decodeText([]byte("!!!"))
+	assert.Error(t, err)
+}
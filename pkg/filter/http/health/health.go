@@ -0,0 +1,367 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	stdHttp "net/http"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPHealthFilter
+
+	defaultPath               = "/health"
+	defaultInterval           = 10 * time.Second
+	defaultTimeout            = time.Second
+	defaultUnhealthyThreshold = 3
+	defaultHealthyThreshold   = 1
+
+	statusUp   = "UP"
+	statusDown = "DOWN"
+)
+
+var (
+	// clusterEndpoints return the endpoints of the cluster, replaced in test
+	clusterEndpoints = func(cluster string) []*model.Endpoint {
+		return server.GetClusterManager().Endpoints(cluster)
+	}
+	// checkEndpoint check whether the endpoint is reachable, replaced in test
+	checkEndpoint = func(ep *model.Endpoint, timeout time.Duration) error {
+		conn, err := net.DialTimeout("tcp", ep.Address.GetAddress(), timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg      *Config
+		interval time.Duration
+		timeout  time.Duration
+		checker  *checker
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		path    string
+		checker *checker
+	}
+
+	// Config describe the config of FilterFactory, the path must be routed to be reachable by the probe
+	Config struct {
+		// Path the path of health endpoint, /health by default
+		Path string `yaml:"path" json:"path" mapstructure:"path"`
+		// Clusters the upstream clusters to check
+		Clusters []*Cluster `yaml:"clusters" json:"clusters" mapstructure:"clusters"`
+		// Interval the interval of background checks, 10s by default
+		Interval string `yaml:"interval" json:"interval" mapstructure:"interval"`
+		// Timeout the connect timeout of each endpoint, 1s by default
+		Timeout string `yaml:"timeout" json:"timeout" mapstructure:"timeout"`
+		// UnhealthyThreshold the consecutive failures before the cluster is down, 3 by default
+		UnhealthyThreshold int `yaml:"unhealthy_threshold" json:"unhealthy_threshold" mapstructure:"unhealthy_threshold"`
+		// HealthyThreshold the consecutive successes before the down cluster is up, 1 by default
+		HealthyThreshold int `yaml:"healthy_threshold" json:"healthy_threshold" mapstructure:"healthy_threshold"`
+	}
+
+	// Cluster the upstream cluster, the gateway is healthy only when all the required clusters are up
+	Cluster struct {
+		Name     string `yaml:"name" json:"name" mapstructure:"name"`
+		Required bool   `yaml:"required" json:"required" mapstructure:"required"`
+	}
+
+	// UpstreamStatus the health of an upstream cluster in the response body
+	UpstreamStatus struct {
+		Status    string    `json:"status"`
+		Required  bool      `json:"required"`
+		Error     string    `json:"error,omitempty"`
+		CheckedAt time.Time `json:"checked_at"`
+	}
+
+	// Response the response body of health endpoint
+	Response struct {
+		Status    string                     `json:"status"`
+		Upstreams map[string]*UpstreamStatus `json:"upstreams"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Path == "" {
+		cfg.Path = defaultPath
+	}
+	if cfg.UnhealthyThreshold <= 0 {
+		cfg.UnhealthyThreshold = defaultUnhealthyThreshold
+	}
+	if cfg.HealthyThreshold <= 0 {
+		cfg.HealthyThreshold = defaultHealthyThreshold
+	}
+
+	factory.interval = defaultInterval
+	if cfg.Interval != "" {
+		interval, err := time.ParseDuration(cfg.Interval)
+		if err != nil {
+			return errors.Wrap(err, "health check interval parse fail")
+		}
+		factory.interval = interval
+	}
+	factory.timeout = defaultTimeout
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return errors.Wrap(err, "health check timeout parse fail")
+		}
+		factory.timeout = timeout
+	}
+	if factory.interval <= 0 || factory.timeout <= 0 {
+		return errors.Errorf("invalid health check interval %s or timeout %s", factory.interval, factory.timeout)
+	}
+	for _, c := range cfg.Clusters {
+		if c.Name == "" {
+			return errors.New("health check cluster name is required")
+		}
+	}
+
+	// stop the checker of the previous apply
+	if factory.checker != nil {
+		factory.checker.stop()
+	}
+	factory.checker = newChecker(cfg, factory.timeout)
+	return nil
+}
+
+// Close stop the background checks
+func (factory *FilterFactory) Close() error {
+	if factory.checker != nil {
+		factory.checker.stop()
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	// the checks start with the first chain, so that the factory applied but never served, e.g. by a
+	// dry run or a failed reload, does not leak the checker
+	factory.checker.start(factory.interval)
+	f := &Filter{path: factory.cfg.Path, checker: factory.checker}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// Decode answer the health probe with the result of the last background checks
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	if ctx.GetUrl() != f.path {
+		return filter.Continue
+	}
+
+	resp := f.checker.snapshot()
+	status := stdHttp.StatusOK
+	if resp.Status != statusUp {
+		status = stdHttp.StatusServiceUnavailable
+	}
+	data, _ := json.Marshal(resp)
+	header := stdHttp.Header{}
+	header.Set(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+	ctx.SourceResp = &stdHttp.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(data)),
+	}
+	return filter.Stop
+}
+
+type (
+	// checker check the clusters in background and keep their states
+	checker struct {
+		clusters           []*Cluster
+		timeout            time.Duration
+		unhealthyThreshold int
+		healthyThreshold   int
+
+		mu     sync.RWMutex
+		states map[string]*state
+
+		startOnce sync.Once
+		stopOnce  sync.Once
+		done      chan struct{}
+	}
+
+	// state the health state of a cluster, the cluster is down until it is checked
+	state struct {
+		healthy   bool
+		checked   bool
+		failures  int
+		successes int
+		err       string
+		checkedAt time.Time
+	}
+)
+
+func newChecker(cfg *Config, timeout time.Duration) *checker {
+	states := make(map[string]*state, len(cfg.Clusters))
+	for _, c := range cfg.Clusters {
+		states[c.Name] = &state{}
+	}
+	return &checker{
+		clusters:           cfg.Clusters,
+		timeout:            timeout,
+		unhealthyThreshold: cfg.UnhealthyThreshold,
+		healthyThreshold:   cfg.HealthyThreshold,
+		states:             states,
+		done:               make(chan struct{}),
+	}
+}
+
+func (c *checker) run(interval time.Duration) {
+	c.checkAll()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.checkAll()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// start the background checks once, the stopped checker is never started
+func (c *checker) start(interval time.Duration) {
+	c.startOnce.Do(func() {
+		go c.run(interval)
+	})
+}
+
+func (c *checker) stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// checkAll check the clusters concurrently, so that a slow cluster does not delay the others
+func (c *checker) checkAll() {
+	var wg sync.WaitGroup
+	for _, cluster := range c.clusters {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			c.record(name, c.check(name))
+		}(cluster.Name)
+	}
+	wg.Wait()
+}
+
+// check the cluster is reachable if any of its endpoints is reachable
+func (c *checker) check(cluster string) error {
+	endpoints := clusterEndpoints(cluster)
+	if len(endpoints) == 0 {
+		return errors.Errorf("cluster %s has no endpoint", cluster)
+	}
+	var err error
+	for _, ep := range endpoints {
+		if err = checkEndpoint(ep, c.timeout); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (c *checker) record(cluster string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.states[cluster]
+	s.checkedAt = time.Now()
+	if err == nil {
+		s.failures, s.err = 0, ""
+		s.successes++
+		if !s.checked || s.successes >= c.healthyThreshold {
+			s.healthy = true
+		}
+	} else {
+		s.successes, s.err = 0, err.Error()
+		s.failures++
+		if !s.checked || s.failures >= c.unhealthyThreshold {
+			if s.healthy {
+				logger.Warnw("health check cluster is down", "cluster", cluster, "error", err.Error())
+			}
+			s.healthy = false
+		}
+	}
+	s.checked = true
+}
+
+func (c *checker) snapshot() *Response {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	resp := &Response{Status: statusUp, Upstreams: make(map[string]*UpstreamStatus, len(c.clusters))}
+	for _, cluster := range c.clusters {
+		s := c.states[cluster.Name]
+		us := &UpstreamStatus{Status: statusUp, Required: cluster.Required, Error: s.err, CheckedAt: s.checkedAt}
+		if !s.healthy {
+			us.Status = statusDown
+			if cluster.Required {
+				resp.Status = statusDown
+			}
+		}
+		resp.Upstreams[cluster.Name] = us
+	}
+	return resp
+}
@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	stdHttp "net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// mockUpstreams mark the clusters reachable or not
+type mockUpstreams struct {
+	mu   sync.Mutex
+	down map[string]bool
+}
+
+func (m *mockUpstreams) set(cluster string, down bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.down[cluster] = down
+}
+
+func mockClusters(t *testing.T) *mockUpstreams {
+	m := &mockUpstreams{down: map[string]bool{}}
+	originEndpoints, originCheck := clusterEndpoints, checkEndpoint
+	clusterEndpoints = func(cluster string) []*model.Endpoint {
+		if cluster == "empty" {
+			return nil
+		}
+		return []*model.Endpoint{{ID: cluster}}
+	}
+	checkEndpoint = func(ep *model.Endpoint, timeout time.Duration) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.down[ep.ID] {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	t.Cleanup(func() {
+		clusterEndpoints, checkEndpoint = originEndpoints, originCheck
+	})
+	return m
+}
+
+func probe(t *testing.T, factory *FilterFactory) (int, *Response) {
+	request, err := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/health", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	assert.NoError(t, factory.PrepareFilterChain(ctx, chain))
+	chain.OnDecode(ctx)
+
+	resp := ctx.SourceResp.(*stdHttp.Response)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	res := &Response{}
+	assert.NoError(t, json.Unmarshal(body, res))
+	return resp.StatusCode, res
+}
+
+func TestHealth(t *testing.T) {
+	upstreams := mockClusters(t)
+	upstreams.set("optional", true)
+
+	factory := &FilterFactory{cfg: &Config{
+		Interval:           "1h",
+		UnhealthyThreshold: 2,
+		Clusters: []*Cluster{
+			{Name: "user", Required: true},
+			{Name: "optional"},
+		},
+	}}
+	assert.Nil(t, factory.Apply())
+	defer factory.Close()
+	// wait for the first background check
+	assert.Eventually(t, func() bool {
+		status, res := probe(t, factory)
+		return status == stdHttp.StatusOK && res.Upstreams["optional"].Error != ""
+	}, time.Second, 10*time.Millisecond)
+
+	status, res := probe(t, factory)
+	assert.Equal(t, stdHttp.StatusOK, status)
+	assert.Equal(t, statusUp, res.Status)
+	assert.Equal(t, statusUp, res.Upstreams["user"].Status)
+	assert.Equal(t, statusDown, res.Upstreams["optional"].Status)
+	assert.Equal(t, "connection refused", res.Upstreams["optional"].Error)
+
+	// the required cluster is down after the consecutive failures reach the threshold
+	upstreams.set("user", true)
+	factory.checker.checkAll()
+	status, _ = probe(t, factory)
+	assert.Equal(t, stdHttp.StatusOK, status)
+	factory.checker.checkAll()
+	status, res = probe(t, factory)
+	assert.Equal(t, stdHttp.StatusServiceUnavailable, status)
+	assert.Equal(t, statusDown, res.Status)
+
+	upstreams.set("user", false)
+	factory.checker.checkAll()
+	status, _ = probe(t, factory)
+	assert.Equal(t, stdHttp.StatusOK, status)
+}
+
+func TestHealthNoEndpoint(t *testing.T) {
+	mockClusters(t)
+	factory := &FilterFactory{cfg: &Config{Interval: "1h", Clusters: []*Cluster{{Name: "empty", Required: true}}}}
+	assert.Nil(t, factory.Apply())
+	defer factory.Close()
+	factory.checker.checkAll()
+
+	status, res := probe(t, factory)
+	assert.Equal(t, stdHttp.StatusServiceUnavailable, status)
+	assert.Contains(t, res.Upstreams["empty"].Error, "no endpoint")
+}
+
+func TestCheckerStartLazily(t *testing.T) {
+	mockClusters(t)
+	var checks int32
+	check := checkEndpoint
+	checkEndpoint = func(ep *model.Endpoint, timeout time.Duration) error {
+		atomic.AddInt32(&checks, 1)
+		return check(ep, timeout)
+	}
+
+	// the applied factory does not check until it serves
+	factory := &FilterFactory{cfg: &Config{Interval: "10ms", Clusters: []*Cluster{{Name: "user"}}}}
+	assert.Nil(t, factory.Apply())
+	defer factory.Close()
+	prev := factory.checker
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&checks))
+
+	// apply again stops the previous checker
+	assert.Nil(t, factory.Apply())
+	select {
+	case <-prev.done:
+	default:
+		t.Fatal("the previous checker is not stopped")
+	}
+
+	probe(t, factory)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&checks) > 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHealthSkipOtherPath(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Interval: "1h"}}
+	assert.Nil(t, factory.Apply())
+	defer factory.Close()
+
+	request, err := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/user", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	f := &Filter{path: factory.cfg.Path, checker: factory.checker}
+	f.Decode(ctx)
+	assert.Nil(t, ctx.SourceResp)
+
+	factory = &FilterFactory{cfg: &Config{Interval: "-1s"}}
+	assert.Error(t, factory.Apply())
+}
@@ -0,0 +1,110 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"crypto/tls"
+	"net"
+	http3 "net/http"
+	"sync"
+	"time"
+)
+
+import (
+	"golang.org/x/net/http2"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+// defaultIdleTimeout same as http.DefaultTransport
+const defaultIdleTimeout = 90 * time.Second
+
+// clusterIdleTimeout get the idle connection timeout of the cluster from the cluster manager
+var clusterIdleTimeout = func(clusterName string) time.Duration {
+	return server.GetClusterManager().IdleTimeout(clusterName)
+}
+
+// transports the connection pool of each upstream cluster
+var transports = &transportPool{}
+
+type (
+	// transportPool keep one transport per cluster, so that the idle connections of each cluster
+	// are recycled by its own idle timeout
+	transportPool struct {
+		mu    sync.Mutex
+		pools map[string]*clusterTransport
+		// h2cPools the cleartext http2 transports of the clusters upgraded by the protocol filter
+		h2cPools map[string]*http2.Transport
+	}
+
+	clusterTransport struct {
+		idleTimeout time.Duration
+		transport   *http3.Transport
+	}
+)
+
+// get return the transport of the cluster, the transport is rebuilt when the idle timeout changed
+func (p *transportPool) get(clusterName string) *http3.Transport {
+	idle := clusterIdleTimeout(clusterName)
+	if idle <= 0 {
+		idle = defaultIdleTimeout
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pools == nil {
+		p.pools = make(map[string]*clusterTransport)
+	}
+	if ct, ok := p.pools[clusterName]; ok {
+		if ct.idleTimeout == idle {
+			return ct.transport
+		}
+		ct.transport.CloseIdleConnections()
+	}
+
+	t := &http3.Transport{
+		Proxy:               http3.ProxyFromEnvironment,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     idle,
+	}
+	p.pools[clusterName] = &clusterTransport{idleTimeout: idle, transport: t}
+	return t
+}
+
+// getH2C return the cleartext http2 transport of the cluster, the requests share one connection per endpoint
+func (p *transportPool) getH2C(clusterName string) *http2.Transport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.h2cPools == nil {
+		p.h2cPools = make(map[string]*http2.Transport)
+	}
+	if t, ok := p.h2cPools[clusterName]; ok {
+		return t
+	}
+
+	t := &http2.Transport{
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+		AllowHTTP: true,
+	}
+	p.h2cPools[clusterName] = t
+	return t
+}
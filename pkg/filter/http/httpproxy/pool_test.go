@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// connTracker record the state of the server side connections
+type connTracker struct {
+	mu     sync.Mutex
+	closed int
+}
+
+func (c *connTracker) track(_ net.Conn, state http.ConnState) {
+	if state == http.StateClosed {
+		c.mu.Lock()
+		c.closed++
+		c.mu.Unlock()
+	}
+}
+
+func (c *connTracker) closedCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func newTrackedServer(tracker *connTracker) *httptest.Server {
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	s.Config.ConnState = tracker.track
+	s.Start()
+	return s
+}
+
+func TestClusterIdleTimeout(t *testing.T) {
+	shortTracker, longTracker := &connTracker{}, &connTracker{}
+	short := newTrackedServer(shortTracker)
+	defer short.Close()
+	long := newTrackedServer(longTracker)
+	defer long.Close()
+
+	endpoints := map[string]*model.Endpoint{
+		"short": mockEndpoint(t, short),
+		"long":  mockEndpoint(t, long),
+	}
+	timeouts := map[string]time.Duration{
+		"short": 100 * time.Millisecond,
+		"long":  time.Minute,
+	}
+	originPick, originIdle, originPool := pickEndpoint, clusterIdleTimeout, transports
+	pickEndpoint = func(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+		return endpoints[clusterName]
+	}
+	clusterIdleTimeout = func(clusterName string) time.Duration {
+		return timeouts[clusterName]
+	}
+	transports = &transportPool{}
+	defer func() { pickEndpoint, clusterIdleTimeout, transports = originPick, originIdle, originPool }()
+
+	for _, cluster := range []string{"short", "long"} {
+		request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+		assert.NoError(t, err)
+		ctx := mock.GetMockHTTPContext(request)
+		ctx.RouteEntry(&model.RouteAction{Cluster: cluster})
+
+		f := &Filter{retry: defaultRetryPolicy}
+		f.Decode(ctx)
+		resp := ctx.SourceResp.(*http.Response)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		// close the body so that the connection go back to the pool
+		resp.Body.Close()
+	}
+
+	assert.Eventually(t, func() bool {
+		return shortTracker.closedCount() == 1
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 0, longTracker.closedCount())
+
+	assert.Equal(t, 100*time.Millisecond, transports.get("short").IdleConnTimeout)
+	assert.Equal(t, time.Minute, transports.get("long").IdleConnTimeout)
+}
+
+func TestTransportRebuildWhenIdleTimeoutChanged(t *testing.T) {
+	originIdle := clusterIdleTimeout
+	defer func() { clusterIdleTimeout = originIdle }()
+
+	timeout := time.Duration(0)
+	clusterIdleTimeout = func(string) time.Duration {
+		return timeout
+	}
+
+	p := &transportPool{}
+	first := p.get("c")
+	assert.Equal(t, defaultIdleTimeout, first.IdleConnTimeout)
+	assert.True(t, first == p.get("c"))
+
+	timeout = time.Second
+	second := p.get("c")
+	assert.False(t, first == second)
+	assert.Equal(t, time.Second, second.IdleConnTimeout)
+}
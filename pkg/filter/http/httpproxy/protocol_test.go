@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/http/protocol"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+var h2cTransport = &http2.Transport{
+	DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+		return net.Dial(network, addr)
+	},
+	AllowHTTP: true,
+}
+
+// protocolGateway run the protocol and proxy filters like http connection manager, over h2c and http1
+func protocolGateway(t *testing.T, cluster string, conf map[string]interface{}) http.Handler {
+	fm := filter.NewEmptyFilterManager()
+	protocolFactory, err := fm.Apply(constant.HTTPProtocolFilter, conf)
+	assert.NoError(t, err)
+	proxyFactory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, proxyFactory.Apply())
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := &contexthttp.HttpContext{Writer: w, Request: r}
+		ctx.Reset()
+		ctx.RouteEntry(&model.RouteAction{Cluster: cluster})
+
+		chain := filter.NewDefaultFilterChain()
+		_ = protocolFactory.PrepareFilterChain(ctx, chain)
+		_ = proxyFactory.PrepareFilterChain(ctx, chain)
+		chain.OnDecode(ctx)
+
+		resp := ctx.SourceResp.(*http.Response)
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		for k := range resp.Header {
+			ctx.AddHeader(k, resp.Header.Get(k))
+		}
+		ctx.StatusCode(resp.StatusCode)
+		chain.OnEncode(ctx)
+
+		w.WriteHeader(ctx.GetStatusCode())
+		_, _ = w.Write(body)
+	})
+	return h2c.NewHandler(h, &http2.Server{})
+}
+
+func TestProtocolDowngrade(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 1, r.ProtoMajor)
+		// the trailer of the h2 client is sent as header to the legacy upstream
+		assert.Equal(t, "abc", r.Header.Get("X-Checksum"))
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "payload", string(body))
+
+		w.Header().Set("Trailer", "X-Upstream-Trailer")
+		w.Header().Set("Keep-Alive", "timeout=5")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("legacy"))
+		w.Header().Set("X-Upstream-Trailer", "done")
+	}))
+	defer upstream.Close()
+	origin := pickEndpoint
+	pickEndpoint = func(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+		return mockEndpoint(t, upstream)
+	}
+	defer func() { pickEndpoint = origin }()
+
+	gateway := httptest.NewServer(protocolGateway(t, "legacy", map[string]interface{}{"upstream": constant.UpstreamProtocolHTTP1}))
+	defer gateway.Close()
+
+	req, err := http.NewRequest("POST", gateway.URL+"/mock/test", bytes.NewReader([]byte("payload")))
+	assert.NoError(t, err)
+	req.Trailer = http.Header{"X-Checksum": {"abc"}}
+	resp, err := (&http.Client{Transport: h2cTransport}).Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "legacy", string(body))
+	assert.Empty(t, resp.Header.Get("Keep-Alive"))
+	assert.Equal(t, "done", resp.Trailer.Get("X-Upstream-Trailer"))
+}
+
+func TestProtocolUpgrade(t *testing.T) {
+	upstream := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 2, r.ProtoMajor)
+		assert.Empty(t, r.Header.Get("X-Hop"))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("modern"))
+	}), &http2.Server{}))
+	defer upstream.Close()
+	origin := pickEndpoint
+	pickEndpoint = func(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+		return mockEndpoint(t, upstream)
+	}
+	defer func() { pickEndpoint = origin }()
+
+	gateway := httptest.NewServer(protocolGateway(t, "modern", map[string]interface{}{"upstream": constant.UpstreamProtocolH2C}))
+	defer gateway.Close()
+
+	req, err := http.NewRequest("GET", gateway.URL+"/mock/test", nil)
+	assert.NoError(t, err)
+	// the connection specific headers are forbidden by http2
+	req.Header.Set("Connection", "X-Hop")
+	req.Header.Set("X-Hop", "1")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	assert.Equal(t, 1, resp.ProtoMajor)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "modern", string(body))
+}
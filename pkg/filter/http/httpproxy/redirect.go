@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	http3 "net/http"
+	"net/url"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const defaultMaxRedirects = 10
+
+// checkRedirectFunc build the CheckRedirect of http client by the redirect policy of route,
+// nil means the default policy of http client.
+func checkRedirectFunc(policy *model.RedirectPolicy) (func(req *http3.Request, via []*http3.Request) error, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	switch policy.Mode {
+	case "", model.RedirectModeFollow:
+		maxRedirects := policy.MaxRedirects
+		if maxRedirects <= 0 {
+			maxRedirects = defaultMaxRedirects
+		}
+		return func(req *http3.Request, via []*http3.Request) error {
+			if len(via) > maxRedirects {
+				logger.Warnw("stopped after max redirects, return the last response", "redirects", maxRedirects)
+				return http3.ErrUseLastResponse
+			}
+			return nil
+		}, nil
+	case model.RedirectModePassthrough:
+		return func(req *http3.Request, via []*http3.Request) error {
+			return http3.ErrUseLastResponse
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported redirect mode %s", policy.Mode)
+	}
+}
+
+// rewriteLocation rewrite the Location pointing to the upstream into gateway-relative url,
+// the Location pointing to other hosts is kept.
+func rewriteLocation(resp *http3.Response) {
+	location := resp.Header.Get("Location")
+	if location == "" || resp.Request == nil {
+		return
+	}
+	u, err := url.Parse(location)
+	if err != nil || !u.IsAbs() || u.Host != resp.Request.URL.Host {
+		return
+	}
+	relative := url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery, Fragment: u.Fragment}
+	if relative.Path == "" {
+		relative.Path = "/"
+	}
+	resp.Header.Set("Location", relative.String())
+}
+
+func isRedirect(code int) bool {
+	return code >= http3.StatusMultipleChoices && code < http3.StatusBadRequest
+}
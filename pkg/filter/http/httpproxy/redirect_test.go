@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestRedirect(t *testing.T) {
+	var upstream *httptest.Server
+	upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, upstream.URL+"/b?from=a", http.StatusFound)
+		case "/b":
+			http.Redirect(w, r, upstream.URL+"/c", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer upstream.Close()
+
+	origin := pickEndpoint
+	endpoint := mockEndpoint(t, upstream)
+	pickEndpoint = func(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+		return endpoint
+	}
+	defer func() { pickEndpoint = origin }()
+
+	tests := []struct {
+		name     string
+		policy   *model.RedirectPolicy
+		status   int
+		location string
+	}{
+		{name: "follow", policy: &model.RedirectPolicy{Mode: model.RedirectModeFollow}, status: http.StatusOK},
+		{name: "follow bounded", policy: &model.RedirectPolicy{MaxRedirects: 1}, status: http.StatusFound, location: "/c"},
+		{name: "passthrough", policy: &model.RedirectPolicy{Mode: model.RedirectModePassthrough}, status: http.StatusFound, location: "/b?from=a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/a", nil)
+			assert.NoError(t, err)
+			ctx := mock.GetMockHTTPContext(request)
+			ctx.RouteEntry(&model.RouteAction{Cluster: "mock", Redirect: tt.policy})
+
+			f := &Filter{transport: &http.Transport{}, retry: defaultRetryPolicy}
+			f.Decode(ctx)
+
+			resp := ctx.SourceResp.(*http.Response)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.location, resp.Header.Get("Location"))
+		})
+	}
+}
+
+func TestRewriteLocationKeepExternal(t *testing.T) {
+	request, err := http.NewRequest("GET", "http://127.0.0.1:8080/a", nil)
+	assert.NoError(t, err)
+	resp := &http.Response{Header: http.Header{}, Request: request}
+	resp.Header.Set("Location", "https://login.example.com/auth")
+	rewriteLocation(resp)
+	assert.Equal(t, "https://login.example.com/auth", resp.Header.Get("Location"))
+}
+
+func TestCheckRedirectFuncInvalidMode(t *testing.T) {
+	_, err := checkRedirectFunc(&model.RedirectPolicy{Mode: "unknown"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func listenerEndpoint(t *testing.T, l net.Listener) *model.Endpoint {
+	host, port, err := net.SplitHostPort(l.Addr().String())
+	assert.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	assert.NoError(t, err)
+	return &model.Endpoint{Address: model.SocketAddress{Address: host, Port: p}}
+}
+
+func decodeWithReset(t *testing.T, method string, endpoint *model.Endpoint) *contexthttp.HttpContext {
+	origin := pickEndpoint
+	pickEndpoint = func(string, loadbalancer.Hint) *model.Endpoint {
+		return endpoint
+	}
+	defer func() { pickEndpoint = origin }()
+
+	factory := &FilterFactory{cfg: &Config{Retry: &RetryPolicy{Attempts: 3}}}
+	assert.Nil(t, factory.Apply())
+
+	request, err := http.NewRequest(method, "http://www.dubbogopixiu.com/mock/test", bytes.NewReader([]byte("{\"id\":\"12345\"}")))
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(&model.RouteAction{Cluster: "reset"})
+
+	f := &Filter{transport: &http.Transport{}, retry: factory.cfg.Retry}
+	f.Decode(ctx)
+	return ctx
+}
+
+func TestResetBeforeSend(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	endpoint := listenerEndpoint(t, l)
+	_ = l.Close()
+
+	ctx := decodeWithReset(t, http.MethodPost, endpoint)
+	assert.Equal(t, http.StatusServiceUnavailable, ctx.GetStatusCode())
+}
+
+func TestResetAfterSend(t *testing.T) {
+	var hits int32
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			if _, err := http.ReadRequest(bufio.NewReader(conn)); err == nil {
+				atomic.AddInt32(&hits, 1)
+			}
+			_ = conn.(*net.TCPConn).SetLinger(0)
+			_ = conn.Close()
+		}
+	}()
+	endpoint := listenerEndpoint(t, l)
+
+	// the non idempotent request is never sent twice
+	ctx := decodeWithReset(t, http.MethodPost, endpoint)
+	assert.Equal(t, http.StatusBadGateway, ctx.GetStatusCode())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	atomic.StoreInt32(&hits, 0)
+	ctx = decodeWithReset(t, http.MethodGet, endpoint)
+	assert.Equal(t, http.StatusBadGateway, ctx.GetStatusCode())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
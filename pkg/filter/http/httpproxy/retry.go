@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"math/rand"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+type (
+	// RetryPolicy describe how to retry a failed upstream call.
+	// After PrimaryAttempts failures on the route cluster, the rest attempts of the budget
+	// are sent to one of the FallbackClusters, picked by weight.
+	RetryPolicy struct {
+		// Attempts the total attempts budget, including the first call
+		Attempts int `yaml:"attempts" json:"attempts" mapstructure:"attempts"`
+		// PrimaryAttempts the attempts on the route cluster before escalating to fallback clusters
+		PrimaryAttempts  int                `yaml:"primary_attempts" json:"primary_attempts" mapstructure:"primary_attempts"`
+		FallbackClusters []*WeightedCluster `yaml:"fallback_clusters" json:"fallback_clusters" mapstructure:"fallback_clusters"`
+	}
+
+	// WeightedCluster fallback cluster with its weight
+	WeightedCluster struct {
+		Name   string `yaml:"name" json:"name" mapstructure:"name"`
+		Weight int    `yaml:"weight" json:"weight" mapstructure:"weight"`
+	}
+)
+
+// defaultRetryPolicy only call the route cluster once
+var defaultRetryPolicy = &RetryPolicy{Attempts: 1, PrimaryAttempts: 1}
+
+// check verify the policy and fill the default value
+func (p *RetryPolicy) check() error {
+	if p.Attempts <= 0 {
+		p.Attempts = 1
+	}
+	if p.PrimaryAttempts <= 0 || p.PrimaryAttempts > p.Attempts {
+		p.PrimaryAttempts = p.Attempts
+	}
+	totalWeight := 0
+	for _, c := range p.FallbackClusters {
+		if c.Name == "" {
+			return errors.New("fallback cluster name is empty")
+		}
+		if c.Weight < 0 {
+			return errors.Errorf("fallback cluster %s weight must not be negative", c.Name)
+		}
+		totalWeight += c.Weight
+	}
+	if len(p.FallbackClusters) > 0 && totalWeight == 0 {
+		// no weight configured, treat all fallback clusters equally
+		for _, c := range p.FallbackClusters {
+			c.Weight = 1
+		}
+	}
+	return nil
+}
+
+// pickCluster return the cluster which the attempt (start from 0) should be sent to
+func (p *RetryPolicy) pickCluster(primary string, attempt int) string {
+	if attempt < p.PrimaryAttempts || len(p.FallbackClusters) == 0 {
+		return primary
+	}
+
+	totalWeight := 0
+	for _, c := range p.FallbackClusters {
+		totalWeight += c.Weight
+	}
+	n := rand.Intn(totalWeight)
+	for _, c := range p.FallbackClusters {
+		if n < c.Weight {
+			return c.Name
+		}
+		n -= c.Weight
+	}
+	return p.FallbackClusters[len(p.FallbackClusters)-1].Name
+}
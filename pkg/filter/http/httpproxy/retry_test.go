@@ -0,0 +1,242 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestPickCluster(t *testing.T) {
+	p := &RetryPolicy{
+		Attempts:         4,
+		PrimaryAttempts:  2,
+		FallbackClusters: []*WeightedCluster{{Name: "backup", Weight: 1}, {Name: "never", Weight: 0}},
+	}
+	assert.Nil(t, p.check())
+
+	assert.Equal(t, "primary", p.pickCluster("primary", 0))
+	assert.Equal(t, "primary", p.pickCluster("primary", 1))
+	assert.Equal(t, "backup", p.pickCluster("primary", 2))
+	assert.Equal(t, "backup", p.pickCluster("primary", 3))
+
+	p = &RetryPolicy{FallbackClusters: []*WeightedCluster{{Name: ""}}}
+	assert.Error(t, p.check())
+}
+
+func TestRetryEscalateToFallbackCluster(t *testing.T) {
+	primaryHits, fallbackHits := 0, 0
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackHits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fallback.Close()
+
+	endpoints := map[string]*model.Endpoint{
+		"primary":  mockEndpoint(t, primary),
+		"fallback": mockEndpoint(t, fallback),
+	}
+	origin := pickEndpoint
+	pickEndpoint = func(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+		return endpoints[clusterName]
+	}
+	defer func() { pickEndpoint = origin }()
+
+	factory := &FilterFactory{cfg: &Config{Retry: &RetryPolicy{
+		Attempts:         3,
+		PrimaryAttempts:  2,
+		FallbackClusters: []*WeightedCluster{{Name: "fallback", Weight: 10}},
+	}}}
+	assert.Nil(t, factory.Apply())
+
+	request, err := http.NewRequest("PUT", "http://www.dubbogopixiu.com/mock/test", bytes.NewReader([]byte("{\"id\":\"12345\"}")))
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(&model.RouteAction{Cluster: "primary"})
+
+	f := &Filter{transport: &http.Transport{}, retry: factory.cfg.Retry}
+	f.Decode(ctx)
+
+	assert.Equal(t, 2, primaryHits)
+	assert.Equal(t, 1, fallbackHits)
+	assert.Equal(t, http.StatusOK, ctx.SourceResp.(*http.Response).StatusCode)
+
+	// the non idempotent request processed by the upstream is not sent again
+	primaryHits, fallbackHits = 0, 0
+	request, err = http.NewRequest("POST", "http://www.dubbogopixiu.com/mock/test", bytes.NewReader([]byte("{\"id\":\"12345\"}")))
+	assert.NoError(t, err)
+	ctx = mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(&model.RouteAction{Cluster: "primary"})
+	f.Decode(ctx)
+
+	assert.Equal(t, 1, primaryHits)
+	assert.Equal(t, 0, fallbackHits)
+	assert.Equal(t, http.StatusServiceUnavailable, ctx.SourceResp.(*http.Response).StatusCode)
+}
+
+func TestDefaultRetryPolicyNotShared(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+	factory.cfg.Retry.Attempts = 3
+	assert.Equal(t, 1, defaultRetryPolicy.Attempts)
+}
+
+func TestRetrySeekableBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, strconv.FormatInt(r.ContentLength, 10)+":"+string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	origin := pickEndpoint
+	endpoint := mockEndpoint(t, server)
+	pickEndpoint = func(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+		return endpoint
+	}
+	defer func() { pickEndpoint = origin }()
+
+	// the file body is rewound for the retry instead of being buffered
+	file, err := os.Create(filepath.Join(t.TempDir(), "body"))
+	assert.NoError(t, err)
+	defer file.Close()
+	_, err = file.WriteString("0123456789")
+	assert.NoError(t, err)
+	request, err := http.NewRequest("PUT", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	request.Body, request.ContentLength = file, 10
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(&model.RouteAction{Cluster: "primary"})
+
+	f := &Filter{transport: &http.Transport{}, retry: &RetryPolicy{Attempts: 2}}
+	f.Decode(ctx)
+	assert.Equal(t, []string{"10:0123456789", "10:0123456789"}, bodies)
+	assert.Equal(t, http.StatusOK, ctx.SourceResp.(*http.Response).StatusCode)
+}
+
+// countWaiter allow the queued retries without waiting
+type countWaiter struct {
+	waits int
+}
+
+func (w *countWaiter) WaitRetryAfter(ctx context.Context, retryAfter string) bool {
+	w.waits++
+	return retryAfter == "0"
+}
+
+func TestRetryAfterQueued(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	origin := pickEndpoint
+	endpoint := mockEndpoint(t, server)
+	pickEndpoint = func(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+		return endpoint
+	}
+	defer func() { pickEndpoint = origin }()
+
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(&model.RouteAction{Cluster: "primary"})
+	waiter := &countWaiter{}
+	ctx.Params = map[string]interface{}{constant.RetryAfterParam: waiter}
+
+	// the queued retry does not consume the single attempt
+	f := &Filter{transport: &http.Transport{}, retry: defaultRetryPolicy}
+	f.Decode(ctx)
+	assert.Equal(t, 2, hits)
+	assert.Equal(t, 1, waiter.waits)
+	assert.Equal(t, http.StatusOK, ctx.SourceResp.(*http.Response).StatusCode)
+}
+
+func mockEndpoint(t *testing.T, s *httptest.Server) *model.Endpoint {
+	host, port, err := net.SplitHostPort(s.Listener.Addr().String())
+	assert.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	assert.NoError(t, err)
+	return &model.Endpoint{Address: model.SocketAddress{Address: host, Port: p}}
+}
+
+func TestActiveCallUntilBodyClosed(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("streamed"))
+	}))
+	defer upstream.Close()
+
+	endpoint := mockEndpoint(t, upstream)
+	origin := pickEndpoint
+	pickEndpoint = func(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+		return endpoint
+	}
+	defer func() { pickEndpoint = origin }()
+
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(&model.RouteAction{Cluster: "active"})
+
+	f := &Filter{transport: &http.Transport{}}
+	f.Decode(ctx)
+
+	cluster := &model.Cluster{Name: "active"}
+	// the response is still being read, so the call is in flight
+	assert.Equal(t, int64(1), loadbalancer.ActiveCalls(cluster, endpoint))
+	resp := ctx.SourceResp.(*http.Response)
+	_, _ = ioutil.ReadAll(resp.Body)
+	assert.Nil(t, resp.Body.Close())
+	assert.Equal(t, int64(0), loadbalancer.ActiveCalls(cluster, endpoint))
+}
@@ -18,17 +18,24 @@
 package httpproxy
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	http3 "net/http"
 	"net/url"
 )
 
 import (
+	clienthttp "github.com/apache/dubbo-go-pixiu/pkg/client/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
 	"github.com/apache/dubbo-go-pixiu/pkg/server"
 )
 
@@ -41,6 +48,11 @@ func init() {
 	filter.RegisterHttpFilter(&Plugin{})
 }
 
+// pickEndpoint pick an endpoint from the cluster manager
+var pickEndpoint = func(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+	return server.GetClusterManager().PickEndpointWithHint(clusterName, hint)
+}
+
 type (
 	// Plugin is http filter plugin.
 	Plugin struct {
@@ -51,10 +63,16 @@ type (
 	}
 	//Filter
 	Filter struct {
+		// transport override the per cluster connection pool when set
 		transport http3.RoundTripper
+		retry     *RetryPolicy
+		// chain ends the call reported to the load balancer if the response body is never closed
+		chain filter.FilterChain
 	}
 	// Config describe the config of FilterFactory
-	Config struct{}
+	Config struct {
+		Retry *RetryPolicy `yaml:"retry" json:"retry" mapstructure:"retry"`
+	}
 )
 
 func (p *Plugin) Kind() string {
@@ -69,12 +87,21 @@ func (factory *FilterFactory) Config() interface{} {
 	return factory.cfg
 }
 
+// Stage the filter reads the request body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
 func (factory *FilterFactory) Apply() error {
-	return nil
+	if factory.cfg.Retry == nil {
+		policy := *defaultRetryPolicy
+		factory.cfg.Retry = &policy
+	}
+	return factory.cfg.Retry.check()
 }
 
 func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
-	f := &Filter{transport: &http3.Transport{}}
+	f := &Filter{retry: factory.cfg.Retry, chain: chain}
 	chain.AppendDecodeFilters(f)
 	return nil
 }
@@ -84,47 +111,173 @@ func (f *Filter) Decode(hc *http.HttpContext) filter.FilterStatus {
 	if rEntry == nil {
 		panic("no route entry")
 	}
-	logger.Debugf("[dubbo-go-pixiu] client choose endpoint from cluster :%v", rEntry.Cluster)
+	retry := f.retry
+	if retry == nil {
+		retry = defaultRetryPolicy
+	}
 
-	clusterName := rEntry.Cluster
-	clusterManager := server.GetClusterManager()
-	endpoint := clusterManager.PickEndpoint(clusterName)
-	if endpoint == nil {
-		bt, _ := json.Marshal(http.ErrResponse{Message: "cluster not found endpoint"})
-		hc.SendLocalReply(http3.StatusServiceUnavailable, bt)
+	checkRedirect, err := checkRedirectFunc(rEntry.Redirect)
+	if err != nil {
+		bt, _ := json.Marshal(http.ErrResponse{Message: err.Error()})
+		hc.SendLocalReply(http3.StatusInternalServerError, bt)
 		return filter.Stop
 	}
 
-	logger.Debugf("[dubbo-go-pixiu] client choose endpoint :%v", endpoint.Address.GetAddress())
 	r := hc.Request
+	waiter, _ := hc.Params[constant.RetryAfterParam].(retryAfterWaiter)
+	// buffer the body only when it may be sent again, the file body, e.g. the assembled upload,
+	// is rewound for each attempt instead of being read into memory
+	var (
+		body   []byte
+		seeker io.ReadSeeker
+		stream io.Reader
+	)
+	if rs, ok := r.Body.(io.ReadSeeker); ok {
+		seeker = rs
+	} else if r.Body != nil && retry.Attempts == 1 && waiter == nil {
+		stream = r.Body
+	} else if r.Body != nil {
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("read request body failed: %v", err)})
+			hc.SendLocalReply(http3.StatusBadRequest, bt)
+			return filter.Stop
+		}
+	}
 
 	var (
-		req *http3.Request
-		err error
+		resp    *http3.Response
+		callErr error
 	)
+	hint := loadbalancer.Hint{RequestID: requestID(hc), Header: hc.GetHeader}
+	for attempt := 0; attempt < retry.Attempts; attempt++ {
+		clusterName := retry.pickCluster(rEntry.Cluster, attempt)
+		logger.Debugw("client choose endpoint from cluster", "cluster", clusterName, "attempt", attempt)
+
+		endpoint := pickEndpoint(clusterName, hint)
+		if endpoint == nil {
+			resp, callErr = nil, nil
+			continue
+		}
+		logger.Debugw("client choose endpoint", "endpoint", endpoint.Address.GetAddress())
+
+		parsedURL := url.URL{
+			Host:     endpoint.Address.GetAddress(),
+			Scheme:   "http",
+			Path:     r.URL.Path,
+			RawQuery: r.URL.RawQuery,
+		}
+
+		var reqBody io.Reader = bytes.NewReader(body)
+		if stream != nil {
+			// sent once, so the body is streamed to the upstream
+			reqBody = stream
+		} else if seeker != nil {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("rewind request body failed: %v", err)})
+				hc.SendLocalReply(http3.StatusInternalServerError, bt)
+				return filter.Stop
+			}
+			// the body is closed by the owner, not by the transport
+			reqBody = ioutil.NopCloser(seeker)
+		}
+		req, err := http3.NewRequest(r.Method, parsedURL.String(), reqBody)
+		if err != nil {
+			bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("BUG: new request failed: %v", err)})
+			hc.SendLocalReply(http3.StatusInternalServerError, bt)
+			return filter.Stop
+		}
+		if stream != nil || seeker != nil {
+			req.ContentLength = r.ContentLength
+		}
+		req.Header = r.Header
+		proto, _ := hc.Params[constant.UpstreamProtocolParam].(string)
+		if proto == constant.UpstreamProtocolH2C {
+			// the trailers are received after the body is buffered, http2 sends them regardless of the content length
+			req.Trailer = r.Trailer
+		}
 
-	parsedURL := url.URL{
-		Host:     endpoint.Address.GetAddress(),
-		Scheme:   "http",
-		Path:     r.URL.Path,
-		RawQuery: r.URL.RawQuery,
+		cli := &http3.Client{Transport: f.transportFor(clusterName, proto), CheckRedirect: checkRedirect}
+		done := loadbalancer.Begin(clusterName, endpoint)
+		resp, callErr = clienthttp.Do(cli, req)
+		if callErr != nil {
+			done()
+		} else {
+			// the call is in flight until the body is read, the least connections strategy counts the streaming responses
+			resp.Body = loadbalancer.DoneOnClose(resp.Body, done)
+			filter.Defer(f.chain, done)
+		}
+		if callErr == nil && resp.StatusCode == http3.StatusServiceUnavailable && waiter != nil &&
+			waiter.WaitRetryAfter(r.Context(), resp.Header.Get(constant.HeaderKeyRetryAfter)) {
+			// the upstream asks to come back later, the queued retry does not count as an attempt
+			resp.Body.Close()
+			attempt--
+			continue
+		}
+		if callErr == nil && resp.StatusCode < http3.StatusInternalServerError {
+			break
+		}
+		if callErr == nil && !clienthttp.IsIdempotent(r.Method) {
+			// the upstream has received the non idempotent request, the 5xx is returned as is
+			break
+		}
+		if ue, ok := callErr.(*clienthttp.UpstreamError); ok && !ue.Retryable(r.Method) {
+			// the upstream may have processed the non idempotent request, never send it twice
+			logger.Warnw("call cluster fail after request sent, not retry", "cluster", clusterName, "method", r.Method, "error", callErr.Error())
+			break
+		}
+		if attempt < retry.Attempts-1 {
+			logger.Warnw("call cluster fail", "cluster", clusterName, "attempt", attempt, "error", callErr)
+			if resp != nil {
+				resp.Body.Close()
+			}
+		}
 	}
 
-	req, err = http3.NewRequest(r.Method, parsedURL.String(), r.Body)
-	if err != nil {
-		bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("BUG: new request failed: %v", err)})
-		hc.SendLocalReply(http3.StatusInternalServerError, bt)
+	if callErr != nil {
+		status := http3.StatusBadGateway
+		if ue, ok := callErr.(*clienthttp.UpstreamError); ok {
+			status = ue.Status()
+		}
+		bt, _ := json.Marshal(http.ErrResponse{Message: callErr.Error()})
+		hc.SendLocalReply(status, bt)
 		return filter.Stop
 	}
-	req.Header = r.Header
-
-	resp, err := (&http3.Client{Transport: f.transport}).Do(req)
-	if err != nil {
-		panic(err)
+	if resp == nil {
+		bt, _ := json.Marshal(http.ErrResponse{Message: "cluster not found endpoint"})
+		hc.SendLocalReply(http3.StatusServiceUnavailable, bt)
+		return filter.Stop
 	}
-	logger.Debugf("[dubbo-go-pixiu] client call resp:%v", resp)
+	if rEntry.Redirect != nil && isRedirect(resp.StatusCode) {
+		rewriteLocation(resp)
+	}
+	logger.Debugw("client call", "response", resp)
 
 	hc.SourceResp = resp
 	// response write in hcm
 	return filter.Continue
 }
+
+// retryAfterWaiter wait the delay of Retry-After before the request is sent again, it returns false if the retry
+// is abandoned, see the retryafter filter
+type retryAfterWaiter interface {
+	WaitRetryAfter(ctx context.Context, retryAfter string) bool
+}
+
+// requestID the request id shared by the retries, the consistent hashing cluster picks by it by default
+func requestID(hc *http.HttpContext) string {
+	if id := hc.GetRequestID(); id != "" {
+		return id
+	}
+	return hc.GetHeader(constant.HeaderKeyRequestID)
+}
+
+// transportFor pick the transport of the cluster by the upstream protocol
+func (f *Filter) transportFor(clusterName, proto string) http3.RoundTripper {
+	if f.transport != nil {
+		return f.transport
+	}
+	if proto == constant.UpstreamProtocolH2C {
+		return transports.getH2C(clusterName)
+	}
+	return transports.get(clusterName)
+}
@@ -0,0 +1,323 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	stdHttp "net/http"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/redis"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPIdempotencyFilter
+
+	defaultHeader      = "Idempotency-Key"
+	defaultTTL         = 24 * time.Hour
+	defaultWaitTimeout = 10 * time.Second
+	defaultMaxBodySize = 1 << 20
+
+	headerReplayed = "Idempotent-Replayed"
+)
+
+// defaultIdentityHeaders the headers identifying the caller
+var defaultIdentityHeaders = []string{"Authorization", "X-Consumer-Id"}
+
+var (
+	// now is replaced in test
+	now = time.Now
+	// pollInterval how often the waiting request checks the in-progress key
+	pollInterval = 20 * time.Millisecond
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg         *Config
+		ttl         time.Duration
+		waitTimeout time.Duration
+		methods     map[string]struct{}
+		store       Store
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		factory *FilterFactory
+		// key the reserved key, empty if the request is not deduplicated
+		key string
+		// fingerprint the hash of the request body stored with the response
+		fingerprint string
+	}
+
+	// Config describe the config of FilterFactory. The response of the request carrying the idempotency key
+	// is stored, and replayed for the requests with the same key in the ttl instead of calling upstream again.
+	// The key is scoped by the endpoint and the caller identity, the reused key with a different body is rejected
+	// with 422. The 5xx responses and the local replies like 429 are not stored, so that the client can retry.
+	Config struct {
+		// Header the header of idempotency key, Idempotency-Key by default
+		Header string `yaml:"header" json:"header" mapstructure:"header"`
+		// TTL how long the response is kept, 24h by default
+		TTL string `yaml:"ttl" json:"ttl" mapstructure:"ttl"`
+		// Store the name of the store, memory or redis, memory by default, the others are registered by RegisterStore
+		Store string `yaml:"store" json:"store" mapstructure:"store"`
+		// MaxEntries the max keys of the memory store, 100000 by default
+		MaxEntries int `yaml:"max_entries" json:"max_entries" mapstructure:"max_entries"`
+		// Redis the redis of the redis store
+		Redis *redis.Config `yaml:"redis" json:"redis" mapstructure:"redis"`
+		// IdentityHeaders the headers identifying the caller, Authorization and X-Consumer-Id by default
+		IdentityHeaders []string `yaml:"identity_headers" json:"identity_headers" mapstructure:"identity_headers"`
+		// MaxBodySize the max body of the deduplicated request fingerprinted, 1MB by default, the larger one is rejected with 413
+		MaxBodySize int64 `yaml:"max_body_size" json:"max_body_size" mapstructure:"max_body_size"`
+		// Methods the deduplicated http methods, POST by default
+		Methods []string `yaml:"methods" json:"methods" mapstructure:"methods"`
+		// WaitTimeout how long the request waits for the in-progress one with the same key, 10s by default
+		WaitTimeout string `yaml:"wait_timeout" json:"wait_timeout" mapstructure:"wait_timeout"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Header == "" {
+		cfg.Header = defaultHeader
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{stdHttp.MethodPost}
+	}
+	factory.methods = make(map[string]struct{}, len(cfg.Methods))
+	for _, m := range cfg.Methods {
+		factory.methods[strings.ToUpper(m)] = struct{}{}
+	}
+
+	var err error
+	if factory.ttl, err = parseDuration(cfg.TTL, defaultTTL); err != nil {
+		return errors.Wrap(err, "idempotency ttl parse fail")
+	}
+	if factory.waitTimeout, err = parseDuration(cfg.WaitTimeout, defaultWaitTimeout); err != nil {
+		return errors.Wrap(err, "idempotency wait timeout parse fail")
+	}
+
+	if len(cfg.IdentityHeaders) == 0 {
+		cfg.IdentityHeaders = defaultIdentityHeaders
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultMaxBodySize
+	}
+
+	store, err := CreateStore(cfg)
+	if err != nil {
+		return err
+	}
+	factory.store = store
+	return nil
+}
+
+// Close close the store like the redis connections
+func (factory *FilterFactory) Close() error {
+	if c, ok := factory.store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func parseDuration(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, errors.Errorf("invalid duration %s", d)
+	}
+	return d, nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{factory: factory}
+	chain.AppendDecodeFilters(f)
+	chain.AppendEncodeFilters(f)
+	// the reservation is released if the request stops or panics before the response is stored
+	filter.Defer(chain, f.release)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	factory := f.factory
+	if _, ok := factory.methods[ctx.GetMethod()]; !ok {
+		return filter.Continue
+	}
+	idempotencyKey := ctx.GetHeader(factory.cfg.Header)
+	if idempotencyKey == "" {
+		return filter.Continue
+	}
+	fingerprint, err := f.hashBody(ctx)
+	if err != nil {
+		return reply(ctx, stdHttp.StatusRequestEntityTooLarge, err.Error())
+	}
+	// the same key of different endpoints or callers are different requests
+	key := ctx.GetMethod() + " " + ctx.GetUrl() + " " + f.identity(ctx) + " " + idempotencyKey
+
+	timer := time.NewTimer(factory.waitTimeout)
+	defer timer.Stop()
+	for {
+		resp, reserved, err := factory.store.Reserve(key, fingerprint, now().Add(factory.ttl))
+		if errors.Is(err, ErrFingerprintMismatch) {
+			return reply(ctx, stdHttp.StatusUnprocessableEntity, err.Error())
+		}
+		if err != nil {
+			logger.Warnw("idempotency reserve key fail, skip it", "key", idempotencyKey, "error", err.Error())
+			return filter.Continue
+		}
+		if reserved {
+			f.key, f.fingerprint = key, fingerprint
+			return filter.Continue
+		}
+		if resp != nil {
+			return f.replay(ctx, resp)
+		}
+
+		select {
+		case <-time.After(pollInterval):
+		case <-timer.C:
+			return reply(ctx, stdHttp.StatusConflict, "request with the same idempotency key is in progress")
+		case <-ctx.Request.Context().Done():
+			return reply(ctx, stdHttp.StatusConflict, "request with the same idempotency key is in progress")
+		}
+	}
+}
+
+// identity the hash of the identity headers, the raw credentials are never kept in the store
+func (f *Filter) identity(ctx *http.HttpContext) string {
+	h := sha256.New()
+	for _, name := range f.factory.cfg.IdentityHeaders {
+		_, _ = io.WriteString(h, name+"="+ctx.GetHeader(name)+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashBody the hash of the request body, the body is restored for the filters after
+func (f *Filter) hashBody(ctx *http.HttpContext) (string, error) {
+	h := sha256.New()
+	if ctx.Request.Body == nil {
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+	max := f.factory.cfg.MaxBodySize
+	body, err := ioutil.ReadAll(io.LimitReader(ctx.Request.Body, max+1))
+	if err != nil {
+		return "", errors.Wrap(err, "read request body fail")
+	}
+	if int64(len(body)) > max {
+		return "", errors.Errorf("request body exceeds %d bytes", max)
+	}
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Encode store the response of the reserved key
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.key == "" || ctx.LocalReply() || ctx.TargetResp == nil || ctx.GetStatusCode() >= stdHttp.StatusInternalServerError {
+		return filter.Continue
+	}
+	resp := &Response{
+		Status: ctx.GetStatusCode(),
+		Header: ctx.Writer.Header().Clone(),
+		Body:   append([]byte(nil), ctx.TargetResp.Data...),
+
+		Fingerprint: f.fingerprint,
+	}
+	if err := f.factory.store.Complete(f.key, resp, now().Add(f.factory.ttl)); err != nil {
+		logger.Warnw("idempotency store response fail", "url", ctx.GetUrl(), "error", err.Error())
+		return filter.Continue
+	}
+	f.key = ""
+	return filter.Continue
+}
+
+// replay reply the stored response, the chain stops like the cache hit
+func (f *Filter) replay(ctx *http.HttpContext, resp *Response) filter.FilterStatus {
+	header := resp.Header.Clone()
+	if header == nil {
+		header = stdHttp.Header{}
+	}
+	header.Set(headerReplayed, "true")
+	ctx.SourceResp = &stdHttp.Response{
+		StatusCode: resp.Status,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(resp.Body)),
+	}
+	return filter.Stop
+}
+
+func (f *Filter) release() {
+	if f.key == "" {
+		return
+	}
+	if err := f.factory.store.Release(f.key); err != nil {
+		logger.Warnw("idempotency release key fail", "error", err.Error())
+	}
+	f.key = ""
+}
+
+func reply(ctx *http.HttpContext, status int, msg string) filter.FilterStatus {
+	bt, _ := json.Marshal(http.ErrResponse{Message: msg})
+	return filter.Abort(ctx, &filter.AbortResponse{
+		Status:  status,
+		Body:    bt,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	})
+}
@@ -0,0 +1,265 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package idempotency
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	pmock "github.com/apache/dubbo-go-pixiu/pkg/common/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/redis"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func newContext(t *testing.T, key string) *contexthttp.HttpContext {
+	return newCallerContext(t, key, "", "")
+}
+
+func newCallerContext(t *testing.T, key, authorization, body string) *contexthttp.HttpContext {
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/test-dubbo/student/create", strings.NewReader(body))
+	assert.NoError(t, err)
+	if key != "" {
+		request.Header.Set(defaultHeader, key)
+	}
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	return mock.GetMockHTTPContext(request)
+}
+
+// respond act as the upstream and the connection manager building the response
+func respond(ctx *contexthttp.HttpContext, status int, body string) {
+	ctx.StatusCode(status)
+	ctx.TargetResp = &client.Response{Data: []byte(body)}
+}
+
+func replayed(t *testing.T, ctx *contexthttp.HttpContext) (int, string) {
+	resp, ok := ctx.SourceResp.(*http.Response)
+	if !ok {
+		return 0, ""
+	}
+	assert.Equal(t, "true", resp.Header.Get(headerReplayed))
+	body, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestReplay(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+
+	ctx := newContext(t, "k1")
+	f := &Filter{factory: factory}
+	assert.Equal(t, filter.Continue, f.Decode(ctx))
+	respond(ctx, http.StatusCreated, `{"id":"1"}`)
+	f.Encode(ctx)
+	f.release()
+
+	// the retry gets the stored response instead of creating the student again
+	ctx = newContext(t, "k1")
+	f = &Filter{factory: factory}
+	assert.Equal(t, filter.Stop, f.Decode(ctx))
+	status, body := replayed(t, ctx)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, `{"id":"1"}`, body)
+
+	// the other key and the request without key are not affected
+	ctx = newContext(t, "k2")
+	assert.Equal(t, filter.Continue, (&Filter{factory: factory}).Decode(ctx))
+	assert.Nil(t, ctx.SourceResp)
+	ctx = newContext(t, "")
+	assert.Equal(t, filter.Continue, (&Filter{factory: factory}).Decode(ctx))
+}
+
+func TestCallerAndFingerprint(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{MaxBodySize: 16}}
+	assert.Nil(t, factory.Apply())
+
+	ctx := newCallerContext(t, "k1", "Bearer alice", `{"name":"a"}`)
+	f := &Filter{factory: factory}
+	assert.Equal(t, filter.Continue, f.Decode(ctx))
+	// the body is still readable by the filters after
+	body, _ := ioutil.ReadAll(ctx.Request.Body)
+	assert.Equal(t, `{"name":"a"}`, string(body))
+	respond(ctx, http.StatusCreated, `{"id":"alice"}`)
+	f.Encode(ctx)
+	f.release()
+
+	// the same key of another caller is another request
+	ctx = newCallerContext(t, "k1", "Bearer bob", `{"name":"a"}`)
+	assert.Equal(t, filter.Continue, (&Filter{factory: factory}).Decode(ctx))
+	assert.Nil(t, ctx.SourceResp)
+
+	// the reused key with another body is rejected
+	ctx = newCallerContext(t, "k1", "Bearer alice", `{"name":"b"}`)
+	assert.Equal(t, filter.Stop, (&Filter{factory: factory}).Decode(ctx))
+	assert.Equal(t, http.StatusUnprocessableEntity, ctx.GetAbortResponse().Status)
+
+	ctx = newCallerContext(t, "k1", "Bearer alice", `{"name":"a"}`)
+	assert.Equal(t, filter.Stop, (&Filter{factory: factory}).Decode(ctx))
+	_, replayedBody := replayed(t, ctx)
+	assert.Equal(t, `{"id":"alice"}`, replayedBody)
+
+	ctx = newCallerContext(t, "k2", "Bearer alice", `{"name":"too large body"}`)
+	assert.Equal(t, filter.Stop, (&Filter{factory: factory}).Decode(ctx))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ctx.GetAbortResponse().Status)
+}
+
+func TestMemoryStoreBounded(t *testing.T) {
+	current := time.Now()
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	s := NewMemoryStore(2)
+	_, reserved, err := s.Reserve("a", "", current.Add(time.Second))
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	_, reserved, _ = s.Reserve("b", "", current.Add(time.Minute))
+	assert.True(t, reserved)
+	// both keys are in progress
+	_, _, err = s.Reserve("c", "", current.Add(time.Minute))
+	assert.Equal(t, ErrStoreFull, err)
+
+	// the completed entry expiring first is evicted for the new key
+	assert.NoError(t, s.Complete("b", &Response{Status: http.StatusOK}, current.Add(time.Minute)))
+	_, reserved, _ = s.Reserve("c", "", current.Add(time.Minute))
+	assert.True(t, reserved)
+	_, _, err = s.Reserve("b", "", current.Add(time.Minute))
+	assert.Equal(t, ErrStoreFull, err)
+
+	// the expired entry makes room
+	current = current.Add(2 * time.Second)
+	_, reserved, _ = s.Reserve("b", "", current.Add(time.Minute))
+	assert.True(t, reserved)
+	assert.Len(t, s.entries, 2)
+}
+
+func TestRedisStore(t *testing.T) {
+	server, err := pmock.NewRedisServer()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	factory := &FilterFactory{cfg: &Config{Store: redisStore, Redis: &redis.Config{Address: server.Addr()}}}
+	assert.Nil(t, factory.Apply())
+	defer factory.Close()
+	s := factory.store
+
+	expireAt := time.Now().Add(time.Minute)
+	_, reserved, err := s.Reserve("k1", "fp", expireAt)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	_, reserved, err = s.Reserve("k1", "fp", expireAt)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	_, _, err = s.Reserve("k1", "other", expireAt)
+	assert.Equal(t, ErrFingerprintMismatch, err)
+
+	assert.NoError(t, s.Complete("k1", &Response{Status: http.StatusCreated, Body: []byte("ok"), Fingerprint: "fp"}, expireAt))
+	// the completed response is not released
+	assert.NoError(t, s.Release("k1"))
+	resp, reserved, err := s.Reserve("k1", "fp", expireAt)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, http.StatusCreated, resp.Status)
+	assert.Equal(t, "ok", string(resp.Body))
+
+	_, reserved, _ = s.Reserve("k2", "fp", expireAt)
+	assert.True(t, reserved)
+	assert.NoError(t, s.Release("k2"))
+	_, reserved, _ = s.Reserve("k2", "fp", expireAt)
+	assert.True(t, reserved)
+
+	assert.Error(t, (&FilterFactory{cfg: &Config{Store: redisStore}}).Apply())
+}
+
+func TestServerErrorNotStored(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+
+	ctx := newContext(t, "k1")
+	f := &Filter{factory: factory}
+	assert.Equal(t, filter.Continue, f.Decode(ctx))
+	respond(ctx, http.StatusInternalServerError, "data is exist")
+	f.Encode(ctx)
+	f.release()
+
+	ctx = newContext(t, "k1")
+	assert.Equal(t, filter.Continue, (&Filter{factory: factory}).Decode(ctx))
+	assert.Nil(t, ctx.SourceResp)
+}
+
+func TestConcurrentWait(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+
+	first := &Filter{factory: factory}
+	firstCtx := newContext(t, "k1")
+	assert.Equal(t, filter.Continue, first.Decode(firstCtx))
+
+	var wg sync.WaitGroup
+	results := make([]string, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := newContext(t, "k1")
+			assert.Equal(t, filter.Stop, (&Filter{factory: factory}).Decode(ctx))
+			_, results[i] = replayed(t, ctx)
+		}(i)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	respond(firstCtx, http.StatusOK, `{"id":"1"}`)
+	first.Encode(firstCtx)
+	wg.Wait()
+	assert.Equal(t, []string{`{"id":"1"}`, `{"id":"1"}`, `{"id":"1"}`}, results)
+}
+
+func TestWaitTimeout(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{WaitTimeout: "50ms"}}
+	assert.Nil(t, factory.Apply())
+
+	first := &Filter{factory: factory}
+	assert.Equal(t, filter.Continue, first.Decode(newContext(t, "k1")))
+
+	ctx := newContext(t, "k1")
+	assert.Equal(t, filter.Stop, (&Filter{factory: factory}).Decode(ctx))
+	assert.Equal(t, http.StatusConflict, ctx.GetAbortResponse().Status)
+
+	// the failed request releases the key, then it can be retried
+	first.release()
+	assert.Equal(t, filter.Continue, (&Filter{factory: factory}).Decode(newContext(t, "k1")))
+}
+
+func TestApplyInvalid(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{TTL: "1x"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{WaitTimeout: "-1s"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Store: "unknown"}}).Apply())
+}
@@ -0,0 +1,329 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package idempotency
+
+import (
+	"container/heap"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/redis"
+)
+
+const (
+	defaultStore = "memory"
+	redisStore   = "redis"
+
+	defaultMaxEntries = 100000
+	redisKeyPrefix    = "pixiu:idempotency:"
+	// redisInProgress the prefix of the redis value of the key in progress, followed by the fingerprint
+	redisInProgress = "in-progress:"
+)
+
+// ErrFingerprintMismatch the key is reused by a request with a different payload
+var ErrFingerprintMismatch = errors.New("idempotency key is reused with a different request")
+
+// ErrStoreFull the memory store holds the max entries in progress
+var ErrStoreFull = errors.New("idempotency store is full")
+
+type (
+	// Store keep the responses by idempotency key, it can be shared by multiple pixiu instances, e.g. the redis store
+	Store interface {
+		// Reserve return the stored response of key if it is completed, otherwise mark key in progress until expireAt
+		// and return reserved true, reserved is false if key is in progress by another request.
+		// It returns ErrFingerprintMismatch if key is reserved or completed with another fingerprint.
+		Reserve(key, fingerprint string, expireAt time.Time) (resp *Response, reserved bool, err error)
+		// Complete store the response of the reserved key until expireAt
+		Complete(key string, resp *Response, expireAt time.Time) error
+		// Release drop the reservation of key, so that the request can be retried
+		Release(key string) error
+	}
+
+	// StoreCreator create the store by the filter config
+	StoreCreator func(cfg *Config) (Store, error)
+
+	// Response the response replayed for the requests with the same key
+	Response struct {
+		Status int         `json:"status"`
+		Header http.Header `json:"header"`
+		Body   []byte      `json:"body"`
+		// Fingerprint the hash of the request the response is for
+		Fingerprint string `json:"fingerprint"`
+	}
+
+	// MemoryStore the in-memory store, the responses are lost on restart. The entries expiring first are evicted
+	// beyond the max entries.
+	MemoryStore struct {
+		maxEntries int
+
+		mu      sync.Mutex
+		entries map[string]*memoryEntry
+		expiry  expiryHeap
+	}
+
+	memoryEntry struct {
+		// resp nil means in progress
+		resp        *Response
+		fingerprint string
+		expireAt    time.Time
+	}
+
+	// expiryItem the expiry of a key, it is stale if the entry of key is removed or expires at another time
+	expiryItem struct {
+		key      string
+		expireAt time.Time
+	}
+
+	// expiryHeap the min heap of the expiries
+	expiryHeap []expiryItem
+
+	// RedisStore the store shared by the pixiu instances through redis
+	RedisStore struct {
+		client *redis.Client
+	}
+)
+
+var stores = map[string]StoreCreator{
+	defaultStore: func(cfg *Config) (Store, error) { return NewMemoryStore(cfg.MaxEntries), nil },
+	redisStore: func(cfg *Config) (Store, error) {
+		if cfg.Redis == nil {
+			return nil, errors.New("redis config is required by redis store")
+		}
+		return NewRedisStore(cfg.Redis)
+	},
+}
+
+// RegisterStore register the store creator by name, it should be called in init
+func RegisterStore(name string, creator StoreCreator) {
+	stores[name] = creator
+}
+
+// CreateStore create the registered store by the store name of config, memory by default
+func CreateStore(cfg *Config) (Store, error) {
+	name := cfg.Store
+	if name == "" {
+		name = defaultStore
+	}
+	creator, ok := stores[name]
+	if !ok {
+		return nil, errors.Errorf("idempotency store %s not found", name)
+	}
+	return creator(cfg)
+}
+
+// NewMemoryStore create memory store keeping at most maxEntries, 100000 if not positive
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	return &MemoryStore{maxEntries: maxEntries, entries: make(map[string]*memoryEntry)}
+}
+
+// Reserve the expired entries are removed
+func (s *MemoryStore) Reserve(key, fingerprint string, expireAt time.Time) (*Response, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	if e, ok := s.entries[key]; ok && t.Before(e.expireAt) {
+		if e.fingerprint != fingerprint {
+			return nil, false, ErrFingerprintMismatch
+		}
+		return e.resp, false, nil
+	}
+	s.evict(t)
+	if len(s.entries) >= s.maxEntries && !s.evictCompleted() {
+		return nil, false, ErrStoreFull
+	}
+	s.set(key, &memoryEntry{fingerprint: fingerprint, expireAt: expireAt})
+	return nil, true, nil
+}
+
+// Complete store the response
+func (s *MemoryStore) Complete(key string, resp *Response, expireAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set(key, &memoryEntry{resp: resp, fingerprint: resp.Fingerprint, expireAt: expireAt})
+	return nil
+}
+
+// Release remove the reservation, the completed response is kept
+func (s *MemoryStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.resp == nil {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// set the entry and track its expiry, the caller must hold the lock
+func (s *MemoryStore) set(key string, e *memoryEntry) {
+	s.entries[key] = e
+	heap.Push(&s.expiry, expiryItem{key: key, expireAt: e.expireAt})
+	// the stale items of the released or completed keys are dropped once they outnumber the entries
+	if len(s.expiry) > 2*len(s.entries)+64 {
+		s.expiry = s.expiry[:0]
+		for k, e := range s.entries {
+			s.expiry = append(s.expiry, expiryItem{key: k, expireAt: e.expireAt})
+		}
+		heap.Init(&s.expiry)
+	}
+}
+
+// evict remove the expired entries from the earliest, the caller must hold the lock
+func (s *MemoryStore) evict(t time.Time) {
+	for len(s.expiry) > 0 && !t.Before(s.expiry[0].expireAt) {
+		item := heap.Pop(&s.expiry).(expiryItem)
+		if e, ok := s.entries[item.key]; ok && e.expireAt.Equal(item.expireAt) {
+			delete(s.entries, item.key)
+		}
+	}
+}
+
+// evictCompleted remove the completed entry expiring first, the entries in progress are kept,
+// it returns false if nothing is removed, the caller must hold the lock
+func (s *MemoryStore) evictCompleted() bool {
+	var kept []expiryItem
+	defer func() {
+		for _, item := range kept {
+			heap.Push(&s.expiry, item)
+		}
+	}()
+	for len(s.expiry) > 0 {
+		item := heap.Pop(&s.expiry).(expiryItem)
+		e, ok := s.entries[item.key]
+		if !ok || !e.expireAt.Equal(item.expireAt) {
+			continue
+		}
+		if e.resp == nil {
+			kept = append(kept, item)
+			continue
+		}
+		delete(s.entries, item.key)
+		return true
+	}
+	return false
+}
+
+func (h expiryHeap) Len() int            { return len(h) }
+func (h expiryHeap) Less(i, j int) bool  { return h[i].expireAt.Before(h[j].expireAt) }
+func (h expiryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap) Push(x interface{}) { *h = append(*h, x.(expiryItem)) }
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// NewRedisStore create the redis store
+func NewRedisStore(cfg *redis.Config) (*RedisStore, error) {
+	client, err := redis.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Reserve mark the key in progress by SET NX, or return the stored response
+func (s *RedisStore) Reserve(key, fingerprint string, expireAt time.Time) (*Response, bool, error) {
+	k := redisKeyPrefix + key
+	reply, err := s.client.Do("SET", k, redisInProgress+fingerprint, "NX", "PX", ttlMillis(expireAt))
+	if err != nil {
+		return nil, false, err
+	}
+	if reply != nil {
+		return nil, true, nil
+	}
+
+	reply, err = s.client.Do("GET", k)
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := reply.(string)
+	if !ok {
+		// expired just now, the request waits and tries again
+		return nil, false, nil
+	}
+	if strings.HasPrefix(value, redisInProgress) {
+		if strings.TrimPrefix(value, redisInProgress) != fingerprint {
+			return nil, false, ErrFingerprintMismatch
+		}
+		return nil, false, nil
+	}
+	resp := &Response{}
+	if err := json.Unmarshal([]byte(value), resp); err != nil {
+		return nil, false, errors.Wrap(err, "decode stored response fail")
+	}
+	if resp.Fingerprint != fingerprint {
+		return nil, false, ErrFingerprintMismatch
+	}
+	return resp, false, nil
+}
+
+// Complete store the response as json
+func (s *RedisStore) Complete(key string, resp *Response, expireAt time.Time) error {
+	value, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Do("SET", redisKeyPrefix+key, string(value), "PX", ttlMillis(expireAt))
+	return err
+}
+
+// Release delete the key only if it is still in progress
+func (s *RedisStore) Release(key string) error {
+	k := redisKeyPrefix + key
+	reply, err := s.client.Do("GET", k)
+	if err != nil {
+		return err
+	}
+	value, ok := reply.(string)
+	if !ok || !strings.HasPrefix(value, redisInProgress) {
+		return nil
+	}
+	_, err = s.client.DelIfEqual(k, value)
+	return err
+}
+
+// Close close the redis connections
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// ttlMillis the ttl in milliseconds of redis PX, at least 1
+func ttlMillis(expireAt time.Time) string {
+	ms := expireAt.Sub(now()).Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return strconv.FormatInt(ms, 10)
+}
@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsoncase
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+const (
+	// CaseCamel userName
+	CaseCamel = "camel"
+	// CaseSnake user_name
+	CaseSnake = "snake"
+	// CasePascal UserName
+	CasePascal = "pascal"
+)
+
+// renames convert a key in any of the cases to the case
+var renames = map[string]func(string) string{
+	CaseCamel:  toCamel,
+	CaseSnake:  camelToSnake,
+	CasePascal: toPascal,
+}
+
+// convertJSON rename the keys of the json objects in data recursively, the values are kept as they are
+func convertJSON(data []byte, rename func(string) string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(convertKeys(v, rename))
+}
+
+func convertKeys(v interface{}, rename func(string) string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			out[rename(k)] = convertKeys(item, rename)
+		}
+		return out
+	case []interface{}:
+		for i, item := range t {
+			t[i] = convertKeys(item, rename)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// camelToSnake convert userName to user_name, the acronym is kept as a word, such as userID to user_id
+func camelToSnake(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' {
+				prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// snakeToCamel convert user_name to userName, the leading underscores are kept
+func snakeToCamel(s string) string {
+	prefix := len(s) - len(strings.TrimLeft(s, "_"))
+	var b strings.Builder
+	b.WriteString(s[:prefix])
+	upper := false
+	for _, r := range s[prefix:] {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// toCamel convert user_name and UserName to userName, the leading acronym is lowered as a word, such as HTTPServer to httpServer
+func toCamel(s string) string {
+	s = snakeToCamel(s)
+	prefix := len(s) - len(strings.TrimLeft(s, "_"))
+	runes := []rune(s[prefix:])
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	// the last upper one begins the next word, such as the S of HTTPServer
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return s[:prefix] + string(runes)
+}
+
+// toPascal convert user_name and userName to UserName
+func toPascal(s string) string {
+	s = snakeToCamel(s)
+	prefix := len(s) - len(strings.TrimLeft(s, "_"))
+	runes := []rune(s[prefix:])
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return s[:prefix] + string(runes)
+}
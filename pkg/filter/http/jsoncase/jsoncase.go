@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsoncase
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPJSONCaseFilter
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Request convert the camelCase keys of request body to snake_case for upstream, ignored if RequestCase is set
+		Request bool `yaml:"request" json:"request" mapstructure:"request"`
+		// Response convert the snake_case keys of response body to camelCase for client, ignored if ResponseCase is set
+		Response bool `yaml:"response" json:"response" mapstructure:"response"`
+		// RequestCase the case of request body keys for upstream: camel, snake or pascal
+		RequestCase string `yaml:"request_case" json:"request_case" mapstructure:"request_case"`
+		// ResponseCase the case of response body keys for client: camel, snake or pascal
+		ResponseCase string `yaml:"response_case" json:"response_case" mapstructure:"response_case"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{Request: true, Response: true}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter reads the request body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
+func (factory *FilterFactory) Apply() error {
+	for _, c := range []string{factory.cfg.RequestCase, factory.cfg.ResponseCase} {
+		if _, ok := renames[c]; c != "" && !ok {
+			return errors.Errorf("json case %s is not supported, it should be camel, snake or pascal", c)
+		}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	if f.cfg.requestCase() != "" {
+		chain.AppendDecodeFilters(f)
+	}
+	if f.cfg.responseCase() != "" {
+		chain.AppendEncodeFilters(f)
+	}
+	return nil
+}
+
+// requestCase the case to convert the request to, empty if not converted
+func (c *Config) requestCase() string {
+	if c.RequestCase == "" && c.Request {
+		return CaseSnake
+	}
+	return c.RequestCase
+}
+
+// responseCase the case to convert the response to, empty if not converted
+func (c *Config) responseCase() string {
+	if c.ResponseCase == "" && c.Response {
+		return CaseCamel
+	}
+	return c.ResponseCase
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	req := ctx.Request
+	rename, ok := renames[f.cfg.requestCase()]
+	if !ok || req.Body == nil || !isJSON(req.Header.Get(constant.HeaderKeyContextType)) {
+		return filter.Continue
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Warnw("json case filter read body fail", "error", err.Error())
+		return filter.Continue
+	}
+	if converted, err := convertJSON(body, rename); err == nil {
+		body = converted
+	} else {
+		logger.Debugw("json case filter skip invalid request body", "error", err.Error())
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	return filter.Continue
+}
+
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	rename, ok := renames[f.cfg.responseCase()]
+	if !ok || ctx.TargetResp == nil || len(ctx.TargetResp.Data) == 0 || !isJSON(ctx.Writer.Header().Get(constant.HeaderKeyContextType)) {
+		return filter.Continue
+	}
+	converted, err := convertJSON(ctx.TargetResp.Data, rename)
+	if err != nil {
+		logger.Debugw("json case filter skip invalid response body", "error", err.Error())
+		return filter.Continue
+	}
+	ctx.TargetResp.Data = converted
+	ctx.Writer.Header().Del("Content-Length")
+	return filter.Continue
+}
+
+func isJSON(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "json")
+}
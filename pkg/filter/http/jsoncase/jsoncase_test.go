@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsoncase
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestCaseConvert(t *testing.T) {
+	tests := []struct {
+		camel string
+		snake string
+	}{
+		{camel: "userName", snake: "user_name"},
+		{camel: "userID", snake: "user_id"},
+		{camel: "HTTPServer", snake: "http_server"},
+		{camel: "age", snake: "age"},
+		{camel: "address2City", snake: "address2_city"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.snake, camelToSnake(tt.camel))
+	}
+	assert.Equal(t, "userName", snakeToCamel("user_name"))
+	assert.Equal(t, "_userName", snakeToCamel("_user_name"))
+}
+
+func TestRoundTrip(t *testing.T) {
+	camel := `{"userName":"tc","userInfo":{"homeAddress":{"zipCode":"100000"},"phoneList":[{"phoneNumber":"1380000"}]},"tagIds":[1,2],"createdAt":1665705600000}`
+	snake := `{"created_at":1665705600000,"tag_ids":[1,2],"user_info":{"home_address":{"zip_code":"100000"},"phone_list":[{"phone_number":"1380000"}]},"user_name":"tc"}`
+
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/user", bytes.NewReader([]byte(camel)))
+	assert.NoError(t, err)
+	request.Header.Set(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+	ctx := mock.GetMockHTTPContext(request)
+	f := &Filter{cfg: &Config{Request: true, Response: true}}
+
+	// to upstream
+	f.Decode(ctx)
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, snake, string(body))
+	assert.Equal(t, int64(len(body)), ctx.Request.ContentLength)
+
+	// back to client
+	ctx.AddHeader(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+	ctx.TargetResp = &client.Response{Data: body}
+	f.Encode(ctx)
+	assert.JSONEq(t, camel, string(ctx.TargetResp.Data))
+}
+
+func TestSkipNotJSON(t *testing.T) {
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/user", bytes.NewReader([]byte("userName=tc")))
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	f := &Filter{cfg: &Config{Request: true, Response: true}}
+
+	f.Decode(ctx)
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "userName=tc", string(body))
+
+	ctx.TargetResp = &client.Response{Data: []byte(`{"user_name":"tc"}`)}
+	f.Encode(ctx)
+	assert.Equal(t, `{"user_name":"tc"}`, string(ctx.TargetResp.Data))
+}
+
+func TestToCase(t *testing.T) {
+	tests := []struct {
+		key    string
+		camel  string
+		pascal string
+	}{
+		{key: "user_name", camel: "userName", pascal: "UserName"},
+		{key: "UserName", camel: "userName", pascal: "UserName"},
+		{key: "userName", camel: "userName", pascal: "UserName"},
+		{key: "HTTPServer", camel: "httpServer", pascal: "HTTPServer"},
+		{key: "ID", camel: "id", pascal: "ID"},
+		{key: "_user_name", camel: "_userName", pascal: "_UserName"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.camel, toCamel(tt.key), tt.key)
+		assert.Equal(t, tt.pascal, toPascal(tt.key), tt.key)
+	}
+}
+
+func TestConfigCase(t *testing.T) {
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/user", bytes.NewReader([]byte(`{"user_name":"tc","phone_list":[{"phone_number":"1380000"}]}`)))
+	assert.NoError(t, err)
+	request.Header.Set(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+	ctx := mock.GetMockHTTPContext(request)
+	f := &Filter{cfg: &Config{Request: true, Response: true, RequestCase: CasePascal, ResponseCase: CaseSnake}}
+
+	f.Decode(ctx)
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"UserName":"tc","PhoneList":[{"PhoneNumber":"1380000"}]}`, string(body))
+
+	ctx.AddHeader(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+	ctx.TargetResp = &client.Response{Data: []byte(`{"userName":"tc","PhoneList":[{"phoneNumber":"1380000"}]}`)}
+	f.Encode(ctx)
+	assert.JSONEq(t, `{"user_name":"tc","phone_list":[{"phone_number":"1380000"}]}`, string(ctx.TargetResp.Data))
+
+	factory := &FilterFactory{cfg: &Config{RequestCase: "kebab"}}
+	assert.Error(t, factory.Apply())
+}
@@ -0,0 +1,224 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	stdHttp "net/http"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPJSONSchemaFilter
+
+	// ModeLog only log the invalid response
+	ModeLog = "log"
+	// ModeEnforce replace the invalid response with 502
+	ModeEnforce = "enforce"
+
+	defaultMaxBodySize = 1 << 20
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg *Config
+		// rule the rule matching the request, nil if no rule matches
+		rule *Rule
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Rules the schemas of the routes, the first rule matching the request is used
+		Rules []*Rule `yaml:"rules" json:"rules" mapstructure:"rules"`
+		// MaxBodySize the max size of the validated request body in bytes, the larger one is rejected with 413, 1MB by default
+		MaxBodySize int64 `yaml:"max_body_size" json:"max_body_size" mapstructure:"max_body_size"`
+	}
+
+	// Rule the schemas of a route
+	Rule struct {
+		// Path the exact path of the route
+		Path string `yaml:"path" json:"path" mapstructure:"path"`
+		// Method the method of the route, empty means any method
+		Method string `yaml:"method" json:"method" mapstructure:"method"`
+		// RequestSchema the json schema of the request body, the invalid request is rejected with 400
+		RequestSchema string `yaml:"request_schema" json:"request_schema" mapstructure:"request_schema"`
+		// ResponseSchema the json schema of the response body
+		ResponseSchema string `yaml:"response_schema" json:"response_schema" mapstructure:"response_schema"`
+		// ResponseMode log or enforce, what to do with the invalid response, log by default
+		ResponseMode string `yaml:"response_mode" json:"response_mode" mapstructure:"response_mode"`
+
+		request  *schema
+		response *schema
+	}
+
+	// invalidResponse the body of the rejected request, it lists all validation errors
+	invalidResponse struct {
+		Message string   `json:"message"`
+		Errors  []string `json:"errors"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter reads the request body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
+// Apply compile the schemas once, so that the requests are validated without parsing the schemas
+func (factory *FilterFactory) Apply() error {
+	if factory.cfg.MaxBodySize <= 0 {
+		factory.cfg.MaxBodySize = defaultMaxBodySize
+	}
+	for i, r := range factory.cfg.Rules {
+		if err := r.compile(); err != nil {
+			return errors.Wrapf(err, "json schema rule %d of path %s", i, r.Path)
+		}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendDecodeFilters(f)
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+func (r *Rule) compile() error {
+	if r.Path == "" {
+		return errors.New("path is required")
+	}
+	switch r.ResponseMode {
+	case "":
+		r.ResponseMode = ModeLog
+	case ModeLog, ModeEnforce:
+	default:
+		return errors.Errorf("unknown response mode %s", r.ResponseMode)
+	}
+	var err error
+	if r.RequestSchema != "" {
+		if r.request, err = compileSchema(r.RequestSchema); err != nil {
+			return errors.Wrap(err, "request schema")
+		}
+	}
+	if r.ResponseSchema != "" {
+		if r.response, err = compileSchema(r.ResponseSchema); err != nil {
+			return errors.Wrap(err, "response schema")
+		}
+	}
+	return nil
+}
+
+func (r *Rule) match(method, path string) bool {
+	return r.Path == path && (r.Method == "" || strings.EqualFold(r.Method, method))
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	req := ctx.Request
+	for _, r := range f.cfg.Rules {
+		if r.match(req.Method, req.URL.Path) {
+			f.rule = r
+			break
+		}
+	}
+	if f.rule == nil || f.rule.request == nil {
+		return filter.Continue
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		max := f.cfg.MaxBodySize
+		if body, err = ioutil.ReadAll(io.LimitReader(req.Body, max+1)); err != nil {
+			logger.Warnw("json schema filter read body fail", "error", err.Error())
+			return filter.Abort(ctx, &filter.AbortResponse{Status: stdHttp.StatusBadRequest})
+		}
+		if int64(len(body)) > max {
+			return filter.Abort(ctx, invalid(stdHttp.StatusRequestEntityTooLarge, "request body is too large", nil))
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	if errs := f.rule.request.validateJSON(body); len(errs) > 0 {
+		return filter.Abort(ctx, invalid(stdHttp.StatusBadRequest, "request body is invalid", errs))
+	}
+	return filter.Continue
+}
+
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.rule == nil || f.rule.response == nil || ctx.LocalReply() || ctx.TargetResp == nil {
+		return filter.Continue
+	}
+	errs := f.rule.response.validateJSON(ctx.TargetResp.Data)
+	if len(errs) == 0 {
+		return filter.Continue
+	}
+	logger.Warnw("response is invalid", "method", ctx.Request.Method, "path", ctx.Request.URL.Path, "errors", errs)
+	if f.rule.ResponseMode != ModeEnforce {
+		return filter.Continue
+	}
+	return filter.Abort(ctx, invalid(stdHttp.StatusBadGateway, "response body is invalid", errs))
+}
+
+func invalid(status int, message string, errs []string) *filter.AbortResponse {
+	body, _ := json.Marshal(invalidResponse{Message: message, Errors: errs})
+	return &filter.AbortResponse{
+		Status:  status,
+		Body:    body,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	}
+}
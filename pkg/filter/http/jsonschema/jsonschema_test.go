@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+const studentSchema = `{
+	"type": "object",
+	"required": ["name", "code"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"code": {"type": "integer", "minimum": 1},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	}
+}`
+
+func newFactory(t *testing.T, mode string) *FilterFactory {
+	factory := &FilterFactory{cfg: &Config{Rules: []*Rule{{
+		Path:           "/api/v1/student",
+		Method:         "POST",
+		RequestSchema:  studentSchema,
+		ResponseSchema: `{"type": "object", "required": ["id"]}`,
+		ResponseMode:   mode,
+	}}}}
+	assert.Nil(t, factory.Apply())
+	return factory
+}
+
+func TestValidateRequest(t *testing.T) {
+	factory := newFactory(t, "")
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		// errs the json pointers of the errors
+		errs []string
+	}{
+		{name: "valid", method: "POST", body: `{"name":"tc","code":1,"tags":["a"]}`},
+		{name: "other method", method: "PUT", body: `{}`},
+		{name: "missing", method: "POST", body: `{"name":"tc"}`, errs: []string{"#"}},
+		{name: "wrong types", method: "POST", body: `{"name":1,"code":1.5,"tags":["a",2]}`, errs: []string{
+			"#/code",
+			"#/name",
+			"#/tags/1",
+		}},
+		{name: "invalid json", method: "POST", body: `{"name"`, errs: []string{"#"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, "http://www.dubbogopixiu.com/api/v1/student", bytes.NewReader([]byte(tt.body)))
+			assert.NoError(t, err)
+			ctx := mock.GetMockHTTPContext(request)
+			chain := filter.NewDefaultFilterChain()
+			assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+			chain.OnDecode(ctx)
+
+			if tt.errs == nil {
+				assert.False(t, ctx.LocalReply())
+				body, _ := ioutil.ReadAll(ctx.Request.Body)
+				assert.Equal(t, tt.body, string(body))
+				return
+			}
+			assert.Equal(t, http.StatusBadRequest, ctx.GetStatusCode())
+			var resp invalidResponse
+			assert.Nil(t, json.Unmarshal(ctx.TargetResp.Data, &resp))
+			assert.Equal(t, len(tt.errs), len(resp.Errors), resp.Errors)
+			for i, at := range tt.errs {
+				if i < len(resp.Errors) {
+					assert.True(t, strings.HasPrefix(resp.Errors[i], at+": "), resp.Errors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	for _, mode := range []string{ModeLog, ModeEnforce} {
+		factory := newFactory(t, mode)
+		request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/student", bytes.NewReader([]byte(`{"name":"tc","code":1}`)))
+		assert.NoError(t, err)
+		ctx := mock.GetMockHTTPContext(request)
+		chain := filter.NewDefaultFilterChain()
+		assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+		chain.OnDecode(ctx)
+		ctx.TargetResp = &client.Response{Data: []byte(`{"name":"tc"}`)}
+		chain.OnEncode(ctx)
+
+		if mode == ModeLog {
+			assert.False(t, ctx.LocalReply())
+			continue
+		}
+		assert.True(t, ctx.LocalReply())
+		assert.Equal(t, http.StatusBadGateway, ctx.GetStatusCode())
+	}
+}
+
+func TestRequestTooLarge(t *testing.T) {
+	factory := newFactory(t, "")
+	factory.cfg.MaxBodySize = 16
+
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/student", bytes.NewReader([]byte(`{"name":"tc","code":1,"tags":["a"]}`)))
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+	chain.OnDecode(ctx)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ctx.GetStatusCode())
+}
+
+func TestApply(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{Rules: []*Rule{{RequestSchema: `{}`}}}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Rules: []*Rule{{Path: "/a", RequestSchema: `{"type":"text"}`}}}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Rules: []*Rule{{Path: "/a", ResponseSchema: `[`}}}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Rules: []*Rule{{Path: "/a", ResponseMode: "drop"}}}}).Apply())
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schema the compiled json schema, it is validated by github.com/santhosh-tekuri/jsonschema, so that all keywords
+// of the schema drafts are supported
+type schema struct {
+	compiled *jsonschema.Schema
+}
+
+// compileSchema compile the json schema text
+func compileSchema(raw string) (*schema, error) {
+	compiled, err := jsonschema.CompileString("schema.json", raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid json schema")
+	}
+	return &schema{compiled: compiled}, nil
+}
+
+// validateJSON validate the json document, it returns the validation errors located by json pointer, nil means valid
+func (s *schema) validateJSON(doc []byte) []string {
+	d := json.NewDecoder(bytes.NewReader(doc))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return []string{"#: invalid json: " + err.Error()}
+	}
+	if d.More() {
+		return []string{"#: invalid json: unexpected data after the value"}
+	}
+	err := s.compiled.Validate(v)
+	if err == nil {
+		return nil
+	}
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []string{"#: " + err.Error()}
+	}
+	var errs []string
+	collect(ve, &errs)
+	sort.Strings(errs)
+	return errs
+}
+
+// collect the leaf causes, they tell where the document is invalid
+func collect(ve *jsonschema.ValidationError, errs *[]string) {
+	if len(ve.Causes) == 0 {
+		*errs = append(*errs, "#"+ve.InstanceLocation+": "+ve.Message)
+		return
+	}
+	for _, cause := range ve.Causes {
+		collect(cause, errs)
+	}
+}
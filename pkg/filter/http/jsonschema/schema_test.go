@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaKeywords(t *testing.T) {
+	s, err := compileSchema(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"additionalProperties": false,
+		"definitions": {"grade": {"enum": ["A", "B"]}},
+		"properties": {
+			"grade": {"$ref": "#/definitions/grade"},
+			"email": {"type": "string", "format": "email"},
+			"score": {"type": "number", "exclusiveMaximum": 100},
+			"ids": {"type": "array", "minItems": 1},
+			"note": {"oneOf": [{"type": "string"}, {"type": "null"}]}
+		}
+	}`)
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.validateJSON([]byte(`{"grade":"A","email":"a@b.com","score":99.5,"ids":[1],"note":null}`)))
+	errs := s.validateJSON([]byte(`{"grade":"C","email":"not-an-email","score":100,"ids":[],"note":1,"x":1}`))
+	for _, at := range []string{"#/email", "#/grade", "#/ids", "#/note", "#/score"} {
+		assert.True(t, hasError(errs, at), at)
+	}
+	// the additional property is reported on the object
+	assert.True(t, hasError(errs, "#"))
+	assert.True(t, hasError(s.validateJSON([]byte(`[]`)), "#"))
+	assert.Equal(t, []string{"#: invalid json: unexpected EOF"}, s.validateJSON([]byte(`{"grade"`)))
+}
+
+func TestInvalidSchema(t *testing.T) {
+	for _, raw := range []string{
+		`[`,
+		`{"type": "text"}`,
+		`{"$ref": "#/definitions/missing"}`,
+		`{"minLength": -1}`,
+	} {
+		_, err := compileSchema(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+// hasError whether any error is located at the json pointer
+func hasError(errs []string, at string) bool {
+	for _, e := range errs {
+		if strings.HasPrefix(e, at+": ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// stats the comparison counters by mirror cluster
+var stats sync.Map
+
+// metricOnce register the counters on the first apply, when the meter provider is set up
+var metricOnce sync.Once
+
+type clusterStats struct {
+	compared   int64
+	statusDiff int64
+	bodyDiff   int64
+	failed     int64
+}
+
+func statsOf(cluster string) *clusterStats {
+	s, _ := stats.LoadOrStore(cluster, &clusterStats{})
+	return s.(*clusterStats)
+}
+
+// compare record the diffs between the responses of primary and mirror
+func compare(cluster, path string, primary, mirror *mirrorResult) {
+	s := statsOf(cluster)
+	if mirror.err != nil {
+		atomic.AddInt64(&s.failed, 1)
+		return
+	}
+	// count the compared at last, so that the diffs are visible once it is counted
+	defer atomic.AddInt64(&s.compared, 1)
+
+	if primary.status != mirror.status {
+		atomic.AddInt64(&s.statusDiff, 1)
+		logger.Warnw("mirror status diff", "cluster", cluster, "path", path, "primary", primary.status, "mirror", mirror.status)
+	}
+	if diffs := diffBody(primary.body, mirror.body); len(diffs) > 0 {
+		atomic.AddInt64(&s.bodyDiff, 1)
+		logger.Warnw("mirror body diff", "cluster", cluster, "path", path, "diffs", diffs)
+	}
+}
+
+// diffBody compare the structure of json bodies, the values are not compared as they may vary by request,
+// like the timestamps. The non json bodies are compared byte by byte.
+func diffBody(primary, mirror []byte) []string {
+	var p, m interface{}
+	if json.Unmarshal(primary, &p) != nil || json.Unmarshal(mirror, &m) != nil {
+		if bytes.Equal(primary, mirror) {
+			return nil
+		}
+		return []string{"$"}
+	}
+	var diffs []string
+	diffStructure("$", p, m, &diffs)
+	return diffs
+}
+
+func diffStructure(path string, p, m interface{}, diffs *[]string) {
+	switch pv := p.(type) {
+	case map[string]interface{}:
+		mv, ok := m.(map[string]interface{})
+		if !ok {
+			*diffs = append(*diffs, path)
+			return
+		}
+		keys := make([]string, 0, len(pv)+len(mv))
+		for k := range pv {
+			keys = append(keys, k)
+		}
+		for k := range mv {
+			if _, ok := pv[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub := path + "." + k
+			pc, inP := pv[k]
+			mc, inM := mv[k]
+			if !inP || !inM {
+				*diffs = append(*diffs, sub)
+				continue
+			}
+			diffStructure(sub, pc, mc, diffs)
+		}
+	case []interface{}:
+		mv, ok := m.([]interface{})
+		if !ok {
+			*diffs = append(*diffs, path)
+			return
+		}
+		for i := 0; i < len(pv) && i < len(mv); i++ {
+			diffStructure(fmt.Sprintf("%s[%d]", path, i), pv[i], mv[i], diffs)
+		}
+	default:
+		if fmt.Sprintf("%T", p) != fmt.Sprintf("%T", m) {
+			*diffs = append(*diffs, path)
+		}
+	}
+}
+
+// registerMirrorMetric publish the comparison counters by mirror cluster
+func registerMirrorMetric() {
+	meter := global.GetMeterProvider().Meter("pixiu")
+	observe := func(value func(s *clusterStats) int64) func(context.Context, metric.Int64ObserverResult) {
+		return func(_ context.Context, result metric.Int64ObserverResult) {
+			stats.Range(func(k, v interface{}) bool {
+				result.Observe(value(v.(*clusterStats)), attribute.String("cluster", k.(string)))
+				return true
+			})
+		}
+	}
+	_ = metric.Must(meter).NewInt64SumObserver("pixiu_mirror_compared",
+		observe(func(s *clusterStats) int64 { return atomic.LoadInt64(&s.compared) }),
+		metric.WithDescription("responses of mirror compared with the primary ones"),
+	)
+	_ = metric.Must(meter).NewInt64SumObserver("pixiu_mirror_status_diff",
+		observe(func(s *clusterStats) int64 { return atomic.LoadInt64(&s.statusDiff) }),
+		metric.WithDescription("responses of mirror with a different status"),
+	)
+	_ = metric.Must(meter).NewInt64SumObserver("pixiu_mirror_body_diff",
+		observe(func(s *clusterStats) int64 { return atomic.LoadInt64(&s.bodyDiff) }),
+		metric.WithDescription("responses of mirror with a different body structure"),
+	)
+	_ = metric.Must(meter).NewInt64SumObserver("pixiu_mirror_failed",
+		observe(func(s *clusterStats) int64 { return atomic.LoadInt64(&s.failed) }),
+		metric.WithDescription("mirror requests failed"),
+	)
+}
@@ -0,0 +1,247 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mirror
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	stdHttp "net/http"
+	"net/url"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPMirrorFilter
+
+	// mirrorHeader tell the mirror upstream the request is a copy
+	mirrorHeader = "X-Pixiu-Mirror"
+
+	defaultTimeout = 5 * time.Second
+)
+
+// pickEndpoint pick an endpoint from the cluster manager
+var pickEndpoint = func(clusterName string) *model.Endpoint {
+	return server.GetClusterManager().PickEndpoint(clusterName)
+}
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg    *Config
+		client *stdHttp.Client
+		// policy the default policy from config, nil if no shadow cluster
+		policy *model.MirrorPolicy
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		client   *stdHttp.Client
+		fallback *model.MirrorPolicy
+		policy   *model.MirrorPolicy
+		// result the response of mirror, only set when the responses are compared
+		result chan *mirrorResult
+	}
+
+	// Config describe the config of FilterFactory, it shadows the routes without their own mirror policy
+	Config struct {
+		// Cluster the shadow cluster, the filter only mirrors the routes with mirror policy if empty
+		Cluster string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+		// Percent the percentage of the requests shadowed, 100 if not set
+		Percent float64 `yaml:"percent" json:"percent" mapstructure:"percent"`
+		// Compare compare the response of shadow with the primary one and log the diffs
+		Compare bool `yaml:"compare" json:"compare" mapstructure:"compare"`
+		// Timeout the timeout of the shadow request, independent of the primary one, 5s by default
+		Timeout string `yaml:"timeout" json:"timeout" mapstructure:"timeout"`
+	}
+
+	mirrorResult struct {
+		status int
+		body   []byte
+		err    error
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter reads the request body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	timeout := defaultTimeout
+	if cfg.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(cfg.Timeout); err != nil {
+			return errors.Wrap(err, "mirror timeout parse fail")
+		}
+	}
+	if cfg.Percent < 0 || cfg.Percent > 100 {
+		return errors.Errorf("invalid mirror percent %v", cfg.Percent)
+	}
+	// the shadow request never shares the deadline of the primary one
+	factory.client = &stdHttp.Client{Timeout: timeout}
+	if cfg.Cluster != "" {
+		factory.policy = &model.MirrorPolicy{Cluster: cfg.Cluster, Percent: cfg.Percent, Compare: cfg.Compare}
+	}
+	metricOnce.Do(registerMirrorMetric)
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{client: factory.client, fallback: factory.policy}
+	chain.AppendDecodeFilters(f)
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+// Decode send the copy of the sampled request to the mirror cluster in background
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	policy := f.fallback
+	if ra := ctx.GetRouteEntry(); ra != nil && ra.Mirror != nil {
+		policy = ra.Mirror
+	}
+	if policy == nil || policy.Cluster == "" || !sampled(policy.Percent) {
+		return filter.Continue
+	}
+
+	req, err := mirrorRequest(ctx.Request)
+	if err != nil {
+		logger.Warnw("mirror copy request fail", "error", err.Error())
+		return filter.Continue
+	}
+	f.policy = policy
+	if f.policy.Compare {
+		f.result = make(chan *mirrorResult, 1)
+	}
+	go f.send(req, f.policy.Cluster, f.result)
+	return filter.Continue
+}
+
+// Encode compare the responses in background, so that the client never waits for the mirror
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.result == nil {
+		return filter.Continue
+	}
+	primary := &mirrorResult{status: ctx.GetStatusCode()}
+	if ctx.TargetResp != nil {
+		primary.body = append([]byte(nil), ctx.TargetResp.Data...)
+	}
+	cluster, path := f.policy.Cluster, ctx.GetUrl()
+	go func(result chan *mirrorResult) {
+		compare(cluster, path, primary, <-result)
+	}(f.result)
+	return filter.Continue
+}
+
+func (f *Filter) send(req *stdHttp.Request, cluster string, result chan *mirrorResult) {
+	r := &mirrorResult{}
+	defer func() {
+		if result != nil {
+			result <- r
+		}
+	}()
+
+	endpoint := pickEndpoint(cluster)
+	if endpoint == nil {
+		r.err = errors.Errorf("mirror cluster %s not found endpoint", cluster)
+		logger.Warnw("mirror cluster not found endpoint", "cluster", cluster)
+		return
+	}
+	defer loadbalancer.Begin(cluster, endpoint)()
+	req.URL.Host = endpoint.Address.GetAddress()
+	req.Host = req.URL.Host
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		r.err = err
+		logger.Warnw("mirror request fail", "cluster", cluster, "error", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	r.status = resp.StatusCode
+	if result != nil {
+		r.body, r.err = ioutil.ReadAll(resp.Body)
+	}
+}
+
+// mirrorRequest copy the request, the body is buffered and restored for the primary upstream,
+// so that both upstreams receive the identical payload
+func mirrorRequest(r *stdHttp.Request) (*stdHttp.Request, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+	}
+
+	u := url.URL{Scheme: "http", Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+	req, err := stdHttp.NewRequest(r.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+	req.Header.Set(mirrorHeader, "true")
+	return req, nil
+}
+
+func sampled(percent float64) bool {
+	if percent <= 0 || percent >= 100 {
+		return true
+	}
+	return rand.Float64()*100 < percent
+}
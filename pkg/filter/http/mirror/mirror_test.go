@@ -0,0 +1,178 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mirror
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	stdHttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// mirrorUpstream reply the mirrored request with the status and body
+func mirrorUpstream(t *testing.T, status int, body string) *httptest.Server {
+	return httptest.NewServer(stdHttp.HandlerFunc(func(w stdHttp.ResponseWriter, r *stdHttp.Request) {
+		assert.Equal(t, "true", r.Header.Get(mirrorHeader))
+		payload, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, `{"id":"12345"}`, string(payload))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func mockEndpoint(t *testing.T, s *httptest.Server) *model.Endpoint {
+	host, port, err := net.SplitHostPort(s.Listener.Addr().String())
+	assert.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	assert.NoError(t, err)
+	return &model.Endpoint{Address: model.SocketAddress{Address: host, Port: p}}
+}
+
+func TestMirrorCompare(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		statusDiff int64
+		bodyDiff   int64
+	}{
+		{name: "same", status: stdHttp.StatusOK, body: `{"id":"12345","name":"other"}`},
+		{name: "status diverge", status: stdHttp.StatusInternalServerError, body: `{"id":"12345","name":"tc"}`, statusDiff: 1},
+		{name: "body diverge", status: stdHttp.StatusOK, body: `{"id":12345}`, bodyDiff: 1},
+	}
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := mirrorUpstream(t, tt.status, tt.body)
+			defer upstream.Close()
+			origin := pickEndpoint
+			pickEndpoint = func(clusterName string) *model.Endpoint {
+				return mockEndpoint(t, upstream)
+			}
+			defer func() { pickEndpoint = origin }()
+
+			request, err := stdHttp.NewRequest("POST", "http://www.dubbogopixiu.com/mock/test", bytes.NewReader([]byte(`{"id":"12345"}`)))
+			assert.NoError(t, err)
+			ctx := mock.GetMockHTTPContext(request)
+			cluster := "mirror-" + tt.name
+			ctx.RouteEntry(&model.RouteAction{Cluster: "primary", Mirror: &model.MirrorPolicy{Cluster: cluster, Compare: true}})
+
+			chain := filter.NewDefaultFilterChain()
+			_ = factory.PrepareFilterChain(ctx, chain)
+			chain.OnDecode(ctx)
+
+			// the primary upstream receives the same body
+			body, _ := ioutil.ReadAll(ctx.Request.Body)
+			assert.Equal(t, `{"id":"12345"}`, string(body))
+			ctx.StatusCode(stdHttp.StatusOK)
+			ctx.TargetResp = &client.Response{Data: []byte(`{"id":"12345","name":"tc"}`)}
+			chain.OnEncode(ctx)
+
+			s := statsOf(cluster)
+			assert.Eventually(t, func() bool { return atomic.LoadInt64(&s.compared) == 1 }, time.Second, 10*time.Millisecond)
+			assert.Equal(t, tt.statusDiff, atomic.LoadInt64(&s.statusDiff))
+			assert.Equal(t, tt.bodyDiff, atomic.LoadInt64(&s.bodyDiff))
+		})
+	}
+}
+
+func TestShadowByConfig(t *testing.T) {
+	var shadowed int64
+	upstream := httptest.NewServer(stdHttp.HandlerFunc(func(w stdHttp.ResponseWriter, r *stdHttp.Request) {
+		payload, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, `{"id":"12345"}`, string(payload))
+		atomic.AddInt64(&shadowed, 1)
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer upstream.Close()
+	origin := pickEndpoint
+	pickEndpoint = func(clusterName string) *model.Endpoint {
+		return mockEndpoint(t, upstream)
+	}
+	defer func() { pickEndpoint = origin }()
+
+	factory := &FilterFactory{cfg: &Config{Cluster: "shadow", Compare: true, Timeout: "50ms"}}
+	assert.Nil(t, factory.Apply())
+
+	request, err := stdHttp.NewRequest("POST", "http://www.dubbogopixiu.com/mock/test", bytes.NewReader([]byte(`{"id":"12345"}`)))
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(&model.RouteAction{Cluster: "primary"})
+
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	start := time.Now()
+	chain.OnDecode(ctx)
+	ctx.StatusCode(stdHttp.StatusOK)
+	ctx.TargetResp = &client.Response{Data: []byte("{}")}
+	chain.OnEncode(ctx)
+	// the slow shadow never delays the primary path
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	// the shadow request times out by its own timeout
+	s := statsOf("shadow")
+	assert.Eventually(t, func() bool { return atomic.LoadInt64(&s.failed) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&shadowed))
+	assert.Equal(t, int64(0), atomic.LoadInt64(&s.compared))
+}
+
+func TestApplyInvalid(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{Timeout: "soon"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Percent: 120}}).Apply())
+}
+
+func TestMirrorNotSampled(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+
+	request, err := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.RouteEntry(&model.RouteAction{Cluster: "primary"})
+
+	f := &Filter{client: factory.client}
+	f.Decode(ctx)
+	assert.Nil(t, f.policy)
+	assert.Nil(t, f.result)
+}
+
+func TestDiffBody(t *testing.T) {
+	assert.Empty(t, diffBody([]byte(`{"a":1,"b":[{"c":"x"}]}`), []byte(`{"b":[{"c":"y"}],"a":2}`)))
+	assert.Equal(t, []string{"$.a", "$.b[0].c", "$.d"},
+		diffBody([]byte(`{"a":1,"b":[{"c":"x"}]}`), []byte(`{"a":"1","b":[{"c":true}],"d":null}`)))
+	assert.Equal(t, []string{"$"}, diffBody([]byte("plain"), []byte("other")))
+	assert.Empty(t, diffBody([]byte("plain"), []byte("plain")))
+}
@@ -0,0 +1,238 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package negotiate
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPNegotiateFilter
+
+	mediaTypeJSON = "application/json"
+	mediaTypeXML  = "application/xml"
+
+	defaultRoot = "response"
+	defaultItem = "item"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// MediaTypes the supported media types, application/json and application/xml by default
+		MediaTypes []string `yaml:"media_types" json:"media_types" mapstructure:"media_types"`
+		// Default the media type used when the Accept header is absent or not acceptable, application/json by default
+		Default string `yaml:"default" json:"default" mapstructure:"default"`
+		// Root the root element name of xml, response by default
+		Root string `yaml:"root" json:"root" mapstructure:"root"`
+		// Item the element name of the array elements in xml, item by default
+		Item string `yaml:"item" json:"item" mapstructure:"item"`
+	}
+
+	// accepted the media range of the Accept header
+	accepted struct {
+		mediaType string
+		q         float64
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if len(cfg.MediaTypes) == 0 {
+		cfg.MediaTypes = []string{mediaTypeJSON, mediaTypeXML}
+	}
+	for _, mt := range cfg.MediaTypes {
+		if mt != mediaTypeJSON && mt != mediaTypeXML {
+			return errors.Errorf("unsupported media type %s", mt)
+		}
+	}
+	if cfg.Default == "" {
+		cfg.Default = mediaTypeJSON
+	}
+	if !contains(cfg.MediaTypes, cfg.Default) {
+		return errors.Errorf("default media type %s is not in media types", cfg.Default)
+	}
+	if cfg.Root == "" {
+		cfg.Root = defaultRoot
+	}
+	if cfg.Item == "" {
+		cfg.Item = defaultItem
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+// Encode serialize the json response as the negotiated media type
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if ctx.LocalReply() || ctx.TargetResp == nil {
+		return filter.Continue
+	}
+	header := ctx.Writer.Header()
+	if !isJSON(header.Get(constant.HeaderKeyContextType)) {
+		return filter.Continue
+	}
+
+	header.Add("Vary", "Accept")
+	mt := f.negotiate(ctx.GetHeader("Accept"))
+	if mt != mediaTypeXML {
+		return filter.Continue
+	}
+
+	data, err := jsonToXML(ctx.TargetResp.Data, f.cfg.Root, f.cfg.Item)
+	if err != nil {
+		logger.Warnw("negotiate filter keep json response", "url", ctx.GetUrl(), "error", err.Error())
+		return filter.Continue
+	}
+	ctx.TargetResp.Data = data
+	// the upstream length is of the json body
+	header.Del("Content-Length")
+	header.Set(constant.HeaderKeyContextType, mediaTypeXML+";charset=UTF-8")
+	return filter.Continue
+}
+
+// negotiate pick the supported media type of the highest quality, the default one wins the tie
+func (f *Filter) negotiate(accept string) string {
+	if accept == "" {
+		return f.cfg.Default
+	}
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, mt := range f.candidates() {
+		q := quality(ranges, mt)
+		if q > bestQ {
+			best, bestQ = mt, q
+		}
+	}
+	if best == "" {
+		return f.cfg.Default
+	}
+	return best
+}
+
+// candidates the supported media types with the default one first
+func (f *Filter) candidates() []string {
+	c := []string{f.cfg.Default}
+	for _, mt := range f.cfg.MediaTypes {
+		if mt != f.cfg.Default {
+			c = append(c, mt)
+		}
+	}
+	return c
+}
+
+// quality the q of the most specific media range matching the media type
+func quality(ranges []accepted, mediaType string) float64 {
+	typ := mediaType[:strings.IndexByte(mediaType, '/')]
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.mediaType == mediaType:
+			s = 2
+		case r.mediaType == typ+"/*":
+			s = 1
+		case r.mediaType == "*/*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+func parseAccept(accept string) []accepted {
+	var ranges []accepted
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, accepted{mediaType: mt, q: q})
+	}
+	return ranges
+}
+
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == mediaTypeJSON || strings.HasSuffix(mt, "+json"))
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
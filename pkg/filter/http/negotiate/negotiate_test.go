@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package negotiate
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+const studentJSON = `{"id":"001","name":"tc & co","age":18,"classes":["math","art"],"time":null}`
+
+const studentXML = `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+	`<response><age>18</age><classes><item>math</item><item>art</item></classes><id>001</id><name>tc &amp; co</name><time/></response>`
+
+func TestNegotiate(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{cfg: factory.cfg}
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+	}{
+		{name: "no accept", accept: "", contentType: constant.HeaderValueJsonUtf8, body: studentJSON},
+		{name: "xml", accept: "application/xml", contentType: "application/xml;charset=UTF-8", body: studentXML},
+		{name: "xml preferred", accept: "application/json;q=0.5, application/xml", contentType: "application/xml;charset=UTF-8", body: studentXML},
+		{name: "json preferred", accept: "application/xml;q=0.8, application/json", contentType: constant.HeaderValueJsonUtf8, body: studentJSON},
+		{name: "wildcard", accept: "*/*", contentType: constant.HeaderValueJsonUtf8, body: studentJSON},
+		{name: "not acceptable fallback", accept: "text/csv", contentType: constant.HeaderValueJsonUtf8, body: studentJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/student/001", nil)
+			assert.NoError(t, err)
+			if tt.accept != "" {
+				request.Header.Set("Accept", tt.accept)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			ctx.Writer.Header().Set(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+			ctx.Writer.Header().Set("Content-Length", strconv.Itoa(len(studentJSON)))
+			ctx.TargetResp = &client.Response{Data: []byte(studentJSON)}
+
+			f.Encode(ctx)
+			assert.Equal(t, tt.contentType, ctx.Writer.Header().Get(constant.HeaderKeyContextType))
+			assert.Equal(t, tt.body, string(ctx.TargetResp.Data))
+			if tt.body == studentJSON {
+				assert.Equal(t, strconv.Itoa(len(studentJSON)), ctx.Writer.Header().Get("Content-Length"))
+			} else {
+				assert.Empty(t, ctx.Writer.Header().Get("Content-Length"))
+			}
+			assert.Equal(t, "Accept", ctx.Writer.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestElementName(t *testing.T) {
+	assert.Equal(t, "userName", elementName("userName"))
+	assert.Equal(t, "_1st", elementName("1st"))
+	assert.Equal(t, "a_b", elementName("a b"))
+	assert.Equal(t, "_xmlns", elementName("xmlns"))
+	assert.Equal(t, "_", elementName(""))
+}
+
+func TestApplyInvalid(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{MediaTypes: []string{"text/csv"}}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{MediaTypes: []string{mediaTypeJSON}, Default: mediaTypeXML}}).Apply())
+}
@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package negotiate
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+// jsonToXML convert the json document to xml, the object keys become the element names
+// and the array elements are wrapped by item elements
+func jsonToXML(data []byte, root, item string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, errors.Wrap(err, "invalid json response")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := writeElement(&buf, elementName(root), v, item); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeElement(buf *bytes.Buffer, name string, v interface{}, item string) error {
+	if v == nil {
+		buf.WriteString("<" + name + "/>")
+		return nil
+	}
+	buf.WriteString("<" + name + ">")
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := writeElement(buf, elementName(k), val[k], item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, e := range val {
+			if err := writeElement(buf, item, e, item); err != nil {
+				return err
+			}
+		}
+	case string:
+		if err := xml.EscapeText(buf, []byte(val)); err != nil {
+			return err
+		}
+	case json.Number:
+		buf.WriteString(val.String())
+	case bool:
+		if val {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	}
+	buf.WriteString("</" + name + ">")
+	return nil
+}
+
+// elementName make the json key a valid xml name, the invalid chars are replaced by _
+func elementName(key string) string {
+	if key == "" {
+		return "_"
+	}
+	var b strings.Builder
+	for i, r := range key {
+		valid := r == '_' || unicode.IsLetter(r) || (i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'))
+		if !valid {
+			if i == 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+				b.WriteRune('_')
+				b.WriteRune(r)
+				continue
+			}
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+	// the names starting with xml are reserved
+	if strings.HasPrefix(strings.ToLower(name), "xml") {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,181 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nonce
+
+import (
+	"encoding/json"
+	stdHttp "net/http"
+	"strconv"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/http/quota"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPNonceFilter
+
+	defaultNonceHeader     = "X-Nonce"
+	defaultTimestampHeader = "X-Timestamp"
+	defaultTTL             = 5 * time.Minute
+
+	keyPrefix = "nonce:"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+// now the clock, replaced in tests
+var now = time.Now
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg   *Config
+		ttl   time.Duration
+		store quota.Store
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg   *Config
+		ttl   time.Duration
+		store quota.Store
+	}
+
+	// Config describe the config of FilterFactory. The nonce and timestamp headers should be
+	// covered by the request signature, otherwise the client can simply regenerate them.
+	Config struct {
+		// NonceHeader the header of the unique nonce, X-Nonce by default
+		NonceHeader string `yaml:"nonce_header" json:"nonce_header" mapstructure:"nonce_header"`
+		// TimestampHeader the header of the unix timestamp in seconds, X-Timestamp by default
+		TimestampHeader string `yaml:"timestamp_header" json:"timestamp_header" mapstructure:"timestamp_header"`
+		// TTL how long the seen nonce is kept, the timestamp out of TTL is rejected as well, 5m by default
+		TTL string `yaml:"ttl" json:"ttl" mapstructure:"ttl"`
+		// Store the name of store registered by quota.RegisterStore, memory by default
+		Store string `yaml:"store" json:"store" mapstructure:"store"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the replay is rejected before the body is read
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageAuth
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.NonceHeader == "" {
+		cfg.NonceHeader = defaultNonceHeader
+	}
+	if cfg.TimestampHeader == "" {
+		cfg.TimestampHeader = defaultTimestampHeader
+	}
+	factory.ttl = defaultTTL
+	if cfg.TTL != "" {
+		ttl, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return errors.Wrap(err, "nonce ttl parse fail")
+		}
+		if ttl <= 0 {
+			return errors.Errorf("invalid nonce ttl %s", cfg.TTL)
+		}
+		factory.ttl = ttl
+	}
+
+	// the store outlives the reload, otherwise every nonce seen is forgotten on a config change
+	store, err := quota.KeepStore(Kind, cfg.Store)
+	if err != nil {
+		return err
+	}
+	factory.store = store
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, ttl: factory.ttl, store: factory.store}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	nonce := ctx.GetHeader(f.cfg.NonceHeader)
+	if nonce == "" {
+		return f.reject(ctx, "nonce required")
+	}
+	ts, err := strconv.ParseInt(ctx.GetHeader(f.cfg.TimestampHeader), 10, 64)
+	if err != nil {
+		return f.reject(ctx, "invalid timestamp")
+	}
+	t := now()
+	skew := t.Sub(time.Unix(ts, 0))
+	if skew > f.ttl || skew < -f.ttl {
+		return f.reject(ctx, "timestamp expired")
+	}
+
+	// the nonce is scoped by the caller identity set by the auth filters, so that one caller can't burn
+	// the nonce of another one. the first request of the nonce gets count 1, the store keeps it as long as
+	// its timestamp is accepted, the timestamp dated in the future is accepted until ts+ttl, plus a second
+	// of the timestamp precision
+	caller, _ := ctx.Params[constant.AuthUserParam].(string)
+	count, err := f.store.Incr(keyPrefix+caller+"/"+nonce, time.Unix(ts, 0).Add(f.ttl+time.Second))
+	if err != nil {
+		// fail closed, the replay can't be detected without the store
+		logger.Warnw("nonce store incr fail", "error", err.Error())
+		bt, _ := json.Marshal(http.ErrResponse{Message: "nonce store unavailable"})
+		ctx.SendLocalReply(stdHttp.StatusServiceUnavailable, bt)
+		return filter.Stop
+	}
+	if count > 1 {
+		logger.Debugw("replayed nonce", "nonce", nonce, "caller", caller, "url", ctx.GetUrl())
+		return f.reject(ctx, "replayed request")
+	}
+	return filter.Continue
+}
+
+func (f *Filter) reject(ctx *http.HttpContext, msg string) filter.FilterStatus {
+	bt, _ := json.Marshal(http.ErrResponse{Message: msg})
+	ctx.SendLocalReply(stdHttp.StatusUnauthorized, bt)
+	return filter.Stop
+}
@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nonce
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func decode(t *testing.T, f *Filter, caller, nonce string, ts int64) (filter.FilterStatus, int) {
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/transfer", nil)
+	assert.NoError(t, err)
+	if nonce != "" {
+		request.Header.Set(defaultNonceHeader, nonce)
+	}
+	request.Header.Set(defaultTimestampHeader, strconv.FormatInt(ts, 10))
+	ctx := mock.GetMockHTTPContext(request)
+	if caller != "" {
+		ctx.Params = map[string]interface{}{constant.AuthUserParam: caller}
+	}
+	return f.Decode(ctx), ctx.GetStatusCode()
+}
+
+func TestReplay(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{TTL: "1m"}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{cfg: factory.cfg, ttl: factory.ttl, store: factory.store}
+
+	ts := time.Now().Unix()
+	status, _ := decode(t, f, "replay", "n-1", ts)
+	assert.Equal(t, filter.Continue, status)
+
+	// replay within ttl
+	status, code := decode(t, f, "replay", "n-1", ts)
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	status, _ = decode(t, f, "replay", "n-2", ts)
+	assert.Equal(t, filter.Continue, status)
+}
+
+func TestInvalidRequest(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{TTL: "1m"}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{cfg: factory.cfg, ttl: factory.ttl, store: factory.store}
+
+	n := time.Unix(1650000000, 0)
+	now = func() time.Time { return n }
+	defer func() { now = time.Now }()
+
+	status, _ := decode(t, f, "invalid", "", n.Unix())
+	assert.Equal(t, filter.Stop, status)
+
+	// the stale timestamp is rejected, since its nonce may have been forgotten
+	status, _ = decode(t, f, "invalid", "n-1", n.Add(-2*time.Minute).Unix())
+	assert.Equal(t, filter.Stop, status)
+
+	status, _ = decode(t, f, "invalid", "n-1", n.Add(30*time.Second).Unix())
+	assert.Equal(t, filter.Continue, status)
+}
+
+// expiryStore record the expiry of the nonce
+type expiryStore struct {
+	expireAt time.Time
+}
+
+func (s *expiryStore) Incr(key string, expireAt time.Time) (int64, error) {
+	s.expireAt = expireAt
+	return 1, nil
+}
+
+func (s *expiryStore) Get(key string) (int64, error) {
+	return 0, nil
+}
+
+func TestNonceKeptWhileAccepted(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{TTL: "1m"}}
+	assert.Nil(t, factory.Apply())
+	store := &expiryStore{}
+	f := &Filter{cfg: factory.cfg, ttl: factory.ttl, store: store}
+
+	n := time.Unix(1650000000, 0)
+	now = func() time.Time { return n }
+	defer func() { now = time.Now }()
+
+	// the timestamp dated in the future is accepted until ts+ttl, the nonce must be kept until then
+	ts := n.Add(50 * time.Second)
+	status, _ := decode(t, f, "kept", "n-1", ts.Unix())
+	assert.Equal(t, filter.Continue, status)
+	assert.True(t, store.expireAt.After(ts.Add(time.Minute)))
+}
+
+func TestApplyInvalid(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{TTL: "-1s"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Store: "not-exist"}}).Apply())
+}
+
+func TestNonceScopedByCaller(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{TTL: "1m"}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{cfg: factory.cfg, ttl: factory.ttl, store: factory.store}
+
+	ts := time.Now().Unix()
+	status, _ := decode(t, f, "alice", "n-1", ts)
+	assert.Equal(t, filter.Continue, status)
+
+	// the nonce of alice is not burnt for bob
+	status, _ = decode(t, f, "bob", "n-1", ts)
+	assert.Equal(t, filter.Continue, status)
+
+	status, _ = decode(t, f, "alice", "n-1", ts)
+	assert.Equal(t, filter.Stop, status)
+}
+
+func TestKeepStoreAcrossApply(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{TTL: "1m"}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{cfg: factory.cfg, ttl: factory.ttl, store: factory.store}
+
+	ts := time.Now().Unix()
+	status, _ := decode(t, f, "reload", "n-1", ts)
+	assert.Equal(t, filter.Continue, status)
+
+	// the nonce seen before the reload is still rejected
+	reloaded := &FilterFactory{cfg: &Config{TTL: "2m"}}
+	assert.Nil(t, reloaded.Apply())
+	f = &Filter{cfg: reloaded.cfg, ttl: reloaded.ttl, store: reloaded.store}
+	status, _ = decode(t, f, "reload", "n-1", ts)
+	assert.Equal(t, filter.Stop, status)
+}
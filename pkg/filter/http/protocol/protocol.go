@@ -0,0 +1,226 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	stdHttp "net/http"
+	"net/textproto"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPProtocolFilter
+)
+
+// hopHeaders the connection specific headers, they are meaningless to the next hop and forbidden by http2
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Upstream the protocol speaking to the upstream, http1 or h2c, the protocol of client is not cared
+		Upstream string `yaml:"upstream" json:"upstream" mapstructure:"upstream"`
+		// Clusters override the upstream protocol by cluster name
+		Clusters map[string]string `yaml:"clusters" json:"clusters" mapstructure:"clusters"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter reads the request body when the request carries trailers
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Upstream == "" {
+		cfg.Upstream = constant.UpstreamProtocolHTTP1
+	}
+	if err := checkProtocol(cfg.Upstream); err != nil {
+		return err
+	}
+	for cluster, p := range cfg.Clusters {
+		if err := checkProtocol(p); err != nil {
+			return errors.Wrapf(err, "cluster %s", cluster)
+		}
+	}
+	return nil
+}
+
+func checkProtocol(p string) error {
+	switch p {
+	case constant.UpstreamProtocolHTTP1, constant.UpstreamProtocolH2C:
+		return nil
+	default:
+		return errors.Errorf("unsupported upstream protocol %s", p)
+	}
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendDecodeFilters(f)
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+// Decode translate the request for the upstream protocol, the proxy filter picks the transport by it
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	proto := f.cfg.Upstream
+	if ra := ctx.GetRouteEntry(); ra != nil {
+		if p, ok := f.cfg.Clusters[ra.Cluster]; ok {
+			proto = p
+		}
+	}
+
+	r := ctx.Request
+	removeHopHeaders(r.Header)
+	if proto == constant.UpstreamProtocolHTTP1 && len(r.Trailer) > 0 {
+		// the legacy http1 upstream hardly reads chunked trailers, send them as headers instead
+		if err := mergeTrailers(r); err != nil {
+			logger.Warnw("protocol filter read request body fail", "error", err.Error())
+			return filter.Abort(ctx, &filter.AbortResponse{Status: stdHttp.StatusBadRequest})
+		}
+	}
+
+	if ctx.Params == nil {
+		ctx.Params = make(map[string]interface{})
+	}
+	ctx.Params[constant.UpstreamProtocolParam] = proto
+	return filter.Continue
+}
+
+// Encode drop the connection headers of the upstream and forward its trailers to the client
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	header := ctx.Writer.Header()
+	removeHopHeaders(header)
+	header.Del("Trailer")
+	addTrailers(ctx)
+	return filter.Continue
+}
+
+// EncodeStream remove the hop-by-hop headers as Encode, the trailers are added after the streamed body is written
+func (f *Filter) EncodeStream(ctx *http.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	header := ctx.Writer.Header()
+	removeHopHeaders(header)
+	header.Del("Trailer")
+	return filter.CloseFuncWriter(w, func() { addTrailers(ctx) }), filter.Continue
+}
+
+// addTrailers add the trailers of upstream response to client, they are filled after the body is read
+func addTrailers(ctx *http.HttpContext) {
+	resp, ok := ctx.SourceResp.(*stdHttp.Response)
+	if !ok {
+		return
+	}
+	header := ctx.Writer.Header()
+	for k, vv := range resp.Trailer {
+		for _, v := range vv {
+			header.Add(stdHttp.TrailerPrefix+k, v)
+		}
+	}
+}
+
+// removeHopHeaders remove the hop-by-hop headers, including the ones listed by the Connection header
+func removeHopHeaders(h stdHttp.Header) {
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			if name = textproto.TrimString(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+	// only "TE: trailers" is allowed in http2
+	if te := h.Get("Te"); te != "" {
+		h.Del("Te")
+		if strings.Contains(strings.ToLower(te), "trailers") {
+			h.Set("Te", "trailers")
+		}
+	}
+}
+
+// mergeTrailers buffer the body so that the trailers are received, then move them into the headers
+func mergeTrailers(r *stdHttp.Request) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+
+	for k, vv := range r.Trailer {
+		for _, v := range vv {
+			r.Header.Add(k, v)
+		}
+	}
+	r.Trailer = nil
+	return nil
+}
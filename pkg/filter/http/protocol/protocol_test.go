@@ -0,0 +1,146 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"bytes"
+	"io/ioutil"
+	stdHttp "net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestRemoveHopHeaders(t *testing.T) {
+	h := stdHttp.Header{}
+	h.Set("Connection", "keep-alive, X-Hop")
+	h.Set("X-Hop", "1")
+	h.Set("Keep-Alive", "timeout=5")
+	h.Set("Upgrade", "h2c")
+	h.Set("Te", "gzip, trailers")
+	h.Set("X-Keep", "1")
+
+	removeHopHeaders(h)
+	assert.Equal(t, stdHttp.Header{"Te": {"trailers"}, "X-Keep": {"1"}}, h)
+
+	h = stdHttp.Header{"Te": {"gzip"}}
+	removeHopHeaders(h)
+	assert.Empty(t, h)
+}
+
+func TestDecode(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Clusters: map[string]string{"modern": constant.UpstreamProtocolH2C}}}
+	assert.Nil(t, factory.Apply())
+
+	tests := []struct {
+		name    string
+		cluster string
+		proto   string
+		merged  bool
+	}{
+		{name: "downgrade merge trailers", cluster: "legacy", proto: constant.UpstreamProtocolHTTP1, merged: true},
+		{name: "upgrade keep trailers", cluster: "modern", proto: constant.UpstreamProtocolH2C, merged: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := stdHttp.NewRequest("POST", "http://www.dubbogopixiu.com/mock/test", bytes.NewReader([]byte("payload")))
+			assert.NoError(t, err)
+			request.Header.Set("Connection", "Upgrade")
+			request.Header.Set("Upgrade", "h2c")
+			request.Trailer = stdHttp.Header{"X-Checksum": {"abc"}}
+			ctx := mock.GetMockHTTPContext(request)
+			ctx.RouteEntry(&model.RouteAction{Cluster: tt.cluster})
+
+			chain := filter.NewDefaultFilterChain()
+			_ = factory.PrepareFilterChain(ctx, chain)
+			chain.OnDecode(ctx)
+
+			assert.Equal(t, tt.proto, ctx.Params[constant.UpstreamProtocolParam])
+			assert.Empty(t, request.Header.Get("Connection"))
+			assert.Empty(t, request.Header.Get("Upgrade"))
+			if tt.merged {
+				assert.Equal(t, "abc", request.Header.Get("X-Checksum"))
+				assert.Nil(t, request.Trailer)
+			} else {
+				assert.Empty(t, request.Header.Get("X-Checksum"))
+				assert.Equal(t, "abc", request.Trailer.Get("X-Checksum"))
+			}
+			body, _ := ioutil.ReadAll(request.Body)
+			assert.Equal(t, "payload", string(body))
+		})
+	}
+}
+
+func TestEncodeForwardTrailers(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+
+	request, err := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.AddHeader("Keep-Alive", "timeout=5")
+	ctx.SourceResp = &stdHttp.Response{Trailer: stdHttp.Header{"Grpc-Status": {"0"}}}
+
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnEncode(ctx)
+
+	assert.Empty(t, ctx.Writer.Header().Get("Keep-Alive"))
+	assert.Equal(t, "0", ctx.Writer.Header().Get(stdHttp.TrailerPrefix+"Grpc-Status"))
+}
+
+func TestEncodeStreamForwardTrailers(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+
+	request, err := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.AddHeader("Keep-Alive", "timeout=5")
+	resp := &stdHttp.Response{Trailer: stdHttp.Header{}}
+	ctx.SourceResp = resp
+
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	sc := chain.(filter.StreamFilterChain)
+	assert.True(t, sc.Streamable())
+	w := sc.OnEncodeStream(ctx, &bytes.Buffer{})
+	assert.Empty(t, ctx.Writer.Header().Get("Keep-Alive"))
+
+	// the trailers are filled once the upstream body is read
+	resp.Trailer.Set("Grpc-Status", "0")
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "0", ctx.Writer.Header().Get(stdHttp.TrailerPrefix+"Grpc-Status"))
+}
+
+func TestApplyInvalidProtocol(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Upstream: "spdy"}}
+	assert.Error(t, factory.Apply())
+
+	factory = &FilterFactory{cfg: &Config{Clusters: map[string]string{"c": "h3"}}}
+	assert.Error(t, factory.Apply())
+}
@@ -18,6 +18,7 @@
 package proxyrewrite
 
 import (
+	stdHttp "net/http"
 	"regexp"
 )
 
@@ -47,7 +48,8 @@ type (
 
 	// FilterFactory is http filter instance
 	FilterFactory struct {
-		cfg *Config
+		cfg   *Config
+		rules []*rule
 	}
 	//Filter
 	Filter struct {
@@ -55,11 +57,33 @@ type (
 
 		uriRegex *regexp.Regexp
 		replace  string
+		rules    []*rule
+		// originalURIHeader forward the uri before rewrite to upstream, disabled if empty
+		originalURIHeader string
 	}
 	//Config
 	Config struct {
 		UriRegex []string          `yaml:"uri_regex" json:"uri_regex"`
 		Headers  map[string]string `yaml:"headers" json:"headers"`
+		// Rules rewrite the path by the first matched rule and route the request again, UriRegex is ignored if set
+		Rules []*Rule `yaml:"rules" json:"rules"`
+		// OriginalURIHeader the request header forwarding the uri before rewrite to upstream, e.g. X-Original-URI,
+		// the value sent by client is overwritten, or removed if the uri is not rewritten. It is disabled if empty
+		OriginalURIHeader string `yaml:"original_uri_header" json:"original_uri_header"`
+	}
+
+	// Rule replace the matched part of path, the captured groups can be used in replacement and header values
+	// as $1 or ${name}, e.g. pattern ^/api/v1/students/(\d+)$ and replacement /student/GetStudentByCode/$1
+	Rule struct {
+		Pattern     string            `yaml:"pattern" json:"pattern"`
+		Replacement string            `yaml:"replacement" json:"replacement"`
+		Headers     map[string]string `yaml:"headers" json:"headers"`
+	}
+
+	rule struct {
+		re          *regexp.Regexp
+		replacement string
+		headers     map[string]string
 	}
 )
 
@@ -76,6 +100,18 @@ func (factory *FilterFactory) Config() interface{} {
 }
 
 func (factory *FilterFactory) Apply() error {
+	if len(factory.cfg.Rules) > 0 {
+		rules := make([]*rule, 0, len(factory.cfg.Rules))
+		for _, r := range factory.cfg.Rules {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return errors.Wrapf(err, "rewrite rule %s is invalid", r.Pattern)
+			}
+			rules = append(rules, &rule{re: re, replacement: r.Replacement, headers: r.Headers})
+		}
+		factory.rules = rules
+		return nil
+	}
 	if len(factory.cfg.UriRegex) != 2 {
 		return errors.Errorf("UriRegex len must == 2, %v", factory.cfg.UriRegex)
 	}
@@ -83,30 +119,81 @@ func (factory *FilterFactory) Apply() error {
 }
 
 func (factory *FilterFactory) PrepareFilterChain(ctx *contexthttp.HttpContext, chain filter.FilterChain) error {
+	if len(factory.rules) > 0 {
+		chain.AppendDecodeFilters(&Filter{rules: factory.rules, originalURIHeader: factory.cfg.OriginalURIHeader})
+		return nil
+	}
 	cfg := factory.cfg
 
 	headers := make(map[string]string, len(cfg.Headers))
 	for k, v := range cfg.Headers {
 		headers[k] = v
 	}
-	f := &Filter{uriRegex: regexp.MustCompile(cfg.UriRegex[0]), replace: cfg.UriRegex[1], Headers: headers}
+	f := &Filter{uriRegex: regexp.MustCompile(cfg.UriRegex[0]), replace: cfg.UriRegex[1], Headers: headers, originalURIHeader: cfg.OriginalURIHeader}
 
 	chain.AppendDecodeFilters(f)
 	return nil
 }
 
 func (f *Filter) Decode(c *contexthttp.HttpContext) filter.FilterStatus {
+	if len(f.rules) > 0 {
+		return f.rewrite(c)
+	}
 	url := c.GetUrl()
 
 	newUrl := f.uriRegex.ReplaceAllString(url, f.replace)
-	logger.Infof("proxy rewrite filter change url from %s to %s", url, newUrl)
+	logger.Infow("proxy rewrite filter change url", "from", url, "to", newUrl)
+	f.preserveURI(c)
 	c.SetUrl(newUrl)
 
 	if len(f.Headers) > 0 {
 		for k, v := range f.Headers {
-			logger.Infof("proxy rewrite filter add header  key %s and value %s", k, v)
+			logger.Infow("proxy rewrite filter add header", "key", k, "value", v)
 			c.AddHeader(k, v)
 		}
 	}
 	return filter.Continue
 }
+
+// preserveURI keep the uri before rewrite in the header, including the query
+func (f *Filter) preserveURI(c *contexthttp.HttpContext) {
+	if f.originalURIHeader == "" {
+		return
+	}
+	c.Request.Header.Set(f.originalURIHeader, c.Request.URL.RequestURI())
+}
+
+// rewrite the path by the first matched rule, then find the route of the new path
+func (f *Filter) rewrite(c *contexthttp.HttpContext) filter.FilterStatus {
+	path := c.GetUrl()
+	for _, r := range f.rules {
+		match := r.re.FindStringSubmatchIndex(path)
+		if match == nil {
+			continue
+		}
+
+		newPath := path[:match[0]] + string(r.re.ExpandString(nil, r.replacement, path, match)) + path[match[1]:]
+		logger.Debugw("proxy rewrite filter change url", "from", path, "to", newPath)
+		f.preserveURI(c)
+		c.SetUrl(newPath)
+		c.Request.URL.RawPath = ""
+		for k, v := range r.headers {
+			c.Request.Header.Set(k, string(r.re.ExpandString(nil, v, path, match)))
+		}
+
+		if c.Router == nil {
+			return filter.Continue
+		}
+		if err := c.Reroute(); err != nil {
+			logger.Debugw("proxy rewrite filter route fail", "path", newPath, "error", err.Error())
+			c.SendLocalReply(stdHttp.StatusNotFound, constant.Default404Body)
+			return filter.Stop
+		}
+		return filter.Continue
+	}
+	// nothing is rewritten, the value sent by client must not reach the upstream either
+	if f.originalURIHeader != "" {
+		c.Request.Header.Del(f.originalURIHeader)
+	}
+	return filter.Continue
+}
@@ -18,6 +18,7 @@
 package proxyrewrite
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 )
@@ -28,7 +29,9 @@ import (
 
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
 	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
 )
 
 func TestDecode(t *testing.T) {
@@ -63,3 +66,104 @@ func TestConfigUpdate(t *testing.T) {
 
 	assert.Equal(t, ctx.GetUrl(), "/query")
 }
+
+// mockRouter route the paths to the methods
+type mockRouter map[string]string
+
+func (r mockRouter) Route(hc *contexthttp.HttpContext) (*model.RouteAction, error) {
+	if mth, ok := r[hc.GetUrl()]; ok {
+		return &model.RouteAction{Cluster: mth}, nil
+	}
+	return nil, errors.New("route not found")
+}
+
+func TestRewriteRules(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Rules: []*Rule{
+		{
+			Pattern:     `^/api/v1/students/(?P<code>\d+)$`,
+			Replacement: "/student/GetStudentByCode/${code}",
+			Headers:     map[string]string{"X-Student-Code": "$1"},
+		},
+		{Pattern: `^/api/v1/students/(\w+)$`, Replacement: "/student/GetStudentByName/$1"},
+		{Pattern: `^/api/v1/(.*)$`, Replacement: "/$1"},
+	}}}
+	assert.Equal(t, nil, factory.Apply())
+	router := mockRouter{
+		"/student/GetStudentByCode/123":  "GetStudentByCode",
+		"/student/GetStudentByName/lily": "GetStudentByName",
+	}
+
+	tests := []struct {
+		path   string
+		want   string
+		route  string
+		code   string
+		status int
+	}{
+		{path: "/api/v1/students/123", want: "/student/GetStudentByCode/123", route: "GetStudentByCode", code: "123"},
+		// the first matched rule wins
+		{path: "/api/v1/students/lily", want: "/student/GetStudentByName/lily", route: "GetStudentByName"},
+		{path: "/api/v1/teachers", want: "/teachers", status: http.StatusNotFound},
+		{path: "/other/students/123", want: "/other/students/123", route: "origin"},
+	}
+	for _, tt := range tests {
+		request, _ := http.NewRequest("GET", tt.path, nil)
+		ctx := mock.GetMockHTTPContext(request)
+		ctx.RouteEntry(&model.RouteAction{Cluster: "origin"})
+		ctx.Router = router
+
+		chain := filter.NewDefaultFilterChain()
+		_ = factory.PrepareFilterChain(ctx, chain)
+		chain.OnDecode(ctx)
+
+		assert.Equal(t, tt.want, ctx.GetUrl())
+		assert.Equal(t, tt.code, request.Header.Get("X-Student-Code"))
+		if tt.status != 0 {
+			assert.Equal(t, tt.status, ctx.GetStatusCode())
+			continue
+		}
+		assert.Equal(t, tt.route, ctx.GetRouteEntry().Cluster)
+	}
+
+	factory = &FilterFactory{cfg: &Config{Rules: []*Rule{{Pattern: "("}}}}
+	assert.NotEqual(t, nil, factory.Apply())
+}
+
+func TestOriginalURIHeader(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{
+		Rules:             []*Rule{{Pattern: `^/api/v1/students/(\d+)$`, Replacement: "/student/GetStudentByCode/$1"}},
+		OriginalURIHeader: "X-Original-URI",
+	}}
+	assert.Equal(t, nil, factory.Apply())
+
+	request, _ := http.NewRequest("GET", "/api/v1/students/123?verbose=true", nil)
+	// the value sent by client can not be trusted
+	request.Header.Set("X-Original-URI", "/forged")
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+
+	assert.Equal(t, "/student/GetStudentByCode/123", ctx.GetUrl())
+	assert.Equal(t, "/api/v1/students/123?verbose=true", request.Header.Get("X-Original-URI"))
+
+	// not rewritten, nothing to preserve and the forged value is removed
+	request, _ = http.NewRequest("GET", "/api/v1/teachers", nil)
+	request.Header.Set("X-Original-URI", "/forged")
+	ctx = mock.GetMockHTTPContext(request)
+	chain = filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+	assert.Equal(t, "", request.Header.Get("X-Original-URI"))
+
+	// the uri regex rewrite
+	factory = &FilterFactory{cfg: &Config{UriRegex: []string{"^/([^/]*)/(.*)$", "/$2"}, OriginalURIHeader: "X-Original-URI"}}
+	assert.Equal(t, nil, factory.Apply())
+	request, _ = http.NewRequest("GET", "/user-service/query", nil)
+	ctx = mock.GetMockHTTPContext(request)
+	chain = filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+	assert.Equal(t, "/query", ctx.GetUrl())
+	assert.Equal(t, "/user-service/query", request.Header.Get("X-Original-URI"))
+}
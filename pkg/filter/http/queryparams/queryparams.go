@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package queryparams
+
+import (
+	"encoding/json"
+	stdHttp "net/http"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPQueryParamsFilter
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		maxParams int
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// MaxParams the max number of query parameters, the repeated key is counted for each value
+		MaxParams int `yaml:"max_params" json:"max_params" mapstructure:"max_params"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter rejects the polluted requests before any other work is done
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageAuth
+}
+
+func (factory *FilterFactory) Apply() error {
+	if factory.cfg.MaxParams <= 0 {
+		return errors.Errorf("max params %d must be positive", factory.cfg.MaxParams)
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{maxParams: factory.cfg.MaxParams}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	if countParams(ctx.Request.URL.RawQuery, f.maxParams) <= f.maxParams {
+		return filter.Continue
+	}
+	logger.Debugw("query params filter reject too many params", "url", ctx.GetUrl(), "max_params", f.maxParams)
+	bt, _ := json.Marshal(http.ErrResponse{Message: "too many query parameters"})
+	return filter.Abort(ctx, &filter.AbortResponse{
+		Status:  stdHttp.StatusBadRequest,
+		Body:    bt,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	})
+}
+
+// countParams count the non empty pairs of the raw query without decoding it, the counting
+// stops once the limit is exceeded so that the huge query is not scanned through
+func countParams(rawQuery string, limit int) int {
+	n := 0
+	for rawQuery != "" && n <= limit {
+		var pair string
+		if i := strings.IndexByte(rawQuery, '&'); i >= 0 {
+			pair, rawQuery = rawQuery[:i], rawQuery[i+1:]
+		} else {
+			pair, rawQuery = rawQuery, ""
+		}
+		if pair != "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package queryparams
+
+import (
+	stdHttp "net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestQueryParams(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{MaxParams: 3}}
+	assert.Nil(t, factory.Apply())
+
+	tests := []struct {
+		name     string
+		query    string
+		rejected bool
+	}{
+		{name: "no query", query: ""},
+		{name: "under limit", query: "a=1&b=2"},
+		{name: "at limit", query: "a=1&a=2&b=3"},
+		{name: "empty pairs ignored", query: "a=1&&b=2&&c=3&"},
+		{name: "over limit", query: "a=1&b=2&c=3&d=4", rejected: true},
+		{name: "repeated key over limit", query: "a=1&a=2&a=3&a=4", rejected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test?"+tt.query, nil)
+			assert.NoError(t, err)
+			ctx := mock.GetMockHTTPContext(request)
+			chain := filter.NewDefaultFilterChain()
+			_ = factory.PrepareFilterChain(ctx, chain)
+			chain.OnDecode(ctx)
+
+			assert.Equal(t, tt.rejected, ctx.LocalReply())
+			if tt.rejected {
+				assert.Equal(t, stdHttp.StatusBadRequest, ctx.GetStatusCode())
+			}
+		})
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Error(t, factory.Apply())
+}
@@ -0,0 +1,273 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	stdHttp "net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPQuotaFilter
+
+	defaultName           = "default"
+	defaultConsumerHeader = "X-Consumer-Id"
+	defaultMaxConsumers   = 10000
+	anonymousConsumer     = "anonymous"
+	headerQuotaReset      = "X-Quota-Reset"
+	headerRetryAfter      = "Retry-After"
+
+	windowDaily   = "daily"
+	windowMonthly = "monthly"
+)
+
+// now is the clock of quota windows, replaced in tests
+var now = time.Now
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg       *Config
+		store     Store
+		mu        sync.Mutex
+		consumers map[string]struct{}
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		factory *FilterFactory
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Name the name of quota, the usage stats are grouped by it
+		Name string `yaml:"name" json:"name" mapstructure:"name"`
+		// ConsumerHeader the header identifying the consumer when the request is not authenticated by an auth filter,
+		// it is read only when TrustConsumerHeader is set
+		ConsumerHeader string `yaml:"consumer_header" json:"consumer_header" mapstructure:"consumer_header"`
+		// TrustConsumerHeader trust the ConsumerHeader, set it only when the header is set by a trusted proxy
+		TrustConsumerHeader bool `yaml:"trust_consumer_header" json:"trust_consumer_header" mapstructure:"trust_consumer_header"`
+		// Store the name of registered store, memory by default
+		Store string `yaml:"store" json:"store" mapstructure:"store"`
+		// Limit the default quota of consumers
+		Limit Limit `yaml:"limit" json:"limit" mapstructure:"limit"`
+		// Consumers the quota of specific consumers, the requests without identity share the anonymous consumer
+		Consumers map[string]*Limit `yaml:"consumers" json:"consumers" mapstructure:"consumers"`
+		// MaxConsumers the max number of consumers tracked in the usage stats, the others are limited but not listed
+		MaxConsumers int `yaml:"max_consumers" json:"max_consumers" mapstructure:"max_consumers"`
+	}
+
+	// Limit the max request count in the windows, 0 means unlimited
+	Limit struct {
+		Daily   int64 `yaml:"daily" json:"daily" mapstructure:"daily"`
+		Monthly int64 `yaml:"monthly" json:"monthly" mapstructure:"monthly"`
+	}
+
+	// Usage the quota usage of a consumer
+	Usage struct {
+		Daily        int64     `json:"daily"`
+		DailyLimit   int64     `json:"daily_limit"`
+		DailyReset   time.Time `json:"daily_reset"`
+		Monthly      int64     `json:"monthly"`
+		MonthlyLimit int64     `json:"monthly_limit"`
+		MonthlyReset time.Time `json:"monthly_reset"`
+	}
+
+	window struct {
+		name  string
+		limit int64
+		start time.Time
+		end   time.Time
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Name == "" {
+		cfg.Name = defaultName
+	}
+	if cfg.ConsumerHeader == "" {
+		cfg.ConsumerHeader = defaultConsumerHeader
+	}
+	if cfg.MaxConsumers <= 0 {
+		cfg.MaxConsumers = defaultMaxConsumers
+	}
+	// keep the counts of the quota across the reloads
+	store, err := KeepStore(Kind+"/"+cfg.Name, cfg.Store)
+	if err != nil {
+		return err
+	}
+	factory.store = store
+	factory.consumers = make(map[string]struct{})
+	factories.Store(cfg.Name, factory)
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{factory: factory}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	factory := f.factory
+	consumer := factory.consumer(ctx)
+	factory.track(consumer)
+
+	// count first and compare the counted value, so the concurrent requests can not overshoot the quota,
+	// the request rejected by a window is not counted in the later windows
+	for _, w := range factory.windows(consumer, now()) {
+		count, err := factory.store.Incr(w.key(consumer), w.end)
+		if err != nil {
+			// fail open, the store outage should not break the traffic
+			logger.Warnw("quota store incr fail", "error", err.Error())
+			return filter.Continue
+		}
+		if count > w.limit {
+			reject(ctx, consumer, w)
+			return filter.Stop
+		}
+	}
+	return filter.Continue
+}
+
+// consumer the identity set by the auth filters, or the trusted header, the requests without identity share
+// the anonymous consumer, so that dropping the identity does not bypass the quota
+func (factory *FilterFactory) consumer(ctx *http.HttpContext) string {
+	if consumer, _ := ctx.Params[constant.AuthUserParam].(string); consumer != "" {
+		return consumer
+	}
+	if factory.cfg.TrustConsumerHeader {
+		if consumer := ctx.GetHeader(factory.cfg.ConsumerHeader); consumer != "" {
+			return consumer
+		}
+	}
+	return anonymousConsumer
+}
+
+// track remember the consumer for the usage stats, at most MaxConsumers are kept
+func (factory *FilterFactory) track(consumer string) {
+	factory.mu.Lock()
+	defer factory.mu.Unlock()
+	if _, ok := factory.consumers[consumer]; ok || len(factory.consumers) >= factory.cfg.MaxConsumers {
+		return
+	}
+	factory.consumers[consumer] = struct{}{}
+}
+
+func reject(ctx *http.HttpContext, consumer string, w *window) {
+	ctx.AddHeader(headerQuotaReset, w.end.Format(time.RFC3339))
+	ctx.AddHeader(headerRetryAfter, strconv.FormatInt(int64(w.end.Sub(now())/time.Second)+1, 10))
+	bt, _ := json.Marshal(http.ErrResponse{
+		Message: fmt.Sprintf("%s quota of %s is exhausted, reset at %s", w.name, consumer, w.end.Format(time.RFC3339)),
+	})
+	ctx.SendLocalReply(stdHttp.StatusTooManyRequests, bt)
+}
+
+func (factory *FilterFactory) limit(consumer string) Limit {
+	if l, ok := factory.cfg.Consumers[consumer]; ok && l != nil {
+		return *l
+	}
+	return factory.cfg.Limit
+}
+
+// windows return the limited windows of consumer at t, the windows are aligned to UTC
+func (factory *FilterFactory) windows(consumer string, t time.Time) []*window {
+	l := factory.limit(consumer)
+	t = t.UTC()
+	var windows []*window
+	if l.Daily > 0 {
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		windows = append(windows, &window{name: windowDaily, limit: l.Daily, start: start, end: start.AddDate(0, 0, 1)})
+	}
+	if l.Monthly > 0 {
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		windows = append(windows, &window{name: windowMonthly, limit: l.Monthly, start: start, end: start.AddDate(0, 1, 0)})
+	}
+	return windows
+}
+
+func (w *window) key(consumer string) string {
+	return consumer + "/" + w.name + "/" + w.start.Format("20060102")
+}
+
+// Usage return the quota usage of the consumers seen by this filter
+func (factory *FilterFactory) Usage() map[string]*Usage {
+	factory.mu.Lock()
+	consumers := make([]string, 0, len(factory.consumers))
+	for consumer := range factory.consumers {
+		consumers = append(consumers, consumer)
+	}
+	factory.mu.Unlock()
+
+	usage := make(map[string]*Usage)
+	t := now()
+	for _, consumer := range consumers {
+		u := &Usage{}
+		for _, w := range factory.windows(consumer, t) {
+			count, err := factory.store.Get(w.key(consumer))
+			if err != nil {
+				logger.Warnw("quota store get fail", "error", err.Error())
+			}
+			// the rejected requests are counted too, the consumed quota is at most the limit
+			if count > w.limit {
+				count = w.limit
+			}
+			switch w.name {
+			case windowDaily:
+				u.Daily, u.DailyLimit, u.DailyReset = count, w.limit, w.end
+			case windowMonthly:
+				u.Monthly, u.MonthlyLimit, u.MonthlyReset = count, w.limit, w.end
+			}
+		}
+		usage[consumer] = u
+	}
+	return usage
+}
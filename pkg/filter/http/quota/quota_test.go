@@ -0,0 +1,179 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quota
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func setNow(t time.Time) {
+	now = func() time.Time { return t }
+}
+
+func decode(t *testing.T, f *Filter, consumer string) (filter.FilterStatus, http.Header) {
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	if consumer != "" {
+		ctx.Params = map[string]interface{}{constant.AuthUserParam: consumer}
+	}
+	return f.Decode(ctx), ctx.Writer.Header()
+}
+
+func newFilter(t *testing.T, cfg *Config) (*FilterFactory, *Filter) {
+	factory := &FilterFactory{cfg: cfg}
+	assert.Nil(t, factory.Apply())
+	return factory, &Filter{factory: factory}
+}
+
+func TestDailyQuotaReset(t *testing.T) {
+	defer func() { now = time.Now }()
+	setNow(time.Date(2022, 10, 14, 23, 59, 0, 0, time.UTC))
+
+	factory, f := newFilter(t, &Config{Name: "daily", Limit: Limit{Daily: 2}})
+	for i := 0; i < 2; i++ {
+		status, _ := decode(t, f, "app-a")
+		assert.Equal(t, filter.Continue, status)
+	}
+	status, header := decode(t, f, "app-a")
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, "2022-10-15T00:00:00Z", header.Get(headerQuotaReset))
+	assert.Equal(t, "61", header.Get(headerRetryAfter))
+
+	// the other consumer is not affected
+	status, _ = decode(t, f, "app-b")
+	assert.Equal(t, filter.Continue, status)
+
+	usage := Stats()["daily"]["app-a"]
+	assert.Equal(t, int64(2), usage.Daily)
+	assert.Equal(t, int64(2), usage.DailyLimit)
+
+	// the next window
+	setNow(time.Date(2022, 10, 15, 0, 0, 1, 0, time.UTC))
+	status, _ = decode(t, f, "app-a")
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, int64(1), factory.Usage()["app-a"].Daily)
+}
+
+func TestMonthlyQuota(t *testing.T) {
+	defer func() { now = time.Now }()
+	setNow(time.Date(2022, 10, 30, 12, 0, 0, 0, time.UTC))
+
+	_, f := newFilter(t, &Config{
+		Name:      "monthly",
+		Limit:     Limit{Monthly: 100},
+		Consumers: map[string]*Limit{"app-a": {Daily: 10, Monthly: 2}},
+	})
+	for i := 0; i < 2; i++ {
+		status, _ := decode(t, f, "app-a")
+		assert.Equal(t, filter.Continue, status)
+	}
+	status, header := decode(t, f, "app-a")
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, "2022-11-01T00:00:00Z", header.Get(headerQuotaReset))
+
+	// the daily window is reset, but the monthly quota is still exhausted
+	setNow(time.Date(2022, 10, 31, 12, 0, 0, 0, time.UTC))
+	status, _ = decode(t, f, "app-a")
+	assert.Equal(t, filter.Stop, status)
+
+	setNow(time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC))
+	status, _ = decode(t, f, "app-a")
+	assert.Equal(t, filter.Continue, status)
+}
+
+func TestConcurrentQuota(t *testing.T) {
+	_, f := newFilter(t, &Config{Name: "concurrent", Limit: Limit{Daily: 10}})
+	var passed int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if status, _ := decode(t, f, "app-a"); status == filter.Continue {
+				atomic.AddInt64(&passed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(10), passed)
+}
+
+func TestMaxConsumers(t *testing.T) {
+	factory, f := newFilter(t, &Config{Name: "bounded", Limit: Limit{Daily: 1}, MaxConsumers: 2})
+	for _, consumer := range []string{"app-a", "app-b", "app-c"} {
+		status, _ := decode(t, f, consumer)
+		assert.Equal(t, filter.Continue, status)
+	}
+	// the untracked consumer is still limited
+	status, _ := decode(t, f, "app-c")
+	assert.Equal(t, filter.Stop, status)
+	assert.Len(t, factory.Usage(), 2)
+}
+
+func TestUnknownStore(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Store: "redis"}}
+	assert.Error(t, factory.Apply())
+}
+
+func TestAnonymousQuota(t *testing.T) {
+	_, f := newFilter(t, &Config{Name: "anonymous", Limit: Limit{Daily: 1}})
+	status, _ := decode(t, f, "")
+	assert.Equal(t, filter.Continue, status)
+
+	// the untrusted header does not identify the consumer, the anonymous requests share a bucket
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+	assert.NoError(t, err)
+	request.Header.Set(defaultConsumerHeader, "app-a")
+	assert.Equal(t, filter.Stop, f.Decode(mock.GetMockHTTPContext(request)))
+}
+
+func TestTrustConsumerHeader(t *testing.T) {
+	_, f := newFilter(t, &Config{Name: "trusted", Limit: Limit{Daily: 1}, TrustConsumerHeader: true})
+	for _, consumer := range []string{"app-a", "app-b"} {
+		request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user", nil)
+		assert.NoError(t, err)
+		request.Header.Set(defaultConsumerHeader, consumer)
+		assert.Equal(t, filter.Continue, f.Decode(mock.GetMockHTTPContext(request)))
+	}
+}
+
+func TestKeepStoreAcrossApply(t *testing.T) {
+	_, f := newFilter(t, &Config{Name: "reloaded", Limit: Limit{Daily: 1}})
+	status, _ := decode(t, f, "app-a")
+	assert.Equal(t, filter.Continue, status)
+
+	// the counts are kept when the quota is applied again
+	_, f = newFilter(t, &Config{Name: "reloaded", Limit: Limit{Daily: 1}})
+	status, _ = decode(t, f, "app-a")
+	assert.Equal(t, filter.Stop, status)
+}
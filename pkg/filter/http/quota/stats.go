@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quota
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+)
+
+// StatsName the name of quota usage stats, served by the admin filter
+const StatsName = "quota"
+
+// factories the applied quota filter factories, keyed by config name
+var factories sync.Map
+
+func init() {
+	filter.RegisterStats(StatsName, func() interface{} { return Stats() })
+}
+
+// Stats return the quota usage grouped by quota name and consumer
+func Stats() map[string]map[string]*Usage {
+	stats := make(map[string]map[string]*Usage)
+	factories.Range(func(key, value interface{}) bool {
+		stats[key.(string)] = value.(*FilterFactory).Usage()
+		return true
+	})
+	return stats
+}
@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quota
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+const defaultStore = "memory"
+
+type (
+	// Store keep the request counts of quota windows, it can be shared by multiple pixiu instances
+	Store interface {
+		// Incr add the count of key by 1 and return the new count, the key expires at expireAt
+		Incr(key string, expireAt time.Time) (int64, error)
+		// Get return the count of key, 0 if not exists or expired
+		Get(key string) (int64, error)
+	}
+
+	// StoreCreator create the store
+	StoreCreator func() Store
+
+	// MemoryStore the in-memory store, the counts are lost on restart
+	MemoryStore struct {
+		mu      sync.Mutex
+		entries map[string]*memoryEntry
+	}
+
+	memoryEntry struct {
+		count    int64
+		expireAt time.Time
+	}
+)
+
+var stores = map[string]StoreCreator{
+	defaultStore: func() Store { return NewMemoryStore() },
+}
+
+var (
+	keptMu sync.Mutex
+	// kept the stores kept across the reloads, keyed by owner and store name
+	kept = make(map[string]Store)
+)
+
+// RegisterStore register the store creator by name, it should be called in init
+func RegisterStore(name string, creator StoreCreator) {
+	stores[name] = creator
+}
+
+// CreateStore create the registered store by name, memory by default, the store can be shared by other filters
+func CreateStore(name string) (Store, error) {
+	if name == "" {
+		name = defaultStore
+	}
+	creator, ok := stores[name]
+	if !ok {
+		return nil, errors.Errorf("quota store %s not found", name)
+	}
+	return creator(), nil
+}
+
+// KeepStore return the store of the owner, it is created by CreateStore on the first call and reused later,
+// so that the counts are not reset when the owner is applied again by a reload
+func KeepStore(owner, name string) (Store, error) {
+	if name == "" {
+		name = defaultStore
+	}
+	key := owner + "/" + name
+
+	keptMu.Lock()
+	defer keptMu.Unlock()
+	if store, ok := kept[key]; ok {
+		return store, nil
+	}
+	store, err := CreateStore(name)
+	if err != nil {
+		return nil, err
+	}
+	kept[key] = store
+	return store, nil
+}
+
+// NewMemoryStore create memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+// Incr add the count of key by 1, the expired keys are removed
+func (s *MemoryStore) Incr(key string, expireAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	e, ok := s.entries[key]
+	if !ok || !t.Before(e.expireAt) {
+		s.evict(t)
+		e = &memoryEntry{expireAt: expireAt}
+		s.entries[key] = e
+	}
+	e.count++
+	return e.count, nil
+}
+
+// Get return the count of key
+func (s *MemoryStore) Get(key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || !now().Before(e.expireAt) {
+		return 0, nil
+	}
+	return e.count, nil
+}
+
+func (s *MemoryStore) evict(t time.Time) {
+	for k, e := range s.entries {
+		if !t.Before(e.expireAt) {
+			delete(s.entries, k)
+		}
+	}
+}
@@ -73,6 +73,10 @@ type (
 	config struct {
 		Level mockLevel               `yaml:"level,omitempty" json:"level,omitempty"`
 		Dpc   *dubbo.DubboProxyConfig `yaml:"dubboProxyConfig,omitempty" json:"dubboProxyConfig,omitempty"`
+		// Errors map the provider errors to the http status and code of the structured error body
+		Errors *ErrorMapping `yaml:"errors,omitempty" json:"errors,omitempty"`
+		// Debug echo the invocation metadata to client as response headers, never enable it in production
+		Debug bool `yaml:"debug,omitempty" json:"debug,omitempty"`
 	}
 )
 
@@ -89,6 +93,9 @@ func (factory *FilterFactory) Config() interface{} {
 }
 
 func (factory *FilterFactory) Apply() error {
+	if err := factory.conf.Errors.check(); err != nil {
+		return err
+	}
 	mock := 1
 	mockStr := os.Getenv(constant.EnvMock)
 	if len(mockStr) > 0 {
@@ -132,33 +139,50 @@ func (f *Filter) Decode(c *contexthttp.HttpContext) filter.FilterStatus {
 		return filter.Continue
 	}
 
-	typ := api.Method.IntegrationRequest.RequestType
+	req := client.NewReq(c.Request.Context(), c.Request, *api)
+	typ, done, err := routeCluster(req)
+	if err != nil {
+		logger.Warnw("remote call filter route cluster fail", "error", err.Error())
+		c.SendLocalReply(http.StatusServiceUnavailable, []byte(err.Error()))
+		return filter.Stop
+	}
+	defer done()
 
 	cli, err := matchClient(typ)
 	if err != nil {
 		panic(err)
 	}
 
-	req := client.NewReq(c.Request.Context(), c.Request, *api)
+	var meta *client.InvocationMeta
+	if f.conf.Debug {
+		req.Context, meta = client.WithInvocationMeta(req.Context)
+	}
 	resp, err := cli.Call(req)
+	if meta != nil {
+		writeInvocationMeta(c, meta)
+	}
 	if err != nil {
-		logger.Errorf("[dubbo-go-pixiu] client call err:%v!", err)
-		c.SendLocalReply(http.StatusInternalServerError, []byte(fmt.Sprintf("client call err: %s", err)))
+		if client.IsParamError(err) {
+			logger.Debugw("client call invalid param", "error", err.Error())
+		} else {
+			logger.Errorw("client call fail", "error", err.Error())
+		}
+		f.conf.Errors.replyError(c, err, req.API.Method.IntegrationRequest.Method)
 		return filter.Stop
 	}
 
-	logger.Debugf("[dubbo-go-pixiu] client call resp:%v", resp)
+	logger.Debugw("client call", "response", resp)
 
 	c.SourceResp = resp
 	return filter.Continue
 }
 
-func matchClient(typ apiConf.RequestType) (client.Client, error) {
+// matchClient return the client of request type, it is a var so that it can be replaced in tests
+var matchClient = func(typ apiConf.RequestType) (client.Client, error) {
 	switch strings.ToLower(string(typ)) {
 	case string(apiConf.DubboRequest):
 		return dubbo.SingletonDubboClient(), nil
-	// todo @(laurence) add triple to apiConf
-	case "triple":
+	case string(tripleRequest):
 		return triple.SingletonTripleClient(), nil
 	case string(apiConf.HTTPRequest):
 		return clienthttp.SingletonHTTPClient(), nil
@@ -193,8 +217,8 @@ func (f *Filter) resolve(ctx *contexthttp.HttpContext) error {
 		integrationRequest.RequestType = apiConf.HTTPRequest
 	} else if resolveProtocol == string(apiConf.DubboRequest) {
 		integrationRequest.RequestType = apiConf.DubboRequest
-	} else if resolveProtocol == "triple" {
-		integrationRequest.RequestType = "triple"
+	} else if resolveProtocol == string(tripleRequest) {
+		integrationRequest.RequestType = tripleRequest
 	} else {
 		return errors.New("http request has unknown protocol in x-dubbo-service-protocol when trying to auto resolve")
 	}
@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"strings"
+)
+
+import (
+	apiConf "github.com/dubbogo/dubbo-go-pixiu-filter/pkg/api/config"
+
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+// tripleRequest the request type of triple, todo @(laurence) add triple to apiConf
+const tripleRequest apiConf.RequestType = "triple"
+
+// clusterProtocol and pickEndpoint are vars so that they can be replaced in tests
+var (
+	clusterProtocol = func(clusterName string) string {
+		return server.GetClusterManager().Protocol(clusterName)
+	}
+	pickEndpoint = func(clusterName string) *model.Endpoint {
+		return server.GetClusterManager().PickEndpoint(clusterName)
+	}
+)
+
+// routeCluster dispatch the dubbo request to triple client when the providers of its cluster serve triple, so that
+// the same api config works for both protocols. The endpoint is picked by the lb policy of the cluster.
+// It returns the request type to match the client and the func to call when the invocation finishes.
+func routeCluster(req *client.Request) (apiConf.RequestType, func(), error) {
+	ir := &req.API.Method.IntegrationRequest
+	name := ir.DubboBackendConfig.ClusterName
+	if name == "" || !strings.EqualFold(string(ir.RequestType), string(apiConf.DubboRequest)) ||
+		clusterProtocol(name) != model.ClusterProtocolTriple {
+		return ir.RequestType, func() {}, nil
+	}
+	endpoint := pickEndpoint(name)
+	if endpoint == nil {
+		return ir.RequestType, nil, errors.Errorf("no endpoint available in cluster %s", name)
+	}
+	ir.HTTPBackendConfig.URL = endpoint.Address.GetAddress()
+	return tripleRequest, loadbalancer.Begin(name, endpoint), nil
+}
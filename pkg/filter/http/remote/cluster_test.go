@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	apiConf "github.com/dubbogo/dubbo-go-pixiu-filter/pkg/api/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestRouteCluster(t *testing.T) {
+	endpoint := &model.Endpoint{ID: "triple-1", Address: model.SocketAddress{Address: "127.0.0.1", Port: 20001}}
+	clusters := map[string]*model.Cluster{
+		"students": {Name: "students", Protocol: model.ClusterProtocolTriple, Endpoints: []*model.Endpoint{endpoint}},
+		"teachers": {Name: "teachers", Protocol: model.ClusterProtocolDubbo},
+		"empty":    {Name: "empty", Protocol: model.ClusterProtocolTriple},
+	}
+	originProtocol, originPick := clusterProtocol, pickEndpoint
+	clusterProtocol = func(name string) string {
+		return clusters[name].Protocol
+	}
+	pickEndpoint = func(name string) *model.Endpoint {
+		if c := clusters[name]; len(c.Endpoints) > 0 {
+			return c.Endpoints[0]
+		}
+		return nil
+	}
+	defer func() { clusterProtocol, pickEndpoint = originProtocol, originPick }()
+
+	request := func(cluster string) *client.Request {
+		api := mock.GetMockAPI(apiConf.MethodPost, "/api/v1/test-dubbo/student")
+		api.Method.IntegrationRequest.DubboBackendConfig.ClusterName = cluster
+		return client.NewReq(context.Background(), nil, api)
+	}
+
+	// the dubbo request of the triple cluster is dispatched to the picked endpoint by triple
+	req := request("students")
+	typ, done, err := routeCluster(req)
+	assert.NoError(t, err)
+	assert.Equal(t, tripleRequest, typ)
+	assert.Equal(t, "127.0.0.1:20001", req.API.Method.IntegrationRequest.HTTPBackendConfig.URL)
+	assert.Equal(t, int64(1), loadbalancer.ActiveCalls(clusters["students"], endpoint))
+	done()
+	assert.Equal(t, int64(0), loadbalancer.ActiveCalls(clusters["students"], endpoint))
+
+	for _, cluster := range []string{"teachers", ""} {
+		typ, done, err = routeCluster(request(cluster))
+		assert.NoError(t, err)
+		assert.Equal(t, apiConf.DubboRequest, typ)
+		done()
+	}
+
+	_, _, err = routeCluster(request("empty"))
+	assert.Error(t, err)
+}
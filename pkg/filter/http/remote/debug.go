@@ -0,0 +1,43 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"strconv"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	headerProvider     = "X-Pixiu-Provider"
+	headerInvocationID = "X-Pixiu-Invocation-Id"
+	headerElapsed      = "X-Pixiu-Elapsed-Ms"
+)
+
+// writeInvocationMeta echo the invocation metadata as response headers, the unknown provider is omitted
+func writeInvocationMeta(c *contexthttp.HttpContext, meta *client.InvocationMeta) {
+	provider, invocationID, elapsed := meta.Snapshot()
+	if provider != "" {
+		c.AddHeader(headerProvider, provider)
+	}
+	c.AddHeader(headerInvocationID, invocationID)
+	c.AddHeader(headerElapsed, strconv.FormatInt(elapsed.Milliseconds(), 10))
+}
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	apiConf "github.com/dubbogo/dubbo-go-pixiu-filter/pkg/api/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/client/dubbo"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/mock"
+	ctxmock "github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+// stubClient record the provider like the dubbo filter does
+type stubClient struct{}
+
+func (c *stubClient) Apply() error {
+	return nil
+}
+
+func (c *stubClient) Close() error {
+	return nil
+}
+
+func (c *stubClient) Call(req *client.Request) (interface{}, error) {
+	if meta := client.GetInvocationMeta(req.Context); meta != nil {
+		meta.SetProvider("10.0.0.1:20000")
+		meta.SetElapsed(15 * time.Millisecond)
+	}
+	return map[string]interface{}{"name": "Joe"}, nil
+}
+
+func (c *stubClient) MapParams(req *client.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func TestDebugInvocationMeta(t *testing.T) {
+	origin := matchClient
+	matchClient = func(apiConf.RequestType) (client.Client, error) {
+		return &stubClient{}, nil
+	}
+	defer func() { matchClient = origin }()
+
+	for _, debug := range []bool{true, false} {
+		f := &Filter{conf: config{Level: close, Dpc: &dubbo.DubboProxyConfig{}, Debug: debug}}
+		request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/test-dubbo/student", nil)
+		assert.NoError(t, err)
+		ctx := ctxmock.GetMockHTTPContext(request)
+		ctx.API(mock.GetMockAPI(apiConf.MethodGet, "/api/v1/test-dubbo/student"))
+
+		assert.Equal(t, filter.Continue, f.Decode(ctx))
+		header := ctx.Writer.Header()
+		if debug {
+			assert.Equal(t, "10.0.0.1:20000", header.Get(headerProvider))
+			assert.Len(t, header.Get(headerInvocationID), 16)
+			assert.Equal(t, "15", header.Get(headerElapsed))
+		} else {
+			assert.Empty(t, header.Get(headerProvider))
+			assert.Empty(t, header.Get(headerInvocationID))
+			assert.Empty(t, header.Get(headerElapsed))
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	codeInvalidParam  = "INVALID_PARAM"
+	codeUpstreamError = "UPSTREAM_ERROR"
+)
+
+type (
+	// ErrorMapping map the errors returned by provider to the http status and error code
+	ErrorMapping struct {
+		// Rules the first matched rule wins
+		Rules []*ErrorRule `yaml:"rules" json:"rules,omitempty"`
+		// DefaultStatus the status of the unmatched errors, 500 by default
+		DefaultStatus int `yaml:"default_status" json:"default_status,omitempty"`
+		// DefaultCode the code of the unmatched errors, UPSTREAM_ERROR by default
+		DefaultCode string `yaml:"default_code" json:"default_code,omitempty"`
+	}
+
+	// ErrorRule match the error by its message or java exception class
+	ErrorRule struct {
+		// Message the error message contains it, e.g. data is exist
+		Message string `yaml:"message" json:"message,omitempty"`
+		// Type the java exception class, e.g. java.lang.IllegalArgumentException
+		Type string `yaml:"type" json:"type,omitempty"`
+		// Methods the provider methods the rule applies to, e.g. the write methods CreateStudent, empty means all
+		Methods []string `yaml:"methods" json:"methods,omitempty"`
+		Status  int      `yaml:"status" json:"status"`
+		Code    string   `yaml:"code" json:"code"`
+	}
+
+	// ErrorBody the body replied for the failed call
+	ErrorBody struct {
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"requestId,omitempty"`
+	}
+
+	// javaThrowable the java exception decoded by hessian
+	javaThrowable interface {
+		JavaClassName() string
+	}
+)
+
+// match return the status and code of the error returned by the provider method, the param error
+// is always the fault of client
+func (m *ErrorMapping) match(err error, method string) (int, string) {
+	if client.IsParamError(err) {
+		return http.StatusBadRequest, codeInvalidParam
+	}
+	var (
+		rules  []*ErrorRule
+		status = http.StatusInternalServerError
+		code   = codeUpstreamError
+	)
+	if m != nil {
+		rules = m.Rules
+		if m.DefaultStatus != 0 {
+			status = m.DefaultStatus
+		}
+		if m.DefaultCode != "" {
+			code = m.DefaultCode
+		}
+	}
+
+	var javaClass string
+	var throwable javaThrowable
+	if errors.As(err, &throwable) {
+		javaClass = throwable.JavaClassName()
+	}
+	msg := err.Error()
+	for _, rule := range rules {
+		if rule.Type != "" && rule.Type != javaClass {
+			continue
+		}
+		if rule.Message != "" && !strings.Contains(msg, rule.Message) {
+			continue
+		}
+		if !rule.appliesTo(method) {
+			continue
+		}
+		return rule.Status, rule.Code
+	}
+	return status, code
+}
+
+func (r *ErrorRule) appliesTo(method string) bool {
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// replyError reply the structured error body of the failed call of the provider method
+func (m *ErrorMapping) replyError(c *contexthttp.HttpContext, err error, method string) {
+	status, code := m.match(err, method)
+	requestID := c.GetRequestID()
+	if requestID == "" {
+		requestID = c.Request.Header.Get(constant.HeaderKeyRequestID)
+	}
+	bt, _ := json.Marshal(ErrorBody{Code: code, Message: err.Error(), RequestID: requestID})
+	c.AddHeader(constant.HeaderKeyContextType, constant.HeaderValueJsonUtf8)
+	c.SendLocalReply(status, bt)
+}
+
+// check validate the rules, each should match something and map to a valid status
+func (m *ErrorMapping) check() error {
+	if m == nil {
+		return nil
+	}
+	for _, rule := range m.Rules {
+		if rule.Message == "" && rule.Type == "" {
+			return errors.New("error rule must match message or type")
+		}
+		if rule.Status < 400 || rule.Status > 599 {
+			return errors.New("error rule status must be 4xx or 5xx")
+		}
+	}
+	if m.DefaultStatus != 0 && (m.DefaultStatus < 400 || m.DefaultStatus > 599) {
+		return errors.New("default error status must be 4xx or 5xx")
+	}
+	return nil
+}
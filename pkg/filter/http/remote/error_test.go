@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+type illegalArgument struct {
+	msg string
+}
+
+func (e *illegalArgument) Error() string {
+	return e.msg
+}
+
+func (e *illegalArgument) JavaClassName() string {
+	return "java.lang.IllegalArgumentException"
+}
+
+func TestErrorMapping(t *testing.T) {
+	m := &ErrorMapping{
+		Rules: []*ErrorRule{
+			{Message: "data is exist", Status: http.StatusConflict, Code: "STUDENT_EXISTS"},
+			{Type: "java.lang.IllegalArgumentException", Status: http.StatusUnprocessableEntity, Code: "ILLEGAL_ARGUMENT"},
+		},
+		DefaultStatus: http.StatusBadGateway,
+	}
+	assert.Nil(t, m.check())
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "message", err: perrors.Wrap(errors.New("data is exist"), "call CreateStudent fail"), status: http.StatusConflict, code: "STUDENT_EXISTS"},
+		{name: "java type", err: perrors.WithStack(&illegalArgument{msg: "age is negative"}), status: http.StatusUnprocessableEntity, code: "ILLEGAL_ARGUMENT"},
+		{name: "param", err: client.NewParamError("Query parameter %s does not exist", "id"), status: http.StatusBadRequest, code: codeInvalidParam},
+		{name: "default", err: errors.New("connection refused"), status: http.StatusBadGateway, code: codeUpstreamError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := m.match(tt.err, "")
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+
+	// nothing configured
+	var empty *ErrorMapping
+	status, code := empty.match(errors.New("data is exist"), "CreateStudent")
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, codeUpstreamError, code)
+
+	assert.Error(t, (&ErrorMapping{Rules: []*ErrorRule{{Status: 409}}}).check())
+	assert.Error(t, (&ErrorMapping{Rules: []*ErrorRule{{Message: "data is exist", Status: 200}}}).check())
+}
+
+func TestReplyError(t *testing.T) {
+	m := &ErrorMapping{Rules: []*ErrorRule{{Message: "data is exist", Status: http.StatusConflict, Code: "STUDENT_EXISTS"}}}
+
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/test-dubbo/student/create", nil)
+	assert.NoError(t, err)
+	request.Header.Set(constant.HeaderKeyRequestID, "req-1")
+	ctx := mock.GetMockHTTPContext(request)
+	m.replyError(ctx, errors.New("data is exist"), "")
+
+	assert.Equal(t, http.StatusConflict, ctx.GetStatusCode())
+	var body ErrorBody
+	assert.Nil(t, json.Unmarshal(ctx.TargetResp.Data, &body))
+	assert.Equal(t, ErrorBody{Code: "STUDENT_EXISTS", Message: "data is exist", RequestID: "req-1"}, body)
+
+	// the id set by requestid filter wins
+	ctx = mock.GetMockHTTPContext(request)
+	ctx.SetRequestID("req-2")
+	m.replyError(ctx, errors.New("data is exist"), "")
+	assert.Nil(t, json.Unmarshal(ctx.TargetResp.Data, &body))
+	assert.Equal(t, "req-2", body.RequestID)
+}
+
+func TestWriteMethodConflict(t *testing.T) {
+	m := &ErrorMapping{Rules: []*ErrorRule{
+		{Message: "data is exist", Methods: []string{"CreateStudent", "UpdateStudent"}, Status: http.StatusConflict, Code: "STUDENT_EXISTS"},
+	}}
+	assert.Nil(t, m.check())
+
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com/api/v1/test-dubbo/student/create", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	m.replyError(ctx, perrors.Wrap(errors.New("data is exist"), "call fail"), "CreateStudent")
+
+	assert.Equal(t, http.StatusConflict, ctx.GetStatusCode())
+	assert.Equal(t, constant.HeaderValueJsonUtf8, ctx.Writer.Header().Get(constant.HeaderKeyContextType))
+	var body ErrorBody
+	assert.Nil(t, json.Unmarshal(ctx.TargetResp.Data, &body))
+	assert.Equal(t, ErrorBody{Code: "STUDENT_EXISTS", Message: "call fail: data is exist"}, body)
+
+	// the rule is scoped to the write methods
+	status, code := m.match(errors.New("data is exist"), "GetStudentByName")
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, codeUpstreamError, code)
+}
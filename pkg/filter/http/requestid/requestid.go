@@ -0,0 +1,185 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPRequestIDFilter
+
+	// FormatUUID generate the random uuid (version 4)
+	FormatUUID = "uuid"
+	// FormatULID generate the lexicographically sortable ulid
+	FormatULID = "ulid"
+
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg      *Config
+		generate func() string
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg      *Config
+		generate func() string
+		id       string
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Header the header carrying request id, X-Request-Id by default
+		Header string `yaml:"header" json:"header" mapstructure:"header"`
+		// Format the format of generated id, uuid or ulid, uuid by default
+		Format string `yaml:"format" json:"format" mapstructure:"format"`
+		// Overwrite always generate a new id even if the request carries one
+		Overwrite bool `yaml:"overwrite" json:"overwrite" mapstructure:"overwrite"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Header == "" {
+		cfg.Header = constant.HeaderKeyRequestID
+	}
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatUUID:
+		factory.generate = newUUID
+	case FormatULID:
+		factory.generate = newULID
+	default:
+		return errors.Errorf("unsupported request id format %s", cfg.Format)
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, generate: factory.generate}
+	chain.AppendDecodeFilters(f)
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	id := ctx.GetHeader(f.cfg.Header)
+	if id == "" || f.cfg.Overwrite {
+		id = f.generate()
+		// propagate to the upstream
+		ctx.Request.Header.Set(f.cfg.Header, id)
+	}
+	f.id = id
+	ctx.SetRequestID(id)
+	return filter.Continue
+}
+
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.id != "" {
+		ctx.Writer.Header().Set(f.cfg.Header, f.id)
+	}
+	return filter.Continue
+}
+
+// EncodeStream the id header is set as Encode, the streamed body is kept as it is
+func (f *Filter) EncodeStream(ctx *http.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	return filter.NopWriteCloser(w), f.Encode(ctx)
+}
+
+// newUUID generate the random uuid, see RFC 4122 section 4.4
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+// newULID generate the ulid with 48 bits millisecond timestamp and 80 bits randomness,
+// encoded with crockford's base32, see https://github.com/ulid/spec
+func newULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(b[:6], ts[2:])
+	_, _ = rand.Read(b[6:])
+	return encodeULID(b)
+}
+
+func encodeULID(b [16]byte) string {
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	// 26 characters hold 130 bits, the first character only holds the top 3 bits
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestid
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidPattern = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		inbound string
+		pattern *regexp.Regexp
+		expect  string
+	}{
+		{name: "generate uuid", cfg: &Config{}, pattern: uuidPattern},
+		{name: "generate ulid", cfg: &Config{Format: FormatULID}, pattern: ulidPattern},
+		{name: "honor inbound", cfg: &Config{}, inbound: "abc", expect: "abc"},
+		{name: "overwrite inbound", cfg: &Config{Overwrite: true}, inbound: "abc", pattern: uuidPattern},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &FilterFactory{cfg: tt.cfg}
+			assert.Nil(t, factory.Apply())
+
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+			assert.NoError(t, err)
+			if tt.inbound != "" {
+				request.Header.Set(constant.HeaderKeyRequestID, tt.inbound)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			chain := filter.NewDefaultFilterChain()
+			assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+			chain.OnDecode(ctx)
+			chain.OnEncode(ctx)
+
+			id := ctx.GetRequestID()
+			if tt.expect != "" {
+				assert.Equal(t, tt.expect, id)
+			} else {
+				assert.Regexp(t, tt.pattern, id)
+				assert.NotEqual(t, tt.inbound, id)
+			}
+			assert.Equal(t, id, request.Header.Get(constant.HeaderKeyRequestID))
+			assert.Equal(t, id, ctx.Writer.Header().Get(constant.HeaderKeyRequestID))
+		})
+	}
+}
+
+func TestEncodeULID(t *testing.T) {
+	var b [16]byte
+	assert.Equal(t, "00000000000000000000000000", encodeULID(b))
+	for i := range b {
+		b[i] = 0xff
+	}
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeULID(b))
+}
+
+func TestApplyUnsupportedFormat(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Format: "snowflake"}}
+	assert.Error(t, factory.Apply())
+}
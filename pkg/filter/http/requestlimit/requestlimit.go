@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestlimit
+
+import (
+	"encoding/json"
+	"fmt"
+	stdHttp "net/http"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPRequestLimitFilter
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory, zero means no limit
+	Config struct {
+		// MaxURILength the max length of the request uri with the query, 414 if exceeded
+		MaxURILength int `yaml:"max_uri_length" json:"max_uri_length" mapstructure:"max_uri_length"`
+		// MaxHeaderCount the max header lines, each value of a header counts, 431 if exceeded
+		MaxHeaderCount int `yaml:"max_header_count" json:"max_header_count" mapstructure:"max_header_count"`
+		// MaxHeaderValueLength the max length of a header value, 431 if exceeded
+		MaxHeaderValueLength int `yaml:"max_header_value_length" json:"max_header_value_length" mapstructure:"max_header_value_length"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter rejects the oversized requests before the body is read
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageAuth
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.MaxURILength < 0 || cfg.MaxHeaderCount < 0 || cfg.MaxHeaderValueLength < 0 {
+		return errors.New("request limits must not be negative")
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// Decode reject the request exceeding the limits at once, the rest of the chain is skipped
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	req := ctx.Request
+	uri := req.RequestURI
+	if uri == "" {
+		uri = req.URL.RequestURI()
+	}
+	if f.cfg.MaxURILength > 0 && len(uri) > f.cfg.MaxURILength {
+		return reject(ctx, stdHttp.StatusRequestURITooLong, fmt.Sprintf("request uri exceeds %d bytes", f.cfg.MaxURILength))
+	}
+
+	count := 0
+	for name, values := range req.Header {
+		count += len(values)
+		if f.cfg.MaxHeaderValueLength <= 0 {
+			continue
+		}
+		for _, v := range values {
+			if len(v) > f.cfg.MaxHeaderValueLength {
+				return reject(ctx, stdHttp.StatusRequestHeaderFieldsTooLarge,
+					fmt.Sprintf("header %s exceeds %d bytes", name, f.cfg.MaxHeaderValueLength))
+			}
+		}
+	}
+	if f.cfg.MaxHeaderCount > 0 && count > f.cfg.MaxHeaderCount {
+		return reject(ctx, stdHttp.StatusRequestHeaderFieldsTooLarge, fmt.Sprintf("request exceeds %d headers", f.cfg.MaxHeaderCount))
+	}
+	return filter.Continue
+}
+
+func reject(ctx *http.HttpContext, status int, message string) filter.FilterStatus {
+	body, _ := json.Marshal(http.ErrResponse{Message: message})
+	return filter.Abort(ctx, &filter.AbortResponse{
+		Status:  status,
+		Body:    body,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	})
+}
@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestlimit
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestRequestLimit(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{MaxURILength: 32, MaxHeaderCount: 3, MaxHeaderValueLength: 16}}
+	assert.Nil(t, factory.Apply())
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		status int
+	}{
+		{name: "ok", path: "/api/v1/user?id=1", header: http.Header{"X-A": {"a"}, "X-B": {"b"}}},
+		{name: "long uri", path: "/api/v1/user?name=" + strings.Repeat("a", 20), status: http.StatusRequestURITooLong},
+		{name: "too many headers", path: "/api/v1/user", header: http.Header{"X-A": {"a", "b"}, "X-B": {"c", "d"}}, status: http.StatusRequestHeaderFieldsTooLarge},
+		{name: "long header value", path: "/api/v1/user", header: http.Header{"X-A": {strings.Repeat("a", 17)}}, status: http.StatusRequestHeaderFieldsTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com"+tt.path, nil)
+			assert.NoError(t, err)
+			request.Header = tt.header
+			if request.Header == nil {
+				request.Header = http.Header{}
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			chain := filter.NewDefaultFilterChain()
+			assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+			chain.OnDecode(ctx)
+
+			assert.Equal(t, tt.status != 0, ctx.LocalReply())
+			if tt.status != 0 {
+				assert.Equal(t, tt.status, ctx.GetStatusCode())
+			}
+		})
+	}
+
+	assert.Error(t, (&FilterFactory{cfg: &Config{MaxHeaderCount: -1}}).Apply())
+}
@@ -0,0 +1,203 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package retryafter
+
+import (
+	"context"
+	stdHttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPRetryAfterFilter
+
+	defaultMaxRetryAfter = 5 * time.Second
+	defaultMaxQueued     = 10 * time.Second
+	defaultMaxRetries    = 3
+)
+
+// defaultMethods the idempotent methods
+var defaultMethods = []string{stdHttp.MethodGet, stdHttp.MethodHead, stdHttp.MethodOptions, stdHttp.MethodPut, stdHttp.MethodDelete}
+
+// now the clock, replaced in tests
+var now = time.Now
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg           *Config
+		maxRetryAfter time.Duration
+		maxQueued     time.Duration
+		methods       map[string]struct{}
+	}
+
+	// Filter queue the retry of the idempotent request when the upstream replies 503 with Retry-After, the http proxy
+	// filter waits by it and sends the buffered request again to the cluster. The filter should be configured before
+	// the http proxy filter.
+	Filter struct {
+		factory *FilterFactory
+		queued  time.Duration
+		retries int
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// MaxRetryAfter the max Retry-After honored, the longer one is replied to client as is, 5s by default
+		MaxRetryAfter string `yaml:"max_retry_after" json:"max_retry_after" mapstructure:"max_retry_after"`
+		// MaxQueued the max total time a request is queued, 10s by default
+		MaxQueued string `yaml:"max_queued" json:"max_queued" mapstructure:"max_queued"`
+		// MaxRetries the max queued retries of a request, 3 by default
+		MaxRetries int `yaml:"max_retries" json:"max_retries" mapstructure:"max_retries"`
+		// Methods the idempotent methods to retry, GET, HEAD, OPTIONS, PUT and DELETE by default
+		Methods []string `yaml:"methods" json:"methods" mapstructure:"methods"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	var err error
+	if factory.maxRetryAfter, err = parseDuration(cfg.MaxRetryAfter, defaultMaxRetryAfter); err != nil {
+		return errors.Wrap(err, "max retry after")
+	}
+	if factory.maxQueued, err = parseDuration(cfg.MaxQueued, defaultMaxQueued); err != nil {
+		return errors.Wrap(err, "max queued")
+	}
+	if cfg.MaxRetries < 0 {
+		return errors.New("max retries must not be negative")
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = defaultMethods
+	}
+	factory.methods = make(map[string]struct{}, len(cfg.Methods))
+	for _, m := range cfg.Methods {
+		factory.methods[strings.ToUpper(m)] = struct{}{}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{factory: factory}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// Decode offer the waiter to the http proxy filter for the idempotent request only
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	if _, ok := f.factory.methods[ctx.Request.Method]; !ok {
+		return filter.Continue
+	}
+	if ctx.Params == nil {
+		ctx.Params = make(map[string]interface{})
+	}
+	ctx.Params[constant.RetryAfterParam] = f
+	return filter.Continue
+}
+
+// WaitRetryAfter wait the delay of Retry-After, it returns false at once if the delay exceeds the limits or
+// the request deadline, and returns false when the request is cancelled during the wait
+func (f *Filter) WaitRetryAfter(ctx context.Context, retryAfter string) bool {
+	delay, ok := parseRetryAfter(retryAfter)
+	if !ok || delay > f.factory.maxRetryAfter || f.queued+delay > f.factory.maxQueued || f.retries >= f.factory.cfg.MaxRetries {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && now().Add(delay).After(deadline) {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		logger.Debugw("queued retry is abandoned", "error", ctx.Err().Error())
+		return false
+	}
+	f.queued += delay
+	f.retries++
+	return true
+}
+
+// parseRetryAfter parse the delay seconds or the http date of Retry-After
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil {
+		return time.Duration(sec) * time.Second, sec >= 0
+	}
+	t, err := stdHttp.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	delay := t.Sub(now())
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+func parseDuration(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, errors.Errorf("%s should be positive", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package retryafter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestDecodeIdempotentOnly(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+	for method, queued := range map[string]bool{"GET": true, "DELETE": true, "POST": false} {
+		request, err := http.NewRequest(method, "http://www.dubbogopixiu.com/api/v1/test-dubbo/student", nil)
+		assert.NoError(t, err)
+		ctx := mock.GetMockHTTPContext(request)
+		chain := filter.NewDefaultFilterChain()
+		_ = factory.PrepareFilterChain(ctx, chain)
+		chain.OnDecode(ctx)
+		_, ok := ctx.Params[constant.RetryAfterParam]
+		assert.Equal(t, queued, ok, method)
+	}
+}
+
+func TestWaitRetryAfter(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{MaxRetryAfter: "1s", MaxQueued: "1500ms", MaxRetries: 3}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{factory: factory}
+	ctx := context.Background()
+
+	assert.True(t, f.WaitRetryAfter(ctx, "0"))
+	assert.False(t, f.WaitRetryAfter(ctx, ""))
+	assert.False(t, f.WaitRetryAfter(ctx, "soon"))
+	// exceed the max retry after
+	assert.False(t, f.WaitRetryAfter(ctx, "2"))
+
+	// the request is cancelled during the wait
+	cancelled, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	assert.False(t, f.WaitRetryAfter(cancelled, "1"))
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+
+	// the deadline comes before the retry
+	short, cancelShort := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancelShort()
+	assert.False(t, f.WaitRetryAfter(short, "1"))
+
+	// exceed the max queued time
+	f.queued = time.Second
+	assert.False(t, f.WaitRetryAfter(ctx, "1"))
+	// exceed the max retries
+	f.queued, f.retries = 0, 3
+	assert.False(t, f.WaitRetryAfter(ctx, "0"))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	at := time.Date(2022, 4, 15, 8, 0, 0, 0, time.UTC)
+	now = func() time.Time { return at }
+	defer func() { now = time.Now }()
+
+	delay, ok := parseRetryAfter("3")
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, delay)
+	delay, ok = parseRetryAfter(at.Add(2 * time.Second).Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, delay)
+	_, ok = parseRetryAfter("-1")
+	assert.False(t, ok)
+}
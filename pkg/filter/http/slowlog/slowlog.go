@@ -0,0 +1,180 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slowlog
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPSlowLogFilter
+
+	// DetailBasic log the method, path, status, cluster and duration
+	DetailBasic = "basic"
+	// DetailFull log the query, request id and filter timings as well
+	DetailFull = "full"
+
+	defaultSlowThreshold = time.Second
+)
+
+// now the clock, replaced in tests
+var now = time.Now
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg       *Config
+		threshold time.Duration
+	}
+
+	// Filter log the request taking longer than the threshold, the duration is measured from the decode of the
+	// filter to the release of the chain, so the filter should be configured first to cover the whole chain
+	Filter struct {
+		factory *FilterFactory
+		start   time.Time
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// SlowThreshold the request taking longer is logged, 1s by default
+		SlowThreshold string `yaml:"slow_threshold" json:"slow_threshold" mapstructure:"slow_threshold"`
+		// Detail the detail level, basic or full, basic by default. The filter timings are logged in full level
+		// only when the filter timings tracing of the connection manager is enabled
+		Detail string `yaml:"detail" json:"detail" mapstructure:"detail"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	factory.threshold = defaultSlowThreshold
+	if cfg.SlowThreshold != "" {
+		threshold, err := time.ParseDuration(cfg.SlowThreshold)
+		if err != nil {
+			return errors.Wrap(err, "slow threshold parse fail")
+		}
+		if threshold <= 0 {
+			return errors.New("slow threshold must be positive")
+		}
+		factory.threshold = threshold
+	}
+	cfg.Detail = strings.ToLower(cfg.Detail)
+	switch cfg.Detail {
+	case "":
+		cfg.Detail = DetailBasic
+	case DetailBasic, DetailFull:
+	default:
+		return errors.Errorf("unknown detail %s, expect basic or full", cfg.Detail)
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{factory: factory}
+	chain.AppendDecodeFilters(f)
+	// log with the chain release to cover the local reply, the panic and the streamed response
+	if !filter.Defer(chain, func() { f.log(ctx) }) {
+		chain.AppendEncodeFilters(f)
+	}
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	f.start = now()
+	return filter.Continue
+}
+
+// Encode log the request when the chain does not support Defer
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	f.log(ctx)
+	return filter.Continue
+}
+
+// EncodeStream log the slow request once the streamed body is written
+func (f *Filter) EncodeStream(ctx *http.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	return filter.CloseFuncWriter(w, func() { f.log(ctx) }), filter.Continue
+}
+
+func (f *Filter) log(ctx *http.HttpContext) {
+	if kv := f.entry(ctx); kv != nil {
+		logger.Warnw("slow request", kv...)
+	}
+}
+
+// entry build the fields of the slow request entry, nil if the request is not slow or is logged already
+func (f *Filter) entry(ctx *http.HttpContext) []interface{} {
+	if f.start.IsZero() {
+		return nil
+	}
+	elapsed := now().Sub(f.start)
+	f.start = time.Time{}
+	if elapsed < f.factory.threshold {
+		return nil
+	}
+
+	kv := []interface{}{
+		"method", ctx.GetMethod(),
+		"path", ctx.Request.URL.Path,
+		"status", ctx.GetStatusCode(),
+		"duration", elapsed.String(),
+		"threshold", f.factory.threshold.String(),
+	}
+	if route := ctx.GetRouteEntry(); route != nil {
+		kv = append(kv, "cluster", route.Cluster)
+	}
+	if f.factory.cfg.Detail == DetailFull {
+		kv = append(kv, "query", ctx.Request.URL.RawQuery, "request_id", ctx.GetRequestID())
+		if timings := filter.GetFilterTimings(ctx); timings != nil {
+			kv = append(kv, "filter_timings", timings.String())
+		}
+	}
+	return kv
+}
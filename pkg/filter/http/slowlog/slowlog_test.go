@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package slowlog
+
+import (
+	stdHttp "net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestApply(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{}}
+	assert.Nil(t, factory.Apply())
+	assert.Equal(t, defaultSlowThreshold, factory.threshold)
+	assert.Equal(t, DetailBasic, factory.cfg.Detail)
+
+	assert.Error(t, (&FilterFactory{cfg: &Config{SlowThreshold: "1x"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{SlowThreshold: "-1s"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Detail: "verbose"}}).Apply())
+}
+
+func TestEntry(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	factory := &FilterFactory{cfg: &Config{SlowThreshold: "500ms", Detail: "full"}}
+	assert.Nil(t, factory.Apply())
+
+	request, err := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/user?id=1", nil)
+	assert.NoError(t, err)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.Route = &model.RouteAction{Cluster: "user"}
+	chain := filter.NewDefaultFilterChain()
+	assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+
+	// the fast request is not logged
+	f := &Filter{factory: factory}
+	f.Decode(ctx)
+	clock = clock.Add(100 * time.Millisecond)
+	assert.Nil(t, f.entry(ctx))
+
+	f.Decode(ctx)
+	clock = clock.Add(time.Second)
+	kv := f.entry(ctx)
+	fields := make(map[interface{}]interface{})
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields[kv[i]] = kv[i+1]
+	}
+	assert.Equal(t, "/api/v1/user", fields["path"])
+	assert.Equal(t, "user", fields["cluster"])
+	assert.Equal(t, "1s", fields["duration"])
+	assert.Equal(t, "id=1", fields["query"])
+
+	// the entry is emitted once
+	assert.Nil(t, f.entry(ctx))
+}
@@ -0,0 +1,182 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package statusmap
+
+import (
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPStatusMapFilter
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg *Config
+	}
+
+	// Filter replace the upstream status in encode stage, the local replies are sent already and kept
+	Filter struct {
+		cfg *Config
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Rules the first rule matching the upstream status wins
+		Rules []*Rule `yaml:"rules" json:"rules" mapstructure:"rules"`
+		// Default map the status matching no rule, e.g. any other 5xx to 502
+		Default *Default `yaml:"default" json:"default" mapstructure:"default"`
+	}
+
+	// Rule map a status to another, the body and headers are kept unless Body or Headers is set
+	Rule struct {
+		From int `yaml:"from" json:"from" mapstructure:"from"`
+		To   int `yaml:"to" json:"to" mapstructure:"to"`
+		// Body replace the response body, the streamed body is not replaced
+		Body string `yaml:"body" json:"body" mapstructure:"body"`
+		// Headers set on the response
+		Headers map[string]string `yaml:"headers" json:"headers" mapstructure:"headers"`
+	}
+
+	// Default map the unmatched status within the range
+	Default struct {
+		// Range the status range like 5xx or 500-599
+		Range string `yaml:"range" json:"range" mapstructure:"range"`
+		To    int    `yaml:"to" json:"to" mapstructure:"to"`
+
+		min, max int
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if len(cfg.Rules) == 0 && cfg.Default == nil {
+		return errors.New("no status mapping configured")
+	}
+	for i, r := range cfg.Rules {
+		if !validStatus(r.From) || !validStatus(r.To) {
+			return errors.Errorf("rule %d: invalid status mapping %d to %d", i, r.From, r.To)
+		}
+	}
+	if d := cfg.Default; d != nil {
+		min, max, err := parseRange(d.Range)
+		if err != nil {
+			return errors.Wrap(err, "default mapping")
+		}
+		if !validStatus(d.To) {
+			return errors.Errorf("default mapping: invalid status %d", d.To)
+		}
+		d.min, d.max = min, max
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg}
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if ctx.LocalReply() {
+		return filter.Continue
+	}
+	from := ctx.GetStatusCode()
+	for _, r := range f.cfg.Rules {
+		if r.From != from {
+			continue
+		}
+		f.remap(ctx, from, r.To)
+		for k, v := range r.Headers {
+			ctx.Writer.Header().Set(k, v)
+		}
+		if r.Body != "" && ctx.TargetResp != nil {
+			ctx.TargetResp.Data = []byte(r.Body)
+			ctx.Writer.Header().Del("Content-Length")
+		}
+		return filter.Continue
+	}
+	if d := f.cfg.Default; d != nil && from >= d.min && from <= d.max {
+		f.remap(ctx, from, d.To)
+	}
+	return filter.Continue
+}
+
+func (f *Filter) remap(ctx *http.HttpContext, from, to int) {
+	if from == to {
+		return
+	}
+	logger.Debugw("map upstream status", "from", from, "to", to, "url", ctx.GetUrl())
+	ctx.StatusCode(to)
+}
+
+// parseRange parse the status range like 5xx or 500-599
+func parseRange(s string) (int, int, error) {
+	if len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '9' {
+		min := int(s[0]-'0') * 100
+		return min, min + 99, nil
+	}
+	bounds := strings.SplitN(s, "-", 2)
+	if len(bounds) == 2 {
+		min, err1 := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		max, err2 := strconv.Atoi(strings.TrimSpace(bounds[1]))
+		if err1 == nil && err2 == nil && min <= max {
+			return min, max, nil
+		}
+	}
+	return 0, 0, errors.Errorf("invalid status range %q, it should be like 5xx or 500-599", s)
+}
+
+// validStatus the status can be written by net/http
+func validStatus(status int) bool {
+	return status >= 100 && status <= 999
+}
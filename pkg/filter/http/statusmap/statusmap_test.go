@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package statusmap
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestStatusMap(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{
+		Rules: []*Rule{
+			{From: 599, To: http.StatusServiceUnavailable},
+			{From: 598, To: http.StatusGatewayTimeout, Body: `{"message":"upstream timeout"}`, Headers: map[string]string{"Retry-After": "1"}},
+		},
+		Default: &Default{Range: "5xx", To: http.StatusBadGateway},
+	}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{cfg: factory.cfg}
+
+	tests := []struct {
+		from       int
+		to         int
+		body       string
+		retryAfter string
+	}{
+		{from: 599, to: http.StatusServiceUnavailable, body: "busy"},
+		{from: 598, to: http.StatusGatewayTimeout, body: `{"message":"upstream timeout"}`, retryAfter: "1"},
+		{from: http.StatusInternalServerError, to: http.StatusBadGateway, body: "busy"},
+		{from: http.StatusNotFound, to: http.StatusNotFound, body: "busy"},
+		{from: http.StatusOK, to: http.StatusOK, body: "busy"},
+	}
+	for _, tt := range tests {
+		request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/test-dubbo/student", nil)
+		assert.NoError(t, err)
+		ctx := mock.GetMockHTTPContext(request)
+		ctx.StatusCode(tt.from)
+		ctx.Writer.Header().Set("Content-Length", "4")
+		ctx.TargetResp = &client.Response{Data: []byte("busy")}
+		f.Encode(ctx)
+		assert.Equal(t, tt.to, ctx.GetStatusCode(), "from %d", tt.from)
+		assert.Equal(t, tt.body, string(ctx.TargetResp.Data))
+		assert.Equal(t, tt.retryAfter, ctx.Writer.Header().Get("Retry-After"))
+		if tt.body != "busy" {
+			assert.Empty(t, ctx.Writer.Header().Get("Content-Length"))
+		} else {
+			assert.Equal(t, "4", ctx.Writer.Header().Get("Content-Length"))
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	min, max, err := parseRange("4xx")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{400, 499}, []int{min, max})
+	min, max, err = parseRange("500-504")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{500, 504}, []int{min, max})
+	for _, invalid := range []string{"", "xx", "0xx", "504-500", "5xx-6xx"} {
+		_, _, err = parseRange(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	invalid := []*Config{
+		{},
+		{Rules: []*Rule{{From: 599}}},
+		{Default: &Default{Range: "5xx"}},
+		{Default: &Default{Range: "50x", To: http.StatusBadGateway}},
+	}
+	for _, cfg := range invalid {
+		assert.Error(t, (&FilterFactory{cfg: cfg}).Apply())
+	}
+}
@@ -0,0 +1,193 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stub
+
+import (
+	"bytes"
+	"io/ioutil"
+	stdHttp "net/http"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/yaml"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPStubFilter
+
+	// wildcard the path ends with it matches by prefix
+	wildcard = "*"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg   *Config
+		rules []*Rule
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		rules []*Rule
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		Rules []*Rule `yaml:"rules" json:"rules" mapstructure:"rules"`
+		// File the yaml file of the rules, the rules in it are appended after Rules
+		File string `yaml:"file" json:"file" mapstructure:"file"`
+	}
+
+	// stubFile the content of the stub file
+	stubFile struct {
+		Rules []*Rule `yaml:"rules" json:"rules"`
+	}
+
+	// Rule the canned response of the matched request
+	Rule struct {
+		Match Match `yaml:"match" json:"match" mapstructure:"match"`
+		// Status the response status, 200 by default
+		Status  int               `yaml:"status" json:"status" mapstructure:"status"`
+		Headers map[string]string `yaml:"headers" json:"headers" mapstructure:"headers"`
+		Body    string            `yaml:"body" json:"body" mapstructure:"body"`
+		// Delay the time to wait before responding, e.g. 200ms
+		Delay string `yaml:"delay" json:"delay" mapstructure:"delay"`
+
+		delay time.Duration
+	}
+
+	// Match the request matcher, path ends with * matches by prefix, empty method matches all
+	Match struct {
+		Path   string `yaml:"path" json:"path" mapstructure:"path"`
+		Method string `yaml:"method" json:"method" mapstructure:"method"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	rules := append([]*Rule{}, factory.cfg.Rules...)
+	if factory.cfg.File != "" {
+		sf := &stubFile{}
+		if err := yaml.UnmarshalYMLConfig(factory.cfg.File, sf); err != nil {
+			return errors.Wrapf(err, "load stub file %s fail", factory.cfg.File)
+		}
+		rules = append(rules, sf.Rules...)
+	}
+
+	for _, rule := range rules {
+		if rule.Match.Path == "" {
+			return errors.New("stub rule without path")
+		}
+		if rule.Status == 0 {
+			rule.Status = stdHttp.StatusOK
+		}
+		if rule.Delay != "" {
+			d, err := time.ParseDuration(rule.Delay)
+			if err != nil {
+				return errors.Wrapf(err, "stub rule %s delay parse fail", rule.Match.Path)
+			}
+			rule.delay = d
+		}
+	}
+	factory.rules = rules
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{rules: factory.rules}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	rule := f.match(ctx.Request)
+	if rule == nil {
+		return filter.Continue
+	}
+	logger.Debugw("stub filter respond", "method", ctx.GetMethod(), "url", ctx.GetUrl(), "status", rule.Status)
+
+	if rule.delay > 0 {
+		timer := time.NewTimer(rule.delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Request.Context().Done():
+		}
+	}
+
+	header := stdHttp.Header{}
+	for k, v := range rule.Headers {
+		header.Set(k, v)
+	}
+	// the canned response is written by hcm as the upstream response
+	ctx.SourceResp = &stdHttp.Response{
+		StatusCode: rule.Status,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(rule.Body))),
+		Request:    ctx.Request,
+	}
+	return filter.Stop
+}
+
+func (f *Filter) match(r *stdHttp.Request) *Rule {
+	for _, rule := range f.rules {
+		if rule.Match.Method != "" && !strings.EqualFold(rule.Match.Method, r.Method) {
+			continue
+		}
+		if strings.HasSuffix(rule.Match.Path, wildcard) {
+			if strings.HasPrefix(r.URL.Path, strings.TrimSuffix(rule.Match.Path, wildcard)) {
+				return rule
+			}
+			continue
+		}
+		if r.URL.Path == rule.Match.Path {
+			return rule
+		}
+	}
+	return nil
+}
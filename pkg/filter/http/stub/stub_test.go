@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stub
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+const stubYaml = `
+rules:
+  - match:
+      path: /api/v1/student/*
+    headers:
+      Content-Type: application/json
+    body: '{"name":"tc","age":18}'
+    delay: 50ms
+`
+
+func TestStub(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stub.yaml")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(stubYaml), 0600))
+
+	factory := &FilterFactory{cfg: &Config{
+		Rules: []*Rule{{Match: Match{Path: "/api/v1/user", Method: "POST"}, Status: http.StatusCreated, Body: "created"}},
+		File:  file,
+	}}
+	assert.Nil(t, factory.Apply())
+	assert.Len(t, factory.rules, 2)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+		delay  time.Duration
+	}{
+		{name: "inline", method: "POST", path: "/api/v1/user", status: http.StatusCreated, body: "created"},
+		{name: "method not match", method: "GET", path: "/api/v1/user"},
+		{name: "file prefix", method: "GET", path: "/api/v1/student/1", status: http.StatusOK, body: `{"name":"tc","age":18}`, delay: 50 * time.Millisecond},
+		{name: "pass through", method: "GET", path: "/api/v1/teacher"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, "http://www.dubbogopixiu.com"+tt.path, nil)
+			assert.NoError(t, err)
+			ctx := mock.GetMockHTTPContext(request)
+			f := &Filter{rules: factory.rules}
+
+			start := time.Now()
+			status := f.Decode(ctx)
+			assert.True(t, time.Since(start) >= tt.delay)
+
+			if tt.status == 0 {
+				assert.Equal(t, filter.Continue, status)
+				assert.Nil(t, ctx.SourceResp)
+				return
+			}
+			assert.Equal(t, filter.Stop, status)
+			resp := ctx.SourceResp.(*http.Response)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			body, _ := ioutil.ReadAll(resp.Body)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{File: "not-exist.yaml"}}
+	assert.Error(t, factory.Apply())
+
+	factory = &FilterFactory{cfg: &Config{Rules: []*Rule{{Match: Match{Path: "/a"}, Delay: "1x"}}}}
+	assert.Error(t, factory.Apply())
+}
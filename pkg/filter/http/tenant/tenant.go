@@ -0,0 +1,214 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tenant
+
+import (
+	"encoding/json"
+	"net"
+	stdHttp "net/http"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPTenantFilter
+
+	// SourceHost derive the tenant from the sub domain
+	SourceHost = "host"
+	// SourcePath derive the tenant from the path segment after the prefix
+	SourcePath = "path"
+
+	defaultHeader     = "X-Tenant-Id"
+	defaultPathPrefix = "/"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg     *Config
+		tenants map[string]struct{}
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg     *Config
+		tenants map[string]struct{}
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Source where the tenant id is derived from, host or path, host by default
+		Source string `yaml:"source" json:"source" mapstructure:"source"`
+		// HostSuffix the domain of tenants for host source, e.g. .api.example.com, the tenant is the first label if empty
+		HostSuffix string `yaml:"host_suffix" json:"host_suffix" mapstructure:"host_suffix"`
+		// PathPrefix the prefix before the tenant segment for path source, e.g. /tenants/, "/" by default
+		PathPrefix string `yaml:"path_prefix" json:"path_prefix" mapstructure:"path_prefix"`
+		// StripPath remove the prefix and tenant segment from the path sent to upstream
+		StripPath bool `yaml:"strip_path" json:"strip_path" mapstructure:"strip_path"`
+		// Tenants the allowed tenant ids, it is required
+		Tenants []string `yaml:"tenants" json:"tenants" mapstructure:"tenants"`
+		// Header the header carrying the tenant id to upstream, X-Tenant-Id by default.
+		// The request whose header claims another tenant is rejected as cross-tenant access.
+		Header string `yaml:"header" json:"header" mapstructure:"header"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter rejects the requests of other tenants, it should run before reading body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageAuth
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Source == "" {
+		cfg.Source = SourceHost
+	}
+	if cfg.Source != SourceHost && cfg.Source != SourcePath {
+		return errors.Errorf("unsupported tenant source %s", cfg.Source)
+	}
+	if cfg.PathPrefix == "" {
+		cfg.PathPrefix = defaultPathPrefix
+	}
+	if !strings.HasSuffix(cfg.PathPrefix, "/") {
+		cfg.PathPrefix += "/"
+	}
+	if cfg.HostSuffix != "" && !strings.HasPrefix(cfg.HostSuffix, ".") {
+		cfg.HostSuffix = "." + cfg.HostSuffix
+	}
+	if cfg.Header == "" {
+		cfg.Header = defaultHeader
+	}
+	if len(cfg.Tenants) == 0 {
+		return errors.New("tenants is required")
+	}
+	factory.tenants = make(map[string]struct{}, len(cfg.Tenants))
+	for _, t := range cfg.Tenants {
+		factory.tenants[strings.ToLower(t)] = struct{}{}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, tenants: factory.tenants}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	tenant, rest := f.derive(ctx.Request)
+	if tenant == "" {
+		return reply(ctx, stdHttp.StatusBadRequest, "tenant is required")
+	}
+	if _, ok := f.tenants[tenant]; !ok {
+		logger.Warnw("tenant filter reject unknown tenant", "tenant", tenant)
+		return reply(ctx, stdHttp.StatusForbidden, "unknown tenant")
+	}
+	if claimed := ctx.GetHeader(f.cfg.Header); claimed != "" && !strings.EqualFold(claimed, tenant) {
+		logger.Warnw("tenant filter reject cross-tenant access", "claimed", claimed, "tenant", tenant)
+		return reply(ctx, stdHttp.StatusForbidden, "cross-tenant access")
+	}
+
+	r := ctx.Request
+	if f.cfg.Source == SourcePath && f.cfg.StripPath {
+		r.URL.Path, r.URL.RawPath = rest, ""
+	}
+	r.Header.Set(f.cfg.Header, tenant)
+	ctx.Request = r.WithContext(client.WithAttachment(r.Context(), constant.TenantParam, tenant))
+	if ctx.Params == nil {
+		ctx.Params = make(map[string]interface{})
+	}
+	ctx.Params[constant.TenantParam] = tenant
+	return filter.Continue
+}
+
+// derive the lower case tenant id and the path after the tenant segment
+func (f *Filter) derive(r *stdHttp.Request) (string, string) {
+	if f.cfg.Source == SourcePath {
+		if !strings.HasPrefix(r.URL.Path, f.cfg.PathPrefix) {
+			return "", r.URL.Path
+		}
+		segment := strings.TrimPrefix(r.URL.Path, f.cfg.PathPrefix)
+		rest := "/"
+		if i := strings.IndexByte(segment, '/'); i >= 0 {
+			segment, rest = segment[:i], segment[i:]
+		}
+		return strings.ToLower(segment), rest
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if f.cfg.HostSuffix != "" {
+		suffix := strings.ToLower(f.cfg.HostSuffix)
+		if !strings.HasSuffix(host, suffix) {
+			return "", r.URL.Path
+		}
+		host = strings.TrimSuffix(host, suffix)
+		if strings.Contains(host, ".") {
+			return "", r.URL.Path
+		}
+		return host, r.URL.Path
+	}
+	if i := strings.IndexByte(host, '.'); i > 0 {
+		return host[:i], r.URL.Path
+	}
+	return "", r.URL.Path
+}
+
+func reply(ctx *http.HttpContext, status int, msg string) filter.FilterStatus {
+	bt, _ := json.Marshal(http.ErrResponse{Message: msg})
+	return filter.Abort(ctx, &filter.AbortResponse{
+		Status:  status,
+		Body:    bt,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	})
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tenant
+
+import (
+	stdHttp "net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestTenant(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    *Config
+		url    string
+		header string
+		status int
+		tenant string
+		path   string
+	}{
+		{name: "host", cfg: &Config{Tenants: []string{"acme"}}, url: "http://ACME.example.com:8888/api/user", tenant: "acme", path: "/api/user"},
+		{name: "host suffix", cfg: &Config{HostSuffix: "api.example.com", Tenants: []string{"acme"}}, url: "http://acme.api.example.com/user", tenant: "acme", path: "/user"},
+		{name: "host suffix not matched", cfg: &Config{HostSuffix: "api.example.com", Tenants: []string{"acme"}}, url: "http://acme.example.com/user", status: stdHttp.StatusBadRequest},
+		{name: "path strip", cfg: &Config{Source: SourcePath, PathPrefix: "/tenants", StripPath: true, Tenants: []string{"acme"}}, url: "http://localhost/tenants/acme/orders/1", tenant: "acme", path: "/orders/1"},
+		{name: "path keep", cfg: &Config{Source: SourcePath, Tenants: []string{"acme"}}, url: "http://localhost/acme/orders", tenant: "acme", path: "/acme/orders"},
+		{name: "unknown tenant", cfg: &Config{Tenants: []string{"acme"}}, url: "http://globex.example.com/api", status: stdHttp.StatusForbidden},
+		{name: "cross tenant", cfg: &Config{Tenants: []string{"acme", "globex"}}, url: "http://acme.example.com/api", header: "globex", status: stdHttp.StatusForbidden},
+		{name: "same claimed tenant", cfg: &Config{Tenants: []string{"acme"}}, url: "http://acme.example.com/api", header: "ACME", tenant: "acme", path: "/api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &FilterFactory{cfg: tt.cfg}
+			assert.Nil(t, factory.Apply())
+
+			request, err := stdHttp.NewRequest("GET", tt.url, nil)
+			assert.NoError(t, err)
+			if tt.header != "" {
+				request.Header.Set(defaultHeader, tt.header)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			chain := filter.NewDefaultFilterChain()
+			_ = factory.PrepareFilterChain(ctx, chain)
+			chain.OnDecode(ctx)
+
+			if tt.status != 0 {
+				assert.True(t, ctx.LocalReply())
+				assert.Equal(t, tt.status, ctx.GetStatusCode())
+				return
+			}
+			assert.False(t, ctx.LocalReply())
+			assert.Equal(t, tt.tenant, ctx.Params[constant.TenantParam])
+			assert.Equal(t, tt.tenant, ctx.Request.Header.Get(defaultHeader))
+			assert.Equal(t, tt.tenant, client.Attachments(ctx.Request.Context())[constant.TenantParam])
+			assert.Equal(t, tt.path, ctx.Request.URL.Path)
+		})
+	}
+}
+
+func TestApplyRequireTenants(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Source: "query", Tenants: []string{"acme"}}}).Apply())
+}
@@ -0,0 +1,258 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package timeout
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	stdHttp "net/http"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPTimeoutFilter
+
+	defaultBody = `{"message":"request timeout"}`
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg         *Config
+		timeout     time.Duration
+		partialWait time.Duration
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg         *Config
+		timeout     time.Duration
+		partialWait time.Duration
+
+		cancel context.CancelFunc
+		timer  *time.Timer
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Timeout the max time of the whole filter chain, the filter should be configured as the first one
+		Timeout string `yaml:"timeout" json:"timeout" mapstructure:"timeout"`
+		// Status the status of timeout response, default is 504
+		Status int `yaml:"status" json:"status" mapstructure:"status"`
+		// Body the body of timeout response
+		Body string `yaml:"body" json:"body" mapstructure:"body"`
+		// Headers the headers of timeout response
+		Headers map[string]string `yaml:"headers" json:"headers" mapstructure:"headers"`
+		// PartialWait how long to wait for the partial result after the timeout, e.g. the aggregate filter
+		// replies the finished upstreams once the deadline is exceeded. 0 means reply the timeout response at once
+		PartialWait string `yaml:"partial_wait" json:"partial_wait" mapstructure:"partial_wait"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return errors.Wrap(err, "timeout parse fail")
+	}
+	if timeout <= 0 {
+		return errors.Errorf("invalid timeout %s", cfg.Timeout)
+	}
+	factory.timeout = timeout
+	if cfg.PartialWait != "" {
+		if factory.partialWait, err = time.ParseDuration(cfg.PartialWait); err != nil {
+			return errors.Wrap(err, "partial wait parse fail")
+		}
+	}
+	if cfg.Status == 0 {
+		cfg.Status = stdHttp.StatusGatewayTimeout
+	}
+	if cfg.Body == "" {
+		cfg.Body = defaultBody
+		if _, ok := cfg.Headers[constant.HeaderKeyContextType]; !ok {
+			if cfg.Headers == nil {
+				cfg.Headers = map[string]string{}
+			}
+			cfg.Headers[constant.HeaderKeyContextType] = constant.HeaderValueJsonUtf8
+		}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, timeout: factory.timeout, partialWait: factory.partialWait}
+	chain.AppendDecodeFilters(f)
+	chain.AppendEncodeFilters(f)
+	return nil
+}
+
+// Decode set the deadline of the request, so that the filters respecting the request context end in time.
+// The timeout response is written when the chain still has not replied after the deadline.
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	c, cancel := context.WithTimeout(ctx.Request.Context(), f.timeout)
+	f.cancel = cancel
+	ctx.Request = ctx.Request.WithContext(c)
+
+	w := newTimeoutWriter(ctx.Writer)
+	ctx.Writer = w
+	url := ctx.GetUrl()
+	f.timer = time.AfterFunc(f.timeout+f.partialWait, func() {
+		if w.timeout(f.cfg.Status, f.cfg.Headers, []byte(f.cfg.Body)) {
+			logger.Warnw("request exceeds the max response time", "url", url, "timeout", f.timeout.String())
+		}
+	})
+	return filter.Continue
+}
+
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	if f.cancel != nil {
+		f.cancel()
+	}
+	return filter.Continue
+}
+
+// EncodeStream stop the timer before the streamed response is written, the request context is canceled
+// after the whole body is written
+func (f *Filter) EncodeStream(ctx *http.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	return filter.CloseFuncWriter(w, f.cancel), filter.Continue
+}
+
+// timeoutWriter only the first of timeout response and chain response is written, the other is dropped
+type timeoutWriter struct {
+	w      stdHttp.ResponseWriter
+	header stdHttp.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func newTimeoutWriter(w stdHttp.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{w: w, header: stdHttp.Header{}}
+}
+
+func (tw *timeoutWriter) Header() stdHttp.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	tw.w.WriteHeader(status)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	if !tw.written() {
+		tw.WriteHeader(stdHttp.StatusOK)
+	}
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		// the late response is dropped silently, the writers of the chain should not log it as a failure
+		return len(b), nil
+	}
+	return tw.w.Write(b)
+}
+
+// Hijack pass through to the origin writer, the timeout response is not written after hijacked
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := tw.w.(stdHttp.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijack")
+	}
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return nil, nil, stdHttp.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	return hj.Hijack()
+}
+
+func (tw *timeoutWriter) written() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.wroteHeader || tw.timedOut
+}
+
+// timeout write the timeout response if the chain has not replied, it returns whether the response is written
+func (tw *timeoutWriter) timeout(status int, headers map[string]string, body []byte) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader {
+		return false
+	}
+	tw.timedOut = true
+	for k, v := range headers {
+		tw.w.Header().Set(k, v)
+	}
+	tw.w.WriteHeader(status)
+	if _, err := tw.w.Write(body); err != nil {
+		logger.Warnw("write timeout response fail", "error", err.Error())
+	}
+	return true
+}
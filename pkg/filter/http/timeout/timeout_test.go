@@ -0,0 +1,149 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package timeout
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+// slowFilter reply after the delay, it stops early when the request context is done if respectCtx
+type slowFilter struct {
+	delay      time.Duration
+	respectCtx bool
+	partial    bool
+}
+
+func (f *slowFilter) Decode(ctx *contexthttp.HttpContext) filter.FilterStatus {
+	select {
+	case <-time.After(f.delay):
+		ctx.StatusCode(http.StatusOK)
+		ctx.SourceResp = "full"
+	case <-done(ctx, f.respectCtx):
+		if f.partial {
+			ctx.StatusCode(http.StatusOK)
+			ctx.SourceResp = "partial"
+			return filter.Continue
+		}
+		ctx.SendLocalReply(http.StatusBadGateway, []byte("canceled"))
+		return filter.Stop
+	}
+	return filter.Continue
+}
+
+func done(ctx *contexthttp.HttpContext, respectCtx bool) <-chan struct{} {
+	if respectCtx {
+		return ctx.Request.Context().Done()
+	}
+	return nil
+}
+
+// recorder record when the response header is written
+type recorder struct {
+	*httptest.ResponseRecorder
+	mu        sync.Mutex
+	writtenAt time.Time
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.mu.Lock()
+	r.writtenAt = time.Now()
+	r.mu.Unlock()
+	r.ResponseRecorder.WriteHeader(status)
+}
+
+// serve run the chain and write the response as the http connection manager does
+func serve(t *testing.T, cfg *Config, slow *slowFilter) (*recorder, time.Duration) {
+	factory := &FilterFactory{cfg: cfg}
+	assert.Nil(t, factory.Apply())
+
+	request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+	assert.NoError(t, err)
+	rec := &recorder{ResponseRecorder: httptest.NewRecorder()}
+	ctx := &contexthttp.HttpContext{Request: request, Writer: rec}
+	ctx.Reset()
+
+	start := time.Now()
+	chain := filter.NewDefaultFilterChain()
+	assert.Nil(t, factory.PrepareFilterChain(ctx, chain))
+	chain.AppendDecodeFilters(slow)
+	chain.OnDecode(ctx)
+	chain.OnEncode(ctx)
+	if !ctx.LocalReply() {
+		ctx.Writer.WriteHeader(ctx.GetStatusCode())
+		_, _ = ctx.Writer.Write([]byte(ctx.SourceResp.(string)))
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec, rec.writtenAt.Sub(start)
+}
+
+func TestTimeoutResponse(t *testing.T) {
+	// the filter ignores the deadline, the timeout response is still written in time
+	rec, cost := serve(t, &Config{Timeout: "50ms"}, &slowFilter{delay: 300 * time.Millisecond})
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	assert.Equal(t, defaultBody, rec.Body.String())
+	assert.Equal(t, "application/json;charset=UTF-8", rec.Header().Get("Content-Type"))
+	assert.True(t, cost < 200*time.Millisecond, cost)
+
+	rec, _ = serve(t, &Config{Timeout: "50ms", Status: http.StatusServiceUnavailable, Body: "busy", Headers: map[string]string{"Retry-After": "1"}},
+		&slowFilter{delay: 300 * time.Millisecond})
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "busy", rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
+
+func TestWithinTimeout(t *testing.T) {
+	rec, _ := serve(t, &Config{Timeout: "500ms"}, &slowFilter{delay: 10 * time.Millisecond, respectCtx: true})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "full", rec.Body.String())
+}
+
+func TestPartialResponse(t *testing.T) {
+	rec, cost := serve(t, &Config{Timeout: "50ms", PartialWait: "200ms"}, &slowFilter{delay: time.Second, respectCtx: true, partial: true})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "partial", rec.Body.String())
+	assert.True(t, cost < 200*time.Millisecond, cost)
+}
+
+func TestLateWrite(t *testing.T) {
+	tw := newTimeoutWriter(httptest.NewRecorder())
+	assert.True(t, tw.timeout(http.StatusGatewayTimeout, nil, []byte(defaultBody)))
+	n, err := tw.Write([]byte("late"))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+}
+
+func TestApply(t *testing.T) {
+	assert.Error(t, (&FilterFactory{cfg: &Config{}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Timeout: "-1s"}}).Apply())
+	assert.Error(t, (&FilterFactory{cfg: &Config{Timeout: "1s", PartialWait: "abc"}}).Apply())
+}
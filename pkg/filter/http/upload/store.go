@@ -0,0 +1,173 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// now the clock, replaced in tests
+var now = time.Now
+
+var (
+	errTotalMismatch = errors.New("total size mismatch with the previous chunks")
+	errStoreFull     = errors.New("too many incomplete uploads")
+)
+
+type (
+	// store keep the partial uploads in temp files, the chunk is written at its offset,
+	// so the chunks can arrive in any order. The count of uploads and the sum of their sizes are bounded.
+	store struct {
+		mu         sync.Mutex
+		dir        string
+		ttl        time.Duration
+		maxUploads int
+		maxBytes   int64
+		reserved   int64
+		uploads    map[string]*partial
+	}
+
+	// partial the incomplete upload
+	partial struct {
+		file     *os.File
+		total    int64
+		spans    []span
+		expireAt time.Time
+		// writers the count of chunks being written, the upload is not evicted or completed while writing
+		writers int
+	}
+
+	// span the received bytes [start, end)
+	span struct {
+		start, end int64
+	}
+)
+
+func newStore(dir string, ttl time.Duration, maxUploads int, maxBytes int64) *store {
+	return &store{dir: dir, ttl: ttl, maxUploads: maxUploads, maxBytes: maxBytes, uploads: make(map[string]*partial)}
+}
+
+// get return the partial upload of id to write a chunk, it is created if not exists,
+// the caller must call received or abort after writing
+func (s *store) get(id string, total int64) (*partial, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	s.evict(t)
+	p, ok := s.uploads[id]
+	if !ok {
+		if len(s.uploads) >= s.maxUploads || s.reserved+total > s.maxBytes {
+			return nil, errStoreFull
+		}
+		f, err := ioutil.TempFile(s.dir, "pixiu-upload-")
+		if err != nil {
+			return nil, errors.Wrap(err, "create upload temp file fail")
+		}
+		p = &partial{file: f, total: total}
+		s.uploads[id] = p
+		s.reserved += total
+	}
+	if p.total != total {
+		return nil, errTotalMismatch
+	}
+	p.expireAt = t.Add(s.ttl)
+	p.writers++
+	return p, nil
+}
+
+// abort end the failed write of chunk
+func (s *store) abort(p *partial) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.writers--
+}
+
+// received record the chunk, the file of the completed upload is returned and the upload is removed from store
+func (s *store) received(id string, p *partial, start, end int64) (int64, *os.File) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p.writers--
+	p.spans = mergeSpan(p.spans, span{start: start, end: end})
+	if len(p.spans) == 1 && p.spans[0].start == 0 && p.spans[0].end == p.total && p.writers == 0 {
+		// the last writer of the upload takes the file
+		s.remove(id, p)
+		return p.total, p.file
+	}
+	var n int64
+	for _, sp := range p.spans {
+		n += sp.end - sp.start
+	}
+	return n, nil
+}
+
+// evict remove the expired uploads and their temp files
+func (s *store) evict(t time.Time) {
+	for id, p := range s.uploads {
+		if t.Before(p.expireAt) || p.writers > 0 {
+			continue
+		}
+		s.remove(id, p)
+		removeFile(p.file)
+		logger.Debugw("incomplete upload expired")
+	}
+}
+
+func (s *store) remove(id string, p *partial) {
+	if s.uploads[id] == p {
+		delete(s.uploads, id)
+		s.reserved -= p.total
+	}
+}
+
+func mergeSpan(spans []span, sp span) []span {
+	spans = append(spans, sp)
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	merged := spans[:1]
+	for _, cur := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if cur.start <= last.end {
+			if cur.end > last.end {
+				last.end = cur.end
+			}
+			continue
+		}
+		merged = append(merged, cur)
+	}
+	return merged
+}
+
+func removeFile(f *os.File) {
+	_ = f.Close()
+	if err := os.Remove(f.Name()); err != nil && !os.IsNotExist(err) {
+		logger.Warnw("remove upload temp file fail", "path", f.Name(), "error", err.Error())
+	}
+}
@@ -0,0 +1,289 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	stdHttp "net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPUploadFilter
+
+	defaultIDHeader     = "X-Upload-Id"
+	defaultTTL          = 10 * time.Minute
+	defaultMaxSize      = 100 << 20
+	defaultMaxUploads   = 100
+	defaultMaxTotalSize = 1 << 30
+
+	headerContentRange = "Content-Range"
+)
+
+// defaultIdentityHeaders the headers identifying the caller
+var defaultIdentityHeaders = []string{"Authorization", "X-Consumer-Id"}
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg   *Config
+		store *store
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg   *Config
+		store *store
+		// assembled the temp file forwarded as the body, it is removed when the chain is released
+		assembled *os.File
+	}
+
+	// Config describe the config of FilterFactory. The client sends each chunk with the upload id header
+	// and Content-Range: bytes <start>-<end>/<total>, the chunks can be sent in any order. The incomplete
+	// chunks are answered with 202, and the assembled body is forwarded with the last chunk. The upload id
+	// is scoped by the identity headers, so a caller can not write into the upload of another.
+	Config struct {
+		// IDHeader the header of upload id, X-Upload-Id by default
+		IDHeader string `yaml:"id_header" json:"id_header" mapstructure:"id_header"`
+		// TTL how long the incomplete upload is kept since its last chunk, 10m by default
+		TTL string `yaml:"ttl" json:"ttl" mapstructure:"ttl"`
+		// MaxSize the max total size of an upload in bytes, 100MB by default
+		MaxSize int64 `yaml:"max_size" json:"max_size" mapstructure:"max_size"`
+		// MaxUploads the max count of incomplete uploads, 100 by default
+		MaxUploads int `yaml:"max_uploads" json:"max_uploads" mapstructure:"max_uploads"`
+		// MaxTotalSize the max sum of the total size of incomplete uploads in bytes, 1GB by default
+		MaxTotalSize int64 `yaml:"max_total_size" json:"max_total_size" mapstructure:"max_total_size"`
+		// IdentityHeaders the headers identifying the caller, Authorization and X-Consumer-Id by default
+		IdentityHeaders []string `yaml:"identity_headers" json:"identity_headers" mapstructure:"identity_headers"`
+		// Dir the directory of the temp files, the os temp dir by default
+		Dir string `yaml:"dir" json:"dir" mapstructure:"dir"`
+	}
+
+	// progress the response body of the incomplete upload
+	progress struct {
+		UploadID string `json:"upload_id"`
+		Received int64  `json:"received"`
+		Total    int64  `json:"total"`
+	}
+
+	// assembledBody remove the temp file when the body is closed
+	assembledBody struct {
+		*os.File
+	}
+
+	// offsetWriter write sequentially from the offset
+	offsetWriter struct {
+		w      io.WriterAt
+		offset int64
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Stage the filter reads the request body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.IDHeader == "" {
+		cfg.IDHeader = defaultIDHeader
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = defaultMaxSize
+	}
+	if cfg.MaxUploads <= 0 {
+		cfg.MaxUploads = defaultMaxUploads
+	}
+	if cfg.MaxTotalSize <= 0 {
+		cfg.MaxTotalSize = defaultMaxTotalSize
+	}
+	if len(cfg.IdentityHeaders) == 0 {
+		cfg.IdentityHeaders = defaultIdentityHeaders
+	}
+	ttl := defaultTTL
+	if cfg.TTL != "" {
+		d, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return errors.Wrap(err, "upload ttl parse fail")
+		}
+		ttl = d
+	}
+	factory.store = newStore(cfg.Dir, ttl, cfg.MaxUploads, cfg.MaxTotalSize)
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, store: factory.store}
+	chain.AppendDecodeFilters(f)
+	// the assembled file is removed even if the upstream never closes the body
+	filter.Defer(chain, f.release)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	id := ctx.GetHeader(f.cfg.IDHeader)
+	if id == "" {
+		return filter.Continue
+	}
+	start, end, total, err := parseContentRange(ctx.GetHeader(headerContentRange))
+	if err != nil {
+		return f.reply(ctx, stdHttp.StatusBadRequest, err.Error())
+	}
+	if total > f.cfg.MaxSize {
+		return f.reply(ctx, stdHttp.StatusRequestEntityTooLarge, fmt.Sprintf("upload exceeds the max size %d", f.cfg.MaxSize))
+	}
+
+	key := f.identity(ctx) + "\n" + id
+	p, err := f.store.get(key, total)
+	if err != nil {
+		switch err {
+		case errTotalMismatch:
+			return f.reply(ctx, stdHttp.StatusConflict, err.Error())
+		case errStoreFull:
+			return f.reply(ctx, stdHttp.StatusServiceUnavailable, err.Error())
+		}
+		logger.Warnw("upload fail", "upload_id", id, "error", err.Error())
+		return f.reply(ctx, stdHttp.StatusInternalServerError, "upload store unavailable")
+	}
+
+	// write the bytes of the range at its offset, the short body is rejected
+	n, err := io.Copy(&offsetWriter{w: p.file, offset: start}, io.LimitReader(ctx.Request.Body, end-start+1))
+	if err != nil || n != end-start+1 {
+		f.store.abort(p)
+		return f.reply(ctx, stdHttp.StatusBadRequest, "chunk size mismatch with content range")
+	}
+
+	received, file := f.store.received(key, p, start, end+1)
+	if file == nil {
+		bt, _ := json.Marshal(progress{UploadID: id, Received: received, Total: total})
+		ctx.SendLocalReply(stdHttp.StatusAccepted, bt)
+		return filter.Stop
+	}
+
+	f.assembled = file
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return f.reply(ctx, stdHttp.StatusInternalServerError, "read assembled upload fail")
+	}
+	r := ctx.Request
+	r.Body = &assembledBody{File: file}
+	r.ContentLength = total
+	r.Header.Set("Content-Length", strconv.FormatInt(total, 10))
+	r.Header.Del(headerContentRange)
+	logger.Debugw("upload assembled", "upload_id", id, "bytes", total)
+	return filter.Continue
+}
+
+// release remove the assembled file
+func (f *Filter) release() {
+	if f.assembled != nil {
+		removeFile(f.assembled)
+	}
+}
+
+// identity the hash of the identity headers, the raw credentials are never kept in the store
+func (f *Filter) identity(ctx *http.HttpContext) string {
+	h := sha256.New()
+	for _, name := range f.cfg.IdentityHeaders {
+		_, _ = io.WriteString(h, name+"="+ctx.GetHeader(name)+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (f *Filter) reply(ctx *http.HttpContext, status int, msg string) filter.FilterStatus {
+	bt, _ := json.Marshal(http.ErrResponse{Message: msg})
+	ctx.SendLocalReply(status, bt)
+	return filter.Stop
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.w.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
+
+// Close close and remove the temp file
+func (b *assembledBody) Close() error {
+	removeFile(b.File)
+	return nil
+}
+
+// parseContentRange parse bytes <start>-<end>/<total>, the end is inclusive
+func parseContentRange(v string) (start, end, total int64, err error) {
+	invalid := errors.Errorf("invalid content range %q", v)
+	if !strings.HasPrefix(v, "bytes ") {
+		return 0, 0, 0, invalid
+	}
+	v = strings.TrimPrefix(v, "bytes ")
+	slash := strings.IndexByte(v, '/')
+	dash := strings.IndexByte(v, '-')
+	if slash < 0 || dash < 0 || dash > slash {
+		return 0, 0, 0, invalid
+	}
+	if start, err = strconv.ParseInt(v[:dash], 10, 64); err != nil {
+		return 0, 0, 0, invalid
+	}
+	if end, err = strconv.ParseInt(v[dash+1:slash], 10, 64); err != nil {
+		return 0, 0, 0, invalid
+	}
+	if total, err = strconv.ParseInt(v[slash+1:], 10, 64); err != nil {
+		return 0, 0, 0, invalid
+	}
+	if start < 0 || end < start || end >= total {
+		return 0, 0, 0, invalid
+	}
+	return start, end, total, nil
+}
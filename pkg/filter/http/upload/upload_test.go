@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func newFilter(t *testing.T, cfg *Config) *Filter {
+	cfg.Dir = t.TempDir()
+	factory := &FilterFactory{cfg: cfg}
+	assert.Nil(t, factory.Apply())
+	return &Filter{cfg: factory.cfg, store: factory.store}
+}
+
+func sendChunk(t *testing.T, f *Filter, id string, content []byte, start, end int) (filter.FilterStatus, *contexthttp.HttpContext) {
+	return sendChunkAs(t, f, "", id, content, start, end)
+}
+
+func sendChunkAs(t *testing.T, f *Filter, auth, id string, content []byte, start, end int) (filter.FilterStatus, *contexthttp.HttpContext) {
+	request, err := http.NewRequest("PUT", "http://www.dubbogopixiu.com/api/v1/file", bytes.NewReader(content[start:end+1]))
+	assert.NoError(t, err)
+	request.Header.Set(defaultIDHeader, id)
+	if auth != "" {
+		request.Header.Set("Authorization", auth)
+	}
+	request.Header.Set(headerContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+	ctx := mock.GetMockHTTPContext(request)
+	return f.Decode(ctx), ctx
+}
+
+func TestOutOfOrderChunks(t *testing.T) {
+	f := newFilter(t, &Config{})
+	content := []byte("0123456789abcdefghij")
+
+	status, ctx := sendChunk(t, f, "u1", content, 15, 19)
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusAccepted, ctx.GetStatusCode())
+	assert.JSONEq(t, `{"upload_id":"u1","received":5,"total":20}`, string(ctx.GetLocalReplyBody()))
+
+	status, _ = sendChunk(t, f, "u1", content, 0, 4)
+	assert.Equal(t, filter.Stop, status)
+	// overlapped chunk is fine
+	status, _ = sendChunk(t, f, "u1", content, 3, 9)
+	assert.Equal(t, filter.Stop, status)
+
+	status, ctx = sendChunk(t, f, "u1", content, 10, 14)
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, int64(len(content)), ctx.Request.ContentLength)
+	assert.Empty(t, ctx.Request.Header.Get(headerContentRange))
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, content, body)
+
+	// the temp file is removed when the forwarded body is closed
+	name := ctx.Request.Body.(*assembledBody).Name()
+	assert.NoError(t, ctx.Request.Body.Close())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+	assert.Empty(t, f.store.uploads)
+	assert.Zero(t, f.store.reserved)
+}
+
+func TestAssembledFileReleased(t *testing.T) {
+	f := newFilter(t, &Config{})
+	content := []byte("0123456789")
+
+	status, ctx := sendChunk(t, f, "u1", content, 0, 9)
+	assert.Equal(t, filter.Continue, status)
+	// the body is not closed, the file is removed when the chain is released
+	name := ctx.Request.Body.(*assembledBody).Name()
+	f.release()
+	_, err := os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUploadScopedByCaller(t *testing.T) {
+	f := newFilter(t, &Config{})
+	content := []byte("0123456789")
+
+	status, _ := sendChunkAs(t, f, "Bearer alice", "u1", content, 0, 4)
+	assert.Equal(t, filter.Stop, status)
+	// the same upload id of another caller is another upload
+	status, ctx := sendChunkAs(t, f, "Bearer mallory", "u1", content, 5, 9)
+	assert.Equal(t, filter.Stop, status)
+	assert.JSONEq(t, `{"upload_id":"u1","received":5,"total":10}`, string(ctx.GetLocalReplyBody()))
+	assert.Len(t, f.store.uploads, 2)
+
+	status, _ = sendChunkAs(t, f, "Bearer alice", "u1", content, 5, 9)
+	assert.Equal(t, filter.Continue, status)
+}
+
+func TestUploadStoreBounded(t *testing.T) {
+	f := newFilter(t, &Config{MaxUploads: 2, MaxTotalSize: 25})
+	content := []byte("0123456789")
+
+	status, _ := sendChunk(t, f, "u1", content, 0, 4)
+	assert.Equal(t, filter.Stop, status)
+	status, _ = sendChunk(t, f, "u2", content, 0, 4)
+	assert.Equal(t, filter.Stop, status)
+	status, ctx := sendChunk(t, f, "u3", content, 0, 4)
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusServiceUnavailable, ctx.GetStatusCode())
+
+	// the completed upload frees its room, but the bytes are still bounded
+	status, _ = sendChunk(t, f, "u1", content, 5, 9)
+	assert.Equal(t, filter.Continue, status)
+	status, ctx = sendChunk(t, f, "u3", append(content, content...), 0, 4)
+	assert.Equal(t, http.StatusServiceUnavailable, ctx.GetStatusCode())
+	status, ctx = sendChunk(t, f, "u3", content, 0, 4)
+	assert.Equal(t, http.StatusAccepted, ctx.GetStatusCode())
+}
+
+func TestIncompleteUploadExpired(t *testing.T) {
+	f := newFilter(t, &Config{TTL: "1m"})
+	content := []byte("0123456789")
+
+	n := time.Now()
+	now = func() time.Time { return n }
+	defer func() { now = time.Now }()
+
+	status, _ := sendChunk(t, f, "u1", content, 0, 4)
+	assert.Equal(t, filter.Stop, status)
+	assert.Len(t, f.store.uploads, 1)
+	var name string
+	for _, p := range f.store.uploads {
+		name = p.file.Name()
+	}
+
+	// the incomplete upload is cleaned up after ttl, the later chunk starts a new upload
+	n = n.Add(2 * time.Minute)
+	status, ctx := sendChunk(t, f, "u1", content, 5, 9)
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusAccepted, ctx.GetStatusCode())
+	_, err := os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+	assert.Len(t, f.store.uploads, 1)
+}
+
+func TestInvalidChunk(t *testing.T) {
+	f := newFilter(t, &Config{MaxSize: 100})
+
+	tests := []struct {
+		name   string
+		rng    string
+		body   string
+		status int
+	}{
+		{name: "invalid range", rng: "bytes 5-1/10", body: "x", status: http.StatusBadRequest},
+		{name: "no unit", rng: "0-1/10", body: "xx", status: http.StatusBadRequest},
+		{name: "too large", rng: "bytes 0-1/1000", body: "xx", status: http.StatusRequestEntityTooLarge},
+		{name: "short body", rng: "bytes 0-4/10", body: "xx", status: http.StatusBadRequest},
+		{name: "total mismatch", rng: "bytes 0-1/20", body: "xx", status: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest("PUT", "http://www.dubbogopixiu.com/api/v1/file", bytes.NewReader([]byte(tt.body)))
+			assert.NoError(t, err)
+			request.Header.Set(defaultIDHeader, "u1")
+			request.Header.Set(headerContentRange, tt.rng)
+			ctx := mock.GetMockHTTPContext(request)
+			assert.Equal(t, filter.Stop, f.Decode(ctx))
+			assert.Equal(t, tt.status, ctx.GetStatusCode())
+		})
+	}
+
+	// no upload id, pass through
+	request, err := http.NewRequest("PUT", "http://www.dubbogopixiu.com/api/v1/file", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, filter.Continue, f.Decode(mock.GetMockHTTPContext(request)))
+}
@@ -0,0 +1,42 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package waf
+
+const (
+	// PackSQLi the built-in rules of sql injection
+	PackSQLi = "sqli"
+	// PackXSS the built-in rules of cross site scripting
+	PackXSS = "xss"
+)
+
+// packs the built-in rules by pack name, the patterns are matched case insensitively
+var packs = map[string][]*Rule{
+	PackSQLi: {
+		{ID: "sqli-union-select", Pattern: `\bunion\b[\s\S]{0,40}\bselect\b`},
+		{ID: "sqli-tautology", Pattern: `['"\s]\s*(or|and)\s+['"]?(\w+)['"]?\s*=\s*['"]?(\w+)['"]?\s*(--|#|$)`},
+		{ID: "sqli-stacked-query", Pattern: `;\s*(drop|delete|insert|update|alter|truncate|exec)\s`},
+		{ID: "sqli-time-based", Pattern: `\b(sleep|benchmark|pg_sleep)\s*\(`},
+		{ID: "sqli-comment", Pattern: `'\s*(--|#|/\*)`},
+	},
+	PackXSS: {
+		{ID: "xss-script-tag", Pattern: `<\s*/?\s*script\b`},
+		{ID: "xss-javascript-uri", Pattern: `\bjavascript\s*:`},
+		{ID: "xss-event-handler", Pattern: `\bon(error|load|click|mouseover|focus|blur|submit)\s*=`},
+		{ID: "xss-embedded-tag", Pattern: `<\s*(iframe|object|embed|svg)\b[^>]*\b(src|data|on\w+)\s*=`},
+	},
+}
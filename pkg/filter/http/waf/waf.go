@@ -0,0 +1,343 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package waf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	stdHttp "net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPWAFFilter
+
+	// TargetPath the decoded request path
+	TargetPath = "path"
+	// TargetQuery the decoded query keys and values
+	TargetQuery = "query"
+	// TargetHeader the values of the inspected headers
+	TargetHeader = "header"
+	// TargetBody the keys and string values of the json body, the raw text of the other bodies
+	TargetBody = "body"
+
+	defaultMaxBodySize = 64 * 1024
+)
+
+// errBodyTooLarge the body is larger than MaxBodySize, so it can not be inspected completely
+var errBodyTooLarge = errors.New("request body too large")
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg   *Config
+		rules []*Rule
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		cfg   *Config
+		rules []*Rule
+	}
+
+	// Config describe the config of FilterFactory. The rules are regexp of RE2 syntax, which matches in linear time,
+	// and the inspected body is limited by MaxBodySize, so the inspection of a request is bounded.
+	Config struct {
+		// Packs the built-in rule packs to enable, sqli or xss
+		Packs []string `yaml:"packs" json:"packs" mapstructure:"packs"`
+		// Rules the custom rules
+		Rules []*Rule `yaml:"rules" json:"rules" mapstructure:"rules"`
+		// DetectOnly log the matched request without blocking it
+		DetectOnly bool `yaml:"detect_only" json:"detect_only" mapstructure:"detect_only"`
+		// Headers the headers to inspect, empty means all
+		Headers []string `yaml:"headers" json:"headers" mapstructure:"headers"`
+		// MaxBodySize the max bytes of the body inspected, 64KB by default. The larger body is rejected with 413,
+		// or only its first MaxBodySize bytes are inspected in DetectOnly mode
+		MaxBodySize int64 `yaml:"max_body_size" json:"max_body_size" mapstructure:"max_body_size"`
+	}
+
+	// Rule block the request matching the pattern
+	Rule struct {
+		ID string `yaml:"id" json:"id" mapstructure:"id"`
+		// Pattern the regexp matched case insensitively
+		Pattern string `yaml:"pattern" json:"pattern" mapstructure:"pattern"`
+		// Targets the parts of request to inspect: path, query, header or body, empty means all
+		Targets []string `yaml:"targets" json:"targets" mapstructure:"targets"`
+
+		re      *regexp.Regexp
+		targets map[string]bool
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+// Apply compile the rules once, the rules of packs are copied so that the factories do not share them
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.MaxBodySize < 0 {
+		return errors.New("max body size must not be negative")
+	}
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = defaultMaxBodySize
+	}
+	var rules []*Rule
+	for _, name := range cfg.Packs {
+		pack, ok := packs[name]
+		if !ok {
+			return errors.Errorf("unknown rule pack %s", name)
+		}
+		for _, r := range pack {
+			copied := *r
+			rules = append(rules, &copied)
+		}
+	}
+	rules = append(rules, cfg.Rules...)
+	if len(rules) == 0 {
+		return errors.New("no waf rule configured")
+	}
+	for _, r := range rules {
+		if err := r.compile(); err != nil {
+			return errors.Wrapf(err, "waf rule %s", r.ID)
+		}
+	}
+	factory.rules = rules
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, rules: factory.rules}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (r *Rule) compile() error {
+	if r.ID == "" || r.Pattern == "" {
+		return errors.New("id and pattern are required")
+	}
+	re, err := regexp.Compile("(?i)" + r.Pattern)
+	if err != nil {
+		return errors.Wrap(err, "pattern compile fail")
+	}
+	r.re = re
+	r.targets = make(map[string]bool, len(r.Targets))
+	for _, t := range r.Targets {
+		switch t {
+		case TargetPath, TargetQuery, TargetHeader, TargetBody:
+			r.targets[t] = true
+		default:
+			return errors.Errorf("unknown target %s", t)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) inspects(target string) bool {
+	return len(r.targets) == 0 || r.targets[target]
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	rule, target, err := f.inspect(ctx.Request)
+	if err == errBodyTooLarge {
+		// the uninspected rest may carry the attack, so it never passes the blocking waf
+		return reply(ctx, stdHttp.StatusRequestEntityTooLarge, "request body too large to inspect")
+	}
+	if err != nil {
+		return reply(ctx, stdHttp.StatusBadRequest, "read request body fail")
+	}
+	if rule == nil {
+		return filter.Continue
+	}
+	if f.cfg.DetectOnly {
+		logger.Warnw("waf rule matches, detect only", "rule", rule.ID, "target", target, "method", ctx.Request.Method, "url", ctx.GetUrl())
+		return filter.Continue
+	}
+	logger.Warnw("waf rule blocks request", "rule", rule.ID, "target", target, "method", ctx.Request.Method, "url", ctx.GetUrl())
+	return reply(ctx, stdHttp.StatusForbidden, "request blocked by waf rule "+rule.ID)
+}
+
+// inspect return the first rule matching the request and the target matched
+func (f *Filter) inspect(r *stdHttp.Request) (*Rule, string, error) {
+	if rule := f.match(TargetPath, r.URL.Path); rule != nil {
+		return rule, TargetPath, nil
+	}
+	for k, values := range r.URL.Query() {
+		if rule := f.match(TargetQuery, append(values, k)...); rule != nil {
+			return rule, TargetQuery, nil
+		}
+	}
+	for k, values := range r.Header {
+		if len(f.cfg.Headers) > 0 && !containsFold(f.cfg.Headers, k) {
+			continue
+		}
+		if rule := f.match(TargetHeader, values...); rule != nil {
+			return rule, TargetHeader, nil
+		}
+	}
+	if r.Body == nil || r.Body == stdHttp.NoBody {
+		return nil, "", nil
+	}
+	if !f.cfg.DetectOnly && r.ContentLength > f.cfg.MaxBodySize {
+		return nil, "", errBodyTooLarge
+	}
+	body, err := f.readBody(r)
+	if err != nil {
+		return nil, "", err
+	}
+	var values []string
+	contentType := strings.ToLower(r.Header.Get(constant.HeaderKeyContextType))
+	switch {
+	case strings.Contains(contentType, "json"):
+		values = bodyStrings(body)
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		values = formStrings(body)
+	default:
+		values = []string{string(body)}
+	}
+	if rule := f.match(TargetBody, values...); rule != nil {
+		return rule, TargetBody, nil
+	}
+	return nil, "", nil
+}
+
+// readBody read the inspected part of body, the body is restored for the later filters. It returns
+// errBodyTooLarge if the body is larger than MaxBodySize unless the waf detects only
+func (f *Filter) readBody(r *stdHttp.Request) ([]byte, error) {
+	read, err := ioutil.ReadAll(io.LimitReader(r.Body, f.cfg.MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(read), r.Body), r.Body}
+	if int64(len(read)) > f.cfg.MaxBodySize {
+		if !f.cfg.DetectOnly {
+			return nil, errBodyTooLarge
+		}
+		read = read[:f.cfg.MaxBodySize]
+	}
+	return read, nil
+}
+
+func (f *Filter) match(target string, values ...string) *Rule {
+	for _, rule := range f.rules {
+		if !rule.inspects(target) {
+			continue
+		}
+		for _, v := range values {
+			if rule.re.MatchString(v) {
+				return rule
+			}
+		}
+	}
+	return nil
+}
+
+// bodyStrings the keys and string values of the json body, the raw body is returned if it is not a
+// complete json, e.g. truncated by MaxBodySize
+func bodyStrings(body []byte) []string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []string{string(body)}
+	}
+	var values []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case string:
+			values = append(values, t)
+		case []interface{}:
+			for _, e := range t {
+				walk(e)
+			}
+		case map[string]interface{}:
+			for k, e := range t {
+				values = append(values, k)
+				walk(e)
+			}
+		}
+	}
+	walk(v)
+	return values
+}
+
+// formStrings the raw form body and its decoded keys and values, so that the encoded attack is matched too
+func formStrings(body []byte) []string {
+	values := []string{string(body)}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return values
+	}
+	for k, vs := range form {
+		values = append(values, k)
+		values = append(values, vs...)
+	}
+	return values
+}
+
+func containsFold(values []string, v string) bool {
+	for _, s := range values {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func reply(ctx *http.HttpContext, status int, message string) filter.FilterStatus {
+	bt, _ := json.Marshal(http.ErrResponse{Message: message})
+	return filter.Abort(ctx, &filter.AbortResponse{
+		Status:  status,
+		Body:    bt,
+		Headers: map[string]string{constant.HeaderKeyContextType: constant.HeaderValueJsonUtf8},
+	})
+}
@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package waf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func decode(t *testing.T, factory *FilterFactory, uri, body string, headers map[string]string) *contexthttp.HttpContext {
+	request, err := http.NewRequest("POST", "http://www.dubbogopixiu.com"+uri, bytes.NewReader([]byte(body)))
+	assert.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+	ctx := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	_ = factory.PrepareFilterChain(ctx, chain)
+	chain.OnDecode(ctx)
+	return ctx
+}
+
+func TestWAF(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{
+		Packs:   []string{PackSQLi, PackXSS},
+		Rules:   []*Rule{{ID: "no-debug", Pattern: `^/debug`, Targets: []string{TargetPath}}},
+		Headers: []string{"User-Agent", "Referer"},
+	}}
+	assert.Nil(t, factory.Apply())
+
+	tests := []struct {
+		name    string
+		uri     string
+		body    string
+		headers map[string]string
+		blocked bool
+	}{
+		{name: "clean", uri: "/api/v1/test-dubbo/student?name=O'Brien", body: `{"name":"tc","id":"0001"}`},
+		{name: "sqli query", uri: "/api/v1/test-dubbo/student?id=" + url.QueryEscape("1 UNION SELECT password FROM users"), blocked: true},
+		{name: "xss body", uri: "/api/v1/test-dubbo/student", body: `{"student":{"name":"<script>alert(1)</script>"}}`, blocked: true},
+		{name: "xss header", uri: "/api/v1/test-dubbo/student", headers: map[string]string{"Referer": "javascript:alert(1)"}, blocked: true},
+		{name: "header not inspected", uri: "/api/v1/test-dubbo/student", headers: map[string]string{"X-Note": "javascript:alert(1)"}},
+		{name: "custom rule", uri: "/debug/pprof", blocked: true},
+		{name: "custom rule targets path only", uri: "/api?path=/debug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			if body == "" {
+				body = "{}"
+			}
+			ctx := decode(t, factory, tt.uri, body, tt.headers)
+			if tt.blocked {
+				assert.Equal(t, http.StatusForbidden, ctx.GetStatusCode())
+				assert.True(t, ctx.LocalReply())
+				return
+			}
+			assert.False(t, ctx.LocalReply())
+			// the body is still readable by upstream
+			forwarded, err := ioutil.ReadAll(ctx.Request.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, body, string(forwarded))
+		})
+	}
+}
+
+func TestWAFDetectOnly(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Packs: []string{PackXSS}, DetectOnly: true}}
+	assert.Nil(t, factory.Apply())
+	ctx := decode(t, factory, "/api/v1/test-dubbo/student", `{"name":"<script>alert(1)</script>"}`, nil)
+	assert.False(t, ctx.LocalReply())
+}
+
+func TestWAFBodyLimit(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Packs: []string{PackXSS}, MaxBodySize: 16}}
+	assert.Nil(t, factory.Apply())
+	// the rest after the inspected part may carry the attack, so the blocking waf rejects it
+	body := `{"name":"` + strings.Repeat("a", 32) + `<script>"}`
+	ctx := decode(t, factory, "/api/v1/test-dubbo/student", body, nil)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ctx.GetStatusCode())
+
+	// the body of the limit size is inspected as usual
+	ctx = decode(t, factory, "/api/v1/test-dubbo/student", `{"name":"abcde"}`, nil)
+	assert.False(t, ctx.LocalReply())
+
+	// the waf detecting only inspects the first part, and the whole body is forwarded
+	factory = &FilterFactory{cfg: &Config{Packs: []string{PackXSS}, MaxBodySize: 16, DetectOnly: true}}
+	assert.Nil(t, factory.Apply())
+	ctx = decode(t, factory, "/api/v1/test-dubbo/student", body, nil)
+	assert.False(t, ctx.LocalReply())
+	forwarded, err := ioutil.ReadAll(ctx.Request.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(forwarded))
+}
+
+func TestWAFRawBody(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Packs: []string{PackXSS}}}
+	assert.Nil(t, factory.Apply())
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "text/plain", body: "hello <script>alert(1)</script>"},
+		{contentType: "application/x-www-form-urlencoded", body: "name=" + url.QueryEscape("<script>alert(1)</script>")},
+		{contentType: "", body: "<script>alert(1)</script>"},
+	}
+	for _, tt := range tests {
+		ctx := decode(t, factory, "/api/v1/test-dubbo/student", tt.body, map[string]string{"Content-Type": tt.contentType})
+		assert.Equal(t, http.StatusForbidden, ctx.GetStatusCode(), tt.contentType)
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	invalid := []*Config{
+		{},
+		{Packs: []string{"rce"}},
+		{Rules: []*Rule{{ID: "broken", Pattern: `(`}}},
+		{Rules: []*Rule{{Pattern: `x`}}},
+		{Rules: []*Rule{{ID: "x", Pattern: `x`, Targets: []string{"cookie"}}}},
+		{Packs: []string{PackSQLi}, MaxBodySize: -1},
+	}
+	for _, cfg := range invalid {
+		assert.Error(t, (&FilterFactory{cfg: cfg}).Apply())
+	}
+}
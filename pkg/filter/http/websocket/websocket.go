@@ -0,0 +1,361 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	stdHttp "net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	// Kind is the kind of plugin.
+	Kind = constant.HTTPWebSocketFilter
+
+	defaultIdleTimeout = 60 * time.Second
+	defaultReadTimeout = 10 * time.Second
+	bufferSize         = 32 * 1024
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg         *Config
+		upstream    *url.URL
+		origins     map[string]struct{}
+		allOrigins  bool
+		idleTimeout time.Duration
+		readTimeout time.Duration
+	}
+
+	// Filter is http filter instance
+	Filter struct {
+		upstream    *url.URL
+		origins     map[string]struct{}
+		allOrigins  bool
+		idleTimeout time.Duration
+		readTimeout time.Duration
+	}
+
+	// Config describe the config of FilterFactory
+	Config struct {
+		// Upstream the websocket backend, e.g. ws://127.0.0.1:8080, the request path is used if the path is absent
+		Upstream string `yaml:"upstream" json:"upstream" mapstructure:"upstream"`
+		// AllowedOrigins the allowed Origin headers, * allows all, empty means the same origin as the request host
+		AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins" mapstructure:"allowed_origins"`
+		// IdleTimeout the connection is closed when both sides have no data within it
+		IdleTimeout string `yaml:"idle_timeout" json:"idle_timeout" mapstructure:"idle_timeout"`
+		// ReadTimeout the timeout of connecting upstream and reading its handshake response
+		ReadTimeout string `yaml:"read_timeout" json:"read_timeout" mapstructure:"read_timeout"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	if cfg.Upstream == "" {
+		return errors.New("websocket upstream is empty")
+	}
+	upstream := cfg.Upstream
+	if !strings.Contains(upstream, "://") {
+		upstream = "ws://" + upstream
+	}
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return errors.Wrap(err, "websocket upstream parse fail")
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return errors.Errorf("websocket upstream scheme %s is not supported", u.Scheme)
+	}
+	factory.upstream = u
+
+	if factory.idleTimeout, err = parseDuration(cfg.IdleTimeout, defaultIdleTimeout); err != nil {
+		return errors.Wrap(err, "idle timeout parse fail")
+	}
+	if factory.readTimeout, err = parseDuration(cfg.ReadTimeout, defaultReadTimeout); err != nil {
+		return errors.Wrap(err, "read timeout parse fail")
+	}
+
+	factory.origins = make(map[string]struct{}, len(cfg.AllowedOrigins))
+	factory.allOrigins = false
+	for _, o := range cfg.AllowedOrigins {
+		if o == constant.HeaderValueAll {
+			factory.allOrigins = true
+			continue
+		}
+		factory.origins[strings.ToLower(o)] = struct{}{}
+	}
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{
+		upstream:    factory.upstream,
+		origins:     factory.origins,
+		allOrigins:  factory.allOrigins,
+		idleTimeout: factory.idleTimeout,
+		readTimeout: factory.readTimeout,
+	}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	r := ctx.Request
+	if !isUpgrade(r) {
+		return filter.Continue
+	}
+	if !f.allowOrigin(r) {
+		sendError(ctx, stdHttp.StatusForbidden, "websocket origin is not allowed")
+		return filter.Stop
+	}
+
+	upConn, err := f.dial()
+	if err != nil {
+		logger.Warnw("websocket dial upstream fail", "upstream", f.upstream.Host, "error", err.Error())
+		sendError(ctx, stdHttp.StatusBadGateway, "websocket upstream is unavailable")
+		return filter.Stop
+	}
+
+	outReq := r.Clone(r.Context())
+	outReq.URL = &url.URL{Scheme: "http", Host: f.upstream.Host, Path: f.upstream.Path, RawQuery: r.URL.RawQuery}
+	if outReq.URL.Path == "" {
+		outReq.URL.Path = r.URL.Path
+	}
+	outReq.Host = f.upstream.Host
+	outReq.RequestURI = ""
+
+	_ = upConn.SetDeadline(time.Now().Add(f.readTimeout))
+	upReader := bufio.NewReaderSize(upConn, bufferSize)
+	resp, err := handshake(upConn, upReader, outReq)
+	if err != nil {
+		upConn.Close()
+		logger.Warnw("websocket handshake with upstream fail", "upstream", f.upstream.Host, "error", err.Error())
+		sendError(ctx, stdHttp.StatusBadGateway, "websocket upstream handshake fail")
+		return filter.Stop
+	}
+	if resp.StatusCode != stdHttp.StatusSwitchingProtocols {
+		// the upstream refuses to upgrade, return its response as normal
+		body, _ := ioutil.ReadAll(resp.Body)
+		upConn.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		ctx.SourceResp = resp
+		return filter.Stop
+	}
+	_ = upConn.SetDeadline(time.Time{})
+
+	clientConn, clientRW, err := ctx.Hijack()
+	if err != nil {
+		upConn.Close()
+		sendError(ctx, stdHttp.StatusInternalServerError, err.Error())
+		return filter.Stop
+	}
+	// clear the deadlines set by the server
+	_ = clientConn.SetDeadline(time.Time{})
+	if err := resp.Write(clientConn); err != nil {
+		upConn.Close()
+		clientConn.Close()
+		return filter.Stop
+	}
+
+	logger.Debugw("websocket upgraded", "url", ctx.GetUrl(), "upstream", f.upstream.Host)
+	p := &pipe{idle: f.idleTimeout}
+	p.run(clientConn, clientRW.Reader, upConn, upReader)
+	return filter.Stop
+}
+
+// allowOrigin check the Origin header against the allowed origins, the browser always sends it on
+// the cross origin upgrade, so only the same origin is allowed by default
+func (f *Filter) allowOrigin(r *stdHttp.Request) bool {
+	if f.allOrigins {
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if len(f.origins) > 0 {
+		_, ok := f.origins[strings.ToLower(origin)]
+		return ok
+	}
+	// the non browser clients may not send the Origin header
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func (f *Filter) dial() (net.Conn, error) {
+	host := f.upstream.Host
+	if f.upstream.Port() == "" {
+		if f.upstream.Scheme == "wss" {
+			host = net.JoinHostPort(host, "443")
+		} else {
+			host = net.JoinHostPort(host, "80")
+		}
+	}
+	dialer := &net.Dialer{Timeout: f.readTimeout}
+	if f.upstream.Scheme == "wss" {
+		return tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: f.upstream.Hostname()})
+	}
+	return dialer.Dial("tcp", host)
+}
+
+// handshake forward the upgrade request to upstream and read its response
+func handshake(conn net.Conn, br *bufio.Reader, req *stdHttp.Request) (*stdHttp.Response, error) {
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+	return stdHttp.ReadResponse(br, req)
+}
+
+// pipe copy the data between client and upstream in both directions,
+// both connections are closed when either side disconnects or both sides are idle
+type pipe struct {
+	idle       time.Duration
+	lastActive int64
+	closeOnce  sync.Once
+}
+
+func (p *pipe) run(clientConn net.Conn, clientReader io.Reader, upConn net.Conn, upReader io.Reader) {
+	p.touch()
+	closeAll := func() {
+		p.closeOnce.Do(func() {
+			clientConn.Close()
+			upConn.Close()
+		})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer closeAll()
+		p.copy(upConn, clientConn, clientReader)
+	}()
+	go func() {
+		defer wg.Done()
+		defer closeAll()
+		p.copy(clientConn, upConn, upReader)
+	}()
+	wg.Wait()
+}
+
+func (p *pipe) copy(dst io.Writer, srcConn net.Conn, src io.Reader) {
+	buf := make([]byte, bufferSize)
+	for {
+		_ = srcConn.SetReadDeadline(time.Now().Add(p.idle))
+		n, err := src.Read(buf)
+		if n > 0 {
+			p.touch()
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			// the other direction is still active, keep waiting
+			if ne, ok := err.(net.Error); ok && ne.Timeout() && p.active() {
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (p *pipe) touch() {
+	atomic.StoreInt64(&p.lastActive, time.Now().UnixNano())
+}
+
+func (p *pipe) active() bool {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&p.lastActive))) < p.idle
+}
+
+func isUpgrade(r *stdHttp.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+func headerContains(h stdHttp.Header, key, token string) bool {
+	for _, v := range h.Values(key) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sendError(ctx *http.HttpContext, status int, msg string) {
+	bt, _ := json.Marshal(http.ErrResponse{Message: msg})
+	ctx.SendLocalReply(status, bt)
+}
+
+func parseDuration(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, errors.Errorf("invalid duration %s", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,207 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+// echoUpstream accept the websocket upgrade and echo the data back
+func echoUpstream(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isUpgrade(r) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("not websocket"))
+			return
+		}
+		assert.Equal(t, "/chat", r.URL.Path)
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		assert.NoError(t, err)
+		defer conn.Close()
+		_, _ = conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"))
+		_, _ = io.Copy(conn, rw)
+	}))
+}
+
+// gateway run the filter as the http connection manager does, done is closed when the filter returns
+func gateway(factory *FilterFactory) (*httptest.Server, chan struct{}) {
+	done := make(chan struct{}, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := &contexthttp.HttpContext{Request: r, Writer: w}
+		ctx.Reset()
+		chain := filter.NewDefaultFilterChain()
+		_ = factory.PrepareFilterChain(ctx, chain)
+		chain.OnDecode(ctx)
+		if !ctx.LocalReply() {
+			w.WriteHeader(http.StatusOK)
+		}
+		select {
+		case done <- struct{}{}:
+		default:
+		}
+	}))
+	return s, done
+}
+
+func dialUpgrade(t *testing.T, addr, origin string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", addr)
+	assert.NoError(t, err)
+	req := "GET /chat HTTP/1.1\r\nHost: " + addr + "\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nOrigin: " + origin + "\r\n\r\n"
+	_, err = conn.Write([]byte(req))
+	assert.NoError(t, err)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	assert.NoError(t, err)
+	return conn, br, resp
+}
+
+func TestWebSocketProxy(t *testing.T) {
+	upstream := echoUpstream(t)
+	defer upstream.Close()
+
+	factory := &FilterFactory{cfg: &Config{Upstream: upstream.Listener.Addr().String(), AllowedOrigins: []string{"http://a.com"}}}
+	assert.Nil(t, factory.Apply())
+	s, done := gateway(factory)
+	defer s.Close()
+
+	conn, br, resp := dialUpgrade(t, s.Listener.Addr().String(), "http://a.com")
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "websocket", resp.Header.Get("Upgrade"))
+
+	for _, msg := range []string{"hello", "pixiu"} {
+		_, err := conn.Write([]byte(msg))
+		assert.NoError(t, err)
+		buf := make([]byte, len(msg))
+		_, err = io.ReadFull(br, buf)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, string(buf))
+	}
+
+	// the client disconnects, both directions should be closed
+	conn.Close()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("websocket pipe is not closed after client disconnects")
+	}
+}
+
+func TestWebSocketIdleTimeout(t *testing.T) {
+	upstream := echoUpstream(t)
+	defer upstream.Close()
+
+	factory := &FilterFactory{cfg: &Config{Upstream: "ws://" + upstream.Listener.Addr().String(), IdleTimeout: "100ms"}}
+	assert.Nil(t, factory.Apply())
+	s, done := gateway(factory)
+	defer s.Close()
+
+	// the same origin is allowed without allowed origins
+	conn, br, resp := dialUpgrade(t, s.Listener.Addr().String(), "http://"+s.Listener.Addr().String())
+	defer conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("idle websocket is not closed")
+	}
+	_, err := br.ReadByte()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestWebSocketReject(t *testing.T) {
+	upstream := echoUpstream(t)
+	defer upstream.Close()
+
+	factory := &FilterFactory{cfg: &Config{Upstream: upstream.Listener.Addr().String(), AllowedOrigins: []string{"http://a.com"}}}
+	assert.Nil(t, factory.Apply())
+	s, _ := gateway(factory)
+	defer s.Close()
+
+	conn, _, resp := dialUpgrade(t, s.Listener.Addr().String(), "http://evil.com")
+	defer conn.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// not an upgrade request, the filter is bypassed
+	resp, err := http.Get(s.URL + "/chat")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestApply(t *testing.T) {
+	for _, cfg := range []*Config{
+		{},
+		{Upstream: "http://127.0.0.1:8080"},
+		{Upstream: "127.0.0.1:8080", IdleTimeout: "abc"},
+	} {
+		assert.Error(t, (&FilterFactory{cfg: cfg}).Apply())
+	}
+
+	factory := &FilterFactory{cfg: &Config{Upstream: "wss://echo.com/ws", AllowedOrigins: []string{"*"}}}
+	assert.Nil(t, factory.Apply())
+	assert.Equal(t, "echo.com", factory.upstream.Host)
+	assert.Equal(t, defaultIdleTimeout, factory.idleTimeout)
+	assert.True(t, (&Filter{origins: factory.origins, allOrigins: factory.allOrigins}).allowOrigin(originRequest(t, "http://any.com")))
+	assert.True(t, strings.HasPrefix(factory.upstream.Path, "/ws"))
+}
+
+func originRequest(t *testing.T, origin string) *http.Request {
+	r, err := http.NewRequest("GET", "http://pixiu.com/chat", nil)
+	assert.NoError(t, err)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	return r
+}
+
+func TestAllowOrigin(t *testing.T) {
+	factory := &FilterFactory{cfg: &Config{Upstream: "ws://echo.com"}}
+	assert.Nil(t, factory.Apply())
+	f := &Filter{origins: factory.origins, allOrigins: factory.allOrigins}
+
+	// only the same origin by default
+	assert.True(t, f.allowOrigin(originRequest(t, "https://Pixiu.com")))
+	assert.True(t, f.allowOrigin(originRequest(t, "")))
+	assert.False(t, f.allowOrigin(originRequest(t, "http://evil.com")))
+	assert.False(t, f.allowOrigin(originRequest(t, "null")))
+
+	factory = &FilterFactory{cfg: &Config{Upstream: "ws://echo.com", AllowedOrigins: []string{"http://A.com"}}}
+	assert.Nil(t, factory.Apply())
+	f = &Filter{origins: factory.origins, allOrigins: factory.allOrigins}
+	assert.True(t, f.allowOrigin(originRequest(t, "http://a.com")))
+	assert.False(t, f.allowOrigin(originRequest(t, "http://pixiu.com")))
+	assert.False(t, f.allowOrigin(originRequest(t, "")))
+}
@@ -19,16 +19,19 @@ package metric
 
 import (
 	"context"
+	"io"
 	"sync/atomic"
 	"time"
 )
 
 import (
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/global"
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/client"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
@@ -98,10 +101,15 @@ func (f *Filter) Encode(c *http.HttpContext) filter.FilterStatus {
 	atomic.AddInt64(&totalElapsed, latency.Nanoseconds())
 	atomic.AddInt64(&totalCount, 1)
 
-	logger.Infof("[Metric] [UPSTREAM] receive request | %d | %s | %s | %s | ", c.GetStatusCode(), latency, c.GetMethod(), c.GetUrl())
+	logger.Infow("upstream request received", "status", c.GetStatusCode(), "latency", latency.String(), "method", c.GetMethod(), "url", c.GetUrl())
 	return filter.Continue
 }
 
+// EncodeStream count the latency until the streamed body is written
+func (f *Filter) EncodeStream(c *http.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	return filter.CloseFuncWriter(w, func() { f.Encode(c) }), filter.Continue
+}
+
 func registerOtelMetric() {
 	meter := global.GetMeterProvider().Meter("pixiu")
 	observerElapsedCallback := func(_ context.Context, result metric.Int64ObserverResult) {
@@ -117,4 +125,63 @@ func registerOtelMetric() {
 	_ = metric.Must(meter).NewInt64SumObserver("pixiu_request_count", observerCountCallback,
 		metric.WithDescription("request total count in pixiu"),
 	)
+	registerConcurrencyMetric(meter)
+	registerReloadMetric(meter)
+}
+
+// registerConcurrencyMetric publish the concurrency limiting statistics of the upstream clients per cluster
+func registerConcurrencyMetric(meter metric.Meter) {
+	observe := func(value func(s client.ConcurrencyStats) int64) func(context.Context, metric.Int64ObserverResult) {
+		return func(_ context.Context, result metric.Int64ObserverResult) {
+			for _, s := range client.CollectConcurrencyStats() {
+				result.Observe(value(s), attribute.String("client", s.Client), attribute.String("cluster", s.Cluster))
+			}
+		}
+	}
+	_ = metric.Must(meter).NewInt64GaugeObserver("pixiu_upstream_concurrency_active",
+		observe(func(s client.ConcurrencyStats) int64 { return s.Active }),
+		metric.WithDescription("invocations in flight to the upstream cluster"),
+	)
+	_ = metric.Must(meter).NewInt64GaugeObserver("pixiu_upstream_concurrency_available",
+		observe(func(s client.ConcurrencyStats) int64 { return s.Available }),
+		metric.WithDescription("invocations allowed at once by the concurrency limit of the upstream cluster"),
+	)
+	_ = metric.Must(meter).NewInt64SumObserver("pixiu_upstream_concurrency_wait_time",
+		observe(func(s client.ConcurrencyStats) int64 { return s.WaitTime.Milliseconds() }),
+		metric.WithDescription("accumulated milliseconds waiting for the concurrency limit of the upstream cluster"),
+	)
+	_ = metric.Must(meter).NewInt64SumObserver("pixiu_upstream_concurrency_limited",
+		observe(func(s client.ConcurrencyStats) int64 { return s.Limited }),
+		metric.WithDescription("invocations waiting for the concurrency limit beyond the threshold"),
+	)
+}
+
+// registerReloadMetric publish the reload statistics of the filter managers, so that the failed reloads
+// can be alerted before the requests fail
+func registerReloadMetric(meter metric.Meter) {
+	observe := func(value func(s filter.ReloadStats) int64) func(context.Context, metric.Int64ObserverResult) {
+		return func(_ context.Context, result metric.Int64ObserverResult) {
+			result.Observe(value(filter.CollectReloadStats()))
+		}
+	}
+	_ = metric.Must(meter).NewInt64SumObserver("pixiu_filter_reload_total",
+		observe(func(s filter.ReloadStats) int64 { return s.Reloads }),
+		metric.WithDescription("reloads of the http filters"),
+	)
+	_ = metric.Must(meter).NewInt64SumObserver("pixiu_filter_reload_failed_total",
+		observe(func(s filter.ReloadStats) int64 { return s.Failures }),
+		metric.WithDescription("reloads of the http filters failed or having any filter failed to apply"),
+	)
+	_ = metric.Must(meter).NewInt64GaugeObserver("pixiu_filter_loaded",
+		observe(func(s filter.ReloadStats) int64 { return s.Loaded }),
+		metric.WithDescription("http filters loaded currently"),
+	)
+	_ = metric.Must(meter).NewInt64SumObserver("pixiu_filter_apply_failed_total",
+		func(_ context.Context, result metric.Int64ObserverResult) {
+			for name, n := range filter.CollectReloadStats().ApplyFailures {
+				result.Observe(n, attribute.String("filter", name))
+			}
+		},
+		metric.WithDescription("apply failures of the http filter"),
+	)
 }
@@ -35,6 +35,7 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	dubbo2 "github.com/apache/dubbo-go-pixiu/pkg/context/dubbo"
@@ -91,6 +92,7 @@ func (f Filter) Handle(ctx *dubbo2.RpcContext) filter.FilterStatus {
 		ctx.SetError(errors.Errorf("Requested dubbo rpc invocation endpoint not found"))
 		return filter.Stop
 	}
+	defer loadbalancer.Begin(clusterName, endpoint)()
 
 	var (
 		req *http3.Request
@@ -35,6 +35,7 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	dubbo2 "github.com/apache/dubbo-go-pixiu/pkg/context/dubbo"
@@ -103,6 +104,7 @@ func (f Filter) sendDubboRequest(ctx *dubbo2.RpcContext) filter.FilterStatus {
 		ctx.SetError(errors.Errorf("Requested dubbo rpc invocation endpoint not found"))
 		return filter.Stop
 	}
+	defer loadbalancer.Begin(clusterName, endpoint)()
 
 	invoc := ctx.RpcInvocation
 	url, err := common.NewURL(endpoint.Address.GetAddress(),
@@ -154,6 +156,7 @@ func (f Filter) sendTripleRequest(ctx *dubbo2.RpcContext) filter.FilterStatus {
 		ctx.SetError(errors.Errorf("Requested dubbo rpc invocation endpoint not found"))
 		return filter.Stop
 	}
+	defer loadbalancer.Begin(clusterName, endpoint)()
 
 	invoc := ctx.RpcInvocation
 	path := invoc.Attachment(dubboConstant.PathKey).(string)
@@ -19,6 +19,7 @@ package dubboproxy
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 )
 
@@ -72,7 +73,7 @@ func (dcm *DubboProxyConnectionManager) OnDecode(data []byte) (interface{}, int,
 		if perrors.Is(err, hessian.ErrHeaderNotEnough) || perrors.Is(err, hessian.ErrBodyNotEnough) {
 			return nil, 0, nil
 		}
-		logger.Errorf("pkg.Unmarshal error:%+v", err)
+		logger.Errorw("dubbo proxy decode fail", "error", err.Error())
 		return nil, length, err
 	}
 	return resp, length, nil
@@ -84,7 +85,7 @@ func (dcm *DubboProxyConnectionManager) OnEncode(pkg interface{}) ([]byte, error
 	if ok {
 		buf, err := (dcm.codec).EncodeResponse(res)
 		if err != nil {
-			logger.Warnf("binary.Write(res{%#v}) = err{%#v}", res, perrors.WithStack(err))
+			logger.Warnw("dubbo proxy encode response fail", "response", res, "error", err.Error())
 			return nil, perrors.WithStack(err)
 		}
 		return buf.Bytes(), nil
@@ -94,13 +95,13 @@ func (dcm *DubboProxyConnectionManager) OnEncode(pkg interface{}) ([]byte, error
 	if ok {
 		buf, err := (dcm.codec).EncodeRequest(req)
 		if err != nil {
-			logger.Warnf("binary.Write(req{%#v}) = err{%#v}", res, perrors.WithStack(err))
+			logger.Warnw("dubbo proxy encode request fail", "request", req, "error", err.Error())
 			return nil, perrors.WithStack(err)
 		}
 		return buf.Bytes(), nil
 	}
 
-	logger.Errorf("illegal pkg:%+v\n, it is %+v", pkg, reflect.TypeOf(pkg))
+	logger.Errorw("dubbo proxy encode illegal package", "package", pkg, "type", reflect.TypeOf(pkg).String())
 	return nil, perrors.New("invalid rpc response")
 }
 
@@ -181,7 +182,7 @@ func (dcm *DubboProxyConnectionManager) handleRpcInvocation(c *dubbo2.RpcContext
 	// recover any err when filterChain run
 	defer func() {
 		if err := recover(); err != nil {
-			logger.Warnf("[dubbopixiu go] Occur An Unexpected Err: %+v", err)
+			logger.Warnw("dubbo proxy filter chain panic", "panic", fmt.Sprintf("%v", err))
 			c.SetError(errors.Errorf("Occur An Unexpected Err: %v", err))
 		}
 	}()
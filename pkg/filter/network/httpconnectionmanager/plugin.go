@@ -44,7 +44,11 @@ func (p *Plugin) Kind() string {
 // CreateFilter create http network filter
 func (p *Plugin) CreateFilter(config interface{}, bs *model.Bootstrap) (filter.NetworkFilter, error) {
 	hcmc := config.(*model.HttpConnectionManagerConfig)
-	return http.CreateHttpConnectionManager(hcmc, bs), nil
+	hcm, err := http.CreateHttpConnectionManager(hcmc, bs)
+	if err != nil {
+		return nil, err
+	}
+	return hcm, nil
 }
 
 // Config return HttpConnectionManagerConfig
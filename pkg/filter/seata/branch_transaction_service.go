@@ -88,7 +88,7 @@ func (f *FilterFactory) branchCommunicate() {
 				data := msg.GetMessage().GetValue()
 				err := request.Unmarshal(data)
 				if err != nil {
-					logger.Errorf(err.Error(), nil)
+					logger.Errorw("unmarshal branch message fail", "error", err.Error())
 					continue
 				}
 				response := branchCommit(context.Background(), request)
@@ -133,7 +133,7 @@ func branchCommit(ctx context.Context, request *apis.BranchCommitRequest) *apis.
 
 	err := requestContext.Decode(request.ApplicationData)
 	if err != nil {
-		logger.Errorf("commit failed, err: %v", err)
+		logger.Errorw("branch commit fail", "error", err.Error())
 		return &apis.BranchCommitResponse{
 			ResultCode: apis.ResultCodeFailed,
 			Message:    err.Error(),
@@ -142,7 +142,7 @@ func branchCommit(ctx context.Context, request *apis.BranchCommitRequest) *apis.
 
 	resp, err := doHttp1Request(requestContext, true)
 	if err != nil {
-		logger.Errorf("commit failed, err: %v", err)
+		logger.Errorw("branch commit fail", "error", err.Error())
 		return &apis.BranchCommitResponse{
 			ResultCode: apis.ResultCodeFailed,
 			Message:    err.Error(),
@@ -175,7 +175,7 @@ func branchRollback(ctx context.Context, request *apis.BranchRollbackRequest) *a
 
 	err := requestContext.Decode(request.ApplicationData)
 	if err != nil {
-		logger.Errorf("rollback failed, err: %v", err)
+		logger.Errorw("branch rollback fail", "error", err.Error())
 		return &apis.BranchRollbackResponse{
 			ResultCode: apis.ResultCodeFailed,
 			Message:    err.Error(),
@@ -184,7 +184,7 @@ func branchRollback(ctx context.Context, request *apis.BranchRollbackRequest) *a
 
 	resp, err := doHttp1Request(requestContext, false)
 	if err != nil {
-		logger.Errorf("rollback failed, err: %v", err)
+		logger.Errorw("branch rollback fail", "error", err.Error())
 		return &apis.BranchRollbackResponse{
 			ResultCode: apis.ResultCodeFailed,
 			Message:    err.Error(),
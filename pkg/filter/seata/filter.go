@@ -18,6 +18,7 @@
 package seata
 
 import (
+	"io"
 	netHttp "net/http"
 	"strings"
 )
@@ -90,6 +91,11 @@ func (factory *FilterFactory) Config() interface{} {
 	return factory.conf
 }
 
+// Stage the filter reads the request body
+func (factory *FilterFactory) Stage() filter.FilterStage {
+	return filter.StageBody
+}
+
 func (factory *FilterFactory) Apply() error {
 	conn, err := grpc.Dial(factory.conf.ServerAddressing,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
@@ -158,3 +164,8 @@ func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
 	}
 	return filter.Continue
 }
+
+// EncodeStream end the transaction by the status as Encode, the streamed body is kept as it is
+func (f *Filter) EncodeStream(ctx *http.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	return filter.NopWriteCloser(w), f.Encode(ctx)
+}
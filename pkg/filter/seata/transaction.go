@@ -43,8 +43,7 @@ func (f *Filter) handleHttp1GlobalBegin(ctx *http.HttpContext, transactionInfo *
 	// todo support transaction isolation level
 	xid, err := f.globalBegin(ctx.Ctx, transactionInfo.RequestPath, transactionInfo.Timeout)
 	if err != nil {
-		logger.Errorf("failed to begin global transaction, transaction info: %v, err: %v",
-			transactionInfo, err)
+		logger.Errorw("failed to begin global transaction", "transaction", transactionInfo, "error", err.Error())
 		ctx.SendLocalReply(netHttp.StatusInternalServerError, []byte(fmt.Sprintf("failed to begin global transaction, %v", err)))
 		return false
 	}
@@ -106,7 +105,7 @@ func (f *Filter) handleHttp1BranchRegister(ctx *http.HttpContext, tccResource *T
 	if rEntry == nil {
 		panic("no route entry")
 	}
-	logger.Debugf("[dubbo-go-pixiu] client choose endpoint from cluster :%v", rEntry.Cluster)
+	logger.Debugw("client choose endpoint from cluster", "cluster", rEntry.Cluster)
 
 	clusterName := rEntry.Cluster
 	clusterManager := server.GetClusterManager()
@@ -126,14 +125,14 @@ func (f *Filter) handleHttp1BranchRegister(ctx *http.HttpContext, tccResource *T
 
 	data, err := requestContext.Encode()
 	if err != nil {
-		logger.Errorf("encode request context failed, request context: %v, err: %v", requestContext, err)
+		logger.Errorw("encode request context fail", "request_context", requestContext, "error", err.Error())
 		ctx.SendLocalReply(netHttp.StatusInternalServerError, []byte(fmt.Sprintf("encode request context failed, %v", err)))
 		return false
 	}
 
 	branchID, err := f.branchRegister(ctx.Ctx, xid, tccResource.PrepareRequestPath, apis.TCC, data, "")
 	if err != nil {
-		logger.Errorf("branch transaction register failed, xid: %s, err: %v", xid, err)
+		logger.Errorw("branch transaction register fail", "xid", xid, "error", err.Error())
 		ctx.SendLocalReply(netHttp.StatusInternalServerError, []byte(fmt.Sprintf("branch transaction register failed, %v", err)))
 		return false
 	}
@@ -194,8 +193,7 @@ func (f *Filter) globalCommit(ctx *http.HttpContext, xid string) error {
 	for retry > 0 {
 		status, err = f.commit(ctx.Ctx, xid)
 		if err != nil {
-			logger.Errorf("failed to report global commit [%s],Retry Countdown: %d, reason: %s",
-				xid, retry, err.Error())
+			logger.Errorw("failed to report global commit", "xid", xid, "retry_countdown", retry, "error", err.Error())
 		} else {
 			break
 		}
@@ -204,7 +202,7 @@ func (f *Filter) globalCommit(ctx *http.HttpContext, xid string) error {
 			return errors.New("failed to report global commit")
 		}
 	}
-	logger.Infof("[%s] commit status: %s", xid, status.String())
+	logger.Infow("global commit", "xid", xid, "status", status.String())
 	return nil
 }
 
@@ -220,8 +218,7 @@ func (f *Filter) globalRollback(ctx *http.HttpContext, xid string) error {
 	for retry > 0 {
 		status, err = f.rollback(ctx.Ctx, xid)
 		if err != nil {
-			logger.Errorf("failed to report global rollback [%s],Retry Countdown: %d, reason: %s",
-				xid, retry, err.Error())
+			logger.Errorw("failed to report global rollback", "xid", xid, "retry_countdown", retry, "error", err.Error())
 		} else {
 			break
 		}
@@ -230,7 +227,7 @@ func (f *Filter) globalRollback(ctx *http.HttpContext, xid string) error {
 			return errors.New("failed to report global rollback")
 		}
 	}
-	logger.Infof("[%s] rollback status: %s", xid, status.String())
+	logger.Infow("global rollback", "xid", xid, "status", status.String())
 	return nil
 }
 
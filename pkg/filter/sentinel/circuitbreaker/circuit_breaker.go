@@ -19,6 +19,7 @@ package circuitbreaker
 
 import (
 	"fmt"
+	"io"
 	stdHttp "net/http"
 	"strings"
 )
@@ -55,18 +56,25 @@ type (
 	FilterFactory struct {
 		cfg     *Config
 		matcher *pkgs.Matcher
+		shared  *sharedBreaker
 	}
 
 	// Filter is http filter instance
 	Filter struct {
 		cfg     *Config
 		matcher *pkgs.Matcher
+		shared  *sharedBreaker
+
+		// resource the matched resource whose result is recorded in shared breaker
+		resource string
 	}
 
 	// Config describe the config of FilterFactory
 	Config struct {
 		Resources []*pkgs.Resource       `json:"resources,omitempty" yaml:"resources,omitempty"`
 		Rules     []*circuitbreaker.Rule `json:"rules" yaml:"rules"` // circuit breaker base config info
+		// Shared share the breaker state among replicas, the local sentinel breaker still takes effect
+		Shared *SharedConfig `json:"shared,omitempty" yaml:"shared,omitempty"`
 	}
 )
 
@@ -78,8 +86,17 @@ func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
 	return &FilterFactory{cfg: &Config{}}, nil
 }
 
+// OrderHint the retries of http proxy should happen inside the breaker, so that the tripped breaker is not retried
+func (factory *FilterFactory) OrderHint() filter.OrderHint {
+	return filter.OrderHint{Before: []string{constant.HTTPProxyFilter}}
+}
+
 func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
-	chain.AppendDecodeFilters(&Filter{cfg: factory.cfg, matcher: factory.matcher})
+	f := &Filter{cfg: factory.cfg, matcher: factory.matcher, shared: factory.shared}
+	chain.AppendDecodeFilters(f)
+	if f.shared != nil {
+		chain.AppendEncodeFilters(f)
+	}
 	return nil
 }
 
@@ -92,18 +109,45 @@ func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
 		return filter.Continue
 	}
 
+	if f.shared != nil && f.shared.isOpen(resourceName) {
+		return reject(ctx)
+	}
+
 	// entry, blockErr := sentinel.Entry(resourceName, sentinel.WithResourceType(base.ResTypeAPIGateway), sentinel.WithTrafficType(base.Inbound))
 	entry, blockErr := sentinel.Entry(resourceName)
 
 	// if blockErr not nil, indicates the request was blocked by Sentinel
 	if blockErr != nil {
-		ctx.SendLocalReply(stdHttp.StatusServiceUnavailable, constant.Default503Body)
-		return filter.Stop
+		return reject(ctx)
 	}
 	defer entry.Exit()
+	f.resource = resourceName
+	return filter.Continue
+}
+
+// reject reply 503 and mark the circuit open, so that the fallback filter can tell it from the upstream errors
+func reject(ctx *http.HttpContext) filter.FilterStatus {
+	if ctx.Params == nil {
+		ctx.Params = make(map[string]interface{})
+	}
+	ctx.Params[constant.CircuitOpenParam] = true
+	ctx.SendLocalReply(stdHttp.StatusServiceUnavailable, constant.Default503Body)
+	return filter.Stop
+}
+
+// Encode record the result in shared breaker, the rejected requests are not counted
+func (f *Filter) Encode(ctx *http.HttpContext) filter.FilterStatus {
+	if f.resource != "" {
+		f.shared.record(f.resource, ctx.GetStatusCode() >= stdHttp.StatusInternalServerError)
+	}
 	return filter.Continue
 }
 
+// EncodeStream record the status as Encode, the streamed body is kept as it is
+func (f *Filter) EncodeStream(ctx *http.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	return filter.NopWriteCloser(w), f.Encode(ctx)
+}
+
 func (factory *FilterFactory) Config() interface{} {
 	return factory.cfg
 }
@@ -113,6 +157,14 @@ func (factory *FilterFactory) Apply() error {
 		return fmt.Errorf("circuit breaker router or resources is null")
 	}
 
+	if factory.cfg.Shared != nil {
+		shared, err := newSharedBreaker(factory.cfg.Shared)
+		if err != nil {
+			return err
+		}
+		factory.shared = shared
+	}
+
 	factory.resetResources()
 
 	// init matcher
@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package circuitbreaker
+
+import (
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/http/quota"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	defaultSharedWindow       = 10 * time.Second
+	defaultSharedOpenDuration = 30 * time.Second
+	defaultSharedMinRequests  = 20
+	defaultSharedErrorRatio   = 0.5
+
+	sharedKeyPrefix = "cb:"
+)
+
+var now = time.Now
+
+type (
+	// SharedConfig share the breaker state among the gateway replicas through the quota store,
+	// so that all replicas open and close together based on the aggregate error ratio
+	SharedConfig struct {
+		// Store the registered quota store, e.g. redis, the memory store is only useful for single replica
+		Store string `yaml:"store" json:"store" mapstructure:"store"`
+		// Window the statistic window of requests
+		Window string `yaml:"window" json:"window" mapstructure:"window"`
+		// MinRequests the breaker is not opened if the requests in window are less than it
+		MinRequests int64 `yaml:"min_requests" json:"min_requests" mapstructure:"min_requests"`
+		// ErrorRatio the breaker is opened when the ratio of 5xx responses in window reaches it
+		ErrorRatio float64 `yaml:"error_ratio" json:"error_ratio" mapstructure:"error_ratio"`
+		// OpenDuration how long the breaker keeps open
+		OpenDuration string `yaml:"open_duration" json:"open_duration" mapstructure:"open_duration"`
+	}
+
+	// sharedBreaker the breaker whose counts are kept in the shared store
+	sharedBreaker struct {
+		store        quota.Store
+		window       time.Duration
+		openDuration time.Duration
+		minRequests  int64
+		errorRatio   float64
+	}
+)
+
+func newSharedBreaker(cfg *SharedConfig) (*sharedBreaker, error) {
+	store, err := quota.CreateStore(cfg.Store)
+	if err != nil {
+		return nil, err
+	}
+	b := &sharedBreaker{
+		store:        store,
+		window:       defaultSharedWindow,
+		openDuration: defaultSharedOpenDuration,
+		minRequests:  cfg.MinRequests,
+		errorRatio:   cfg.ErrorRatio,
+	}
+	if cfg.Window != "" {
+		if b.window, err = time.ParseDuration(cfg.Window); err != nil {
+			return nil, errors.Wrap(err, "shared window parse fail")
+		}
+	}
+	if cfg.OpenDuration != "" {
+		if b.openDuration, err = time.ParseDuration(cfg.OpenDuration); err != nil {
+			return nil, errors.Wrap(err, "shared open duration parse fail")
+		}
+	}
+	if b.window <= 0 || b.openDuration <= 0 {
+		return nil, errors.New("shared window and open duration must be positive")
+	}
+	if b.minRequests <= 0 {
+		b.minRequests = defaultSharedMinRequests
+	}
+	if b.errorRatio <= 0 || b.errorRatio > 1 {
+		b.errorRatio = defaultSharedErrorRatio
+	}
+	return b, nil
+}
+
+// isOpen check whether the breaker of resource is opened by any replica, it fails open when the store is unavailable
+func (b *sharedBreaker) isOpen(resource string) bool {
+	open, err := b.store.Get(sharedKeyPrefix + resource + ":open")
+	if err != nil {
+		logger.Warnw("circuit breaker get shared state fail, fail open", "resource", resource, "error", err.Error())
+		return false
+	}
+	return open > 0
+}
+
+// record count the request in current window, and open the breaker for all replicas when the error ratio is reached
+func (b *sharedBreaker) record(resource string, failed bool) {
+	t := now()
+	start := t.Truncate(b.window)
+	key := sharedKeyPrefix + resource + ":" + start.Format("20060102150405")
+	// keep the counts a little longer than the window, the key of next window is different anyway
+	expireAt := start.Add(2 * b.window)
+
+	total, err := b.store.Incr(key+":total", expireAt)
+	if err != nil {
+		logger.Warnw("circuit breaker record shared state fail", "resource", resource, "error", err.Error())
+		return
+	}
+	var fails int64
+	if failed {
+		fails, err = b.store.Incr(key+":fail", expireAt)
+	} else {
+		fails, err = b.store.Get(key + ":fail")
+	}
+	if err != nil {
+		logger.Warnw("circuit breaker record shared state fail", "resource", resource, "error", err.Error())
+		return
+	}
+	if total < b.minRequests || float64(fails)/float64(total) < b.errorRatio || b.isOpen(resource) {
+		return
+	}
+	if _, err := b.store.Incr(sharedKeyPrefix+resource+":open", t.Add(b.openDuration)); err != nil {
+		logger.Warnw("circuit breaker open shared state fail", "resource", resource, "error", err.Error())
+		return
+	}
+	logger.Infow("circuit breaker is opened for all replicas", "resource", resource, "errors", fails, "total", total)
+}
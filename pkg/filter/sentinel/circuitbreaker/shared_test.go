@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package circuitbreaker
+
+import (
+	stdHttp "net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/http/quota"
+)
+
+// brokenStore the store is unavailable
+type brokenStore struct{}
+
+func (s *brokenStore) Incr(key string, expireAt time.Time) (int64, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func (s *brokenStore) Get(key string) (int64, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func init() {
+	// the memory store stands for the redis shared by replicas
+	shared := quota.NewMemoryStore()
+	quota.RegisterStore("cb-shared", func() quota.Store { return shared })
+	quota.RegisterStore("cb-broken", func() quota.Store { return &brokenStore{} })
+}
+
+// call run the request through the filter, the upstream replies the status
+func call(t *testing.T, f *Filter, status int) int {
+	request, _ := stdHttp.NewRequest(stdHttp.MethodGet, "https://www.dubbogopixiu.com/api/v1/test-dubbo/user/1111", nil)
+	c := mock.GetMockHTTPContext(request)
+	chain := filter.NewDefaultFilterChain()
+	assert.Nil(t, (&FilterFactory{cfg: f.cfg, matcher: f.matcher, shared: f.shared}).PrepareFilterChain(c, chain))
+	chain.OnDecode(c)
+	if !c.LocalReply() {
+		c.StatusCode(status)
+	}
+	chain.OnEncode(c)
+	return c.GetStatusCode()
+}
+
+func TestSharedBreaker(t *testing.T) {
+	origin := now
+	base := time.Now()
+	now = func() time.Time { return base }
+	defer func() { now = origin }()
+
+	cfg := mockConfig()
+	cfg.Shared = &SharedConfig{Store: "cb-shared", Window: "1m", MinRequests: 4, ErrorRatio: 0.5, OpenDuration: "1m"}
+	factory := &FilterFactory{cfg: cfg}
+	assert.Nil(t, factory.Apply())
+	replicaB, err := newSharedBreaker(cfg.Shared)
+	assert.Nil(t, err)
+
+	a := &Filter{cfg: factory.cfg, matcher: factory.matcher, shared: factory.shared}
+	b := &Filter{cfg: factory.cfg, matcher: factory.matcher, shared: replicaB}
+
+	// neither replica reaches the min requests alone, but the aggregate error ratio does
+	assert.Equal(t, stdHttp.StatusOK, call(t, a, stdHttp.StatusOK))
+	assert.Equal(t, stdHttp.StatusBadGateway, call(t, a, stdHttp.StatusBadGateway))
+	assert.Equal(t, stdHttp.StatusOK, call(t, b, stdHttp.StatusOK))
+	assert.Equal(t, stdHttp.StatusInternalServerError, call(t, b, stdHttp.StatusInternalServerError))
+
+	// both replicas fast fail together
+	assert.Equal(t, stdHttp.StatusServiceUnavailable, call(t, a, stdHttp.StatusOK))
+	assert.Equal(t, stdHttp.StatusServiceUnavailable, call(t, b, stdHttp.StatusOK))
+	assert.True(t, replicaB.isOpen("test-dubbo@/api/v1/test-dubbo/user/*"))
+}
+
+func TestSharedBreakerFailOpen(t *testing.T) {
+	replica, err := newSharedBreaker(&SharedConfig{Store: "cb-broken", MinRequests: 1})
+	assert.Nil(t, err)
+	f := &Filter{cfg: mockConfig(), shared: replica}
+	factory := &FilterFactory{cfg: f.cfg}
+	assert.Nil(t, factory.Apply())
+	f.matcher = factory.matcher
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, stdHttp.StatusInternalServerError, call(t, f, stdHttp.StatusInternalServerError))
+	}
+}
+
+func TestNewSharedBreaker(t *testing.T) {
+	_, err := newSharedBreaker(&SharedConfig{Store: "not-exist"})
+	assert.Error(t, err)
+	_, err = newSharedBreaker(&SharedConfig{Window: "abc"})
+	assert.Error(t, err)
+
+	b, err := newSharedBreaker(&SharedConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, defaultSharedWindow, b.window)
+	assert.Equal(t, int64(defaultSharedMinRequests), b.minRequests)
+	assert.Equal(t, defaultSharedErrorRatio, b.errorRatio)
+}
@@ -31,19 +31,19 @@ func GetWrappedLogger() loggerWrapper {
 }
 
 func (l loggerWrapper) Debug(msg string, keysAndValues ...interface{}) {
-	logger.Debugf(msg, keysAndValues...)
+	logger.Debugw(msg, keysAndValues...)
 }
 
 func (l loggerWrapper) Info(msg string, keysAndValues ...interface{}) {
-	logger.Infof(msg, keysAndValues...)
+	logger.Infow(msg, keysAndValues...)
 }
 
 func (l loggerWrapper) Warn(msg string, keysAndValues ...interface{}) {
-	logger.Warnf(msg, keysAndValues...)
+	logger.Warnw(msg, keysAndValues...)
 }
 
 func (l loggerWrapper) Error(err error, msg string, keysAndValues ...interface{}) {
-	logger.Warnf(msg, err, keysAndValues)
+	logger.Warnw(msg, append([]interface{}{"error", err}, keysAndValues...)...)
 }
 
 // InfoEnabled todo logger should implements this method
@@ -23,6 +23,7 @@ import (
 
 import (
 	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
 )
 
 type (
@@ -31,6 +32,17 @@ type (
 		Resources []*pkgs.Resource `json:"resources,omitempty" yaml:"resources,omitempty"`
 		Rules     []*Rule          `json:"rules,omitempty" yaml:"rules,omitempty"`
 		LogPath   string           `json:"logPath,omitempty" yaml:"logPath,omitempty"`
+		// KeyBy the request attributes composing the limit key, e.g. [ip, path, method], each key has its own bucket
+		KeyBy []string `json:"keyBy,omitempty" yaml:"keyBy,omitempty"`
+		// KeySeparator join the attributes of KeyBy, "|" by default
+		KeySeparator string `json:"keySeparator,omitempty" yaml:"keySeparator,omitempty"`
+		// TrustedProxies the addresses or CIDRs of the proxies whose X-Forwarded-For is trusted for the ip attribute
+		TrustedProxies []string `json:"trustedProxies,omitempty" yaml:"trustedProxies,omitempty"`
+		// KeyRules the rules limiting each composite key of the resource
+		KeyRules []*KeyRule `json:"keyRules,omitempty" yaml:"keyRules,omitempty"`
+		// EmitHeaders reply X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset on every limited response,
+		// the remaining of a key rule is only replied when the request is blocked
+		EmitHeaders bool `json:"emitHeaders,omitempty" yaml:"emitHeaders,omitempty"`
 	}
 
 	// Rule api group 's rate-limit rule
@@ -39,4 +51,11 @@ type (
 		FlowRule flow.Rule `json:"flowRule,omitempty" yaml:"flowRule,omitempty"`
 		Enable   bool      `json:"enable,omitempty" yaml:"enable,omitempty"`
 	}
+
+	// KeyRule api group 's rate-limit rule per composite key
+	KeyRule struct {
+		ID          int64        `json:"id,omitempty" yaml:"id,omitempty"`
+		HotspotRule hotspot.Rule `json:"hotspotRule,omitempty" yaml:"hotspotRule,omitempty"`
+		Enable      bool         `json:"enable,omitempty" yaml:"enable,omitempty"`
+	}
 )
@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"net"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	keyIP     = "ip"
+	keyPath   = "path"
+	keyMethod = "method"
+	keyHost   = "host"
+
+	keyHeaderPrefix = "header:"
+	keyQueryPrefix  = "query:"
+
+	defaultKeySeparator = "|"
+)
+
+// keyAttr extract one attribute of the request
+type keyAttr func(hc *contexthttp.HttpContext) string
+
+// compositeKey build the limit key from the request attributes in the configured order
+type compositeKey struct {
+	attrs     []keyAttr
+	separator string
+}
+
+// newCompositeKey parse the attributes, the supported ones are ip, path, method, host, header:<name> and query:<name>,
+// the ip is the peer address unless the peer is one of the trusted proxies
+func newCompositeKey(keyBy []string, separator string, trustedProxies []string) (*compositeKey, error) {
+	if len(keyBy) == 0 {
+		return nil, nil
+	}
+	if separator == "" {
+		separator = defaultKeySeparator
+	}
+	trusted, err := contexthttp.ParseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	k := &compositeKey{separator: separator}
+	for _, name := range keyBy {
+		attr, err := parseKeyAttr(strings.TrimSpace(name), trusted)
+		if err != nil {
+			return nil, err
+		}
+		k.attrs = append(k.attrs, attr)
+	}
+	return k, nil
+}
+
+func parseKeyAttr(name string, trusted []*net.IPNet) (keyAttr, error) {
+	lower := strings.ToLower(name)
+	switch lower {
+	case keyIP:
+		return func(hc *contexthttp.HttpContext) string { return hc.GetTrustedClientIP(trusted) }, nil
+	case keyPath:
+		return func(hc *contexthttp.HttpContext) string { return hc.GetUrl() }, nil
+	case keyMethod:
+		return func(hc *contexthttp.HttpContext) string { return hc.GetMethod() }, nil
+	case keyHost:
+		return func(hc *contexthttp.HttpContext) string { return hc.Request.Host }, nil
+	}
+	// the prefix is case insensitive as the attribute names, the query name is kept as configured
+	if strings.HasPrefix(lower, keyHeaderPrefix) && len(name) > len(keyHeaderPrefix) {
+		header := name[len(keyHeaderPrefix):]
+		return func(hc *contexthttp.HttpContext) string { return hc.GetHeader(header) }, nil
+	}
+	if strings.HasPrefix(lower, keyQueryPrefix) && len(name) > len(keyQueryPrefix) {
+		query := name[len(keyQueryPrefix):]
+		return func(hc *contexthttp.HttpContext) string { return hc.Request.URL.Query().Get(query) }, nil
+	}
+	return nil, errors.Errorf("unknown rate limit key attribute %q", name)
+}
+
+// build join the attributes of the request
+func (k *compositeKey) build(hc *contexthttp.HttpContext) string {
+	parts := make([]string, len(k.attrs))
+	for i, attr := range k.attrs {
+		parts[i] = attr(hc)
+	}
+	return strings.Join(parts, k.separator)
+}
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	stdHttp "net/http"
+	"testing"
+)
+
+import (
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestCompositeKey(t *testing.T) {
+	k, err := newCompositeKey([]string{"ip", "method", "path", "Header:X-Tenant", "QUERY:name"}, "", nil)
+	assert.Nil(t, err)
+
+	request, _ := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/http/foo?name=tc", nil)
+	request.RemoteAddr = "10.0.0.1:5678"
+	request.Header.Set("X-Tenant", "t1")
+	assert.Equal(t, "10.0.0.1|GET|/api/v1/http/foo|t1|tc", k.build(mock.GetMockHTTPContext(request)))
+
+	k, err = newCompositeKey(nil, "", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, k)
+
+	_, err = newCompositeKey([]string{"ip", "cookie"}, "", nil)
+	assert.Error(t, err)
+	_, err = newCompositeKey([]string{"header:"}, "", nil)
+	assert.Error(t, err)
+	_, err = newCompositeKey([]string{"ip"}, "", []string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestCompositeKeyClientIP(t *testing.T) {
+	k, err := newCompositeKey([]string{"ip"}, "", []string{"10.0.0.0/8", "192.168.1.1"})
+	assert.Nil(t, err)
+
+	build := func(remote, xff string) string {
+		request, _ := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/http/foo", nil)
+		request.RemoteAddr = remote
+		request.Header.Set("X-Forwarded-For", xff)
+		return k.build(mock.GetMockHTTPContext(request))
+	}
+
+	// the headers of an untrusted peer are ignored
+	assert.Equal(t, "1.1.1.1", build("1.1.1.1:80", "2.2.2.2"))
+	// the address in front of the trusted proxies is the client, the spoofed left ones are skipped
+	assert.Equal(t, "3.3.3.3", build("10.0.0.1:80", "2.2.2.2, 3.3.3.3, 192.168.1.1"))
+	assert.Equal(t, "10.0.0.2", build("10.0.0.1:80", "10.0.0.2"))
+	assert.Equal(t, "10.0.0.1", build("10.0.0.1:80", ""))
+}
+
+func TestCompositeKeyBuckets(t *testing.T) {
+	conf := mockConfig()
+	conf.KeyBy = []string{"ip", "path", "method"}
+	conf.KeyRules = []*KeyRule{{
+		Enable: true,
+		HotspotRule: hotspot.Rule{
+			Resource:        "test-http",
+			MetricType:      hotspot.QPS,
+			ControlBehavior: hotspot.Reject,
+			Threshold:       1,
+			DurationInSec:   10,
+		},
+	}}
+	f := &FilterFactory{conf: conf}
+	assert.Nil(t, f.Apply())
+	defer OnKeyRulesUpdate(nil)
+
+	decode := func(method, path, ip string) filter.FilterStatus {
+		request, _ := stdHttp.NewRequest(method, "http://www.dubbogopixiu.com"+path, nil)
+		request.RemoteAddr = ip + ":5678"
+		c := mock.GetMockHTTPContext(request)
+		chain := filter.NewDefaultFilterChain()
+		_ = f.PrepareFilterChain(c, chain)
+		chain.OnDecode(c)
+		if c.LocalReply() {
+			assert.Equal(t, stdHttp.StatusTooManyRequests, c.GetStatusCode())
+			return filter.Stop
+		}
+		return filter.Continue
+	}
+
+	assert.Equal(t, filter.Continue, decode("GET", "/api/v1/http/foo", "10.0.0.1"))
+	assert.Equal(t, filter.Stop, decode("GET", "/api/v1/http/foo", "10.0.0.1"))
+
+	// each combination of ip, path and method has its own bucket
+	assert.Equal(t, filter.Continue, decode("GET", "/api/v1/http/foo", "10.0.0.2"))
+	assert.Equal(t, filter.Continue, decode("POST", "/api/v1/http/foo", "10.0.0.1"))
+	assert.Equal(t, filter.Continue, decode("GET", "/api/v1/http/bar", "10.0.0.1"))
+	assert.Equal(t, filter.Stop, decode("POST", "/api/v1/http/foo", "10.0.0.1"))
+}
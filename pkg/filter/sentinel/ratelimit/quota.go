@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"strconv"
+	"time"
+)
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/stat"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const defaultQuotaInterval = time.Second
+
+// passedOf return the requests of the resource passed in the last second, read from the sentinel stat node
+var passedOf = func(resource string) float64 {
+	node := stat.GetResourceNode(resource)
+	if node == nil {
+		return 0
+	}
+	return node.GetQPS(base.MetricEventPass)
+}
+
+// quota describe the rule limiting a resource to reply the X-RateLimit-* headers.
+// The remaining requests of a flow rule are read from the sentinel stat node of the resource, the hotspot rule keeps
+// the tokens of each key inside sentinel, so the remaining is only replied for the requests limited by resource.
+type quota struct {
+	resource string
+	limit    int64
+	interval time.Duration
+	byKey    bool
+}
+
+// buildQuotas return the quota of each resource, the key rule is used if the requests are limited by key,
+// and the first rule is used if a resource has more than one
+func buildQuotas(conf *Config, byKey bool) map[string]*quota {
+	quotas := make(map[string]*quota)
+	if byKey {
+		for _, r := range conf.KeyRules {
+			if !r.Enable || quotas[r.HotspotRule.Resource] != nil {
+				continue
+			}
+			interval := time.Duration(r.HotspotRule.DurationInSec) * time.Second
+			q := newQuota(r.HotspotRule.Resource, r.HotspotRule.Threshold, interval)
+			q.byKey = true
+			quotas[r.HotspotRule.Resource] = q
+		}
+	}
+	for _, r := range conf.Rules {
+		if !r.Enable || quotas[r.FlowRule.Resource] != nil {
+			continue
+		}
+		interval := time.Duration(r.FlowRule.StatIntervalInMs) * time.Millisecond
+		quotas[r.FlowRule.Resource] = newQuota(r.FlowRule.Resource, int64(r.FlowRule.Threshold), interval)
+	}
+	return quotas
+}
+
+func newQuota(resource string, limit int64, interval time.Duration) *quota {
+	if interval <= 0 {
+		interval = defaultQuotaInterval
+	}
+	return &quota{resource: resource, limit: limit, interval: interval}
+}
+
+// remaining return the requests left in the rule interval, the passed rate of sentinel is scaled to the interval
+func (q *quota) remaining(passed bool) int64 {
+	if !passed {
+		return 0
+	}
+	remaining := q.limit - int64(passedOf(q.resource)*q.interval.Seconds()+0.5)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining
+}
+
+// annotate set the quota headers, the reset is the seconds of the rule interval as the sentinel window slides
+func (q *quota) annotate(hc *contexthttp.HttpContext, passed bool) {
+	seconds := int64((q.interval + time.Second - 1) / time.Second)
+	header := hc.Writer.Header()
+	header.Set(constant.HeaderKeyRateLimitLimit, strconv.FormatInt(q.limit, 10))
+	header.Set(constant.HeaderKeyRateLimitReset, strconv.FormatInt(seconds, 10))
+	if !q.byKey || !passed {
+		header.Set(constant.HeaderKeyRateLimitRemaining, strconv.FormatInt(q.remaining(passed), 10))
+	}
+}
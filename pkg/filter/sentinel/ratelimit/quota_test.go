@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	stdHttp "net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func TestQuotaRemaining(t *testing.T) {
+	defer func(fn func(string) float64) { passedOf = fn }(passedOf)
+	rates := map[string]float64{"foo": 30}
+	passedOf = func(resource string) float64 { return rates[resource] }
+
+	q := newQuota("foo", 100, 2*time.Second)
+	// the passed rate of sentinel is scaled to the rule interval
+	assert.Equal(t, int64(40), q.remaining(true))
+	assert.Equal(t, int64(0), q.remaining(false))
+	rates["foo"] = 80
+	assert.Equal(t, int64(0), q.remaining(true))
+
+	q = newQuota("bar", 10, 0)
+	assert.Equal(t, time.Second, q.interval)
+	assert.Equal(t, int64(10), q.remaining(true))
+}
+
+func TestBuildQuotas(t *testing.T) {
+	conf := &Config{
+		Rules: []*Rule{
+			{Enable: true, FlowRule: flow.Rule{Resource: "foo", Threshold: 100, StatIntervalInMs: 2000}},
+			{Enable: true, FlowRule: flow.Rule{Resource: "bar", Threshold: 10}},
+			{Enable: false, FlowRule: flow.Rule{Resource: "baz", Threshold: 10}},
+		},
+		KeyRules: []*KeyRule{{Enable: true, HotspotRule: hotspot.Rule{Resource: "foo", Threshold: 5, DurationInSec: 1}}},
+	}
+
+	quotas := buildQuotas(conf, false)
+	assert.Len(t, quotas, 2)
+	assert.Equal(t, int64(100), quotas["foo"].limit)
+	assert.Equal(t, 2*time.Second, quotas["foo"].interval)
+	assert.Equal(t, time.Second, quotas["bar"].interval)
+
+	// the key rule takes precedence for the requests limited by key
+	quotas = buildQuotas(conf, true)
+	assert.Equal(t, int64(5), quotas["foo"].limit)
+	assert.True(t, quotas["foo"].byKey)
+	assert.False(t, quotas["bar"].byKey)
+}
+
+func TestEmitHeaders(t *testing.T) {
+	conf := mockConfig()
+	conf.Rules[0].FlowRule.Resource = "test-http"
+	conf.EmitHeaders = true
+	f := &FilterFactory{conf: conf}
+	assert.Nil(t, f.Apply())
+	decoder := &Filter{conf: f.conf, matcher: f.matcher, quotas: f.quotas}
+
+	request, _ := stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/http/foo", nil)
+	c := mock.GetMockHTTPContext(request)
+	assert.Equal(t, filter.Continue, decoder.Decode(c))
+	header := c.Writer.Header()
+	assert.Equal(t, "100", header.Get(constant.HeaderKeyRateLimitLimit))
+	assert.Equal(t, "99", header.Get(constant.HeaderKeyRateLimitRemaining))
+	assert.Equal(t, "1", header.Get(constant.HeaderKeyRateLimitReset))
+
+	// the unmatched request is not annotated
+	request, _ = stdHttp.NewRequest("GET", "http://www.dubbogopixiu.com/api/v1/other", nil)
+	c = mock.GetMockHTTPContext(request)
+	assert.Equal(t, filter.Continue, decoder.Decode(c))
+	assert.Empty(t, c.Writer.Header().Get(constant.HeaderKeyRateLimitLimit))
+}
+
+func TestKeyQuotaHeaders(t *testing.T) {
+	q := newQuota("foo", 5, time.Second)
+	q.byKey = true
+
+	c := mock.GetMockHTTPContext(nil)
+	q.annotate(c, true)
+	header := c.Writer.Header()
+	assert.Equal(t, "5", header.Get(constant.HeaderKeyRateLimitLimit))
+	assert.Equal(t, "1", header.Get(constant.HeaderKeyRateLimitReset))
+	// the tokens of each key are kept by sentinel, so the remaining is not guessed
+	assert.Empty(t, header.Get(constant.HeaderKeyRateLimitRemaining))
+
+	c = mock.GetMockHTTPContext(nil)
+	q.annotate(c, false)
+	assert.Equal(t, "0", c.Writer.Header().Get(constant.HeaderKeyRateLimitRemaining))
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/alibaba/sentinel-golang/core/base"
 	sc "github.com/alibaba/sentinel-golang/core/config"
 	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
 	"github.com/alibaba/sentinel-golang/logging"
 )
 
@@ -55,12 +56,16 @@ type (
 	FilterFactory struct {
 		conf    *Config
 		matcher *pkgs.Matcher
+		key     *compositeKey
+		quotas  map[string]*quota
 	}
 
 	// Filter is http filter instance
 	Filter struct {
 		conf    *Config
 		matcher *pkgs.Matcher
+		key     *compositeKey
+		quotas  map[string]*quota
 	}
 )
 
@@ -73,7 +78,7 @@ func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
 }
 
 func (factory *FilterFactory) PrepareFilterChain(ctx *contexthttp.HttpContext, chain filter.FilterChain) error {
-	f := &Filter{conf: factory.conf, matcher: factory.matcher}
+	f := &Filter{conf: factory.conf, matcher: factory.matcher, key: factory.key, quotas: factory.quotas}
 	chain.AppendDecodeFilters(f)
 	return nil
 }
@@ -87,7 +92,17 @@ func (f *Filter) Decode(hc *contexthttp.HttpContext) filter.FilterStatus {
 		return filter.Continue
 	}
 
-	entry, blockErr := sentinel.Entry(resourceName, sentinel.WithResourceType(base.ResTypeAPIGateway), sentinel.WithTrafficType(base.Inbound))
+	opts := []sentinel.EntryOption{sentinel.WithResourceType(base.ResTypeAPIGateway), sentinel.WithTrafficType(base.Inbound)}
+	var key string
+	if f.key != nil {
+		// the key rules take the composite key as the first param
+		key = f.key.build(hc)
+		opts = append(opts, sentinel.WithArgs(key))
+	}
+	entry, blockErr := sentinel.Entry(resourceName, opts...)
+	if q := f.quotas[resourceName]; q != nil && f.conf.EmitHeaders {
+		q.annotate(hc, blockErr == nil)
+	}
 
 	//if blockErr not nil, indicates the request was blocked by Sentinel
 	if blockErr != nil {
@@ -109,6 +124,13 @@ func (factory *FilterFactory) Apply() error {
 	conf := factory.conf
 	factory.matcher.Load(conf.Resources)
 
+	key, err := newCompositeKey(conf.KeyBy, conf.KeySeparator, conf.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	factory.key = key
+	factory.quotas = buildQuotas(conf, key != nil)
+
 	// init sentinel
 	sentinelConf := sc.NewDefaultConfig()
 	if len(conf.LogPath) > 0 {
@@ -120,6 +142,7 @@ func (factory *FilterFactory) Apply() error {
 		return err
 	}
 	OnRulesUpdate(conf.Rules)
+	OnKeyRulesUpdate(conf.KeyRules)
 	return nil
 }
 
@@ -133,6 +156,25 @@ func OnRulesUpdate(rules []*Rule) {
 	}
 
 	if _, err := flow.LoadRules(enableRules); err != nil {
-		logger.Warnf("rate limit load rules err: %v", err)
+		logger.Warnw("rate limit load rules fail", "error", err.Error())
+	}
+}
+
+// OnKeyRulesUpdate update the rules per composite key
+func OnKeyRulesUpdate(rules []*KeyRule) {
+	var enableRules []*hotspot.Rule
+	for _, v := range rules {
+		if v.Enable {
+			r := v.HotspotRule
+			r.ParamIndex = 0
+			if r.DurationInSec == 0 {
+				r.DurationInSec = 1
+			}
+			enableRules = append(enableRules, &r)
+		}
+	}
+
+	if _, err := hotspot.LoadRules(enableRules); err != nil {
+		logger.Warnw("rate limit load key rules fail", "error", err.Error())
 	}
 }
@@ -0,0 +1,132 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// PropagationW3C propagate trace context by traceparent header
+	PropagationW3C = "w3c"
+	// PropagationB3 propagate trace context by b3 single header
+	PropagationB3 = "b3"
+	// PropagationB3Multi propagate trace context by X-B3-* headers
+	PropagationB3Multi = "b3multi"
+
+	traceparentHeader = "traceparent"
+	b3Header          = "b3"
+	b3TraceIDHeader   = "X-B3-TraceId"
+	b3SpanIDHeader    = "X-B3-SpanId"
+	b3SampledHeader   = "X-B3-Sampled"
+)
+
+// checkPropagation check the propagation format, w3c is used if empty
+func checkPropagation(format string) (string, error) {
+	switch format {
+	case "":
+		return PropagationW3C, nil
+	case PropagationW3C, PropagationB3, PropagationB3Multi:
+		return format, nil
+	default:
+		return "", errors.Errorf("unsupported propagation %s", format)
+	}
+}
+
+// injectTraceHeaders write the span context into the request headers in the format expected by upstream
+func injectTraceHeaders(format string, sc trace.SpanContext, header http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	sampled := "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+	switch format {
+	case PropagationB3:
+		header.Set(b3Header, fmt.Sprintf("%s-%s-%s", sc.TraceID(), sc.SpanID(), sampled))
+	case PropagationB3Multi:
+		header.Set(b3TraceIDHeader, sc.TraceID().String())
+		header.Set(b3SpanIDHeader, sc.SpanID().String())
+		header.Set(b3SampledHeader, sampled)
+	default:
+		header.Set(traceparentHeader, fmt.Sprintf("00-%s-%s-0%s", sc.TraceID(), sc.SpanID(), sampled))
+	}
+}
+
+// extractTraceHeaders read the remote span context in the format, ok is false if the headers are absent or malformed
+func extractTraceHeaders(format string, header http.Header) (trace.SpanContext, bool) {
+	var tid, sid, sampled string
+	switch format {
+	case PropagationB3:
+		parts := strings.Split(header.Get(b3Header), "-")
+		if len(parts) < 2 {
+			return trace.SpanContext{}, false
+		}
+		tid, sid = parts[0], parts[1]
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+	case PropagationB3Multi:
+		tid, sid, sampled = header.Get(b3TraceIDHeader), header.Get(b3SpanIDHeader), header.Get(b3SampledHeader)
+	default:
+		parts := strings.Split(header.Get(traceparentHeader), "-")
+		if len(parts) != 4 {
+			return trace.SpanContext{}, false
+		}
+		tid, sid = parts[1], parts[2]
+		if parts[3] == "01" {
+			sampled = "1"
+		}
+	}
+
+	// the 64 bits b3 trace id is left padded
+	if len(tid) == 16 {
+		tid = strings.Repeat("0", 16) + tid
+	}
+	traceID, err := trace.TraceIDFromHex(tid)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(sid)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	cfg := trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, Remote: true}
+	if sampled == "1" || sampled == "d" || sampled == "true" {
+		cfg.TraceFlags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(cfg), true
+}
+
+// extractTraceCtx extract the remote span context in the format from request
+func extractTraceCtx(format string, req *http.Request) context.Context {
+	if sc, ok := extractTraceHeaders(format, req.Header); ok {
+		return trace.ContextWithRemoteSpanContext(req.Context(), sc)
+	}
+	return extractTraceCtxRequest(req)
+}
@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+const (
+	incomingTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	incomingSpanID  = "00f067aa0ba902b7"
+)
+
+func TestPropagation(t *testing.T) {
+	otel.SetTracerProvider(tracesdk.NewTracerProvider())
+
+	tests := []struct {
+		format   string
+		incoming map[string]string
+		emitted  map[string]*regexp.Regexp
+	}{
+		{
+			format:   PropagationW3C,
+			incoming: map[string]string{"traceparent": "00-" + incomingTraceID + "-" + incomingSpanID + "-01"},
+			emitted:  map[string]*regexp.Regexp{"traceparent": regexp.MustCompile("^00-" + incomingTraceID + "-[0-9a-f]{16}-01$")},
+		},
+		{
+			format:   PropagationB3,
+			incoming: map[string]string{"b3": incomingTraceID + "-" + incomingSpanID + "-1"},
+			emitted:  map[string]*regexp.Regexp{"b3": regexp.MustCompile("^" + incomingTraceID + "-[0-9a-f]{16}-1$")},
+		},
+		{
+			format: PropagationB3Multi,
+			incoming: map[string]string{
+				"X-B3-TraceId": "a3ce929d0e0e4736",
+				"X-B3-SpanId":  incomingSpanID,
+				"X-B3-Sampled": "1",
+			},
+			emitted: map[string]*regexp.Regexp{
+				"X-B3-TraceId": regexp.MustCompile("^0000000000000000a3ce929d0e0e4736$"),
+				"X-B3-SpanId":  regexp.MustCompile("^[0-9a-f]{16}$"),
+				"X-B3-Sampled": regexp.MustCompile("^1$"),
+			},
+		},
+		{
+			format:  PropagationB3,
+			emitted: map[string]*regexp.Regexp{"b3": regexp.MustCompile("^[0-9a-f]{32}-[0-9a-f]{16}-1$")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://www.dubbogopixiu.com/mock/test", nil)
+			assert.NoError(t, err)
+			for k, v := range tt.incoming {
+				request.Header.Set(k, v)
+			}
+			ctx := mock.GetMockHTTPContext(request)
+			f := &TraceFilterFilter{cfg: &TraceConfig{Propagation: tt.format}}
+			f.Decode(ctx)
+			f.Encode(ctx)
+
+			for k, re := range tt.emitted {
+				v := ctx.Request.Header.Get(k)
+				assert.Regexp(t, re, v, k)
+				assert.NotContains(t, v, "-"+incomingSpanID+"-", "the gateway span should be the parent of upstream")
+			}
+			if tt.format != PropagationW3C {
+				assert.Empty(t, ctx.Request.Header.Get("traceparent"))
+			}
+		})
+	}
+}
+
+func TestCheckPropagation(t *testing.T) {
+	format, err := checkPropagation("")
+	assert.NoError(t, err)
+	assert.Equal(t, PropagationW3C, format)
+
+	_, err = checkPropagation("zipkin")
+	assert.Error(t, err)
+}
@@ -19,12 +19,14 @@ package tracing
 
 import (
 	"context"
+	"io"
 	"log"
 	"net/http"
 )
 
 import (
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/jaeger"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
@@ -42,6 +44,7 @@ const (
 	TracingType_Jaeger    = "jaeger"
 	traceName             = "http-server"
 	jaegerTraceIDInHeader = "uber-trace-id"
+	requestIDAttribute    = "pixiu.request_id"
 )
 
 // nolint
@@ -65,6 +68,8 @@ type (
 	TraceConfig struct {
 		URL  string `yaml:"url" json:"url,omitempty"`
 		Type string `yaml:"type" json:"type,omitempty"`
+		// Propagation the trace header format of upstream, w3c, b3 or b3multi, w3c by default
+		Propagation string `yaml:"propagation" json:"propagation,omitempty"`
 	}
 )
 
@@ -83,6 +88,12 @@ func (m *TraceFilterFactory) Config() interface{} {
 func (m *TraceFilterFactory) Apply() error {
 	// init
 	tc := m.cfg
+	propagation, err := checkPropagation(tc.Propagation)
+	if err != nil {
+		return err
+	}
+	tc.Propagation = propagation
+
 	switch tc.Type {
 	case TracingType_Jaeger:
 		tp, err := newTracerProvider(tc.URL)
@@ -126,19 +137,29 @@ func newTracerProvider(url string) (*tracesdk.TracerProvider, error) {
 func (f *TraceFilterFilter) Decode(hc *contexthttp.HttpContext) filter.FilterStatus {
 	spanName := "HTTP " + hc.Request.Method
 	tr := otel.Tracer(traceName)
-	ctx := extractTraceCtxRequest(hc.Request)
+	ctx := extractTraceCtx(f.cfg.Propagation, hc.Request)
 	ctxWithTid, span := tr.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindServer))
 
 	hc.Request = hc.Request.WithContext(ctxWithTid)
+	// the upstream is the child of gateway span
+	injectTraceHeaders(f.cfg.Propagation, span.SpanContext(), hc.Request.Header)
 	f.span = span
 	return filter.Continue
 }
 
 func (f *TraceFilterFilter) Encode(hc *contexthttp.HttpContext) filter.FilterStatus {
+	if id := hc.GetRequestID(); id != "" {
+		f.span.SetAttributes(attribute.String(requestIDAttribute, id))
+	}
 	f.span.End()
 	return filter.Continue
 }
 
+// EncodeStream end the span once the streamed body is written
+func (f *TraceFilterFilter) EncodeStream(hc *contexthttp.HttpContext, w io.Writer) (io.WriteCloser, filter.FilterStatus) {
+	return filter.CloseFuncWriter(w, func() { f.Encode(hc) }), filter.Continue
+}
+
 func extractTraceCtxRequest(req *http.Request) context.Context {
 	tidHex := req.Header.Get(jaegerTraceIDInHeader)
 	if tidHex == "" {
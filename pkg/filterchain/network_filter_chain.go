@@ -82,27 +82,28 @@ func (fc *NetworkFilterChain) OnTripleData(ctx context.Context, methodName strin
 	return nil, errors.Errorf("filterChain don't have network filter")
 }
 
-// CreateNetworkFilterChain create network filter chain
-func CreateNetworkFilterChain(config model.FilterChain, bs *model.Bootstrap) *NetworkFilterChain {
+// CreateNetworkFilterChain create network filter chain, it fails if any filter fails to create,
+// so that the listener never serves with a partial chain
+func CreateNetworkFilterChain(config model.FilterChain, bs *model.Bootstrap) (*NetworkFilterChain, error) {
 	var filters []filter.NetworkFilter
 
 	for _, f := range config.Filters {
 		p, err := filter.GetNetworkFilterPlugin(f.Name)
 		if err != nil {
 			logger.Error("CreateNetworkFilterChain %s getNetworkFilterPlugin error %s", f.Name, err)
-			continue
+			return nil, errors.Wrapf(err, "network filter %s", f.Name)
 		}
 
 		config := p.Config()
 		if err := yaml.ParseConfig(config, f.Config); err != nil {
 			logger.Error("CreateNetworkFilterChain %s parse config error %s", f.Name, err)
-			continue
+			return nil, errors.Wrapf(err, "network filter %s", f.Name)
 		}
 
 		filter, err := p.CreateFilter(config, bs)
 		if err != nil {
 			logger.Error("CreateNetworkFilterChain %s createFilter error %s", f.Name, err)
-			continue
+			return nil, errors.Wrapf(err, "network filter %s", f.Name)
 		}
 		filters = append(filters, filter)
 	}
@@ -110,5 +111,5 @@ func CreateNetworkFilterChain(config model.FilterChain, bs *model.Bootstrap) *Ne
 	return &NetworkFilterChain{
 		filtersArray: filters,
 		config:       config,
-	}
+	}, nil
 }
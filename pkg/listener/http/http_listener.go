@@ -57,7 +57,10 @@ type (
 )
 
 func newHttpListenerService(lc *model.Listener, bs *model.Bootstrap) (listener.ListenerService, error) {
-	fc := filterchain.CreateNetworkFilterChain(lc.FilterChain, bs)
+	fc, err := filterchain.CreateNetworkFilterChain(lc.FilterChain, bs)
+	if err != nil {
+		return nil, err
+	}
 	return &HttpListenerService{
 		BaseListenerService: listener.BaseListenerService{
 			Config:      lc,
@@ -49,7 +49,7 @@ func CreateListenerService(lc *model.Listener, bs *model.Bootstrap) (ListenerSer
 	if registry, ok := factoryMap[lc.Protocol]; ok {
 		reg, err := registry(lc, bs)
 		if err != nil {
-			panic("Initialize ListenerService " + lc.Name + "failed due to: " + err.Error())
+			return nil, errors.Wrapf(err, "initialize listener service %s fail", lc.Name)
 		}
 		return reg, nil
 	}
@@ -51,7 +51,10 @@ func newTcpListenerService(lc *model.Listener, bs *model.Bootstrap) (listener.Li
 	// todo taskPoolMode
 	server := getty.NewTCPServer(serverOpts...)
 
-	fc := filterchain.CreateNetworkFilterChain(lc.FilterChain, bs)
+	fc, err := filterchain.CreateNetworkFilterChain(lc.FilterChain, bs)
+	if err != nil {
+		return nil, err
+	}
 	return &TcpListenerService{
 		BaseListenerService: listener.BaseListenerService{
 			Config:      lc,
@@ -56,7 +56,10 @@ type (
 
 func newTripleListenerService(lc *model.Listener, bs *model.Bootstrap) (listener.ListenerService, error) {
 
-	fc := filterchain.CreateNetworkFilterChain(lc.FilterChain, bs)
+	fc, err := filterchain.CreateNetworkFilterChain(lc.FilterChain, bs)
+	if err != nil {
+		return nil, err
+	}
 	ls := &TripleListenerService{
 		BaseListenerService: listener.BaseListenerService{
 			Config:      lc,
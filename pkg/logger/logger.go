@@ -42,6 +42,9 @@ type DubbogoPXLogger struct {
 	mutex sync.Mutex
 	Logger
 	dynamicLevel zap.AtomicLevel
+	config       zap.Config
+	// structured skip one more caller for the StructuredLogger methods
+	structured *zap.SugaredLogger
 	// disable presents the logger state. if disable is true, the logger will write nothing
 	// the default value is false
 	disable bool
@@ -60,6 +63,17 @@ type Logger interface {
 	Debugf(fmt string, args ...interface{})
 }
 
+// StructuredLogger is an optional interface of Logger, the key-value pairs are emitted as fields
+type StructuredLogger interface {
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	Debugw(msg string, keysAndValues ...interface{})
+}
+
+// FormatJSON the structured json log format, with level, time, message and the fields
+const FormatJSON = "json"
+
 func init() {
 	// only use in test case, so just load default config
 	if logger == nil {
@@ -118,7 +132,37 @@ func InitLogger(conf *zap.Config) {
 	}
 	zapLogger, _ := zapLoggerConfig.Build(zap.AddCallerSkip(1))
 	// logger = zapLogger.Sugar()
-	logger = &DubbogoPXLogger{Logger: zapLogger.Sugar(), dynamicLevel: zapLoggerConfig.Level}
+	logger = &DubbogoPXLogger{
+		Logger:       zapLogger.Sugar(),
+		dynamicLevel: zapLoggerConfig.Level,
+		config:       zapLoggerConfig,
+		structured:   zapLogger.WithOptions(zap.AddCallerSkip(1)).Sugar(),
+	}
+}
+
+// SetLoggerFormat switch the encoding of the global logger, json or console, the level is kept
+func SetLoggerFormat(format string) bool {
+	dpl, ok := logger.(*DubbogoPXLogger)
+	if !ok {
+		return false
+	}
+	conf := dpl.config
+	switch format {
+	case FormatJSON:
+		conf.Encoding = FormatJSON
+		// keep the same keys for the log pipelines, and the color codes break the json parsers
+		conf.EncoderConfig.TimeKey = "time"
+		conf.EncoderConfig.LevelKey = "level"
+		conf.EncoderConfig.MessageKey = "message"
+		conf.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		conf.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	case "", "console":
+		conf.Encoding = "console"
+	default:
+		return false
+	}
+	InitLogger(&conf)
+	return true
 }
 
 func SetLogger(log Logger) {
@@ -149,3 +193,23 @@ func (dpl *DubbogoPXLogger) SetLoggerLevel(level string) {
 	l.Set(level)
 	dpl.dynamicLevel.SetLevel(*l)
 }
+
+// Infow ...
+func (dpl *DubbogoPXLogger) Infow(msg string, keysAndValues ...interface{}) {
+	dpl.structured.Infow(msg, keysAndValues...)
+}
+
+// Warnw ...
+func (dpl *DubbogoPXLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	dpl.structured.Warnw(msg, keysAndValues...)
+}
+
+// Errorw ...
+func (dpl *DubbogoPXLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	dpl.structured.Errorw(msg, keysAndValues...)
+}
+
+// Debugw ...
+func (dpl *DubbogoPXLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	dpl.structured.Debugw(msg, keysAndValues...)
+}
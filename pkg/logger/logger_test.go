@@ -18,7 +18,11 @@
 package logger
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -26,6 +30,8 @@ import (
 
 import (
 	"github.com/stretchr/testify/assert"
+
+	"go.uber.org/zap"
 )
 
 func TestInitLog(t *testing.T) {
@@ -81,3 +87,35 @@ func TestSetLevel(t *testing.T) {
 	Debug("debug")
 	Info("info")
 }
+
+func TestJSONFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pixiu.log")
+
+	conf := zap.NewProductionConfig()
+	conf.OutputPaths = []string{path}
+	InitLogger(&conf)
+	defer InitLogger(nil)
+	assert.True(t, SetLoggerFormat(FormatJSON))
+	assert.False(t, SetLoggerFormat("xml"))
+
+	Errorw("apply filter init fail", "filter", "dgp.filter.http.demo")
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	assert.True(t, scanner.Scan())
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, "apply filter init fail", entry["message"])
+	assert.Equal(t, "dgp.filter.http.demo", entry["filter"])
+	assert.NotEmpty(t, entry["time"])
+}
+
+func TestWithFields(t *testing.T) {
+	assert.Equal(t, "msg filter=demo dangling", withFields("msg", []interface{}{"filter", "demo", "dangling"}))
+}
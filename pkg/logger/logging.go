@@ -17,6 +17,11 @@
 
 package logger
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Info
 func Info(args ...interface{}) {
 	logger.Info(args...)
@@ -56,3 +61,53 @@ func Errorf(fmt string, args ...interface{}) {
 func Debugf(fmt string, args ...interface{}) {
 	logger.Debugf(fmt, args...)
 }
+
+// Infow log the message with key-value fields
+func Infow(msg string, keysAndValues ...interface{}) {
+	if l, ok := logger.(StructuredLogger); ok {
+		l.Infow(msg, keysAndValues...)
+		return
+	}
+	logger.Info(withFields(msg, keysAndValues))
+}
+
+// Warnw log the message with key-value fields
+func Warnw(msg string, keysAndValues ...interface{}) {
+	if l, ok := logger.(StructuredLogger); ok {
+		l.Warnw(msg, keysAndValues...)
+		return
+	}
+	logger.Warn(withFields(msg, keysAndValues))
+}
+
+// Errorw log the message with key-value fields
+func Errorw(msg string, keysAndValues ...interface{}) {
+	if l, ok := logger.(StructuredLogger); ok {
+		l.Errorw(msg, keysAndValues...)
+		return
+	}
+	logger.Error(withFields(msg, keysAndValues))
+}
+
+// Debugw log the message with key-value fields
+func Debugw(msg string, keysAndValues ...interface{}) {
+	if l, ok := logger.(StructuredLogger); ok {
+		l.Debugw(msg, keysAndValues...)
+		return
+	}
+	logger.Debug(withFields(msg, keysAndValues))
+}
+
+// withFields append the key-value fields to message for the logger without structured support
+func withFields(msg string, keysAndValues []interface{}) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 < len(keysAndValues) {
+			fmt.Fprintf(&b, " %v=%v", keysAndValues[i], keysAndValues[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", keysAndValues[i])
+		}
+	}
+	return b.String()
+}
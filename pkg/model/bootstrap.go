@@ -24,6 +24,22 @@ type Bootstrap struct {
 	Metric           Metric            `yaml:"metric" json:"metric" mapstructure:"metric"`
 	Node             *Node             `yaml:"node" json:"node" mapstructure:"node"`
 	Trace            *TracerConfig     `yaml:"tracing" json:"tracing" mapstructure:"tracing"`
+	// PluginDir the dir of the go plugin .so files registering the custom filters, loaded at startup
+	PluginDir string `yaml:"plugin_dir" json:"plugin_dir" mapstructure:"plugin_dir"`
+	// Remote the remote source of the config, it is polled after startup and the changed clusters and listeners are applied
+	Remote *RemoteConfigSource `yaml:"remote_config" json:"remote_config" mapstructure:"remote_config"`
+}
+
+// RemoteConfigSource the remote source of the bootstrap config
+type RemoteConfigSource struct {
+	// Type http or etcd
+	Type string `yaml:"type" json:"type" mapstructure:"type"`
+	// Address the url of http source, or the comma separated endpoints of etcd
+	Address string `yaml:"address" json:"address" mapstructure:"address"`
+	// Key the etcd key of the config
+	Key string `yaml:"key" json:"key" mapstructure:"key"`
+	// Interval the poll interval, 30s by default
+	Interval string `default:"30s" yaml:"interval" json:"interval" mapstructure:"interval"`
 }
 
 // Node node info for dynamic identifier
@@ -17,6 +17,10 @@
 
 package model
 
+import (
+	"time"
+)
+
 const (
 	Static DiscoveryType = iota
 	StrictDNS
@@ -45,6 +49,13 @@ var (
 	}
 )
 
+const (
+	// ClusterProtocolDubbo the providers of the cluster serve dubbo
+	ClusterProtocolDubbo = "dubbo"
+	// ClusterProtocolTriple the providers of the cluster serve triple
+	ClusterProtocolTriple = "triple"
+)
+
 type (
 	// Cluster a single upstream cluster
 	Cluster struct {
@@ -55,6 +66,9 @@ type (
 		LbStr                LbPolicyType     `yaml:"lb_policy" json:"lb_policy"` // Lb the cluster select node used loadBalance policy
 		HealthChecks         []HealthCheck    `yaml:"health_checks" json:"health_checks"`
 		Endpoints            []*Endpoint      `yaml:"endpoints" json:"endpoints"`
+		IdleTimeoutStr       string           `yaml:"idle_timeout" json:"idle_timeout" mapstructure:"idle_timeout"` // IdleTimeoutStr how long an idle upstream connection is kept, e.g. 30s
+		HashKey              string           `yaml:"hash_key" json:"hash_key" mapstructure:"hash_key"`             // HashKey the key of ConsistentHashing, request_id by default or header:<name>
+		Protocol             string           `yaml:"protocol" json:"protocol" mapstructure:"protocol"`             // Protocol the rpc protocol of the providers, dubbo by default or triple
 		PrePickEndpointIndex int
 	}
 
@@ -84,3 +98,15 @@ type (
 		Metadata map[string]string `yaml:"meta" json:"meta"`                                                   // Metadata extra info such as label or other meta data
 	}
 )
+
+// IdleTimeout parse IdleTimeoutStr, zero means the default of the connection pool is used
+func (c *Cluster) IdleTimeout() time.Duration {
+	if c.IdleTimeoutStr == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(c.IdleTimeoutStr)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
@@ -17,6 +17,12 @@
 
 package model
 
+import (
+	"net"
+	stdHttp "net/http"
+	"strings"
+)
+
 import (
 	"github.com/mitchellh/mapstructure"
 )
@@ -29,9 +35,17 @@ import (
 type HttpConnectionManagerConfig struct {
 	RouteConfig       RouteConfiguration `yaml:"route_config" json:"route_config" mapstructure:"route_config"`
 	HTTPFilters       []*HTTPFilter      `yaml:"http_filters" json:"http_filters" mapstructure:"http_filters"`
+	HTTPFilterChains  []*HTTPFilterChain `yaml:"http_filter_chains" json:"http_filter_chains" mapstructure:"http_filter_chains"`
 	ServerName        string             `yaml:"server_name" json:"server_name" mapstructure:"server_name"`
 	IdleTimeoutStr    string             `yaml:"idle_timeout" json:"idle_timeout" mapstructure:"idle_timeout"`
 	GenerateRequestID bool               `yaml:"generate_request_id" json:"generate_request_id" mapstructure:"generate_request_id"`
+	// FilterConfigMode how the unknown keys of the filter configs are treated, lenient by default
+	FilterConfigMode string `yaml:"filter_config_mode" json:"filter_config_mode" mapstructure:"filter_config_mode"`
+	// FilterTimings trace the latency of each filter and reply it in the X-Pixiu-Filter-Timings header, for debugging only
+	FilterTimings bool `yaml:"filter_timings" json:"filter_timings" mapstructure:"filter_timings"`
+	// StreamThreshold the upstream responses larger than it in bytes or of unknown length are streamed through the
+	// encode filters when all of them support it, 0 means the responses are always buffered
+	StreamThreshold int64 `yaml:"stream_threshold" json:"stream_threshold" mapstructure:"stream_threshold"`
 }
 
 // GRPCConnectionManagerConfig
@@ -49,6 +63,73 @@ type DubboProxyConnectionManagerConfig struct {
 type HTTPFilter struct {
 	Name   string                 `yaml:"name" json:"name" mapstructure:"name"`
 	Config map[string]interface{} `yaml:"config" json:"config" mapstructure:"config"`
+	// OnPanic how the panic of the filter is handled, fail_closed by default
+	OnPanic string `yaml:"on_panic" json:"on_panic" mapstructure:"on_panic"`
+	// Match the filter only applies to the matched requests, nil means always apply
+	Match *HTTPFilterMatch `yaml:"match" json:"match,omitempty" mapstructure:"match"`
+	// ConfigMode overrides the filter config mode of the connection manager, strict or lenient
+	ConfigMode string `yaml:"config_mode" json:"config_mode,omitempty" mapstructure:"config_mode"`
+}
+
+// HTTPFilterMatch the predicate of the filter, all the non-empty conditions must be matched
+type HTTPFilterMatch struct {
+	Prefix  string           `yaml:"prefix" json:"prefix" mapstructure:"prefix"`
+	Methods []string         `yaml:"methods" json:"methods" mapstructure:"methods"`
+	Headers []*HeaderMatcher `yaml:"headers" json:"headers" mapstructure:"headers"`
+}
+
+const (
+	// FilterConfigStrict reject the filter config containing unknown keys
+	FilterConfigStrict = "strict"
+	// FilterConfigLenient ignore the unknown keys, so that the config written for newer version still loads
+	FilterConfigLenient = "lenient"
+)
+
+const (
+	// FilterPanicFailClosed abort the request with 500 when the filter panics
+	FilterPanicFailClosed = "fail_closed"
+	// FilterPanicFailOpen skip the panicking filter and continue the chain
+	FilterPanicFailOpen = "fail_open"
+)
+
+// HTTPFilterChain named http filter chain, used instead of the default http filters when the request matches
+type HTTPFilterChain struct {
+	Name        string               `yaml:"name" json:"name" mapstructure:"name"`
+	Match       HTTPFilterChainMatch `yaml:"match" json:"match" mapstructure:"match"`
+	HTTPFilters []*HTTPFilter        `yaml:"http_filters" json:"http_filters" mapstructure:"http_filters"`
+	// Overrides compose the filters of the chain from the default filters instead of HTTPFilters,
+	// the operations are applied in order
+	Overrides []*HTTPFilterOverride `yaml:"overrides" json:"overrides" mapstructure:"overrides"`
+}
+
+// HTTPFilterOverride an operation against the default filters, the filters are referred by name
+type HTTPFilterOverride struct {
+	// Op append, insert or remove
+	Op string `yaml:"op" json:"op" mapstructure:"op"`
+	// Name the filter to remove
+	Name string `yaml:"name" json:"name" mapstructure:"name"`
+	// Before insert the filter before the named one
+	Before string `yaml:"before" json:"before" mapstructure:"before"`
+	// After insert the filter after the named one
+	After string `yaml:"after" json:"after" mapstructure:"after"`
+	// Filter the filter to append or insert
+	Filter *HTTPFilter `yaml:"filter" json:"filter" mapstructure:"filter"`
+}
+
+const (
+	// FilterOverrideAppend append the filter after the default filters
+	FilterOverrideAppend = "append"
+	// FilterOverrideInsert insert the filter before or after the named default filter
+	FilterOverrideInsert = "insert"
+	// FilterOverrideRemove remove the named default filter
+	FilterOverrideRemove = "remove"
+)
+
+// HTTPFilterChainMatch match the request by host and path prefix, empty field matches any request
+type HTTPFilterChainMatch struct {
+	// Hosts the request hosts, "*.foo.com" matches any sub domain of foo.com
+	Hosts  []string `yaml:"hosts" json:"hosts" mapstructure:"hosts"`
+	Prefix string   `yaml:"prefix" json:"prefix" mapstructure:"prefix"`
 }
 
 // HTTPFilter http filter
@@ -112,3 +193,60 @@ func MapInStruct(cfg interface{}) *HttpConfig {
 	}
 	return hc
 }
+
+// Match check whether the request host and path match the filter chain
+// Compile compile the header matchers, it is called once when the filter is loaded
+func (m *HTTPFilterMatch) Compile() error {
+	for _, h := range m.Headers {
+		if err := h.Compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match check the request against the predicate
+func (m *HTTPFilterMatch) Match(r *stdHttp.Request) bool {
+	if m.Prefix != "" && !strings.HasPrefix(r.URL.Path, m.Prefix) {
+		return false
+	}
+	if len(m.Methods) > 0 {
+		matched := false
+		for _, method := range m.Methods {
+			if strings.EqualFold(method, r.Method) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, h := range m.Headers {
+		if !h.Match(r.Header) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *HTTPFilterChainMatch) Match(host, path string) bool {
+	if m.Prefix != "" && !strings.HasPrefix(path, m.Prefix) {
+		return false
+	}
+	if len(m.Hosts) == 0 {
+		return true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, pattern := range m.Hosts {
+		if pattern == host {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -24,10 +24,12 @@ const (
 	LoadBalancerRand             LbPolicyType = "Rand"
 	LoadBalancerRoundRobin       LbPolicyType = "RoundRobin"
 	LoadBalanceConsistentHashing LbPolicyType = "ConsistentHashing"
+	LoadBalancerLeastConnections LbPolicyType = "LeastConnections"
 )
 
 var LbPolicyTypeValue = map[string]LbPolicyType{
 	"Rand":              LoadBalancerRand,
 	"RoundRobin":        LoadBalancerRoundRobin,
 	"ConsistentHashing": LoadBalanceConsistentHashing,
+	"LeastConnections":  LoadBalancerLeastConnections,
 }
@@ -20,6 +20,8 @@ package model
 import (
 	stdHttp "net/http"
 	"regexp"
+	"strings"
+	"time"
 )
 
 import (
@@ -31,6 +33,20 @@ import (
 	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
 )
 
+const (
+	// RedirectModeFollow follow the upstream redirects
+	RedirectModeFollow = "follow"
+	// RedirectModePassthrough return the upstream redirect to client
+	RedirectModePassthrough = "passthrough"
+)
+
+const (
+	// ResponseViewFull render the whole dubbo result as json
+	ResponseViewFull = "full"
+	// ResponseViewFlat render the dubbo result with the nested keys joined by dot
+	ResponseViewFlat = "flat"
+)
+
 // Router struct
 type (
 	Router struct {
@@ -51,8 +67,79 @@ type (
 
 	// RouteAction match route should do
 	RouteAction struct {
-		Cluster                     string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
-		ClusterNotFoundResponseCode int    `yaml:"cluster_not_found_response_code" json:"cluster_not_found_response_code" mapstructure:"cluster_not_found_response_code"`
+		Cluster                     string          `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+		ClusterNotFoundResponseCode int             `yaml:"cluster_not_found_response_code" json:"cluster_not_found_response_code" mapstructure:"cluster_not_found_response_code"`
+		Redirect                    *RedirectPolicy `yaml:"redirect" json:"redirect,omitempty" mapstructure:"redirect"`
+		Deprecation                 *Deprecation    `yaml:"deprecation" json:"deprecation,omitempty" mapstructure:"deprecation"`
+		GeoRoutes                   []*GeoRoute     `yaml:"geo_routes" json:"geo_routes,omitempty" mapstructure:"geo_routes"`
+		// StatusMapping map the upstream status to the one sent to client, e.g. 201: 200
+		StatusMapping map[int]int `yaml:"status_mapping" json:"status_mapping,omitempty" mapstructure:"status_mapping"`
+		// ResponseView the default view of dubbo result, full or flat. The client can choose the view
+		// by the view query param or Accept header when it is set, empty means full and no negotiation
+		ResponseView string `yaml:"response_view" json:"response_view,omitempty" mapstructure:"response_view"`
+		// CacheTags the tags of the responses cached by cache filter, the entries can be invalidated by tag
+		CacheTags []string `yaml:"cache_tags" json:"cache_tags,omitempty" mapstructure:"cache_tags"`
+		// Mirror send a copy of the sampled requests to another cluster, taken effect by the mirror filter
+		Mirror *MirrorPolicy `yaml:"mirror" json:"mirror,omitempty" mapstructure:"mirror"`
+		// Stream write the upstream response to client as it arrives instead of buffering it, for SSE or NDJSON,
+		// the encode filters see the headers only
+		Stream *StreamPolicy `yaml:"stream" json:"stream,omitempty" mapstructure:"stream"`
+		// Pattern the method and path pattern matching the route, e.g. GET /api/v1/**, set when the route is added
+		Pattern string `yaml:"-" json:"-" mapstructure:"-"`
+	}
+
+	// StreamPolicy how the streamed response is flushed and kept alive
+	StreamPolicy struct {
+		// FlushInterval flush the buffered data periodically, e.g. 100ms, empty means flush on each write
+		FlushInterval string `yaml:"flush_interval" json:"flush_interval,omitempty" mapstructure:"flush_interval"`
+		// HeartbeatInterval send the heartbeat when the stream is idle for the interval, empty disables it
+		HeartbeatInterval string `yaml:"heartbeat_interval" json:"heartbeat_interval,omitempty" mapstructure:"heartbeat_interval"`
+		// Heartbeat the keepalive payload, a comment line ":\n" for text/event-stream by default,
+		// the other content types send no heartbeat unless it is set
+		Heartbeat string `yaml:"heartbeat" json:"heartbeat,omitempty" mapstructure:"heartbeat"`
+
+		flush     time.Duration
+		heartbeat time.Duration
+		compiled  bool
+	}
+
+	// MirrorPolicy the mirror of a route, the response of mirror never reaches the client
+	MirrorPolicy struct {
+		Cluster string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+		// Percent the percentage of the requests mirrored, 100 if not set
+		Percent float64 `yaml:"percent" json:"percent,omitempty" mapstructure:"percent"`
+		// Compare compare the response of mirror with the primary one, the diffs are logged and counted
+		Compare bool `yaml:"compare" json:"compare,omitempty" mapstructure:"compare"`
+	}
+
+	// GeoRoute route the requests from the countries or regions to the cluster, the location of
+	// client ip is resolved by the geoip filter, so the filter must be configured to take effect
+	GeoRoute struct {
+		// Countries the ISO 3166-1 country codes, e.g. DE
+		Countries []string `yaml:"countries" json:"countries" mapstructure:"countries"`
+		// Regions the ISO 3166-2 subdivision codes with or without the country prefix, e.g. BY or DE-BY
+		Regions []string `yaml:"regions" json:"regions" mapstructure:"regions"`
+		Cluster string   `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+	}
+
+	// Deprecation mark the route deprecated with Deprecation and Sunset headers, see RFC 8594
+	Deprecation struct {
+		// Date when the route is deprecated in HTTP-date format, "true" is sent if empty
+		Date string `yaml:"date" json:"date" mapstructure:"date"`
+		// Sunset when the route will become unavailable in HTTP-date format
+		Sunset string `yaml:"sunset" json:"sunset" mapstructure:"sunset"`
+		// Link the url describing the deprecation, sent as Link header with rel="deprecation"
+		Link string `yaml:"link" json:"link" mapstructure:"link"`
+		// Log log each usage of the route for migration tracking
+		Log bool `yaml:"log" json:"log" mapstructure:"log"`
+	}
+
+	// RedirectPolicy how to handle the 3xx response of upstream
+	RedirectPolicy struct {
+		// Mode follow or passthrough, follow by default
+		Mode string `yaml:"mode" json:"mode" mapstructure:"mode"`
+		// MaxRedirects the max redirects to follow in follow mode, 10 by default
+		MaxRedirects int `yaml:"max_redirects" json:"max_redirects" mapstructure:"max_redirects"`
 	}
 
 	// RouteConfiguration
@@ -71,6 +158,75 @@ type (
 	}
 )
 
+// Match check whether the location matches, the empty countries or regions match any
+func (g *GeoRoute) Match(country, region string) bool {
+	if len(g.Countries) == 0 && len(g.Regions) == 0 {
+		return false
+	}
+	if len(g.Countries) > 0 && !containsFold(g.Countries, country) {
+		return false
+	}
+	if len(g.Regions) > 0 && (region == "" || !(containsFold(g.Regions, region) || containsFold(g.Regions, country+"-"+region))) {
+		return false
+	}
+	return true
+}
+
+// Compile compile the regex values, it must be called before Match when Regex is true
+func (hm *HeaderMatcher) Compile() error {
+	if !hm.Regex {
+		return nil
+	}
+	if len(hm.Values) == 0 {
+		return errors.Errorf("header matcher %s has no regex", hm.Name)
+	}
+	exprs := make([]string, 0, len(hm.Values))
+	for _, v := range hm.Values {
+		exprs = append(exprs, "(?:"+v+")")
+	}
+	re, err := regexp.Compile(strings.Join(exprs, "|"))
+	if err != nil {
+		return errors.Wrapf(err, "header matcher %s regex invalid", hm.Name)
+	}
+	hm.valueRE = re
+	return nil
+}
+
+// Match check whether the header value equals any of Values, or matches any regex of Values,
+// the header is only required to be present when Values is empty
+func (hm *HeaderMatcher) Match(header stdHttp.Header) bool {
+	vs, ok := header[stdHttp.CanonicalHeaderKey(hm.Name)]
+	if !ok {
+		return false
+	}
+	if len(hm.Values) == 0 {
+		return true
+	}
+	for _, v := range vs {
+		if hm.Regex {
+			if hm.valueRE != nil && hm.valueRE.MatchString(v) {
+				return true
+			}
+			continue
+		}
+		for _, want := range hm.Values {
+			if v == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
+
 func (rc *RouteConfiguration) RouteByPathAndMethod(path, method string) (*RouteAction, error) {
 	if rc.RouteTrie.IsEmpty() {
 		return nil, errors.Errorf("router configuration is empty")
@@ -90,3 +246,38 @@ func (rc *RouteConfiguration) RouteByPathAndMethod(path, method string) (*RouteA
 func (rc *RouteConfiguration) Route(req *stdHttp.Request) (*RouteAction, error) {
 	return rc.RouteByPathAndMethod(req.URL.Path, req.Method)
 }
+
+// Compile parse the intervals of the stream policy, it is called once when the route is loaded
+func (p *StreamPolicy) Compile() error {
+	flush, heartbeat, err := p.parse()
+	if err != nil {
+		return err
+	}
+	p.flush, p.heartbeat, p.compiled = flush, heartbeat, true
+	return nil
+}
+
+// Intervals return the flush and heartbeat intervals, the policy not compiled is parsed on each call
+func (p *StreamPolicy) Intervals() (flush, heartbeat time.Duration, err error) {
+	if p.compiled {
+		return p.flush, p.heartbeat, nil
+	}
+	return p.parse()
+}
+
+func (p *StreamPolicy) parse() (flush, heartbeat time.Duration, err error) {
+	if p.FlushInterval != "" {
+		if flush, err = time.ParseDuration(p.FlushInterval); err != nil {
+			return 0, 0, errors.Wrap(err, "stream flush interval parse fail")
+		}
+	}
+	if p.HeartbeatInterval != "" {
+		if heartbeat, err = time.ParseDuration(p.HeartbeatInterval); err != nil {
+			return 0, 0, errors.Wrap(err, "stream heartbeat interval parse fail")
+		}
+	}
+	if flush < 0 || heartbeat < 0 {
+		return 0, 0, errors.New("stream interval must not be negative")
+	}
+	return flush, heartbeat, nil
+}
@@ -20,21 +20,62 @@ package pluginregistry
 import (
 	_ "github.com/apache/dubbo-go-pixiu/pkg/adapter/dubboregistry"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/adapter/springcloud"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/consistenthash"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/leastconn"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/rand"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/roundrobin"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/accesslog"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/apikey"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/basic"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/jwt"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/mtls"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/signature"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/webhook"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/authority"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/cors"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/csrf"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/header"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/host"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/admin"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/aggregate"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/apiconfig"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/cache"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/canary"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/concurrency"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/contenttype"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/delay"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/etag"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/fallback"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/fault"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/featureflag"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/geoip"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/grpcproxy"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/grpcweb"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/health"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/httpproxy"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/idempotency"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/jsoncase"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/jsonschema"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/loadbalancer"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/mirror"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/negotiate"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/nonce"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/protocol"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/proxyrewrite"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/queryparams"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/quota"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/remote"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/requestid"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/requestlimit"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/retryafter"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/slowlog"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/statusmap"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/stub"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/tenant"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/timeout"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/upload"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/waf"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/http/websocket"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/metric"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/network/dubboproxy"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/network/dubboproxy/filter/http"
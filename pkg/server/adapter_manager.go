@@ -17,6 +17,10 @@
 
 package server
 
+import (
+	"github.com/pkg/errors"
+)
+
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/adapter"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/yaml"
@@ -27,6 +31,8 @@ import (
 type AdapterManager struct {
 	configs  []*model.Adapter
 	adapters []adapter.Adapter
+	// err the first error initializing the adapters
+	err error
 }
 
 func CreateDefaultAdapterManager(server *Server, bs *model.Bootstrap) *AdapterManager {
@@ -41,6 +47,11 @@ func (am *AdapterManager) Start() {
 	}
 }
 
+// Ready return the error initializing the adapters, nil if all of them are applied
+func (am *AdapterManager) Ready() error {
+	return am.err
+}
+
 func (am *AdapterManager) Stop() {
 	for _, a := range am.adapters {
 		a.Stop()
@@ -55,23 +66,36 @@ func (am *AdapterManager) initAdapters() {
 		hp, err := adapter.GetAdapterPlugin(f.Name)
 		if err != nil {
 			logger.Error("initAdapters get plugin error %s", err)
+			am.fail(f.Name, err)
+			continue
 		}
 
 		hf, err := hp.CreateAdapter(f)
 		if err != nil {
 			logger.Error("initFilterIfNeed create adapter error %s", err)
+			am.fail(f.Name, err)
+			continue
 		}
 
 		cfg := hf.Config()
 		if err := yaml.ParseConfig(cfg, f.Config); err != nil {
 			logger.Error("initAdapters init config error %s", err)
+			am.fail(f.Name, err)
 		}
 
 		err = hf.Apply()
 		if err != nil {
 			logger.Error("initFilterIfNeed apply adapter error %s", err)
+			am.fail(f.Name, err)
 		}
 		ads = append(ads, hf)
 	}
 	am.adapters = ads
 }
+
+// fail keep the first error of the adapters
+func (am *AdapterManager) fail(name string, err error) {
+	if am.err == nil {
+		am.err = errors.Wrapf(err, "adapter %s", name)
+	}
+}
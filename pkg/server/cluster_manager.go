@@ -20,6 +20,7 @@ package server
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 import (
@@ -80,6 +81,14 @@ func (cm *ClusterManager) UpdateCluster(new *model.Cluster) {
 	cm.store.UpdateCluster(new)
 }
 
+// ReplaceClusters replace all the clusters at once, e.g. by the remote config
+func (cm *ClusterManager) ReplaceClusters(clusters []*model.Cluster) {
+	cm.rw.Lock()
+	defer cm.rw.Unlock()
+
+	cm.store = &ClusterStore{Config: clusters, Version: cm.store.Version + 1}
+}
+
 func (cm *ClusterManager) SetEndpoint(clusterName string, endpoint *model.Endpoint) {
 	cm.rw.Lock()
 	defer cm.rw.Unlock()
@@ -132,18 +141,66 @@ func (cm *ClusterManager) CompareAndSetStore(store *ClusterStore) bool {
 }
 
 func (cm *ClusterManager) PickEndpoint(clusterName string) *model.Endpoint {
+	return cm.PickEndpointWithHint(clusterName, loadbalancer.Hint{})
+}
+
+// PickEndpointWithHint pick the endpoint by the lb policy of the cluster, the policy like consistent hashing
+// picks by the request attributes of the hint
+func (cm *ClusterManager) PickEndpointWithHint(clusterName string, hint loadbalancer.Hint) *model.Endpoint {
+	cm.rw.RLock()
+	defer cm.rw.RUnlock()
+
+	for _, cluster := range cm.store.Config {
+		if cluster.Name == clusterName {
+			return pickOneEndpoint(cluster, hint)
+		}
+	}
+	return nil
+}
+
+// IdleTimeout return the idle connection timeout configured for the cluster, zero if not set
+func (cm *ClusterManager) IdleTimeout(clusterName string) time.Duration {
+	cm.rw.RLock()
+	defer cm.rw.RUnlock()
+
+	for _, cluster := range cm.store.Config {
+		if cluster.Name == clusterName {
+			return cluster.IdleTimeout()
+		}
+	}
+	return 0
+}
+
+// Protocol return the rpc protocol of the providers of the cluster, empty if the cluster not exists
+func (cm *ClusterManager) Protocol(clusterName string) string {
+	cm.rw.RLock()
+	defer cm.rw.RUnlock()
+
+	for _, cluster := range cm.store.Config {
+		if cluster.Name == clusterName {
+			if cluster.Protocol == "" {
+				return model.ClusterProtocolDubbo
+			}
+			return cluster.Protocol
+		}
+	}
+	return ""
+}
+
+// Endpoints return a copy of the endpoints of the cluster, nil if the cluster not exists
+func (cm *ClusterManager) Endpoints(clusterName string) []*model.Endpoint {
 	cm.rw.RLock()
 	defer cm.rw.RUnlock()
 
 	for _, cluster := range cm.store.Config {
 		if cluster.Name == clusterName {
-			return pickOneEndpoint(cluster)
+			return append([]*model.Endpoint(nil), cluster.Endpoints...)
 		}
 	}
 	return nil
 }
 
-func pickOneEndpoint(c *model.Cluster) *model.Endpoint {
+func pickOneEndpoint(c *model.Cluster, hint loadbalancer.Hint) *model.Endpoint {
 	if c.Endpoints == nil || len(c.Endpoints) == 0 {
 		return nil
 	}
@@ -154,7 +211,7 @@ func pickOneEndpoint(c *model.Cluster) *model.Endpoint {
 
 	loadBalancer, ok := loadbalancer.LoadBalancerStrategy[c.LbStr]
 	if ok {
-		return loadBalancer.Handler(c)
+		return loadbalancer.Pick(loadBalancer, c, hint)
 	}
 	return loadbalancer.LoadBalancerStrategy[model.LoadBalancerRand].Handler(c)
 }
@@ -21,6 +21,10 @@ import (
 	"runtime/debug"
 )
 
+import (
+	"github.com/pkg/errors"
+)
+
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/listener"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
@@ -38,16 +42,23 @@ type ListenerManager struct {
 	activeListener        []*model.Listener
 	bootstrap             *model.Bootstrap
 	activeListenerService []*wrapListenerService
+	// err the first error creating the listener services and their filters
+	err error
 }
 
 // CreateDefaultListenerManager create listener manager from config
 func CreateDefaultListenerManager(bs *model.Bootstrap) *ListenerManager {
 	sl := bs.GetStaticListeners()
 	var listeners []*wrapListenerService
+	var createErr error
 	for _, lsCof := range bs.StaticResources.Listeners {
 		ls, err := listener.CreateListenerService(lsCof, bs)
 		if err != nil {
 			logger.Error("CreateDefaultListenerManager %s error: %v", lsCof.Name, err)
+			if createErr == nil {
+				createErr = errors.Wrapf(err, "listener %s", lsCof.Name)
+			}
+			continue
 		}
 		listeners = append(listeners, &wrapListenerService{ls, lsCof})
 	}
@@ -56,9 +67,15 @@ func CreateDefaultListenerManager(bs *model.Bootstrap) *ListenerManager {
 		activeListener:        sl,
 		activeListenerService: listeners,
 		bootstrap:             bs,
+		err:                   createErr,
 	}
 }
 
+// Ready return the error creating the listener services, nil if all of them and their filters are created
+func (lm *ListenerManager) Ready() error {
+	return lm.err
+}
+
 func (lm *ListenerManager) AddOrUpdateListener(lsConf *model.Listener) error {
 	//todo add sync lock for concurrent using
 	if theListener := lm.getListener(lsConf.Name); theListener != nil {
@@ -18,6 +18,7 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"sync"
@@ -25,6 +26,7 @@ import (
 
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	"github.com/apache/dubbo-go-pixiu/pkg/config"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
@@ -45,9 +47,16 @@ type Server struct {
 	apiConfigManager      *ApiConfigManager
 	dynamicResourceManger DynamicResourceManager
 	traceDriverManager    *tracing.TraceDriverManager
+	remoteConfigPoller    *config.RemoteConfigPoller
 }
 
 func (s *Server) initialize(bs *model.Bootstrap) {
+	// the plugin filters are registered before the listeners load the filters
+	if bs.PluginDir != "" {
+		if _, err := filter.LoadPlugins(bs.PluginDir); err != nil {
+			logger.Errorf("[dubbopixiu go] load filter plugins fail: %v", err)
+		}
+	}
 	s.clusterManager = CreateDefaultClusterManager(bs)
 	s.routerManager = CreateDefaultRouterManager(s, bs)
 	s.apiConfigManager = CreateDefaultApiConfigManager(s, bs)
@@ -81,22 +90,21 @@ func (s *Server) GetTraceDriverManager() *tracing.TraceDriverManager {
 	return s.traceDriverManager
 }
 
-// Start server start
-func (s *Server) Start() {
+// Start server start, the error is returned when a subsystem fails to start and no listener is started
+func (s *Server) Start() (err error) {
 	conf := config.GetBootstrap()
 
-	s.startWG.Add(1)
-
 	defer func() {
 		if re := recover(); re != nil {
 			logger.Error(re)
-			// TODO stop
+			err = fmt.Errorf("start panic: %v", re)
 		}
 	}()
 
-	registerOtelMetricMeter(conf.Metric)
-	s.listenerManager.StartListen()
-	s.adapterManager.Start()
+	if err := s.startup(conf); err != nil {
+		return err
+	}
+	s.startWG.Add(1)
 
 	if conf.GetPprof().Enable {
 		addr := conf.GetPprof().Address.SocketAddress
@@ -109,6 +117,7 @@ func (s *Server) Start() {
 		go http.ListenAndServe(addr.Address+":"+strconv.Itoa(addr.Port), nil)
 		logger.Infof("[dubbopixiu go pprof] httpListener start by : %s", addr.Address+":"+strconv.Itoa(addr.Port))
 	}
+	return nil
 }
 
 // NewServer create server
@@ -123,7 +132,10 @@ func Start(bs *model.Bootstrap) {
 	// global variable
 	server = NewServer()
 	server.initialize(bs)
-	server.Start()
+	if err := server.Start(); err != nil {
+		logger.Errorf("[dubbopixiu go] start fail: %v", err)
+		return
+	}
 	server.startWG.Wait()
 }
 
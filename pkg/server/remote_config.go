@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/config"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// startRemoteConfig poll the remote config if it is configured, the first poll must succeed
+func (s *Server) startRemoteConfig(bs *model.Bootstrap) error {
+	if bs.Remote == nil {
+		return nil
+	}
+	interval, err := time.ParseDuration(bs.Remote.Interval)
+	if err != nil {
+		return errors.Wrapf(err, "invalid remote config interval %s", bs.Remote.Interval)
+	}
+	source, err := config.NewRemoteSource(bs.Remote)
+	if err != nil {
+		return err
+	}
+	poller := config.NewRemoteConfigPoller(source, interval, validateRemoteConfig, s.applyRemoteConfig)
+	if err := poller.Start(); err != nil {
+		return err
+	}
+	s.remoteConfigPoller = poller
+	return nil
+}
+
+func (s *Server) stopRemoteConfig() {
+	if s.remoteConfigPoller != nil {
+		s.remoteConfigPoller.Stop()
+	}
+}
+
+// validateRemoteConfig check the clusters and listeners of remote config are named
+func validateRemoteConfig(bs *model.Bootstrap) error {
+	for _, c := range bs.StaticResources.Clusters {
+		if c == nil || c.Name == "" {
+			return errors.New("the cluster name is required")
+		}
+	}
+	for _, l := range bs.StaticResources.Listeners {
+		if l == nil || l.Name == "" {
+			return errors.New("the listener name is required")
+		}
+	}
+	return nil
+}
+
+// applyRemoteConfig replace the clusters and start the new listeners, the running listeners are kept,
+// their changes take effect after restart
+func (s *Server) applyRemoteConfig(bs *model.Bootstrap) error {
+	s.clusterManager.ReplaceClusters(bs.StaticResources.Clusters)
+	for _, l := range bs.StaticResources.Listeners {
+		if s.listenerManager.getListener(l.Name) != nil {
+			logger.Debugf("[dubbopixiu go] remote config keep running listener %s", l.Name)
+			continue
+		}
+		if err := s.listenerManager.AddOrUpdateListener(l); err != nil {
+			return errors.Wrapf(err, "add listener %s", l.Name)
+		}
+	}
+	return nil
+}
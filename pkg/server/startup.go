@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// startupStep a subsystem started by the server
+type startupStep struct {
+	name  string
+	start func() error
+	// stop undo the started step when a later step fails, it can be nil
+	stop func()
+}
+
+// startup start the subsystems in order, the logger, config and clients are initialized before, the listeners
+// are started last, so that no traffic is accepted before the filters and the service discovery are ready
+func (s *Server) startup(conf *model.Bootstrap) error {
+	return runStartup([]startupStep{
+		{name: "metric", start: func() error {
+			registerOtelMetricMeter(conf.Metric)
+			return nil
+		}},
+		{name: "filters", start: s.listenerManager.Ready},
+		{name: "discovery", start: func() error {
+			if err := s.adapterManager.Ready(); err != nil {
+				return err
+			}
+			s.adapterManager.Start()
+			return nil
+		}, stop: s.adapterManager.Stop},
+		{name: "listeners", start: func() error {
+			s.listenerManager.StartListen()
+			return nil
+		}},
+		// the remote config is polled after the listeners are started, the new listeners are started when applied
+		{name: "remote config", start: func() error {
+			return s.startRemoteConfig(conf)
+		}, stop: s.stopRemoteConfig},
+	})
+}
+
+// runStartup run the steps in order, once a step fails the remaining steps are skipped
+// and the started ones are stopped in reverse order
+func runStartup(steps []startupStep) error {
+	for i, step := range steps {
+		logger.Infof("[dubbopixiu go] start %s", step.name)
+		if err := step.start(); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				if steps[j].stop != nil {
+					steps[j].stop()
+				}
+			}
+			return errors.Wrapf(err, "start %s fail", step.name)
+		}
+	}
+	return nil
+}
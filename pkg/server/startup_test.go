@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/adapter"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// startupRecorder record the order the subsystems are started
+type startupRecorder struct {
+	mu     sync.Mutex
+	events []string
+	bound  chan struct{}
+}
+
+func (r *startupRecorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *startupRecorder) Events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+type (
+	recordListener struct {
+		r *startupRecorder
+	}
+
+	recordAdapter struct {
+		DemoAdapter
+		r *startupRecorder
+	}
+)
+
+func (l *recordListener) Start() error {
+	l.r.record("listener bound")
+	close(l.r.bound)
+	return nil
+}
+
+func (a *recordAdapter) Start() {
+	a.r.record("discovery started")
+}
+
+func (a *recordAdapter) Stop() {
+	a.r.record("discovery stopped")
+}
+
+func newRecordServer(r *startupRecorder, adapterErr, listenerErr error) *Server {
+	return &Server{
+		listenerManager: &ListenerManager{
+			activeListenerService: []*wrapListenerService{{ListenerService: &recordListener{r: r}, cfg: &model.Listener{Name: "mock"}}},
+			err:                   listenerErr,
+		},
+		adapterManager: &AdapterManager{adapters: []adapter.Adapter{&recordAdapter{r: r}}, err: adapterErr},
+	}
+}
+
+func TestStartupOrder(t *testing.T) {
+	r := &startupRecorder{bound: make(chan struct{})}
+	s := newRecordServer(r, nil, nil)
+	assert.Nil(t, s.startup(&model.Bootstrap{}))
+
+	select {
+	case <-r.bound:
+	case <-time.After(time.Second):
+		t.Fatal("listener is not started")
+	}
+	assert.Equal(t, []string{"discovery started", "listener bound"}, r.Events())
+}
+
+func TestStartupFailure(t *testing.T) {
+	// the filters of listener fail, the discovery and listeners are not started
+	r := &startupRecorder{bound: make(chan struct{})}
+	s := newRecordServer(r, nil, errors.New("mock filter fail"))
+	err := s.startup(&model.Bootstrap{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "start filters fail")
+
+	// the discovery fails, the listeners are not started
+	r = &startupRecorder{bound: make(chan struct{})}
+	s = newRecordServer(r, errors.New("mock adapter fail"), nil)
+	err = s.startup(&model.Bootstrap{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "start discovery fail")
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, r.Events())
+}
+
+func TestRunStartupStopStarted(t *testing.T) {
+	var events []string
+	err := runStartup([]startupStep{
+		{name: "a", start: func() error { events = append(events, "start a"); return nil }, stop: func() { events = append(events, "stop a") }},
+		{name: "b", start: func() error { events = append(events, "start b"); return nil }, stop: func() { events = append(events, "stop b") }},
+		{name: "c", start: func() error { return errors.New("mock fail") }},
+		{name: "d", start: func() error { events = append(events, "start d"); return nil }},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"start a", "start b", "stop b", "stop a"}, events)
+}
+
+func TestApplyRemoteConfig(t *testing.T) {
+	s := &Server{
+		clusterManager:  CreateDefaultClusterManager(&model.Bootstrap{StaticResources: model.StaticResources{Clusters: []*model.Cluster{{Name: "cluster_v1"}}}}),
+		listenerManager: &ListenerManager{activeListener: []*model.Listener{{Name: "mock"}}},
+	}
+	bs := &model.Bootstrap{StaticResources: model.StaticResources{
+		Clusters:  []*model.Cluster{{Name: "cluster_v2"}},
+		Listeners: []*model.Listener{{Name: "mock"}},
+	}}
+	assert.Nil(t, validateRemoteConfig(bs))
+	assert.Nil(t, s.applyRemoteConfig(bs))
+	assert.False(t, s.clusterManager.HasCluster("cluster_v1"))
+	assert.True(t, s.clusterManager.HasCluster("cluster_v2"))
+
+	bs.StaticResources.Clusters = append(bs.StaticResources.Clusters, &model.Cluster{})
+	assert.Error(t, validateRemoteConfig(bs))
+
+	// the remote config is not configured
+	assert.Nil(t, s.startRemoteConfig(&model.Bootstrap{}))
+	assert.Error(t, s.startRemoteConfig(&model.Bootstrap{Remote: &model.RemoteConfigSource{Type: "zookeeper", Interval: "1s"}}))
+}